@@ -1,10 +1,14 @@
 package cmd
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -13,7 +17,9 @@ import (
 	"github.com/charmbracelet/glamour"
 	"github.com/spf13/cobra"
 
+	"github.com/pable/go-cs-metrics/internal/aggregator"
 	"github.com/pable/go-cs-metrics/internal/model"
+	"github.com/pable/go-cs-metrics/internal/report"
 	"github.com/pable/go-cs-metrics/internal/storage"
 )
 
@@ -31,7 +37,10 @@ Rules:
 Metrics glossary:
 - ADR: Avg Damage per Round. Typical range 60–90. <60 is low.
 - KAST%: % rounds with Kill/Assist/Survival/Trade. Good: >70%.
+- kast_strict_pct: KAST% without survived lost rounds where the player dealt no damage (saves), as some stats sites count it; null for data parsed before it was recorded. A wide gap to kast_pct = KAST propped up by saving.
 - K/D: Kills ÷ deaths. 1.0 is break-even.
+- adr_buy / adr_eco: ADR in rounds the enemy forced or bought vs. saved (full or semi eco); pistol rounds in neither, null without such rounds. adr_eco far above adr_buy = damage padded on save rounds, so judge output by adr_buy.
+- head_hit_pct: % of your bullet hits on enemies that landed on the head (every hit, not just kills; null when none recorded). High head_hit_pct with low hs_pct = a finishing problem (head hits that body shots finish), not an aim problem.
 - TTK (ms): Your first shot to kill, multi-hit kills only. Lower = faster finishing.
 - TTD (ms): Enemy's first shot to your death, multi-hit only. Higher = harder to kill.
 - One-tap kills: kills where one bullet was enough; shown as % of total kills.
@@ -42,18 +51,36 @@ Metrics glossary:
 - Effective flashes: blinded enemy died to your team within 1.5s of your flash.
 - AWP dry peek: you died to AWP while initiating the peek (not pre-aimed).
 - AWP repeek: died to AWP when enemy re-peeked your position.
+- awp_deaths.rounds_faced: rounds where an enemy used an AWP; judge AWP deaths by total/rounds_faced, not the raw count.
+- consistency: spread of per-match values — *_sd = standard deviation, *_iqr = interquartile range (lower = steadier); boom/bust matches = rated ≥1.30 / ≤0.70, boom_bust_pct = share of matches that were either. Null with fewer than 2 matches.
+- utility_synergy: kills you got off a teammate's utility — within 2s of their flash blinding the victim, or through their smoke; off_utility_pct = share of your kills. Contrast with flash assists/effective flashes (utility you threw for others).
+- utility_timing: grenades you threw by round phase — early = within 25s of freeze end (setup), late = after the plant or with ≤20s on the round timer, mid = in between; setup_pct = early share of all throws, avg_median_sec = typical seconds after freeze end of a throw. Low setup_pct with a high mid count = reactive utility; compare with the team's setup before reading it as a flaw.
+- late_round: late_deaths = deaths with ≤20s on the round/bomb clock; play_for_time_deaths/play_for_time_rounds = how often you died when your side was up in players with the clock on your side (CT pre-plant, T post-plant) — a discipline metric, lower is better.
+- momentum: streak_* = your output in rounds after your team won ≥3 straight, bounce_* = after it lost ≥3 straight (runs reset each half); compare streak_adr/bounce_adr to overall ADR — bounce-back rounds are where a player steadies a slump. half_first_kills = halves opened by your kill.
 - 1vN clutch W/A: won/attempted clutch situations when last alive vs N enemies.
 - FHHS: first-hit headshot rate — % of winning duels where the first bullet hit the head.
   confidence tags: high=30+ duels, medium=10–29, low=<10 (treat low with caution).
-- buy_profile: your avg kills/damage/KAST split by round economy (full/force/half/eco).`
+- buy_profile: your avg kills/damage/KAST split by round economy (full/force/half/eco).
+- fhhs_by_round_context: FHHS pooled per round context — pistol (first round of a half), anti-eco (your force/full buy vs an eco), gun (everything else) — overall and by weapon. Judge aim on gun-round rifle FHHS; pistol and anti-eco numbers are not comparable to it.
+- opening_duels (match): first kill of each round between opponents — winner, loser, weapon, and whether the winner's side won the round; opening_matchups counts the same duels per winner/loser pair, most frequent first.
+- positions: where you set up 10–20s into rounds, per map and side — label = callouts held in ≥25% of rounds ("mixed" if none), places = top callouts with share_pct ("grid x,y" when the demo has no callouts). Use it to ground positional advice in the spots you actually play.
+- died_to: your deaths by the killer's weapon bucket and distance (share_pct of all deaths) — use it for positioning advice (e.g. which ranges to avoid against AWPs).
+- death_context: what you were doing in the 3s before your deaths — moving/holding (speed at the killer's first hit), flashed, isolated (no teammate within 512 units), after_kill (you had just killed someone) as % of deaths; top_contexts = most frequent combinations. High isolated-while-moving means dying alone on the move (over-rotating, solo peeks).
+- duel_loss_reasons: factors behind your lost duels as % of duel losses — flashed (blinded in the 1.5s before dying), isolated (no teammate within 512 units), util_support (the killer played off a teammate's flash or smoke), repeek (you had already killed someone that round); factors overlap, none_pct = losses with none of them (straight aim duels). Use it to answer "why do I lose duels".
+- info_deaths: your deaths to an enemy split by whether anyone on your team had spotted the killer that round before the kill — unspotted = information failure (killer never on the radar: unchecked angle, flank, lurk), spotted = lost to a known threat; info_death_pct = unspotted share (null without deaths). A high share points to utility, comms and angle clearing rather than aim.
+- ping_ms / high_ping (trend, match players): mean scoreboard ping in that match; high_ping = mean ≥100 ms. Matches played on a bad connection are listed in low_confidence — attribute a dip in those matches to lag before form. Packet loss is not recorded.`
 
 var (
-	analyzeModel  string
-	analyzeAPIKey string
-
-	analyzePlayerMap   string
-	analyzePlayerSince string
-	analyzePlayerLast  int
+	analyzeModel       string
+	analyzeAPIKey      string
+	analyzeDumpContext bool
+
+	analyzePlayerMap       string
+	analyzePlayerSince     string
+	analyzePlayerLast      int
+	analyzePlayerQuestions string
+	analyzePlayerOut       string
+	analyzeNoCache         bool
 )
 
 var analyzeCmd = &cobra.Command{
@@ -62,51 +89,148 @@ var analyzeCmd = &cobra.Command{
 }
 
 var analyzePlayerCmd = &cobra.Command{
-	Use:   "player <steamid64> <question>",
+	Use:   "player <steamid64> <question> [question...]",
 	Short: "Analyze a player's aggregate stats with AI",
-	Args:  cobra.ExactArgs(2),
-	RunE:  runAnalyzePlayer,
+	Long: `Analyze a player's aggregate stats with AI.
+
+Several questions can be asked at once, as extra arguments and/or one per line
+in a --questions file (blank lines and lines starting with # are skipped). The
+data context is built from the database once and sent with each question;
+the answers are collected into one markdown report, rendered in the terminal
+or written to --out.
+
+Examples:
+  csmetrics analyze player 76561198012345678 "Why is my T side weaker?"
+  csmetrics analyze player 76561198012345678 "What should I practice?" "How do I die to AWPs?"
+  csmetrics analyze player 76561198012345678 --questions review.txt --out review.md`,
+	Args: analyzePlayerArgs,
+	RunE: runAnalyzePlayer,
 }
 
 var analyzeMatchCmd = &cobra.Command{
 	Use:   "match <hash-prefix> <question>",
 	Short: "Analyze a single match with AI",
-	Args:  cobra.ExactArgs(2),
+	Args:  analyzeArgs,
 	RunE:  runAnalyzeMatch,
 }
 
 func init() {
 	analyzeCmd.PersistentFlags().StringVar(&analyzeModel, "model", "claude-haiku-4-5-20251001", "Anthropic model to use")
 	analyzeCmd.PersistentFlags().StringVar(&analyzeAPIKey, "api-key", "", "Anthropic API key (falls back to $ANTHROPIC_API_KEY)")
+	analyzeCmd.PersistentFlags().BoolVar(&analyzeDumpContext, "dump-context", false, "print the JSON data context that would be sent to the model and exit (no API call; question optional)")
 
 	analyzePlayerCmd.Flags().StringVar(&analyzePlayerMap, "map", "", "filter to a specific map (e.g. nuke, de_nuke)")
 	analyzePlayerCmd.Flags().StringVar(&analyzePlayerSince, "since", "", "filter to matches on or after this date (YYYY-MM-DD)")
 	analyzePlayerCmd.Flags().IntVar(&analyzePlayerLast, "last", 0, "only use the N most recent matches")
+	analyzePlayerCmd.Flags().StringVar(&analyzePlayerQuestions, "questions", "", "file with one question per line, asked after any question arguments")
+	analyzePlayerCmd.Flags().StringVar(&analyzePlayerOut, "out", "", "write the answers as one markdown report to this file instead of rendering them")
+	analyzePlayerCmd.Flags().BoolVar(&analyzeNoCache, "no-cache", false, "rebuild the data context from the stored matches without reading or writing the aggregate cache")
 
 	analyzeCmd.AddCommand(analyzePlayerCmd)
 	analyzeCmd.AddCommand(analyzeMatchCmd)
 }
 
-func runAnalyzePlayer(cmd *cobra.Command, args []string) error {
-	id, err := strconv.ParseUint(args[0], 10, 64)
+// analyzeArgs requires the subject and the question; the question may be
+// omitted with --dump-context, which never calls the model.
+func analyzeArgs(cmd *cobra.Command, args []string) error {
+	if analyzeDumpContext {
+		return cobra.RangeArgs(1, 2)(cmd, args)
+	}
+	return cobra.ExactArgs(2)(cmd, args)
+}
+
+// analyzePlayerArgs requires the SteamID; questions may come from extra
+// arguments or --questions, and are checked once the file is read.
+func analyzePlayerArgs(cmd *cobra.Command, args []string) error {
+	return cobra.MinimumNArgs(1)(cmd, args)
+}
+
+// analyzeQuestions returns the question arguments followed by the non-empty,
+// non-comment lines of file (when set).
+func analyzeQuestions(args []string, file string) ([]string, error) {
+	questions := append([]string(nil), args...)
+	if file == "" {
+		return questions, nil
+	}
+	f, err := os.Open(file)
 	if err != nil {
-		return fmt.Errorf("invalid SteamID64 %q: %w", args[0], err)
+		return nil, fmt.Errorf("open questions: %w", err)
 	}
-	question := args[1]
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		questions = append(questions, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read questions: %w", err)
+	}
+	return questions, nil
+}
 
-	db, err := storage.Open(dbPath)
+// dumpContext prints the data context indented for reading. The content is
+// the same document callAnthropic sends; only whitespace differs.
+func dumpContext(contextJSON string) error {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(contextJSON), "", "  "); err != nil {
+		return fmt.Errorf("indent context: %w", err)
+	}
+	buf.WriteByte('\n')
+	_, err := buf.WriteTo(os.Stdout)
+	return err
+}
+
+// analyzePlayerContext is the analyze player data context with the player's
+// name and match count for the report title. It is what
+// player_aggregate_cache stores for analyze player.
+type analyzePlayerContext struct {
+	Name    string
+	Matches int
+	JSON    string
+}
+
+// loadPlayerContext returns the analyze player data context from
+// player_aggregate_cache when an entry for the same filters and pipeline
+// version exists, and builds and caches it otherwise.
+func loadPlayerContext(db *storage.DB, id uint64) (analyzePlayerContext, error) {
+	key := aggregateCacheKey("analyze player", strings.TrimPrefix(strings.ToLower(analyzePlayerMap), "de_"),
+		analyzePlayerSince, strconv.Itoa(analyzePlayerLast))
+	var pc analyzePlayerContext
+	if !analyzeNoCache {
+		ok, err := loadAggregateCache(db, id, key, &pc)
+		if err != nil {
+			return pc, err
+		}
+		if ok {
+			return pc, nil
+		}
+	}
+	pc, err := buildPlayerContextFromDB(db, id)
 	if err != nil {
-		return fmt.Errorf("open storage: %w", err)
+		return pc, err
 	}
-	defer db.Close()
+	if !analyzeNoCache {
+		if err := saveAggregateCache(db, id, key, pc); err != nil {
+			return pc, err
+		}
+	}
+	return pc, nil
+}
 
+// buildPlayerContextFromDB loads the player's matches under --map, --since
+// and --last with their per-demo detail rows and builds the data context.
+func buildPlayerContextFromDB(db *storage.DB, id uint64) (analyzePlayerContext, error) {
+	var pc analyzePlayerContext
 	stats, err := db.GetAllPlayerMatchStats(id)
 	if err != nil {
-		return fmt.Errorf("query stats: %w", err)
+		return pc, fmt.Errorf("query stats: %w", err)
 	}
 	stats = filterStats(stats, analyzePlayerMap, analyzePlayerSince, analyzePlayerLast)
 	if len(stats) == 0 {
-		return fmt.Errorf("no data found for SteamID64 %d (after filters)", id)
+		return pc, noDataError("no data found for SteamID64 %d (after filters)", id)
 	}
 
 	// Build a set of filtered demo hashes for downstream filtering.
@@ -116,12 +240,11 @@ func runAnalyzePlayer(cmd *cobra.Command, args []string) error {
 	}
 
 	agg := buildAggregate(stats)
-	mapSideAggs := buildMapSideAggregates(stats)
 
 	// Duel segments — load all, filter to kept hashes, then merge.
 	allSegs, err := db.GetAllPlayerDuelSegments(id)
 	if err != nil {
-		return fmt.Errorf("query duel segments: %w", err)
+		return pc, fmt.Errorf("query duel segments: %w", err)
 	}
 	var filteredSegs []model.PlayerDuelSegment
 	for _, seg := range allSegs {
@@ -130,13 +253,31 @@ func runAnalyzePlayer(cmd *cobra.Command, args []string) error {
 		}
 	}
 	// mergedSegs is computed inside buildPlayerContext alongside the per-map breakdown.
+	mapSideAggs := buildMapSideAggregates(stats, filteredSegs)
+
+	deathSegs, err := db.GetAllPlayerDeathSegments(id)
+	if err != nil {
+		return pc, fmt.Errorf("query death segments: %w", err)
+	}
+	diedTo := mergeDeathSegments(id, deathSegs, keep)
+	deathCtxRows, err := db.GetAllPlayerDeathContexts(id)
+	if err != nil {
+		return pc, fmt.Errorf("query death contexts: %w", err)
+	}
+	deathCtx := mergeDeathContexts(id, deathCtxRows, keep)
+
+	positionRows, err := db.GetAllPlayerPositions(id)
+	if err != nil {
+		return pc, fmt.Errorf("query positions: %w", err)
+	}
+	positions := aggregator.PositionProfiles(keepPositions(positionRows, keep))
 
 	// Weapon stats — load per-demo and aggregate across filtered demos.
 	var allWeaponStats []model.PlayerWeaponStats
 	for _, s := range stats {
 		ws, err := db.GetPlayerWeaponStats(s.DemoHash)
 		if err != nil {
-			return fmt.Errorf("query weapon stats for %s: %w", s.DemoHash, err)
+			return pc, fmt.Errorf("query weapon stats for %s: %w", s.DemoHash, err)
 		}
 		for _, w := range ws {
 			if w.SteamID == id {
@@ -150,7 +291,7 @@ func runAnalyzePlayer(cmd *cobra.Command, args []string) error {
 	for _, s := range stats {
 		rs, err := db.GetPlayerRoundStats(s.DemoHash, id)
 		if err != nil {
-			return fmt.Errorf("query round stats for %s: %w", s.DemoHash, err)
+			return pc, fmt.Errorf("query round stats for %s: %w", s.DemoHash, err)
 		}
 		allRoundStats = append(allRoundStats, rs...)
 	}
@@ -158,7 +299,7 @@ func runAnalyzePlayer(cmd *cobra.Command, args []string) error {
 	// Aggregate clutch stats across filtered matches.
 	clutchByMatch, err := db.GetPlayerClutchStatsByMatch(id)
 	if err != nil {
-		return fmt.Errorf("query clutch: %w", err)
+		return pc, fmt.Errorf("query clutch: %w", err)
 	}
 	var aggClutch model.PlayerClutchMatchStats
 	aggClutch.SteamID = id
@@ -177,12 +318,59 @@ func runAnalyzePlayer(cmd *cobra.Command, args []string) error {
 		"since": analyzePlayerSince,
 		"last":  analyzePlayerLast,
 	}
-	contextJSON, err := buildPlayerContext(agg, mapSideAggs, &aggClutch, filters, stats, filteredSegs, allWeaponStats, allRoundStats)
+	contextJSON, err := buildPlayerContext(agg, mapSideAggs, &aggClutch, filters, stats, filteredSegs, diedTo, deathCtx, positions, allWeaponStats, allRoundStats)
 	if err != nil {
-		return fmt.Errorf("build context: %w", err)
+		return pc, fmt.Errorf("build context: %w", err)
+	}
+	return analyzePlayerContext{Name: agg.Name, Matches: len(stats), JSON: contextJSON}, nil
+}
+
+func runAnalyzePlayer(cmd *cobra.Command, args []string) error {
+	id, err := resolveSteamID(args[0])
+	if err != nil {
+		return err
+	}
+	questions, err := analyzeQuestions(args[1:], analyzePlayerQuestions)
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+	if len(questions) == 0 && !analyzeDumpContext {
+		return withExitCode(ExitUsage, fmt.Errorf("no question: pass one or more questions or --questions <file>"))
 	}
 
-	return callAnthropic(cmd.Context(), analyzeAPIKey, analyzeModel, contextJSON, question)
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
+	}
+	defer db.Close()
+
+	pc, err := loadPlayerContext(db, id)
+	if err != nil {
+		return err
+	}
+	if analyzeDumpContext {
+		return dumpContext(pc.JSON)
+	}
+
+	if len(questions) == 1 && analyzePlayerOut == "" {
+		return callAnthropic(cmd.Context(), analyzeAPIKey, analyzeModel, pc.JSON, questions[0])
+	}
+	var filterParts []string
+	if analyzePlayerMap != "" {
+		filterParts = append(filterParts, "map "+analyzePlayerMap)
+	}
+	if analyzePlayerSince != "" {
+		filterParts = append(filterParts, "since "+analyzePlayerSince)
+	}
+	if analyzePlayerLast > 0 {
+		filterParts = append(filterParts, fmt.Sprintf("last %d", analyzePlayerLast))
+	}
+	subtitle := fmt.Sprintf("%d matches", pc.Matches)
+	if len(filterParts) > 0 {
+		subtitle += " (" + strings.Join(filterParts, ", ") + ")"
+	}
+	title := fmt.Sprintf("Analysis: %s (%d)", pc.Name, id)
+	return runAnalyzeBatch(cmd.Context(), title, subtitle, pc.JSON, questions, analyzePlayerOut)
 }
 
 func runAnalyzeMatch(cmd *cobra.Command, args []string) error {
@@ -196,7 +384,9 @@ func runAnalyzeMatch(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("find demo: %w", err)
 	}
-	question := args[1]
+	if demo == nil {
+		return noDataError("no demo found with hash prefix %q", args[0])
+	}
 
 	stats, err := db.GetPlayerMatchStats(demo.DemoHash)
 	if err != nil {
@@ -208,12 +398,58 @@ func runAnalyzeMatch(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("query clutch: %w", err)
 	}
 
-	contextJSON, err := buildMatchContext(demo, stats, clutch)
+	killStates, err := db.KillStates([]string{demo.DemoHash})
+	if err != nil {
+		return fmt.Errorf("query kill states: %w", err)
+	}
+
+	contextJSON, err := buildMatchContext(demo, stats, clutch, killStates)
 	if err != nil {
 		return fmt.Errorf("build context: %w", err)
 	}
+	if analyzeDumpContext {
+		return dumpContext(contextJSON)
+	}
+
+	return callAnthropic(cmd.Context(), analyzeAPIKey, analyzeModel, contextJSON, args[1])
+}
+
+// consistencyContext returns the match-to-match spread section, or nil when
+// fewer than two matches leave nothing to compare.
+func consistencyContext(c model.PlayerConsistency) map[string]interface{} {
+	if c.Matches < 2 {
+		return nil
+	}
+	return map[string]interface{}{
+		"rating_sd":     round2(c.RatingSD),
+		"rating_iqr":    round2(c.RatingIQR),
+		"adr_sd":        round2(c.ADRSD),
+		"adr_iqr":       round2(c.ADRIQR),
+		"kast_sd":       round2(c.KASTSD),
+		"kast_iqr":      round2(c.KASTIQR),
+		"boom_matches":  c.Booms,
+		"bust_matches":  c.Busts,
+		"boom_bust_pct": round2(c.BoomBustPct()),
+	}
+}
 
-	return callAnthropic(cmd.Context(), analyzeAPIKey, analyzeModel, contextJSON, question)
+// tiltContext summarises post-loss performance within sessions for the AI
+// context, or nil when no match followed a loss in the same session.
+func tiltContext(t model.PlayerTilt) map[string]interface{} {
+	if t.AfterLoss == 0 {
+		return nil
+	}
+	return map[string]interface{}{
+		"sessions":           len(t.Sessions),
+		"losing_streaks":     t.LossStreaks,
+		"baseline_rating":    round2(t.BaselineRating),
+		"after_loss_matches": t.AfterLoss,
+		"after_loss_rating":  round2(t.AfterLossRating),
+		"after_win_matches":  t.AfterWin,
+		"after_win_rating":   round2(t.AfterWinRating),
+		"post_loss_delta":    round2(t.PostLossDelta()),
+		"tilt":               t.Tilted(),
+	}
 }
 
 // buildPlayerContext serialises all available player data into compact JSON.
@@ -224,6 +460,9 @@ func buildPlayerContext(
 	filters map[string]interface{},
 	stats []model.PlayerMatchStats,
 	rawSegs []model.PlayerDuelSegment, // pre-merge, filtered to the active demo set
+	diedTo []model.PlayerDeathSegment, // merged across the active demo set
+	deathCtx []model.PlayerDeathContext, // merged across the active demo set
+	positions []model.PlayerPositionProfile,
 	weaponStats []model.PlayerWeaponStats,
 	roundStats []model.PlayerRoundStats,
 ) (string, error) {
@@ -240,6 +479,20 @@ func buildPlayerContext(
 		KD      float64 `json:"kd"`
 		ADR     float64 `json:"adr"`
 		KASTPct float64 `json:"kast_pct"`
+
+		DuelWins      int      `json:"duel_wins"`
+		DuelLosses    int      `json:"duel_losses"`
+		AvgExpoWinMs  *float64 `json:"avg_expo_win_ms"`  // nil when no duel won had a sighting
+		AvgExpoLossMs *float64 `json:"avg_expo_loss_ms"` // nil when no duel lost had a sighting
+		FirstHits     int      `json:"first_hits"`
+		FHHSPct       *float64 `json:"fhhs_pct"` // nil when no first hits
+	}
+	optional := func(v float64, ok bool) *float64 {
+		if !ok {
+			return nil
+		}
+		r := round2(v)
+		return &r
 	}
 	mapSide := make([]mapSideEntry, 0, len(mapSideAggs))
 	for _, ms := range mapSideAggs {
@@ -250,6 +503,13 @@ func buildPlayerContext(
 			KD:      round2(ms.KDRatio()),
 			ADR:     round2(ms.ADR()),
 			KASTPct: round2(ms.KASTPct()),
+
+			DuelWins:      ms.DuelWins,
+			DuelLosses:    ms.DuelLosses,
+			AvgExpoWinMs:  optional(ms.AvgExpoWinMs, ms.AvgExpoWinMs > 0),
+			AvgExpoLossMs: optional(ms.AvgExpoLossMs, ms.AvgExpoLossMs > 0),
+			FirstHits:     ms.FirstHitCount,
+			FHHSPct:       optional(ms.FHHSPct(), ms.FirstHitCount > 0),
 		})
 	}
 
@@ -282,18 +542,25 @@ func buildPlayerContext(
 		"matches_analyzed": agg.Matches,
 		"filters":          filters,
 		"overview": map[string]interface{}{
-			"role":       agg.Role,
-			"kd":         round2(agg.KDRatio()),
-			"hs_pct":     round2(agg.HSPercent()),
-			"adr":        round2(agg.ADR()),
-			"kast_pct":   round2(agg.KASTPct()),
-			"kills":      agg.Kills,
-			"assists":    agg.Assists,
-			"deaths":     agg.Deaths,
-			"rounds":     agg.RoundsPlayed,
-			"rounds_won": agg.RoundsWon,
-			"win_rate":   round2(float64(agg.RoundsWon) / float64(max(agg.RoundsPlayed, 1)) * 100),
+			"role":            agg.Role,
+			"kd":              round2(agg.KDRatio()),
+			"hs_pct":          round2(agg.HSPercent()),
+			"head_hit_pct":    headHitPct(agg.BulletHits, agg.HeadHits),
+			"adr":             round2(agg.ADR()),
+			"adr_buy":         roundADR(agg.BuyRounds, agg.BuyDamage),
+			"adr_eco":         roundADR(agg.EcoRounds, agg.EcoDamage),
+			"kast_pct":        round2(agg.KASTPct()),
+			"kast_strict_pct": kastStrictPct(agg.KASTStrictRounds, agg.RoundsPlayed),
+			"kills":           agg.Kills,
+			"assists":         agg.Assists,
+			"deaths":          agg.Deaths,
+			"rounds":          agg.RoundsPlayed,
+			"rounds_won":      agg.RoundsWon,
+			"win_rate":        round2(float64(agg.RoundsWon) / float64(max(agg.RoundsPlayed, 1)) * 100),
 		},
+		"consistency": consistencyContext(agg.Consistency),
+		// sessions = match dates; rating of the next match after a loss vs baseline
+		"tilt": tiltContext(aggregator.Tilt(stats)),
 		"opening": map[string]interface{}{
 			"kills":  agg.OpeningKills,
 			"deaths": agg.OpeningDeaths,
@@ -309,6 +576,10 @@ func buildPlayerContext(
 			"effective_flashes": agg.EffectiveFlashes,
 			"utility_damage":    sumUtilityDamage(stats),
 			"unused_utility":    sumUnusedUtility(stats),
+			// enemy utility absorbed (defensive): lower is better
+			"utility_damage_taken": agg.UtilityDamageTaken,
+			"flashes_received":     agg.FlashesReceived,
+			"blind_sec_received":   round2(agg.BlindTimeReceivedSec),
 		},
 		"aim": aimSection,
 		"awp_deaths": map[string]interface{}{
@@ -316,17 +587,91 @@ func buildPlayerContext(
 			"dry":      agg.AWPDeathsDry,
 			"repeek":   agg.AWPDeathsRePeek,
 			"isolated": agg.AWPDeathsIsolated,
+			// rounds with an enemy AWP; total/rounds_faced is the exposure-normalized rate
+			"rounds_faced": agg.AWPRoundsFaced,
+		},
+		// kills off a teammate's flash (≤2s) or through a teammate's smoke
+		"utility_synergy": map[string]interface{}{
+			"off_flash_kills":   agg.PlayedOffFlashKills,
+			"off_smoke_kills":   agg.PlayedOffSmokeKills,
+			"off_utility_kills": agg.PlayedOffUtilityKills,
+			"off_utility_pct":   round2(float64(agg.PlayedOffUtilityKills) / float64(max(agg.Kills, 1)) * 100),
+		},
+		// early = ≤25s after freeze end, late = post-plant or ≤20s on the round timer
+		"utility_timing": map[string]interface{}{
+			"throws":         agg.UtilThrows(),
+			"early":          agg.UtilThrowsEarly,
+			"mid":            agg.UtilThrowsMid,
+			"late":           agg.UtilThrowsLate,
+			"setup_pct":      round2(float64(agg.UtilThrowsEarly) / float64(max(agg.UtilThrows(), 1)) * 100),
+			"avg_median_sec": round2(agg.AvgUtilThrowSec),
+		},
+		// play-for-time = ≤20s on the clock, clock on the player's side, side up in players
+		"late_round": map[string]interface{}{
+			"late_deaths":          agg.LateRoundDeaths,
+			"play_for_time_rounds": agg.PlayForTimeRounds,
+			"play_for_time_deaths": agg.PlayForTimeDeaths,
 		},
-		"clutch":      clutchSummary(clutch),
-		"map_side":    mapSide,
-		"trend":       buildTrendContext(stats),
-		"fhhs":        buildFHHSContext(mergedSegs),
-		"fhhs_by_map": buildFHHSByMap(rawSegs, agg.SteamID, demoToMap),
-		"aim_by_map":  buildAimByMap(stats),
-		"weapons":     buildWeaponContext(weaponStats),
-		"buy_profile":  buildBuyProfile(roundStats),
-		"post_plant":   buildPostPlantProfile(roundStats),
-		"low_confidence": buildLowConfidence(agg, clutch, mergedSegs),
+		// streak = after ≥3 straight team round wins, bounce = after ≥3 straight losses, within a half
+		"momentum": map[string]interface{}{
+			"longest_win_streak": agg.LongestWinStreak,
+			"streak_rounds":      agg.StreakRounds,
+			"streak_kpr":         round2(float64(agg.StreakKills) / float64(max(agg.StreakRounds, 1))),
+			"streak_adr":         round2(float64(agg.StreakDamage) / float64(max(agg.StreakRounds, 1))),
+			"bounce_rounds":      agg.BounceRounds,
+			"bounce_kpr":         round2(float64(agg.BounceKills) / float64(max(agg.BounceRounds, 1))),
+			"bounce_adr":         round2(float64(agg.BounceDamage) / float64(max(agg.BounceRounds, 1))),
+			"bounce_win_rate":    round2(float64(agg.BounceWins) / float64(max(agg.BounceRounds, 1)) * 100),
+			"half_first_kills":   agg.HalfFirstKills,
+		},
+		// decided rounds by the team's match score at round start
+		"scoreline": map[string]interface{}{
+			"leading":  scorelineContext(agg.Leading),
+			"tied":     scorelineContext(agg.Tied),
+			"trailing": scorelineContext(agg.Trailing),
+		},
+		"loadout_efficiency": loadoutEfficiencyContext(agg),
+		"peeker_advantage": map[string]interface{}{
+			"peeks":        agg.PeekDuels,
+			"peek_win_pct": round2(float64(agg.PeekWins) / float64(max(agg.PeekDuels, 1)) * 100),
+			"holds":        agg.HoldDuels,
+			"hold_win_pct": round2(float64(agg.HoldWins) / float64(max(agg.HoldDuels, 1)) * 100),
+		},
+		// deaths with a primary carried but a pistol, knife or grenade in hand
+		"weapon_discipline": map[string]interface{}{
+			"primary_deaths":      agg.PrimaryDeaths,
+			"wrong_weapon_deaths": agg.WrongWeaponDeaths,
+			"wrong_weapon_pct":    round2(float64(agg.WrongWeaponDeaths) / float64(max(agg.PrimaryDeaths, 1)) * 100),
+		},
+		// assisted = a teammate dealt ≥41 damage to the victim in the 5s before the kill
+		"assisted_duels":    assistedDuelContext(agg),
+		"duel_loss_reasons": duelLossReasonContext(agg),
+		// deaths to a killer nobody on the team had spotted that round
+		"info_deaths": map[string]interface{}{
+			"unspotted":      agg.UnspottedDeaths,
+			"spotted":        agg.SpottedDeaths,
+			"info_death_pct": infoDeathPct(agg),
+		},
+		"awp_shots": map[string]interface{}{
+			"shots":     agg.AWPShots,
+			"kills":     agg.AWPShotKills,
+			"body_hits": agg.AWPShotBodyHits,
+			"misses":    agg.AWPShots - agg.AWPShotKills - agg.AWPShotBodyHits,
+		},
+		"clutch":                clutchSummary(clutch),
+		"map_side":              mapSide,
+		"trend":                 buildTrendContext(stats),
+		"fhhs":                  buildFHHSContext(mergedSegs),
+		"fhhs_by_map":           buildFHHSByMap(rawSegs, agg.SteamID, demoToMap),
+		"fhhs_by_round_context": buildFHHSByRoundContext(rawSegs),
+		"aim_by_map":            buildAimByMap(stats),
+		"died_to":               buildDiedToContext(diedTo),
+		"death_context":         buildDeathContext(deathCtx),
+		"positions":             buildPositionContext(positions),
+		"weapons":               buildWeaponContext(weaponStats),
+		"buy_profile":           buildBuyProfile(roundStats),
+		"post_plant":            buildPostPlantProfile(roundStats),
+		"low_confidence":        append(buildLowConfidence(agg, clutch, mergedSegs), connectionCaveats(stats)...),
 	}
 
 	b, err := json.Marshal(doc)
@@ -360,6 +705,10 @@ func buildTrendContext(stats []model.PlayerMatchStats) []map[string]interface{}
 		if s.CounterStrafePercent > 0 {
 			entry["cs_pct"] = round2(s.CounterStrafePercent)
 		}
+		if s.PingSamples > 0 {
+			entry["ping_ms"] = round2(s.AvgPingMs)
+			entry["high_ping"] = s.HighPing()
+		}
 		out = append(out, entry)
 	}
 	return out
@@ -393,15 +742,157 @@ func buildFHHSContext(segs []model.PlayerDuelSegment) []map[string]interface{} {
 		if seg.MedianCorrDeg > 0 {
 			entry["correction_deg"] = round2(seg.MedianCorrDeg)
 		}
+		if seg.StillFirstHits > 0 {
+			entry["still_first_hits"] = seg.StillFirstHits
+			entry["still_fhhs_pct"] = round2(float64(seg.StillFirstHitHS) / float64(seg.StillFirstHits) * 100)
+		}
+		if seg.MovingFirstHits > 0 {
+			entry["moving_first_hits"] = seg.MovingFirstHits
+			entry["moving_fhhs_pct"] = round2(float64(seg.MovingFirstHitHS) / float64(seg.MovingFirstHits) * 100)
+		}
 		out = append(out, entry)
 	}
 	return out
 }
 
+// buildDiedToContext lists what the player died to — deaths by enemy weapon
+// bucket and distance bin — most frequent first, with each segment's share of
+// those deaths, so positioning advice can target the matchups that kill them.
+func buildDiedToContext(segs []model.PlayerDeathSegment) []map[string]interface{} {
+	sorted := append([]model.PlayerDeathSegment(nil), segs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Deaths > sorted[j].Deaths })
+	total := 0
+	for _, s := range sorted {
+		total += s.Deaths
+	}
+	out := make([]map[string]interface{}, 0, len(sorted))
+	for _, s := range sorted {
+		out = append(out, map[string]interface{}{
+			"enemy_weapon": s.WeaponBucket,
+			"distance":     s.DistanceBin,
+			"deaths":       s.Deaths,
+			"share_pct":    round2(float64(s.Deaths) / float64(total) * 100),
+			"hs_pct":       round2(float64(s.HeadshotDeaths) / float64(s.Deaths) * 100),
+		})
+	}
+	return out
+}
+
+// buildDeathContext summarises what the player was doing before their deaths:
+// the share of deaths moving, holding, flashed, isolated and right after a
+// kill, and the most frequent combinations with their share.
+func buildDeathContext(rows []model.PlayerDeathContext) map[string]interface{} {
+	total := 0
+	flags := map[string]int{}
+	for _, r := range rows {
+		total += r.Deaths
+		flags[r.Movement] += r.Deaths
+		if r.Flashed {
+			flags["flashed"] += r.Deaths
+		}
+		if r.Isolated {
+			flags["isolated"] += r.Deaths
+		}
+		if r.AfterKill {
+			flags["after_kill"] += r.Deaths
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	sorted := append([]model.PlayerDeathContext(nil), rows...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Deaths > sorted[j].Deaths })
+	var top []map[string]interface{}
+	for i, r := range sorted {
+		if i == 3 {
+			break
+		}
+		top = append(top, map[string]interface{}{
+			"context":   r.Label(),
+			"deaths":    r.Deaths,
+			"share_pct": round2(float64(r.Deaths) / float64(total) * 100),
+		})
+	}
+	pct := func(k string) float64 { return round2(float64(flags[k]) / float64(total) * 100) }
+	return map[string]interface{}{
+		"deaths":         total,
+		"moving_pct":     pct(model.DeathMoving),
+		"holding_pct":    pct(model.DeathHolding),
+		"flashed_pct":    pct("flashed"),
+		"isolated_pct":   pct("isolated"),
+		"after_kill_pct": pct("after_kill"),
+		"top_contexts":   top,
+	}
+}
+
+// buildPositionContext lists the player's early-round positions per map and
+// side, with the top three places and their share of rounds.
+func buildPositionContext(profiles []model.PlayerPositionProfile) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(profiles))
+	for _, p := range profiles {
+		var places []map[string]interface{}
+		for i, pl := range p.Places {
+			if i == 3 {
+				break
+			}
+			places = append(places, map[string]interface{}{
+				"place":     pl.Place,
+				"share_pct": round2(float64(pl.Rounds) / float64(p.Rounds) * 100),
+			})
+		}
+		out = append(out, map[string]interface{}{
+			"map":    strings.TrimPrefix(p.MapName, "de_"),
+			"side":   p.Side.String(),
+			"rounds": p.Rounds,
+			"label":  p.Label,
+			"places": places,
+		})
+	}
+	return out
+}
+
+// headHitPct returns headHits as a percentage of hits, or nil without hits.
+func headHitPct(hits, headHits int) *float64 {
+	if hits == 0 {
+		return nil
+	}
+	v := round2(float64(headHits) / float64(hits) * 100)
+	return &v
+}
+
+// kastStrictPct returns the strict KAST percentage rounded to 2dp, or nil
+// when no strict KAST round is stored (data parsed before pipeline v46).
+func kastStrictPct(strictRounds, rounds int) *float64 {
+	if strictRounds == 0 || rounds == 0 {
+		return nil
+	}
+	v := round2(float64(strictRounds) / float64(rounds) * 100)
+	return &v
+}
+
+// infoDeathPct returns the share of deaths to unspotted enemies rounded to
+// 2dp, or nil without deaths to an enemy.
+func infoDeathPct(agg model.PlayerAggregate) *float64 {
+	if agg.UnspottedDeaths+agg.SpottedDeaths == 0 {
+		return nil
+	}
+	v := round2(agg.InfoDeathPct())
+	return &v
+}
+
+// roundADR returns damage per round rounded to 2dp, or nil without rounds.
+func roundADR(rounds, damage int) *float64 {
+	if rounds == 0 {
+		return nil
+	}
+	v := round2(float64(damage) / float64(rounds))
+	return &v
+}
+
 // buildWeaponContext aggregates weapon stats across all filtered matches.
 func buildWeaponContext(stats []model.PlayerWeaponStats) []map[string]interface{} {
 	type accum struct {
-		kills, hsKills, assists, deaths, damage, hits int
+		kills, hsKills, assists, deaths, damage, hits, headHits int
 	}
 	m := make(map[string]*accum)
 	for _, w := range stats {
@@ -415,6 +906,7 @@ func buildWeaponContext(stats []model.PlayerWeaponStats) []map[string]interface{
 		a.deaths += w.Deaths
 		a.damage += w.Damage
 		a.hits += w.Hits
+		a.headHits += w.HeadHits
 	}
 
 	// Sort by kills descending.
@@ -453,6 +945,7 @@ func buildWeaponContext(stats []model.PlayerWeaponStats) []map[string]interface{
 			"deaths":          e.a.deaths,
 			"damage":          e.a.damage,
 			"hits":            e.a.hits,
+			"head_hit_pct":    headHitPct(e.a.hits, e.a.headHits),
 			"avg_dmg_per_hit": avgDmg,
 		})
 	}
@@ -595,6 +1088,19 @@ func buildLowConfidence(agg model.PlayerAggregate, clutch *model.PlayerClutchMat
 	return warnings
 }
 
+// connectionCaveats returns one low-confidence line per match the player
+// averaged at least model.HighPingMs of ping in, oldest first.
+func connectionCaveats(stats []model.PlayerMatchStats) []string {
+	var out []string
+	for _, s := range stats {
+		if s.HighPing() {
+			out = append(out, fmt.Sprintf("connection: %s on %s averaged %.0f ms ping (peak %d ms) — stats from that match may reflect lag",
+				s.MatchDate, strings.TrimPrefix(s.MapName, "de_"), s.AvgPingMs, s.MaxPingMs))
+		}
+	}
+	return out
+}
+
 // buildFHHSByMap groups raw duel segments by map, merges each group, and returns
 // a map[mapName][]fhhsEntry for per-map FHHS analysis.
 func buildFHHSByMap(rawSegs []model.PlayerDuelSegment, steamID uint64, demoToMap map[string]string) map[string]interface{} {
@@ -614,6 +1120,57 @@ func buildFHHSByMap(rawSegs []model.PlayerDuelSegment, steamID uint64, demoToMap
 	return out
 }
 
+// buildFHHSByRoundContext pools first-hit headshot counts per round context
+// (pistol / anti-eco / gun; "unknown" for demos parsed before round contexts),
+// overall and per weapon bucket.
+func buildFHHSByRoundContext(rawSegs []model.PlayerDuelSegment) map[string]interface{} {
+	type counts struct{ duels, hits, hs int }
+	pct := func(c *counts) interface{} {
+		if c.hits == 0 {
+			return nil
+		}
+		return round2(float64(c.hs) / float64(c.hits) * 100)
+	}
+	total := make(map[string]*counts)
+	byWeapon := make(map[string]map[string]*counts)
+	for _, seg := range rawSegs {
+		ctx := seg.RoundContext
+		if ctx == "" {
+			ctx = "unknown"
+		}
+		if total[ctx] == nil {
+			total[ctx] = &counts{}
+			byWeapon[ctx] = make(map[string]*counts)
+		}
+		if byWeapon[ctx][seg.WeaponBucket] == nil {
+			byWeapon[ctx][seg.WeaponBucket] = &counts{}
+		}
+		for _, c := range []*counts{total[ctx], byWeapon[ctx][seg.WeaponBucket]} {
+			c.duels += seg.DuelCount
+			c.hits += seg.FirstHitCount
+			c.hs += seg.FirstHitHSCount
+		}
+	}
+	out := make(map[string]interface{}, len(total))
+	for ctx, c := range total {
+		weapons := make(map[string]interface{}, len(byWeapon[ctx]))
+		for bucket, wc := range byWeapon[ctx] {
+			weapons[bucket] = map[string]interface{}{
+				"duels":      wc.duels,
+				"first_hits": wc.hits,
+				"fhhs_pct":   pct(wc),
+			}
+		}
+		out[ctx] = map[string]interface{}{
+			"duels":      c.duels,
+			"first_hits": c.hits,
+			"fhhs_pct":   pct(c),
+			"by_weapon":  weapons,
+		}
+	}
+	return out
+}
+
 // buildAimByMap averages per-match aim metrics grouped by map.
 func buildAimByMap(stats []model.PlayerMatchStats) map[string]interface{} {
 	type accum struct {
@@ -670,42 +1227,51 @@ func buildAimByMap(stats []model.PlayerMatchStats) map[string]interface{} {
 	return out
 }
 
-// buildMatchContext serialises a single match into compact JSON.
-func buildMatchContext(demo *model.MatchSummary, stats []model.PlayerMatchStats, clutch map[uint64]*model.PlayerClutchMatchStats) (string, error) {
+// buildMatchContext serialises a single match into compact JSON, including
+// the per-round opening duels derived from the stored kill states.
+func buildMatchContext(demo *model.MatchSummary, stats []model.PlayerMatchStats, clutch map[uint64]*model.PlayerClutchMatchStats, killStates []model.KillState) (string, error) {
 	type playerEntry struct {
-		Name     string            `json:"name"`
-		Role     string            `json:"role"`
-		KD       float64           `json:"kd"`
-		ADR      float64           `json:"adr"`
-		KASTPct  float64           `json:"kast_pct"`
-		Kills    int               `json:"kills"`
-		Assists  int               `json:"assists"`
-		Deaths   int               `json:"deaths"`
-		HSPct    float64           `json:"hs_pct"`
-		OpeningK int               `json:"opening_k"`
-		OpeningD int               `json:"opening_d"`
-		TradeK   int               `json:"trade_k"`
-		TradeD   int               `json:"trade_d"`
-		Clutch   map[string]string `json:"clutch"`
+		Name          string            `json:"name"`
+		Role          string            `json:"role"`
+		KD            float64           `json:"kd"`
+		ADR           float64           `json:"adr"`
+		KASTPct       float64           `json:"kast_pct"`
+		KASTStrictPct *float64          `json:"kast_strict_pct"`
+		Kills         int               `json:"kills"`
+		Assists       int               `json:"assists"`
+		Deaths        int               `json:"deaths"`
+		HSPct         float64           `json:"hs_pct"`
+		OpeningK      int               `json:"opening_k"`
+		OpeningD      int               `json:"opening_d"`
+		TradeK        int               `json:"trade_k"`
+		TradeD        int               `json:"trade_d"`
+		Clutch        map[string]string `json:"clutch"`
+		PingMs        *float64          `json:"ping_ms,omitempty"`
+		HighPing      bool              `json:"high_ping,omitempty"`
 	}
 
 	players := make([]playerEntry, 0, len(stats))
 	for _, s := range stats {
 		p := playerEntry{
-			Name:     s.Name,
-			Role:     s.Role,
-			KD:       round2(s.KDRatio()),
-			ADR:      round2(s.ADR()),
-			KASTPct:  round2(s.KASTPct()),
-			Kills:    s.Kills,
-			Assists:  s.Assists,
-			Deaths:   s.Deaths,
-			HSPct:    round2(s.HSPercent()),
-			OpeningK: s.OpeningKills,
-			OpeningD: s.OpeningDeaths,
-			TradeK:   s.TradeKills,
-			TradeD:   s.TradeDeaths,
-			Clutch:   clutchSummary(clutch[s.SteamID]),
+			Name:          s.Name,
+			Role:          s.Role,
+			KD:            round2(s.KDRatio()),
+			ADR:           round2(s.ADR()),
+			KASTPct:       round2(s.KASTPct()),
+			KASTStrictPct: kastStrictPct(s.KASTStrictRounds, s.RoundsPlayed),
+			Kills:         s.Kills,
+			Assists:       s.Assists,
+			Deaths:        s.Deaths,
+			HSPct:         round2(s.HSPercent()),
+			OpeningK:      s.OpeningKills,
+			OpeningD:      s.OpeningDeaths,
+			TradeK:        s.TradeKills,
+			TradeD:        s.TradeDeaths,
+			Clutch:        clutchSummary(clutch[s.SteamID]),
+		}
+		if s.PingSamples > 0 {
+			ping := round2(s.AvgPingMs)
+			p.PingMs, p.HighPing = &ping, s.HighPing()
 		}
 		if p.Role == "" {
 			p.Role = "Rifler"
@@ -722,11 +1288,94 @@ func buildMatchContext(demo *model.MatchSummary, stats []model.PlayerMatchStats,
 		"type":    demo.MatchType,
 		"players": players,
 	}
+	names := make(map[uint64]string, len(stats))
+	for _, s := range stats {
+		names[s.SteamID] = s.Name
+	}
+	if duels, matchups := buildOpeningDuels(killStates, names); len(duels) > 0 {
+		doc["opening_duels"] = duels
+		doc["opening_matchups"] = matchups
+	}
+	if warnings := report.ConnectionWarnings(stats); len(warnings) > 0 {
+		doc["low_confidence"] = warnings
+	}
 
 	b, err := json.Marshal(doc)
 	return string(b), err
 }
 
+// openingDuel is the first enemy kill of one round.
+type openingDuel struct {
+	Round      int    `json:"round"`
+	Winner     string `json:"winner"`
+	WinnerSide string `json:"winner_side"`
+	Loser      string `json:"loser"`
+	LoserSide  string `json:"loser_side"`
+	Weapon     string `json:"weapon,omitempty"`
+	RoundWon   bool   `json:"round_won"` // the duel winner's side won the round
+}
+
+// openingMatchup counts the opening duels one player won against another.
+type openingMatchup struct {
+	Winner    string `json:"winner"`
+	Loser     string `json:"loser"`
+	Count     int    `json:"count"`
+	RoundsWon int    `json:"rounds_won"` // rounds the winner's side went on to win
+}
+
+// buildOpeningDuels takes the first kill of each round between opponents
+// (kill states are ordered by round and tick) and groups them by winner/loser
+// pair, most frequent first. names maps SteamID64 to display name; unknown IDs
+// fall back to the number.
+func buildOpeningDuels(states []model.KillState, names map[uint64]string) ([]openingDuel, []openingMatchup) {
+	name := func(id uint64) string {
+		if n, ok := names[id]; ok {
+			return n
+		}
+		return strconv.FormatUint(id, 10)
+	}
+	var duels []openingDuel
+	seen := make(map[int]bool)
+	for _, ks := range states {
+		if seen[ks.RoundNumber] || ks.KillerSteamID == 0 || ks.KillerTeam == ks.VictimTeam {
+			continue
+		}
+		seen[ks.RoundNumber] = true
+		duels = append(duels, openingDuel{
+			Round:      ks.RoundNumber,
+			Winner:     name(ks.KillerSteamID),
+			WinnerSide: ks.KillerTeam.String(),
+			Loser:      name(ks.VictimSteamID),
+			LoserSide:  ks.VictimTeam.String(),
+			Weapon:     ks.Weapon,
+			RoundWon:   ks.WinnerTeam == ks.KillerTeam,
+		})
+	}
+
+	type pair struct{ winner, loser string }
+	byPair := make(map[pair]*openingMatchup)
+	var matchups []openingMatchup
+	var order []pair
+	for _, d := range duels {
+		k := pair{d.Winner, d.Loser}
+		m := byPair[k]
+		if m == nil {
+			m = &openingMatchup{Winner: d.Winner, Loser: d.Loser}
+			byPair[k] = m
+			order = append(order, k)
+		}
+		m.Count++
+		if d.RoundWon {
+			m.RoundsWon++
+		}
+	}
+	for _, k := range order {
+		matchups = append(matchups, *byPair[k])
+	}
+	sort.SliceStable(matchups, func(i, j int) bool { return matchups[i].Count > matchups[j].Count })
+	return duels, matchups
+}
+
 // clutchSummary builds a map of "1v1"…"1v5" + "total" clutch strings.
 // Returns "—" for any count where attempts == 0.
 func clutchSummary(c *model.PlayerClutchMatchStats) map[string]string {
@@ -763,19 +1412,90 @@ func round2(v float64) float64 {
 	return float64(int(v*100+0.5)) / 100
 }
 
-// callAnthropic streams a response from the Anthropic API and prints it to stdout.
-func callAnthropic(ctx context.Context, apiKey, modelID, dataJSON, question string) error {
+// scorelineContext summarizes one scoreline split for the analyze context.
+func scorelineContext(sp model.ScorelineSplit) map[string]interface{} {
+	return map[string]interface{}{
+		"rounds": sp.Rounds,
+		"kd":     round2(float64(sp.Kills) / float64(max(sp.Deaths, 1))),
+		"adr":    round2(float64(sp.Damage) / float64(max(sp.Rounds, 1))),
+	}
+}
+
+// loadoutEfficiencyContext summarizes kills and damage per $1000 of the
+// player's freeze-end loadout for the analyze context.
+func loadoutEfficiencyContext(agg model.PlayerAggregate) map[string]interface{} {
+	k := float64(max(agg.EquipmentValue, 1)) / 1000
+	return map[string]interface{}{
+		"avg_loadout":       agg.EquipmentValue / max(agg.RoundsPlayed, 1),
+		"kills_per_1k_usd":  round2(float64(agg.Kills) / k),
+		"damage_per_1k_usd": round2(float64(agg.TotalDamage) / k),
+	}
+}
+
+// assistedDuelContext summarizes assisted duels and the 1v1 (clean) duel win
+// rate; clean_win_pct is nil when every duel was assisted.
+func assistedDuelContext(agg model.PlayerAggregate) map[string]interface{} {
+	ctx := map[string]interface{}{
+		"duel_wins":       agg.DuelWins,
+		"duel_losses":     agg.DuelLosses,
+		"assisted_wins":   agg.AssistedDuelWins,
+		"assisted_losses": agg.AssistedDuelLosses,
+		"clean_win_pct":   nil,
+	}
+	if pct, ok := model.CleanDuelWinPct(agg.DuelWins, agg.DuelLosses, agg.AssistedDuelWins, agg.AssistedDuelLosses); ok {
+		ctx["clean_win_pct"] = round2(pct)
+	}
+	return ctx
+}
+
+// duelLossReasonContext summarizes the factors behind lost duels as shares
+// of the duel losses; top_reason is nil when no loss had a factor.
+func duelLossReasonContext(agg model.PlayerAggregate) map[string]interface{} {
+	losses := float64(max(agg.DuelLosses, 1))
+	ctx := map[string]interface{}{
+		"duel_losses":      agg.DuelLosses,
+		"flashed_pct":      round2(float64(agg.LossFlashed) / losses * 100),
+		"isolated_pct":     round2(float64(agg.LossIsolated) / losses * 100),
+		"util_support_pct": round2(float64(agg.LossUtilSupport) / losses * 100),
+		"repeek_pct":       round2(float64(agg.LossRePeek) / losses * 100),
+		"none_pct":         round2(float64(max(agg.DuelLosses-agg.LossExplained, 0)) / losses * 100),
+		"top_reason":       nil,
+	}
+	top := 0
+	for _, r := range []struct {
+		name string
+		n    int
+	}{{"flashed", agg.LossFlashed}, {"isolated", agg.LossIsolated}, {"util_support", agg.LossUtilSupport}, {"repeek", agg.LossRePeek}} {
+		if r.n > top {
+			ctx["top_reason"], top = r.name, r.n
+		}
+	}
+	return ctx
+}
+
+// errAnthropicAuth reports a rejected API key.
+var errAnthropicAuth = errors.New("API authentication failed — check your API key")
+
+// newAnthropicClient returns an API client for apiKey, falling back to
+// $ANTHROPIC_API_KEY.
+func newAnthropicClient(apiKey string) (anthropic.Client, error) {
 	if apiKey == "" {
 		apiKey = os.Getenv("ANTHROPIC_API_KEY")
 	}
 	if apiKey == "" {
-		return fmt.Errorf("no API key: set ANTHROPIC_API_KEY or use --api-key")
+		return anthropic.Client{}, withExitCode(ExitAPIKey, fmt.Errorf("no API key: set ANTHROPIC_API_KEY or use --api-key"))
 	}
+	return anthropic.NewClient(option.WithAPIKey(apiKey)), nil
+}
 
-	client := anthropic.NewClient(option.WithAPIKey(apiKey))
-
-	userMsg := fmt.Sprintf("DATA:\n%s\n\nQUESTION: %s", dataJSON, question)
-
+// askAnthropic streams the answer to one question about dataJSON and returns
+// it as markdown. The data block is marked for prompt caching, so a batch of
+// questions over the same context reuses it instead of paying for it again.
+func askAnthropic(ctx context.Context, client anthropic.Client, modelID, dataJSON, question string) (string, error) {
+	dataBlock := anthropic.TextBlockParam{
+		Text:         "DATA:\n" + dataJSON,
+		CacheControl: anthropic.NewCacheControlEphemeralParam(),
+	}
 	stream := client.Messages.NewStreaming(ctx, anthropic.MessageNewParams{
 		Model:     anthropic.Model(modelID),
 		MaxTokens: 1024,
@@ -783,15 +1503,13 @@ func callAnthropic(ctx context.Context, apiKey, modelID, dataJSON, question stri
 			{Text: analyzeSystemPrompt},
 		},
 		Messages: []anthropic.MessageParam{
-			anthropic.NewUserMessage(anthropic.NewTextBlock(userMsg)),
+			anthropic.NewUserMessage(
+				anthropic.ContentBlockParamUnion{OfText: &dataBlock},
+				anthropic.NewTextBlock("QUESTION: "+question),
+			),
 		},
 	})
 
-	// Buffer the full response before rendering so glamour can process the
-	// complete markdown document (it needs the full text for proper formatting).
-	fmt.Fprintln(os.Stdout, "\n─── AI Analysis ──────────────────────────────────────")
-	fmt.Fprintln(os.Stdout, "  Waiting for response...")
-
 	var buf strings.Builder
 	for stream.Next() {
 		evt := stream.Current()
@@ -805,28 +1523,96 @@ func callAnthropic(ctx context.Context, apiKey, modelID, dataJSON, question stri
 	if err := stream.Err(); err != nil {
 		errStr := err.Error()
 		if strings.Contains(errStr, "401") || strings.Contains(errStr, "authentication") {
-			return fmt.Errorf("API authentication failed — check your API key")
+			return "", errAnthropicAuth
 		}
-		return fmt.Errorf("streaming error: %w", err)
+		return "", fmt.Errorf("streaming error: %w", err)
 	}
+	return buf.String(), nil
+}
 
+// printMarkdown renders md for the terminal between the AI Analysis rules,
+// falling back to plain text if glamour fails.
+func printMarkdown(md string) {
 	renderer, err := glamour.NewTermRenderer(
 		glamour.WithAutoStyle(),
 		glamour.WithWordWrap(100),
 	)
 	if err != nil {
 		// Fallback to plain text if glamour fails to initialise.
-		fmt.Fprintln(os.Stdout, buf.String())
+		fmt.Fprintln(os.Stdout, md)
 		fmt.Fprintln(os.Stdout, "──────────────────────────────────────────────────────")
-		return nil
+		return
 	}
 
-	rendered, err := renderer.Render(buf.String())
+	rendered, err := renderer.Render(md)
 	if err != nil {
-		fmt.Fprintln(os.Stdout, buf.String())
+		fmt.Fprintln(os.Stdout, md)
 	} else {
 		fmt.Fprint(os.Stdout, rendered)
 	}
 	fmt.Fprintln(os.Stdout, "──────────────────────────────────────────────────────")
+}
+
+// callAnthropic streams a response from the Anthropic API and prints it to stdout.
+func callAnthropic(ctx context.Context, apiKey, modelID, dataJSON, question string) error {
+	client, err := newAnthropicClient(apiKey)
+	if err != nil {
+		return err
+	}
+
+	// Buffer the full response before rendering so glamour can process the
+	// complete markdown document (it needs the full text for proper formatting).
+	fmt.Fprintln(os.Stdout, "\n─── AI Analysis ──────────────────────────────────────")
+	fmt.Fprintln(os.Stdout, "  Waiting for response...")
+
+	answer, err := askAnthropic(ctx, client, modelID, dataJSON, question)
+	if err != nil {
+		return err
+	}
+	printMarkdown(answer)
+	return nil
+}
+
+// runAnalyzeBatch asks every question about the same data context and
+// collects the answers into one markdown report under title, written to out
+// or rendered to stdout. A failed question is noted in its section and the
+// rest are still asked; a rejected API key stops the batch.
+func runAnalyzeBatch(ctx context.Context, title, subtitle, dataJSON string, questions []string, out string) error {
+	client, err := newAnthropicClient(analyzeAPIKey)
+	if err != nil {
+		return err
+	}
+
+	var md strings.Builder
+	fmt.Fprintf(&md, "# %s\n\n_%s · %d questions · model %s_\n", title, subtitle, len(questions), analyzeModel)
+	failed := 0
+	for i, q := range questions {
+		fmt.Fprintf(os.Stderr, "[%d/%d] %s\n", i+1, len(questions), q)
+		answer, err := askAnthropic(ctx, client, analyzeModel, dataJSON, q)
+		if errors.Is(err, errAnthropicAuth) {
+			return err
+		}
+		fmt.Fprintf(&md, "\n## %d. %s\n\n", i+1, q)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  [error] %v\n", err)
+			fmt.Fprintf(&md, "_No answer: %v_\n", err)
+			failed++
+			continue
+		}
+		md.WriteString(strings.TrimSpace(answer) + "\n")
+	}
+
+	if out != "" {
+		if err := os.WriteFile(out, []byte(md.String()), 0644); err != nil {
+			return fmt.Errorf("write %s: %w", out, err)
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %s\n", out)
+	} else {
+		fmt.Fprintln(os.Stdout, "\n─── AI Analysis ──────────────────────────────────────")
+		printMarkdown(md.String())
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d questions got no answer", failed, len(questions))
+	}
 	return nil
 }
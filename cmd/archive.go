@@ -0,0 +1,339 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/spf13/cobra"
+
+	"github.com/pable/go-cs-metrics/internal/aggregator"
+	"github.com/pable/go-cs-metrics/internal/parser"
+	"github.com/pable/go-cs-metrics/internal/storage"
+)
+
+var (
+	archiveDir      string
+	archiveTo       string
+	archiveDelete   bool
+	archiveCompress bool
+	archiveDryRun   bool
+	archiveForce    bool
+)
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "Move or delete demo files that are already stored in the database",
+	Long: `Free disk space in a replay folder by moving the .dem files that have
+already been parsed to cold storage (--to), or deleting them (--delete).
+
+A file is only touched when the SHA-256 of its full contents — the key parse
+stores a demo under — is in the database; every other file is left alone.
+Moves across filesystems are copied, re-hashed and only then removed from
+--dir. With --compress each demo is written as <name>.dem.zst (decompress
+with "zstd -d" before parsing it again) and verified by decompressing it
+before the original is removed. An existing file in --to is never
+overwritten.
+
+--delete is permanent and requires --force. It keeps demos stored by an
+older pipeline version, since parse needs the file to refresh them; re-parse
+those first (see "list --outdated"). --dry-run lists what would happen
+without touching anything.
+
+Example:
+  csmetrics archive --dir ~/replays --to /mnt/cold/demos --compress
+  csmetrics archive --dir ~/replays --delete --force`,
+	Args: cobra.NoArgs,
+	RunE: runArchive,
+}
+
+func init() {
+	archiveCmd.Flags().StringVar(&archiveDir, "dir", "", "replay folder with the .dem files to archive")
+	archiveCmd.Flags().StringVar(&archiveTo, "to", "", "cold-storage directory the stored demos are moved to")
+	archiveCmd.Flags().BoolVar(&archiveDelete, "delete", false, "delete the stored demos instead of moving them")
+	archiveCmd.Flags().BoolVar(&archiveCompress, "compress", false, "zstd-compress the demos moved with --to")
+	archiveCmd.Flags().BoolVar(&archiveDryRun, "dry-run", false, "list what would be archived without touching any file")
+	archiveCmd.Flags().BoolVarP(&archiveForce, "force", "f", false, "confirm the permanent --delete")
+	_ = archiveCmd.MarkFlagRequired("dir")
+}
+
+// archiveCandidate is a .dem file in the replay folder with its stored state.
+type archiveCandidate struct {
+	path     string
+	size     int64
+	hash     string
+	stored   bool
+	outdated bool
+}
+
+func runArchive(cmd *cobra.Command, args []string) error {
+	switch {
+	case archiveTo == "" && !archiveDelete:
+		return withExitCode(ExitUsage, fmt.Errorf("one of --to or --delete is required"))
+	case archiveTo != "" && archiveDelete:
+		return withExitCode(ExitUsage, fmt.Errorf("--to and --delete cannot be combined"))
+	case archiveCompress && archiveTo == "":
+		return withExitCode(ExitUsage, fmt.Errorf("--compress needs --to"))
+	}
+	if archiveTo != "" {
+		same, err := sameDir(archiveDir, archiveTo)
+		if err != nil {
+			return err
+		}
+		if same {
+			return withExitCode(ExitUsage, fmt.Errorf("--to must differ from --dir"))
+		}
+	}
+
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
+	}
+	defer db.Close()
+
+	candidates, err := archiveCandidates(db, archiveDir)
+	if err != nil {
+		return err
+	}
+	var todo []archiveCandidate
+	var unknown, outdated int
+	for _, c := range candidates {
+		switch {
+		case !c.stored:
+			unknown++
+		case archiveDelete && c.outdated:
+			outdated++
+			fmt.Fprintf(os.Stderr, "warn: %s kept: stored by an older pipeline version; re-parse it before deleting\n", filepath.Base(c.path))
+		default:
+			todo = append(todo, c)
+		}
+	}
+	var total int64
+	for _, c := range todo {
+		total += c.size
+	}
+	if len(todo) == 0 {
+		return noDataError("no stored demos to archive in %s (%d .dem file(s), %d not in the database)", archiveDir, len(candidates), unknown)
+	}
+
+	if archiveDryRun || (archiveDelete && !archiveForce) {
+		verb := "move to " + archiveTo
+		if archiveDelete {
+			verb = "delete"
+		}
+		for _, c := range todo {
+			fmt.Fprintf(os.Stdout, "would %s  %.12s  %s  (%s)\n", verb, c.hash, filepath.Base(c.path), mbLabel(c.size))
+		}
+		fmt.Fprintf(os.Stdout, "%d demo(s), %s; %d file(s) not in the database left alone\n", len(todo), mbLabel(total), unknown)
+		if archiveDelete && !archiveForce && !archiveDryRun {
+			fmt.Fprintf(os.Stderr, "This will permanently delete %d demo file(s).\n", len(todo))
+			fmt.Fprintf(os.Stderr, "Re-run with --force to confirm.\n")
+		}
+		return nil
+	}
+
+	if archiveTo != "" {
+		if err := os.MkdirAll(archiveTo, 0755); err != nil {
+			return fmt.Errorf("create %s: %w", archiveTo, err)
+		}
+	}
+	var done, failed int
+	var freed int64
+	for _, c := range todo {
+		name := filepath.Base(c.path)
+		var err error
+		switch {
+		case archiveDelete:
+			err = os.Remove(c.path)
+		case archiveCompress:
+			err = compressDemo(c, filepath.Join(archiveTo, name+".zst"))
+		default:
+			err = moveDemo(c, filepath.Join(archiveTo, name))
+		}
+		if err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "warn: %s: %v\n", name, err)
+			continue
+		}
+		done++
+		freed += c.size
+		fmt.Fprintf(os.Stdout, "%s  %.12s  %s  (%s)\n", archiveAction(), c.hash, name, mbLabel(c.size))
+	}
+	fmt.Fprintf(os.Stdout, "Archived %d demo(s), freed %s in %s\n", done, mbLabel(freed), archiveDir)
+	if unknown > 0 {
+		fmt.Fprintf(os.Stdout, "%d file(s) not in the database left alone\n", unknown)
+	}
+	if outdated > 0 {
+		fmt.Fprintf(os.Stdout, "%d outdated demo(s) kept; re-parse them, then archive again\n", outdated)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d demo(s) could not be archived", failed)
+	}
+	return nil
+}
+
+// archiveAction is the verb printed for each archived demo.
+func archiveAction() string {
+	switch {
+	case archiveDelete:
+		return "Deleted"
+	case archiveCompress:
+		return "Compressed"
+	}
+	return "Moved"
+}
+
+// archiveCandidates hashes every .dem file in dir and looks it up in db.
+func archiveCandidates(db *storage.DB, dir string) ([]archiveCandidate, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read dir: %w", err)
+	}
+	var out []archiveCandidate
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".dem" {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, fmt.Errorf("stat %s: %w", e.Name(), err)
+		}
+		c := archiveCandidate{path: filepath.Join(dir, e.Name()), size: info.Size()}
+		if c.hash, err = parser.FileHash(c.path); err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Name(), err)
+		}
+		version, found, err := db.DemoPipelineVersion(c.hash)
+		if err != nil {
+			return nil, fmt.Errorf("look up %s: %w", e.Name(), err)
+		}
+		c.stored = found
+		c.outdated = found && version < aggregator.PipelineVersion
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// moveDemo moves c to dst without ever replacing an existing dst: it hard
+// links c to dst (which fails if dst exists) and removes the original, or,
+// when a link is not possible (dst on another filesystem), copies it with
+// O_EXCL and re-hashes the copy first.
+func moveDemo(c archiveCandidate, dst string) error {
+	err := os.Link(c.path, dst)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%s already exists", dst)
+	}
+	if err == nil {
+		return os.Remove(c.path)
+	}
+	if err := copyFile(c.path, dst); err != nil {
+		return err
+	}
+	hash, err := parser.FileHash(dst)
+	if err != nil {
+		return err
+	}
+	if hash != c.hash {
+		os.Remove(dst)
+		return fmt.Errorf("copy to %s does not match the original; original kept", dst)
+	}
+	return os.Remove(c.path)
+}
+
+// compressDemo writes c zstd-compressed to dst, verifies that dst
+// decompresses to the original contents, then removes the original. dst
+// must not exist.
+func compressDemo(c archiveCandidate, dst string) error {
+	in, err := os.Open(c.path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", dst, err)
+	}
+	enc, err := zstd.NewWriter(out)
+	if err != nil {
+		out.Close()
+		os.Remove(dst)
+		return fmt.Errorf("zstd: %w", err)
+	}
+	_, err = io.Copy(enc, in)
+	err = errors.Join(err, enc.Close(), out.Close())
+	if err == nil {
+		err = verifyCompressed(dst, c.hash)
+	}
+	if err != nil {
+		os.Remove(dst)
+		return fmt.Errorf("compress: %w", err)
+	}
+	in.Close()
+	return os.Remove(c.path)
+}
+
+// verifyCompressed checks that the zstd file at path decompresses to contents
+// with the SHA-256 hash.
+func verifyCompressed(path, hash string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	dec, err := zstd.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("zstd: %w", err)
+	}
+	defer dec.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, dec); err != nil {
+		return fmt.Errorf("verify %s: %w", path, err)
+	}
+	if fmt.Sprintf("%x", h.Sum(nil)) != hash {
+		return fmt.Errorf("%s does not decompress to the original", path)
+	}
+	return nil
+}
+
+// copyFile copies src to dst, failing if dst exists.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", dst, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return fmt.Errorf("copy to %s: %w", dst, err)
+	}
+	return out.Close()
+}
+
+// sameDir reports whether a and b name the same directory (b need not exist).
+func sameDir(a, b string) (bool, error) {
+	ai, err := os.Stat(a)
+	if err != nil {
+		return false, fmt.Errorf("read dir: %w", err)
+	}
+	bi, err := os.Stat(b)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("stat %s: %w", b, err)
+	}
+	return os.SameFile(ai, bi), nil
+}
+
+// mbLabel formats a byte count in megabytes.
+func mbLabel(n int64) string {
+	return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+}
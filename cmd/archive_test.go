@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/pable/go-cs-metrics/internal/aggregator"
+	"github.com/pable/go-cs-metrics/internal/model"
+	"github.com/pable/go-cs-metrics/internal/parser"
+	"github.com/pable/go-cs-metrics/internal/storage"
+)
+
+// writeDemo writes a fake demo into dir and returns it as an archive candidate.
+func writeDemo(t *testing.T, dir, name, body string) archiveCandidate {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := parser.FileHash(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return archiveCandidate{path: path, size: int64(len(body)), hash: hash, stored: true}
+}
+
+// readFile returns the contents of path, failing the test if it is missing.
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return string(b)
+}
+
+// fileExists reports whether path exists.
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestMoveDemo(t *testing.T) {
+	t.Run("moves the file", func(t *testing.T) {
+		src, dst := t.TempDir(), t.TempDir()
+		c := writeDemo(t, src, "a.dem", "demo a")
+		to := filepath.Join(dst, "a.dem")
+		if err := moveDemo(c, to); err != nil {
+			t.Fatalf("moveDemo: %v", err)
+		}
+		if got := readFile(t, to); got != "demo a" {
+			t.Errorf("moved contents = %q", got)
+		}
+		if fileExists(c.path) {
+			t.Error("original still in --dir")
+		}
+	})
+
+	t.Run("existing destination is never replaced", func(t *testing.T) {
+		src, dst := t.TempDir(), t.TempDir()
+		c := writeDemo(t, src, "a.dem", "demo a")
+		to := filepath.Join(dst, "a.dem")
+		if err := os.WriteFile(to, []byte("other demo"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := moveDemo(c, to); err == nil {
+			t.Fatal("moveDemo onto an existing file: want an error")
+		}
+		if got := readFile(t, to); got != "other demo" {
+			t.Errorf("destination = %q, want it untouched", got)
+		}
+		if got := readFile(t, c.path); got != "demo a" {
+			t.Errorf("original = %q, want it kept", got)
+		}
+	})
+}
+
+func TestCompressDemo(t *testing.T) {
+	t.Run("compresses, verifies and removes the original", func(t *testing.T) {
+		src, dst := t.TempDir(), t.TempDir()
+		c := writeDemo(t, src, "a.dem", "demo a, compressible demo a, demo a")
+		to := filepath.Join(dst, "a.dem.zst")
+		if err := compressDemo(c, to); err != nil {
+			t.Fatalf("compressDemo: %v", err)
+		}
+		if fileExists(c.path) {
+			t.Error("original still in --dir")
+		}
+		dec, err := zstd.NewReader(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer dec.Close()
+		got, err := dec.DecodeAll([]byte(readFile(t, to)), nil)
+		if err != nil {
+			t.Fatalf("decompress: %v", err)
+		}
+		if string(got) != "demo a, compressible demo a, demo a" {
+			t.Errorf("decompressed = %q", got)
+		}
+	})
+
+	t.Run("existing destination is never replaced", func(t *testing.T) {
+		src, dst := t.TempDir(), t.TempDir()
+		c := writeDemo(t, src, "a.dem", "demo a")
+		to := filepath.Join(dst, "a.dem.zst")
+		if err := os.WriteFile(to, []byte("other"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := compressDemo(c, to); err == nil {
+			t.Fatal("compressDemo onto an existing file: want an error")
+		}
+		if got := readFile(t, to); got != "other" {
+			t.Errorf("destination = %q, want it untouched", got)
+		}
+		if !fileExists(c.path) {
+			t.Error("original removed")
+		}
+	})
+
+	t.Run("failed verification keeps the original", func(t *testing.T) {
+		src, dst := t.TempDir(), t.TempDir()
+		c := writeDemo(t, src, "a.dem", "demo a")
+		c.hash = "0000"
+		to := filepath.Join(dst, "a.dem.zst")
+		if err := compressDemo(c, to); err == nil {
+			t.Fatal("compressDemo with a wrong hash: want an error")
+		}
+		if fileExists(to) {
+			t.Error("unverified archive left in --to")
+		}
+		if !fileExists(c.path) {
+			t.Error("original removed")
+		}
+	})
+}
+
+func TestVerifyCompressed(t *testing.T) {
+	dir := t.TempDir()
+	c := writeDemo(t, dir, "a.dem", "demo a")
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	good := filepath.Join(dir, "a.dem.zst")
+	if err := os.WriteFile(good, enc.EncodeAll([]byte("demo a"), nil), 0644); err != nil {
+		t.Fatal(err)
+	}
+	other := filepath.Join(dir, "b.dem.zst")
+	if err := os.WriteFile(other, enc.EncodeAll([]byte("demo b"), nil), 0644); err != nil {
+		t.Fatal(err)
+	}
+	enc.Close()
+
+	if err := verifyCompressed(good, c.hash); err != nil {
+		t.Errorf("matching archive: %v", err)
+	}
+	if err := verifyCompressed(other, c.hash); err == nil {
+		t.Error("archive of other contents: want an error")
+	}
+	if err := verifyCompressed(c.path, c.hash); err == nil {
+		t.Error("uncompressed file: want an error")
+	}
+	if err := verifyCompressed(filepath.Join(dir, "missing.zst"), c.hash); err == nil {
+		t.Error("missing file: want an error")
+	}
+}
+
+func TestSameDir(t *testing.T) {
+	root := t.TempDir()
+	a := filepath.Join(root, "a")
+	b := filepath.Join(root, "b")
+	for _, d := range []string{a, b} {
+		if err := os.Mkdir(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link := filepath.Join(root, "link")
+	if err := os.Symlink(a, link); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name, a, b string
+		want       bool
+	}{
+		{"same path", a, a, true},
+		{"unclean path", a, filepath.Join(b, "..", "a"), true},
+		{"symlink", a, link, true},
+		{"different", a, b, false},
+		{"missing target", a, filepath.Join(root, "new"), false},
+	}
+	for _, c := range cases {
+		got, err := sameDir(c.a, c.b)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("%s: sameDir = %v, want %v", c.name, got, c.want)
+		}
+	}
+	if _, err := sameDir(filepath.Join(root, "missing"), b); err == nil {
+		t.Error("missing --dir: want an error")
+	}
+}
+
+func TestArchiveDeleteNeedsForce(t *testing.T) {
+	dir := t.TempDir()
+	c := writeDemo(t, dir, "a.dem", "demo a")
+
+	oldDB, oldDir, oldDelete, oldForce := dbPath, archiveDir, archiveDelete, archiveForce
+	t.Cleanup(func() { dbPath, archiveDir, archiveDelete, archiveForce = oldDB, oldDir, oldDelete, oldForce })
+	dbPath = filepath.Join(t.TempDir(), "metrics.db")
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.InsertDemo(model.MatchSummary{DemoHash: c.hash, MapName: "de_mirage", PipelineVersion: aggregator.PipelineVersion}, "")
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	archiveDir, archiveDelete = dir, true
+
+	archiveForce = false
+	if err := runArchive(archiveCmd, nil); err != nil {
+		t.Fatalf("--delete without --force: %v", err)
+	}
+	if !fileExists(c.path) {
+		t.Fatal("--delete without --force removed the demo")
+	}
+
+	archiveForce = true
+	if err := runArchive(archiveCmd, nil); err != nil {
+		t.Fatalf("--delete --force: %v", err)
+	}
+	if fileExists(c.path) {
+		t.Error("--delete --force kept the demo")
+	}
+}
@@ -238,7 +238,9 @@ func buildBTTeamStats(db *storage.DB, rosterPath string, since, before time.Time
 	if err != nil {
 		return nil, fmt.Errorf("roster match totals: %w", err)
 	}
-	ratings := buildWeightedRatings(byDemo, weights)
+	// The dataset keeps the plain top five by weighted rounds, without
+	// export's role balancing, so its rows stay comparable across versions.
+	ratings, _ := buildWeightedRatings(byDemo, weights, nil, false)
 
 	// Populate per-map entry kill rates.
 	entryByMap, err := db.MapEntryStats(rf.Players, allHashes)
@@ -0,0 +1,436 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pable/go-cs-metrics/internal/aggregator"
+	"github.com/pable/go-cs-metrics/internal/config"
+	"github.com/pable/go-cs-metrics/internal/faceit"
+	"github.com/pable/go-cs-metrics/internal/model"
+	"github.com/pable/go-cs-metrics/internal/parser"
+	"github.com/pable/go-cs-metrics/internal/report"
+	"github.com/pable/go-cs-metrics/internal/storage"
+)
+
+var (
+	baselineTier    string
+	baselineTarget  int
+	baselineAnchors []string
+	baselineDir     string
+	baselineLevel   int
+	baselineMap     string
+	baselineHistory int
+)
+
+// baselineCmd is the parent command for building the baseline corpus.
+var baselineCmd = &cobra.Command{
+	Use:   "baseline",
+	Short: "Build and track the per-tier baseline corpus",
+}
+
+// baselineBuildCmd ingests baseline demos for a tier until its quota is met.
+var baselineBuildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Ingest baseline demos for a tier until --target demos are stored",
+	Long: `Set the tier's quota to --target demos and ingest baseline demos
+(is_baseline=true, tier=--tier) until the tier holds that many.
+
+Sources are tried in order:
+  --dir      local .dem files (e.g. demos downloaded by hand from FACEIT)
+  --anchors  FACEIT nicknames or SteamID64s; each anchor's recent match
+             history (--history matches) is walked, keeping matches at
+             --level (default: N from a "faceit-N" tier) and --map
+
+Every source tried is recorded, so re-running resumes where the last run
+stopped: stored, duplicate and filtered sources are never fetched again and
+failed ones are retried. Demos already in the database (under any tier) are
+recorded as duplicates and do not count towards the quota.
+
+FACEIT sources need a Data API key (FACEIT_API_KEY or
+~/.csmetrics/faceit_api_key). Most FACEIT demo URLs point at a CDN that no
+longer resolves; without a Downloads API key (FACEIT_DOWNLOADS_KEY or
+~/.csmetrics/faceit_downloads_key) those downloads fail and are retried on the
+next run.
+
+Examples:
+  csmetrics baseline build --tier faceit-10 --target 200 --anchors s1mple,donk
+  csmetrics baseline build --tier faceit-10 --target 200 --dir ~/demos/faceit10`,
+	Args: cobra.NoArgs,
+	RunE: runBaselineBuild,
+}
+
+// baselineStatusCmd prints quota progress.
+var baselineStatusCmd = &cobra.Command{
+	Use:   "status [tier]",
+	Short: "Show baseline quota progress per tier",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runBaselineStatus,
+}
+
+func init() {
+	baselineBuildCmd.Flags().StringVar(&baselineTier, "tier", "", "tier label of the baseline demos (required, e.g. faceit-10)")
+	baselineBuildCmd.Flags().IntVar(&baselineTarget, "target", 0, "number of baseline demos wanted for the tier (required)")
+	baselineBuildCmd.Flags().StringSliceVar(&baselineAnchors, "anchors", nil, "comma-separated FACEIT nicknames or SteamID64s whose match history is walked")
+	baselineBuildCmd.Flags().StringVar(&baselineDir, "dir", "", "directory of local .dem files to ingest before the anchors")
+	baselineBuildCmd.Flags().IntVar(&baselineLevel, "level", 0, "only ingest FACEIT matches at this skill level (default: N from a faceit-N tier)")
+	baselineBuildCmd.Flags().StringVar(&baselineMap, "map", "", "only ingest FACEIT matches on this map (e.g. de_mirage)")
+	baselineBuildCmd.Flags().IntVar(&baselineHistory, "history", 100, "recent matches walked per anchor")
+	_ = baselineBuildCmd.MarkFlagRequired("tier")
+	_ = baselineBuildCmd.MarkFlagRequired("target")
+
+	baselineCmd.AddCommand(baselineBuildCmd, baselineStatusCmd)
+}
+
+// baselineBuilder ingests sources for one tier and tracks what is left of
+// its quota.
+type baselineBuilder struct {
+	db        *storage.DB
+	tier      string
+	sights    map[uint64]bool
+	cfg       config.Config // storage feature flags
+	remaining int
+	counts    map[string]int // sources recorded this run, by status
+}
+
+// record stores s's outcome and counts it.
+func (b *baselineBuilder) record(s storage.BaselineSource) error {
+	s.Tier = b.tier
+	if err := b.db.RecordBaselineSource(s); err != nil {
+		return fmt.Errorf("record baseline source: %w", err)
+	}
+	b.counts[s.Status]++
+	if s.Status == storage.BaselineStored {
+		b.remaining--
+	}
+	return nil
+}
+
+// ingest parses the demo at path and stores it as a baseline demo of the
+// tier, recording the outcome under s and the demo's provenance as src. A
+// non-empty matchDate overrides the file date. Parse errors are recorded as
+// failed; only storage errors are returned.
+func (b *baselineBuilder) ingest(s storage.BaselineSource, src model.DemoSource, path, matchType, matchDate string) error {
+	quickHash, err := parser.QuickHash(path)
+	if err != nil {
+		s.Status, s.Detail = storage.BaselineFailed, err.Error()
+		return b.record(s)
+	}
+	raw, err := parser.ParseDemo(path, matchType)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  [error] parse: %v\n", err)
+		s.Status, s.Detail = storage.BaselineFailed, err.Error()
+		return b.record(s)
+	}
+	defer debug.FreeOSMemory()
+	s.DemoHash = raw.DemoHash
+	if matchDate != "" {
+		raw.MatchDate = matchDate
+	}
+
+	exists, err := b.db.DemoExists(raw.DemoHash)
+	if err != nil {
+		return err
+	}
+	if exists {
+		if err := b.db.UpdateDemoSource(raw.DemoHash, src); err != nil {
+			return fmt.Errorf("update demo source: %w", err)
+		}
+		fmt.Printf("  already stored\n")
+		s.Status = storage.BaselineDuplicate
+		return b.record(s)
+	}
+
+	matchStats, roundStats, weaponStats, duelSegs, err := aggregator.Aggregate(raw)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  [error] aggregate: %v\n", err)
+		s.Status, s.Detail = storage.BaselineFailed, err.Error()
+		return b.record(s)
+	}
+	data := newDemoData(raw, quickHash, b.cfg, b.sights)
+	data.MatchStats, data.RoundStats, data.WeaponStats, data.DuelSegments = matchStats, roundStats, weaponStats, duelSegs
+	diagRef, err := b.db.ListDemoDiagnostics()
+	if err != nil {
+		return fmt.Errorf("list diagnostics: %w", err)
+	}
+	for _, msg := range report.DiagnosticsWarnings(data.Diagnostics, diagRef) {
+		fmt.Fprintf(os.Stderr, "  warn: %s\n", msg)
+	}
+	data.Summary.Tier = b.tier
+	data.Summary.IsBaseline = true
+	data.Summary.Source = src
+	if err := b.db.ReplaceDemo(data); err != nil {
+		return fmt.Errorf("store demo: %w", err)
+	}
+	fmt.Printf("  stored: %s  %d players, %d rounds\n", raw.MapName, len(data.MatchStats), len(raw.Rounds))
+	s.Status = storage.BaselineStored
+	return b.record(s)
+}
+
+// ingestDir ingests the .dem files in dir until the quota is met.
+func (b *baselineBuilder) ingestDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("read dir: %w", err)
+	}
+	for _, e := range entries {
+		if b.remaining <= 0 {
+			return nil
+		}
+		if e.IsDir() || filepath.Ext(e.Name()) != ".dem" {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		quickHash, err := parser.QuickHash(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  [skip] %s: %v\n", e.Name(), err)
+			continue
+		}
+		s := storage.BaselineSource{SourceID: "file:" + quickHash}
+		done, err := b.db.BaselineSourceDone(s.SourceID)
+		if err != nil {
+			return err
+		}
+		if done {
+			continue
+		}
+		fmt.Printf("[%d left] %s\n", b.remaining, e.Name())
+		found, fullHash, err := b.db.DemoExistsByQuickHash(quickHash)
+		if err != nil {
+			return err
+		}
+		if found {
+			if err := b.db.UpdateDemoSource(fullHash, fileSource(path)); err != nil {
+				return fmt.Errorf("update demo source: %w", err)
+			}
+			fmt.Printf("  already stored\n")
+			s.Status, s.DemoHash = storage.BaselineDuplicate, fullHash
+			if err := b.record(s); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := b.ingest(s, fileSource(path), path, "FACEIT", ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ingestFaceit walks each anchor's FACEIT match history until the quota is
+// met. Anchors that cannot be resolved are warned about and skipped.
+func (b *baselineBuilder) ingestFaceit(anchors []string, level int, mapFilter string, history int) error {
+	apiKey, err := loadFaceitAPIKey()
+	if err != nil {
+		return withExitCode(ExitAPIKey, err)
+	}
+	client := faceit.NewClient(apiKey)
+
+	tmpDir, err := os.MkdirTemp("", "csmetrics-*")
+	if err != nil {
+		return fmt.Errorf("temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, anchor := range anchors {
+		if b.remaining <= 0 {
+			return nil
+		}
+		var fp *faceit.Player
+		if looksLikeSteamID(anchor) {
+			fp, err = client.GetPlayerBySteamID(anchor)
+		} else {
+			fp, err = client.GetPlayerByNickname(anchor)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[warn] lookup anchor %q: %v\n", anchor, err)
+			continue
+		}
+		items, err := client.GetMatchHistory(fp.PlayerID, history)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[warn] match history of %s: %v\n", fp.Nickname, err)
+			continue
+		}
+		fmt.Printf("Anchor: %s  level=%d  %d matches\n", fp.Nickname, fp.Games.CS2.SkillLevel, len(items))
+
+		for _, item := range items {
+			if b.remaining <= 0 {
+				return nil
+			}
+			s := storage.BaselineSource{SourceID: "faceit:" + item.MatchID, Anchor: fp.Nickname}
+			done, err := b.db.BaselineSourceDone(s.SourceID)
+			if err != nil {
+				return err
+			}
+			if done {
+				continue
+			}
+			if !strings.EqualFold(item.Status, "FINISHED") {
+				s.Status, s.Detail = storage.BaselineSkipped, "status "+item.Status
+				if err := b.record(s); err != nil {
+					return err
+				}
+				continue
+			}
+			match, err := client.GetMatch(item.MatchID)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "  [skip] %s: %v\n", item.MatchID, err)
+				s.Status, s.Detail = storage.BaselineFailed, err.Error()
+				if err := b.record(s); err != nil {
+					return err
+				}
+				continue
+			}
+			var skip string
+			switch {
+			case level > 0 && match.SkillLevel != level:
+				skip = fmt.Sprintf("level %d", match.SkillLevel)
+			case mapFilter != "" && match.MapName() != mapFilter:
+				skip = "map " + match.MapName()
+			case len(match.DemoURLs) == 0:
+				skip = "no demo URL"
+			}
+			if skip != "" {
+				s.Status, s.Detail = storage.BaselineSkipped, skip
+				if err := b.record(s); err != nil {
+					return err
+				}
+				continue
+			}
+
+			matchDate := time.Unix(match.StartedAt, 0).UTC().Format("2006-01-02")
+			fmt.Printf("[%d left] %s  map=%-15s  level=%d  date=%s\n",
+				b.remaining, item.MatchID, match.MapName(), match.SkillLevel, matchDate)
+			demPath, err := downloadAndDecompress(usableDemoURL(match.DemoURLs[0]), tmpDir, item.MatchID)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "  [error] download: %v\n", err)
+				s.Status, s.Detail = storage.BaselineFailed, err.Error()
+				if err := b.record(s); err != nil {
+					return err
+				}
+				continue
+			}
+			err = b.ingest(s, faceitSource(item.MatchID), demPath, "FACEIT", matchDate)
+			os.Remove(demPath)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// tierLevel returns N for a "faceit-N" tier, or 0.
+func tierLevel(tier string) int {
+	n, err := strconv.Atoi(strings.TrimPrefix(tier, "faceit-"))
+	if err != nil || !strings.HasPrefix(tier, "faceit-") || n < 1 || n > 10 {
+		return 0
+	}
+	return n
+}
+
+// runBaselineBuild sets the tier's quota and ingests sources until it is met.
+func runBaselineBuild(cmd *cobra.Command, args []string) error {
+	if baselineTarget < 1 {
+		return withExitCode(ExitUsage, fmt.Errorf("--target must be ≥ 1"))
+	}
+	if baselineHistory < 1 {
+		return withExitCode(ExitUsage, fmt.Errorf("--history must be ≥ 1"))
+	}
+	if len(baselineAnchors) == 0 && baselineDir == "" {
+		return withExitCode(ExitUsage, fmt.Errorf("no sources: provide --anchors and/or --dir"))
+	}
+	level := baselineLevel
+	if level == 0 {
+		level = tierLevel(baselineTier)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return fmt.Errorf("create db dir: %w", err)
+	}
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
+	}
+	defer db.Close()
+
+	if err := db.SetBaselineQuota(baselineTier, baselineTarget); err != nil {
+		return fmt.Errorf("set quota: %w", err)
+	}
+	quotas, err := db.GetBaselineQuotas(baselineTier)
+	if err != nil {
+		return fmt.Errorf("baseline quotas: %w", err)
+	}
+	sights, err := trackedSightPlayers()
+	if err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	b := &baselineBuilder{
+		db:        db,
+		tier:      baselineTier,
+		sights:    sights,
+		cfg:       cfg,
+		remaining: quotas[0].Remaining(),
+		counts:    make(map[string]int),
+	}
+	fmt.Printf("Tier %s: %d/%d baseline demos stored, %d to go\n",
+		baselineTier, quotas[0].Stored, baselineTarget, b.remaining)
+
+	if baselineDir != "" && b.remaining > 0 {
+		if err := b.ingestDir(baselineDir); err != nil {
+			return err
+		}
+	}
+	if len(baselineAnchors) > 0 && b.remaining > 0 {
+		if err := b.ingestFaceit(baselineAnchors, level, baselineMap, baselineHistory); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("\nThis run: %d stored, %d duplicate, %d skipped, %d failed\n",
+		b.counts[storage.BaselineStored], b.counts[storage.BaselineDuplicate],
+		b.counts[storage.BaselineSkipped], b.counts[storage.BaselineFailed])
+	quotas, err = db.GetBaselineQuotas(baselineTier)
+	if err != nil {
+		return fmt.Errorf("baseline quotas: %w", err)
+	}
+	report.PrintBaselineQuotaTable(os.Stdout, quotas)
+	if r := quotas[0].Remaining(); r > 0 {
+		fmt.Fprintf(os.Stderr, "target not reached: %d more demos needed; add anchors, raise --history or re-run to retry failures\n", r)
+	}
+	return nil
+}
+
+// runBaselineStatus prints quota progress for one tier or all of them.
+func runBaselineStatus(cmd *cobra.Command, args []string) error {
+	tier := ""
+	if len(args) == 1 {
+		tier = args[0]
+	}
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
+	}
+	defer db.Close()
+
+	quotas, err := db.GetBaselineQuotas(tier)
+	if err != nil {
+		return fmt.Errorf("baseline quotas: %w", err)
+	}
+	if tier != "" && len(quotas) == 0 {
+		return noDataError("no baseline quota for tier %q", tier)
+	}
+	report.PrintBaselineQuotaTable(os.Stdout, quotas)
+	return nil
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/pable/go-cs-metrics/internal/aggregator"
+	"github.com/pable/go-cs-metrics/internal/storage"
+)
+
+// aggregateCacheKey hashes a command name and its filter values into the
+// filters_hash of player_aggregate_cache. Pass every input that changes the
+// cached result.
+func aggregateCacheKey(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// loadAggregateCache decodes the payload cached for a player under key by the
+// current pipeline version into v. A payload that no longer decodes (written
+// by a build with a different shape) is a miss.
+func loadAggregateCache(db *storage.DB, steamID uint64, key string, v any) (bool, error) {
+	payload, ok, err := db.GetAggregateCache(steamID, key, aggregator.PipelineVersion)
+	if err != nil || !ok {
+		return false, err
+	}
+	if err := json.Unmarshal(payload, v); err != nil {
+		return false, nil
+	}
+	return true, nil
+}
+
+// saveAggregateCache caches v for a player under key. Values JSON cannot
+// encode (a NaN average) are simply not cached.
+func saveAggregateCache(db *storage.DB, steamID uint64, key string, v any) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return db.PutAggregateCache(steamID, key, aggregator.PipelineVersion, payload)
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+	"github.com/pable/go-cs-metrics/internal/report"
+	"github.com/pable/go-cs-metrics/internal/storage"
+)
+
+var (
+	clutchesPlayer string
+	clutchesLead   float64
+)
+
+// clutchesCmd is the cobra command listing every clutch of a match with where
+// it starts in the demo.
+var clutchesCmd = &cobra.Command{
+	Use:   "clutches <hash-prefix>",
+	Short: "List a match's clutches with start tick, opponents and a demo_gototick command",
+	Long: `List every clutch of a stored match round by round: the clutcher, the
+enemies alive when they were left alone, the round clock and demo tick of that
+moment, the result, and a demo_gototick console command that jumps --lead
+seconds before it (for reviewing or cutting highlights). Use --format csv/json
+to export the list.
+
+Demos stored before pipeline version 26 have no start tick; re-parse them
+with parse --force.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runClutches,
+}
+
+func init() {
+	clutchesCmd.Flags().StringVar(&clutchesPlayer, "player", "", "only this player's clutches: SteamID64 or name:<nickname>")
+	clutchesCmd.Flags().Float64Var(&clutchesLead, "lead", 5, "seconds before the clutch start that demo_gototick jumps to")
+}
+
+// runClutches loads a demo's round stats and prints its clutches.
+func runClutches(cmd *cobra.Command, args []string) error {
+	if err := validateFocusPlayer(clutchesPlayer); err != nil {
+		return err
+	}
+	if clutchesLead < 0 {
+		return fmt.Errorf("--lead must be ≥ 0, got %g", clutchesLead)
+	}
+
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
+	}
+	defer db.Close()
+
+	demo, err := db.GetDemoByPrefix(args[0])
+	if err != nil {
+		return fmt.Errorf("query demo: %w", err)
+	}
+	if demo == nil {
+		return noDataError("no demo found with hash prefix %q", args[0])
+	}
+
+	matchStats, err := db.GetPlayerMatchStats(demo.DemoHash)
+	if err != nil {
+		return fmt.Errorf("get match stats: %w", err)
+	}
+	focus, err := resolveFocusPlayer(clutchesPlayer, matchStats)
+	if err != nil {
+		return err
+	}
+	names := make(map[uint64]string, len(matchStats))
+	for _, ms := range matchStats {
+		names[ms.SteamID] = ms.Name
+	}
+
+	byRound, err := db.GetAllRoundStats(demo.DemoHash)
+	if err != nil {
+		return fmt.Errorf("get round stats: %w", err)
+	}
+	var clutches []model.PlayerRoundStats
+	for _, rs := range byRound {
+		for _, s := range rs {
+			if s.IsInClutch && (focus == 0 || s.SteamID == focus) {
+				clutches = append(clutches, s)
+			}
+		}
+	}
+	sort.Slice(clutches, func(i, j int) bool {
+		a, b := clutches[i], clutches[j]
+		if a.RoundNumber != b.RoundNumber {
+			return a.RoundNumber < b.RoundNumber
+		}
+		return a.ClutchStartTick < b.ClutchStartTick
+	})
+
+	report.SetDataVersion(demo.PipelineVersion)
+	report.PrintClutchTimelineTable(os.Stdout, clutches, names, demo.MapName, demo.Tickrate, clutchesLead)
+	return nil
+}
@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+	"github.com/pable/go-cs-metrics/internal/report"
+	"github.com/pable/go-cs-metrics/internal/storage"
+)
+
+var (
+	dashboardInterval time.Duration
+	dashboardLast     int
+)
+
+// dashboardCmd is the cobra command drawing a full-screen, self-refreshing
+// view of one player.
+var dashboardCmd = &cobra.Command{
+	Use:   "dashboard <steamid64>",
+	Short: "Full-screen live dashboard for one player, redrawn when the database changes",
+	Long: `Draw a full-screen terminal dashboard for one player: aggregate cards
+(rating, K/D, ADR, KAST%, HS%, FHHS%, entries, clutches), rating and ADR
+sparklines, an FHHS heat-grid by weapon and distance, and the most recent
+matches. The database file is checked every --interval and the screen is
+redrawn when it changes, so a parse running in another terminal shows up
+without restarting. Press q (or Ctrl-C) to quit, r to reload now.
+
+When stdout is not a terminal a single frame is printed and the command exits.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDashboard,
+}
+
+func init() {
+	dashboardCmd.Flags().DurationVar(&dashboardInterval, "interval", 2*time.Second, "how often to check the database for changes")
+	dashboardCmd.Flags().IntVar(&dashboardLast, "last", 0, "only use the N most recent matches")
+}
+
+// runDashboard loads the player's data and draws it, once or until quit.
+func runDashboard(cmd *cobra.Command, args []string) error {
+	steamID, err := resolveSteamID(args[0])
+	if err != nil {
+		return err
+	}
+	if dashboardInterval < 100*time.Millisecond {
+		return fmt.Errorf("--interval must be at least 100ms, got %s", dashboardInterval)
+	}
+
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
+	}
+	defer db.Close()
+
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		d, err := loadDashboard(db, steamID)
+		if err != nil {
+			return err
+		}
+		width := tableWidth
+		if width <= 0 {
+			width = 100
+		}
+		fmt.Println(report.RenderDashboard(d, width, 0))
+		return nil
+	}
+	return dashboardLoop(db, steamID)
+}
+
+// dashboardModel is the bubbletea model behind the interactive dashboard.
+// Bubbletea owns the terminal (raw mode, alternate screen, key reading and
+// restore on exit); the model only reloads and renders.
+type dashboardModel struct {
+	db      *storage.DB
+	steamID uint64
+	d       report.Dashboard
+	loadErr error
+	stamp   string
+	w, h    int
+}
+
+// dashboardTickMsg asks the model to check the database for changes.
+type dashboardTickMsg time.Time
+
+// dashboardTick schedules the next database check after --interval.
+func dashboardTick() tea.Cmd {
+	return tea.Tick(dashboardInterval, func(t time.Time) tea.Msg { return dashboardTickMsg(t) })
+}
+
+// reload re-reads the player's data and remembers the database stamp it saw.
+func (m *dashboardModel) reload() {
+	m.stamp = dbStamp(dbPath)
+	m.d, m.loadErr = loadDashboard(m.db, m.steamID)
+}
+
+// Init starts the change-detection ticker.
+func (m *dashboardModel) Init() tea.Cmd {
+	return dashboardTick()
+}
+
+// Update reloads on database changes and r, tracks resizes and quits on q or Ctrl-C.
+func (m *dashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "Q", "ctrl+c":
+			return m, tea.Quit
+		case "r", "R":
+			m.reload()
+		}
+	case tea.WindowSizeMsg:
+		m.w, m.h = msg.Width, msg.Height
+	case dashboardTickMsg:
+		if dbStamp(dbPath) != m.stamp {
+			m.reload()
+		}
+		return m, dashboardTick()
+	}
+	return m, nil
+}
+
+// View renders the current frame at the last reported terminal size.
+func (m *dashboardModel) View() string {
+	if m.loadErr != nil {
+		return fmt.Sprintf(" dashboard: %v\n (q to quit)", m.loadErr)
+	}
+	w, h := m.w, m.h
+	if w <= 0 || h <= 0 {
+		w, h = 100, 40
+	}
+	return report.RenderDashboard(m.d, w, h)
+}
+
+// dashboardLoop runs the dashboard full-screen until the user quits.
+func dashboardLoop(db *storage.DB, steamID uint64) error {
+	m := &dashboardModel{db: db, steamID: steamID}
+	m.reload()
+	if _, err := tea.NewProgram(m, tea.WithAltScreen()).Run(); err != nil {
+		return fmt.Errorf("dashboard: %w", err)
+	}
+	return nil
+}
+
+// dbStamp fingerprints the database and its write-ahead log by size and
+// modification time; commits from other processes land in the WAL first.
+func dbStamp(path string) string {
+	var b strings.Builder
+	for _, p := range []string{path, path + "-wal"} {
+		if fi, err := os.Stat(p); err == nil {
+			fmt.Fprintf(&b, "%d:%d;", fi.Size(), fi.ModTime().UnixNano())
+		}
+	}
+	return b.String()
+}
+
+// loadDashboard reads one player's matches (limited by --last), merged duel
+// segments and clutch totals into a dashboard frame.
+func loadDashboard(db *storage.DB, steamID uint64) (report.Dashboard, error) {
+	d := report.Dashboard{Updated: time.Now()}
+	stats, err := db.GetAllPlayerMatchStats(steamID)
+	if err != nil {
+		return d, fmt.Errorf("query stats: %w", err)
+	}
+	stats = filterStats(stats, "", "", dashboardLast)
+	if len(stats) == 0 {
+		d.Agg = model.PlayerAggregate{SteamID: steamID}
+		return d, nil
+	}
+	keep := make(map[string]struct{}, len(stats))
+	for _, s := range stats {
+		keep[s.DemoHash] = struct{}{}
+	}
+
+	segs, err := db.GetAllPlayerDuelSegments(steamID)
+	if err != nil {
+		return d, fmt.Errorf("query segments: %w", err)
+	}
+	var kept []model.PlayerDuelSegment
+	for _, s := range segs {
+		if _, ok := keep[s.DemoHash]; ok {
+			kept = append(kept, s)
+		}
+	}
+
+	clutchByMatch, err := db.GetPlayerClutchStatsByMatch(steamID)
+	if err != nil {
+		return d, fmt.Errorf("query clutch stats: %w", err)
+	}
+	d.Clutch.SteamID = steamID
+	for hash, c := range clutchByMatch {
+		if _, ok := keep[hash]; !ok {
+			continue
+		}
+		for i := 1; i <= 5; i++ {
+			d.Clutch.Attempts[i] += c.Attempts[i]
+			d.Clutch.Wins[i] += c.Wins[i]
+		}
+	}
+
+	d.Agg = buildAggregate(stats)
+	d.Matches = stats
+	d.Segs = mergeSegments(steamID, kept)
+	return d, nil
+}
@@ -0,0 +1,346 @@
+package cmd
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/spf13/cobra"
+
+	"github.com/pable/go-cs-metrics/internal/storage"
+)
+
+// archiveDBName is the name of the database entry inside a db export archive.
+const archiveDBName = "metrics.db"
+
+// dbExportOut is the archive path written by "db export", set via --out.
+var dbExportOut string
+
+// dbCmd groups database maintenance subcommands (path, export, import, merge,
+// cache, soft delete, the audit log and storage flags) and the database-wide
+// weapons report. Run alone it prints the storage overview.
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Database maintenance and corpus reports: path, export, import, merge, delete, restore, audit, weapons, storage",
+	Long: `Run alone, print the database and config paths and the size of each
+optional dataset (positions, duel distances, kill states, timeline events,
+first sights): whether it is stored for new demos, its rows, its share of the
+database file and the size it adds per demo. Switch datasets with
+"db storage <feature> on|off". The subcommands maintain the database and
+report on the whole corpus.`,
+	Args: cobra.NoArgs,
+	RunE: runDBOverview,
+}
+
+// dbPathCmd prints the resolved metrics database path.
+var dbPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Print the metrics database path",
+	Long: `Print the path of the metrics database used by every command: --db when
+given, otherwise the platform data directory ($XDG_DATA_HOME/csmetrics or
+~/.local/share/csmetrics on Linux, ~/Library/Application Support/csmetrics on
+macOS, %APPDATA%\csmetrics on Windows). A database found at the old default,
+~/.csmetrics/metrics.db, is moved there on first use.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(os.Stdout, dbPath)
+		return nil
+	},
+}
+
+// dbExportCmd writes a compressed snapshot of the metrics database.
+var dbExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the metrics database to a .tar.zst archive",
+	Long: `Write a consistent snapshot of the metrics database (all tables) into a
+zstd-compressed tar archive. The archive can be restored or merged on another
+machine with "db import".`,
+	Args: cobra.NoArgs,
+	RunE: runDBExport,
+}
+
+// dbImportCmd merges an archive or another csmetrics SQLite file into the
+// current database.
+var dbImportCmd = &cobra.Command{
+	Use:   "import <backup.tar.zst | other.db>",
+	Short: "Import demos from a db export archive or another csmetrics database",
+	Long: `Merge every demo from a "db export" archive or a plain csmetrics SQLite
+file into the current database. Demos are deduplicated by hash: demos already
+stored here are skipped, new demos are copied together with all their stats rows.
+Conflicting copies are resolved as in "db merge". Importing into an empty (or
+missing) database restores the backup in full.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDBImport,
+}
+
+// dbMergeCmd pools another csmetrics SQLite file into the current database and
+// reports per-demo conflicts.
+var dbMergeCmd = &cobra.Command{
+	Use:   "merge <other.db>",
+	Short: "Merge a teammate's csmetrics database, reporting conflicts",
+	Long: `Insert every demo from another csmetrics SQLite file that is missing here,
+together with its stats. Demos stored in both databases with identical stats are
+skipped. Demos whose stats differ are listed as conflicts; the copy aggregated by
+the newer pipeline version is kept (the local copy wins ties).`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDBMerge,
+}
+
+// dbClearCacheCmd empties the player aggregate cache.
+var dbClearCacheCmd = &cobra.Command{
+	Use:   "clear-cache",
+	Short: "Drop every cached player and analyze player report",
+	Long: `Empty player_aggregate_cache. "player" and "analyze player" cache what they
+compute per player and filter set, and a player's entries are dropped
+automatically whenever a demo with them is stored, re-parsed, merged, deleted
+or restored. Clearing is only needed to reclaim space, or after editing the
+database by hand outside player_match_stats.`,
+	Args: cobra.NoArgs,
+	RunE: runDBClearCache,
+}
+
+func init() {
+	dbExportCmd.Flags().StringVar(&dbExportOut, "out", "", "output archive path (default: csmetrics-<date>.tar.zst)")
+
+	dbCmd.AddCommand(dbPathCmd)
+	dbCmd.AddCommand(dbExportCmd)
+	dbCmd.AddCommand(dbImportCmd)
+	dbCmd.AddCommand(dbMergeCmd)
+	dbCmd.AddCommand(dbClearCacheCmd)
+	dbCmd.AddCommand(dbWeaponsCmd)
+}
+
+func runDBClearCache(cmd *cobra.Command, args []string) error {
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
+	}
+	defer db.Close()
+
+	n, err := db.ClearAggregateCache()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "Cleared %d cached report(s)\n", n)
+	return nil
+}
+
+func runDBExport(cmd *cobra.Command, args []string) error {
+	out := dbExportOut
+	if out == "" {
+		out = fmt.Sprintf("csmetrics-%s.tar.zst", time.Now().Format("2006-01-02"))
+	}
+
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
+	}
+	defer db.Close()
+
+	tmpDir, err := os.MkdirTemp("", "csmetrics-export-*")
+	if err != nil {
+		return fmt.Errorf("temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	snapPath := filepath.Join(tmpDir, archiveDBName)
+	if err := db.Snapshot(snapPath); err != nil {
+		return err
+	}
+	if err := writeDBArchive(out, snapPath); err != nil {
+		return err
+	}
+
+	demos, err := db.ListDemos(storage.DemoFilter{})
+	if err != nil {
+		return fmt.Errorf("list demos: %w", err)
+	}
+	fmt.Fprintf(os.Stdout, "Exported %d demo(s) to %s\n", len(demos), out)
+	return nil
+}
+
+func runDBImport(cmd *cobra.Command, args []string) error {
+	src := args[0]
+	if _, err := os.Stat(src); err != nil {
+		return fmt.Errorf("import source: %w", err)
+	}
+
+	if strings.HasSuffix(src, ".zst") {
+		tmpDir, err := os.MkdirTemp("", "csmetrics-import-*")
+		if err != nil {
+			return fmt.Errorf("temp dir: %w", err)
+		}
+		defer os.RemoveAll(tmpDir)
+
+		extracted, err := extractDBArchive(src, tmpDir)
+		if err != nil {
+			return err
+		}
+		src = extracted
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return fmt.Errorf("create db dir: %w", err)
+	}
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
+	}
+	defer db.Close()
+
+	res, err := db.MergeFrom(src)
+	if err != nil {
+		return fmt.Errorf("merge %s: %w", args[0], err)
+	}
+	fmt.Fprintf(os.Stdout, "Imported %d demo(s) (%d stats rows); skipped %d already stored\n",
+		res.DemosInserted, res.RowsInserted, res.DemosSkipped)
+	if len(res.Conflicts) > 0 {
+		fmt.Fprintf(os.Stdout, "%d conflict(s) resolved (%d replaced by newer pipeline version); run 'db merge' for details\n",
+			len(res.Conflicts), res.DemosReplaced)
+	}
+	return nil
+}
+
+func runDBMerge(cmd *cobra.Command, args []string) error {
+	if _, err := os.Stat(args[0]); err != nil {
+		return fmt.Errorf("merge source: %w", err)
+	}
+
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
+	}
+	defer db.Close()
+
+	res, err := db.MergeFrom(args[0])
+	if err != nil {
+		return fmt.Errorf("merge %s: %w", args[0], err)
+	}
+
+	fmt.Fprintf(os.Stdout, "Inserted : %d demo(s)\n", res.DemosInserted)
+	fmt.Fprintf(os.Stdout, "Replaced : %d demo(s)\n", res.DemosReplaced)
+	fmt.Fprintf(os.Stdout, "Skipped  : %d demo(s)\n", res.DemosSkipped)
+	fmt.Fprintf(os.Stdout, "Rows     : %d stats row(s) copied\n", res.RowsInserted)
+
+	if len(res.Conflicts) == 0 {
+		return nil
+	}
+	fmt.Fprintf(os.Stdout, "\n%d conflict(s) — same demo, different stats:\n\n", len(res.Conflicts))
+	fmt.Fprintf(os.Stdout, "%-14s  %-12s  %-10s  %5s  %5s  %s\n",
+		"HASH", "MAP", "DATE", "LOCAL", "OTHER", "KEPT")
+	fmt.Fprintf(os.Stdout, "%-14s  %-12s  %-10s  %5s  %5s  %s\n",
+		"──────────────", "────────────", "──────────", "─────", "─────", "─────")
+	for _, c := range res.Conflicts {
+		kept := "local"
+		if c.TookSource {
+			kept = "other"
+		}
+		fmt.Fprintf(os.Stdout, "%-14s  %-12s  %-10s  %5s  %5s  %s\n",
+			c.Hash[:12], c.MapName, c.MatchDate, pipelineVersionLabel(c.LocalVersion), pipelineVersionLabel(c.SourceVersion), kept)
+	}
+	return nil
+}
+
+// pipelineVersionLabel formats a stored pipeline version, rendering 0
+// (stored before versioning) as "—".
+func pipelineVersionLabel(v int) string {
+	if v == 0 {
+		return "—"
+	}
+	return fmt.Sprintf("v%d", v)
+}
+
+// writeDBArchive packs the SQLite file at dbFile into a zstd-compressed tar
+// archive at out, under the entry name archiveDBName.
+func writeDBArchive(out, dbFile string) error {
+	in, err := os.Open(dbFile)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(out)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", out, err)
+	}
+	defer f.Close()
+
+	enc, err := zstd.NewWriter(f)
+	if err != nil {
+		return fmt.Errorf("zstd: %w", err)
+	}
+	tw := tar.NewWriter(enc)
+
+	hdr := &tar.Header{
+		Name:    archiveDBName,
+		Mode:    0644,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("tar header: %w", err)
+	}
+	if _, err := io.Copy(tw, in); err != nil {
+		return fmt.Errorf("write archive: %w", err)
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("close tar: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("close zstd: %w", err)
+	}
+	return f.Close()
+}
+
+// extractDBArchive unpacks the database entry of a db export archive into dir
+// and returns the path of the extracted file.
+func extractDBArchive(archive, dir string) (string, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	dec, err := zstd.NewReader(f)
+	if err != nil {
+		return "", fmt.Errorf("zstd: %w", err)
+	}
+	defer dec.Close()
+
+	tr := tar.NewReader(dec)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return "", fmt.Errorf("%s: no %s entry in archive", archive, archiveDBName)
+		}
+		if err != nil {
+			return "", fmt.Errorf("read archive: %w", err)
+		}
+		if hdr.Name != archiveDBName {
+			continue
+		}
+
+		outPath := filepath.Join(dir, archiveDBName)
+		out, err := os.Create(outPath)
+		if err != nil {
+			return "", err
+		}
+		if _, err := io.Copy(out, tr); err != nil {
+			out.Close()
+			return "", fmt.Errorf("extract: %w", err)
+		}
+		if err := out.Close(); err != nil {
+			return "", err
+		}
+		return outPath, nil
+	}
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pable/go-cs-metrics/internal/config"
+	"github.com/pable/go-cs-metrics/internal/model"
+	"github.com/pable/go-cs-metrics/internal/report"
+	"github.com/pable/go-cs-metrics/internal/storage"
+)
+
+// dbStorageCmd switches an optional dataset on or off in the config file.
+var dbStorageCmd = &cobra.Command{
+	Use:   "storage <feature> on|off",
+	Short: "Switch storage of an optional dataset on or off",
+	Long: `Choose which optional, high-volume datasets parse and baseline store for
+each demo. The setting is written to the config file (--config) and applies
+to demos parsed afterwards; re-parse with parse --force to drop or fill in the
+rows of demos already stored. Run "csmetrics db" to see each dataset's size.
+
+Features (default):
+` + storageFeatureList() + `
+Commands that read a switched-off dataset show it as missing for new demos.
+
+Example:
+  csmetrics db storage positions off
+  csmetrics db storage first_sights on`,
+	Args: cobra.ExactArgs(2),
+	RunE: runDBStorage,
+}
+
+func init() {
+	dbCmd.AddCommand(dbStorageCmd)
+}
+
+// storageFeatureList formats config.Features for the db storage help.
+func storageFeatureList() string {
+	var b strings.Builder
+	for _, f := range config.Features {
+		def := "off"
+		if f.Default {
+			def = "on"
+		}
+		fmt.Fprintf(&b, "  %-15s (%s) %s\n", f.Name, def, f.Summary)
+	}
+	return b.String()
+}
+
+func runDBStorage(cmd *cobra.Command, args []string) error {
+	name, state := args[0], strings.ToLower(args[1])
+	if state != "on" && state != "off" {
+		return withExitCode(ExitUsage, fmt.Errorf("invalid state %q: want on or off", args[1]))
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if err := cfg.SetStore(name, state == "on"); err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+	if err := cfg.Save(configPath); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "Storage of %s is %s for newly parsed demos (%s)\n", name, state, configPath)
+	return nil
+}
+
+// runDBOverview prints the database path and size and the storage used by
+// each optional dataset, so depth can be weighed against database size.
+func runDBOverview(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
+	}
+	defer db.Close()
+
+	ov, err := db.GetDBOverview()
+	if err != nil {
+		return fmt.Errorf("get overview: %w", err)
+	}
+	fileBytes, err := db.SizeBytes()
+	if err != nil {
+		return fmt.Errorf("database size: %w", err)
+	}
+	rows := make([]model.DatasetFootprint, 0, len(config.Features))
+	for _, f := range config.Features {
+		fp, err := db.TableFootprint(f.Table)
+		if err != nil {
+			return fmt.Errorf("dataset %s: %w", f.Name, err)
+		}
+		fp.Feature = f.Name
+		fp.Summary = f.Summary
+		fp.Stored = cfg.Stores(f.Name)
+		rows = append(rows, fp)
+	}
+
+	fmt.Fprintf(os.Stdout, "Database: %s\n", dbPath)
+	fmt.Fprintf(os.Stdout, "Config:   %s\n", configPath)
+	report.PrintStorageFootprintTable(os.Stdout, rows, ov.TotalMatches, fileBytes)
+	return nil
+}
@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pable/go-cs-metrics/internal/storage"
+)
+
+var (
+	dbPurgeOlderThan int
+	dbPurgeAll       bool
+	dbPurgeForce     bool
+	dbAuditDemo      string
+	dbAuditLimit     int
+)
+
+// dbDeleteCmd moves stored demos to the trash.
+var dbDeleteCmd = &cobra.Command{
+	Use:   "delete <hash-prefix>...",
+	Short: "Move demos to the trash (restorable with db restore)",
+	Long: `Soft-delete one or more demos by hash prefix. The demo row and all its stats
+rows are moved to the trash in one transaction, so every report stops counting
+the demo at once. "db restore" puts it back; "db purge" drops it for good.
+Every delete is recorded in the audit log ("db audit").`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runDBDelete,
+}
+
+// dbRestoreCmd moves a trashed demo back into the live tables.
+var dbRestoreCmd = &cobra.Command{
+	Use:   "restore <hash-prefix>",
+	Short: "Restore a demo from the trash",
+	Long: `Restore a soft-deleted demo and all its stats rows. Columns added by newer
+builds since the delete take their defaults; re-parse with parse --force to
+fill them. Restoring fails if the same demo has been parsed or merged again
+since it was deleted.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDBRestore,
+}
+
+// dbTrashCmd lists the trash.
+var dbTrashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "List soft-deleted demos",
+	Args:  cobra.NoArgs,
+	RunE:  runDBTrash,
+}
+
+// dbPurgeCmd permanently drops old trash entries.
+var dbPurgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Permanently drop trashed demos",
+	Long: `Permanently drop demos that have been in the trash for longer than
+--older-than days (or every trashed demo with --all). Purged demos cannot be
+restored. Requires --force.`,
+	Args: cobra.NoArgs,
+	RunE: runDBPurge,
+}
+
+// dbAuditCmd prints the audit log.
+var dbAuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Show the audit log of demo inserts, replaces, re-tags and deletes",
+	Long: `Print the most recent demo-level writes, newest first: inserts and
+re-parses (with the pipeline version written), re-tags of match type, tier,
+event or baseline flag, merges, deletes, restores and purges. Each entry
+records the time (UTC), the OS user that made the change and the demo's
+pipeline version.
+
+Example:
+  csmetrics db audit --limit 20
+  csmetrics db audit --demo 3fa9c1`,
+	Args: cobra.NoArgs,
+	RunE: runDBAudit,
+}
+
+func init() {
+	dbPurgeCmd.Flags().IntVar(&dbPurgeOlderThan, "older-than", 30, "only demos deleted more than this many days ago")
+	dbPurgeCmd.Flags().BoolVar(&dbPurgeAll, "all", false, "purge every trashed demo regardless of age")
+	dbPurgeCmd.Flags().BoolVarP(&dbPurgeForce, "force", "f", false, "confirm the permanent purge")
+	dbAuditCmd.Flags().StringVar(&dbAuditDemo, "demo", "", "only entries for demos with this hash prefix")
+	dbAuditCmd.Flags().IntVar(&dbAuditLimit, "limit", 50, "most recent entries to show (0 = all)")
+
+	dbCmd.AddCommand(dbDeleteCmd)
+	dbCmd.AddCommand(dbRestoreCmd)
+	dbCmd.AddCommand(dbTrashCmd)
+	dbCmd.AddCommand(dbPurgeCmd)
+	dbCmd.AddCommand(dbAuditCmd)
+}
+
+func runDBDelete(cmd *cobra.Command, args []string) error {
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
+	}
+	defer db.Close()
+
+	for _, prefix := range args {
+		demo, err := db.GetDemoByPrefix(prefix)
+		if err != nil {
+			return fmt.Errorf("get demo: %w", err)
+		}
+		if demo == nil {
+			return noDataError("no demo found with hash prefix %q", prefix)
+		}
+		t, err := db.DeleteDemo(demo.DemoHash)
+		if err != nil {
+			return fmt.Errorf("delete %s: %w", prefix, err)
+		}
+		fmt.Fprintf(os.Stdout, "Trashed %.12s  %s  %s  (%d stats rows)\n", t.DemoHash, t.MapName, t.MatchDate, t.Rows)
+	}
+	fmt.Fprintln(os.Stdout, "Restore with 'csmetrics db restore <hash-prefix>'.")
+	return nil
+}
+
+func runDBRestore(cmd *cobra.Command, args []string) error {
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
+	}
+	defer db.Close()
+
+	trashed, err := db.GetTrashedByPrefix(args[0])
+	if err != nil {
+		return fmt.Errorf("get trashed demo: %w", err)
+	}
+	if trashed == nil {
+		return noDataError("no trashed demo found with hash prefix %q", args[0])
+	}
+	t, err := db.RestoreDemo(trashed.DemoHash)
+	if err != nil {
+		return fmt.Errorf("restore %s: %w", args[0], err)
+	}
+	fmt.Fprintf(os.Stdout, "Restored %.12s  %s  %s  (%d stats rows, deleted %s by %s)\n",
+		t.DemoHash, t.MapName, t.MatchDate, t.Rows, t.DeletedAt, t.DeletedBy)
+	return nil
+}
+
+func runDBTrash(cmd *cobra.Command, args []string) error {
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
+	}
+	defer db.Close()
+
+	list, err := db.ListTrash()
+	if err != nil {
+		return fmt.Errorf("list trash: %w", err)
+	}
+	if len(list) == 0 {
+		fmt.Fprintln(os.Stdout, "Trash is empty.")
+		return nil
+	}
+	fmt.Fprintf(os.Stdout, "%-12s  %-12s  %-10s  %5s  %6s  %-20s  %s\n",
+		"HASH", "MAP", "DATE", "VER", "ROWS", "DELETED", "BY")
+	fmt.Fprintf(os.Stdout, "%-12s  %-12s  %-10s  %5s  %6s  %-20s  %s\n",
+		"────────────", "────────────", "──────────", "─────", "──────", "────────────────────", "──")
+	for _, t := range list {
+		fmt.Fprintf(os.Stdout, "%-12.12s  %-12s  %-10s  %5s  %6d  %-20s  %s\n",
+			t.DemoHash, t.MapName, t.MatchDate, pipelineVersionLabel(t.PipelineVersion), t.Rows, t.DeletedAt, t.DeletedBy)
+	}
+	return nil
+}
+
+func runDBPurge(cmd *cobra.Command, args []string) error {
+	var cutoff time.Time
+	if !dbPurgeAll {
+		if dbPurgeOlderThan < 0 {
+			return withExitCode(ExitUsage, fmt.Errorf("--older-than must be >= 0"))
+		}
+		cutoff = time.Now().AddDate(0, 0, -dbPurgeOlderThan)
+	}
+
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
+	}
+	defer db.Close()
+
+	if !dbPurgeForce {
+		list, err := db.ListTrash()
+		if err != nil {
+			return fmt.Errorf("list trash: %w", err)
+		}
+		n := 0
+		for _, t := range list {
+			at, err := time.Parse(time.RFC3339, t.DeletedAt)
+			if cutoff.IsZero() || err != nil || at.Before(cutoff) {
+				n++
+			}
+		}
+		fmt.Fprintf(os.Stderr, "This will permanently drop %d of %d trashed demo(s).\n", n, len(list))
+		fmt.Fprintf(os.Stderr, "Re-run with --force to confirm.\n")
+		return nil
+	}
+
+	purged, err := db.PurgeTrash(cutoff)
+	if err != nil {
+		return fmt.Errorf("purge trash: %w", err)
+	}
+	fmt.Fprintf(os.Stdout, "Purged %d demo(s) from the trash\n", len(purged))
+	return nil
+}
+
+func runDBAudit(cmd *cobra.Command, args []string) error {
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
+	}
+	defer db.Close()
+
+	entries, err := db.GetAuditLog(dbAuditDemo, dbAuditLimit)
+	if err != nil {
+		return fmt.Errorf("audit log: %w", err)
+	}
+	if len(entries) == 0 {
+		return noDataError("no audit entries recorded")
+	}
+	fmt.Fprintf(os.Stdout, "%-20s  %-7s  %-12s  %5s  %-10s  %s\n",
+		"TIME", "ACTION", "HASH", "VER", "BY", "DETAIL")
+	fmt.Fprintf(os.Stdout, "%-20s  %-7s  %-12s  %5s  %-10s  %s\n",
+		"────────────────────", "───────", "────────────", "─────", "──────────", "──────")
+	for _, e := range entries {
+		fmt.Fprintf(os.Stdout, "%-20s  %-7s  %-12.12s  %5s  %-10s  %s\n",
+			e.At, e.Action, e.DemoHash, pipelineVersionLabel(e.PipelineVersion), e.Actor, e.Detail)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+	"github.com/pable/go-cs-metrics/internal/report"
+	"github.com/pable/go-cs-metrics/internal/storage"
+)
+
+var (
+	dbWeaponsMap        string
+	dbWeaponsSince      string
+	dbWeaponsType       string
+	dbWeaponsTier       string
+	dbWeaponsBaseline   bool
+	dbWeaponsMinKills   int
+	dbWeaponsTop        int
+	dbWeaponsWindows    int
+	dbWeaponsWindowDays int
+)
+
+var dbWeaponsCmd = &cobra.Command{
+	Use:   "weapons",
+	Short: "Weapon meta across all stored demos: kills, HS%, kill distance and trend",
+	Long: `Summarize weapon usage and effectiveness over every stored demo (or those
+matching the filters): kills, share of all kills, headshot %, mean kill
+distance, damage per hit and the demos a weapon was used in, over every
+player. Use --baseline or --tier to read the meta of a reference corpus.
+
+A trend table follows: each top weapon's share of kills in --windows periods
+of --window-days, ending at the latest match date in scope.
+
+Kill distance is stored from pipeline version 33; older demos count toward
+every column except AVG_DIST until re-parsed with parse --force.
+
+Example:
+  csmetrics db weapons --baseline --tier faceit-8
+  csmetrics db weapons --map mirage --windows 6 --window-days 30 --format csv`,
+	Args: cobra.NoArgs,
+	RunE: runDBWeapons,
+}
+
+func init() {
+	dbWeaponsCmd.Flags().StringVar(&dbWeaponsMap, "map", "", "only demos on this map")
+	dbWeaponsCmd.Flags().StringVar(&dbWeaponsSince, "since", "", "only demos on or after this date (YYYY-MM-DD)")
+	dbWeaponsCmd.Flags().StringVar(&dbWeaponsType, "type", "", "only demos of this match type")
+	dbWeaponsCmd.Flags().StringVar(&dbWeaponsTier, "tier", "", "only demos with this tier label")
+	dbWeaponsCmd.Flags().BoolVar(&dbWeaponsBaseline, "baseline", false, "only baseline (reference corpus) demos")
+	dbWeaponsCmd.Flags().IntVar(&dbWeaponsMinKills, "min-kills", 20, "hide weapons with fewer kills")
+	dbWeaponsCmd.Flags().IntVar(&dbWeaponsTop, "top", 10, "weapons in the trend table, by kills")
+	dbWeaponsCmd.Flags().IntVar(&dbWeaponsWindows, "windows", 4, "trend periods (0 = no trend table)")
+	dbWeaponsCmd.Flags().IntVar(&dbWeaponsWindowDays, "window-days", 90, "days per trend period")
+}
+
+func runDBWeapons(cmd *cobra.Command, args []string) error {
+	if dbWeaponsSince != "" {
+		if _, err := time.Parse("2006-01-02", dbWeaponsSince); err != nil {
+			return withExitCode(ExitUsage, fmt.Errorf("invalid --since %q: want YYYY-MM-DD", dbWeaponsSince))
+		}
+	}
+	if dbWeaponsWindows < 0 || dbWeaponsWindowDays < 1 {
+		return withExitCode(ExitUsage, fmt.Errorf("--windows must be ≥ 0 and --window-days ≥ 1"))
+	}
+
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
+	}
+	defer db.Close()
+
+	filter := storage.DemoFilter{
+		Map:      dbWeaponsMap,
+		Since:    dbWeaponsSince,
+		Type:     dbWeaponsType,
+		Tier:     dbWeaponsTier,
+		Baseline: dbWeaponsBaseline,
+	}
+	demos, err := db.ListDemos(filter)
+	if err != nil {
+		return fmt.Errorf("list demos: %w", err)
+	}
+	rows, err := db.GetWeaponMeta(filter)
+	if err != nil {
+		return fmt.Errorf("weapon meta: %w", err)
+	}
+	if len(rows) == 0 {
+		return noDataError("no weapon stats stored for the selected demos")
+	}
+
+	totals := foldWeaponMeta(rows)
+	report.PrintWeaponMetaTable(os.Stdout, weaponMetaScope(filter), totals, len(demos), dbWeaponsMinKills)
+	if dbWeaponsWindows == 0 {
+		return nil
+	}
+	latest := rows[0].MatchDate
+	for _, r := range rows {
+		latest = max(latest, r.MatchDate)
+	}
+	windows, err := weaponMetaWindows(rows, latest, dbWeaponsWindows, dbWeaponsWindowDays)
+	if err != nil {
+		return err
+	}
+	var top []string
+	for _, t := range totals {
+		if len(top) == dbWeaponsTop {
+			break
+		}
+		if t.Kills >= dbWeaponsMinKills {
+			top = append(top, t.Weapon)
+		}
+	}
+	fmt.Fprintln(os.Stdout)
+	report.PrintWeaponMetaTrendTable(os.Stdout, top, windows)
+	return nil
+}
+
+// foldWeaponMeta sums per-date rows into one row per weapon, ordered by
+// kills descending then weapon name.
+func foldWeaponMeta(rows []model.WeaponMetaRow) []model.WeaponMetaRow {
+	byWeapon := make(map[string]*model.WeaponMetaRow)
+	for _, r := range rows {
+		t := byWeapon[r.Weapon]
+		if t == nil {
+			t = &model.WeaponMetaRow{Weapon: r.Weapon}
+			byWeapon[r.Weapon] = t
+		}
+		t.Add(r)
+	}
+	out := make([]model.WeaponMetaRow, 0, len(byWeapon))
+	for _, t := range byWeapon {
+		out = append(out, *t)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Kills != out[j].Kills {
+			return out[i].Kills > out[j].Kills
+		}
+		return out[i].Weapon < out[j].Weapon
+	})
+	return out
+}
+
+// weaponMetaWindows splits per-date rows into n consecutive periods of days
+// each, the last ending at latest (YYYY-MM-DD), oldest first. Rows before
+// the first period are dropped.
+func weaponMetaWindows(rows []model.WeaponMetaRow, latest string, n, days int) ([]model.WeaponMetaWindow, error) {
+	end, err := time.Parse("2006-01-02", latest)
+	if err != nil {
+		return nil, fmt.Errorf("latest match date %q: %w", latest, err)
+	}
+	windows := make([]model.WeaponMetaWindow, n)
+	for i := range windows {
+		to := end.AddDate(0, 0, -days*(n-1-i))
+		windows[i] = model.WeaponMetaWindow{
+			From:    to.AddDate(0, 0, -days+1).Format("2006-01-02"),
+			To:      to.Format("2006-01-02"),
+			Weapons: make(map[string]model.WeaponMetaRow),
+		}
+	}
+	for _, r := range rows {
+		for i := range windows {
+			win := &windows[i]
+			if r.MatchDate < win.From || r.MatchDate > win.To {
+				continue
+			}
+			t := win.Weapons[r.Weapon]
+			t.Weapon = r.Weapon
+			t.Add(r)
+			win.Weapons[r.Weapon] = t
+			win.Kills += r.Kills
+			break
+		}
+	}
+	return windows, nil
+}
+
+// weaponMetaScope describes f's non-empty filters for the report title.
+func weaponMetaScope(f storage.DemoFilter) string {
+	var parts []string
+	if f.Baseline {
+		parts = append(parts, "baseline")
+	}
+	if f.Tier != "" {
+		parts = append(parts, "tier "+f.Tier)
+	}
+	if f.Map != "" {
+		parts = append(parts, f.Map)
+	}
+	if f.Type != "" {
+		parts = append(parts, f.Type)
+	}
+	if f.Since != "" {
+		parts = append(parts, "since "+f.Since)
+	}
+	return strings.Join(parts, ", ")
+}
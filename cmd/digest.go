@@ -0,0 +1,365 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pable/go-cs-metrics/internal/aggregator"
+	"github.com/pable/go-cs-metrics/internal/model"
+	"github.com/pable/go-cs-metrics/internal/storage"
+)
+
+var (
+	digestSince      string
+	digestPlayer     string
+	digestOut        string
+	digestWeak       int
+	digestHighlights int
+	digestMinDuels   int
+	digestGap        float64
+	digestAI         bool
+	digestModel      string
+	digestAPIKey     string
+)
+
+// digestCmd is the cobra command that writes a markdown summary of a player's recent matches.
+var digestCmd = &cobra.Command{
+	Use:   "digest --player <id> [--since 7d] [--out digest.md]",
+	Short: "Markdown digest of a player's recent matches for a team channel",
+	Long: `Compose a markdown summary of a player's matches since --since (7d = the
+last seven days, 2w = two weeks, or a YYYY-MM-DD date), ready to paste into a
+team channel:
+
+  - matches played: date, map, score, K–D, ADR and rating, with the W/L/T record
+  - deltas: the period's per-match means against the same number of matches
+    before it, as in progress (★ marks a significant change)
+  - best and worst match by rating
+  - weakest duel segments (weapon × distance FHHS well below the reference) over
+    the period's demos, with a drill, as in practice-plan
+  - highlight rounds: multi-kills and won clutches, best first
+
+The digest is written to --out, or printed to stdout. With --ai it is
+rewritten by the Anthropic API for reading rather than scanning; without a
+key or on error the deterministic digest is written instead.
+
+Example:
+  csmetrics digest --player 76561198012345678 --since 7d --out digest.md
+  csmetrics digest --player 76561198012345678 --since 2026-10-01 --ai`,
+	Args: cobra.NoArgs,
+	RunE: runDigest,
+}
+
+func init() {
+	digestCmd.Flags().StringVar(&digestSince, "since", "7d", "period start: <N>d, <N>w or YYYY-MM-DD")
+	digestCmd.Flags().StringVar(&digestPlayer, "player", "", "player SteamID64 (or any form accepted by other commands)")
+	digestCmd.Flags().StringVar(&digestOut, "out", "", "write the digest to this markdown file instead of stdout")
+	digestCmd.Flags().IntVar(&digestWeak, "weak", 3, "weak duel segments to list")
+	digestCmd.Flags().IntVar(&digestHighlights, "highlights", 5, "highlight rounds to list")
+	digestCmd.Flags().IntVar(&digestMinDuels, "min-duels", 10, "minimum duels in a segment (and first hits in its reference) to list it as weak")
+	digestCmd.Flags().Float64Var(&digestGap, "gap", 5, "minimum FHHS gap below the reference for a weak segment, in percentage points")
+	digestCmd.Flags().BoolVar(&digestAI, "ai", false, "polish the digest with the Anthropic API")
+	digestCmd.Flags().StringVar(&digestModel, "model", "claude-haiku-4-5-20251001", "Anthropic model to use with --ai")
+	digestCmd.Flags().StringVar(&digestAPIKey, "api-key", "", "Anthropic API key (falls back to $ANTHROPIC_API_KEY)")
+	_ = digestCmd.MarkFlagRequired("player")
+}
+
+// digestData is everything a digest shows for one player and period.
+type digestData struct {
+	name     string
+	from, to string                   // period: first match date included, today
+	matches  []model.PlayerMatchStats // the period's matches with rounds played, chronological
+	progress model.PlayerProgress     // the period vs the same number of matches before it
+	weak     []model.PracticeItem
+	rounds   []model.HighlightRound
+}
+
+// sinceRelative matches a relative --since value such as 7d or 2w.
+var sinceRelative = regexp.MustCompile(`^(\d+)([dw])$`)
+
+// digestFrom resolves a --since value to the first match date included,
+// counting relative periods back from now.
+func digestFrom(since string, now time.Time) (string, error) {
+	if m := sinceRelative.FindStringSubmatch(since); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		if m[2] == "w" {
+			n *= 7
+		}
+		return now.AddDate(0, 0, -n).Format("2006-01-02"), nil
+	}
+	if _, err := time.Parse("2006-01-02", since); err != nil {
+		return "", fmt.Errorf("invalid --since %q: want <N>d, <N>w or YYYY-MM-DD", since)
+	}
+	return since, nil
+}
+
+func runDigest(cmd *cobra.Command, args []string) error {
+	steamID, err := resolveSteamID(digestPlayer)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	from, err := digestFrom(digestSince, now)
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+	if digestWeak < 0 || digestHighlights < 0 {
+		return withExitCode(ExitUsage, fmt.Errorf("--weak and --highlights must be ≥ 0"))
+	}
+
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
+	}
+	defer db.Close()
+
+	d, err := buildDigest(db, steamID, from, now.Format("2006-01-02"))
+	if err != nil {
+		return err
+	}
+	md := digestMarkdown(d)
+
+	if digestAI {
+		polished, err := polishDigest(context.Background(), md)
+		if err == nil {
+			md = polished
+		} else {
+			fmt.Fprintf(os.Stderr, "warn: AI polishing failed (%v); writing the deterministic digest\n", err)
+		}
+	}
+
+	if digestOut == "" {
+		fmt.Fprint(os.Stdout, md)
+		return nil
+	}
+	if err := os.WriteFile(digestOut, []byte(md), 0644); err != nil {
+		return fmt.Errorf("write %s: %w", digestOut, err)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s (%d matches since %s)\n", digestOut, len(d.matches), from)
+	return nil
+}
+
+// buildDigest loads the player's matches from the from date on and derives
+// every digest section from them.
+func buildDigest(db *storage.DB, steamID uint64, from, to string) (digestData, error) {
+	d := digestData{name: strconv.FormatUint(steamID, 10), from: from, to: to}
+	all, err := db.GetAllPlayerMatchStats(steamID)
+	if err != nil {
+		return d, fmt.Errorf("query stats: %w", err)
+	}
+	var played []model.PlayerMatchStats
+	for _, s := range all {
+		if s.RoundsPlayed == 0 {
+			continue
+		}
+		played = append(played, s)
+		if s.MatchDate >= from {
+			d.matches = append(d.matches, s)
+		}
+	}
+	if len(d.matches) == 0 {
+		return d, noDataError("no matches found for player %d since %s", steamID, from)
+	}
+	d.name = d.matches[len(d.matches)-1].Name
+	// The period's matches are the latest ones, so Progress with a window of
+	// their count compares them with as many matches before them.
+	d.progress = aggregator.Progress(played, len(d.matches))
+
+	keep := make(map[string]struct{}, len(d.matches))
+	for _, s := range d.matches {
+		keep[s.DemoHash] = struct{}{}
+	}
+
+	if digestWeak > 0 {
+		segs, err := db.GetAllPlayerDuelSegments(steamID)
+		if err != nil {
+			return d, fmt.Errorf("get duel segments: %w", err)
+		}
+		var period []model.PlayerDuelSegment
+		for _, s := range segs {
+			if _, ok := keep[s.DemoHash]; ok {
+				period = append(period, s)
+			}
+		}
+		refs, err := db.GetSegmentReferences(steamID, digestMinDuels)
+		if err != nil {
+			return d, fmt.Errorf("get segment references: %w", err)
+		}
+		ttdmg, err := db.GetAllPlayerTimeToDamage(steamID)
+		if err != nil {
+			return d, fmt.Errorf("get time to damage: %w", err)
+		}
+		ttdmgRefs, err := db.GetTimeToDamageReferences(steamID, digestMinDuels)
+		if err != nil {
+			return d, fmt.Errorf("get time-to-damage references: %w", err)
+		}
+		// Drill minutes are not shown, so no session length is split.
+		d.weak, _ = buildPracticePlan(mergeSegments(steamID, period), refs, mergeTimeToDamage(steamID, ttdmg, keep), ttdmgRefs,
+			digestMinDuels, digestGap, digestWeak, 0)
+	}
+
+	if digestHighlights > 0 {
+		var rounds []model.PlayerRoundStats
+		byHash := make(map[string]model.PlayerMatchStats, len(d.matches))
+		for _, s := range d.matches {
+			rs, err := db.GetPlayerRoundStats(s.DemoHash, steamID)
+			if err != nil {
+				return d, fmt.Errorf("get round stats: %w", err)
+			}
+			rounds = append(rounds, rs...)
+			byHash[s.DemoHash] = s
+		}
+		d.rounds = aggregator.HighlightRounds(rounds, digestHighlights)
+		for i := range d.rounds {
+			s := byHash[d.rounds[i].DemoHash]
+			d.rounds[i].MapName, d.rounds[i].MatchDate = s.MapName, s.MatchDate
+		}
+	}
+	return d, nil
+}
+
+// digestMarkdown renders d as a markdown document.
+func digestMarkdown(d digestData) string {
+	var md strings.Builder
+	var won, lost, tied int
+	for i := range d.matches {
+		switch digestResult(&d.matches[i]) {
+		case "W":
+			won++
+		case "L":
+			lost++
+		default:
+			tied++
+		}
+	}
+	fmt.Fprintf(&md, "# Digest — %s\n\n", d.name)
+	fmt.Fprintf(&md, "_%s → %s · %d matches · %dW %dL %dT_\n", d.from, d.to, len(d.matches), won, lost, tied)
+
+	md.WriteString("\n## Matches\n\n")
+	md.WriteString("| Date | Map | Score | K–D | ADR | Rating |\n|---|---|---|---|---|---|\n")
+	for i := range d.matches {
+		s := &d.matches[i]
+		fmt.Fprintf(&md, "| %s | %s | %s %d–%d | %d–%d | %.1f | %.2f |\n",
+			s.MatchDate, mdCell(s.MapName), digestResult(s), s.RoundsWon, s.RoundsPlayed-s.RoundsWon,
+			s.Kills, s.Deaths, s.ADR(), s.Rating())
+	}
+
+	p := d.progress
+	md.WriteString("\n## Versus the matches before\n\n")
+	if p.Previous == 0 {
+		md.WriteString("No earlier matches stored to compare with.\n")
+	} else {
+		fmt.Fprintf(&md, "Per-match means of this period against the %d matches before it (%s – %s). ★ = significant (Welch's t ≥ %.0f, %d+ matches each side).\n\n",
+			p.Previous, p.PreviousFrom, p.PreviousTo, model.ProgressSignificantT, model.ProgressMinMatches)
+		md.WriteString("| Metric | Before | This period | Δ |\n|---|---|---|---|\n")
+		for _, m := range p.Metrics {
+			if m.PreviousN == 0 || m.RecentN == 0 {
+				continue
+			}
+			delta := fmt.Sprintf(strings.Replace(m.Format, "%", "%+", 1), m.Delta())
+			switch {
+			case m.Delta() == 0:
+			case m.Improved():
+				delta += " ▲"
+			default:
+				delta += " ▼"
+			}
+			if m.Significant() {
+				delta += " ★"
+			}
+			fmt.Fprintf(&md, "| %s | %s | %s | %s |\n", m.Name, fmt.Sprintf(m.Format, m.Previous), fmt.Sprintf(m.Format, m.Recent), delta)
+		}
+	}
+
+	best, worst := &d.matches[0], &d.matches[0]
+	for i := range d.matches {
+		if d.matches[i].Rating() > best.Rating() {
+			best = &d.matches[i]
+		}
+		if d.matches[i].Rating() < worst.Rating() {
+			worst = &d.matches[i]
+		}
+	}
+	md.WriteString("\n## Best and worst match\n\n")
+	fmt.Fprintf(&md, "- **Best:** %s\n", digestMatchLine(best))
+	if worst != best {
+		fmt.Fprintf(&md, "- **Worst:** %s\n", digestMatchLine(worst))
+	}
+
+	if len(d.weak) > 0 {
+		md.WriteString("\n## Weak spots\n\n")
+		md.WriteString("First-hit headshot rate by weapon and range against other players' pooled rate.\n\n")
+		md.WriteString("| Weapon | Range | Duels | FHHS% | Ref% | Drill |\n|---|---|---|---|---|---|\n")
+		for _, it := range d.weak {
+			fmt.Fprintf(&md, "| %s | %s | %d | %.1f%% | %.1f%% | %s |\n",
+				it.WeaponBucket, it.DistanceBin, it.DuelCount, it.FHHSPct, it.RefFHHSPct, mdCell(it.Drill))
+		}
+	}
+
+	if len(d.rounds) > 0 {
+		md.WriteString("\n## Highlight rounds\n\n")
+		for _, h := range d.rounds {
+			var what []string
+			if h.ClutchVs > 0 {
+				what = append(what, fmt.Sprintf("1v%d clutch won", h.ClutchVs))
+			}
+			switch {
+			case h.Kills == 1:
+				what = append(what, "1 kill")
+			case h.Kills > 1:
+				what = append(what, fmt.Sprintf("%d kills", h.Kills))
+			}
+			fmt.Fprintf(&md, "- %s %s, round %d: %s (`%s`)\n",
+				mdCell(h.MapName), h.MatchDate, h.RoundNumber, strings.Join(what, ", "), h.DemoHash[:min(12, len(h.DemoHash))])
+		}
+	}
+	return md.String()
+}
+
+// digestResult returns W, L or T for s by the player's rounds won.
+func digestResult(s *model.PlayerMatchStats) string {
+	lost := s.RoundsPlayed - s.RoundsWon
+	switch {
+	case s.RoundsWon > lost:
+		return "W"
+	case s.RoundsWon < lost:
+		return "L"
+	}
+	return "T"
+}
+
+// digestMatchLine describes one match for the best/worst section.
+func digestMatchLine(s *model.PlayerMatchStats) string {
+	return fmt.Sprintf("%s %s (%s %d–%d) — rating %.2f, %d–%d, ADR %.1f (`%s`)",
+		mdCell(s.MapName), s.MatchDate, digestResult(s), s.RoundsWon, s.RoundsPlayed-s.RoundsWon,
+		s.Rating(), s.Kills, s.Deaths, s.ADR(), s.DemoHash[:min(12, len(s.DemoHash))])
+}
+
+// mdCell escapes the characters that would break a markdown table cell.
+func mdCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
+
+// polishDigest asks the Anthropic API to rewrite the markdown digest md for a
+// team channel, keeping every number it cites.
+func polishDigest(ctx context.Context, md string) (string, error) {
+	client, err := newAnthropicClient(digestAPIKey)
+	if err != nil {
+		return "", err
+	}
+	question := "The data is a markdown digest of one player's recent CS2 matches. Rewrite it as a short post " +
+		"for a team chat channel, in markdown: open with a two-sentence summary of the period, then keep the " +
+		"sections (matches, changes, best and worst match, weak spots, highlight rounds) as tight bullet lists. " +
+		"Keep every number exactly as given, add none, and drop the tables' unchanged rows."
+	answer, err := askAnthropic(ctx, client, digestModel, md, question)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(answer) + "\n", nil
+}
@@ -35,6 +35,12 @@ func runDrop(cmd *cobra.Command, args []string) error {
 		}
 		return fmt.Errorf("remove database: %w", err)
 	}
+	// WAL mode leaves -wal and -shm side files next to the database.
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dbPath + suffix); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove database %s file: %w", suffix, err)
+		}
+	}
 	fmt.Fprintf(os.Stdout, "Deleted: %s\n", dbPath)
 	return nil
 }
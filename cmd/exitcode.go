@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+// Exit codes returned by csmetrics. Scripts can branch on them instead of
+// matching stderr text; --json-errors prints the same code and kind.
+const (
+	ExitOK         = 0 // success
+	ExitError      = 1 // any failure without a more specific code
+	ExitUsage      = 2 // bad flags or arguments
+	ExitParse      = 3 // a demo could not be parsed or aggregated
+	ExitDemoExists = 4 // parse found every demo already stored; nothing new was written
+	ExitNoData     = 5 // the database has nothing for the requested demo, player or filters
+	ExitAPIKey     = 6 // a required API key (Anthropic or FACEIT) is not set
+)
+
+// exitKinds names each exit code in --json-errors output.
+var exitKinds = map[int]string{
+	ExitError:      "error",
+	ExitUsage:      "usage",
+	ExitParse:      "parse_failure",
+	ExitDemoExists: "demo_exists",
+	ExitNoData:     "no_data",
+	ExitAPIKey:     "api_key_missing",
+}
+
+// exitError is an error carrying the process exit code it should produce.
+// quiet marks outcomes that are not failures to the user (a cached demo):
+// in text mode nothing more is printed for them.
+type exitError struct {
+	code  int
+	err   error
+	quiet bool
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// withExitCode wraps err so the process exits with code.
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{code: code, err: err}
+}
+
+// noDataError reports that nothing is stored for the request (ExitNoData).
+func noDataError(format string, args ...any) error {
+	return &exitError{code: ExitNoData, err: fmt.Errorf(format, args...)}
+}
+
+// exitCode returns the exit code for err: its exitError code, else ExitError.
+func exitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var ee *exitError
+	if errors.As(err, &ee) {
+		return ee.code
+	}
+	return ExitError
+}
+
+// writeError prints err to w, as one JSON object when asJSON is set:
+// {"error": "...", "code": N, "kind": "..."}. Quiet errors print nothing in
+// text mode, since the command already reported the outcome.
+func writeError(w io.Writer, err error, asJSON bool) {
+	code := exitCode(err)
+	if asJSON {
+		b, _ := json.Marshal(struct {
+			Error string `json:"error"`
+			Code  int    `json:"code"`
+			Kind  string `json:"kind"`
+		}{err.Error(), code, exitKinds[code]})
+		fmt.Fprintln(w, string(b))
+		return
+	}
+	var ee *exitError
+	if errors.As(err, &ee) && ee.quiet {
+		return
+	}
+	fmt.Fprintln(w, "Error:", err)
+	if code == ExitUsage {
+		fmt.Fprintln(w, "Run with --help for usage.")
+	}
+}
+
+// demoExistsError reports that parse wrote nothing because the demos were
+// already stored (ExitDemoExists). It is quiet: parse has already said so.
+func demoExistsError(format string, args ...any) error {
+	return &exitError{code: ExitDemoExists, err: fmt.Errorf(format, args...), quiet: true}
+}
+
+// markUsageErrors makes flag and positional-argument errors of c and every
+// subcommand exit with ExitUsage. Subcommands inherit c's flag error func.
+func markUsageErrors(c *cobra.Command) {
+	if c.Parent() == nil {
+		c.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+			return withExitCode(ExitUsage, err)
+		})
+	}
+	if args := c.Args; args != nil {
+		c.Args = func(cmd *cobra.Command, a []string) error {
+			return withExitCode(ExitUsage, args(cmd, a))
+		}
+	}
+	for _, sub := range c.Commands() {
+		markUsageErrors(sub)
+	}
+}
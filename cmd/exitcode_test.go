@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// exitCases is one error of every class, plain and wrapped.
+var exitCases = []struct {
+	name string
+	err  error
+	code int
+	kind string
+}{
+	{"plain error", errors.New("boom"), ExitError, "error"},
+	{"usage", withExitCode(ExitUsage, errors.New("bad flag")), ExitUsage, "usage"},
+	{"parse", withExitCode(ExitParse, errors.New("bad demo")), ExitParse, "parse_failure"},
+	{"demo exists", demoExistsError("all stored"), ExitDemoExists, "demo_exists"},
+	{"no data", noDataError("no demo %s", "abc"), ExitNoData, "no_data"},
+	{"api key", withExitCode(ExitAPIKey, errors.New("no key")), ExitAPIKey, "api_key_missing"},
+	{"wrapped no data", fmt.Errorf("player: %w", noDataError("nothing")), ExitNoData, "no_data"},
+	{"wrapped twice", fmt.Errorf("a: %w", fmt.Errorf("b: %w", withExitCode(ExitParse, errors.New("c")))), ExitParse, "parse_failure"},
+	{"joined", errors.Join(errors.New("first"), withExitCode(ExitAPIKey, errors.New("no key"))), ExitAPIKey, "api_key_missing"},
+}
+
+func TestExitCode(t *testing.T) {
+	if got := exitCode(nil); got != ExitOK {
+		t.Errorf("exitCode(nil) = %d, want %d", got, ExitOK)
+	}
+	if err := withExitCode(ExitUsage, nil); err != nil {
+		t.Errorf("withExitCode(nil) = %v, want nil", err)
+	}
+	for _, c := range exitCases {
+		if got := exitCode(c.err); got != c.code {
+			t.Errorf("%s: exitCode = %d, want %d", c.name, got, c.code)
+		}
+	}
+}
+
+func TestWriteErrorJSON(t *testing.T) {
+	for _, c := range exitCases {
+		var buf bytes.Buffer
+		writeError(&buf, c.err, true)
+		var got struct {
+			Error string `json:"error"`
+			Code  int    `json:"code"`
+			Kind  string `json:"kind"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("%s: output %q is not JSON: %v", c.name, buf.String(), err)
+		}
+		if got.Error != c.err.Error() || got.Code != c.code || got.Kind != c.kind {
+			t.Errorf("%s: got %+v, want error %q code %d kind %q", c.name, got, c.err.Error(), c.code, c.kind)
+		}
+	}
+}
+
+func TestWriteErrorText(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"plain error", errors.New("boom"), "Error: boom\n"},
+		{"usage adds the help hint", withExitCode(ExitUsage, errors.New("bad flag")), "Error: bad flag\nRun with --help for usage.\n"},
+		{"no data", noDataError("no demo %s", "abc"), "Error: no demo abc\n"},
+		{"wrapped", fmt.Errorf("player: %w", noDataError("nothing")), "Error: player: nothing\n"},
+		{"quiet prints nothing", demoExistsError("all stored"), ""},
+		{"wrapped quiet prints nothing", fmt.Errorf("parse: %w", demoExistsError("all stored")), ""},
+	}
+	for _, c := range cases {
+		var buf bytes.Buffer
+		writeError(&buf, c.err, false)
+		if buf.String() != c.want {
+			t.Errorf("%s: writeError = %q, want %q", c.name, buf.String(), c.want)
+		}
+	}
+}
+
+func TestMarkUsageErrors(t *testing.T) {
+	root := &cobra.Command{Use: "root", SilenceErrors: true, SilenceUsage: true}
+	sub := &cobra.Command{Use: "sub", Args: cobra.ExactArgs(1), RunE: func(*cobra.Command, []string) error { return nil }}
+	sub.Flags().Int("n", 0, "")
+	root.AddCommand(sub)
+	markUsageErrors(root)
+
+	for _, args := range [][]string{{"sub"}, {"sub", "a", "--n", "x"}, {"sub", "a", "--bogus"}} {
+		root.SetArgs(args)
+		if got := exitCode(root.Execute()); got != ExitUsage {
+			t.Errorf("%q: exit code %d, want %d", args, got, ExitUsage)
+		}
+	}
+	root.SetArgs([]string{"sub", "a"})
+	if err := root.Execute(); err != nil {
+		t.Errorf("valid arguments: %v", err)
+	}
+}
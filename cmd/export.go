@@ -6,22 +6,27 @@ import (
 	"math"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/pable/go-cs-metrics/internal/aggregator"
+	"github.com/pable/go-cs-metrics/internal/model"
 	"github.com/pable/go-cs-metrics/internal/storage"
 )
 
 var (
 	exportTeam     string
 	exportPlayers  string
+	exportActive   string
 	exportRoster   string
 	exportSince    int
 	exportQuorum   int
 	exportOut      string
 	exportHalfLife float64
+	exportValidate string
 )
 
 // rosterFile is the schema for --roster JSON files.
@@ -30,38 +35,80 @@ type rosterFile struct {
 	Players []string `json:"players"`
 }
 
+// simbo3SchemaVersion is the layout version of the simbo3 export, written as
+// schema_version. Bump it when a field is renamed, removed or changes meaning.
+const simbo3SchemaVersion = 1
+
 // simbo3TeamStats is the top-level JSON schema expected by cs2-pro-match-simulator.
 //
 // players_rating2_3m and matches_3m use the "_3m" naming convention from HLTV's
 // standard 3-month rolling window. The actual window is recorded in window_days;
 // the field names are kept as-is for compatibility with simbo3, which ignores
-// the provenance fields (generated_at, window_days, latest_match_date, demo_count)
-// via standard JSON unmarshalling.
+// the provenance fields (generated_at, window_days, latest_match_date, demo_count,
+// the versions and hashes) via standard JSON unmarshalling.
 type simbo3TeamStats struct {
-	Team              string                    `json:"team"`
-	PlayersRating2_3m []float64                 `json:"players_rating2_3m"`
-	Maps              map[string]simbo3MapStats `json:"maps"`
-	GeneratedAt       string                    `json:"generated_at"`
-	WindowDays        int                       `json:"window_days"`
-	LatestMatchDate   string                    `json:"latest_match_date"`
-	DemoCount         int                       `json:"demo_count"`
-	TradeNetRate      float64                   `json:"trade_net_rate,omitempty"`
-	EcoWinPct         float64                   `json:"eco_win_pct,omitempty"`
-	ForceWinPct       float64                   `json:"force_win_pct,omitempty"`
-	RatingFloor       float64                   `json:"rating_floor,omitempty"`
+	Team               string                    `json:"team"`
+	PlayersRating2_3m  []float64                 `json:"players_rating2_3m"`
+	PlayersRating2ByID map[string]float64        `json:"players_rating2_by_id,omitempty"`
+	Maps               map[string]simbo3MapStats `json:"maps"`
+	GeneratedAt        string                    `json:"generated_at"`
+	WindowDays         int                       `json:"window_days"`
+	LatestMatchDate    string                    `json:"latest_match_date"`
+	DemoCount          int                       `json:"demo_count"`
+	TradeNetRate       float64                   `json:"trade_net_rate,omitempty"`
+	EcoWinPct          float64                   `json:"eco_win_pct,omitempty"`
+	ForceWinPct        float64                   `json:"force_win_pct,omitempty"`
+	RoundTypeWinPct    map[string]float64        `json:"round_type_win_pct,omitempty"`
+	RatingFloor        float64                   `json:"rating_floor,omitempty"`
+	PlayersImpact      []simbo3PlayerImpact      `json:"players_impact,omitempty"`
+	KillGini           float64                   `json:"kill_gini,omitempty"`
+	DamageGini         float64                   `json:"damage_gini,omitempty"`
+	TopKillShare       float64                   `json:"top_kill_share,omitempty"`
+	TopDamageShare     float64                   `json:"top_damage_share,omitempty"`
+	SchemaVersion      int                       `json:"schema_version"`
+	PipelineVersion    int                       `json:"pipeline_version"`
+	DemoPipelineMin    int                       `json:"demo_pipeline_min"`
+	DemoPipelineMax    int                       `json:"demo_pipeline_max"`
+	Hashes             map[string]string         `json:"hashes"`
+}
+
+// simbo3PlayerImpact is one roster player's round impact: kill win probability
+// added per round, weighted like the ratings. Omitted when no demo in the
+// window has stored kill states.
+type simbo3PlayerImpact struct {
+	SteamID     string  `json:"steam_id"`
+	Name        string  `json:"name"`
+	Rounds      int     `json:"rounds"`
+	WPAPerRound float64 `json:"wpa_per_round"`
 }
 
-// simbo3MapStats is the per-map block within the simbo3 team JSON.
+// simbo3MapStats is the per-map block within the simbo3 team JSON. The
+// half-split fields are nil (omitted) when no demo in the window has rounds
+// in that half on that side; SideSwitchDelta needs demos with both halves.
+// The bomb site maps are keyed by site ("A", "B") and omitted below
+// siteMinPlants plants.
 type simbo3MapStats struct {
-	MapWinPct        float64 `json:"map_win_pct"`
-	CTRoundWinPct    float64 `json:"ct_round_win_pct"`
-	TRoundWinPct     float64 `json:"t_round_win_pct"`
-	Matches3m        int     `json:"matches_3m"`
-	EntryKillRate    float64 `json:"entry_kill_rate,omitempty"`
-	EntryDeathRate   float64 `json:"entry_death_rate,omitempty"`
-	PostPlantTWinPct float64 `json:"post_plant_t_win_pct,omitempty"`
+	MapWinPct               float64            `json:"map_win_pct"`
+	CTRoundWinPct           float64            `json:"ct_round_win_pct"`
+	TRoundWinPct            float64            `json:"t_round_win_pct"`
+	FirstHalfCTRoundWinPct  *float64           `json:"first_half_ct_round_win_pct,omitempty"`
+	FirstHalfTRoundWinPct   *float64           `json:"first_half_t_round_win_pct,omitempty"`
+	SecondHalfCTRoundWinPct *float64           `json:"second_half_ct_round_win_pct,omitempty"`
+	SecondHalfTRoundWinPct  *float64           `json:"second_half_t_round_win_pct,omitempty"`
+	SideSwitchDelta         *float64           `json:"side_switch_delta,omitempty"`
+	Matches3m               int                `json:"matches_3m"`
+	EntryKillRate           float64            `json:"entry_kill_rate,omitempty"`
+	EntryDeathRate          float64            `json:"entry_death_rate,omitempty"`
+	PostPlantTWinPct        float64            `json:"post_plant_t_win_pct,omitempty"`
+	TSitePct                map[string]float64 `json:"t_site_pct,omitempty"`
+	TSiteWinPct             map[string]float64 `json:"t_site_win_pct,omitempty"`
+	CTSiteLossPct           map[string]float64 `json:"ct_site_loss_pct,omitempty"`
 }
 
+// siteMinPlants is the fewest plants a map needs for its site shares, and a
+// site for its T win rate, to be exported.
+const siteMinPlants = 5
+
 var exportCmd = &cobra.Command{
 	Use:   "export",
 	Short: "Export team stats as a simbo3-compatible JSON file",
@@ -76,25 +123,61 @@ Player ratings are estimated using the community approximation of HLTV Rating 2.
   Rating ≈ 0.0073*KAST% + 0.3591*KPR - 0.5329*DPR + 0.2372*Impact + 0.0032*ADR + 0.1587
   Impact  = 2.13*KPR + 0.42*APR - 0.41
 
+Five players are rated: those in --active (the current lineup) first, then the
+most active by decay-weighted rounds. In rosters of more than five, a second
+AWPer is skipped while others remain. players_rating2_by_id gives the same
+ratings keyed by SteamID64.
+
+Round impact (players_impact, optional) is win probability added by kills:
+each kill credits the killer with the swing in their side's round win
+probability (and debits the victim), using a players-alive/bomb-planted win
+probability table fitted from every demo in the database.
+
+Economy win rates classify each of the team's rounds by its summed freeze-end
+equipment value: pistol, full-eco, semi-eco, force or full-buy.
+round_type_win_pct gives the win rate per class with at least 10 rounds;
+eco_win_pct pools full and semi ecos and force_win_pct is the force class
+(0.50 below 10 rounds).
+
+Concentration (kill_gini, damage_gini, top_kill_share, top_damage_share) is
+how unevenly the team's kills and damage are spread over its five players,
+per demo with five roster players, decay-weighted: a Gini of 0 is an even
+split, and a top share well above 0.20 means the team leans on one star.
+Omitted when no demo has five roster players.
+
+Integrity: schema_version is the layout version of the file, pipeline_version
+the aggregation pipeline of this build, and demo_pipeline_min/max the oldest
+and newest pipeline the qualifying demos were stored with. hashes holds the
+SHA-256 of each data section (provenance, ratings, impact, maps, economy,
+concentration) as compact JSON with sorted keys. --validate reads a file back,
+checks its schema and every hash (exit 1 on a mismatch) and warns about stale
+or mixed pipeline versions; no database is opened.
+
 Example:
   csmetrics export --team "NaVi" --players "76561198034202275,76561197992321696,..." --out navi.json
-  csmetrics export --roster navi.json --out navi-simbo3.json`,
+  csmetrics export --roster navi.json --out navi-simbo3.json
+  csmetrics export --validate navi-simbo3.json`,
 	RunE: runExport,
 }
 
 func init() {
 	exportCmd.Flags().StringVar(&exportTeam, "team", "", "team name for the output JSON")
-	exportCmd.Flags().StringVar(&exportPlayers, "players", "", "comma-separated SteamID64s")
+	exportCmd.Flags().StringVar(&exportPlayers, "players", "", "comma-separated player IDs (SteamID64, steamID3, steamID2, profile URL or custom URL name)")
+	exportCmd.Flags().StringVar(&exportActive, "active", "", "comma-separated player IDs of the current lineup; always rated when roster has more than 5")
 	exportCmd.Flags().StringVar(&exportRoster, "roster", "", `roster JSON file: {"team":"...","players":["...",...]}`)
 	exportCmd.Flags().IntVar(&exportSince, "since", 90, "look-back window in days")
 	exportCmd.Flags().IntVar(&exportQuorum, "quorum", 3, "min roster players per demo to include it")
 	exportCmd.Flags().StringVar(&exportOut, "out", "", "output file path (default: stdout)")
 	exportCmd.Flags().Float64Var(&exportHalfLife, "half-life", 35,
 		"temporal decay half-life in days (0 = uniform weights)")
+	exportCmd.Flags().StringVar(&exportValidate, "validate", "", "check an export file's schema version and section hashes instead of exporting")
 }
 
 func runExport(_ *cobra.Command, _ []string) error {
-	teamName, steamIDs, err := resolveRoster()
+	if exportValidate != "" {
+		return validateExport(os.Stdout, exportValidate)
+	}
+	teamName, steamIDs, err := resolveRoster(exportTeam, exportPlayers, exportRoster)
 	if err != nil {
 		return err
 	}
@@ -173,14 +256,25 @@ func runExport(_ *cobra.Command, _ []string) error {
 		}
 		ctPct, tPct := weightedSideStats(sidesByDemo, weights)
 
-		maps[mapName] = simbo3MapStats{
+		halvesByDemo, err := db.RoundHalfSideStatsByDemo(steamIDs, hashes)
+		if err != nil {
+			return fmt.Errorf("round half side stats for %s: %w", mapName, err)
+		}
+		ms := simbo3MapStats{
 			MapWinPct:     roundTo2dp(mapWinPct),
 			CTRoundWinPct: roundTo2dp(ctPct),
 			TRoundWinPct:  roundTo2dp(tPct),
 			Matches3m:     n,
 		}
-		fmt.Fprintf(os.Stderr, "  %-12s  %2d matches  win=%.2f  CT=%.2f  T=%.2f\n",
-			mapName, n, mapWinPct, ctPct, tPct)
+		setHalfSideStats(&ms, halvesByDemo, weights)
+		maps[mapName] = ms
+
+		delta := "n/a"
+		if ms.SideSwitchDelta != nil {
+			delta = fmt.Sprintf("%+.2f", *ms.SideSwitchDelta)
+		}
+		fmt.Fprintf(os.Stderr, "  %-12s  %2d matches  win=%.2f  CT=%.2f  T=%.2f  switch=%s\n",
+			mapName, n, mapWinPct, ctPct, tPct, delta)
 	}
 
 	// Compute HLTV Rating 2.0 proxies for the top 5 players by activity.
@@ -188,7 +282,28 @@ func runExport(_ *cobra.Command, _ []string) error {
 	if err != nil {
 		return fmt.Errorf("roster match totals: %w", err)
 	}
-	ratings := buildWeightedRatings(byDemo, weights)
+	active, err := resolveSteamIDs(splitSteamIDs(exportActive))
+	if err != nil {
+		return fmt.Errorf("--active: %w", err)
+	}
+	rostered := make(map[string]bool, len(steamIDs))
+	for _, id := range steamIDs {
+		rostered[id] = true
+	}
+	for _, id := range active {
+		if !rostered[id] {
+			fmt.Fprintf(os.Stderr, "warn: --active player %s is not in the roster; ignored\n", id)
+		}
+	}
+	ratings, ratingsByID := buildWeightedRatings(byDemo, weights, active, true)
+
+	// Round impact (WPA): fit the win-probability table on the whole database,
+	// then credit kills from the qualifying demos.
+	corpus, err := db.KillStates(nil)
+	if err != nil {
+		return fmt.Errorf("kill states: %w", err)
+	}
+	playersImpact := buildWeightedImpact(corpus, byDemo, weights)
 
 	// Populate per-map entry kill/death rates.
 	entryByMap, err := db.MapEntryStats(steamIDs, allHashes)
@@ -224,6 +339,21 @@ func runExport(_ *cobra.Command, _ []string) error {
 		maps[mapName] = ms
 	}
 
+	// Populate per-map bomb site preference: T plant and CT conceded-plant
+	// shares per site, and T win rates per site.
+	sitesByMap, err := db.MapBombSiteStats(steamIDs, allHashes)
+	if err != nil {
+		return fmt.Errorf("map bomb site stats: %w", err)
+	}
+	for mapName, sites := range sitesByMap {
+		ms, ok := maps[mapName]
+		if !ok {
+			continue
+		}
+		setBombSiteStats(&ms, sites)
+		maps[mapName] = ms
+	}
+
 	// Compute team-level trade net rate.
 	tradeStats, err := db.TeamTradeStats(steamIDs, allHashes)
 	if err != nil {
@@ -234,36 +364,72 @@ func runExport(_ *cobra.Command, _ []string) error {
 		tradeNetRate = roundTo2dp(float64(tradeStats.TradeKills-tradeStats.TradeDeaths) / float64(tradeStats.RoundsPlayed))
 	}
 
-	// Compute eco and force buy-type win rates.
-	buyRates, err := db.BuyTypeWinRates(steamIDs, allHashes)
+	// Compute win rates per team economy class. eco_win_pct pools full and
+	// semi ecos; force_win_pct is the force class.
+	econRates, err := db.TeamEconomyWinRates(steamIDs, allHashes)
 	if err != nil {
-		return fmt.Errorf("buy type win rates: %w", err)
+		return fmt.Errorf("team economy win rates: %w", err)
 	}
 	const buyTypeMinRounds = 10
-	ecoWinPct := 0.50
-	if buyRates.EcoTotal >= buyTypeMinRounds {
-		ecoWinPct = roundTo2dp(float64(buyRates.EcoWins) / float64(buyRates.EcoTotal))
+	winPct := func(r storage.RoundTypeWinRate) float64 {
+		if r.Total < buyTypeMinRounds {
+			return 0.50
+		}
+		return roundTo2dp(float64(r.Wins) / float64(r.Total))
+	}
+	eco := econRates[model.TeamRoundFullEco]
+	semi := econRates[model.TeamRoundSemiEco]
+	ecoWinPct := winPct(storage.RoundTypeWinRate{Wins: eco.Wins + semi.Wins, Total: eco.Total + semi.Total})
+	forceWinPct := winPct(econRates[model.TeamRoundForce])
+	var roundTypeWinPct map[string]float64
+	for _, rt := range model.TeamRoundTypes {
+		if r := econRates[rt]; r.Total >= buyTypeMinRounds {
+			if roundTypeWinPct == nil {
+				roundTypeWinPct = make(map[string]float64)
+			}
+			roundTypeWinPct[rt] = winPct(r)
+		}
 	}
-	forceWinPct := 0.50
-	if buyRates.ForceTotal >= buyTypeMinRounds {
-		forceWinPct = roundTo2dp(float64(buyRates.ForceWins) / float64(buyRates.ForceTotal))
+
+	// Kill and damage concentration over demos with a full roster lineup.
+	conc, concDemos := buildWeightedConcentration(byDemo, weights)
+	if concDemos > 0 {
+		fmt.Fprintf(os.Stderr, "Concentration (%d demos): kill Gini=%.2f  damage Gini=%.2f  top kill share=%.2f  top damage share=%.2f\n",
+			concDemos, conc.KillGini, conc.DamageGini, conc.TopKillShare, conc.TopDamageShare)
 	}
 
 	// Rating floor: ratings is sorted descending; index 4 is the 5th player (lowest).
 	ratingFloor := ratings[4]
 
 	out := simbo3TeamStats{
-		Team:              teamName,
-		PlayersRating2_3m: ratings,
-		Maps:              maps,
-		GeneratedAt:       time.Now().UTC().Format(time.RFC3339),
-		WindowDays:        exportSince,
-		LatestMatchDate:   demos[0].MatchDate,
-		DemoCount:         len(demos),
-		TradeNetRate:      tradeNetRate,
-		EcoWinPct:         ecoWinPct,
-		ForceWinPct:       forceWinPct,
-		RatingFloor:       ratingFloor,
+		Team:               teamName,
+		PlayersRating2_3m:  ratings,
+		PlayersRating2ByID: ratingsByID,
+		Maps:               maps,
+		GeneratedAt:        time.Now().UTC().Format(time.RFC3339),
+		WindowDays:         exportSince,
+		LatestMatchDate:    demos[0].MatchDate,
+		DemoCount:          len(demos),
+		TradeNetRate:       tradeNetRate,
+		EcoWinPct:          ecoWinPct,
+		ForceWinPct:        forceWinPct,
+		RoundTypeWinPct:    roundTypeWinPct,
+		RatingFloor:        ratingFloor,
+		PlayersImpact:      playersImpact,
+		KillGini:           roundTo2dp(conc.KillGini),
+		DamageGini:         roundTo2dp(conc.DamageGini),
+		TopKillShare:       roundTo2dp(conc.TopKillShare),
+		TopDamageShare:     roundTo2dp(conc.TopDamageShare),
+		SchemaVersion:      simbo3SchemaVersion,
+		PipelineVersion:    aggregator.PipelineVersion,
+	}
+	out.DemoPipelineMin, out.DemoPipelineMax = demoPipelineRange(demos)
+	if out.DemoPipelineMin != out.DemoPipelineMax {
+		fmt.Fprintf(os.Stderr, "warn: demos were parsed with pipeline versions %d–%d; re-parse the older ones for consistent stats\n",
+			out.DemoPipelineMin, out.DemoPipelineMax)
+	}
+	if out.Hashes, err = simbo3Hashes(out); err != nil {
+		return fmt.Errorf("hash export sections: %w", err)
 	}
 	if exportSince != 90 {
 		fmt.Fprintf(os.Stderr,
@@ -287,19 +453,20 @@ func runExport(_ *cobra.Command, _ []string) error {
 	return nil
 }
 
-// resolveRoster returns the team name and SteamID list from flags.
-// --players takes precedence over --roster; --team always overrides the roster file name.
-func resolveRoster() (teamName string, steamIDs []string, err error) {
-	if exportPlayers != "" {
-		for _, raw := range strings.Split(exportPlayers, ",") {
-			if id := strings.TrimSpace(raw); id != "" {
-				steamIDs = append(steamIDs, id)
-			}
+// resolveRoster returns the team name and SteamID64 list from the --team,
+// --players and --roster flag values. players takes precedence over roster;
+// team always overrides the roster file name. Player IDs may be in any of
+// steamIDFormats (see resolveSteamID).
+func resolveRoster(team, players, roster string) (teamName string, steamIDs []string, err error) {
+	if players != "" {
+		ids, err := resolveSteamIDs(splitSteamIDs(players))
+		if err != nil {
+			return "", nil, fmt.Errorf("--players: %w", err)
 		}
-		return exportTeam, steamIDs, nil
+		return team, ids, nil
 	}
-	if exportRoster != "" {
-		data, readErr := os.ReadFile(exportRoster)
+	if roster != "" {
+		data, readErr := os.ReadFile(roster)
 		if readErr != nil {
 			return "", nil, fmt.Errorf("read roster file: %w", readErr)
 		}
@@ -308,12 +475,27 @@ func resolveRoster() (teamName string, steamIDs []string, err error) {
 			return "", nil, fmt.Errorf("parse roster file: %w", jsonErr)
 		}
 		name := rf.Team
-		if exportTeam != "" {
-			name = exportTeam
+		if team != "" {
+			name = team
+		}
+		ids, err := resolveSteamIDs(rf.Players)
+		if err != nil {
+			return "", nil, fmt.Errorf("roster file %s: %w", roster, err)
 		}
-		return name, rf.Players, nil
+		return name, ids, nil
 	}
-	return exportTeam, nil, nil
+	return team, nil, nil
+}
+
+// splitSteamIDs splits a comma-separated SteamID64 list, dropping blanks.
+func splitSteamIDs(s string) []string {
+	var ids []string
+	for _, raw := range strings.Split(s, ",") {
+		if id := strings.TrimSpace(raw); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
 }
 
 // demoWeights returns exp(-ln(2)/halfLife * days_before_ref) per demo hash.
@@ -385,26 +567,60 @@ func weightedSideStats(byDemo []storage.DemoSideStats, weights map[string]float6
 	return
 }
 
-// buildWeightedRatings groups PlayerDemoTotals by player, accumulates
-// weighted stat sums, computes KPR/DPR/APR/KAST/ADR from weighted totals.
-// Returns a 5-element slice sorted descending, padded with 1.00.
-func buildWeightedRatings(byDemo []storage.PlayerDemoTotals, weights map[string]float64) []float64 {
-	type acc struct {
-		name        string
-		kills       float64
-		deaths      float64
-		assists     float64
-		kastRounds  float64
-		rounds      float64
-		totalDamage float64
+// setHalfSideStats fills ms's first/second-half side win% and side-switch
+// delta from per-demo half stats, weighting each demo like weightedSideStats.
+// The delta is second-half minus first-half round win%, over demos with
+// rounds in both halves: negative means the team fades after switching.
+func setHalfSideStats(ms *simbo3MapStats, byDemo []storage.DemoHalfSideStats, weights map[string]float64) {
+	// Indexed [half][side]: half 0/1, side 0 = CT, 1 = T.
+	var winW, totalW [2][2]float64
+	var firstWinW, firstTotalW, secondWinW, secondTotalW float64
+	for _, d := range byDemo {
+		w := weights[d.Hash]
+		first, second := 0, 1
+		if d.FirstSide == "T" {
+			first, second = 1, 0
+		}
+		winW[0][first] += w * float64(d.FirstWins)
+		totalW[0][first] += w * float64(d.FirstTotal)
+		winW[1][second] += w * float64(d.SecondWins)
+		totalW[1][second] += w * float64(d.SecondTotal)
+		if d.FirstTotal > 0 && d.SecondTotal > 0 {
+			firstWinW += w * float64(d.FirstWins)
+			firstTotalW += w * float64(d.FirstTotal)
+			secondWinW += w * float64(d.SecondWins)
+			secondTotalW += w * float64(d.SecondTotal)
+		}
+	}
+	pct := func(win, total float64) *float64 {
+		if total == 0 {
+			return nil
+		}
+		v := roundTo2dp(win / total)
+		return &v
+	}
+	ms.FirstHalfCTRoundWinPct = pct(winW[0][0], totalW[0][0])
+	ms.FirstHalfTRoundWinPct = pct(winW[0][1], totalW[0][1])
+	ms.SecondHalfCTRoundWinPct = pct(winW[1][0], totalW[1][0])
+	ms.SecondHalfTRoundWinPct = pct(winW[1][1], totalW[1][1])
+	if firstTotalW > 0 && secondTotalW > 0 {
+		v := roundTo2dp(secondWinW/secondTotalW - firstWinW/firstTotalW)
+		ms.SideSwitchDelta = &v
 	}
+}
 
-	players := make(map[string]*acc)
+// buildWeightedRatings groups PlayerDemoTotals by player, accumulates
+// weighted stat sums, computes KPR/DPR/APR/KAST/ADR from weighted totals.
+// Five players are selected by selectRatedPlayers (balanceRoles as there); the
+// result is their ratings as a 5-element slice sorted descending (padded with
+// 1.00) and keyed by SteamID.
+func buildWeightedRatings(byDemo []storage.PlayerDemoTotals, weights map[string]float64, active []string, balanceRoles bool) ([]float64, map[string]float64) {
+	players := make(map[string]*ratedPlayer)
 	for _, d := range byDemo {
 		w := weights[d.DemoHash]
 		a, ok := players[d.SteamID]
 		if !ok {
-			a = &acc{name: d.Name}
+			a = &ratedPlayer{steamID: d.SteamID, name: d.Name, roleRounds: make(map[string]float64)}
 			players[d.SteamID] = a
 		}
 		a.kills += w * float64(d.Kills)
@@ -413,27 +629,16 @@ func buildWeightedRatings(byDemo []storage.PlayerDemoTotals, weights map[string]
 		a.kastRounds += w * float64(d.KastRounds)
 		a.rounds += w * float64(d.RoundsPlayed)
 		a.totalDamage += w * float64(d.TotalDamage)
+		a.roleRounds[d.Role] += w * float64(d.RoundsPlayed)
 	}
 
-	type namedAcc struct {
-		steamID string
-		*acc
-	}
-	sorted := make([]namedAcc, 0, len(players))
-	for id, a := range players {
-		sorted = append(sorted, namedAcc{id, a})
-	}
-	sort.Slice(sorted, func(i, j int) bool { return sorted[i].rounds > sorted[j].rounds })
+	top := selectRatedPlayers(players, active, balanceRoles)
 
 	ratings := make([]float64, 5)
 	for i := range ratings {
 		ratings[i] = 1.00
 	}
-
-	top := sorted
-	if len(top) > 5 {
-		top = top[:5]
-	}
+	byID := make(map[string]float64, len(top))
 
 	for i, p := range top {
 		if p.rounds == 0 {
@@ -447,8 +652,9 @@ func buildWeightedRatings(byDemo []storage.PlayerDemoTotals, weights map[string]
 		impact := 2.13*kpr + 0.42*apr - 0.41
 		r := 0.0073*kast + 0.3591*kpr - 0.5329*dpr + 0.2372*impact + 0.0032*adr + 0.1587
 		ratings[i] = roundTo2dp(r)
-		fmt.Fprintf(os.Stderr, "  %-20s  wRounds=%.1f  KPR=%.2f DPR=%.2f KAST=%.0f%% ADR=%.1f  → rating %.2f\n",
-			p.name, p.rounds, kpr, dpr, kast, adr, r)
+		byID[p.steamID] = ratings[i]
+		fmt.Fprintf(os.Stderr, "  %-20s  %-7s wRounds=%.1f  KPR=%.2f DPR=%.2f KAST=%.0f%% ADR=%.1f  → rating %.2f\n",
+			p.name, p.role(), p.rounds, kpr, dpr, kast, adr, r)
 	}
 
 	if len(top) < 5 {
@@ -457,10 +663,232 @@ func buildWeightedRatings(byDemo []storage.PlayerDemoTotals, weights map[string]
 	}
 
 	sort.Slice(ratings, func(i, j int) bool { return ratings[i] > ratings[j] })
-	return ratings
+	return ratings, byID
 }
 
+// ratedPlayer accumulates one roster player's decay-weighted totals.
+type ratedPlayer struct {
+	steamID     string
+	name        string
+	kills       float64
+	deaths      float64
+	assists     float64
+	kastRounds  float64
+	rounds      float64
+	totalDamage float64
+	roleRounds  map[string]float64 // weighted rounds per per-demo role
+}
+
+// role returns the role the player held for the most weighted rounds.
+func (p *ratedPlayer) role() string {
+	best, bestRounds := "", -1.0
+	for r, n := range p.roleRounds {
+		if n > bestRounds || (n == bestRounds && r < best) {
+			best, bestRounds = r, n
+		}
+	}
+	return best
+}
+
+// selectRatedPlayers picks up to five players to rate. Players listed in active
+// (the current lineup) come first; remaining slots go to the most active players
+// by weighted rounds. With balanceRoles and more than five players, a second
+// AWPer is skipped while other players are left, so a substitute AWPer in a
+// 6-man roster does not displace a rifler.
+func selectRatedPlayers(players map[string]*ratedPlayer, active []string, balanceRoles bool) []*ratedPlayer {
+	sorted := make([]*ratedPlayer, 0, len(players))
+	for _, p := range players {
+		sorted = append(sorted, p)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].rounds != sorted[j].rounds {
+			return sorted[i].rounds > sorted[j].rounds
+		}
+		return sorted[i].steamID < sorted[j].steamID
+	})
+
+	var top []*ratedPlayer
+	picked := make(map[string]bool)
+	hasAWPer := false
+	add := func(p *ratedPlayer) {
+		top = append(top, p)
+		picked[p.steamID] = true
+		if p.role() == "AWPer" {
+			hasAWPer = true
+		}
+	}
+
+	isActive := make(map[string]bool, len(active))
+	for _, id := range active {
+		isActive[id] = true
+	}
+	for _, p := range sorted {
+		if len(top) < 5 && isActive[p.steamID] {
+			add(p)
+		}
+	}
+	for _, skipAWPers := range []bool{balanceRoles && len(players) > 5, false} {
+		for _, p := range sorted {
+			if len(top) == 5 {
+				return top
+			}
+			if picked[p.steamID] || (skipAWPers && hasAWPer && p.role() == "AWPer") {
+				continue
+			}
+			add(p)
+		}
+	}
+	return top
+}
+
+// concentrationLineup is the number of roster players a demo needs for its
+// kill and damage concentration to count: Gini and top share depend on the
+// number of players, so partial lineups would not be comparable.
+const concentrationLineup = 5
+
+// buildWeightedConcentration averages the roster's per-demo kill and damage
+// Gini and top-player shares over demos with concentrationLineup roster
+// players, weighted like the ratings. Returns the averages and the number of
+// demos used.
+func buildWeightedConcentration(byDemo []storage.PlayerDemoTotals, weights map[string]float64) (model.TeamConcentration, int) {
+	kills := make(map[string][]float64)
+	damage := make(map[string][]float64)
+	var hashes []string
+	for _, d := range byDemo {
+		if _, ok := kills[d.DemoHash]; !ok {
+			hashes = append(hashes, d.DemoHash)
+		}
+		kills[d.DemoHash] = append(kills[d.DemoHash], float64(d.Kills))
+		damage[d.DemoHash] = append(damage[d.DemoHash], float64(d.TotalDamage))
+	}
+	var out model.TeamConcentration
+	var wSum float64
+	n := 0
+	for _, hash := range hashes {
+		k := kills[hash]
+		if len(k) != concentrationLineup {
+			continue
+		}
+		w := weights[hash]
+		out.KillGini += w * aggregator.Gini(k)
+		out.DamageGini += w * aggregator.Gini(damage[hash])
+		out.TopKillShare += w * aggregator.TopShare(k)
+		out.TopDamageShare += w * aggregator.TopShare(damage[hash])
+		wSum += w
+		n++
+	}
+	if wSum == 0 {
+		return model.TeamConcentration{}, 0
+	}
+	out.Players = concentrationLineup
+	out.KillGini /= wSum
+	out.DamageGini /= wSum
+	out.TopKillShare /= wSum
+	out.TopDamageShare /= wSum
+	return out, n
+}
+
+// buildWeightedImpact computes each roster player's weighted kill WPA per round
+// over the qualifying demos in byDemo. Demos without stored kill states (parsed
+// before they were recorded) are left out of both WPA and rounds. Returns nil
+// when none of the demos have kill states; otherwise sorted by WPA descending.
+func buildWeightedImpact(corpus []model.KillState, byDemo []storage.PlayerDemoTotals, weights map[string]float64) []simbo3PlayerImpact {
+	tbl := aggregator.BuildWinProbTable(corpus)
+	statesByDemo := make(map[string][]model.KillState)
+	for _, ks := range corpus {
+		if _, ok := weights[ks.DemoHash]; ok {
+			statesByDemo[ks.DemoHash] = append(statesByDemo[ks.DemoHash], ks)
+		}
+	}
+	if len(statesByDemo) == 0 {
+		return nil
+	}
+
+	type acc struct {
+		name    string
+		rounds  int
+		wRounds float64
+		wWPA    float64
+	}
+	wpaByDemo := make(map[string]map[uint64]float64, len(statesByDemo))
+	players := make(map[string]*acc)
+	var order []string
+	for _, d := range byDemo {
+		states, ok := statesByDemo[d.DemoHash]
+		if !ok {
+			continue
+		}
+		wpa, ok := wpaByDemo[d.DemoHash]
+		if !ok {
+			wpa = tbl.KillWPA(states)
+			wpaByDemo[d.DemoHash] = wpa
+		}
+		id, err := strconv.ParseUint(d.SteamID, 10, 64)
+		if err != nil {
+			continue
+		}
+		a, ok := players[d.SteamID]
+		if !ok {
+			a = &acc{name: d.Name}
+			players[d.SteamID] = a
+			order = append(order, d.SteamID)
+		}
+		w := weights[d.DemoHash]
+		a.rounds += d.RoundsPlayed
+		a.wRounds += w * float64(d.RoundsPlayed)
+		a.wWPA += w * wpa[id]
+	}
+
+	out := make([]simbo3PlayerImpact, 0, len(order))
+	for _, id := range order {
+		a := players[id]
+		if a.wRounds == 0 {
+			continue
+		}
+		out = append(out, simbo3PlayerImpact{
+			SteamID:     id,
+			Name:        a.name,
+			Rounds:      a.rounds,
+			WPAPerRound: math.Round(a.wWPA/a.wRounds*1000) / 1000,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].WPAPerRound > out[j].WPAPerRound })
+	for _, p := range out {
+		fmt.Fprintf(os.Stderr, "  %-20s  rounds=%d  WPA/round=%+.3f\n", p.Name, p.Rounds, p.WPAPerRound)
+	}
+	return out
+}
 
 func roundTo2dp(v float64) float64 {
 	return math.Round(v*100) / 100
 }
+
+// setBombSiteStats fills ms's bomb site maps from the map's per-site plant
+// counts, leaving a map nil when its sample is below siteMinPlants.
+func setBombSiteStats(ms *simbo3MapStats, sites []storage.BombSiteStats) {
+	var tTotal, ctTotal int
+	for _, s := range sites {
+		tTotal += s.TPlants
+		ctTotal += s.CTPlants
+	}
+	for _, s := range sites {
+		if tTotal >= siteMinPlants {
+			if ms.TSitePct == nil {
+				ms.TSitePct = make(map[string]float64)
+			}
+			ms.TSitePct[s.Site] = roundTo2dp(float64(s.TPlants) / float64(tTotal))
+		}
+		if s.TPlants >= siteMinPlants {
+			if ms.TSiteWinPct == nil {
+				ms.TSiteWinPct = make(map[string]float64)
+			}
+			ms.TSiteWinPct[s.Site] = roundTo2dp(float64(s.TWins) / float64(s.TPlants))
+		}
+		if ctTotal >= siteMinPlants {
+			if ms.CTSiteLossPct == nil {
+				ms.CTSiteLossPct = make(map[string]float64)
+			}
+			ms.CTSiteLossPct[s.Site] = roundTo2dp(float64(s.CTPlants) / float64(ctTotal))
+		}
+	}
+}
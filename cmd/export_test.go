@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSelectRatedPlayers(t *testing.T) {
+	type p struct {
+		id     string
+		role   string
+		rounds float64
+	}
+	// roster builds the players keyed by SteamID, each holding one role.
+	roster := func(ps ...p) map[string]*ratedPlayer {
+		m := make(map[string]*ratedPlayer, len(ps))
+		for _, x := range ps {
+			m[x.id] = &ratedPlayer{steamID: x.id, rounds: x.rounds, roleRounds: map[string]float64{x.role: x.rounds}}
+		}
+		return m
+	}
+	sixWithSubAWPer := roster(
+		p{"awp", "AWPer", 300},
+		p{"r1", "Rifler", 290},
+		p{"sub", "AWPer", 280},
+		p{"r2", "Entry", 270},
+		p{"r3", "Support", 260},
+		p{"r4", "Rifler", 100},
+	)
+
+	cases := []struct {
+		name         string
+		players      map[string]*ratedPlayer
+		active       []string
+		balanceRoles bool
+		want         []string
+	}{
+		{
+			name:         "active lineup first, its AWPer benches the other",
+			players:      sixWithSubAWPer,
+			active:       []string{"r4", "sub"},
+			balanceRoles: true,
+			want:         []string{"sub", "r4", "r1", "r2", "r3"},
+		},
+		{
+			name:         "active lineup of five is kept whole",
+			players:      sixWithSubAWPer,
+			active:       []string{"r4", "r3", "r2", "sub", "awp"},
+			balanceRoles: true,
+			want:         []string{"awp", "sub", "r2", "r3", "r4"},
+		},
+		{
+			name:         "6-man roster skips substitute AWPer",
+			players:      sixWithSubAWPer,
+			balanceRoles: true,
+			want:         []string{"awp", "r1", "r2", "r3", "r4"},
+		},
+		{
+			name:    "6-man roster without balancing is top five by rounds",
+			players: sixWithSubAWPer,
+			want:    []string{"awp", "r1", "sub", "r2", "r3"},
+		},
+		{
+			name: "5-man roster unchanged",
+			players: roster(
+				p{"awp", "AWPer", 300},
+				p{"r1", "Rifler", 290},
+				p{"awp2", "AWPer", 280},
+				p{"r2", "Entry", 270},
+				p{"r3", "Support", 100},
+			),
+			balanceRoles: true,
+			want:         []string{"awp", "r1", "awp2", "r2", "r3"},
+		},
+		{
+			name:         "fewer than five",
+			players:      roster(p{"b", "AWPer", 50}, p{"a", "AWPer", 50}),
+			balanceRoles: true,
+			want:         []string{"a", "b"},
+		},
+		{
+			name: "only AWPers left fill the last slot",
+			players: roster(
+				p{"awp", "AWPer", 300},
+				p{"awp2", "AWPer", 290},
+				p{"awp3", "AWPer", 280},
+				p{"r1", "Rifler", 270},
+				p{"r2", "Rifler", 260},
+				p{"r3", "Rifler", 250},
+			),
+			balanceRoles: true,
+			want:         []string{"awp", "r1", "r2", "r3", "awp2"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var got []string
+			for _, rp := range selectRatedPlayers(c.players, c.active, c.balanceRoles) {
+				got = append(got, rp.steamID)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("selectRatedPlayers = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
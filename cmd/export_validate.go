@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pable/go-cs-metrics/internal/aggregator"
+	"github.com/pable/go-cs-metrics/internal/storage"
+)
+
+// simbo3Sections groups the export's fields into the sections hashed in
+// hashes. Each section marshals with the same field names and omitempty rules
+// as the export, so a file read back hashes to the values it was written with.
+// schema_version and hashes themselves are not hashed.
+func simbo3Sections(t simbo3TeamStats) map[string]any {
+	return map[string]any{
+		"provenance": struct {
+			Team            string `json:"team"`
+			GeneratedAt     string `json:"generated_at"`
+			WindowDays      int    `json:"window_days"`
+			LatestMatchDate string `json:"latest_match_date"`
+			DemoCount       int    `json:"demo_count"`
+			PipelineVersion int    `json:"pipeline_version"`
+			DemoPipelineMin int    `json:"demo_pipeline_min"`
+			DemoPipelineMax int    `json:"demo_pipeline_max"`
+		}{t.Team, t.GeneratedAt, t.WindowDays, t.LatestMatchDate, t.DemoCount, t.PipelineVersion, t.DemoPipelineMin, t.DemoPipelineMax},
+		"ratings": struct {
+			PlayersRating2_3m  []float64          `json:"players_rating2_3m"`
+			PlayersRating2ByID map[string]float64 `json:"players_rating2_by_id,omitempty"`
+			RatingFloor        float64            `json:"rating_floor,omitempty"`
+		}{t.PlayersRating2_3m, t.PlayersRating2ByID, t.RatingFloor},
+		"impact": struct {
+			PlayersImpact []simbo3PlayerImpact `json:"players_impact,omitempty"`
+		}{t.PlayersImpact},
+		"maps": struct {
+			Maps map[string]simbo3MapStats `json:"maps"`
+		}{t.Maps},
+		"economy": struct {
+			TradeNetRate    float64            `json:"trade_net_rate,omitempty"`
+			EcoWinPct       float64            `json:"eco_win_pct,omitempty"`
+			ForceWinPct     float64            `json:"force_win_pct,omitempty"`
+			RoundTypeWinPct map[string]float64 `json:"round_type_win_pct,omitempty"`
+		}{t.TradeNetRate, t.EcoWinPct, t.ForceWinPct, t.RoundTypeWinPct},
+		"concentration": struct {
+			KillGini       float64 `json:"kill_gini,omitempty"`
+			DamageGini     float64 `json:"damage_gini,omitempty"`
+			TopKillShare   float64 `json:"top_kill_share,omitempty"`
+			TopDamageShare float64 `json:"top_damage_share,omitempty"`
+		}{t.KillGini, t.DamageGini, t.TopKillShare, t.TopDamageShare},
+	}
+}
+
+// simbo3Hashes returns the hex SHA-256 of each section's canonical JSON.
+func simbo3Hashes(t simbo3TeamStats) (map[string]string, error) {
+	sections := simbo3Sections(t)
+	out := make(map[string]string, len(sections))
+	for name, v := range sections {
+		b, err := canonicalJSON(v)
+		if err != nil {
+			return nil, fmt.Errorf("section %s: %w", name, err)
+		}
+		sum := sha256.Sum256(b)
+		out[name] = hex.EncodeToString(sum[:])
+	}
+	return out, nil
+}
+
+// canonicalJSON encodes v as compact JSON with object keys sorted at every
+// level and numbers and strings written as encoding/json writes them, without
+// HTML escaping, so other languages can reproduce the bytes (in Python:
+// json.dumps(v, sort_keys=True, separators=(",", ":"), ensure_ascii=False)).
+func canonicalJSON(v any) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	// Decoding into interface values turns objects into maps, which encode
+	// with sorted keys; UseNumber keeps each number's text unchanged.
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var generic any
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(generic); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// demoPipelineRange returns the oldest and newest pipeline version the demos
+// were stored with.
+func demoPipelineRange(demos []storage.DemoRef) (lo, hi int) {
+	for i, d := range demos {
+		if i == 0 || d.PipelineVersion < lo {
+			lo = d.PipelineVersion
+		}
+		if d.PipelineVersion > hi {
+			hi = d.PipelineVersion
+		}
+	}
+	return lo, hi
+}
+
+// validateExport reads a simbo3 export back and checks it: the file must
+// decode into the current schema with no unknown fields, carry a supported
+// schema_version, and every section must match its hash. Stale or mixed
+// pipeline versions are warnings on stderr. The result line goes to w.
+func validateExport(w io.Writer, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read export: %w", err)
+	}
+	var t simbo3TeamStats
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&t); err != nil {
+		return fmt.Errorf("parse export %s: %w", path, err)
+	}
+
+	var problems, warnings []string
+	switch {
+	case t.SchemaVersion == 0:
+		problems = append(problems, "no schema_version: written before integrity hashes were added; re-run export")
+	case t.SchemaVersion > simbo3SchemaVersion:
+		problems = append(problems, fmt.Sprintf("schema_version %d is newer than this build supports (%d)", t.SchemaVersion, simbo3SchemaVersion))
+	case t.SchemaVersion < simbo3SchemaVersion:
+		warnings = append(warnings, fmt.Sprintf("schema_version %d is older than the current %d; re-run export", t.SchemaVersion, simbo3SchemaVersion))
+	}
+
+	verified := 0
+	if t.SchemaVersion != 0 {
+		want, err := simbo3Hashes(t)
+		if err != nil {
+			return fmt.Errorf("hash export sections: %w", err)
+		}
+		for _, name := range sortedKeys(want) {
+			got, ok := t.Hashes[name]
+			switch {
+			case !ok:
+				problems = append(problems, fmt.Sprintf("no hash for section %s", name))
+			case got != want[name]:
+				problems = append(problems, fmt.Sprintf("section %s does not match its hash (edited or corrupted)", name))
+			default:
+				verified++
+			}
+		}
+		for _, name := range sortedKeys(t.Hashes) {
+			if _, ok := want[name]; !ok {
+				problems = append(problems, fmt.Sprintf("hash for unknown section %q", name))
+			}
+		}
+		warnings = append(warnings, pipelineWarnings(t)...)
+	}
+
+	for _, msg := range warnings {
+		fmt.Fprintf(os.Stderr, "warn: %s: %s\n", path, msg)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s failed validation: %s", path, strings.Join(problems, "; "))
+	}
+	fmt.Fprintf(w, "%s: OK (schema v%d, pipeline v%d, %d sections verified)\n", path, t.SchemaVersion, t.PipelineVersion, verified)
+	return nil
+}
+
+// pipelineWarnings reports an export generated by another pipeline version
+// than this build, or from demos stored by mixed or older pipeline versions.
+func pipelineWarnings(t simbo3TeamStats) []string {
+	var out []string
+	switch {
+	case t.PipelineVersion < aggregator.PipelineVersion:
+		out = append(out, fmt.Sprintf("generated by pipeline v%d, current is v%d; re-run export", t.PipelineVersion, aggregator.PipelineVersion))
+	case t.PipelineVersion > aggregator.PipelineVersion:
+		out = append(out, fmt.Sprintf("generated by pipeline v%d, newer than this build (v%d)", t.PipelineVersion, aggregator.PipelineVersion))
+	}
+	if t.DemoPipelineMin != t.DemoPipelineMax {
+		out = append(out, fmt.Sprintf("mixed inputs: demos parsed with pipeline v%d–v%d", t.DemoPipelineMin, t.DemoPipelineMax))
+	}
+	if t.DemoPipelineMin < aggregator.PipelineVersion {
+		out = append(out, fmt.Sprintf("stale inputs: demos as old as pipeline v%d (current v%d); re-parse them with parse --force and re-run export",
+			t.DemoPipelineMin, aggregator.PipelineVersion))
+	}
+	return out
+}
+
+// sortedKeys returns m's keys in order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
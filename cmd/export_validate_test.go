@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pable/go-cs-metrics/internal/aggregator"
+)
+
+// sampleExport returns a hashed export shaped like runExport's output.
+func sampleExport(t *testing.T) simbo3TeamStats {
+	t.Helper()
+	ctFirst := 0.56
+	out := simbo3TeamStats{
+		Team:               "Vitality",
+		PlayersRating2_3m:  []float64{1.21, 1.08, 1.02, 0.97, 0.91},
+		PlayersRating2ByID: map[string]float64{"76561198034202275": 1.21, "76561197960287930": 0.91},
+		Maps: map[string]simbo3MapStats{
+			"de_mirage":  {MapWinPct: 0.62, CTRoundWinPct: 0.55, TRoundWinPct: 0.48, FirstHalfCTRoundWinPct: &ctFirst},
+			"de_inferno": {MapWinPct: 0.5, CTRoundWinPct: 0.51, TRoundWinPct: 0.47},
+		},
+		GeneratedAt:     "2026-10-18T12:00:00Z",
+		WindowDays:      90,
+		LatestMatchDate: "2026-10-15",
+		DemoCount:       14,
+		TradeNetRate:    0.04,
+		RoundTypeWinPct: map[string]float64{"eco": 0.12, "full": 0.58},
+		PlayersImpact:   []simbo3PlayerImpact{{SteamID: "76561198034202275", Name: "ZywOo <3", Rounds: 310, WPAPerRound: 0.041}},
+		KillGini:        0.11,
+		TopKillShare:    0.27,
+		SchemaVersion:   simbo3SchemaVersion,
+		PipelineVersion: aggregator.PipelineVersion,
+		DemoPipelineMin: aggregator.PipelineVersion,
+		DemoPipelineMax: aggregator.PipelineVersion,
+	}
+	var err error
+	if out.Hashes, err = simbo3Hashes(out); err != nil {
+		t.Fatalf("simbo3Hashes: %v", err)
+	}
+	return out
+}
+
+// writeExport writes v the way runExport writes --out and returns the path.
+func writeExport(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "team.json")
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	return path
+}
+
+func TestValidateExportRoundTrip(t *testing.T) {
+	out := sampleExport(t)
+	if len(out.Hashes) != len(simbo3Sections(out)) {
+		t.Fatalf("got %d hashes, want one per section (%d)", len(out.Hashes), len(simbo3Sections(out)))
+	}
+	var w bytes.Buffer
+	if err := validateExport(&w, writeExport(t, out)); err != nil {
+		t.Fatalf("validateExport on an unedited export: %v", err)
+	}
+	if !strings.Contains(w.String(), ": OK (schema v1") || !strings.Contains(w.String(), "6 sections verified") {
+		t.Errorf("result line = %q", w.String())
+	}
+}
+
+func TestValidateExportDetectsEdits(t *testing.T) {
+	cases := []struct {
+		name    string
+		section string
+		edit    func(*simbo3TeamStats)
+	}{
+		{"rating", "ratings", func(t *simbo3TeamStats) { t.PlayersRating2_3m[0] = 1.22 }},
+		{"map side win", "maps", func(t *simbo3TeamStats) {
+			m := t.Maps["de_mirage"]
+			m.CTRoundWinPct = 0.56
+			t.Maps["de_mirage"] = m
+		}},
+		{"demo count", "provenance", func(t *simbo3TeamStats) { t.DemoCount = 15 }},
+		{"impact name", "impact", func(t *simbo3TeamStats) { t.PlayersImpact[0].Name = "ZywOo" }},
+		{"round type", "economy", func(t *simbo3TeamStats) { t.RoundTypeWinPct["eco"] = 0.2 }},
+		{"omitted field set", "concentration", func(t *simbo3TeamStats) { t.DamageGini = 0.09 }},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out := sampleExport(t)
+			c.edit(&out)
+			err := validateExport(&bytes.Buffer{}, writeExport(t, out))
+			if err == nil {
+				t.Fatal("validateExport accepted an edited export")
+			}
+			want := "section " + c.section + " does not match its hash"
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("error = %q, want it to contain %q", err, want)
+			}
+			if strings.Count(err.Error(), "does not match its hash") != 1 {
+				t.Errorf("error = %q, want only the %s section to fail", err, c.section)
+			}
+		})
+	}
+}
+
+func TestValidateExportRejects(t *testing.T) {
+	t.Run("unknown field", func(t *testing.T) {
+		raw := map[string]any{}
+		b, _ := json.Marshal(sampleExport(t))
+		if err := json.Unmarshal(b, &raw); err != nil {
+			t.Fatal(err)
+		}
+		raw["extra"] = 1
+		err := validateExport(&bytes.Buffer{}, writeExport(t, raw))
+		if err == nil || !strings.Contains(err.Error(), "unknown field") {
+			t.Errorf("err = %v, want unknown field", err)
+		}
+	})
+	t.Run("no schema version", func(t *testing.T) {
+		out := sampleExport(t)
+		out.SchemaVersion = 0
+		err := validateExport(&bytes.Buffer{}, writeExport(t, out))
+		if err == nil || !strings.Contains(err.Error(), "no schema_version") {
+			t.Errorf("err = %v, want no schema_version", err)
+		}
+	})
+	t.Run("missing hash", func(t *testing.T) {
+		out := sampleExport(t)
+		delete(out.Hashes, "maps")
+		err := validateExport(&bytes.Buffer{}, writeExport(t, out))
+		if err == nil || !strings.Contains(err.Error(), "no hash for section maps") {
+			t.Errorf("err = %v, want no hash for section maps", err)
+		}
+	})
+}
+
+func TestCanonicalJSON(t *testing.T) {
+	got, err := canonicalJSON(struct {
+		B string         `json:"b"`
+		A map[string]int `json:"a"`
+		N float64        `json:"n"`
+	}{B: "<x&y>", A: map[string]int{"z": 1, "m": 2}, N: 0.1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"a":{"m":2,"z":1},"b":"<x&y>","n":0.1}`
+	if string(got) != want {
+		t.Errorf("canonicalJSON = %s, want %s", got, want)
+	}
+}
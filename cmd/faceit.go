@@ -0,0 +1,462 @@
+package cmd
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/pable/go-cs-metrics/internal/config"
+	"github.com/pable/go-cs-metrics/internal/faceit"
+	"github.com/pable/go-cs-metrics/internal/model"
+	"github.com/pable/go-cs-metrics/internal/parser"
+	"github.com/pable/go-cs-metrics/internal/storage"
+)
+
+// lookupFaceitPlayer resolves a FACEIT nickname or Steam ID64.
+func lookupFaceitPlayer(client *faceit.Client, query string) (*faceit.Player, error) {
+	var fp *faceit.Player
+	var err error
+	if looksLikeSteamID(query) {
+		fp, err = client.GetPlayerBySteamID(query)
+	} else {
+		fp, err = client.GetPlayerByNickname(query)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lookup player %q: %w", query, err)
+	}
+	return fp, nil
+}
+
+// fetchRun configures one fetchMatches run: which matches of the history to
+// take and how their demos are stored.
+type fetchRun struct {
+	mapFilter    string // only matches on this map; empty for any
+	level        int    // only matches at this skill level; 0 for any
+	count        int    // matches to ingest
+	tier         string // tier label stored with each demo
+	baseline     bool   // store the demos as baseline demos of tier
+	downloaders  int    // concurrent demo downloads
+	workers      int    // parse+aggregate workers (0 = NumCPU)
+	downloadsKey string // Downloads API token; when set, every demo URL is exchanged for a signed one
+}
+
+// demoURL returns the URL to download a match's demo from: a signed URL from
+// the Downloads API when run.downloadsKey is set, otherwise demoURL itself
+// (see usableDemoURL).
+func (run fetchRun) demoURL(demoURL string) (string, error) {
+	if run.downloadsKey == "" {
+		return usableDemoURL(demoURL), nil
+	}
+	return resolveDemoURL(demoURL, run.downloadsKey)
+}
+
+// fetchMatches downloads, parses and stores up to run.count matches of
+// history and returns how many were ingested (demos already stored count).
+// Downloads, parses and database writes overlap: run.downloaders demos are
+// fetched at once while the workers parse earlier ones.
+func fetchMatches(db *storage.DB, client *faceit.Client, history []faceit.MatchHistoryItem, run fetchRun) (int, error) {
+	count := run.count
+	sights, err := trackedSightPlayers()
+	if err != nil {
+		return 0, err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return 0, err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "csmetrics-*")
+	if err != nil {
+		return 0, fmt.Errorf("temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	numWorkers := run.workers
+	if numWorkers <= 0 {
+		numWorkers = runtime.NumCPU()
+	}
+	downloaders := max(run.downloaders, 1)
+	fmt.Printf("Fetching up to %d matches with %d download and %d parse worker(s)...\n", count, downloaders, numWorkers)
+
+	// Pipeline: the selector picks matches from the history, download workers
+	// fetch their demos, the bulk-parse worker pool (runDemoWorker) parses and
+	// aggregates them, and this goroutine stores the results. Each selected
+	// match holds one of count slots until it is ingested (slot kept) or fails
+	// (slot returned), so the selector never runs ahead of the target. jobs
+	// holds at most numWorkers downloaded demos waiting for a parse worker.
+	slots := make(chan struct{}, count)
+	for range count {
+		slots <- struct{}{}
+	}
+	done := make(chan struct{})
+	var stopOnce sync.Once
+	stop := func() { stopOnce.Do(func() { close(done) }) }
+	defer stop()
+
+	items := make(chan fetchItem)
+	jobs := make(chan parseJob, numWorkers)
+	results := make(chan parseResult, numWorkers)
+
+	var mu sync.Mutex
+	byIdx := make(map[int]fetchItem) // parseJob.idx → downloaded match
+
+	go func() {
+		defer close(items)
+		selectFetchItems(client, history, run.mapFilter, run.level, slots, done, items)
+	}()
+
+	var dlWG sync.WaitGroup
+	for range downloaders {
+		dlWG.Add(1)
+		go func() {
+			defer dlWG.Done()
+			for it := range items {
+				t0 := time.Now()
+				var demPath string
+				url, err := run.demoURL(it.demoURL)
+				if err == nil {
+					demPath, err = downloadAndDecompress(url, tmpDir, it.matchID)
+				}
+				it.downloadElapsed = time.Since(t0)
+				mu.Lock()
+				idx := len(byIdx)
+				byIdx[idx] = it
+				mu.Unlock()
+				if err != nil {
+					results <- parseResult{idx: idx, err: fmt.Errorf("download: %w", err)}
+					continue
+				}
+				qh, _ := parser.QuickHash(demPath)
+				jobs <- parseJob{idx: idx, path: demPath, quickHash: qh}
+			}
+		}()
+	}
+	var parseWG sync.WaitGroup
+	for range numWorkers {
+		parseWG.Add(1)
+		go func() {
+			defer parseWG.Done()
+			runDemoWorker(jobs, results, "FACEIT")
+		}()
+	}
+	go func() {
+		dlWG.Wait()
+		close(jobs)
+		parseWG.Wait()
+		close(results)
+	}()
+
+	// Drain results until every stage has exited; after the target is met or
+	// a write fails, nothing more is stored.
+	ingested := 0
+	var writeErr error
+	for res := range results {
+		if res.path != "" {
+			os.Remove(res.path)
+		}
+		if writeErr != nil || ingested >= count {
+			continue
+		}
+		mu.Lock()
+		it := byIdx[res.idx]
+		mu.Unlock()
+		if res.err != nil {
+			fmt.Fprintf(os.Stderr, "  [error] %s: %v\n", it.matchID, res.err)
+			slots <- struct{}{}
+			continue
+		}
+		msg, err := storeFetched(db, it, res, run, cfg, sights)
+		if err != nil {
+			writeErr = err
+			stop()
+			continue
+		}
+		ingested++
+		fmt.Printf("[%d/%d] %s  %s  (download %s  parse %s  agg %s)\n", ingested, count, it.matchID, msg,
+			it.downloadElapsed.Round(time.Millisecond),
+			res.parseElapsed.Round(time.Millisecond),
+			res.aggElapsed.Round(time.Millisecond))
+		if ingested == count {
+			stop()
+		}
+	}
+	if writeErr != nil {
+		return ingested, writeErr
+	}
+	return ingested, nil
+}
+
+// fetchItem is one FACEIT match picked for ingestion by selectFetchItems.
+type fetchItem struct {
+	matchID         string
+	matchDate       string
+	demoURL         string
+	downloadElapsed time.Duration // set by the download worker
+}
+
+// selectFetchItems walks history and sends each finished match that passes
+// the map and level filters and has a demo to items. A slot is taken before
+// each match is looked up and handed back when the match is not selected. It
+// returns when history is exhausted or done is closed.
+func selectFetchItems(client *faceit.Client, history []faceit.MatchHistoryItem, mapFilter string, level int,
+	slots chan struct{}, done <-chan struct{}, items chan<- fetchItem) {
+	release := func() { slots <- struct{}{} }
+	for _, item := range history {
+		if !strings.EqualFold(item.Status, "FINISHED") {
+			continue
+		}
+		select {
+		case <-slots:
+		case <-done:
+			return
+		}
+		match, err := client.GetMatch(item.MatchID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  [skip] %s: %v\n", item.MatchID, err)
+			release()
+			continue
+		}
+		if (mapFilter != "" && match.MapName() != mapFilter) || (level > 0 && match.SkillLevel != level) {
+			release()
+			continue
+		}
+		if len(match.DemoURLs) == 0 {
+			fmt.Printf("  [skip] %s: no demo URL\n", item.MatchID)
+			release()
+			continue
+		}
+		it := fetchItem{
+			matchID:   item.MatchID,
+			matchDate: time.Unix(match.StartedAt, 0).UTC().Format("2006-01-02"),
+			demoURL:   match.DemoURLs[0],
+		}
+		fmt.Printf("  queued %s  map=%-15s  level=%d  date=%s\n", item.MatchID, match.MapName(), match.SkillLevel, it.matchDate)
+		select {
+		case items <- it:
+		case <-done:
+			return
+		}
+	}
+}
+
+// storeFetched stores one parsed FACEIT demo with every table parse stores,
+// or only records its source when the demo is already stored, and returns a
+// one-line outcome.
+func storeFetched(db *storage.DB, it fetchItem, res parseResult, run fetchRun, cfg config.Config, sights map[uint64]bool) (string, error) {
+	raw := res.raw
+	exists, err := db.DemoExists(raw.DemoHash)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		if err := db.UpdateDemoSource(raw.DemoHash, faceitSource(it.matchID)); err != nil {
+			return "", fmt.Errorf("update demo source: %w", err)
+		}
+		return "already stored", nil
+	}
+
+	raw.MatchDate = it.matchDate
+	data := newDemoData(raw, res.quickHash, cfg, sights)
+	data.MatchStats, data.RoundStats, data.WeaponStats, data.DuelSegments = res.matchStats, res.roundStats, res.weaponStats, res.duelSegs
+	data.Summary.Tier = run.tier
+	data.Summary.IsBaseline = run.baseline
+	data.Summary.Source = faceitSource(it.matchID)
+	if err := db.ReplaceDemo(data); err != nil {
+		return "", fmt.Errorf("store demo: %w", err)
+	}
+	return fmt.Sprintf("stored: %s  %d players, %d rounds", raw.MapName, len(res.matchStats), len(raw.Rounds)), nil
+}
+
+// faceitSource returns the provenance of a demo downloaded from the FACEIT
+// match matchID. The downloaded file is temporary, so no path is recorded.
+func faceitSource(matchID string) model.DemoSource {
+	return model.DemoSource{
+		Origin:  model.SourceFACEIT,
+		URL:     "https://www.faceit.com/en/cs2/room/" + matchID,
+		MatchID: matchID,
+	}
+}
+
+// downloadAndDecompress downloads a demo URL (handling bzip2, gzip or zstd)
+// to dir. The compression is taken from the URL path, so signed URLs with a
+// query string are recognised too.
+func downloadAndDecompress(demoURL, dir, matchID string) (string, error) {
+	u, err := url.Parse(demoURL)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.Get(demoURL) //nolint:gosec
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	outPath := filepath.Join(dir, matchID+".dem")
+	f, err := os.Create(outPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var src io.Reader = resp.Body
+	switch {
+	case strings.HasSuffix(u.Path, ".bz2"):
+		src = bzip2.NewReader(resp.Body)
+	case strings.HasSuffix(u.Path, ".zst"):
+		dec, err := zstd.NewReader(resp.Body)
+		if err != nil {
+			return "", fmt.Errorf("zstd: %w", err)
+		}
+		defer dec.Close()
+		src = dec
+	case strings.HasSuffix(u.Path, ".gz") || resp.Header.Get("Content-Encoding") == "gzip":
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return "", fmt.Errorf("gzip: %w", err)
+		}
+		defer gz.Close()
+		src = gz
+	}
+
+	if _, err := io.Copy(f, src); err != nil {
+		os.Remove(outPath)
+		return "", fmt.Errorf("write: %w", err)
+	}
+	return outPath, nil
+}
+
+// usableDemoURL returns demoURL, exchanged for a signed download URL when it
+// points at a known-broken CDN and a Downloads API key is configured. Failures
+// are warned about and the original URL is returned.
+func usableDemoURL(demoURL string) string {
+	if !isKnownBrokenCDN(demoURL) {
+		return demoURL
+	}
+	dlKey := loadFaceitDownloadsKey()
+	if dlKey == "" {
+		fmt.Fprintf(os.Stderr, "  [warn] demo CDN URL won't resolve; set FACEIT_DOWNLOADS_KEY or create ~/.csmetrics/faceit_downloads_key\n")
+		return demoURL
+	}
+	resolved, err := resolveDemoURL(demoURL, dlKey)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  [warn] URL resolution failed: %v\n", err)
+		return demoURL
+	}
+	return resolved
+}
+
+// isKnownBrokenCDN returns true for FACEIT CDN hostnames that have no DNS record.
+func isKnownBrokenCDN(demoURL string) bool {
+	return strings.Contains(demoURL, "backblaze.faceit-cdn.net")
+}
+
+// resolveDemoURL exchanges a FACEIT demo URL from the match data for a signed
+// download URL using the official FACEIT Downloads API (https://docs.faceit.com/getting-started/Guides/download-api).
+// downloadsKey must be a Downloads API access token (separate from the Data API key).
+func resolveDemoURL(demoURL, downloadsKey string) (string, error) {
+	body, err := json.Marshal(map[string]string{"resource_url": demoURL})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest("POST",
+		"https://open.faceit.com/download/v2/demos/download",
+		bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+downloadsKey)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		snippet := string(respBody)
+		if len(snippet) > 200 {
+			snippet = snippet[:200]
+		}
+		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, snippet)
+	}
+
+	var result struct {
+		Payload struct {
+			DownloadURL string `json:"download_url"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("decode: %w", err)
+	}
+	if result.Payload.DownloadURL == "" {
+		return "", fmt.Errorf("empty download_url in response")
+	}
+	return result.Payload.DownloadURL, nil
+}
+
+// loadFaceitDownloadsKey returns the FACEIT Downloads API access token from the
+// FACEIT_DOWNLOADS_KEY environment variable or ~/.csmetrics/faceit_downloads_key.
+// This is a separate token from the Data API key — apply at https://fce.gg/downloads-api-application.
+func loadFaceitDownloadsKey() string {
+	if v := os.Getenv("FACEIT_DOWNLOADS_KEY"); v != "" {
+		return v
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Join(home, ".csmetrics", "faceit_downloads_key"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// loadFaceitAPIKey returns the FACEIT Data API key from the FACEIT_API_KEY
+// environment variable or ~/.csmetrics/faceit_api_key file.
+func loadFaceitAPIKey() (string, error) {
+	if key := os.Getenv("FACEIT_API_KEY"); key != "" {
+		return key, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(filepath.Join(home, ".csmetrics", "faceit_api_key"))
+	if err != nil {
+		return "", fmt.Errorf("FACEIT API key not found: set FACEIT_API_KEY or create ~/.csmetrics/faceit_api_key")
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// looksLikeSteamID returns true if s is a numeric string of at least 15 digits,
+// consistent with a Steam ID64.
+func looksLikeSteamID(s string) bool {
+	if len(s) < 15 {
+		return false
+	}
+	_, err := strconv.ParseUint(s, 10, 64)
+	return err == nil
+}
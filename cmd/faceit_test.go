@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestDownloadAndDecompress(t *testing.T) {
+	demo := []byte("HL2DEMO\x00 demo body")
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write(demo)
+	zw.Close()
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zst := enc.EncodeAll(demo, nil)
+	enc.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/m.dem":
+			w.Write(demo)
+		case "/m.dem.gz":
+			w.Write(gz.Bytes())
+		case "/m.dem.zst":
+			w.Write(zst)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	cases := []struct{ name, path string }{
+		{"plain", "/m.dem"},
+		{"gzip", "/m.dem.gz"},
+		{"zstd", "/m.dem.zst"},
+		{"signed zstd with query", "/m.dem.zst?X-Amz-Signature=abc&X-Amz-Expires=600"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out, err := downloadAndDecompress(srv.URL+c.path, t.TempDir(), "1-abc")
+			if err != nil {
+				t.Fatalf("downloadAndDecompress: %v", err)
+			}
+			got, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, demo) {
+				t.Errorf("demo = %q, want %q", got, demo)
+			}
+		})
+	}
+
+	if _, err := downloadAndDecompress(srv.URL+"/missing.dem", t.TempDir(), "1-abc"); err == nil {
+		t.Error("HTTP 404: want an error")
+	}
+}
@@ -44,6 +44,7 @@ the GC can provide. To ingest your MM/Premier demos:
        - Refrag (refrag.gg) — exports .dem files
        - cs-demo-manager (github.com/akiver/cs-demo-manager)
   3. Ingest with: csmetrics parse --dir <folder-with-demos>
+     (or parse <demo.dem> --share-code <code> to record the match's share code)
 
 Credentials can be provided as flags or environment variables:
   --steam-id    / STEAM_ID         Steam ID64 (e.g. 76561198012345678)
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pable/go-cs-metrics/internal/aggregator"
+	"github.com/pable/go-cs-metrics/internal/model"
+	"github.com/pable/go-cs-metrics/internal/report"
+	"github.com/pable/go-cs-metrics/internal/storage"
+)
+
+var (
+	fingerprintMinMatches int
+	fingerprintTop        int
+	fingerprintProbable   float64
+	fingerprintPossible   float64
+)
+
+// fingerprintCmd is the cobra command comparing one player's mechanical
+// fingerprint against every tracked player.
+var fingerprintCmd = &cobra.Command{
+	Use:   "fingerprint <steamid64>",
+	Short: "Compare a player's aim fingerprint with tracked players to flag probable alt accounts",
+	Long: `Build a player's mechanical fingerprint from every stored match — TTK,
+counter-strafe %, crosshair placement (median angle and % under 5°) and
+first-hit headshot rate per weapon bucket — and rank every other player with
+at least --min-matches matches by distance from it.
+
+DIST is the mean absolute difference over the features both players have
+samples for, in standard deviations of that feature across tracked players.
+Players at DIST ≤ --probable are flagged as probable alts, ≤ --possible as
+possible. Players who shared a match with the target are never compared.
+
+Caveat: the fingerprint is a lead, not proof. Players of similar level and
+role converge on similar numbers, and few matches give noisy medians.
+
+Example:
+  csmetrics fingerprint 76561198012345678 --min-matches 5 --top 5`,
+	Args: cobra.ExactArgs(1),
+	RunE: runFingerprint,
+}
+
+func init() {
+	fingerprintCmd.Flags().IntVar(&fingerprintMinMatches, "min-matches", 3, "minimum stored matches for a tracked player to be compared")
+	fingerprintCmd.Flags().IntVar(&fingerprintTop, "top", 10, "most similar players to show")
+	fingerprintCmd.Flags().Float64Var(&fingerprintProbable, "probable", 0.5, "DIST at or below which a player is flagged as a probable alt")
+	fingerprintCmd.Flags().Float64Var(&fingerprintPossible, "possible", 1.0, "DIST at or below which a player is flagged as a possible alt")
+}
+
+// runFingerprint loads every tracked player's fingerprint and prints the
+// target's closest matches.
+func runFingerprint(cmd *cobra.Command, args []string) error {
+	steamID, err := resolveSteamID(args[0])
+	if err != nil {
+		return err
+	}
+	if fingerprintTop < 1 || fingerprintMinMatches < 1 {
+		return withExitCode(ExitUsage, fmt.Errorf("--top and --min-matches must be ≥ 1"))
+	}
+	if fingerprintProbable < 0 || fingerprintPossible < fingerprintProbable {
+		return withExitCode(ExitUsage, fmt.Errorf("want 0 ≤ --probable ≤ --possible, got %.2f and %.2f", fingerprintProbable, fingerprintPossible))
+	}
+
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
+	}
+	defer db.Close()
+
+	players, err := db.GetPlayerFingerprints(fingerprintMinMatches, steamID)
+	if err != nil {
+		return fmt.Errorf("player fingerprints: %w", err)
+	}
+	coPlayers, err := db.GetCoPlayers(steamID)
+	if err != nil {
+		return fmt.Errorf("co-players: %w", err)
+	}
+
+	var target *model.PlayerFingerprint
+	var candidates []model.PlayerFingerprint
+	excluded := 0
+	for i, p := range players {
+		switch {
+		case p.SteamID == steamID:
+			target = &players[i]
+		case coPlayers[p.SteamID]:
+			excluded++
+		default:
+			candidates = append(candidates, p)
+		}
+	}
+	if target == nil {
+		return noDataError("no stored matches for player %d", steamID)
+	}
+
+	matches := aggregator.CompareFingerprints(*target, candidates)
+	report.PrintFingerprintTable(os.Stdout, *target, matches, fingerprintTop,
+		fingerprintProbable, fingerprintPossible, excluded)
+	return nil
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+	"github.com/pable/go-cs-metrics/internal/steam"
+)
+
+// focusNamePrefix marks a --player value as a nickname hint rather than a
+// SteamID64 (e.g. --player name:s1mple).
+const focusNamePrefix = "name:"
+
+// validateFocusPlayer checks the --player value before any work is done:
+// empty, a player ID in any of steamIDFormats or name:<nickname>.
+func validateFocusPlayer(v string) error {
+	if v == "" {
+		return nil
+	}
+	if nick, ok := strings.CutPrefix(v, focusNamePrefix); ok {
+		if normalizeNick(nick) == "" {
+			return fmt.Errorf("--player %q: empty nickname", v)
+		}
+		return nil
+	}
+	if _, err := steam.ParseSteamID(v); err == nil {
+		return nil
+	}
+	if _, ok := steam.VanityName(v); ok {
+		return nil
+	}
+	return fmt.Errorf("--player %q: want a %s, or name:<nickname>", v, steamIDFormats)
+}
+
+// resolveFocusPlayer returns the focus SteamID64 for a --player value against
+// the demo's roster: 0 when empty, the resolved ID for a player ID (see
+// resolveSteamID), or the one player whose name best matches a
+// name:<nickname> hint (see matchNickname).
+func resolveFocusPlayer(v string, stats []model.PlayerMatchStats) (uint64, error) {
+	if err := validateFocusPlayer(v); err != nil || v == "" {
+		return 0, err
+	}
+	nick, ok := strings.CutPrefix(v, focusNamePrefix)
+	if !ok {
+		return resolveSteamID(v)
+	}
+	return matchNickname(nick, stats)
+}
+
+// Nickname match levels, best first.
+const (
+	nickExact = iota
+	nickPrefix
+	nickContains
+	nickTypo
+	nickNone
+)
+
+// nickTypoMaxEdits is the edit distance up to which a nickname still counts as
+// a typo of a roster name; hints shorter than nickTypoMinLen must match
+// literally, since two edits would turn them into almost any short name.
+const (
+	nickTypoMaxEdits = 2
+	nickTypoMinLen   = 4
+)
+
+// matchNickname finds the roster player whose name matches nick. Names are
+// compared case-insensitively on letters and digits only, so "S1mple" finds
+// "s1mple" and "ropz" finds "ropz-"; an exact match beats a prefix, which
+// beats a substring, which beats a name within two edits (hints of four or
+// more characters). A tie at the best level is an error listing the
+// candidates.
+func matchNickname(nick string, stats []model.PlayerMatchStats) (uint64, error) {
+	want := normalizeNick(nick)
+	best := nickNone
+	var found []model.PlayerMatchStats
+	seen := make(map[uint64]bool) // a player can have a row per team
+	for _, s := range stats {
+		level := nickLevel(want, normalizeNick(s.Name))
+		switch {
+		case level < best:
+			best, found = level, []model.PlayerMatchStats{s}
+			seen = map[uint64]bool{s.SteamID: true}
+		case level == best && level != nickNone && !seen[s.SteamID]:
+			found = append(found, s)
+			seen[s.SteamID] = true
+		}
+	}
+	switch len(found) {
+	case 0:
+		var names []string
+		listed := make(map[uint64]bool)
+		for _, s := range stats {
+			if !listed[s.SteamID] {
+				names = append(names, s.Name)
+				listed[s.SteamID] = true
+			}
+		}
+		sort.Strings(names)
+		return 0, fmt.Errorf("no player matches %q in this demo (players: %s)", nick, strings.Join(names, ", "))
+	case 1:
+		return found[0].SteamID, nil
+	}
+	cands := make([]string, 0, len(found))
+	for _, s := range found {
+		cands = append(cands, fmt.Sprintf("%s (%d)", s.Name, s.SteamID))
+	}
+	return 0, fmt.Errorf("%q matches several players: %s; use a longer name or the SteamID64", nick, strings.Join(cands, ", "))
+}
+
+// nickLevel grades how well the normalized name matches the normalized want.
+func nickLevel(want, name string) int {
+	switch {
+	case name == "":
+		return nickNone
+	case name == want:
+		return nickExact
+	case strings.HasPrefix(name, want):
+		return nickPrefix
+	case strings.Contains(name, want):
+		return nickContains
+	case len([]rune(want)) >= nickTypoMinLen && editDistance(want, name) <= nickTypoMaxEdits:
+		return nickTypo
+	}
+	return nickNone
+}
+
+// normalizeNick lower-cases s and drops everything but letters and digits.
+func normalizeNick(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// editDistance returns the Levenshtein distance between a and b in runes.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
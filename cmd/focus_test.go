@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+func TestMatchNickname(t *testing.T) {
+	// roster builds one stats row per name, the n-th holding SteamID n+1.
+	roster := func(names ...string) []model.PlayerMatchStats {
+		out := make([]model.PlayerMatchStats, len(names))
+		for i, n := range names {
+			out[i] = model.PlayerMatchStats{SteamID: uint64(i + 1), Name: n}
+		}
+		return out
+	}
+	lobby := roster("s1mple", "s1mpleFan", "ropz-", "ZywOo", "m0NESY", "b1t")
+	// A player who switched teams has a stats row per team.
+	twoRows := append(lobby, model.PlayerMatchStats{SteamID: 3, Name: "ropz-"})
+
+	cases := []struct {
+		name    string
+		nick    string
+		stats   []model.PlayerMatchStats
+		want    uint64
+		wantErr string
+	}{
+		{name: "exact beats prefix", nick: "s1mple", stats: lobby, want: 1},
+		{name: "case and punctuation ignored", nick: "ROPZ", stats: lobby, want: 3},
+		{name: "prefix", nick: "zyw", stats: lobby, want: 4},
+		{name: "prefix beats contains", nick: "mon", stats: roster("xmonx", "monesy"), want: 2},
+		{name: "contains", nick: "nes", stats: lobby, want: 5},
+		{name: "typo within two edits", nick: "zywoo1", stats: lobby, want: 4},
+		{name: "typo of two edits", nick: "monesi", stats: lobby, want: 5},
+		{name: "three edits is no match", nick: "xzywxox", stats: lobby, wantErr: "no player matches"},
+		{name: "short hint rejects a typo", nick: "bit", stats: lobby, wantErr: "no player matches"},
+		{name: "tie at the same level", nick: "s1mp", stats: lobby, wantErr: "matches several players"},
+		{name: "tie between typos", nick: "jenny", stats: roster("benny", "penny"), wantErr: "matches several players"},
+		{name: "duplicate rows of one player are not a tie", nick: "ropz", stats: twoRows, want: 3},
+		{name: "empty names never match", nick: "ab", stats: roster("", "abc"), want: 2},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := matchNickname(c.nick, c.stats)
+			if c.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+					t.Fatalf("matchNickname(%q) = %d, %v; want error containing %q", c.nick, got, err, c.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("matchNickname(%q): %v", c.nick, err)
+			}
+			if got != c.want {
+				t.Errorf("matchNickname(%q) = %d, want %d", c.nick, got, c.want)
+			}
+		})
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"zywoo", "zywoo", 0},
+		{"zywoo", "zyowo", 2},
+		{"kitten", "sitting", 3},
+		{"monesi", "m0nesy", 2},
+		{"ñandú", "nandu", 2},
+	}
+	for _, c := range cases {
+		if got := editDistance(c.a, c.b); got != c.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+		}
+		if got := editDistance(c.b, c.a); got != c.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", c.b, c.a, got, c.want)
+		}
+	}
+}
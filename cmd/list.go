@@ -6,9 +6,25 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/pable/go-cs-metrics/internal/aggregator"
+	"github.com/pable/go-cs-metrics/internal/model"
 	"github.com/pable/go-cs-metrics/internal/storage"
 )
 
+var (
+	// listOutdated restricts the listing to demos aggregated by an older pipeline version.
+	listOutdated bool
+
+	// Filters and pagination for the listing.
+	listMap    string
+	listSince  string
+	listType   string
+	listTier   string
+	listPlayer string
+	listLimit  int
+	listOffset int
+)
+
 // listCmd is the cobra command that lists all stored demos in the database.
 var listCmd = &cobra.Command{
 	Use:   "list",
@@ -17,31 +33,92 @@ var listCmd = &cobra.Command{
 	RunE:  runList,
 }
 
-// runList opens the database and prints a summary table of all stored demos.
+func init() {
+	listCmd.Flags().BoolVar(&listOutdated, "outdated", false, "only show demos aggregated by an older pipeline version")
+	listCmd.Flags().StringVar(&listMap, "map", "", "only show demos on this map (e.g. mirage, de_mirage)")
+	listCmd.Flags().StringVar(&listSince, "since", "", "only show demos on or after this date (YYYY-MM-DD)")
+	listCmd.Flags().StringVar(&listType, "type", "", "only show demos of this match type (e.g. scrim, premier)")
+	listCmd.Flags().StringVar(&listTier, "tier", "", "only show demos with this tier label (e.g. faceit-5)")
+	listCmd.Flags().StringVar(&listPlayer, "player", "", "only show demos this SteamID64 played in")
+	listCmd.Flags().IntVar(&listLimit, "limit", 0, "show at most N demos (0 = all)")
+	listCmd.Flags().IntVar(&listOffset, "offset", 0, "skip the first N demos (newest first)")
+}
+
+// runList opens the database and prints a summary table of the stored demos
+// that pass the filters, one page at a time when --limit/--offset are set.
 func runList(cmd *cobra.Command, args []string) error {
+	if listLimit < 0 || listOffset < 0 {
+		return fmt.Errorf("--limit and --offset must not be negative")
+	}
+	filter := storage.DemoFilter{
+		Map:    listMap,
+		Since:  listSince,
+		Type:   listType,
+		Tier:   listTier,
+		Limit:  listLimit,
+		Offset: listOffset,
+	}
+	if listPlayer != "" {
+		id, err := resolveSteamID(listPlayer)
+		if err != nil {
+			return err
+		}
+		filter.SteamID = id
+	}
+	filtered := filter != storage.DemoFilter{Limit: listLimit, Offset: listOffset}
+
 	db, err := storage.Open(dbPath)
 	if err != nil {
 		return fmt.Errorf("open storage: %w", err)
 	}
 	defer db.Close()
 
-	demos, err := db.ListDemos()
+	var demos []model.MatchSummary
+	if listOutdated {
+		demos, err = db.ListOutdatedDemos(aggregator.PipelineVersion, filter)
+	} else {
+		demos, err = db.ListDemos(filter)
+	}
 	if err != nil {
 		return fmt.Errorf("list demos: %w", err)
 	}
 	if len(demos) == 0 {
+		if listOffset > 0 {
+			fmt.Fprintf(os.Stdout, "No demos past offset %d.\n", listOffset)
+			return nil
+		}
+		if filtered {
+			fmt.Fprintln(os.Stdout, "No demos match the filters.")
+			return nil
+		}
+		if listOutdated {
+			fmt.Fprintf(os.Stdout, "All demos are up to date (pipeline v%d).\n", aggregator.PipelineVersion)
+			return nil
+		}
 		fmt.Fprintln(os.Stdout, "No demos stored yet. Run 'csmetrics parse <demo.dem>' to add one.")
 		return nil
 	}
 
-	fmt.Fprintf(os.Stdout, "%-14s  %-12s  %-10s  %-12s  %6s  %s\n",
-		"HASH", "MAP", "DATE", "TYPE", "SCORE", "TICK")
-	fmt.Fprintf(os.Stdout, "%-14s  %-12s  %-10s  %-12s  %6s  %s\n",
-		"──────────────", "────────────", "──────────", "────────────", "──────", "────")
+	fmt.Fprintf(os.Stdout, "%-14s  %-12s  %-10s  %-12s  %6s  %4s  %-4s  %s\n",
+		"HASH", "MAP", "DATE", "TYPE", "SCORE", "TICK", "VER", "SOURCE")
+	fmt.Fprintf(os.Stdout, "%-14s  %-12s  %-10s  %-12s  %6s  %4s  %-4s  %s\n",
+		"──────────────", "────────────", "──────────", "────────────", "──────", "────", "────", "──────")
 	for _, d := range demos {
 		score := fmt.Sprintf("%d-%d", d.CTScore, d.TScore)
-		fmt.Fprintf(os.Stdout, "%-14s  %-12s  %-10s  %-12s  %6s  %.0f\n",
-			d.DemoHash[:12], d.MapName, d.MatchDate, d.MatchType, score, d.Tickrate)
+		source := d.Source.Label()
+		if source == "" {
+			source = "-"
+		}
+		fmt.Fprintf(os.Stdout, "%-14s  %-12s  %-10s  %-12s  %6s  %4.0f  %-4s  %s\n",
+			d.DemoHash[:12], d.MapName, d.MatchDate, d.MatchType, score, d.Tickrate, pipelineVersionLabel(d.PipelineVersion), source)
+	}
+	if listOutdated {
+		fmt.Fprintf(os.Stdout, "\n%d demo(s) older than pipeline v%d. Re-run parse on their files to refresh their stats.\n",
+			len(demos), aggregator.PipelineVersion)
+	}
+	if listLimit > 0 && len(demos) == listLimit {
+		fmt.Fprintf(os.Stdout, "\nShowing demos %d–%d. Next page: --offset %d\n",
+			listOffset+1, listOffset+len(demos), listOffset+listLimit)
 	}
 	return nil
 }
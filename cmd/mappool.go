@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+	"github.com/pable/go-cs-metrics/internal/report"
+	"github.com/pable/go-cs-metrics/internal/storage"
+)
+
+// activeDutyPool is the default --pool: the current CS2 Active Duty maps.
+var activeDutyPool = []string{"Ancient", "Anubis", "Dust2", "Inferno", "Mirage", "Nuke", "Train"}
+
+var (
+	mapPoolTeam       string
+	mapPoolPlayers    string
+	mapPoolRoster     string
+	mapPoolMaps       string
+	mapPoolSince      int
+	mapPoolQuorum     int
+	mapPoolEvent      string
+	mapPoolStaleDays  int
+	mapPoolMinMatches int
+)
+
+var mapPoolCmd = &cobra.Command{
+	Use:   "map-pool",
+	Short: "Per-map match counts, win rates and staleness for a roster, with practice gaps",
+	Long: `Report a roster's coverage of the map pool: for every map, the qualifying
+demos in the look-back window (the same roster/quorum selection as export),
+matches won, CT and T round win rates, the last date played and the days
+since, and how many matches fall within --stale-days of the reference date.
+A second table shows the bomb site preference per map: the share of the
+roster's T-side plants at each site and their win rate, and the share of
+the plants it conceded on CT at each site and how often it still won.
+
+The reference date is today, or --event (YYYY-MM-DD) to prepare for an
+upcoming event; with --event only demos before that date count. Each pool
+map gets a status:
+  no data  not played in the window
+  stale    last played more than --stale-days before the reference date
+  thin     fewer than --min-matches matches within --stale-days
+  ok       otherwise
+Maps with any status other than ok are listed as practice gaps. Maps played
+outside the pool are noted below the table.
+
+Example:
+  csmetrics map-pool --roster navi.json --event 2026-11-20
+  csmetrics map-pool --players "7656...,7656..." --pool Mirage,Inferno,Nuke --format json`,
+	RunE: runMapPool,
+}
+
+func init() {
+	mapPoolCmd.Flags().StringVar(&mapPoolTeam, "team", "", "team name for the report title")
+	mapPoolCmd.Flags().StringVar(&mapPoolPlayers, "players", "", "comma-separated player IDs (SteamID64, steamID3, steamID2, profile URL or custom URL name)")
+	mapPoolCmd.Flags().StringVar(&mapPoolRoster, "roster", "", `roster JSON file: {"team":"...","players":["...",...]}`)
+	mapPoolCmd.Flags().StringVar(&mapPoolMaps, "pool", strings.Join(activeDutyPool, ","), "comma-separated map pool")
+	mapPoolCmd.Flags().IntVar(&mapPoolSince, "since", 90, "look-back window in days before the reference date")
+	mapPoolCmd.Flags().IntVar(&mapPoolQuorum, "quorum", 3, "min roster players per demo to include it")
+	mapPoolCmd.Flags().StringVar(&mapPoolEvent, "event", "", "reference date YYYY-MM-DD (default today); only earlier demos count")
+	mapPoolCmd.Flags().IntVar(&mapPoolStaleDays, "stale-days", 30, "a map last played longer ago than this is stale; also the recent-match window")
+	mapPoolCmd.Flags().IntVar(&mapPoolMinMatches, "min-matches", 3, "recent matches a map needs to not be thin")
+}
+
+func runMapPool(_ *cobra.Command, _ []string) error {
+	teamName, steamIDs, err := resolveRoster(mapPoolTeam, mapPoolPlayers, mapPoolRoster)
+	if err != nil {
+		return err
+	}
+	if len(steamIDs) == 0 {
+		return withExitCode(ExitUsage, fmt.Errorf("no players specified: use --players or --roster"))
+	}
+	pool := splitMapPool(mapPoolMaps)
+	if len(pool) == 0 {
+		return withExitCode(ExitUsage, fmt.Errorf("--pool is empty"))
+	}
+
+	ref := time.Now()
+	if mapPoolEvent != "" {
+		ref, err = time.Parse("2006-01-02", mapPoolEvent)
+		if err != nil {
+			return withExitCode(ExitUsage, fmt.Errorf("invalid --event %q: want YYYY-MM-DD", mapPoolEvent))
+		}
+	}
+	since := ref.AddDate(0, 0, -mapPoolSince)
+
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
+	}
+	defer db.Close()
+
+	var demos []storage.DemoRef
+	if mapPoolEvent != "" {
+		demos, err = db.QualifyingDemosWindow(steamIDs, since, ref, mapPoolQuorum)
+	} else {
+		demos, err = db.QualifyingDemos(steamIDs, since, mapPoolQuorum)
+	}
+	if err != nil {
+		return fmt.Errorf("query qualifying demos: %w", err)
+	}
+	hashes := make([]string, 0, len(demos))
+	for _, d := range demos {
+		hashes = append(hashes, d.Hash)
+	}
+	outcomes, err := db.MapWinOutcomes(steamIDs, hashes)
+	if err != nil {
+		return fmt.Errorf("map win outcomes: %w", err)
+	}
+	sides, err := db.RoundSideStatsByDemo(steamIDs, hashes)
+	if err != nil {
+		return fmt.Errorf("round side stats: %w", err)
+	}
+	siteStats, err := db.MapBombSiteStats(steamIDs, hashes)
+	if err != nil {
+		return fmt.Errorf("bomb site stats: %w", err)
+	}
+	if len(demos) == 0 {
+		fmt.Fprintf(os.Stderr, "hint: no demo in the last %d days has %d+ roster players; try --quorum or --since\n",
+			mapPoolSince, mapPoolQuorum)
+	}
+
+	entries := buildMapPool(pool, demos, outcomes, sides, ref, mapPoolStaleDays, mapPoolMinMatches)
+	report.PrintMapPoolTable(os.Stdout, teamName, entries, ref.Format("2006-01-02"), mapPoolStaleDays, mapPoolMinMatches)
+	report.PrintBombSiteTable(os.Stdout, teamName, buildBombSites(entries, siteStats))
+	return nil
+}
+
+// mapKey is the comparison key of a map name: lower case without "de_".
+func mapKey(name string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(name)), "de_")
+}
+
+// splitMapPool splits a comma-separated map list into display names
+// ("de_mirage" → "Mirage"), dropping blanks and duplicates.
+func splitMapPool(s string) []string {
+	var pool []string
+	seen := make(map[string]bool)
+	for _, raw := range strings.Split(s, ",") {
+		k := mapKey(raw)
+		if k == "" || seen[k] {
+			continue
+		}
+		seen[k] = true
+		pool = append(pool, strings.ToUpper(k[:1])+k[1:])
+	}
+	return pool
+}
+
+// buildMapPool returns one entry per pool map, in pool order, followed by the
+// maps played outside the pool by match count. Days and recent matches are
+// counted back from ref; staleDays and minMatches decide each status.
+func buildMapPool(pool []string, demos []storage.DemoRef, outcomes []storage.WinOutcome,
+	sides []storage.DemoSideStats, ref time.Time, staleDays, minMatches int) []model.MapPoolEntry {
+	outcomeByHash := make(map[string]storage.WinOutcome, len(outcomes))
+	for _, o := range outcomes {
+		outcomeByHash[o.Hash] = o
+	}
+	sideByHash := make(map[string]storage.DemoSideStats, len(sides))
+	for _, s := range sides {
+		sideByHash[s.Hash] = s
+	}
+
+	byKey := make(map[string]*model.MapPoolEntry)
+	var keys []string
+	for _, name := range pool {
+		k := mapKey(name)
+		byKey[k] = &model.MapPoolEntry{MapName: name, InPool: true}
+		keys = append(keys, k)
+	}
+	refDay, _ := time.Parse("2006-01-02", ref.Format("2006-01-02"))
+	for _, d := range demos {
+		k := mapKey(d.MapName)
+		e := byKey[k]
+		if e == nil {
+			e = &model.MapPoolEntry{MapName: d.MapName}
+			byKey[k] = e
+			keys = append(keys, k)
+		}
+		e.Matches++
+		if o, ok := outcomeByHash[d.Hash]; ok && o.RoundsPlayed > 0 {
+			switch {
+			case o.RoundsWon*2 > o.RoundsPlayed:
+				e.Wins++
+			case o.RoundsWon*2 == o.RoundsPlayed:
+				e.Ties++
+			}
+		}
+		s := sideByHash[d.Hash]
+		e.CTWins += s.CTWins
+		e.CTRounds += s.CTTotal
+		e.TWins += s.TWins
+		e.TRounds += s.TTotal
+		if d.MatchDate > e.LastPlayed {
+			e.LastPlayed = d.MatchDate
+		}
+		if played, err := time.Parse("2006-01-02", d.MatchDate); err == nil &&
+			refDay.Sub(played) <= time.Duration(staleDays)*24*time.Hour {
+			e.RecentMatches++
+		}
+	}
+
+	out := make([]model.MapPoolEntry, 0, len(keys))
+	for _, k := range keys {
+		e := byKey[k]
+		e.DaysSince = -1
+		if played, err := time.Parse("2006-01-02", e.LastPlayed); err == nil {
+			e.DaysSince = max(int(refDay.Sub(played).Hours()/24), 0)
+		}
+		switch {
+		case e.Matches == 0:
+			e.Status = model.MapPoolNoData
+		case e.DaysSince > staleDays:
+			e.Status = model.MapPoolStale
+		case e.RecentMatches < minMatches:
+			e.Status = model.MapPoolThin
+		default:
+			e.Status = model.MapPoolOK
+		}
+		out = append(out, *e)
+	}
+	offPool := out[len(pool):]
+	sort.SliceStable(offPool, func(i, j int) bool { return offPool[i].Matches > offPool[j].Matches })
+	return out
+}
+
+// buildBombSites returns the bomb site rows of stats (keyed by stored map
+// name) in the order of the map pool entries, named like them.
+func buildBombSites(entries []model.MapPoolEntry, stats map[string][]storage.BombSiteStats) []model.BombSiteEntry {
+	byKey := make(map[string][]storage.BombSiteStats, len(stats))
+	for mapName, sites := range stats {
+		k := mapKey(mapName)
+		byKey[k] = append(byKey[k], sites...)
+	}
+	var out []model.BombSiteEntry
+	for _, e := range entries {
+		for _, s := range byKey[mapKey(e.MapName)] {
+			out = append(out, model.BombSiteEntry{
+				MapName:  e.MapName,
+				Site:     s.Site,
+				TPlants:  s.TPlants,
+				TWins:    s.TWins,
+				CTPlants: s.CTPlants,
+				CTWins:   s.CTWins,
+			})
+		}
+	}
+	return out
+}
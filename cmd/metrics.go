@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pable/go-cs-metrics/internal/aggregator"
+	"github.com/pable/go-cs-metrics/internal/model"
+	"github.com/pable/go-cs-metrics/internal/report"
+)
+
+var (
+	metricsSince     int
+	metricsChangelog bool
+)
+
+// metricsCmd is the cobra command printing the metric registry compiled into
+// the binary.
+var metricsCmd = &cobra.Command{
+	Use:   "metrics [name...]",
+	Short: "Print every metric's definition, windows and the pipeline versions that changed it",
+	Long: `Print the metric definitions compiled into this binary: each metric's exact
+definition, time windows and thresholds, the stored columns it comes from,
+and the pipeline version that introduced it and every version that later
+changed its stored values. Stored stats carry the version that produced them
+(see list --outdated), so this tells which numbers are comparable.
+
+Names filter by metric or group (case-insensitive substring). --since N keeps
+only metrics introduced or changed after version N; --changelog lists the
+history version by version instead. Use --format json or csv for a
+machine-readable copy.`,
+	RunE: runMetrics,
+}
+
+func init() {
+	metricsCmd.Flags().IntVar(&metricsSince, "since", -1, "only metrics introduced or changed after this pipeline version")
+	metricsCmd.Flags().BoolVar(&metricsChangelog, "changelog", false, "list the version history instead of the definitions")
+}
+
+// runMetrics filters the registry and prints definitions or the changelog.
+func runMetrics(cmd *cobra.Command, args []string) error {
+	if metricsSince > aggregator.PipelineVersion {
+		return fmt.Errorf("--since %d is newer than this binary's pipeline version %d", metricsSince, aggregator.PipelineVersion)
+	}
+	var metrics []model.MetricDef
+	for _, m := range aggregator.Metrics() {
+		if !metricMatches(m, args) {
+			continue
+		}
+		if metricsSince >= 0 {
+			m = metricChangesAfter(m, metricsSince)
+			if m.Since <= metricsSince && len(m.Changes) == 0 {
+				continue
+			}
+		}
+		metrics = append(metrics, m)
+	}
+
+	if metricsChangelog {
+		if metricsSince >= 0 {
+			// Only versions after --since: drop introductions at or before it.
+			for i := range metrics {
+				if metrics[i].Since <= metricsSince {
+					metrics[i].Since = 0
+				}
+			}
+		}
+		report.PrintMetricChangelogTable(os.Stdout, metrics, aggregator.PipelineVersion)
+	} else {
+		report.PrintMetricDefinitionsTable(os.Stdout, metrics, aggregator.PipelineVersion)
+	}
+	return nil
+}
+
+// metricMatches reports whether m's name or group contains any of names
+// (case-insensitive); no names matches everything.
+func metricMatches(m model.MetricDef, names []string) bool {
+	if len(names) == 0 {
+		return true
+	}
+	name, group := strings.ToLower(m.Name), strings.ToLower(m.Group)
+	for _, n := range names {
+		n = strings.ToLower(n)
+		if strings.Contains(name, n) || strings.Contains(group, n) {
+			return true
+		}
+	}
+	return false
+}
+
+// metricChangesAfter returns m with only the changes made after version v.
+func metricChangesAfter(m model.MetricDef, v int) model.MetricDef {
+	var changes []model.MetricChange
+	for _, c := range m.Changes {
+		if c.Version > v {
+			changes = append(changes, c)
+		}
+	}
+	m.Changes = changes
+	return m
+}
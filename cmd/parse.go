@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"runtime/debug"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -15,16 +16,19 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/pable/go-cs-metrics/internal/aggregator"
+	"github.com/pable/go-cs-metrics/internal/config"
 	"github.com/pable/go-cs-metrics/internal/model"
 	"github.com/pable/go-cs-metrics/internal/parser"
 	"github.com/pable/go-cs-metrics/internal/report"
+	"github.com/pable/go-cs-metrics/internal/steam"
 	"github.com/pable/go-cs-metrics/internal/storage"
 )
 
 // parse command flags.
 var (
-	// playerSteamID is the optional focus player SteamID64 for highlighted output.
-	playerSteamID uint64
+	// parsePlayer is the optional focus player for highlighted output: a
+	// SteamID64 or name:<nickname> (see resolveFocusPlayer).
+	parsePlayer string
 	// matchType is the label stored alongside the demo (e.g. "Competitive", "FACEIT").
 	matchType string
 	// parseTier is the tier label for baseline comparisons (e.g. "faceit-5").
@@ -35,6 +39,13 @@ var (
 	parseDir string
 	// parseWorkers is the number of parallel parse workers (0 = NumCPU).
 	parseWorkers int
+	// parseForce re-parses demos that are already stored and replaces their rows.
+	parseForce bool
+	// parseSourceURL, parseShareCode and parseMatchID record where a single
+	// demo came from (see model.DemoSource).
+	parseSourceURL string
+	parseShareCode string
+	parseMatchID   string
 )
 
 // parseCmd is the cobra command for parsing a CS2 demo file and storing its metrics.
@@ -55,18 +66,134 @@ Whole directory:
 When more than one demo is provided, full tables are suppressed and a
 brief status line is printed per demo instead. Multiple demos are parsed
 and aggregated in parallel (parse+aggregate workers); database writes are
-always serialised. Use --workers to control concurrency (default: NumCPU).`,
+always serialised. Use --workers to control concurrency (default: NumCPU).
+
+Stored results are reused only when they were produced by the current
+pipeline version; older results are re-parsed and replaced. Use --force to
+re-parse and replace demos regardless of version.
+
+Every stored demo records its absolute file path. For a single demo,
+--source-url, --share-code and --match-id add where it was downloaded
+from, shown by list and show:
+  parse 1-4b2c.dem --source-url https://www.faceit.com/en/cs2/room/1-4b2c --match-id 1-4b2c
+  parse mm.dem --share-code CSGO-XXXXX-XXXXX-XXXXX-XXXXX-XXXXX`,
 	Args: cobra.ArbitraryArgs,
 	RunE: runParse,
 }
 
 func init() {
-	parseCmd.Flags().Uint64Var(&playerSteamID, "player", 0, "focus player SteamID64")
+	parseCmd.Flags().StringVar(&parsePlayer, "player", "", "focus player: SteamID64 or name:<nickname> (fuzzy match on the demo's roster)")
 	parseCmd.Flags().StringVar(&matchType, "type", "Competitive", "match type label")
 	parseCmd.Flags().StringVar(&parseTier, "tier", "", "tier label for baseline comparisons (e.g. faceit-5)")
 	parseCmd.Flags().BoolVar(&parseBaseline, "baseline", false, "mark this demo as a baseline reference match")
 	parseCmd.Flags().StringVar(&parseDir, "dir", "", "directory containing .dem files to parse in bulk")
 	parseCmd.Flags().IntVar(&parseWorkers, "workers", 0, "parallel parse+aggregate workers (0 = NumCPU)")
+	parseCmd.Flags().BoolVar(&parseForce, "force", false, "re-parse and replace demos that are already stored")
+	parseCmd.Flags().StringVar(&parseSourceURL, "source-url", "", "match page the demo was downloaded from (single demo only)")
+	parseCmd.Flags().StringVar(&parseShareCode, "share-code", "", "CS2 match share code of the demo (single demo only); sets --match-id when empty")
+	parseCmd.Flags().StringVar(&parseMatchID, "match-id", "", "external match ID, e.g. the FACEIT match ID (single demo only)")
+}
+
+// demoCacheHit reports whether a stored demo can be reused instead of
+// re-parsed: it must be stored with stats from the current pipeline version
+// and --force must not be set. found reports whether any copy is stored, and
+// version is that copy's pipeline version.
+func demoCacheHit(db *storage.DB, hash string) (hit, found bool, version int, err error) {
+	version, found, err = db.DemoPipelineVersion(hash)
+	if err != nil || !found {
+		return false, false, 0, err
+	}
+	return !parseForce && version >= aggregator.PipelineVersion, true, version, nil
+}
+
+// replaceReason describes why a stored demo is being re-parsed.
+func replaceReason(version int) string {
+	if parseForce {
+		return "--force"
+	}
+	return fmt.Sprintf("pipeline v%d < v%d", version, aggregator.PipelineVersion)
+}
+
+// sightPlayersEnv names the environment variable listing the SteamID64s
+// (comma-separated) whose raw first-sight events are persisted at parse time.
+const sightPlayersEnv = "CSMETRICS_SIGHT_PLAYERS"
+
+// trackedSightPlayers reads sightPlayersEnv into a set. Returns nil when the
+// variable is unset or empty, which disables first-sight storage.
+func trackedSightPlayers() (map[uint64]bool, error) {
+	v := strings.TrimSpace(os.Getenv(sightPlayersEnv))
+	if v == "" {
+		return nil, nil
+	}
+	ids := make(map[uint64]bool)
+	for _, f := range strings.Split(v, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(f, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid SteamID64 %q: %w", sightPlayersEnv, f, err)
+		}
+		ids[id] = true
+	}
+	return ids, nil
+}
+
+// trackedSights returns the first sights observed by players in tracked.
+func trackedSights(sights []model.RawFirstSight, tracked map[uint64]bool) []model.RawFirstSight {
+	if len(tracked) == 0 {
+		return nil
+	}
+	var out []model.RawFirstSight
+	for _, fs := range sights {
+		if tracked[fs.ObserverID] {
+			out = append(out, fs)
+		}
+	}
+	return out
+}
+
+// fileSource returns the provenance of a demo parsed from the local file at
+// path, recorded as an absolute path.
+func fileSource(path string) model.DemoSource {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return model.DemoSource{Origin: model.SourceFile, Path: path}
+}
+
+// demoSource returns the provenance recorded by parse for the demo at path:
+// the local file plus the --source-url, --share-code and --match-id flags
+// (validated by checkSourceFlags).
+func demoSource(path string) model.DemoSource {
+	src := fileSource(path)
+	src.URL = parseSourceURL
+	src.ShareCode = parseShareCode
+	src.MatchID = parseMatchID
+	if src.MatchID == "" && src.ShareCode != "" {
+		if sc, err := steam.Decode(src.ShareCode); err == nil {
+			src.MatchID = strconv.FormatUint(sc.MatchID, 10)
+		}
+	}
+	return src
+}
+
+// checkSourceFlags rejects the per-demo source flags when more than one demo
+// is parsed, and share codes that do not decode.
+func checkSourceFlags(demos int) error {
+	if parseSourceURL == "" && parseShareCode == "" && parseMatchID == "" {
+		return nil
+	}
+	if demos > 1 {
+		return withExitCode(ExitUsage, fmt.Errorf("--source-url, --share-code and --match-id describe a single demo; got %d", demos))
+	}
+	if parseShareCode != "" {
+		if _, err := steam.Decode(parseShareCode); err != nil {
+			return withExitCode(ExitUsage, fmt.Errorf("--share-code: %w", err))
+		}
+	}
+	return nil
 }
 
 // demoMeta holds the event metadata written by cs-demo-downloader into event.json
@@ -155,6 +282,9 @@ func runDemoWorker(jobs <-chan parseJob, results chan<- parseResult, mt string)
 // printed per demo instead. Multiple demos are parsed in parallel via a worker
 // pool; all DB writes happen on the calling goroutine to avoid SQLite contention.
 func runParse(cmd *cobra.Command, args []string) error {
+	if err := validateFocusPlayer(parsePlayer); err != nil {
+		return err
+	}
 	// Collect demo paths from positional args and --dir.
 	paths := append([]string(nil), args...)
 	if parseDir != "" {
@@ -171,6 +301,9 @@ func runParse(cmd *cobra.Command, args []string) error {
 	if len(paths) == 0 {
 		return fmt.Errorf("no demo files specified; provide file args or --dir")
 	}
+	if err := checkSourceFlags(len(paths)); err != nil {
+		return err
+	}
 
 	// Load event metadata from the event.json sidecar written by demoget.
 	// --dir is the canonical location; fall back to the directory of the first file.
@@ -194,6 +327,15 @@ func runParse(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	sightPlayers, err := trackedSightPlayers()
+	if err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
 	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
 		return fmt.Errorf("create db dir: %w", err)
 	}
@@ -256,12 +398,25 @@ func runParse(cmd *cobra.Command, args []string) error {
 		if singleQuickHash != "" {
 			found, fullHash, _ := db.DemoExistsByQuickHash(singleQuickHash)
 			if found {
-				restoreStderr()
-				if err := db.UpdateDemoMeta(fullHash, singleQuickHash, matchType, effectiveTier, effectiveEventID, parseBaseline); err != nil {
-					return fmt.Errorf("update demo meta: %w", err)
+				hit, _, version, err := demoCacheHit(db, fullHash)
+				if err != nil {
+					return fmt.Errorf("check demo: %w", err)
 				}
-				fmt.Fprintf(os.Stdout, "Demo %s already stored — showing cached results.\n\n", fullHash[:12])
-				return showByHash(db, fullHash)
+				if hit {
+					restoreStderr()
+					if err := db.UpdateDemoMeta(fullHash, singleQuickHash, matchType, effectiveTier, effectiveEventID, parseBaseline); err != nil {
+						return fmt.Errorf("update demo meta: %w", err)
+					}
+					if err := db.UpdateDemoSource(fullHash, demoSource(demoPath)); err != nil {
+						return fmt.Errorf("update demo source: %w", err)
+					}
+					fmt.Fprintf(os.Stdout, "Demo %s already stored — showing cached results.\n\n", fullHash[:12])
+					if err := showByHash(db, fullHash); err != nil {
+						return err
+					}
+					return demoExistsError("demo %s already stored", fullHash[:12])
+				}
+				fmt.Fprintf(os.Stdout, "Demo %s already stored — re-parsing (%s).\n", fullHash[:12], replaceReason(version))
 			}
 		}
 
@@ -270,56 +425,65 @@ func runParse(cmd *cobra.Command, args []string) error {
 		parseElapsed := time.Since(t0)
 		restoreStderr() // no more library stderr output after this point
 		if err != nil {
-			return fmt.Errorf("parse demo: %w", err)
+			return withExitCode(ExitParse, fmt.Errorf("parse demo: %w", err))
 		}
 
-		exists, err := db.DemoExists(raw.DemoHash)
+		hit, _, _, err := demoCacheHit(db, raw.DemoHash)
 		if err != nil {
 			return fmt.Errorf("check demo: %w", err)
 		}
-		if exists {
+		if hit {
 			if err := db.UpdateDemoMeta(raw.DemoHash, singleQuickHash, matchType, effectiveTier, effectiveEventID, parseBaseline); err != nil {
 				return fmt.Errorf("update demo meta: %w", err)
 			}
+			if err := db.UpdateDemoSource(raw.DemoHash, demoSource(demoPath)); err != nil {
+				return fmt.Errorf("update demo source: %w", err)
+			}
 			fmt.Fprintf(os.Stdout, "Demo %s already stored — showing cached results.\n\n", raw.DemoHash[:12])
-			return showByHash(db, raw.DemoHash)
+			if err := showByHash(db, raw.DemoHash); err != nil {
+				return err
+			}
+			return demoExistsError("demo %s already stored", raw.DemoHash[:12])
 		}
 
 		t1 := time.Now()
 		matchStats, roundStats, weaponStats, duelSegs, err := aggregator.Aggregate(raw)
 		aggElapsed := time.Since(t1)
 		if err != nil {
-			return fmt.Errorf("aggregate: %w", err)
+			return withExitCode(ExitParse, fmt.Errorf("aggregate: %w", err))
+		}
+		data := newDemoData(raw, singleQuickHash, cfg, sightPlayers)
+		data.MatchStats, data.RoundStats, data.WeaponStats, data.DuelSegments = matchStats, roundStats, weaponStats, duelSegs
+		warnings := append(report.TeamConflictWarnings(matchStats), report.UnmappedWeaponWarnings(data.Unmapped)...)
+		warnings = append(warnings, report.SuppressedAccountWarnings(raw.Suppressed)...)
+		warnings = append(warnings, report.PreLiveRoundWarnings(raw.PreLiveRounds)...)
+		warnings = append(warnings, report.EntityEventWarnings(raw.EntityEventsDropped)...)
+		warnings = append(warnings, report.ServerFrameDropWarnings(raw.ServerFrameDropPct)...)
+		diagRef, err := db.ListDemoDiagnostics()
+		if err != nil {
+			return fmt.Errorf("list diagnostics: %w", err)
 		}
-
-		ctScore, tScore := computeScore(raw.Rounds)
-		summary := model.MatchSummary{
-			DemoHash:   raw.DemoHash,
-			MapName:    raw.MapName,
-			MatchDate:  raw.MatchDate,
-			MatchType:  raw.MatchType,
-			Tickrate:   raw.Tickrate,
-			CTScore:    ctScore,
-			TScore:     tScore,
-			Tier:       effectiveTier,
-			IsBaseline: parseBaseline,
-			EventID:    effectiveEventID,
+		warnings = append(warnings, report.DiagnosticsWarnings(data.Diagnostics, diagRef)...)
+		for _, msg := range warnings {
+			fmt.Fprintf(os.Stderr, "warn: %s\n", msg)
 		}
 
-		if err := db.InsertDemo(summary, singleQuickHash); err != nil {
-			return fmt.Errorf("insert demo: %w", err)
-		}
-		if err := db.InsertPlayerMatchStats(matchStats); err != nil {
-			return fmt.Errorf("insert player stats: %w", err)
-		}
-		if err := db.InsertPlayerRoundStats(roundStats); err != nil {
-			return fmt.Errorf("insert round stats: %w", err)
+		data.Summary.Tier = effectiveTier
+		data.Summary.IsBaseline = parseBaseline
+		data.Summary.EventID = effectiveEventID
+		data.Summary.Source = demoSource(demoPath)
+
+		if err := db.ReplaceDemo(data); err != nil {
+			return fmt.Errorf("store demo: %w", err)
 		}
-		if err := db.InsertPlayerWeaponStats(weaponStats); err != nil {
-			return fmt.Errorf("insert weapon stats: %w", err)
+		summary := data.Summary
+		// Show the provenance as stored, including fields kept from an earlier parse.
+		stored, err := db.GetDemoByPrefix(summary.DemoHash)
+		if err != nil {
+			return fmt.Errorf("get demo: %w", err)
 		}
-		if err := db.InsertPlayerDuelSegments(duelSegs); err != nil {
-			return fmt.Errorf("insert duel segments: %w", err)
+		if stored != nil {
+			summary.Source = stored.Source
 		}
 
 		fmt.Fprintf(os.Stdout, "  parse: %s  aggregate: %s  total: %s\n\n",
@@ -331,14 +495,49 @@ func runParse(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return fmt.Errorf("get clutch stats: %w", err)
 		}
+		outcomes, err := db.GetRoundOutcomes(summary.DemoHash)
+		if err != nil {
+			return fmt.Errorf("get round outcomes: %w", err)
+		}
+		economy, err := db.GetTeamRoundEconomy(summary.DemoHash)
+		if err != nil {
+			return fmt.Errorf("get team economy: %w", err)
+		}
+		chains, err := db.GetTradeChains(summary.DemoHash)
+		if err != nil {
+			return fmt.Errorf("get trade chains: %w", err)
+		}
+		playerSteamID, err := resolveFocusPlayer(parsePlayer, matchStats)
+		if err != nil {
+			return err
+		}
+		report.SetDataVersion(summary.PipelineVersion)
 		report.PrintMatchSummary(os.Stdout, summary)
+		report.PrintRoundProgression(os.Stdout, outcomes)
 		report.PrintPlayerRosterTable(os.Stdout, matchStats)
 		report.PrintPlayerTable(matchStats, playerSteamID)
+		printLobbyComparison(matchStats, playerSteamID)
 		report.PrintDuelTable(os.Stdout, matchStats, playerSteamID)
 		report.PrintAWPTable(os.Stdout, matchStats, playerSteamID)
+		report.PrintAWPShotsTable(os.Stdout, matchStats, playerSteamID)
 		report.PrintWeaponTable(os.Stdout, weaponStats, matchStats, playerSteamID)
 		report.PrintAimTimingTable(os.Stdout, matchStats, playerSteamID)
+		report.PrintDefensiveUtilityTable(os.Stdout, matchStats, playerSteamID)
+		report.PrintUtilitySynergyTable(os.Stdout, matchStats, playerSteamID)
+		report.PrintUtilityTimingTable(os.Stdout, matchStats, playerSteamID)
+		report.PrintLateRoundTable(os.Stdout, matchStats, playerSteamID)
+		report.PrintWeaponDisciplineTable(os.Stdout, matchStats, playerSteamID)
+		report.PrintDuelLossReasonTable(os.Stdout, matchStats, playerSteamID)
+		report.PrintMomentumTable(os.Stdout, matchStats, playerSteamID)
+		report.PrintScorelineTable(os.Stdout, matchStats, playerSteamID)
+		report.PrintLoadoutEfficiencyTable(os.Stdout, matchStats, playerSteamID)
 		report.PrintMatchClutchTable(os.Stdout, matchStats, clutch)
+		report.PrintObjectiveTable(os.Stdout, matchStats, playerSteamID)
+		report.PrintRoundEndReasonTable(os.Stdout, outcomes)
+		report.PrintTeamEconomyTable(os.Stdout, economy, outcomes)
+		report.PrintTradeChainTable(os.Stdout, chains, outcomes)
+		report.PrintTeamConcentrationTable(os.Stdout, aggregator.TeamConcentration(matchStats))
+		report.PrintParseDiagnosticsTable(os.Stdout, data.Diagnostics, diagRef)
 		return nil
 	}
 
@@ -353,7 +552,7 @@ func runParse(cmd *cobra.Command, args []string) error {
 
 	fmt.Fprintf(os.Stdout, "Parsing %d demos with %d worker(s)...\n", len(paths), numWorkers)
 
-	var stored, skipped, failed int
+	var stored, replaced, skipped, failed int
 
 	// Phase 1: quick-hash pre-check — identify already-stored demos without
 	// a full parse. Reading 64 KB per file costs milliseconds vs. 4+ minutes
@@ -366,10 +565,17 @@ func runParse(cmd *cobra.Command, args []string) error {
 		qh, err := parser.QuickHash(p)
 		if err == nil {
 			found, fullHash, dbErr := db.DemoExistsByQuickHash(qh)
+			var hit bool
 			if dbErr == nil && found {
+				hit, _, _, dbErr = demoCacheHit(db, fullHash)
+			}
+			if dbErr == nil && hit {
 				if err := db.UpdateDemoMeta(fullHash, qh, matchType, effectiveTier, effectiveEventID, parseBaseline); err != nil {
 					fmt.Fprintf(origStderr, "  %s  warn: update meta: %v\n", tag, err)
 				}
+				if err := db.UpdateDemoSource(fullHash, demoSource(p)); err != nil {
+					fmt.Fprintf(origStderr, "  %s  warn: update source: %v\n", tag, err)
+				}
 				fmt.Fprintf(os.Stdout, "  %s  skipped (quick-hash match)\n", tag)
 				skipped++
 				continue
@@ -380,8 +586,8 @@ func runParse(cmd *cobra.Command, args []string) error {
 
 	if len(pendingJobs) == 0 {
 		restoreStderr()
-		fmt.Fprintf(os.Stdout, "\nDone: %d stored, %d skipped, %d failed (total %d)\n",
-			stored, skipped, failed, len(paths))
+		fmt.Fprintf(os.Stdout, "\nDone: %d stored, %d replaced, %d skipped, %d failed (total %d)\n",
+			stored, replaced, skipped, failed, len(paths))
 		return nil
 	}
 
@@ -402,55 +608,59 @@ func runParse(cmd *cobra.Command, args []string) error {
 			return false, nil
 		}
 
-		exists, err := db.DemoExists(res.raw.DemoHash)
+		hit, found, version, err := demoCacheHit(db, res.raw.DemoHash)
 		if err != nil {
 			return false, fmt.Errorf("check demo %s: %w", name, err)
 		}
-		if exists {
+		if hit {
 			if err := db.UpdateDemoMeta(res.raw.DemoHash, res.quickHash, matchType, effectiveTier, effectiveEventID, parseBaseline); err != nil {
 				return false, fmt.Errorf("update demo meta %s: %w", name, err)
 			}
+			if err := db.UpdateDemoSource(res.raw.DemoHash, demoSource(res.path)); err != nil {
+				return false, fmt.Errorf("update demo source %s: %w", name, err)
+			}
 			fmt.Fprintf(os.Stdout, "  %s  skipped (already stored, metadata updated)\n", tag)
 			skipped++
 			return false, nil
 		}
 
-		ctScore, tScore := computeScore(res.raw.Rounds)
-		summary := model.MatchSummary{
-			DemoHash:   res.raw.DemoHash,
-			MapName:    res.raw.MapName,
-			MatchDate:  res.raw.MatchDate,
-			MatchType:  res.raw.MatchType,
-			Tickrate:   res.raw.Tickrate,
-			CTScore:    ctScore,
-			TScore:     tScore,
-			Tier:       effectiveTier,
-			IsBaseline: parseBaseline,
-			EventID:    effectiveEventID,
-		}
-		if err := db.InsertDemo(summary, res.quickHash); err != nil {
-			return false, fmt.Errorf("insert demo: %w", err)
-		}
-		if err := db.InsertPlayerMatchStats(res.matchStats); err != nil {
-			return false, fmt.Errorf("insert player stats: %w", err)
-		}
-		if err := db.InsertPlayerRoundStats(res.roundStats); err != nil {
-			return false, fmt.Errorf("insert round stats: %w", err)
-		}
-		if err := db.InsertPlayerWeaponStats(res.weaponStats); err != nil {
-			return false, fmt.Errorf("insert weapon stats: %w", err)
-		}
-		if err := db.InsertPlayerDuelSegments(res.duelSegs); err != nil {
-			return false, fmt.Errorf("insert duel segments: %w", err)
-		}
-		fmt.Fprintf(os.Stdout, "  %s  stored: %s  %s  %d–%d  %d players  %d rounds  (parse %s  agg %s  total %s)\n",
-			tag,
-			summary.MapName, summary.MatchDate, ctScore, tScore,
+		data := newDemoData(res.raw, res.quickHash, cfg, sightPlayers)
+		data.MatchStats, data.RoundStats, data.WeaponStats, data.DuelSegments = res.matchStats, res.roundStats, res.weaponStats, res.duelSegs
+		data.Summary.Tier = effectiveTier
+		data.Summary.IsBaseline = parseBaseline
+		data.Summary.EventID = effectiveEventID
+		data.Summary.Source = demoSource(res.path)
+		summary := data.Summary
+		if err := db.ReplaceDemo(data); err != nil {
+			return false, fmt.Errorf("store demo %s: %w", name, err)
+		}
+		verb := "stored"
+		if found {
+			verb = "replaced (" + replaceReason(version) + ")"
+			replaced++
+		} else {
+			stored++
+		}
+		fmt.Fprintf(os.Stdout, "  %s  %s: %s  %s  %d–%d  %d players  %d rounds  (parse %s  agg %s  total %s)\n",
+			tag, verb,
+			summary.MapName, summary.MatchDate, summary.CTScore, summary.TScore,
 			len(res.matchStats), len(res.raw.Rounds),
 			res.parseElapsed.Round(time.Millisecond),
 			res.aggElapsed.Round(time.Millisecond),
 			(res.parseElapsed+res.aggElapsed).Round(time.Millisecond))
-		stored++
+		warnings := append(report.TeamConflictWarnings(res.matchStats), report.UnmappedWeaponWarnings(data.Unmapped)...)
+		warnings = append(warnings, report.SuppressedAccountWarnings(res.raw.Suppressed)...)
+		warnings = append(warnings, report.PreLiveRoundWarnings(res.raw.PreLiveRounds)...)
+		warnings = append(warnings, report.EntityEventWarnings(res.raw.EntityEventsDropped)...)
+		warnings = append(warnings, report.ServerFrameDropWarnings(res.raw.ServerFrameDropPct)...)
+		diagRef, err := db.ListDemoDiagnostics()
+		if err != nil {
+			return true, fmt.Errorf("list diagnostics: %w", err)
+		}
+		warnings = append(warnings, report.DiagnosticsWarnings(data.Diagnostics, diagRef)...)
+		for _, msg := range warnings {
+			fmt.Fprintf(origStderr, "  %s  warn: %s\n", tag, msg)
+		}
 		return true, nil
 	}
 
@@ -530,8 +740,14 @@ func runParse(cmd *cobra.Command, args []string) error {
 	}
 
 	restoreStderr()
-	fmt.Fprintf(os.Stdout, "\nDone: %d stored, %d skipped, %d failed (total %d)\n",
-		stored, skipped, failed, len(paths))
+	fmt.Fprintf(os.Stdout, "\nDone: %d stored, %d replaced, %d skipped, %d failed (total %d)\n",
+		stored, replaced, skipped, failed, len(paths))
+	switch {
+	case failed > 0:
+		return withExitCode(ExitParse, fmt.Errorf("%d of %d demos failed to parse", failed, len(paths)))
+	case stored+replaced == 0:
+		return demoExistsError("all %d demos already stored", len(paths))
+	}
 	return nil
 }
 
@@ -548,6 +764,56 @@ func computeScore(rounds []model.RawRound) (ctScore, tScore int) {
 	return
 }
 
+// newDemoData builds the ReplaceDemo payload for a parsed demo: the summary
+// (score and pipeline version; tier, baseline flag and event left to the
+// caller) and every per-demo table derived from raw. The aggregated stats
+// slices are left for the caller to set. Optional datasets switched off in cfg
+// are left empty; first sights are kept for every player when cfg stores them,
+// otherwise only for players in sights.
+func newDemoData(raw *model.RawMatch, quickHash string, cfg config.Config, sights map[uint64]bool) storage.DemoData {
+	ctScore, tScore := computeScore(raw.Rounds)
+	data := storage.DemoData{
+		Summary: model.MatchSummary{
+			DemoHash:  raw.DemoHash,
+			MapName:   raw.MapName,
+			MatchDate: raw.MatchDate,
+			MatchType: raw.MatchType,
+			Tickrate:  raw.Tickrate,
+			CTScore:   ctScore,
+			TScore:    tScore,
+
+			PipelineVersion: aggregator.PipelineVersion,
+		},
+		QuickHash:     quickHash,
+		DeathSegments: aggregator.Timed(raw, "death profile", aggregator.DeathProfile),
+		DeathContexts: aggregator.Timed(raw, "death contexts", aggregator.DeathContexts),
+		TimeToDamage:  aggregator.Timed(raw, "time to damage (per demo)", aggregator.TimeToDamage),
+		BurstStats:    aggregator.Timed(raw, "bursts (per demo)", aggregator.Bursts),
+		TeamEconomy:   aggregator.Timed(raw, "team economy", aggregator.TeamEconomy),
+		TradeChains:   aggregator.Timed(raw, "trade chains", aggregator.TradeChains),
+		Unmapped:      aggregator.Timed(raw, "unmapped weapons", aggregator.UnmappedWeapons),
+	}
+	if cfg.Stores("duel_distances") {
+		data.DuelDistances = aggregator.Timed(raw, "duel distances", aggregator.DuelDistances)
+	}
+	if cfg.Stores("positions") {
+		data.Positions = aggregator.Timed(raw, "positions", aggregator.Positions)
+	}
+	if cfg.Stores("kill_states") {
+		data.KillStates = aggregator.Timed(raw, "kill states", aggregator.KillStates)
+	}
+	if cfg.Stores("timeline") {
+		data.Timeline = aggregator.Timed(raw, "timeline", aggregator.Timeline)
+	}
+	if cfg.Stores("first_sights") {
+		data.FirstSights = raw.FirstSights
+	} else {
+		data.FirstSights = trackedSights(raw.FirstSights, sights)
+	}
+	data.Diagnostics = aggregator.Diagnostics(raw)
+	return data
+}
+
 // showByHash loads a previously stored demo by its full hash and prints all
 // report tables. Used when a re-parsed demo is already in the database.
 func showByHash(db *storage.DB, hash string) error {
@@ -571,14 +837,48 @@ func showByHash(db *storage.DB, hash string) error {
 	if err != nil {
 		return fmt.Errorf("get clutch stats: %w", err)
 	}
+	outcomes, err := db.GetRoundOutcomes(hash)
+	if err != nil {
+		return fmt.Errorf("get round outcomes: %w", err)
+	}
+	economy, err := db.GetTeamRoundEconomy(hash)
+	if err != nil {
+		return fmt.Errorf("get team economy: %w", err)
+	}
+	chains, err := db.GetTradeChains(hash)
+	if err != nil {
+		return fmt.Errorf("get trade chains: %w", err)
+	}
+	playerSteamID, err := resolveFocusPlayer(parsePlayer, stats)
+	if err != nil {
+		return err
+	}
+	report.SetDataVersion(demo.PipelineVersion)
 	report.PrintMatchSummary(os.Stdout, *demo)
+	report.PrintRoundProgression(os.Stdout, outcomes)
 	report.PrintPlayerRosterTable(os.Stdout, stats)
 	report.PrintPlayerTable(stats, playerSteamID)
+	printLobbyComparison(stats, playerSteamID)
 	report.PrintPlayerSideTable(os.Stdout, sideStats, playerSteamID)
 	report.PrintDuelTable(os.Stdout, stats, playerSteamID)
 	report.PrintAWPTable(os.Stdout, stats, playerSteamID)
+	report.PrintAWPShotsTable(os.Stdout, stats, playerSteamID)
 	report.PrintWeaponTable(os.Stdout, weaponStats, stats, playerSteamID)
 	report.PrintAimTimingTable(os.Stdout, stats, playerSteamID)
+	report.PrintDefensiveUtilityTable(os.Stdout, stats, playerSteamID)
+	report.PrintUtilitySynergyTable(os.Stdout, stats, playerSteamID)
+	report.PrintUtilityTimingTable(os.Stdout, stats, playerSteamID)
+	report.PrintLateRoundTable(os.Stdout, stats, playerSteamID)
+	report.PrintWeaponDisciplineTable(os.Stdout, stats, playerSteamID)
+	report.PrintDuelLossReasonTable(os.Stdout, stats, playerSteamID)
+	report.PrintMomentumTable(os.Stdout, stats, playerSteamID)
+	report.PrintScorelineTable(os.Stdout, stats, playerSteamID)
+	report.PrintLoadoutEfficiencyTable(os.Stdout, stats, playerSteamID)
 	report.PrintMatchClutchTable(os.Stdout, stats, clutch)
+	report.PrintObjectiveTable(os.Stdout, stats, playerSteamID)
+	report.PrintRoundEndReasonTable(os.Stdout, outcomes)
+	report.PrintTeamEconomyTable(os.Stdout, economy, outcomes)
+	report.PrintTradeChainTable(os.Stdout, chains, outcomes)
+	report.PrintTeamConcentrationTable(os.Stdout, aggregator.TeamConcentration(stats))
 	return nil
 }
@@ -9,17 +9,22 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/pable/go-cs-metrics/internal/aggregator"
 	"github.com/pable/go-cs-metrics/internal/model"
 	"github.com/pable/go-cs-metrics/internal/report"
 	"github.com/pable/go-cs-metrics/internal/storage"
 )
 
 var (
-	playerMap    string
-	playerSince  string
-	playerLast   int
-	playerTop    int
-	playerTopMin int
+	playerMap          string
+	playerSince        string
+	playerLast         int
+	playerTop          int
+	playerTopMin       int
+	playerCols         []string
+	playerSortBy       string
+	playerRoundContext string
+	playerNoCache      bool
 )
 
 // playerCmd is the cobra command for cross-match aggregate analysis of one or more players.
@@ -36,18 +41,66 @@ func init() {
 	playerCmd.Flags().IntVar(&playerLast, "last", 0, "only use the N most recent matches")
 	playerCmd.Flags().IntVar(&playerTop, "top", 0, "also include the top N players by Rating 2.0 proxy from the database")
 	playerCmd.Flags().IntVar(&playerTopMin, "top-min", 3, "minimum matches a player must have to appear in the top-N ranking")
+	playerCmd.Flags().StringSliceVar(&playerCols, "columns", nil, "only show these table columns, in order (e.g. K,D,ADR,RATING); player columns are always kept")
+	playerCmd.Flags().StringVar(&playerSortBy, "sort-by", "", "sort per-player tables by this column, highest first (append :asc for lowest first)")
+	playerCmd.Flags().StringVar(&playerRoundContext, "round-context", "", "restrict FHHS and duel segments to one round context: pistol, anti-eco or gun")
+	playerCmd.Flags().BoolVar(&playerNoCache, "no-cache", false, "recompute from the stored matches without reading or writing the aggregate cache")
+}
+
+// distanceBinsEnv names the environment variable selecting the distance bins
+// of the player FHHS tables: "fixed" (default) or "quantile", per-weapon bins
+// cut at the quantiles of the baseline demos' duel distances.
+const distanceBinsEnv = "CSMETRICS_DISTANCE_BINS"
+
+// quantileDistanceBins reports whether distanceBinsEnv selects quantile bins.
+func quantileDistanceBins() (bool, error) {
+	switch v := strings.ToLower(strings.TrimSpace(os.Getenv(distanceBinsEnv))); v {
+	case "", "fixed":
+		return false, nil
+	case "quantile":
+		return true, nil
+	default:
+		return false, fmt.Errorf("%s: invalid value %q: want fixed or quantile", distanceBinsEnv, v)
+	}
 }
 
 // runPlayer loads all match data for each given SteamID64, builds cross-match
-// aggregates, and prints overview, duel, AWP, map/side, and FHHS tables.
+// aggregates, and prints overview, duel, AWP, map/side, half split, and FHHS tables.
 // With --top N, the top N players by Rating 2.0 proxy are appended automatically.
 func runPlayer(cmd *cobra.Command, args []string) error {
+	report.SetColumns(playerCols)
+	report.SetSortBy(playerSortBy)
+	switch playerRoundContext {
+	case "", model.RoundContextPistol, model.RoundContextAntiEco, model.RoundContextGun:
+	default:
+		return fmt.Errorf("invalid --round-context %q: want pistol, anti-eco or gun", playerRoundContext)
+	}
+	quantileBins, err := quantileDistanceBins()
+	if err != nil {
+		return err
+	}
+
 	db, err := storage.Open(dbPath)
 	if err != nil {
 		return fmt.Errorf("open storage: %w", err)
 	}
 	defer db.Close()
 
+	// Quantile bins are cut once from the baseline corpus so every player's
+	// FHHS table uses the same edges.
+	var binEdges map[string][]int
+	if quantileBins && playerRoundContext != "" {
+		fmt.Fprintf(os.Stderr, "%s=quantile ignored with --round-context: duel distances carry no round context\n", distanceBinsEnv)
+		quantileBins = false
+	}
+	if quantileBins {
+		base, err := db.GetBaselineDuelDistances()
+		if err != nil {
+			return fmt.Errorf("query baseline duel distances: %w", err)
+		}
+		binEdges = aggregator.QuantileEdges(base, aggregator.QuantileBinCount, aggregator.MinQuantileDuels)
+	}
+
 	// Build the ordered, deduplicated list of IDs to process.
 	// Explicit args come first; --top N appends the highest-rated players not already present.
 	allIDs := make([]string, 0, len(args))
@@ -88,115 +141,294 @@ func runPlayer(cmd *cobra.Command, args []string) error {
 		synth []model.PlayerMatchStats
 	}
 
-	var allAggs    []model.PlayerAggregate
+	var allAggs []model.PlayerAggregate
 	var allMapSide []model.PlayerMapSideAggregate
-	var fhhsList   []fhhsEntry
-	var allClutch  []model.PlayerClutchMatchStats
+	var allAWPMaps []model.PlayerAWPMapStats
+	var fhhsList []fhhsEntry
+	var allClutch []model.PlayerClutchMatchStats
+	var allHalves []model.PlayerHalfSplit
+	var allDeaths []model.PlayerDeathSegment
+	var allDeathCtx []model.PlayerDeathContext
+	var allTTDmg []model.PlayerTimeToDamage
+	var allBursts []model.PlayerBurstStats
+	var allPlaces []model.PlayerPositionProfile
+	var allCtxSegs []model.PlayerDuelSegment
+	oldestVersion := -1 // lowest pipeline version among the reported matches
 
 	for _, arg := range allIDs {
-		id, err := strconv.ParseUint(arg, 10, 64)
+		id, err := resolveSteamID(arg)
 		if err != nil {
-			return fmt.Errorf("invalid SteamID64 %q: %w", arg, err)
+			return err
 		}
 
-		stats, err := db.GetAllPlayerMatchStats(id)
+		r, err := loadPlayerReport(db, id, binEdges, quantileBins)
 		if err != nil {
-			return fmt.Errorf("query stats for %d: %w", id, err)
+			return err
 		}
-		stats = filterStats(stats, playerMap, playerSince, playerLast)
-		if len(stats) == 0 {
+		if r == nil {
 			fmt.Fprintf(os.Stderr, "No data found for SteamID64 %d (after filters)\n", id)
 			continue
 		}
-
-		segs, err := db.GetAllPlayerDuelSegments(id)
-		if err != nil {
-			return fmt.Errorf("query segments for %d: %w", id, err)
-		}
-
-		// Filter segments to only those matching the filtered demo hashes.
-		if playerMap != "" || playerSince != "" || playerLast > 0 {
-			keep := make(map[string]struct{}, len(stats))
-			for _, s := range stats {
-				keep[s.DemoHash] = struct{}{}
-			}
-			var filteredSegs []model.PlayerDuelSegment
-			for _, seg := range segs {
-				if _, ok := keep[seg.DemoHash]; ok {
-					filteredSegs = append(filteredSegs, seg)
-				}
-			}
-			segs = filteredSegs
-		}
-
-		agg := buildAggregate(stats)
-		merged := mergeSegments(id, segs)
-
-		// Compute true aggregate FHHS from merged segment counts.
-		var totalHits, totalHSHits int
-		for _, s := range merged {
-			totalHits += s.FirstHitCount
-			totalHSHits += s.FirstHitHSCount
-		}
-		overallFHHS := 0.0
-		if totalHits > 0 {
-			overallFHHS = float64(totalHSHits) / float64(totalHits) * 100
+		if oldestVersion < 0 || r.OldestVersion < oldestVersion {
+			oldestVersion = r.OldestVersion
 		}
 
-		// Aggregate clutch stats across filtered matches for this player.
-		clutchByMatch, err := db.GetPlayerClutchStatsByMatch(id)
-		if err != nil {
-			return fmt.Errorf("query clutch for %d: %w", id, err)
-		}
-		keep := make(map[string]struct{}, len(stats))
-		for _, s := range stats {
-			keep[s.DemoHash] = struct{}{}
-		}
-		var aggClutch model.PlayerClutchMatchStats
-		aggClutch.SteamID = id
-		for hash, c := range clutchByMatch {
-			if _, ok := keep[hash]; !ok {
-				continue
-			}
-			for i := 1; i <= 5; i++ {
-				aggClutch.Attempts[i] += c.Attempts[i]
-				aggClutch.Wins[i] += c.Wins[i]
-			}
-		}
-		allClutch = append(allClutch, aggClutch)
-
-		allAggs = append(allAggs, agg)
-		allMapSide = append(allMapSide, buildMapSideAggregates(stats)...)
+		allAggs = append(allAggs, r.Agg)
+		allMapSide = append(allMapSide, r.MapSide...)
+		allAWPMaps = append(allAWPMaps, r.AWPMaps...)
+		allClutch = append(allClutch, r.Clutch)
+		if r.Halves.Matches > 0 {
+			allHalves = append(allHalves, r.Halves)
+		}
+		allDeaths = append(allDeaths, r.Deaths...)
+		allDeathCtx = append(allDeathCtx, r.DeathContexts...)
+		allTTDmg = append(allTTDmg, r.TimeToDamage...)
+		allBursts = append(allBursts, r.Bursts...)
+		allPlaces = append(allPlaces, r.Positions...)
+		allCtxSegs = append(allCtxSegs, r.ContextSegments...)
 		fhhsList = append(fhhsList, fhhsEntry{
-			name: agg.Name,
+			name: r.Agg.Name,
 			id:   id,
-			segs: merged,
+			segs: r.FHHSSegments,
 			synth: []model.PlayerMatchStats{{
 				SteamID:        id,
-				Name:           agg.Name,
-				FirstHitHSRate: overallFHHS,
+				Name:           r.Agg.Name,
+				FirstHitHSRate: r.OverallFHHS,
 			}},
 		})
 	}
 
 	if len(allAggs) == 0 {
-		return nil
+		return noDataError("no data found for any requested player (after filters)")
+	}
+	peekBaselines, err := db.GetPeekerBaselines()
+	if err != nil {
+		return fmt.Errorf("query peeker baselines: %w", err)
 	}
 
+	report.SetDataVersion(oldestVersion)
 	fmt.Fprintln(os.Stdout)
 	report.PrintPlayerAggregateOverview(os.Stdout, allAggs)
 	report.PrintPlayerAggregateDuelTable(os.Stdout, allAggs)
 	report.PrintPlayerAggregateAWPTable(os.Stdout, allAggs)
+	report.PrintPlayerAggregateAWPMapTable(os.Stdout, allAWPMaps)
+	report.PrintPlayerAggregateAWPShotsTable(os.Stdout, allAggs)
 	report.PrintPlayerMapSideTable(os.Stdout, allMapSide)
+	report.PrintPlayerHalfSplitTable(os.Stdout, allHalves)
 	report.PrintPlayerAggregateAimTable(os.Stdout, allAggs)
+	report.PrintPlayerAggregateDefensiveUtilityTable(os.Stdout, allAggs)
+	report.PrintPlayerAggregateUtilitySynergyTable(os.Stdout, allAggs)
+	report.PrintPlayerAggregateUtilityTimingTable(os.Stdout, allAggs)
+	report.PrintPlayerAggregateLateRoundTable(os.Stdout, allAggs)
+	report.PrintPlayerAggregateWeaponDisciplineTable(os.Stdout, allAggs)
+	report.PrintPlayerAggregateDuelLossReasonTable(os.Stdout, allAggs)
+	report.PrintPlayerAggregateMomentumTable(os.Stdout, allAggs)
+	report.PrintPlayerAggregateScorelineTable(os.Stdout, allAggs)
+	report.PrintPlayerAggregateLoadoutEfficiencyTable(os.Stdout, allAggs)
+	report.PrintPlayerAggregatePeekTable(os.Stdout, allAggs, peekBaselines)
 	report.PrintPlayerAggregateClutchTable(os.Stdout, allAggs, allClutch)
+	if playerRoundContext != "" {
+		fmt.Fprintf(os.Stdout, "\nFHHS restricted to %s duels (--round-context).\n", playerRoundContext)
+	}
+	if quantileBins {
+		fmt.Fprintf(os.Stdout, "\nFHHS distance bins cut at baseline-demo quantiles (%s=quantile); weapons with < %d baseline duels keep the fixed bins.\n",
+			distanceBinsEnv, aggregator.MinQuantileDuels)
+	}
 	for _, f := range fhhsList {
 		fmt.Fprintln(os.Stdout)
 		report.PrintFHHSTable(os.Stdout, f.segs, f.synth, 0)
 	}
+	var names []model.PlayerMatchStats
+	for _, f := range fhhsList {
+		names = append(names, f.synth...)
+	}
+	report.PrintFHHSByRoundContextTable(os.Stdout, allCtxSegs, names)
+	report.PrintFHHSByMovementTable(os.Stdout, allCtxSegs, names)
+	report.PrintDeathProfileTable(os.Stdout, allDeaths, names, 0)
+	report.PrintDeathContextTable(os.Stdout, allDeathCtx, names)
+	report.PrintTimeToDamageTable(os.Stdout, allTTDmg, names)
+	report.PrintBurstTable(os.Stdout, allBursts, names)
+	report.PrintPositionTable(os.Stdout, allPlaces, names)
 	return nil
 }
 
+// playerReport is everything the player command shows for one player under
+// the current filters. It is what player_aggregate_cache stores, so its
+// fields are exported for JSON.
+type playerReport struct {
+	Agg             model.PlayerAggregate
+	OldestVersion   int // lowest pipeline version among the player's matches
+	MapSide         []model.PlayerMapSideAggregate
+	AWPMaps         []model.PlayerAWPMapStats
+	Clutch          model.PlayerClutchMatchStats
+	Halves          model.PlayerHalfSplit
+	Deaths          []model.PlayerDeathSegment
+	DeathContexts   []model.PlayerDeathContext
+	TimeToDamage    []model.PlayerTimeToDamage
+	Bursts          []model.PlayerBurstStats
+	Positions       []model.PlayerPositionProfile
+	ContextSegments []model.PlayerDuelSegment // filtered demos, every round context
+	FHHSSegments    []model.PlayerDuelSegment // merged (or quantile-binned) for the FHHS table
+	OverallFHHS     float64
+}
+
+// loadPlayerReport returns the player's report from player_aggregate_cache
+// when an entry for the same filters and pipeline version exists, and builds
+// and caches it otherwise. Reports with quantile distance bins depend on the
+// baseline corpus rather than only the player's demos and are never cached.
+// It returns nil when the player has no matches after the filters.
+func loadPlayerReport(db *storage.DB, id uint64, binEdges map[string][]int, quantileBins bool) (*playerReport, error) {
+	useCache := !playerNoCache && !quantileBins
+	key := aggregateCacheKey("player", strings.TrimPrefix(strings.ToLower(playerMap), "de_"),
+		playerSince, strconv.Itoa(playerLast), playerRoundContext)
+	if useCache {
+		var r playerReport
+		ok, err := loadAggregateCache(db, id, key, &r)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return &r, nil
+		}
+	}
+	r, err := buildPlayerReport(db, id, binEdges, quantileBins)
+	if err != nil || r == nil {
+		return r, err
+	}
+	if useCache {
+		if err := saveAggregateCache(db, id, key, r); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// buildPlayerReport aggregates the player's stored matches under --map,
+// --since, --last and --round-context. It returns nil when no match is left
+// after the filters.
+func buildPlayerReport(db *storage.DB, id uint64, binEdges map[string][]int, quantileBins bool) (*playerReport, error) {
+	stats, err := db.GetAllPlayerMatchStats(id)
+	if err != nil {
+		return nil, fmt.Errorf("query stats for %d: %w", id, err)
+	}
+	stats = filterStats(stats, playerMap, playerSince, playerLast)
+	if len(stats) == 0 {
+		return nil, nil
+	}
+	r := &playerReport{OldestVersion: report.OldestPipelineVersion(stats)}
+
+	segs, err := db.GetAllPlayerDuelSegments(id)
+	if err != nil {
+		return nil, fmt.Errorf("query segments for %d: %w", id, err)
+	}
+
+	// Filter segments to only those matching the filtered demo hashes.
+	if playerMap != "" || playerSince != "" || playerLast > 0 {
+		keep := make(map[string]struct{}, len(stats))
+		for _, s := range stats {
+			keep[s.DemoHash] = struct{}{}
+		}
+		var filteredSegs []model.PlayerDuelSegment
+		for _, seg := range segs {
+			if _, ok := keep[seg.DemoHash]; ok {
+				filteredSegs = append(filteredSegs, seg)
+			}
+		}
+		segs = filteredSegs
+	}
+	r.ContextSegments = segs
+	if playerRoundContext != "" {
+		segs = filterRoundContext(segs, playerRoundContext)
+	}
+
+	r.Agg = buildAggregate(stats)
+	merged := mergeSegments(id, segs)
+
+	deathSegs, err := db.GetAllPlayerDeathSegments(id)
+	if err != nil {
+		return nil, fmt.Errorf("query death segments for %d: %w", id, err)
+	}
+	deathCtx, err := db.GetAllPlayerDeathContexts(id)
+	if err != nil {
+		return nil, fmt.Errorf("query death contexts for %d: %w", id, err)
+	}
+	ttdmg, err := db.GetAllPlayerTimeToDamage(id)
+	if err != nil {
+		return nil, fmt.Errorf("query time to damage for %d: %w", id, err)
+	}
+	bursts, err := db.GetAllPlayerBurstStats(id)
+	if err != nil {
+		return nil, fmt.Errorf("query burst stats for %d: %w", id, err)
+	}
+	positions, err := db.GetAllPlayerPositions(id)
+	if err != nil {
+		return nil, fmt.Errorf("query positions for %d: %w", id, err)
+	}
+
+	// Compute true aggregate FHHS from merged segment counts.
+	var totalHits, totalHSHits int
+	for _, s := range merged {
+		totalHits += s.FirstHitCount
+		totalHSHits += s.FirstHitHSCount
+	}
+	if totalHits > 0 {
+		r.OverallFHHS = float64(totalHSHits) / float64(totalHits) * 100
+	}
+
+	// Aggregate clutch stats across filtered matches for this player.
+	clutchByMatch, err := db.GetPlayerClutchStatsByMatch(id)
+	if err != nil {
+		return nil, fmt.Errorf("query clutch for %d: %w", id, err)
+	}
+	keep := make(map[string]struct{}, len(stats))
+	for _, s := range stats {
+		keep[s.DemoHash] = struct{}{}
+	}
+	r.Clutch.SteamID = id
+	for hash, c := range clutchByMatch {
+		if _, ok := keep[hash]; !ok {
+			continue
+		}
+		for i := 1; i <= 5; i++ {
+			r.Clutch.Attempts[i] += c.Attempts[i]
+			r.Clutch.Wins[i] += c.Wins[i]
+		}
+	}
+
+	// The FHHS table regroups per-meter duel rows under quantile bins; the
+	// overall FHHS above stays on the fixed segments.
+	r.FHHSSegments = merged
+	if quantileBins {
+		dists, err := db.GetAllPlayerDuelDistances(id)
+		if err != nil {
+			return nil, fmt.Errorf("query duel distances for %d: %w", id, err)
+		}
+		r.FHHSSegments = aggregator.QuantileSegments(id, keepDuelDistances(dists, keep), binEdges)
+	}
+
+	hashes := make([]string, 0, len(stats))
+	for _, s := range stats {
+		hashes = append(hashes, s.DemoHash)
+	}
+	r.AWPMaps, err = db.GetPlayerAWPByMap(id, hashes)
+	if err != nil {
+		return nil, fmt.Errorf("query AWP deaths by map for %d: %w", id, err)
+	}
+
+	halves, err := db.GetPlayerHalfStats(id)
+	if err != nil {
+		return nil, fmt.Errorf("query half stats for %d: %w", id, err)
+	}
+	r.Halves = buildHalfSplit(id, r.Agg.Name, halves, keep)
+	r.Deaths = mergeDeathSegments(id, deathSegs, keep)
+	r.DeathContexts = mergeDeathContexts(id, deathCtx, keep)
+	r.TimeToDamage = mergeTimeToDamage(id, ttdmg, keep)
+	r.Bursts = mergeBursts(id, bursts, keep)
+	r.Positions = aggregator.PositionProfiles(keepPositions(positions, keep))
+	r.MapSide = buildMapSideAggregates(stats, segs)
+	return r, nil
+}
+
 // filterStats applies --map, --since, and --last filters to a slice of match stats.
 // stats must be ordered ascending by date (as returned by GetAllPlayerMatchStats).
 func filterStats(stats []model.PlayerMatchStats, mapFilter, since string, last int) []model.PlayerMatchStats {
@@ -217,7 +449,8 @@ func filterStats(stats []model.PlayerMatchStats, mapFilter, since string, last i
 	return out
 }
 
-// buildAggregate sums integer stats and averages float medians across all matches.
+// buildAggregate sums integer stats and averages float medians across all
+// matches, and measures the match-to-match spread of rating, ADR and KAST%.
 func buildAggregate(stats []model.PlayerMatchStats) model.PlayerAggregate {
 	agg := model.PlayerAggregate{
 		SteamID: stats[0].SteamID,
@@ -226,10 +459,15 @@ func buildAggregate(stats []model.PlayerMatchStats) model.PlayerAggregate {
 	}
 	var expoWinSum, expoLossSum, corrSum, hitsSum float64
 	var expoWinN, expoLossN, corrN, hitsN int
+	var spottedSum, ttdmgSum float64
+	var spottedN, ttdmgN int
 	var ttkSum, ttdSum, csSum float64
 	var ttkN, ttdN, csN int
 	var tradeKillDelaySum, tradeDeathDelaySum float64
 	var tradeKillDelayN, tradeDeathDelayN int
+	var utilThrowSecSum float64
+	var pingSum float64 // mean ping × samples
+	var utilThrowSecN int
 	roleCounts := make(map[string]int)
 
 	for _, s := range stats {
@@ -240,6 +478,7 @@ func buildAggregate(stats []model.PlayerMatchStats) model.PlayerAggregate {
 		agg.TotalDamage += s.TotalDamage
 		agg.RoundsPlayed += s.RoundsPlayed
 		agg.KASTRounds += s.KASTRounds
+		agg.KASTStrictRounds += s.KASTStrictRounds
 		agg.FlashAssists += s.FlashAssists
 		agg.EffectiveFlashes += s.EffectiveFlashes
 		agg.OpeningKills += s.OpeningKills
@@ -249,11 +488,80 @@ func buildAggregate(stats []model.PlayerMatchStats) model.PlayerAggregate {
 		agg.RoundsWon += s.RoundsWon
 		agg.DuelWins += s.DuelWins
 		agg.DuelLosses += s.DuelLosses
+		agg.AssistedDuelWins += s.AssistedDuelWins
+		agg.AssistedDuelLosses += s.AssistedDuelLosses
 		agg.AWPDeaths += s.AWPDeaths
 		agg.AWPDeathsDry += s.AWPDeathsDry
 		agg.AWPDeathsRePeek += s.AWPDeathsRePeek
 		agg.AWPDeathsIsolated += s.AWPDeathsIsolated
+		agg.AWPRoundsFaced += s.AWPRoundsFaced
+		agg.AWPShots += s.AWPShots
+		agg.AWPShotKills += s.AWPShotKills
+		agg.AWPShotBodyHits += s.AWPShotBodyHits
 		agg.OneTapKills += s.OneTapKills
+		agg.DeathSpeedSamples += s.DeathSpeedSamples
+		agg.MovingDeaths += s.MovingDeaths
+		agg.CollateralKills += s.CollateralKills
+		agg.SprayTransferKills += s.SprayTransferKills
+		agg.BurstTaps += s.BurstTaps
+		agg.BurstShort += s.BurstShort
+		agg.BurstSpray += s.BurstSpray
+		agg.BurstPanic += s.BurstPanic
+		agg.LowHPHanded += s.LowHPHanded
+		agg.LowHPWasted += s.LowHPWasted
+		agg.UtilityDamageTaken += s.UtilityDamageTaken
+		agg.FlashesReceived += s.FlashesReceived
+		agg.BlindTimeReceivedSec += s.BlindTimeReceivedSec
+		agg.PlayedOffFlashKills += s.PlayedOffFlashKills
+		agg.PlayedOffSmokeKills += s.PlayedOffSmokeKills
+		agg.PlayedOffUtilityKills += s.PlayedOffUtilityKills
+		agg.LateRoundDeaths += s.LateRoundDeaths
+		agg.PlayForTimeRounds += s.PlayForTimeRounds
+		agg.PlayForTimeDeaths += s.PlayForTimeDeaths
+		agg.LongestWinStreak = max(agg.LongestWinStreak, s.LongestWinStreak)
+		agg.StreakRounds += s.StreakRounds
+		agg.StreakKills += s.StreakKills
+		agg.StreakDamage += s.StreakDamage
+		agg.BounceRounds += s.BounceRounds
+		agg.BounceKills += s.BounceKills
+		agg.BounceDamage += s.BounceDamage
+		agg.BounceWins += s.BounceWins
+		agg.HalfFirstKills += s.HalfFirstKills
+		agg.Leading = agg.Leading.Add(s.Leading)
+		agg.Tied = agg.Tied.Add(s.Tied)
+		agg.Trailing = agg.Trailing.Add(s.Trailing)
+		agg.EquipmentValue += s.EquipmentValue
+		agg.PeekDuels += s.PeekDuels
+		agg.PeekWins += s.PeekWins
+		agg.HoldDuels += s.HoldDuels
+		agg.HoldWins += s.HoldWins
+		agg.PrimaryDeaths += s.PrimaryDeaths
+		agg.WrongWeaponDeaths += s.WrongWeaponDeaths
+		agg.LossFlashed += s.LossFlashed
+		agg.LossIsolated += s.LossIsolated
+		agg.LossUtilSupport += s.LossUtilSupport
+		agg.LossRePeek += s.LossRePeek
+		agg.LossExplained += s.LossExplained
+		agg.BulletHits += s.BulletHits
+		agg.HeadHits += s.HeadHits
+		agg.UtilThrowsEarly += s.UtilThrowsEarly
+		agg.UtilThrowsMid += s.UtilThrowsMid
+		agg.UtilThrowsLate += s.UtilThrowsLate
+		agg.EcoRounds += s.EcoRounds
+		agg.EcoDamage += s.EcoDamage
+		agg.BuyRounds += s.BuyRounds
+		agg.BuyDamage += s.BuyDamage
+		agg.UnspottedDeaths += s.UnspottedDeaths
+		agg.SpottedDeaths += s.SpottedDeaths
+		if s.PingSamples > 0 {
+			agg.PingSamples += s.PingSamples
+			pingSum += s.AvgPingMs * float64(s.PingSamples)
+			agg.MaxPingMs = max(agg.MaxPingMs, s.MaxPingMs)
+		}
+		if s.UtilThrows() > 0 {
+			utilThrowSecSum += s.MedianUtilThrowSec
+			utilThrowSecN++
+		}
 
 		if s.MedianExposureWinMs > 0 {
 			expoWinSum += s.MedianExposureWinMs
@@ -263,6 +571,14 @@ func buildAggregate(stats []model.PlayerMatchStats) model.PlayerAggregate {
 			expoLossSum += s.MedianExposureLossMs
 			expoLossN++
 		}
+		if s.MedianSpottedBeforeDeathMs > 0 {
+			spottedSum += s.MedianSpottedBeforeDeathMs
+			spottedN++
+		}
+		if s.TimeToDamageSamples > 0 {
+			ttdmgSum += s.MedianTimeToDamageMs
+			ttdmgN++
+		}
 		if s.MedianCorrectionDeg > 0 {
 			corrSum += s.MedianCorrectionDeg
 			corrN++
@@ -298,12 +614,24 @@ func buildAggregate(stats []model.PlayerMatchStats) model.PlayerAggregate {
 		roleCounts[role]++
 	}
 
+	if agg.PingSamples > 0 {
+		agg.AvgPingMs = pingSum / float64(agg.PingSamples)
+	}
 	if expoWinN > 0 {
 		agg.AvgExpoWinMs = expoWinSum / float64(expoWinN)
 	}
+	if utilThrowSecN > 0 {
+		agg.AvgUtilThrowSec = utilThrowSecSum / float64(utilThrowSecN)
+	}
 	if expoLossN > 0 {
 		agg.AvgExpoLossMs = expoLossSum / float64(expoLossN)
 	}
+	if spottedN > 0 {
+		agg.AvgSpottedBeforeDeathMs = spottedSum / float64(spottedN)
+	}
+	if ttdmgN > 0 {
+		agg.AvgTimeToDamageMs = ttdmgSum / float64(ttdmgN)
+	}
 	if corrN > 0 {
 		agg.AvgCorrectionDeg = corrSum / float64(corrN)
 	}
@@ -333,16 +661,164 @@ func buildAggregate(stats []model.PlayerMatchStats) model.PlayerAggregate {
 		}
 	}
 	agg.Role = bestRole
+	agg.Consistency = aggregator.Consistency(stats)
 
 	return agg
 }
 
+// mergeDeathSegments sums a player's per-demo "died to" segments by (weapon
+// bucket, distance bin), keeping only demos in keep.
+func mergeDeathSegments(steamID uint64, segs []model.PlayerDeathSegment, keep map[string]struct{}) []model.PlayerDeathSegment {
+	type key struct{ bucket, bin string }
+	m := make(map[key]*model.PlayerDeathSegment)
+	var order []key
+	for _, s := range segs {
+		if _, ok := keep[s.DemoHash]; !ok {
+			continue
+		}
+		k := key{s.WeaponBucket, s.DistanceBin}
+		if m[k] == nil {
+			m[k] = &model.PlayerDeathSegment{SteamID: steamID, WeaponBucket: k.bucket, DistanceBin: k.bin}
+			order = append(order, k)
+		}
+		m[k].Deaths += s.Deaths
+		m[k].HeadshotDeaths += s.HeadshotDeaths
+	}
+	out := make([]model.PlayerDeathSegment, 0, len(order))
+	for _, k := range order {
+		out = append(out, *m[k])
+	}
+	return out
+}
+
+// mergeDeathContexts sums a player's per-demo pre-death context counts by
+// context, keeping only demos in keep.
+func mergeDeathContexts(steamID uint64, rows []model.PlayerDeathContext, keep map[string]struct{}) []model.PlayerDeathContext {
+	type key struct {
+		movement                     string
+		flashed, isolated, afterKill bool
+	}
+	m := make(map[key]*model.PlayerDeathContext)
+	var order []key
+	for _, r := range rows {
+		if _, ok := keep[r.DemoHash]; !ok {
+			continue
+		}
+		k := key{r.Movement, r.Flashed, r.Isolated, r.AfterKill}
+		if m[k] == nil {
+			m[k] = &model.PlayerDeathContext{SteamID: steamID, Movement: r.Movement,
+				Flashed: r.Flashed, Isolated: r.Isolated, AfterKill: r.AfterKill}
+			order = append(order, k)
+		}
+		m[k].Deaths += r.Deaths
+	}
+	out := make([]model.PlayerDeathContext, 0, len(order))
+	for _, k := range order {
+		out = append(out, *m[k])
+	}
+	return out
+}
+
+// mergeTimeToDamage combines a player's per-demo time-to-damage rows by weapon
+// bucket, keeping only demos in keep (nil keeps all): samples are summed and
+// the per-demo medians averaged, weighted by samples.
+func mergeTimeToDamage(steamID uint64, rows []model.PlayerTimeToDamage, keep map[string]struct{}) []model.PlayerTimeToDamage {
+	m := make(map[string]*model.PlayerTimeToDamage)
+	var order []string
+	for _, r := range rows {
+		if _, ok := keep[r.DemoHash]; keep != nil && !ok {
+			continue
+		}
+		if r.Samples == 0 {
+			continue
+		}
+		a := m[r.WeaponBucket]
+		if a == nil {
+			a = &model.PlayerTimeToDamage{SteamID: steamID, WeaponBucket: r.WeaponBucket}
+			m[r.WeaponBucket] = a
+			order = append(order, r.WeaponBucket)
+		}
+		n := a.Samples + r.Samples
+		a.MedianMs = (a.MedianMs*float64(a.Samples) + r.MedianMs*float64(r.Samples)) / float64(n)
+		a.Samples = n
+	}
+	out := make([]model.PlayerTimeToDamage, 0, len(order))
+	for _, b := range order {
+		out = append(out, *m[b])
+	}
+	return out
+}
+
+// keepPositions returns the position rows of demos in keep (nil keeps all).
+func keepPositions(rows []model.PlayerPositionStats, keep map[string]struct{}) []model.PlayerPositionStats {
+	if keep == nil {
+		return rows
+	}
+	var out []model.PlayerPositionStats
+	for _, r := range rows {
+		if _, ok := keep[r.DemoHash]; ok {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// keepDuelDistances returns the duel distance rows of demos in keep.
+func keepDuelDistances(rows []model.PlayerDuelDistance, keep map[string]struct{}) []model.PlayerDuelDistance {
+	var out []model.PlayerDuelDistance
+	for _, r := range rows {
+		if _, ok := keep[r.DemoHash]; ok {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// mergeBursts sums a player's per-demo burst histograms by weapon bucket,
+// keeping only demos in keep (nil keeps all).
+func mergeBursts(steamID uint64, rows []model.PlayerBurstStats, keep map[string]struct{}) []model.PlayerBurstStats {
+	m := make(map[string]*model.PlayerBurstStats)
+	var order []string
+	for _, r := range rows {
+		if _, ok := keep[r.DemoHash]; keep != nil && !ok {
+			continue
+		}
+		a := m[r.WeaponBucket]
+		if a == nil {
+			a = &model.PlayerBurstStats{SteamID: steamID, WeaponBucket: r.WeaponBucket}
+			m[r.WeaponBucket] = a
+			order = append(order, r.WeaponBucket)
+		}
+		a.Taps += r.Taps
+		a.ShortBursts += r.ShortBursts
+		a.Sprays += r.Sprays
+		a.PanicSprays += r.PanicSprays
+	}
+	out := make([]model.PlayerBurstStats, 0, len(order))
+	for _, b := range order {
+		out = append(out, *m[b])
+	}
+	return out
+}
+
+// filterRoundContext keeps the duel segments won in the given round context.
+func filterRoundContext(segs []model.PlayerDuelSegment, context string) []model.PlayerDuelSegment {
+	var out []model.PlayerDuelSegment
+	for _, s := range segs {
+		if s.RoundContext == context {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
 // mergeSegments groups segment rows by (WeaponBucket, DistanceBin), summing counts
 // and averaging float medians across demos. Returns a single merged slice.
 func mergeSegments(steamID uint64, segs []model.PlayerDuelSegment) []model.PlayerDuelSegment {
 	type key struct{ bucket, bin string }
 	type accum struct {
 		duelCount, firstHitCount, firstHitHSCount int
+		stillHits, stillHS, movingHits, movingHS  int
 		corrSum, sightSum, expoSum                float64
 		corrN, sightN, expoN                      int
 	}
@@ -356,6 +832,10 @@ func mergeSegments(steamID uint64, segs []model.PlayerDuelSegment) []model.Playe
 		a.duelCount += s.DuelCount
 		a.firstHitCount += s.FirstHitCount
 		a.firstHitHSCount += s.FirstHitHSCount
+		a.stillHits += s.StillFirstHits
+		a.stillHS += s.StillFirstHitHS
+		a.movingHits += s.MovingFirstHits
+		a.movingHS += s.MovingFirstHitHS
 		if s.MedianCorrDeg > 0 {
 			a.corrSum += s.MedianCorrDeg
 			a.corrN++
@@ -373,12 +853,16 @@ func mergeSegments(steamID uint64, segs []model.PlayerDuelSegment) []model.Playe
 	out := make([]model.PlayerDuelSegment, 0, len(m))
 	for k, a := range m {
 		seg := model.PlayerDuelSegment{
-			SteamID:         steamID,
-			WeaponBucket:    k.bucket,
-			DistanceBin:     k.bin,
-			DuelCount:       a.duelCount,
-			FirstHitCount:   a.firstHitCount,
-			FirstHitHSCount: a.firstHitHSCount,
+			SteamID:          steamID,
+			WeaponBucket:     k.bucket,
+			DistanceBin:      k.bin,
+			DuelCount:        a.duelCount,
+			FirstHitCount:    a.firstHitCount,
+			FirstHitHSCount:  a.firstHitHSCount,
+			StillFirstHits:   a.stillHits,
+			StillFirstHitHS:  a.stillHS,
+			MovingFirstHits:  a.movingHits,
+			MovingFirstHitHS: a.movingHS,
 		}
 		if a.corrN > 0 {
 			seg.MedianCorrDeg = a.corrSum / float64(a.corrN)
@@ -394,10 +878,49 @@ func mergeSegments(steamID uint64, segs []model.PlayerDuelSegment) []model.Playe
 	return out
 }
 
-// buildMapSideAggregates groups match stats by (map, side) and sums integer stats.
-func buildMapSideAggregates(stats []model.PlayerMatchStats) []model.PlayerMapSideAggregate {
+// buildHalfSplit sums per-half stats over the demos in keep into a single
+// first-half vs second-half split for one player.
+func buildHalfSplit(steamID uint64, name string, halves []model.PlayerHalfStats, keep map[string]struct{}) model.PlayerHalfSplit {
+	split := model.PlayerHalfSplit{
+		SteamID: steamID,
+		Name:    name,
+		First:   model.PlayerHalfStats{SteamID: steamID, Half: 1},
+		Second:  model.PlayerHalfStats{SteamID: steamID, Half: 2},
+	}
+	for _, h := range halves {
+		if _, ok := keep[h.DemoHash]; !ok {
+			continue
+		}
+		dst := &split.First
+		if h.Half == 2 {
+			dst = &split.Second
+			split.Matches++
+		}
+		dst.Kills += h.Kills
+		dst.Assists += h.Assists
+		dst.Deaths += h.Deaths
+		dst.TotalDamage += h.TotalDamage
+		dst.RoundsPlayed += h.RoundsPlayed
+		dst.KASTRounds += h.KASTRounds
+		dst.OpeningKills += h.OpeningKills
+		dst.OpeningDeaths += h.OpeningDeaths
+	}
+	return split
+}
+
+// buildMapSideAggregates groups match stats by (map, side) and sums integer
+// stats. Exposure medians are averaged over the matches that have one, and
+// segs (the player's duel segments) are pooled into the FHHS counts of their
+// demo's (map, side); segments from demos not in stats are ignored.
+func buildMapSideAggregates(stats []model.PlayerMatchStats, segs []model.PlayerDuelSegment) []model.PlayerMapSideAggregate {
 	type key struct{ mapName, side string }
+	type expoSums struct {
+		win, loss   float64
+		winN, lossN int
+	}
 	m := make(map[key]*model.PlayerMapSideAggregate)
+	expo := make(map[key]*expoSums)
+	demoKey := make(map[string]key, len(stats))
 
 	for _, s := range stats {
 		side := s.Team.String()
@@ -406,6 +929,7 @@ func buildMapSideAggregates(stats []model.PlayerMatchStats) []model.PlayerMapSid
 		}
 		mapName := strings.TrimPrefix(s.MapName, "de_")
 		k := key{mapName, side}
+		demoKey[s.DemoHash] = k
 		if m[k] == nil {
 			m[k] = &model.PlayerMapSideAggregate{
 				SteamID: s.SteamID,
@@ -413,6 +937,7 @@ func buildMapSideAggregates(stats []model.PlayerMatchStats) []model.PlayerMapSid
 				MapName: mapName,
 				Side:    side,
 			}
+			expo[k] = &expoSums{}
 		}
 		a := m[k]
 		a.Matches++
@@ -427,10 +952,36 @@ func buildMapSideAggregates(stats []model.PlayerMatchStats) []model.PlayerMapSid
 		a.OpeningDeaths += s.OpeningDeaths
 		a.TradeKills += s.TradeKills
 		a.TradeDeaths += s.TradeDeaths
+		a.DuelWins += s.DuelWins
+		a.DuelLosses += s.DuelLosses
+
+		e := expo[k]
+		if s.MedianExposureWinMs > 0 {
+			e.win += s.MedianExposureWinMs
+			e.winN++
+		}
+		if s.MedianExposureLossMs > 0 {
+			e.loss += s.MedianExposureLossMs
+			e.lossN++
+		}
+	}
+	for _, seg := range segs {
+		k, ok := demoKey[seg.DemoHash]
+		if !ok {
+			continue
+		}
+		m[k].FirstHitCount += seg.FirstHitCount
+		m[k].FirstHitHSCount += seg.FirstHitHSCount
 	}
 
 	out := make([]model.PlayerMapSideAggregate, 0, len(m))
-	for _, v := range m {
+	for k, v := range m {
+		if e := expo[k]; e.winN > 0 {
+			v.AvgExpoWinMs = e.win / float64(e.winN)
+		}
+		if e := expo[k]; e.lossN > 0 {
+			v.AvgExpoLossMs = e.loss / float64(e.lossN)
+		}
 		out = append(out, *v)
 	}
 	// Sort by map name ascending, CT before T within each map.
@@ -0,0 +1,282 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+	"github.com/pable/go-cs-metrics/internal/report"
+	"github.com/pable/go-cs-metrics/internal/storage"
+)
+
+var (
+	practiceMinDuels int
+	practiceGap      float64
+	practiceTop      int
+	practiceMinutes  int
+	practiceAI       bool
+	practiceModel    string
+	practiceAPIKey   string
+)
+
+// practiceAngleSlack is how far (as a ratio) a segment's sight or correction
+// angle must exceed the reference before the drill targets that instead of the
+// first bullet.
+const practiceAngleSlack = 1.2
+
+// practiceHesitationSlack is how far (as a ratio) the player's time to damage
+// with the segment's weapon must exceed the reference before the drill targets
+// hesitation (firing on first contact).
+const practiceHesitationSlack = 1.25
+
+// practicePlanCmd is the cobra command that turns weak FHHS segments into a practice routine.
+var practicePlanCmd = &cobra.Command{
+	Use:   "practice-plan <steamid64>",
+	Short: "Deathmatch practice routine from weak, well-sampled duel segments",
+	Long: `Select the weapon × distance duel segments where the player's first-hit
+headshot rate (FHHS) is clearly below the reference and the sample is large
+enough to trust, and turn them into a concrete practice routine.
+
+The reference for each segment is every other player's pooled FHHS in that
+segment, taken from baseline demos when they hold at least --min-duels first
+hits there. Segments are ranked by headshots lost against the reference
+((ref% − player%) × first hits). Each gets a drill: crosshair placement when the
+median sight angle is well above the reference, flick/correction when the
+correction angle is, a first-contact drill when the time from first seeing an
+enemy to first damaging them with that weapon is well above the reference
+(hesitation), otherwise a first-bullet drill chosen by weapon and range.
+
+With --ai the plan is rewritten as a routine by the Anthropic API; without a key
+or on error the deterministic plan is printed instead.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPracticePlan,
+}
+
+func init() {
+	practicePlanCmd.Flags().IntVar(&practiceMinDuels, "min-duels", 10, "minimum duels in a segment (and first hits in its reference) to consider it")
+	practicePlanCmd.Flags().Float64Var(&practiceGap, "gap", 5, "minimum FHHS gap below the reference, in percentage points")
+	practicePlanCmd.Flags().IntVar(&practiceTop, "top", 5, "maximum number of segments in the plan")
+	practicePlanCmd.Flags().IntVar(&practiceMinutes, "minutes", 30, "session length to split across the drills")
+	practicePlanCmd.Flags().BoolVar(&practiceAI, "ai", false, "render the plan as a routine with the Anthropic API")
+	practicePlanCmd.Flags().StringVar(&practiceModel, "model", "claude-haiku-4-5-20251001", "Anthropic model to use with --ai")
+	practicePlanCmd.Flags().StringVar(&practiceAPIKey, "api-key", "", "Anthropic API key (falls back to $ANTHROPIC_API_KEY)")
+}
+
+// runPracticePlan loads the player's merged duel segments, selects weak ones, and prints the plan.
+func runPracticePlan(cmd *cobra.Command, args []string) error {
+	steamID, err := resolveSteamID(args[0])
+	if err != nil {
+		return err
+	}
+
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
+	}
+	defer db.Close()
+
+	segs, err := db.GetAllPlayerDuelSegments(steamID)
+	if err != nil {
+		return fmt.Errorf("get duel segments: %w", err)
+	}
+	if len(segs) == 0 {
+		return noDataError("no duel segments found for player %d", steamID)
+	}
+	refs, err := db.GetSegmentReferences(steamID, practiceMinDuels)
+	if err != nil {
+		return fmt.Errorf("get segment references: %w", err)
+	}
+
+	name := strconv.FormatUint(steamID, 10)
+	if all, err := db.GetAllPlayerMatchStats(steamID); err == nil && len(all) > 0 {
+		name = all[0].Name
+	}
+
+	ttdmg, err := db.GetAllPlayerTimeToDamage(steamID)
+	if err != nil {
+		return fmt.Errorf("get time to damage: %w", err)
+	}
+	ttdmgRefs, err := db.GetTimeToDamageReferences(steamID, practiceMinDuels)
+	if err != nil {
+		return fmt.Errorf("get time-to-damage references: %w", err)
+	}
+
+	merged := mergeSegments(steamID, segs)
+	items, lowSample := buildPracticePlan(merged, refs, mergeTimeToDamage(steamID, ttdmg, nil), ttdmgRefs,
+		practiceMinDuels, practiceGap, practiceTop, practiceMinutes)
+	if lowSample > 0 {
+		fmt.Fprintf(os.Stderr, "%d segment(s) skipped: fewer than %d duels (or no reference with that many first hits)\n",
+			lowSample, practiceMinDuels)
+	}
+	if len(items) == 0 {
+		fmt.Fprintf(os.Stdout, "No well-sampled segment is more than %.0f pp below the reference — nothing to drill.\n", practiceGap)
+		return nil
+	}
+
+	if practiceAI {
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"player":          name,
+			"session_minutes": practiceMinutes,
+			"weak_segments":   buildPracticeContext(items),
+		}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encode practice context: %w", err)
+		}
+		question := "Turn these weak duel segments into a concrete deathmatch practice routine for one session: " +
+			"for each drill give the weapon, the engagement range, the drill or workshop map type, and the time to spend. " +
+			"Keep the order and the minutes given."
+		err = callAnthropic(context.Background(), practiceAPIKey, practiceModel, string(data), question)
+		if err == nil {
+			return nil
+		}
+		fmt.Fprintf(os.Stderr, "warn: AI rendering failed (%v); showing the deterministic plan\n", err)
+	}
+
+	report.PrintPracticePlanTable(os.Stdout, items, name)
+	return nil
+}
+
+// buildPracticePlan selects merged segments with at least minDuels duels that
+// sit at least gap percentage points below their reference FHHS, ranks them by
+// headshots lost, keeps the top n, and splits minutes across them by that
+// weight (at least 5 minutes each). It also returns how many segments were
+// skipped for too few samples. "Other" weapons and unknown distances are ignored.
+// ttdmg and ttdmgRefs are the player's and the reference time to damage per
+// weapon bucket; buckets with fewer than minDuels samples on either side are
+// not compared.
+func buildPracticePlan(segs []model.PlayerDuelSegment, refs []model.SegmentReference, ttdmg, ttdmgRefs []model.PlayerTimeToDamage, minDuels int, gap float64, n, minutes int) ([]model.PracticeItem, int) {
+	type key struct{ bucket, bin string }
+	refIdx := make(map[key]model.SegmentReference, len(refs))
+	for _, r := range refs {
+		refIdx[key{r.WeaponBucket, r.DistanceBin}] = r
+	}
+	ttdmgIdx := make(map[string]float64, len(ttdmg))
+	for _, t := range ttdmg {
+		if t.Samples >= minDuels {
+			ttdmgIdx[t.WeaponBucket] = t.MedianMs
+		}
+	}
+	ttdmgRefIdx := make(map[string]float64, len(ttdmgRefs))
+	for _, t := range ttdmgRefs {
+		if t.Samples >= minDuels {
+			ttdmgRefIdx[t.WeaponBucket] = t.MedianMs
+		}
+	}
+
+	var items []model.PracticeItem
+	lowSample := 0
+	for _, s := range segs {
+		if s.WeaponBucket == "Other" || s.DistanceBin == "unknown" {
+			continue
+		}
+		ref, ok := refIdx[key{s.WeaponBucket, s.DistanceBin}]
+		if s.DuelCount < minDuels || s.FirstHitCount == 0 || !ok || ref.FirstHitCount < minDuels {
+			lowSample++
+			continue
+		}
+		pct := float64(s.FirstHitHSCount) / float64(s.FirstHitCount) * 100
+		refPct := ref.FHHSPct()
+		if refPct-pct < gap {
+			continue
+		}
+		it := model.PracticeItem{
+			WeaponBucket: s.WeaponBucket,
+			DistanceBin:  s.DistanceBin,
+			DuelCount:    s.DuelCount,
+			FirstHits:    s.FirstHitCount,
+			FHHSPct:      pct,
+			RefFHHSPct:   refPct,
+			HSLost:       (refPct - pct) / 100 * float64(s.FirstHitCount),
+			SightDeg:     s.MedianSightDeg,
+			CorrDeg:      s.MedianCorrDeg,
+			TTDmgMs:      ttdmgIdx[s.WeaponBucket],
+			RefTTDmgMs:   ttdmgRefIdx[s.WeaponBucket],
+		}
+		it.Focus, it.Drill = practiceDrill(it, ref)
+		items = append(items, it)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].HSLost != items[j].HSLost {
+			return items[i].HSLost > items[j].HSLost
+		}
+		return items[i].WeaponBucket+items[i].DistanceBin < items[j].WeaponBucket+items[j].DistanceBin
+	})
+	if len(items) > n {
+		items = items[:n]
+	}
+
+	var total float64
+	for _, it := range items {
+		total += it.HSLost
+	}
+	for i := range items {
+		m := int(float64(minutes)*items[i].HSLost/total + 0.5)
+		if m < 5 {
+			m = 5
+		}
+		items[i].Minutes = m
+	}
+	return items, lowSample
+}
+
+// practiceDrill picks the focus and drill for a weak segment. Crosshair
+// placement wins when the sight angle is well above the reference, then flick
+// correction, then hesitation (slow time to damage with the weapon); otherwise
+// the first bullet itself is drilled by weapon and range.
+func practiceDrill(it model.PracticeItem, ref model.SegmentReference) (focus, drill string) {
+	rng := it.DistanceBin
+	switch {
+	case ref.AvgSightDeg > 0 && it.SightDeg > ref.AvgSightDeg*practiceAngleSlack:
+		return "placement", fmt.Sprintf("Prefire/pre-aim map with the %s: hold head height on common %s angles before they open", it.WeaponBucket, rng)
+	case ref.AvgCorrDeg > 0 && it.CorrDeg > ref.AvgCorrDeg*practiceAngleSlack:
+		if it.WeaponBucket == "AWP" || it.WeaponBucket == "Scout" {
+			return "correction", fmt.Sprintf("%s flick DM: quick-scope small flicks onto targets at %s", it.WeaponBucket, rng)
+		}
+		return "correction", fmt.Sprintf("Micro-flick aim map with the %s: small flicks onto heads at %s, first bullet only", it.WeaponBucket, rng)
+	case it.TTDmgMs > 0 && it.RefTTDmgMs > 0 && it.TTDmgMs > it.RefTTDmgMs*practiceHesitationSlack:
+		return "hesitation", fmt.Sprintf("Reaction DM with the %s: pre-aim the %s angle and fire the moment a head appears, no re-checking", it.WeaponBucket, rng)
+	}
+
+	switch it.WeaponBucket {
+	case "Pistol", "Deagle":
+		return "first bullet", fmt.Sprintf("Headshot-only pistol DM (%s): one tap, reset, re-peek at %s", it.WeaponBucket, rng)
+	case "AWP", "Scout":
+		return "first bullet", fmt.Sprintf("%s DM: hold an angle at %s and fire only on a head/upper-chest line", it.WeaponBucket, rng)
+	}
+	switch rng {
+	case "0-5m", "5-10m":
+		return "first bullet", fmt.Sprintf("Headshot-only DM with the %s: counter-strafe and tap at %s, no spray", it.WeaponBucket, rng)
+	case "10-15m", "15-20m":
+		return "first bullet", fmt.Sprintf("One-tap DM with the %s: stop fully, first bullet at head height at %s", it.WeaponBucket, rng)
+	default:
+		return "first bullet", fmt.Sprintf("Long-range bot drill with the %s: single taps and 2-shot bursts at %s", it.WeaponBucket, rng)
+	}
+}
+
+// buildPracticeContext converts practice items into a context-friendly slice for the AI prompt.
+func buildPracticeContext(items []model.PracticeItem) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(items))
+	for _, it := range items {
+		out = append(out, map[string]interface{}{
+			"weapon":                it.WeaponBucket,
+			"distance":              it.DistanceBin,
+			"duels":                 it.DuelCount,
+			"fhhs_pct":              round2(it.FHHSPct),
+			"ref_fhhs_pct":          round2(it.RefFHHSPct),
+			"headshots_lost":        round2(it.HSLost),
+			"time_to_damage_ms":     round2(it.TTDmgMs),
+			"ref_time_to_damage_ms": round2(it.RefTTDmgMs),
+			"focus":                 it.Focus,
+			"suggested":             it.Drill,
+			"minutes":               it.Minutes,
+		})
+	}
+	return out
+}
@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+	"github.com/pable/go-cs-metrics/internal/report"
+)
+
+const (
+	// predictRatingScale is the log-odds per rating point of difference: a
+	// team rated 0.10 higher is a 73% favourite before the map term.
+	predictRatingScale = 10.0
+	// predictMapPriorMatches is the number of pseudo-matches at 50% a map win
+	// rate is shrunk with, so a 1-0 map record is not read as a sure win.
+	predictMapPriorMatches = 4
+	// Sanity thresholds for the warnings.
+	predictMinDemos      = 5
+	predictMinMapMatches = 3
+	predictStaleDays     = 30
+	predictMinRating     = 0.5
+	predictMaxRating     = 1.6
+)
+
+var predictMap string
+
+var predictCmd = &cobra.Command{
+	Use:   "predict <teamA.json> <teamB.json>",
+	Short: "Naive win probability from two team exports (sanity check before simbo3)",
+	Long: `Read two team files written by "export" and combine them into a naive
+win-probability estimate for team A:
+
+  rating term  logistic on the difference of the mean player ratings,
+               10 log-odds per rating point (+0.10 → 73%)
+  map term     log5 of both map win rates on --map, each shrunk toward 50%
+               with 4 pseudo-matches; 50% for a team without the map
+
+The two terms are added on the log-odds scale. Without --map only the
+rating term is used.
+
+This is not a simulation: it is a quick check that the exported numbers
+are plausible before feeding them to simbo3. Exports with few demos, few
+matches on the map, missing or implausible ratings, different look-back
+windows, or generated more than 30 days ago are reported as warnings.
+
+Example:
+  csmetrics export --roster navi.json --out navi-simbo3.json
+  csmetrics export --roster vitality.json --out vitality-simbo3.json
+  csmetrics predict navi-simbo3.json vitality-simbo3.json --map nuke`,
+	Args: cobra.ExactArgs(2),
+	RunE: runPredict,
+}
+
+func init() {
+	predictCmd.Flags().StringVar(&predictMap, "map", "", "map to predict (e.g. nuke, de_nuke); empty uses ratings only")
+}
+
+func runPredict(_ *cobra.Command, args []string) error {
+	a, err := readTeamExport(args[0])
+	if err != nil {
+		return err
+	}
+	b, err := readTeamExport(args[1])
+	if err != nil {
+		return err
+	}
+	for i, t := range []simbo3TeamStats{a, b} {
+		if len(t.PlayersRating2_3m) == 0 {
+			return noDataError("%s has no player ratings (players_rating2_3m); re-run export", args[i])
+		}
+	}
+
+	mapName := ""
+	if predictMap != "" {
+		pool := splitMapPool(predictMap)
+		if len(pool) != 1 {
+			return withExitCode(ExitUsage, fmt.Errorf("--map takes one map, got %q", predictMap))
+		}
+		mapName = pool[0]
+	}
+
+	p := predictMatch(a, b, mapName)
+	for _, msg := range predictWarnings(a, b, p, time.Now()) {
+		fmt.Fprintf(os.Stderr, "warn: %s\n", msg)
+	}
+	report.PrintPredictionTable(os.Stdout, p)
+	return nil
+}
+
+// readTeamExport reads a team file written by export.
+func readTeamExport(path string) (simbo3TeamStats, error) {
+	var t simbo3TeamStats
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return t, fmt.Errorf("read team export: %w", err)
+	}
+	if err := json.Unmarshal(data, &t); err != nil {
+		return t, fmt.Errorf("parse team export %s: %w", path, err)
+	}
+	if t.Team == "" {
+		t.Team = strings.TrimSuffix(filepath.Base(path), ".json")
+	}
+	return t, nil
+}
+
+// exportMap returns a team export's stats for mapName, matching names the
+// way map-pool does ("nuke", "de_nuke" and "Nuke" are the same map).
+func exportMap(t simbo3TeamStats, mapName string) (simbo3MapStats, bool) {
+	for name, ms := range t.Maps {
+		if mapKey(name) == mapKey(mapName) {
+			return ms, true
+		}
+	}
+	return simbo3MapStats{}, false
+}
+
+// predictionTeam summarises one export for the prediction: mean rating and
+// the shrunk win rate on mapName.
+func predictionTeam(t simbo3TeamStats, mapName string) model.PredictionTeam {
+	pt := model.PredictionTeam{
+		Team:        t.Team,
+		Players:     len(t.PlayersRating2_3m),
+		MapWinPct:   0.5,
+		DemoCount:   t.DemoCount,
+		LatestMatch: t.LatestMatchDate,
+	}
+	for _, r := range t.PlayersRating2_3m {
+		pt.Rating += r
+	}
+	if pt.Players > 0 {
+		pt.Rating /= float64(pt.Players)
+	}
+	if mapName == "" {
+		return pt
+	}
+	if ms, ok := exportMap(t, mapName); ok {
+		pt.HasMap = true
+		pt.MapMatches = ms.Matches3m
+		n := float64(ms.Matches3m)
+		pt.MapWinPct = (ms.MapWinPct*n + 0.5*predictMapPriorMatches) / (n + predictMapPriorMatches)
+	}
+	return pt
+}
+
+// predictMatch estimates P(A wins) on mapName (ratings only when empty).
+func predictMatch(a, b simbo3TeamStats, mapName string) model.MatchPrediction {
+	p := model.MatchPrediction{
+		Map: mapName,
+		A:   predictionTeam(a, mapName),
+		B:   predictionTeam(b, mapName),
+	}
+	ratingLogit := predictRatingScale * (p.A.Rating - p.B.Rating)
+	// log5 on the log-odds scale: logit(a) − logit(b).
+	mapLogit := logit(p.A.MapWinPct) - logit(p.B.MapWinPct)
+	p.RatingProb = logistic(ratingLogit)
+	p.MapProb = logistic(mapLogit)
+	p.WinProb = logistic(ratingLogit + mapLogit)
+	return p
+}
+
+// predictWarnings lists what makes the exports a shaky basis for a
+// prediction (or for simbo3): thin samples, stale or mismatched exports,
+// missing maps and implausible values.
+func predictWarnings(a, b simbo3TeamStats, p model.MatchPrediction, now time.Time) []string {
+	var out []string
+	if strings.EqualFold(a.Team, b.Team) {
+		out = append(out, fmt.Sprintf("both exports are for %q", a.Team))
+	}
+	if a.WindowDays != b.WindowDays {
+		out = append(out, fmt.Sprintf("look-back windows differ: %s %d days, %s %d days", a.Team, a.WindowDays, b.Team, b.WindowDays))
+	}
+	if a.PipelineVersion != b.PipelineVersion {
+		out = append(out, fmt.Sprintf("pipeline versions differ: %s v%d, %s v%d; re-run export for both", a.Team, a.PipelineVersion, b.Team, b.PipelineVersion))
+	}
+	for _, t := range []simbo3TeamStats{a, b} {
+		if n := len(t.PlayersRating2_3m); n != 5 {
+			out = append(out, fmt.Sprintf("%s: %d player ratings, want 5", t.Team, n))
+		}
+		for _, r := range t.PlayersRating2_3m {
+			if r < predictMinRating || r > predictMaxRating {
+				out = append(out, fmt.Sprintf("%s: implausible player rating %.2f (expected %.1f–%.1f)", t.Team, r, predictMinRating, predictMaxRating))
+			}
+		}
+		if t.DemoCount < predictMinDemos {
+			out = append(out, fmt.Sprintf("%s: only %d demo(s) in the export", t.Team, t.DemoCount))
+		}
+		if at, err := time.Parse(time.RFC3339, t.GeneratedAt); err == nil {
+			if days := int(now.Sub(at).Hours() / 24); days > predictStaleDays {
+				out = append(out, fmt.Sprintf("%s: export generated %d days ago; re-run export", t.Team, days))
+			}
+		}
+		if p.Map == "" {
+			continue
+		}
+		ms, ok := exportMap(t, p.Map)
+		switch {
+		case !ok:
+			out = append(out, fmt.Sprintf("%s: no %s matches in the export; map term uses 50%%", t.Team, p.Map))
+		case ms.MapWinPct < 0 || ms.MapWinPct > 1:
+			out = append(out, fmt.Sprintf("%s: %s map_win_pct %.2f is outside 0–1", t.Team, p.Map, ms.MapWinPct))
+		case ms.Matches3m < predictMinMapMatches:
+			out = append(out, fmt.Sprintf("%s: only %d %s match(es); map term is mostly the 50%% prior", t.Team, ms.Matches3m, p.Map))
+		}
+	}
+	return out
+}
+
+// logit returns log(p / (1 − p)), with p clamped away from 0 and 1.
+func logit(p float64) float64 {
+	p = math.Min(math.Max(p, 0.001), 0.999)
+	return math.Log(p / (1 - p))
+}
+
+// logistic returns 1 / (1 + e^−x).
+func logistic(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// predictTeam returns a plausible five-player export: every player rated
+// rating, and the given maps keyed by name.
+func predictTeam(name string, rating float64, maps map[string]simbo3MapStats) simbo3TeamStats {
+	return simbo3TeamStats{
+		Team:              name,
+		PlayersRating2_3m: []float64{rating, rating, rating, rating, rating},
+		Maps:              maps,
+		GeneratedAt:       "2026-10-01T12:00:00Z",
+		WindowDays:        90,
+		DemoCount:         20,
+		PipelineVersion:   50,
+	}
+}
+
+// approx reports whether a and b agree to 1e-9.
+func approx(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestPredictMatch(t *testing.T) {
+	nuke := func(winPct float64, matches int) map[string]simbo3MapStats {
+		return map[string]simbo3MapStats{"de_nuke": {MapWinPct: winPct, Matches3m: matches}}
+	}
+
+	t.Run("equal teams are 50%", func(t *testing.T) {
+		a := predictTeam("A", 1.05, nuke(0.6, 10))
+		b := predictTeam("B", 1.05, nuke(0.6, 10))
+		for _, m := range []string{"", "Nuke"} {
+			p := predictMatch(a, b, m)
+			if !approx(p.RatingProb, 0.5) || !approx(p.MapProb, 0.5) || !approx(p.WinProb, 0.5) {
+				t.Errorf("map %q: rating %v map %v win %v, want all 0.5", m, p.RatingProb, p.MapProb, p.WinProb)
+			}
+		}
+	})
+
+	t.Run("0.10 rating gap is about 73%", func(t *testing.T) {
+		p := predictMatch(predictTeam("A", 1.10, nil), predictTeam("B", 1.00, nil), "")
+		if !approx(p.RatingProb, logistic(1)) || math.Abs(p.RatingProb-0.73) > 0.005 {
+			t.Errorf("RatingProb = %v, want logistic(1) ≈ 0.731", p.RatingProb)
+		}
+		if !approx(p.MapProb, 0.5) || !approx(p.WinProb, p.RatingProb) {
+			t.Errorf("without --map: MapProb %v WinProb %v, want 0.5 and the rating term", p.MapProb, p.WinProb)
+		}
+	})
+
+	t.Run("swapping teams mirrors the probability", func(t *testing.T) {
+		a := predictTeam("A", 1.12, nuke(0.7, 8))
+		b := predictTeam("B", 0.98, nuke(0.45, 12))
+		ab, ba := predictMatch(a, b, "nuke"), predictMatch(b, a, "nuke")
+		if !approx(ab.WinProb+ba.WinProb, 1) || !approx(ab.MapProb+ba.MapProb, 1) {
+			t.Errorf("P(A)=%v P(B)=%v map %v/%v, want each pair to sum to 1", ab.WinProb, ba.WinProb, ab.MapProb, ba.MapProb)
+		}
+	})
+
+	t.Run("map win rate shrunk with four pseudo-matches", func(t *testing.T) {
+		p := predictMatch(predictTeam("A", 1, nuke(1, 1)), predictTeam("B", 1, nil), "de_nuke")
+		// (1×1 + 0.5×4) / (1 + 4)
+		if !approx(p.A.MapWinPct, 0.6) || !p.A.HasMap || p.A.MapMatches != 1 {
+			t.Errorf("A map = %v (has %v, %d matches), want 0.6 from a 1-0 record", p.A.MapWinPct, p.A.HasMap, p.A.MapMatches)
+		}
+		if !approx(p.B.MapWinPct, 0.5) || p.B.HasMap {
+			t.Errorf("B without the map = %v (has %v), want 0.5", p.B.MapWinPct, p.B.HasMap)
+		}
+		if !approx(p.MapProb, 0.6) {
+			t.Errorf("MapProb = %v, want 0.6 against a 50%% team", p.MapProb)
+		}
+	})
+
+	t.Run("log5 and the sum of both terms", func(t *testing.T) {
+		// 0.6 and 0.4 after shrinkage: 20 matches at 0.62 and 0.38.
+		a := predictTeam("A", 1.05, nuke(0.62, 20))
+		b := predictTeam("B", 1.00, nuke(0.38, 20))
+		p := predictMatch(a, b, "nuke")
+		if !approx(p.A.MapWinPct, 0.6) || !approx(p.B.MapWinPct, 0.4) {
+			t.Fatalf("shrunk map win = %v / %v, want 0.6 / 0.4", p.A.MapWinPct, p.B.MapWinPct)
+		}
+		if want := 0.6 * 0.6 / (0.6*0.6 + 0.4*0.4); !approx(p.MapProb, want) {
+			t.Errorf("MapProb = %v, want log5 %v", p.MapProb, want)
+		}
+		if want := logistic(0.5 + logit(p.MapProb)); !approx(p.WinProb, want) {
+			t.Errorf("WinProb = %v, want %v from adding the log-odds", p.WinProb, want)
+		}
+	})
+}
+
+func TestPredictWarnings(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	maps := map[string]simbo3MapStats{"de_nuke": {MapWinPct: 0.55, Matches3m: predictMinMapMatches}}
+	clean := func(name string) simbo3TeamStats {
+		t := predictTeam(name, 1.0, maps)
+		t.DemoCount = predictMinDemos
+		t.GeneratedAt = now.AddDate(0, 0, -predictStaleDays).Format(time.RFC3339)
+		t.PlayersRating2_3m = []float64{predictMinRating, 0.9, 1.0, 1.1, predictMaxRating}
+		return t
+	}
+
+	cases := []struct {
+		name string
+		edit func(a, b *simbo3TeamStats)
+		want string // "" for no warning
+	}{
+		{"thresholds themselves are fine", func(a, b *simbo3TeamStats) {}, ""},
+		{"same team", func(a, b *simbo3TeamStats) { b.Team = "a" }, `both exports are for "A"`},
+		{"windows differ", func(a, b *simbo3TeamStats) { b.WindowDays = 30 }, "look-back windows differ"},
+		{"pipelines differ", func(a, b *simbo3TeamStats) { b.PipelineVersion++ }, "pipeline versions differ"},
+		{"four players", func(a, b *simbo3TeamStats) { a.PlayersRating2_3m = a.PlayersRating2_3m[:4] }, "A: 4 player ratings, want 5"},
+		{"rating too low", func(a, b *simbo3TeamStats) { a.PlayersRating2_3m[0] = 0.49 }, "implausible player rating 0.49"},
+		{"rating too high", func(a, b *simbo3TeamStats) { b.PlayersRating2_3m[4] = 1.61 }, "implausible player rating 1.61"},
+		{"few demos", func(a, b *simbo3TeamStats) { a.DemoCount = predictMinDemos - 1 }, "A: only 4 demo(s)"},
+		{"stale export", func(a, b *simbo3TeamStats) {
+			b.GeneratedAt = now.AddDate(0, 0, -predictStaleDays-1).Format(time.RFC3339)
+		}, "B: export generated 31 days ago"},
+		{"map missing", func(a, b *simbo3TeamStats) { b.Maps = nil }, "B: no nuke matches"},
+		{"few map matches", func(a, b *simbo3TeamStats) {
+			a.Maps = map[string]simbo3MapStats{"nuke": {MapWinPct: 0.5, Matches3m: predictMinMapMatches - 1}}
+		}, "A: only 2 nuke match(es)"},
+		{"map win rate out of range", func(a, b *simbo3TeamStats) {
+			a.Maps = map[string]simbo3MapStats{"de_nuke": {MapWinPct: 55, Matches3m: 10}}
+		}, "map_win_pct 55.00 is outside 0–1"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			a, b := clean("A"), clean("B")
+			c.edit(&a, &b)
+			got := predictWarnings(a, b, predictMatch(a, b, "nuke"), now)
+			if c.want == "" {
+				if len(got) != 0 {
+					t.Errorf("warnings = %q, want none", got)
+				}
+				return
+			}
+			if len(got) != 1 || !strings.Contains(got[0], c.want) {
+				t.Errorf("warnings = %q, want exactly one containing %q", got, c.want)
+			}
+		})
+	}
+
+	t.Run("no map checks without --map", func(t *testing.T) {
+		a, b := clean("A"), clean("B")
+		b.Maps = nil
+		if got := predictWarnings(a, b, predictMatch(a, b, ""), now); len(got) != 0 {
+			t.Errorf("warnings = %q, want none", got)
+		}
+	})
+}
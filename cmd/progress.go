@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pable/go-cs-metrics/internal/aggregator"
+	"github.com/pable/go-cs-metrics/internal/report"
+	"github.com/pable/go-cs-metrics/internal/storage"
+)
+
+// progressWindow is the number of matches in each compared window.
+var progressWindow int
+
+var progressCmd = &cobra.Command{
+	Use:   "progress <steamid64>",
+	Short: "Report card diff: last N matches vs the N before them",
+	Long: `Compare a player's most recent --window matches with the --window matches
+before them across the major metrics (rating, K/D, ADR, KAST%, HS%, match,
+opening and duel win rates, trades, utility, crosshair placement, TTK,
+counter-strafing).
+
+Each value is the mean per match over the window. Δ is recent − previous,
+the arrow is colored green when the change is an improvement and red when
+it is not, and SIG marks changes where Welch's t on the per-match values
+reaches 2 (about p < 0.05) with at least 5 matches in each window — the
+ones that are more than match-to-match noise.
+
+Example:
+  csmetrics progress 76561198012345678 --window 20`,
+	Args: cobra.ExactArgs(1),
+	RunE: runProgress,
+}
+
+func init() {
+	progressCmd.Flags().IntVar(&progressWindow, "window", 20, "matches in each compared window")
+}
+
+func runProgress(cmd *cobra.Command, args []string) error {
+	steamID, err := resolveSteamID(args[0])
+	if err != nil {
+		return err
+	}
+	if progressWindow < 1 {
+		return withExitCode(ExitUsage, fmt.Errorf("--window must be at least 1, got %d", progressWindow))
+	}
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return fmt.Errorf("create db dir: %w", err)
+	}
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("open db: %w", err)
+	}
+	defer db.Close()
+
+	stats, err := db.GetAllPlayerMatchStats(steamID)
+	if err != nil {
+		return fmt.Errorf("query stats: %w", err)
+	}
+	p := aggregator.Progress(stats, progressWindow)
+	if p.Recent == 0 {
+		return noDataError("no matches found for player %d", steamID)
+	}
+	if p.Previous == 0 {
+		return noDataError("only %d match(es) stored for player %d; progress needs more than --window %d to compare",
+			p.Recent, steamID, progressWindow)
+	}
+
+	report.SetDataVersion(report.OldestPipelineVersion(stats))
+	report.PrintProgressTable(os.Stdout, p)
+	return nil
+}
@@ -5,61 +5,160 @@
 // FACEIT and Valve MM respectively. Both are currently non-functional due to
 // platform authentication changes (see docs/demo-download-automation.md) and
 // are NOT registered as CLI commands. The code is preserved for future work.
+// The FACEIT download pipeline itself lives in faceit.go and is shared with
+// baseline and track, which download through the Downloads API.
 package cmd
 
 import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 
+	"github.com/pable/go-cs-metrics/internal/config"
 	"github.com/pable/go-cs-metrics/internal/report"
 )
 
 // dbPath is the file path to the SQLite database, set via the --db flag.
 var dbPath string
 
+// configPath is the settings file (storage feature flags), set via the
+// --config flag.
+var configPath string
+
 // silent suppresses verbose metric explanations when true, set via the --silent flag.
 var silent bool
 
+// outputFormat selects the report renderer (table, csv, json, html), set via the --format flag.
+var outputFormat string
+
+// jsonErrors prints a failure as one JSON object on stderr, set via the
+// --json-errors flag.
+var jsonErrors bool
+
+// tableWidth and overflow control terminal table layout, set via the --width and --overflow flags.
+var (
+	tableWidth int
+	overflow   string
+)
+
+// kastDefinition selects the KAST% definition shown in reports, set via the --kast flag.
+var kastDefinition string
+
 // rootCmd is the top-level cobra command for the csmetrics CLI.
 var rootCmd = &cobra.Command{
 	Use:   "csmetrics",
 	Short: "CS2 demo metrics tool",
 	Long:  "Parse CS2 .dem files and compute player/team performance metrics.",
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+	// Execute prints errors itself, once, as text or JSON.
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		report.Verbose = !silent
+		if !cmd.Flag("db").Changed {
+			migrateLegacyDB()
+		}
+		report.SetWidth(tableWidth)
+		if err := report.SetOverflow(overflow); err != nil {
+			return err
+		}
+		if err := report.SetKAST(kastDefinition); err != nil {
+			return err
+		}
+		return report.SetFormat(outputFormat)
 	},
 }
 
-// Execute runs the root command.
+// Execute runs the root command and exits with the error's exit code (see
+// exitcode.go) on failure.
 func Execute() {
+	markUsageErrors(rootCmd)
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		writeError(os.Stderr, err, jsonErrors)
+		os.Exit(exitCode(err))
 	}
 }
 
 func init() {
-	defaultDB := filepath.Join(mustUserHome(), ".csmetrics", "metrics.db")
+	defaultDB, err := config.DefaultDBPath()
+	if err != nil {
+		defaultDB = filepath.Join(mustUserHome(), ".csmetrics", "metrics.db")
+	}
 	rootCmd.PersistentFlags().StringVar(&dbPath, "db", defaultDB, "path to SQLite database")
+	defaultConfig, err := config.DefaultConfigPath()
+	if err != nil {
+		defaultConfig = filepath.Join(filepath.Dir(defaultDB), "config.json")
+	}
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", defaultConfig, "path to the settings file (storage feature flags)")
 	rootCmd.PersistentFlags().BoolVarP(&silent, "silent", "s", false, "hide metric explanations before each table")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "format", "table",
+		"report table format: "+strings.Join(report.Formats, ", "))
+	rootCmd.PersistentFlags().BoolVar(&jsonErrors, "json-errors", false, "print a failure as one JSON object on stderr: error, exit code and kind")
+	rootCmd.PersistentFlags().IntVar(&tableWidth, "width", 0, "terminal width for table layout (0 = detect)")
+	rootCmd.PersistentFlags().StringVar(&overflow, "overflow", report.OverflowSplit,
+		"how tables wider than the terminal are shown: "+strings.Join(report.OverflowModes, ", "))
+	rootCmd.PersistentFlags().StringVar(&kastDefinition, "kast", report.KASTStandard,
+		"KAST% definition: standard, or strict to drop no-damage survivals of lost rounds")
 
 	rootCmd.AddCommand(parseCmd)
+	rootCmd.AddCommand(baselineCmd)
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(showCmd)
 	// fetchCmd and fetchMMCmd are intentionally not registered — both are
 	// non-functional due to platform auth changes. See docs/demo-download-automation.md.
 	rootCmd.AddCommand(playerCmd)
+	rootCmd.AddCommand(fingerprintCmd)
 	rootCmd.AddCommand(roundsCmd)
+	rootCmd.AddCommand(clutchesCmd)
+	rootCmd.AddCommand(timelineCmd)
+	rootCmd.AddCommand(sightsCmd)
+	rootCmd.AddCommand(practicePlanCmd)
+	rootCmd.AddCommand(digestCmd)
 	rootCmd.AddCommand(trendCmd)
+	rootCmd.AddCommand(progressCmd)
+	rootCmd.AddCommand(trackCmd)
+	rootCmd.AddCommand(dashboardCmd)
 	rootCmd.AddCommand(sqlCmd)
 	rootCmd.AddCommand(dropCmd)
+	rootCmd.AddCommand(archiveCmd)
 	rootCmd.AddCommand(analyzeCmd)
 	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(mapPoolCmd)
+	rootCmd.AddCommand(predictCmd)
 	rootCmd.AddCommand(backtestDatasetCmd)
 	rootCmd.AddCommand(summaryCmd)
+	rootCmd.AddCommand(metricsCmd)
+	rootCmd.AddCommand(dbCmd)
+}
+
+// migrateLegacyDB moves a database left at the pre-XDG default location
+// (~/.csmetrics/metrics.db) to the default dbPath on first run. If the move
+// fails the legacy database keeps being used, so no data is hidden.
+func migrateLegacyDB() {
+	legacy, err := config.LegacyDBPath()
+	if err != nil {
+		return
+	}
+	moved, err := config.MigrateLegacyDB(legacy, dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warn: could not move database to %s (%v); using %s\n", dbPath, err, legacy)
+		dbPath = legacy
+		return
+	}
+	if moved {
+		fmt.Fprintf(os.Stderr, "Moved database from %s to %s\n", legacy, dbPath)
+	}
+}
+
+// loadConfig reads the settings file at configPath.
+func loadConfig() (config.Config, error) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return cfg, withExitCode(ExitUsage, err)
+	}
+	return cfg, nil
 }
 
 // mustUserHome returns the current user's home directory, falling back to "."
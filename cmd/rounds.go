@@ -65,9 +65,9 @@ func filterRounds(stats []model.PlayerRoundStats, clutch, postPlant bool, side,
 // runRounds loads per-round stats for a player in a match and prints the drill-down table.
 func runRounds(cmd *cobra.Command, args []string) error {
 	prefix := args[0]
-	steamID, err := strconv.ParseUint(args[1], 10, 64)
+	steamID, err := resolveSteamID(args[1])
 	if err != nil {
-		return fmt.Errorf("invalid SteamID64 %q: %w", args[1], err)
+		return err
 	}
 
 	db, err := storage.Open(dbPath)
@@ -81,8 +81,7 @@ func runRounds(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("query demo: %w", err)
 	}
 	if demo == nil {
-		fmt.Fprintf(os.Stderr, "No demo found with hash prefix %q\n", prefix)
-		return nil
+		return noDataError("no demo found with hash prefix %q", prefix)
 	}
 
 	roundStats, err := db.GetPlayerRoundStats(demo.DemoHash, steamID)
@@ -90,8 +89,7 @@ func runRounds(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("get round stats: %w", err)
 	}
 	if len(roundStats) == 0 {
-		fmt.Fprintf(os.Stderr, "No round data found for player %d in demo %s\n", steamID, prefix)
-		return nil
+		return noDataError("no round data found for player %d in demo %s", steamID, prefix)
 	}
 
 	// Get player name from match stats.
@@ -109,8 +107,7 @@ func runRounds(cmd *cobra.Command, args []string) error {
 
 	roundStats = filterRounds(roundStats, roundsClutch, roundsPostPlant, roundsSide, roundsBuy)
 	if len(roundStats) == 0 {
-		fmt.Fprintln(os.Stderr, "No rounds match the given filters.")
-		return nil
+		return noDataError("no rounds match the given filters")
 	}
 
 	report.PrintRoundDetailTable(os.Stdout, roundStats, playerName, demo.MapName)
@@ -6,12 +6,17 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/pable/go-cs-metrics/internal/aggregator"
+	"github.com/pable/go-cs-metrics/internal/model"
 	"github.com/pable/go-cs-metrics/internal/report"
 	"github.com/pable/go-cs-metrics/internal/storage"
 )
 
-// showPlayerID is the optional SteamID64 used to highlight a player in the show output.
-var showPlayerID uint64
+var (
+	showPlayer  string   // optional focus player: SteamID64 or name:<nickname>
+	showColumns []string // --columns: table columns to keep
+	showSortBy  string   // --sort-by: column to order per-player tables by
+)
 
 // showCmd is the cobra command that re-displays stored match stats by hash prefix.
 var showCmd = &cobra.Command{
@@ -22,12 +27,19 @@ var showCmd = &cobra.Command{
 }
 
 func init() {
-	showCmd.Flags().Uint64Var(&showPlayerID, "player", 0, "highlight player SteamID64")
+	showCmd.Flags().StringVar(&showPlayer, "player", "", "highlight player: SteamID64 or name:<nickname> (fuzzy match on the demo's roster)")
+	showCmd.Flags().StringSliceVar(&showColumns, "columns", nil, "only show these table columns, in order (e.g. K,D,ADR,RATING); player columns are always kept")
+	showCmd.Flags().StringVar(&showSortBy, "sort-by", "", "sort per-player tables by this column, highest first (append :asc for lowest first)")
 }
 
 // runShow looks up a demo by hash prefix and prints all its report tables.
 func runShow(cmd *cobra.Command, args []string) error {
 	prefix := args[0]
+	if err := validateFocusPlayer(showPlayer); err != nil {
+		return err
+	}
+	report.SetColumns(showColumns)
+	report.SetSortBy(showSortBy)
 
 	db, err := storage.Open(dbPath)
 	if err != nil {
@@ -40,8 +52,7 @@ func runShow(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("query demo: %w", err)
 	}
 	if demo == nil {
-		fmt.Fprintf(os.Stderr, "No demo found with hash prefix %q\n", prefix)
-		return nil
+		return noDataError("no demo found with hash prefix %q", prefix)
 	}
 
 	stats, err := db.GetPlayerMatchStats(demo.DemoHash)
@@ -60,14 +71,56 @@ func runShow(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("get clutch stats: %w", err)
 	}
+	outcomes, err := db.GetRoundOutcomes(demo.DemoHash)
+	if err != nil {
+		return fmt.Errorf("get round outcomes: %w", err)
+	}
+	economy, err := db.GetTeamRoundEconomy(demo.DemoHash)
+	if err != nil {
+		return fmt.Errorf("get team economy: %w", err)
+	}
+	chains, err := db.GetTradeChains(demo.DemoHash)
+	if err != nil {
+		return fmt.Errorf("get trade chains: %w", err)
+	}
+	showPlayerID, err := resolveFocusPlayer(showPlayer, stats)
+	if err != nil {
+		return err
+	}
+	report.SetDataVersion(demo.PipelineVersion)
 	report.PrintMatchSummary(os.Stdout, *demo)
+	report.PrintRoundProgression(os.Stdout, outcomes)
 	report.PrintPlayerRosterTable(os.Stdout, stats)
 	report.PrintPlayerTable(stats, showPlayerID)
+	printLobbyComparison(stats, showPlayerID)
 	report.PrintPlayerSideTable(os.Stdout, sideStats, showPlayerID)
 	report.PrintDuelTable(os.Stdout, stats, showPlayerID)
 	report.PrintAWPTable(os.Stdout, stats, showPlayerID)
+	report.PrintAWPShotsTable(os.Stdout, stats, showPlayerID)
 	report.PrintWeaponTable(os.Stdout, weaponStats, stats, showPlayerID)
 	report.PrintAimTimingTable(os.Stdout, stats, showPlayerID)
+	report.PrintDefensiveUtilityTable(os.Stdout, stats, showPlayerID)
+	report.PrintUtilitySynergyTable(os.Stdout, stats, showPlayerID)
+	report.PrintUtilityTimingTable(os.Stdout, stats, showPlayerID)
+	report.PrintLateRoundTable(os.Stdout, stats, showPlayerID)
+	report.PrintWeaponDisciplineTable(os.Stdout, stats, showPlayerID)
+	report.PrintDuelLossReasonTable(os.Stdout, stats, showPlayerID)
+	report.PrintMomentumTable(os.Stdout, stats, showPlayerID)
+	report.PrintScorelineTable(os.Stdout, stats, showPlayerID)
+	report.PrintLoadoutEfficiencyTable(os.Stdout, stats, showPlayerID)
 	report.PrintMatchClutchTable(os.Stdout, stats, clutch)
+	report.PrintObjectiveTable(os.Stdout, stats, showPlayerID)
+	report.PrintRoundEndReasonTable(os.Stdout, outcomes)
+	report.PrintTeamEconomyTable(os.Stdout, economy, outcomes)
+	report.PrintTradeChainTable(os.Stdout, chains, outcomes)
+	report.PrintTeamConcentrationTable(os.Stdout, aggregator.TeamConcentration(stats))
 	return nil
 }
+
+// printLobbyComparison prints the Focus vs Lobby table for the focus player,
+// if one was given and played the match.
+func printLobbyComparison(stats []model.PlayerMatchStats, steamID uint64) {
+	if c, ok := aggregator.LobbyComparison(stats, steamID); ok {
+		report.PrintLobbyComparisonTable(os.Stdout, c)
+	}
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pable/go-cs-metrics/internal/report"
+	"github.com/pable/go-cs-metrics/internal/storage"
+)
+
+var (
+	sightsBins string
+	sightsRows bool
+)
+
+// sightsCmd is the cobra command for auditing stored first-sight events.
+var sightsCmd = &cobra.Command{
+	Use:   "sights <hash-prefix> <steamid64>",
+	Short: "Stored first-sight crosshair angles for one player in one match",
+	Long: `Bucket the raw first-sight events stored for a player in one match by
+crosshair angle, so the crosshair placement metric can be audited and re-binned
+without re-parsing the demo.
+
+First sights are stored for every player when the first_sights storage feature
+is on ("csmetrics db storage first_sights on"); otherwise only for players
+listed (comma-separated SteamID64s) in the ` + sightPlayersEnv + ` environment
+variable when the demo was parsed. Re-parse a demo with either set to
+backfill it.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runSights,
+}
+
+func init() {
+	sightsCmd.Flags().StringVar(&sightsBins, "bins", "2,5,10,20,45", "comma-separated angle bucket edges in degrees")
+	sightsCmd.Flags().BoolVar(&sightsRows, "rows", false, "also list every stored first sight")
+}
+
+// parseBinEdges parses a comma-separated list of positive degree edges and
+// returns them sorted ascending with duplicates removed.
+func parseBinEdges(s string) ([]float64, error) {
+	seen := make(map[float64]bool)
+	var edges []float64
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("invalid bin edge %q: must be a positive number of degrees", f)
+		}
+		if !seen[v] {
+			seen[v] = true
+			edges = append(edges, v)
+		}
+	}
+	if len(edges) == 0 {
+		return nil, fmt.Errorf("--bins needs at least one edge")
+	}
+	sort.Float64s(edges)
+	return edges, nil
+}
+
+// runSights loads stored first sights for a player in a match and prints the angle histogram.
+func runSights(cmd *cobra.Command, args []string) error {
+	prefix := args[0]
+	steamID, err := resolveSteamID(args[1])
+	if err != nil {
+		return err
+	}
+	edges, err := parseBinEdges(sightsBins)
+	if err != nil {
+		return err
+	}
+
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
+	}
+	defer db.Close()
+
+	demo, err := db.GetDemoByPrefix(prefix)
+	if err != nil {
+		return fmt.Errorf("query demo: %w", err)
+	}
+	if demo == nil {
+		return noDataError("no demo found with hash prefix %q", prefix)
+	}
+
+	sights, err := db.GetFirstSights(demo.DemoHash, steamID)
+	if err != nil {
+		return fmt.Errorf("get first sights: %w", err)
+	}
+	if len(sights) == 0 {
+		return noDataError("no first sights stored for player %d in demo %s (was first_sights storage on or %s set when it was parsed?)",
+			steamID, prefix, sightPlayersEnv)
+	}
+
+	matchStats, err := db.GetPlayerMatchStats(demo.DemoHash)
+	if err != nil {
+		return fmt.Errorf("get match stats: %w", err)
+	}
+	names := make(map[uint64]string, len(matchStats))
+	for _, ms := range matchStats {
+		names[ms.SteamID] = ms.Name
+	}
+	playerName, ok := names[steamID]
+	if !ok {
+		playerName = strconv.FormatUint(steamID, 10)
+	}
+
+	report.PrintFirstSightBinsTable(os.Stdout, sights, edges, playerName, demo.MapName)
+	if sightsRows {
+		report.PrintFirstSightRowsTable(os.Stdout, sights, names)
+	}
+	return nil
+}
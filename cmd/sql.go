@@ -18,15 +18,53 @@ var sqlCmd = &cobra.Command{
 	Long: `Run an arbitrary SQL query against the metrics database and print results as a table.
 
 Schema overview:
-  demos(hash, map_name, match_date, match_type, tickrate, ct_score, t_score, tier, is_baseline)
+  demos(hash, map_name, match_date, match_type, tickrate, ct_score, t_score, tier, is_baseline,
+    source_origin, source_path, source_url, share_code, external_match_id)
   player_match_stats(demo_hash, steam_id TEXT, name, team, kills, assists, deaths,
     headshot_kills, total_damage, rounds_played, kast_rounds, role, median_ttk_ms,
-    median_ttd_ms, one_tap_kills, ...)
+    median_ttd_ms, one_tap_kills, eco_rounds, eco_damage, buy_rounds, buy_damage,
+    unspotted_deaths, spotted_deaths, ping_samples, avg_ping_ms, max_ping_ms,
+    kast_strict_rounds, ...)
   player_round_stats(demo_hash, steam_id TEXT, round_number, team, kills, assists,
-    damage, buy_type, is_post_plant, is_in_clutch, clutch_enemy_count, ...)
-  player_weapon_stats(demo_hash, steam_id TEXT, weapon, kills, headshot_kills, damage, hits)
-  player_duel_segments(demo_hash, steam_id TEXT, weapon_bucket, distance_bin,
-    duel_count, first_hit_count, first_hit_hs_count, median_corr_deg, median_expo_win_ms)
+    damage, buy_type, is_post_plant, is_in_clutch, clutch_enemy_count,
+    clutch_start_tick, clutch_start_sec, clutch_enemies, deaths, death_tick,
+    death_sec, ...)
+  player_weapon_stats(demo_hash, steam_id TEXT, weapon, kills, headshot_kills, damage, hits,
+    head_hits, ...)
+  player_duel_segments(demo_hash, steam_id TEXT, round_context, weapon_bucket, distance_bin,
+    duel_count, first_hit_count, first_hit_hs_count, median_corr_deg, median_expo_win_ms,
+    still_first_hits, still_first_hit_hs, moving_first_hits, moving_first_hit_hs)
+  player_duel_distances(demo_hash, steam_id TEXT, weapon_bucket, meters, duel_count,
+    first_hit_count, first_hit_hs_count)   -- won duels per whole meter
+  player_death_segments(demo_hash, steam_id TEXT, weapon_bucket, distance_bin,
+    deaths, headshot_deaths)   -- steam_id = victim, weapon_bucket = killer's
+  player_death_contexts(demo_hash, steam_id TEXT, movement, flashed, isolated,
+    after_kill, deaths)   -- deaths per context in the 3s before dying
+  player_time_to_damage(demo_hash, steam_id TEXT, weapon_bucket, samples, median_ms)
+  player_burst_stats(demo_hash, steam_id TEXT, weapon_bucket, taps, short_bursts,
+    sprays, panic_sprays)
+  player_positions(demo_hash, steam_id TEXT, side, place, rounds, avg_x, avg_y)
+  player_first_sights(demo_hash, steam_id TEXT, enemy_id TEXT, round_number, tick,
+    angle_deg, pitch_deg, yaw_deg)   -- only players in CSMETRICS_SIGHT_PLAYERS
+  round_kill_states(demo_hash, round_number, tick, killer_id TEXT, victim_id TEXT,
+    killer_team, victim_team, ct_alive, t_alive, bomb_planted, winner_team, weapon,
+    clock_remaining_sec)
+  team_round_economy(demo_hash, round_number, team, players, equip_value,
+    round_type, won)   -- round_type: pistol, full-eco, semi-eco, force, full-buy
+  demo_unmapped_weapons(demo_hash, weapon, events)   -- weapons with no bucket
+  demo_diagnostics(demo_hash, rounds, kills, damages, flashes, weapon_fires,
+    first_sights, position_samples, pre_live_rounds,
+    server_frame_drop_pct)   -- raw event counts per parse
+  demo_pass_timings(demo_hash, seq, pass, ms)   -- parse + aggregation pass wall times
+  baseline_quotas(tier, target, updated_at)   -- baseline build targets
+  baseline_sources(source_id, tier, anchor, status, demo_hash, detail, updated_at)
+    -- status: stored, duplicate, skipped, failed
+  demo_trash(hash, map_name, match_date, pipeline_version, row_count, deleted_at,
+    deleted_by, snapshot)   -- soft-deleted demos (snapshot: JSON rows per table)
+  demo_audit(id, at, action, demo_hash, pipeline_version, actor, detail)
+    -- action: insert, replace, retag, merge, delete, restore, purge
+  player_aggregate_cache(steam_id, filters_hash, pipeline_version, payload,
+    created_at)   -- cached player / analyze player aggregates (JSON)
 
 Note: steam_id is stored as TEXT. Use quotes: WHERE steam_id = '76561198031906602'`,
 	Args: cobra.MinimumNArgs(1),
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/pable/go-cs-metrics/internal/steam"
+)
+
+// steamIDFormats lists the player ID formats accepted wherever a SteamID64 is
+// expected, for error messages.
+const steamIDFormats = "SteamID64, steamID3 ([U:1:...]), steamID2 (STEAM_0:...), profile URL or custom URL name"
+
+// vanityCache holds the custom URL names resolved during this run.
+var vanityCache = make(map[string]uint64)
+
+// resolveSteamID returns the SteamID64 of a player ID given in any of
+// steamIDFormats. SteamIDs and /profiles/ URLs are converted offline; custom
+// URL names (and /id/ URLs) are looked up through the Steam Web API, which
+// needs STEAM_API_KEY or ~/.csmetrics/steam_api_key.
+func resolveSteamID(s string) (uint64, error) {
+	if id, err := steam.ParseSteamID(s); err == nil {
+		return id, nil
+	}
+	name, ok := steam.VanityName(s)
+	if !ok {
+		return 0, withExitCode(ExitUsage, fmt.Errorf("invalid player ID %q: want a %s", s, steamIDFormats))
+	}
+	if id, ok := vanityCache[name]; ok {
+		return id, nil
+	}
+	key, err := loadSteamAPIKey()
+	if err != nil {
+		return 0, fmt.Errorf("resolve custom URL %q: %w", name, err)
+	}
+	id, err := steam.NewClient(key).ResolveVanityURL(name)
+	if err != nil {
+		return 0, fmt.Errorf("resolve custom URL %q: %w", name, err)
+	}
+	vanityCache[name] = id
+	fmt.Fprintf(os.Stderr, "Resolved %q to SteamID64 %d\n", name, id)
+	return id, nil
+}
+
+// resolveSteamIDs resolves each player ID like resolveSteamID and returns
+// them as decimal SteamID64 strings, the form the roster queries take.
+func resolveSteamIDs(ids []string) ([]string, error) {
+	out := make([]string, 0, len(ids))
+	for _, s := range ids {
+		id, err := resolveSteamID(s)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, strconv.FormatUint(id, 10))
+	}
+	return out, nil
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/olekukonko/tablewriter/tw"
 	"github.com/spf13/cobra"
 
+	"github.com/pable/go-cs-metrics/internal/report"
 	"github.com/pable/go-cs-metrics/internal/storage"
 )
 
@@ -113,5 +114,11 @@ func runSummary(cmd *cobra.Command, args []string) error {
 		tt.Render()
 	}
 
+	peek, err := db.GetPeekerBaselines()
+	if err != nil {
+		return fmt.Errorf("get peeker baselines: %w", err)
+	}
+	report.PrintPeekerBaselineTable(os.Stdout, peek)
+
 	return nil
 }
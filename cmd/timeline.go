@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+	"github.com/pable/go-cs-metrics/internal/report"
+	"github.com/pable/go-cs-metrics/internal/storage"
+)
+
+// timelineSchemaVersion is the schema_version of `timeline --json` output.
+// Bump it when a field is renamed or removed; adding fields keeps it.
+const timelineSchemaVersion = 1
+
+var (
+	timelineJSON  bool
+	timelineRound int
+)
+
+// timelineCmd is the cobra command printing a match's round-by-round events.
+var timelineCmd = &cobra.Command{
+	Use:   "timeline <hash-prefix>",
+	Short: "Round-by-round event timeline of a match (kills with positions, flashes, plant/defuse), as text or JSON",
+	Long: `Print every event of a stored match in order, round by round: round start,
+freeze end, kills (with killer and victim world positions), flashes, bomb
+plants and defuses, and the round end with its winner and reason.
+
+--json writes one document in a versioned schema (see README, "timeline")
+for external tools such as web round viewers: match metadata, the player
+list, and an events array. Times are seconds from the round's freeze end.
+
+Demos stored before pipeline version 32 have no timeline; re-parse them with
+parse --force.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTimeline,
+}
+
+func init() {
+	timelineCmd.Flags().BoolVar(&timelineJSON, "json", false, "write the timeline as one JSON document")
+	timelineCmd.Flags().IntVar(&timelineRound, "round", 0, "only this round (0 = all)")
+}
+
+// timelineDoc is the top-level `timeline --json` document.
+type timelineDoc struct {
+	SchemaVersion   int              `json:"schema_version"`
+	DemoHash        string           `json:"demo_hash"`
+	Map             string           `json:"map"`
+	MatchDate       string           `json:"match_date"`
+	Tickrate        float64          `json:"tickrate"`
+	PipelineVersion int              `json:"pipeline_version"`
+	Players         []timelinePlayer `json:"players"`
+	Events          []timelineEvent  `json:"events"`
+}
+
+// timelinePlayer is one entry of the document's player list.
+type timelinePlayer struct {
+	SteamID string `json:"steam_id"`
+	Name    string `json:"name"`
+	Team    string `json:"team"` // side at the end of the match
+}
+
+// timelineRef is a player taking part in an event, with their side and, for
+// kills, plants and defuses, their world position in Hammer units.
+type timelineRef struct {
+	SteamID string       `json:"steam_id"`
+	Name    string       `json:"name"`
+	Team    string       `json:"team"`
+	Pos     *timelinePos `json:"pos,omitempty"`
+}
+
+// timelinePos is a world position in Hammer units.
+type timelinePos struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+}
+
+// timelineEvent is one element of the events array. Fields not used by an
+// event type are omitted.
+type timelineEvent struct {
+	Type        string       `json:"type"`
+	Round       int          `json:"round"`
+	Tick        int          `json:"tick"`
+	TimeSec     float64      `json:"time_sec"`
+	Actor       *timelineRef `json:"actor,omitempty"`
+	Target      *timelineRef `json:"target,omitempty"`
+	Weapon      string       `json:"weapon,omitempty"`
+	Headshot    bool         `json:"headshot,omitempty"`
+	DurationSec float64      `json:"duration_sec,omitempty"`
+	Site        string       `json:"site,omitempty"`
+	Winner      string       `json:"winner,omitempty"`
+	Reason      string       `json:"reason,omitempty"`
+}
+
+// runTimeline loads a demo's stored timeline and prints it.
+func runTimeline(cmd *cobra.Command, args []string) error {
+	if timelineRound < 0 {
+		return withExitCode(ExitUsage, fmt.Errorf("--round must be ≥ 0, got %d", timelineRound))
+	}
+
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
+	}
+	defer db.Close()
+
+	demo, err := db.GetDemoByPrefix(args[0])
+	if err != nil {
+		return fmt.Errorf("query demo: %w", err)
+	}
+	if demo == nil {
+		return noDataError("no demo found with hash prefix %q", args[0])
+	}
+	events, err := db.GetRoundEvents(demo.DemoHash)
+	if err != nil {
+		return fmt.Errorf("get round events: %w", err)
+	}
+	if len(events) == 0 {
+		if cfg, err := loadConfig(); err == nil && !cfg.Stores("timeline") {
+			return noDataError("demo %s has no stored timeline: timeline storage is off (csmetrics db storage timeline on, then re-parse with parse --force)",
+				demo.DemoHash[:12])
+		}
+		return noDataError("demo %s has no stored timeline (stored by pipeline v%d; timeline needs v32+): re-parse it with parse --force",
+			demo.DemoHash[:12], demo.PipelineVersion)
+	}
+	if timelineRound > 0 {
+		var kept []model.TimelineEvent
+		for _, e := range events {
+			if e.RoundNumber == timelineRound {
+				kept = append(kept, e)
+			}
+		}
+		if len(kept) == 0 {
+			return noDataError("demo %s has no round %d", demo.DemoHash[:12], timelineRound)
+		}
+		events = kept
+	}
+
+	matchStats, err := db.GetPlayerMatchStats(demo.DemoHash)
+	if err != nil {
+		return fmt.Errorf("get match stats: %w", err)
+	}
+	names := make(map[uint64]string, len(matchStats))
+	for _, ms := range matchStats {
+		names[ms.SteamID] = ms.Name
+	}
+
+	if !timelineJSON {
+		report.SetDataVersion(demo.PipelineVersion)
+		report.PrintTimelineTable(os.Stdout, events, names, demo.MapName, demo.Tickrate)
+		return nil
+	}
+	doc := buildTimelineDoc(demo, matchStats, events)
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode JSON: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// buildTimelineDoc converts stored events to the `timeline --json` schema.
+func buildTimelineDoc(demo *model.MatchSummary, players []model.PlayerMatchStats, events []model.TimelineEvent) timelineDoc {
+	doc := timelineDoc{
+		SchemaVersion:   timelineSchemaVersion,
+		DemoHash:        demo.DemoHash,
+		Map:             demo.MapName,
+		MatchDate:       demo.MatchDate,
+		Tickrate:        demo.Tickrate,
+		PipelineVersion: demo.PipelineVersion,
+		Players:         make([]timelinePlayer, 0, len(players)),
+		Events:          make([]timelineEvent, 0, len(events)),
+	}
+	names := make(map[uint64]string, len(players))
+	for _, p := range players {
+		names[p.SteamID] = p.Name
+		doc.Players = append(doc.Players, timelinePlayer{
+			SteamID: strconv.FormatUint(p.SteamID, 10), Name: p.Name, Team: p.Team.String(),
+		})
+	}
+	tps := demo.Tickrate
+	if tps <= 0 {
+		tps = 64
+	}
+	ref := func(id uint64, team model.Team, pos *model.Vec3) *timelineRef {
+		if id == 0 {
+			return nil
+		}
+		r := &timelineRef{SteamID: strconv.FormatUint(id, 10), Name: names[id], Team: team.String()}
+		if pos != nil {
+			r.Pos = &timelinePos{X: round2(pos.X), Y: round2(pos.Y), Z: round2(pos.Z)}
+		}
+		return r
+	}
+
+	freezeEnd := make(map[int]int)
+	for _, e := range events {
+		if e.Type == model.TimelineRoundStart {
+			if _, ok := freezeEnd[e.RoundNumber]; !ok {
+				freezeEnd[e.RoundNumber] = e.Tick
+			}
+		}
+		if e.Type == model.TimelineFreezeEnd {
+			freezeEnd[e.RoundNumber] = e.Tick
+		}
+	}
+	for _, e := range events {
+		out := timelineEvent{
+			Type:    e.Type,
+			Round:   e.RoundNumber,
+			Tick:    e.Tick,
+			TimeSec: math.Round(float64(e.Tick-freezeEnd[e.RoundNumber])/tps*100) / 100,
+		}
+		switch e.Type {
+		case model.TimelineKill:
+			out.Actor = ref(e.ActorID, e.ActorTeam, &e.ActorPos)
+			out.Target = ref(e.TargetID, e.TargetTeam, &e.TargetPos)
+			out.Weapon, out.Headshot = e.Weapon, e.Headshot
+		case model.TimelineFlash:
+			out.Actor = ref(e.ActorID, e.ActorTeam, nil)
+			out.Target = ref(e.TargetID, e.TargetTeam, nil)
+			out.DurationSec = round2(e.DurationSec)
+		case model.TimelinePlant, model.TimelineDefuse:
+			out.Actor = ref(e.ActorID, e.ActorTeam, &e.ActorPos)
+			out.Site = e.Detail
+		case model.TimelineRoundEnd:
+			if e.ActorTeam == model.TeamCT || e.ActorTeam == model.TeamT {
+				out.Winner = e.ActorTeam.String()
+			}
+			out.Reason = e.Detail
+		}
+		doc.Events = append(doc.Events, out)
+	}
+	return doc
+}
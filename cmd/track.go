@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pable/go-cs-metrics/internal/aggregator"
+	"github.com/pable/go-cs-metrics/internal/faceit"
+	"github.com/pable/go-cs-metrics/internal/report"
+	"github.com/pable/go-cs-metrics/internal/storage"
+)
+
+// track command flags.
+var (
+	// trackHistory is how many recent FACEIT matches are checked for new demos.
+	trackHistory int
+	// trackWindow is the number of matches in each progress window.
+	trackWindow int
+	// trackDownloaders is the number of concurrent demo downloads.
+	trackDownloaders int
+	// trackWorkers is the number of parallel parse workers (0 = NumCPU).
+	trackWorkers int
+)
+
+// trackCmd is the cobra command that fetches, parses and reports a FACEIT
+// player's new matches. Unlike the disabled fetch, it downloads every demo
+// through a signed URL from the FACEIT Downloads API.
+var trackCmd = &cobra.Command{
+	Use:   "track <faceit-nickname>",
+	Short: "Fetch and parse a FACEIT player's new matches, then show their trend and progress",
+	Long: `One command for keeping up with a FACEIT player: resolves the nickname (or
+Steam ID64) to the player's CS2 Steam ID, checks their last --history FACEIT
+matches, downloads and parses the ones not stored yet (new since the last
+run), rebuilds the player's cached aggregate and prints the Performance Trend
+of the last 2×--window matches and the Progress report card (last --window
+matches vs the --window before them).
+
+Demos are stored as FACEIT matches of the player's own history, not as
+baseline demos. Needs a FACEIT Data API key (FACEIT_API_KEY or
+~/.csmetrics/faceit_api_key) and a Downloads API key (FACEIT_DOWNLOADS_KEY or
+~/.csmetrics/faceit_downloads_key): each demo URL is exchanged for a signed
+download URL, since the CDN links in the match data no longer resolve.
+Matches that fail to download are retried on the next run.
+
+Example:
+  csmetrics track s1mple
+  csmetrics track s1mple --history 50 --window 20`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTrack,
+}
+
+func init() {
+	trackCmd.Flags().IntVar(&trackHistory, "history", 20, "recent FACEIT matches to check for new demos")
+	trackCmd.Flags().IntVar(&trackWindow, "window", 10, "matches in each compared progress window")
+	trackCmd.Flags().IntVar(&trackDownloaders, "download-workers", 2, "concurrent demo downloads")
+	trackCmd.Flags().IntVar(&trackWorkers, "workers", 0, "parallel parse+aggregate workers (0 = NumCPU)")
+}
+
+func runTrack(cmd *cobra.Command, args []string) error {
+	if trackHistory < 1 {
+		return withExitCode(ExitUsage, fmt.Errorf("--history must be at least 1, got %d", trackHistory))
+	}
+	if trackWindow < 1 {
+		return withExitCode(ExitUsage, fmt.Errorf("--window must be at least 1, got %d", trackWindow))
+	}
+	apiKey, err := loadFaceitAPIKey()
+	if err != nil {
+		return withExitCode(ExitAPIKey, err)
+	}
+	downloadsKey := loadFaceitDownloadsKey()
+	if downloadsKey == "" {
+		return withExitCode(ExitAPIKey, fmt.Errorf("FACEIT Downloads API key not found: set FACEIT_DOWNLOADS_KEY or create ~/.csmetrics/faceit_downloads_key"))
+	}
+	client := faceit.NewClient(apiKey)
+
+	fp, err := lookupFaceitPlayer(client, args[0])
+	if err != nil {
+		return err
+	}
+	steamID, err := strconv.ParseUint(fp.CS2SteamID(), 10, 64)
+	if err != nil {
+		return fmt.Errorf("FACEIT player %s has no linked CS2 Steam ID", fp.Nickname)
+	}
+	fmt.Printf("Player: %s  steam=%d  level=%d  ELO=%d\n",
+		fp.Nickname, steamID, fp.Games.CS2.SkillLevel, fp.Games.CS2.FaceitELO)
+
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return fmt.Errorf("create db dir: %w", err)
+	}
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
+	}
+	defer db.Close()
+
+	fresh, err := newFaceitMatches(db, client, fp.PlayerID, trackHistory)
+	if err != nil {
+		return err
+	}
+	if len(fresh) == 0 {
+		fmt.Printf("No new matches in the last %d FACEIT matches.\n", trackHistory)
+	} else {
+		fmt.Printf("%d new match(es) in the last %d FACEIT matches.\n", len(fresh), trackHistory)
+		run := fetchRun{
+			count:        len(fresh),
+			downloaders:  trackDownloaders,
+			workers:      trackWorkers,
+			downloadsKey: downloadsKey,
+		}
+		ingested, err := fetchMatches(db, client, fresh, run)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Done: %d/%d new matches ingested\n", ingested, len(fresh))
+		if ingested > 0 {
+			if _, err := loadPlayerReport(db, steamID, nil, false); err != nil {
+				return fmt.Errorf("update aggregates: %w", err)
+			}
+		}
+	}
+
+	stats, err := db.GetAllPlayerMatchStats(steamID)
+	if err != nil {
+		return fmt.Errorf("query stats: %w", err)
+	}
+	if len(stats) == 0 {
+		return noDataError("no matches stored for %s (%d)", fp.Nickname, steamID)
+	}
+	report.SetDataVersion(report.OldestPipelineVersion(stats))
+	report.PrintTrendTable(os.Stdout, stats[max(0, len(stats)-2*trackWindow):])
+	p := aggregator.Progress(stats, trackWindow)
+	if p.Previous == 0 {
+		fmt.Printf("Progress: only %d match(es) stored; the report card needs more than --window %d.\n", p.Recent, trackWindow)
+		return nil
+	}
+	report.PrintProgressTable(os.Stdout, p)
+	return nil
+}
+
+// newFaceitMatches returns the finished matches among the player's last limit
+// FACEIT matches whose demo is not stored yet, newest first.
+func newFaceitMatches(db *storage.DB, client *faceit.Client, playerID string, limit int) ([]faceit.MatchHistoryItem, error) {
+	history, err := client.GetMatchHistory(playerID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("match history: %w", err)
+	}
+	var finished []faceit.MatchHistoryItem
+	var ids []string
+	for _, item := range history {
+		if strings.EqualFold(item.Status, "FINISHED") {
+			finished = append(finished, item)
+			ids = append(ids, item.MatchID)
+		}
+	}
+	stored, err := db.StoredMatchIDs(ids)
+	if err != nil {
+		return nil, fmt.Errorf("stored matches: %w", err)
+	}
+	var fresh []faceit.MatchHistoryItem
+	for _, item := range finished {
+		if !stored[item.MatchID] {
+			fresh = append(fresh, item)
+		}
+	}
+	return fresh, nil
+}
@@ -4,10 +4,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strconv"
 
 	"github.com/spf13/cobra"
 
+	"github.com/pable/go-cs-metrics/internal/aggregator"
 	"github.com/pable/go-cs-metrics/internal/report"
 	"github.com/pable/go-cs-metrics/internal/storage"
 )
@@ -20,9 +20,9 @@ var trendCmd = &cobra.Command{
 }
 
 func runTrend(cmd *cobra.Command, args []string) error {
-	steamID, err := strconv.ParseUint(args[0], 10, 64)
+	steamID, err := resolveSteamID(args[0])
 	if err != nil {
-		return fmt.Errorf("invalid steamid64: %w", err)
+		return err
 	}
 	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
 		return fmt.Errorf("create db dir: %w", err)
@@ -38,8 +38,7 @@ func runTrend(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("query stats: %w", err)
 	}
 	if len(stats) == 0 {
-		fmt.Println("no matches found")
-		return nil
+		return noDataError("no matches found for player %d", steamID)
 	}
 
 	clutchMap, err := db.GetPlayerClutchStatsByMatch(steamID)
@@ -47,9 +46,12 @@ func runTrend(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("query clutch stats: %w", err)
 	}
 
+	report.SetDataVersion(report.OldestPipelineVersion(stats))
 	report.PrintTrendTable(os.Stdout, stats)
 	report.PrintAimTrendTable(os.Stdout, stats)
 	report.PrintClutchTrendTable(os.Stdout, stats, clutchMap)
+	report.PrintMomentumTrendTable(os.Stdout, stats)
+	report.PrintTiltTable(os.Stdout, aggregator.Tilt(stats))
 	return nil
 }
 
@@ -14,38 +14,27 @@ import (
 	"github.com/pable/go-cs-metrics/internal/model"
 )
 
+// PipelineVersion identifies the metric logic that produced a set of stats.
+// Bump it whenever a change to the parser or aggregator alters stored values,
+// so rows written by older builds can be told apart from current ones.
+const PipelineVersion = 46
+
+// assistedDuelMinDamage and assistedDuelWindowSec define an assisted duel: a
+// teammate of the killer dealt at least this much damage to the victim (the
+// game's damage-assist threshold) within this many seconds before the kill.
+const (
+	assistedDuelMinDamage = 41
+	assistedDuelWindowSec = 5.0
+)
+
+// csThreshold is the horizontal speed (Hammer units/s) at or below which a
+// shot counts as counter-strafed (≈14% of base walk speed). It drives both
+// counter-strafe % and the stationary/moving split of first-hit duels.
+const csThreshold = 34.0
+
 // unitsToMeters is the conversion factor from Source 2 Hammer units to meters.
 const unitsToMeters = 0.01905
 
-// weaponBucket maps a weapon name (as returned by demoinfocs .String()) to a
-// broad category bucket used for FHHS segment grouping. For example, "M4A1-S"
-// and "M4A4" both map to "M4". Weapons that do not match any known category
-// are placed in the "Other" bucket.
-func weaponBucket(weapon string) string {
-	switch weapon {
-	case "AK-47":
-		return "AK"
-	case "M4A1-S", "M4A4":
-		return "M4"
-	case "Galil AR":
-		return "Galil"
-	case "FAMAS":
-		return "FAMAS"
-	case "AUG", "SG 553":
-		return "ScopedRifle"
-	case "AWP":
-		return "AWP"
-	case "SSG 08":
-		return "Scout"
-	case "Desert Eagle":
-		return "Deagle"
-	case "USP-S", "Glock-18", "P250", "Five-SeveN", "Tec-9", "CZ75 Auto", "P2000", "Dual Berettas", "R8 Revolver":
-		return "Pistol"
-	default:
-		return "Other"
-	}
-}
-
 // distanceBin converts a distance in meters to a named bin string used for
 // FHHS segment grouping. Bins are: "0-5m", "5-10m", "10-15m", "15-20m",
 // "20-30m", "30m+". A negative value (unknown distance) returns "unknown".
@@ -69,6 +58,19 @@ func distanceBin(meters float64) string {
 	}
 }
 
+// killDistanceM returns the killer–victim distance of k in meters at the
+// kill. ok is false when either position is unknown (the zero vector).
+func killDistanceM(k model.RawKill) (float64, bool) {
+	var zero model.Vec3
+	if k.KillerPos == zero || k.VictimPos == zero {
+		return 0, false
+	}
+	dx := k.KillerPos.X - k.VictimPos.X
+	dy := k.KillerPos.Y - k.VictimPos.Y
+	dz := k.KillerPos.Z - k.VictimPos.Z
+	return math.Sqrt(dx*dx+dy*dy+dz*dz) * unitsToMeters, true
+}
+
 // wilsonCI computes the 95% Wilson score confidence interval for a proportion
 // p = hits/n. This is preferred over the Wald interval because it remains
 // stable for small sample sizes. Returns (lo, hi) as fractions in [0, 1].
@@ -100,10 +102,23 @@ func wilsonCI(hits, n int) (lo, hi float64) {
 //  9. Role classification (AWPer/Entry/Support/Rifler)
 // 10. TTK and TTD (median ms from first hit to kill/death)
 // 11. Counter-strafe % (shots fired at horizontal velocity ≤ 34 u/s)
+//
+// Coach and spectator slots are removed from raw before the first pass (see
+// SuppressSpectators), so the raw-based helpers called after Aggregate leave
+// them out as well.
 func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRoundStats, []model.PlayerWeaponStats, []model.PlayerDuelSegment, error) {
 	if raw == nil {
 		return nil, nil, nil, nil, fmt.Errorf("nil RawMatch")
 	}
+	mark := passClock(raw)
+	SuppressSpectators(raw)
+	mark("spectators")
+	return aggregate(raw, mark)
+}
+
+// aggregate runs every pass of Aggregate after coach and spectator slots
+// have been removed, recording each pass with mark.
+func aggregate(raw *model.RawMatch, mark func(pass string)) ([]model.PlayerMatchStats, []model.PlayerRoundStats, []model.PlayerWeaponStats, []model.PlayerDuelSegment, error) {
 
 	tradeWindowTicks := int(5.0 * raw.TicksPerSecond)
 
@@ -182,6 +197,8 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 		}
 	}
 
+	mark("trades")
+
 	// ---- Pass 2: first kill per round after FreezeEndTick = opening kill/death. ----
 
 	type openingResult struct {
@@ -204,6 +221,8 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 		}
 	}
 
+	mark("opening kills")
+
 	// ---- Pass 3: per-round per-player stats. ----
 
 	// Build indexed damage/flash maps.
@@ -245,12 +264,16 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 		playerID uint64
 		weapon   string
 	}
-	weaponKills  := make(map[weaponKey]int)
-	weaponHS     := make(map[weaponKey]int)
+	weaponKills := make(map[weaponKey]int)
+	weaponHS := make(map[weaponKey]int)
 	weaponDeaths := make(map[weaponKey]int)
 	weaponAssist := make(map[weaponKey]int)
 	weaponDamage := make(map[weaponKey]int)
-	weaponHits   := make(map[weaponKey]int)
+	weaponHits := make(map[weaponKey]int)
+	weaponHeadHits := make(map[weaponKey]int)
+	// Kills with a known killer–victim distance and their summed meters.
+	weaponDistKills := make(map[weaponKey]int)
+	weaponDistSum := make(map[weaponKey]float64)
 
 	for _, d := range raw.Damages {
 		if d.AttackerSteamID == 0 {
@@ -259,6 +282,9 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 		wk := weaponKey{d.AttackerSteamID, d.Weapon}
 		weaponDamage[wk] += d.HealthDamage
 		weaponHits[wk]++
+		if d.HitGroup == "head" {
+			weaponHeadHits[wk]++
+		}
 	}
 
 	// Flash assists per (attacker, round).
@@ -283,7 +309,57 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 		}
 	}
 
-	// Determine dominant team per player (most common across rounds).
+	// Per-round team observations: end state plus every kill and damage event.
+	// A SteamID seen on both teams in one round (coach slot, shared account)
+	// is a team conflict; such players are attributed round by round.
+	roundTeamObs := make(map[playerRoundKey]map[model.Team]int)
+	observe := func(id uint64, rn int, t model.Team) {
+		if id == 0 || t == model.TeamUnknown {
+			return
+		}
+		pk := playerRoundKey{id, rn}
+		if roundTeamObs[pk] == nil {
+			roundTeamObs[pk] = make(map[model.Team]int)
+		}
+		roundTeamObs[pk][t]++
+	}
+	for _, r := range raw.Rounds {
+		for id, es := range r.PlayerEndState {
+			observe(id, r.Number, es.Team)
+		}
+	}
+	for _, k := range raw.Kills {
+		observe(k.KillerSteamID, k.RoundNumber, k.KillerTeam)
+		observe(k.VictimSteamID, k.RoundNumber, k.VictimTeam)
+	}
+	for _, d := range raw.Damages {
+		observe(d.AttackerSteamID, d.RoundNumber, d.AttackerTeam)
+		observe(d.VictimSteamID, d.RoundNumber, d.VictimTeam)
+	}
+	teamConflictRounds := make(map[uint64]int)
+	for pk, obs := range roundTeamObs {
+		if len(obs) > 1 {
+			teamConflictRounds[pk.playerID]++
+		}
+	}
+	// roundTeam is the team a player is credited with in a round: the end
+	// state when present, else the team seen most often in that round's events.
+	roundTeam := func(id uint64, round model.RawRound) (model.Team, bool) {
+		if es, ok := round.PlayerEndState[id]; ok && es.Team != model.TeamUnknown {
+			return es.Team, true
+		}
+		best, bestCount := model.TeamUnknown, 0
+		for t, c := range roundTeamObs[playerRoundKey{id, round.Number}] {
+			if c > bestCount || (c == bestCount && t < best) {
+				best, bestCount = t, c
+			}
+		}
+		return best, best != model.TeamUnknown
+	}
+
+	// Determine dominant team per player (most common across rounds). Players
+	// with team conflicts count rounds by their per-round team instead of
+	// kills, so events from the other slot cannot flip their team.
 	playerDominantTeam := make(map[uint64]model.Team)
 	teamCount := make(map[uint64]map[model.Team]int)
 	for _, k := range raw.Kills {
@@ -298,6 +374,14 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 	}
 	for id := range playerSet {
 		teams := teamCount[id]
+		if teamConflictRounds[id] > 0 {
+			teams = make(map[model.Team]int)
+			for _, r := range raw.Rounds {
+				if t, ok := roundTeam(id, r); ok {
+					teams[t]++
+				}
+			}
+		}
 		best, bestCount := model.TeamUnknown, 0
 		for t, c := range teams {
 			if c > bestCount {
@@ -320,6 +404,7 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 		killerID     uint64
 		victimID     uint64
 		assisterID   uint64
+		tick         int
 		isTradeKill  bool
 		isTradeDeath bool
 		isHeadshot   bool
@@ -333,6 +418,7 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 				killerID:     k.KillerSteamID,
 				victimID:     k.VictimSteamID,
 				assisterID:   k.AssisterSteamID,
+				tick:         k.Tick,
 				isTradeKill:  k.isTradeKill,
 				isTradeDeath: k.isTradeDeath,
 				isHeadshot:   k.IsHeadshot,
@@ -349,14 +435,20 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 		openingKills, openingDeaths int
 		tradeKills, tradeDeaths     int
 		kastRounds, roundsPlayed    int
+		kastStrictRounds            int
 		unusedUtility               int
 		roundsWon                   int
+		equipValue                  int
 	}
 	matchAccums := make(map[uint64]*matchAccum)
 	for id := range playerSet {
 		matchAccums[id] = &matchAccum{}
 	}
 
+	roundTPS := raw.TicksPerSecond
+	if roundTPS == 0 {
+		roundTPS = 64.0
+	}
 	for _, round := range raw.Rounds {
 		rn := round.Number
 		kills := roundKillResults[rn]
@@ -373,16 +465,17 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 		}
 
 		// Build victim order for clutch detection (kills are already sorted by tick via Pass 1).
-		victimOrder := make([]uint64, 0, len(kills))
+		victimOrder := make([]clutchDeath, 0, len(kills))
 		for _, k := range kills {
-			victimOrder = append(victimOrder, k.victimID)
+			victimOrder = append(victimOrder, clutchDeath{k.victimID, k.tick})
 		}
-		clutchMap := computeClutch(roundPlayers, victimOrder, func(id uint64) model.Team {
-			if es, ok := round.PlayerEndState[id]; ok {
-				return es.Team
+		teamOf := func(id uint64) model.Team {
+			if t, ok := roundTeam(id, round); ok {
+				return t
 			}
 			return playerDominantTeam[id]
-		})
+		}
+		clutchMap := computeClutch(roundPlayers, victimOrder, teamOf)
 
 		for playerID := range roundPlayers {
 			if playerID == 0 {
@@ -395,10 +488,7 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 				DemoHash:    raw.DemoHash,
 				SteamID:     playerID,
 				RoundNumber: rn,
-				Team:        playerDominantTeam[playerID],
-			}
-			if hasEndState {
-				rs.Team = endState.Team
+				Team:        teamOf(playerID),
 			}
 
 			// Per-kill accounting.
@@ -415,6 +505,14 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 					}
 				}
 				if k.victimID == playerID {
+					// Kills are in tick order, so the first death sets DeathTick.
+					rs.Deaths++
+					if rs.DeathTick == 0 {
+						rs.DeathTick = k.tick
+						if round.FreezeEndTick > 0 && k.tick >= round.FreezeEndTick {
+							rs.DeathSec = float64(k.tick-round.FreezeEndTick) / roundTPS
+						}
+					}
 					// victim of a kill that was traded gets WasTraded (earns KAST)
 					if k.isTradeDeath {
 						rs.WasTraded = true
@@ -441,18 +539,7 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 			}
 
 			// Buy type classification from equipment value at freeze-end.
-			buyType := "eco"
-			if equip, ok := round.PlayerEquipValues[playerID]; ok {
-				switch {
-				case equip >= 4500:
-					buyType = "full"
-				case equip >= 2000:
-					buyType = "force"
-				case equip >= 1000:
-					buyType = "half"
-				}
-			}
-			rs.BuyType = buyType
+			rs.BuyType = buyType(round.PlayerEquipValues[playerID])
 
 			// Damage.
 			pk := playerRoundKey{playerID, rn}
@@ -466,8 +553,14 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 			if ci, ok := clutchMap[playerID]; ok {
 				rs.IsInClutch = ci.isClutch
 				rs.ClutchEnemyCount = ci.enemyCount
+				rs.ClutchStartTick = ci.startTick
+				rs.ClutchEnemies = ci.enemies
+				if round.FreezeEndTick > 0 && ci.startTick >= round.FreezeEndTick {
+					rs.ClutchStartSec = float64(ci.startTick-round.FreezeEndTick) / roundTPS
+				}
 			}
 			rs.WonRound = round.WinnerTeam != model.TeamUnknown && round.WinnerTeam == rs.Team
+			rs.EndReason = round.EndReason
 
 			allRoundStats = append(allRoundStats, rs)
 
@@ -480,6 +573,7 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 			acc.kills += rs.Kills
 			acc.assists += rs.Assists
 			acc.totalDamage += rs.Damage
+			acc.equipValue += round.PlayerEquipValues[playerID]
 			acc.utilityDamage += utilDmgByPlayerRound[pk]
 			acc.unusedUtility += rs.UnusedUtility
 			if rs.GotKill {
@@ -500,6 +594,12 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 			if rs.KASTEarned {
 				acc.kastRounds++
 			}
+			// Strict KAST: a survival only counts in a won round or when the
+			// player dealt damage, so saves in lost rounds do not.
+			if rs.GotKill || rs.GotAssist || rs.WasTraded ||
+				(rs.Survived && (rs.WonRound || rs.Damage > 0)) {
+				acc.kastStrictRounds++
+			}
 		}
 	}
 
@@ -525,6 +625,10 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 			if k.IsHeadshot {
 				weaponHS[wk]++
 			}
+			if d, ok := killDistanceM(k); ok {
+				weaponDistKills[wk]++
+				weaponDistSum[wk] += d
+			}
 		}
 		if k.VictimSteamID != 0 && k.Weapon != "" {
 			weaponDeaths[weaponKey{k.VictimSteamID, k.Weapon}]++
@@ -534,6 +638,8 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 		}
 	}
 
+	mark("round stats")
+
 	// ---- Pass 4: roll up into PlayerMatchStats. ----
 	var matchStats []model.PlayerMatchStats
 	for playerID, acc := range matchAccums {
@@ -560,6 +666,11 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 			KASTRounds:     acc.kastRounds,
 			UnusedUtility:  acc.unusedUtility,
 			RoundsWon:      acc.roundsWon,
+			EquipmentValue: acc.equipValue,
+
+			TeamConflictRounds: teamConflictRounds[playerID],
+			KASTStrictRounds:   acc.kastStrictRounds,
+			PipelineVersion:    PipelineVersion,
 		}
 		if delays := tradeKillDelays[playerID]; len(delays) > 0 {
 			sort.Float64s(delays)
@@ -577,6 +688,8 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 		return matchStats[i].Kills > matchStats[j].Kills
 	})
 
+	mark("match rollup")
+
 	// ---- Pass 5: crosshair placement aggregation (total + pitch/yaw split). ----
 	type xhairAccum struct {
 		angles []float64
@@ -644,6 +757,10 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 			Deaths:        weaponDeaths[wk],
 			Damage:        weaponDamage[wk],
 			Hits:          weaponHits[wk],
+			HeadHits:      weaponHeadHits[wk],
+
+			DistanceKills:    weaponDistKills[wk],
+			KillDistanceSumM: weaponDistSum[wk],
 		})
 	}
 	sort.Slice(weaponStats, func(i, j int) bool {
@@ -653,6 +770,8 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 		return weaponStats[i].Damage > weaponStats[j].Damage
 	})
 
+	mark("crosshair")
+
 	// ---- Pass 6: Duel Engine ----
 
 	// Build first-sight index: (observerID, enemyID, roundN) → first-sight tick.
@@ -682,6 +801,18 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 		})
 	}
 
+	// Damage taken index for assisted duels: (roundN, victimID) → all damage
+	// the victim took that round, utility included.
+	type dmgTakenKey struct {
+		roundN   int
+		victimID uint64
+	}
+	dmgTakenIdx := make(map[dmgTakenKey][]model.RawDamage)
+	for _, d := range raw.Damages {
+		k := dmgTakenKey{d.RoundNumber, d.VictimSteamID}
+		dmgTakenIdx[k] = append(dmgTakenIdx[k], d)
+	}
+
 	// Build weapon-fire index: (shooterID, roundN) → sorted slice of RawWeaponFire.
 	type wfKey struct{ shooterID uint64; roundN int }
 	wfIdx := make(map[wfKey][]model.RawWeaponFire)
@@ -697,9 +828,11 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 
 	// Duel accumulators per player.
 	type duelAccum struct {
-		winMs          []float64
-		lossMs         []float64
-		hitsToKill     []float64
+		winMs           []float64
+		lossMs          []float64
+		assistedWins    int
+		assistedLosses  int
+		hitsToKill      []float64
 		firstHitHSCount int
 		firstHitTotal   int
 		correctionDegs  []float64
@@ -712,16 +845,22 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 		return duelAccums[id]
 	}
 
-	// Segment accumulators: per (player, weapon_bucket, distance_bin).
+	// Segment accumulators: per (player, round context, weapon_bucket, distance_bin).
 	type segKey struct {
 		playerID uint64
+		context  string
 		bucket   string
 		bin      string
 	}
+	duelContext := newDuelContexts(raw)
 	type segAccum struct {
 		duelCount       int
 		firstHitCount   int
 		firstHitHSCount int
+		stillHits       int // first hits whose first shot was counter-strafed
+		stillHS         int
+		movingHits      int // first hits whose first shot was fired on the move
+		movingHS        int
 		corrDegs        []float64
 		sightDegs       []float64
 		expoWinMs       []float64
@@ -733,11 +872,31 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 		tps = 64.0
 	}
 
+	// assisted reports whether a teammate of the killer dealt at least
+	// assistedDuelMinDamage to the victim in the assistedDuelWindowSec before
+	// the kill: the duel was not a clean 1v1.
+	assisted := func(kill model.RawKill) bool {
+		if kill.KillerSteamID == 0 || kill.KillerTeam == kill.VictimTeam {
+			return false
+		}
+		from := kill.Tick - int(assistedDuelWindowSec*tps)
+		total := 0
+		for _, d := range dmgTakenIdx[dmgTakenKey{kill.RoundNumber, kill.VictimSteamID}] {
+			if d.Tick < from || d.Tick > kill.Tick || d.AttackerSteamID == 0 ||
+				d.AttackerSteamID == kill.KillerSteamID || d.AttackerTeam != kill.KillerTeam {
+				continue
+			}
+			total += d.HealthDamage
+		}
+		return total >= assistedDuelMinDamage
+	}
+
 	for _, kill := range raw.Kills {
 		rn := kill.RoundNumber
 		killerID := kill.KillerSteamID
 		victimID := kill.VictimSteamID
 		killTick := kill.Tick
+		isAssisted := assisted(kill)
 
 		// Win accounting for killer.
 		sk := sightKey{killerID, victimID, rn}
@@ -768,6 +927,9 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 
 			acc := getDuelAccum(killerID)
 			acc.winMs = append(acc.winMs, winMs)
+			if isAssisted {
+				acc.assistedWins++
+			}
 			if hits > 0 {
 				acc.hitsToKill = append(acc.hitsToKill, float64(hits))
 				acc.firstHitTotal++
@@ -782,6 +944,7 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 			corrComputed := false
 			attackerPos := model.Vec3{}
 			attackerPosSet := false
+			firstShotSpeed := 0.0
 			for _, wf := range wfList {
 				if wf.Tick < sightTick || wf.Tick > killTick {
 					continue
@@ -791,6 +954,7 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 				acc.correctionDegs = append(acc.correctionDegs, corrDeg)
 				attackerPos = wf.AttackerPos
 				attackerPosSet = true
+				firstShotSpeed = wf.HorizontalSpeed
 				break
 			}
 
@@ -805,7 +969,7 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 			bucket := weaponBucket(kill.Weapon)
 			bin := distanceBin(distM)
 
-			sk2 := segKey{killerID, bucket, bin}
+			sk2 := segKey{killerID, duelContext(rn, killerID, victimID), bucket, bin}
 			if segAccums[sk2] == nil {
 				segAccums[sk2] = &segAccum{}
 			}
@@ -818,6 +982,21 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 				if firstHitHS {
 					sa.firstHitHSCount++
 				}
+				// Split by movement at the first shot; without a weapon-fire
+				// sample the duel stays in neither bucket.
+				if corrComputed {
+					if firstShotSpeed <= csThreshold {
+						sa.stillHits++
+						if firstHitHS {
+							sa.stillHS++
+						}
+					} else {
+						sa.movingHits++
+						if firstHitHS {
+							sa.movingHS++
+						}
+					}
+				}
 			}
 			if corrComputed {
 				sa.corrDegs = append(sa.corrDegs, corrDeg)
@@ -837,6 +1016,9 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 			// Victim didn't spot killer; still count as a duel loss with 0ms exposure.
 			getDuelAccum(victimID).lossMs = append(getDuelAccum(victimID).lossMs, 0)
 		}
+		if isAssisted {
+			getDuelAccum(victimID).assistedLosses++
+		}
 
 		// Increment win/loss counts.
 		getDuelAccum(killerID).winMs = getDuelAccum(killerID).winMs // already appended above if sight found
@@ -855,6 +1037,8 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 		}
 		matchStats[i].DuelWins = len(acc.winMs)
 		matchStats[i].DuelLosses = len(acc.lossMs)
+		matchStats[i].AssistedDuelWins = acc.assistedWins
+		matchStats[i].AssistedDuelLosses = acc.assistedLosses
 
 		sort.Float64s(acc.winMs)
 		sort.Float64s(acc.lossMs)
@@ -886,19 +1070,26 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 		sort.Float64s(sa.sightDegs)
 		sort.Float64s(sa.expoWinMs)
 		duelSegments = append(duelSegments, model.PlayerDuelSegment{
-			DemoHash:        raw.DemoHash,
-			SteamID:         k.playerID,
-			WeaponBucket:    k.bucket,
-			DistanceBin:     k.bin,
-			DuelCount:       sa.duelCount,
-			FirstHitCount:   sa.firstHitCount,
-			FirstHitHSCount: sa.firstHitHSCount,
-			MedianCorrDeg:   median(sa.corrDegs),
-			MedianSightDeg:  median(sa.sightDegs),
-			MedianExpoWinMs: median(sa.expoWinMs),
+			DemoHash:         raw.DemoHash,
+			SteamID:          k.playerID,
+			RoundContext:     k.context,
+			WeaponBucket:     k.bucket,
+			DistanceBin:      k.bin,
+			DuelCount:        sa.duelCount,
+			FirstHitCount:    sa.firstHitCount,
+			FirstHitHSCount:  sa.firstHitHSCount,
+			MedianCorrDeg:    median(sa.corrDegs),
+			MedianSightDeg:   median(sa.sightDegs),
+			MedianExpoWinMs:  median(sa.expoWinMs),
+			StillFirstHits:   sa.stillHits,
+			StillFirstHitHS:  sa.stillHS,
+			MovingFirstHits:  sa.movingHits,
+			MovingFirstHitHS: sa.movingHS,
 		})
 	}
 
+	mark("duel engine")
+
 	// ---- Pass 7: AWP Death Classifier ----
 
 	// Build flash index: victimID → []tick for flashes with FlashDuration > 0 per round.
@@ -970,6 +1161,60 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 		}
 	}
 
+	// AWP rounds faced: rounds in which the enemy team used an AWP at all, so
+	// AWP deaths can be read against how often there was an AWP to die to.
+	roundByNum := make(map[int]model.RawRound, len(raw.Rounds))
+	for _, r := range raw.Rounds {
+		roundByNum[r.Number] = r
+	}
+	awpTeams := make(map[int]map[model.Team]bool) // round → teams that used an AWP
+	markAWP := func(rn int, t model.Team) {
+		if t != model.TeamCT && t != model.TeamT {
+			return
+		}
+		if awpTeams[rn] == nil {
+			awpTeams[rn] = make(map[model.Team]bool)
+		}
+		awpTeams[rn][t] = true
+	}
+	for _, k := range raw.Kills {
+		if k.Weapon == "AWP" {
+			markAWP(k.RoundNumber, k.KillerTeam)
+		}
+	}
+	for _, d := range raw.Damages {
+		if d.Weapon == "AWP" {
+			markAWP(d.RoundNumber, d.AttackerTeam)
+		}
+	}
+	for _, wf := range raw.WeaponFires {
+		if wf.Weapon != "AWP" {
+			continue
+		}
+		if r, ok := roundByNum[wf.RoundNumber]; ok {
+			if t, ok := roundTeam(wf.ShooterID, r); ok {
+				markAWP(wf.RoundNumber, t)
+			}
+		}
+	}
+	awpRoundsFaced := make(map[uint64]int)
+	for _, rs := range allRoundStats {
+		enemy := model.TeamT
+		if rs.Team == model.TeamT {
+			enemy = model.TeamCT
+		} else if rs.Team != model.TeamCT {
+			continue
+		}
+		if awpTeams[rs.RoundNumber][enemy] {
+			awpRoundsFaced[rs.SteamID]++
+		}
+	}
+	for i := range matchStats {
+		matchStats[i].AWPRoundsFaced = awpRoundsFaced[matchStats[i].SteamID]
+	}
+
+	mark("awp deaths")
+
 	// ---- Pass 8: Flash Quality Window ----
 
 	// Build kill lookup: sorted by tick within round.
@@ -1005,6 +1250,8 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 		matchStats[i].EffectiveFlashes = effectiveFlashAccum[matchStats[i].SteamID]
 	}
 
+	mark("flash quality")
+
 	// ---- Pass 9: Role classification ----
 	for i := range matchStats {
 		id := matchStats[i].SteamID
@@ -1025,6 +1272,8 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 		}
 	}
 
+	mark("roles")
+
 	// ---- Pass 10: TTK, TTD, and one-tap kills (WeaponFire-based, rolling 3s window) ----
 	// TTK is measured from the first shot FIRED (not first hit) within 3s of the kill tick.
 	// Including missed shots makes the numbers comparable to external tools like Refrag.
@@ -1079,17 +1328,15 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 		matchStats[i].OneTapKills = oneTapKills[id]
 	}
 
+	mark("ttk/ttd")
+
 	// ---- Counter-strafe % ----
 	// A shot is counter-strafed when the shooter's horizontal speed at fire time is
-	// at or below 34 Hammer units/s (≈14% of base walk speed). This threshold is
-	// captured from the velocity field added to RawWeaponFire in the parser.
-	const csThreshold = 34.0
+	// at or below csThreshold. The speed is captured from the velocity field added
+	// to RawWeaponFire in the parser.
 	type csAccum struct{ total, strafed int }
 	csMap := make(map[uint64]*csAccum)
 	for _, wf := range raw.WeaponFires {
-		if wf.ShooterID == 0 {
-			continue
-		}
 		if _, ok := csMap[wf.ShooterID]; !ok {
 			csMap[wf.ShooterID] = &csAccum{}
 		}
@@ -1104,23 +1351,533 @@ func Aggregate(raw *model.RawMatch) ([]model.PlayerMatchStats, []model.PlayerRou
 		}
 	}
 
+	mark("counter-strafe")
+
+	// ---- Moving deaths ----
+	// Defensive mirror of counter-strafe %: for each death, take the killer's
+	// first non-utility hit on the victim within the 3s engagement window used
+	// by TTK/TTD, and check the victim's horizontal speed at that hit.
+	deathSpeedSamples := make(map[uint64]int)
+	movingDeaths := make(map[uint64]int)
+	for _, kill := range raw.Kills {
+		if kill.KillerSteamID == 0 || kill.KillerSteamID == kill.VictimSteamID {
+			continue
+		}
+		windowStart := kill.Tick - ttkWindowTicks
+		for _, d := range duelDmgIdx[duelDmgKey{kill.RoundNumber, kill.KillerSteamID, kill.VictimSteamID}] {
+			if d.Tick < windowStart || d.Tick > kill.Tick {
+				continue
+			}
+			deathSpeedSamples[kill.VictimSteamID]++
+			if d.VictimSpeed > csThreshold {
+				movingDeaths[kill.VictimSteamID]++
+			}
+			break
+		}
+	}
+	for i := range matchStats {
+		id := matchStats[i].SteamID
+		matchStats[i].DeathSpeedSamples = deathSpeedSamples[id]
+		matchStats[i].MovingDeaths = movingDeaths[id]
+	}
+
+	mark("moving deaths")
+
+	// ---- Multi-kills: collaterals and spray transfers ----
+	// Consecutive enemy kills by the same player with the same weapon in one
+	// round. The second kill is a collateral when it lands within collatTicks of
+	// the first with no shot fired in between (one bullet, incl. wallbangs). It
+	// is a spray transfer when it lands within 1.5s, the player kept firing in
+	// between, and the second victim was already spotted by the player before
+	// the first kill (no re-sighting). Utility and knife kills never qualify:
+	// the parser records no WeaponFires for them.
+	const (
+		collatTicks      = 2
+		sprayTransferSec = 1.5
+	)
+	sprayTransferTicks := int(sprayTransferSec * tps)
+	type multiKey struct {
+		killerID uint64
+		roundN   int
+	}
+	killsByKiller := make(map[multiKey][]model.RawKill)
+	for _, k := range raw.Kills {
+		if k.KillerSteamID == 0 || k.KillerTeam == k.VictimTeam {
+			continue
+		}
+		mk := multiKey{k.KillerSteamID, k.RoundNumber}
+		killsByKiller[mk] = append(killsByKiller[mk], k)
+	}
+	collateralKills := make(map[uint64]int)
+	sprayTransferKills := make(map[uint64]int)
+	for mk, kills := range killsByKiller {
+		sort.SliceStable(kills, func(i, j int) bool { return kills[i].Tick < kills[j].Tick })
+		fires := wfIdx[wfKey{mk.killerID, mk.roundN}]
+		for j := 1; j < len(kills); j++ {
+			prev, cur := kills[j-1], kills[j]
+			gap := cur.Tick - prev.Tick
+			if cur.Weapon != prev.Weapon || gap > sprayTransferTicks {
+				continue
+			}
+			firedAtPrev, firedBetween := false, false
+			for _, wf := range fires {
+				if wf.Weapon != cur.Weapon || wf.Tick > cur.Tick {
+					continue
+				}
+				if wf.Tick > prev.Tick {
+					firedBetween = true
+				} else if wf.Tick >= prev.Tick-collatTicks {
+					firedAtPrev = true
+				}
+			}
+			switch {
+			case !firedBetween && firedAtPrev && gap <= collatTicks:
+				collateralKills[mk.killerID]++
+			case firedBetween:
+				fs, ok := firstSightIdx[sightKey{mk.killerID, cur.VictimSteamID, mk.roundN}]
+				if ok && fs.Tick <= prev.Tick {
+					sprayTransferKills[mk.killerID]++
+				}
+			}
+		}
+	}
+	for i := range matchStats {
+		id := matchStats[i].SteamID
+		matchStats[i].CollateralKills = collateralKills[id]
+		matchStats[i].SprayTransferKills = sprayTransferKills[id]
+	}
+
+	mark("multi-kills")
+
+	// ---- AWP shot ledger ----
+	// Every AWP WeaponFire is a shot. Each AWP damage to an enemy is credited
+	// to the shooter's latest AWP shot at most awpShotWindowSec earlier; a shot
+	// whose damage left any victim at 0 HP is a kill, one with only non-lethal
+	// damage is a body hit, and one with no damage is a miss.
+	const awpShotWindowSec = 0.1
+	awpShotWindowTicks := int(awpShotWindowSec * tps)
+	type awpShot struct{ hit, kill bool }
+	awpShots := make(map[wfKey][]awpShot) // parallel to the AWP fires in wfIdx order
+	awpFireTicks := make(map[wfKey][]int)
+	for k, fires := range wfIdx {
+		for _, wf := range fires {
+			if wf.Weapon == "AWP" {
+				awpFireTicks[k] = append(awpFireTicks[k], wf.Tick)
+				awpShots[k] = append(awpShots[k], awpShot{})
+			}
+		}
+	}
+	for _, d := range raw.Damages {
+		if d.Weapon != "AWP" || d.AttackerSteamID == 0 || d.AttackerTeam == d.VictimTeam {
+			continue
+		}
+		k := wfKey{d.AttackerSteamID, d.RoundNumber}
+		ticks := awpFireTicks[k]
+		i := sort.SearchInts(ticks, d.Tick+1) - 1 // latest shot at or before the damage
+		if i < 0 || d.Tick-ticks[i] > awpShotWindowTicks {
+			continue
+		}
+		awpShots[k][i].hit = true
+		if d.VictimHealth <= 0 {
+			awpShots[k][i].kill = true
+		}
+	}
+	type awpLedger struct{ shots, kills, bodyHits int }
+	awpLedgers := make(map[uint64]*awpLedger)
+	for k, shots := range awpShots {
+		l := awpLedgers[k.shooterID]
+		if l == nil {
+			l = &awpLedger{}
+			awpLedgers[k.shooterID] = l
+		}
+		for _, s := range shots {
+			l.shots++
+			switch {
+			case s.kill:
+				l.kills++
+			case s.hit:
+				l.bodyHits++
+			}
+		}
+	}
+	for i := range matchStats {
+		if l := awpLedgers[matchStats[i].SteamID]; l != nil {
+			matchStats[i].AWPShots = l.shots
+			matchStats[i].AWPShotKills = l.kills
+			matchStats[i].AWPShotBodyHits = l.bodyHits
+		}
+	}
+
+	mark("awp shots")
+
+	// ---- Low-HP enemies not finished ----
+	// An enemy is "left low" by a player when one of that player's hits drops
+	// them to 1–19 HP and the player does not kill them later in the round.
+	// Each (player, enemy, round) counts once, split by outcome: finished by a
+	// teammate (kill handed off) or survived / died to anything else (wasted).
+	const lowHPThreshold = 20
+	type lowHPKey struct {
+		attackerID, victimID uint64
+		roundN               int
+	}
+	leftLow := make(map[lowHPKey]model.Team) // → attacker team
+	for _, d := range raw.Damages {
+		if d.AttackerSteamID == 0 || d.VictimHealth <= 0 || d.VictimHealth >= lowHPThreshold {
+			continue
+		}
+		if d.AttackerTeam == d.VictimTeam {
+			continue // team damage
+		}
+		leftLow[lowHPKey{d.AttackerSteamID, d.VictimSteamID, d.RoundNumber}] = d.AttackerTeam
+	}
+	lowHPHanded := make(map[uint64]int)
+	lowHPWasted := make(map[uint64]int)
+	for key, attackerTeam := range leftLow {
+		var killer uint64
+		var killerTeam model.Team
+		for _, k := range killsByRound[key.roundN] {
+			if k.VictimSteamID == key.victimID {
+				killer, killerTeam = k.KillerSteamID, k.KillerTeam
+				break
+			}
+		}
+		switch {
+		case killer == key.attackerID:
+			// Finished it themselves.
+		case killer != 0 && killerTeam == attackerTeam:
+			lowHPHanded[key.attackerID]++
+		default:
+			lowHPWasted[key.attackerID]++
+		}
+	}
+	for i := range matchStats {
+		matchStats[i].LowHPHanded = lowHPHanded[matchStats[i].SteamID]
+		matchStats[i].LowHPWasted = lowHPWasted[matchStats[i].SteamID]
+	}
+
+	mark("low-hp enemies")
+
+	// ---- Objective play ----
+	// A defuse is "ninja" when enemies were still alive close by (≤1000 units)
+	// yet none of them spotted the defuser at any point during the defuse.
+	// A plant denial is a kill on an enemy who was mid-plant.
+	defuses := make(map[uint64]int)
+	ninjaDefuses := make(map[uint64]int)
+	plantDenials := make(map[uint64]int)
+	for _, d := range raw.Defuses {
+		defuses[d.DefuserID]++
+		if d.NearbyEnemies > 0 && !d.Spotted {
+			ninjaDefuses[d.DefuserID]++
+		}
+	}
+	for _, k := range raw.Kills {
+		if k.VictimPlanting && k.KillerSteamID != 0 && k.KillerTeam != k.VictimTeam {
+			plantDenials[k.KillerSteamID]++
+		}
+	}
+	for i := range matchStats {
+		id := matchStats[i].SteamID
+		matchStats[i].Defuses = defuses[id]
+		matchStats[i].NinjaDefuses = ninjaDefuses[id]
+		matchStats[i].PlantDenials = plantDenials[id]
+	}
+
+	mark("objective")
+
+	// ---- Defensive utility ----
+	// Enemy grenades absorbed: utility damage received and flash blindness
+	// (count and total duration). Self and team utility are not counted.
+	utilDmgTaken := make(map[uint64]int)
+	flashesReceived := make(map[uint64]int)
+	blindReceived := make(map[uint64]float64) // seconds
+	for _, d := range raw.Damages {
+		if d.IsUtility && d.AttackerSteamID != d.VictimSteamID && d.AttackerTeam != d.VictimTeam {
+			utilDmgTaken[d.VictimSteamID] += d.HealthDamage
+		}
+	}
+	for _, fl := range raw.Flashes {
+		if fl.FlashDuration <= 0 || fl.AttackerSteamID == fl.VictimSteamID || fl.AttackerTeam == fl.VictimTeam {
+			continue
+		}
+		flashesReceived[fl.VictimSteamID]++
+		blindReceived[fl.VictimSteamID] += fl.FlashDuration.Seconds()
+	}
+	for i := range matchStats {
+		id := matchStats[i].SteamID
+		matchStats[i].UtilityDamageTaken = utilDmgTaken[id]
+		matchStats[i].FlashesReceived = flashesReceived[id]
+		matchStats[i].BlindTimeReceivedSec = blindReceived[id]
+	}
+
+	mark("defensive utility")
+
+	// ---- Spotted before death (overexposure) ----
+	// For each death, the earliest first-sight of the victim by any enemy that
+	// round marks when they became exposed. firstSightIdx keeps one sighting per
+	// (observer, enemy, round), so this is time since first spotted, not
+	// continuous line of sight.
+	type exposedKey struct {
+		victimID uint64
+		roundN   int
+	}
+	firstSpotted := make(map[exposedKey]int) // → earliest sighting tick
+	for k, fs := range firstSightIdx {
+		ek := exposedKey{k.enemyID, k.roundN}
+		if t, ok := firstSpotted[ek]; !ok || fs.Tick < t {
+			firstSpotted[ek] = fs.Tick
+		}
+	}
+	spottedMs := make(map[uint64][]float64)
+	for _, kill := range raw.Kills {
+		t, ok := firstSpotted[exposedKey{kill.VictimSteamID, kill.RoundNumber}]
+		if !ok || t > kill.Tick {
+			continue
+		}
+		spottedMs[kill.VictimSteamID] = append(spottedMs[kill.VictimSteamID], float64(kill.Tick-t)/tps*1000)
+	}
+	for i := range matchStats {
+		ms := spottedMs[matchStats[i].SteamID]
+		sort.Float64s(ms)
+		matchStats[i].MedianSpottedBeforeDeathMs = median(ms)
+	}
+
+	mark("spotted before death")
+
+	// ---- Time to damage (hesitation) ----
+	// Delay from first sighting an enemy to first bullet damage on them, over
+	// all engagements (see damageDelays), not just won duels.
+	delayMs := make(map[uint64][]float64)
+	for _, d := range damageDelays(raw) {
+		delayMs[d.playerID] = append(delayMs[d.playerID], d.ms)
+	}
+	for i := range matchStats {
+		ms := delayMs[matchStats[i].SteamID]
+		sort.Float64s(ms)
+		matchStats[i].TimeToDamageSamples = len(ms)
+		matchStats[i].MedianTimeToDamageMs = median(ms)
+	}
+
+	mark("time to damage")
+
+	// ---- Burst length ----
+	// Consecutive same-weapon shots ≤ burstGapSec apart, binned by length.
+	burstsBy := make(map[uint64]*burstCounts)
+	for _, b := range bursts(raw) {
+		if burstsBy[b.playerID] == nil {
+			burstsBy[b.playerID] = &burstCounts{}
+		}
+		burstsBy[b.playerID].add(b.shots)
+	}
+	for i := range matchStats {
+		if c := burstsBy[matchStats[i].SteamID]; c != nil {
+			matchStats[i].BurstTaps = c.taps
+			matchStats[i].BurstShort = c.short
+			matchStats[i].BurstSpray = c.sprays
+			matchStats[i].BurstPanic = c.panic
+		}
+	}
+
+	mark("bursts")
+
+	// ---- Late-round discipline ----
+	// Deaths late on the round/bomb clock, and deaths while up in numbers with
+	// the clock on the player's side (see lateRoundDiscipline).
+	late := lateRoundDiscipline(raw)
+	for i := range matchStats {
+		if c := late[matchStats[i].SteamID]; c != nil {
+			matchStats[i].LateRoundDeaths = c.lateDeaths
+			matchStats[i].PlayForTimeRounds = c.pftRounds
+			matchStats[i].PlayForTimeDeaths = c.pftDeaths
+		}
+	}
+
+	mark("late round")
+
+	// ---- Momentum ----
+	// Team win streaks and bounce-back rounds within each half, and the first
+	// kill of each half (see momentum).
+	mom := momentum(raw, allRoundStats)
+	for i := range matchStats {
+		if c := mom[matchStats[i].SteamID]; c != nil {
+			matchStats[i].LongestWinStreak = c.longestWin
+			matchStats[i].StreakRounds = c.streakRounds
+			matchStats[i].StreakKills = c.streakKills
+			matchStats[i].StreakDamage = c.streakDamage
+			matchStats[i].BounceRounds = c.bounceRounds
+			matchStats[i].BounceKills = c.bounceKills
+			matchStats[i].BounceDamage = c.bounceDamage
+			matchStats[i].BounceWins = c.bounceWins
+			matchStats[i].HalfFirstKills = c.halfFirstKills
+		}
+	}
+
+	mark("momentum")
+
+	// ---- Utility synergy ----
+	// Kills played off a teammate's flash or smoke (see utilitySynergy).
+	syn := utilitySynergy(raw)
+	for i := range matchStats {
+		if c := syn[matchStats[i].SteamID]; c != nil {
+			matchStats[i].PlayedOffFlashKills = c.offFlash
+			matchStats[i].PlayedOffSmokeKills = c.offSmoke
+			matchStats[i].PlayedOffUtilityKills = c.offUtility
+		}
+	}
+
+	mark("utility synergy")
+
+	// ---- Scoreline splits ----
+	// Output by the team's standing in the match score at round start (see
+	// scorelineSplits).
+	score := scorelineSplits(raw, allRoundStats)
+	for i := range matchStats {
+		if c := score[matchStats[i].SteamID]; c != nil {
+			matchStats[i].Leading = c.leading
+			matchStats[i].Tied = c.tied
+			matchStats[i].Trailing = c.trailing
+		}
+	}
+
+	mark("scoreline")
+
+	// ---- Peeker's advantage ----
+	// Mutual-sight kills split by who was moving at first sight (see
+	// peekerDuels).
+	peeks := peekerDuels(raw)
+	for i := range matchStats {
+		if c := peeks[matchStats[i].SteamID]; c != nil {
+			matchStats[i].PeekDuels = c.peeks
+			matchStats[i].PeekWins = c.peekWins
+			matchStats[i].HoldDuels = c.holds
+			matchStats[i].HoldWins = c.holdWins
+		}
+	}
+
+	mark("peeker")
+
+	// ---- Weapon discipline ----
+	// Deaths caught on a pistol, knife or grenade while carrying a primary
+	// (see weaponDiscipline).
+	disc := weaponDiscipline(raw)
+	for i := range matchStats {
+		if c := disc[matchStats[i].SteamID]; c != nil {
+			matchStats[i].PrimaryDeaths = c.primaryDeaths
+			matchStats[i].WrongWeaponDeaths = c.wrongDeaths
+		}
+	}
+
+	mark("weapon discipline")
+
+	// ---- Head-hit share ----
+	// Bullet hits on enemies and those on the head (see headHits).
+	heads := headHits(raw)
+	for i := range matchStats {
+		if c := heads[matchStats[i].SteamID]; c != nil {
+			matchStats[i].BulletHits = c.hits
+			matchStats[i].HeadHits = c.headHits
+		}
+	}
+
+	mark("head hits")
+
+	// ---- Utility timing ----
+	// Grenade throws by round phase: early setup, mid-round or late (see
+	// utilityTiming).
+	timing := utilityTiming(raw)
+	for i := range matchStats {
+		if c := timing[matchStats[i].SteamID]; c != nil {
+			matchStats[i].UtilThrowsEarly = c.early
+			matchStats[i].UtilThrowsMid = c.mid
+			matchStats[i].UtilThrowsLate = c.late
+			matchStats[i].MedianUtilThrowSec = median(c.secs)
+		}
+	}
+
+	mark("utility timing")
+
+	// ---- Eco damage split ----
+	// Damage per round by the enemy side's economy class (see ecoDamage).
+	eco := ecoDamage(raw, allRoundStats)
+	for i := range matchStats {
+		if c := eco[matchStats[i].SteamID]; c != nil {
+			matchStats[i].EcoRounds = c.ecoRounds
+			matchStats[i].EcoDamage = c.ecoDamage
+			matchStats[i].BuyRounds = c.buyRounds
+			matchStats[i].BuyDamage = c.buyDamage
+		}
+	}
+
+	mark("eco damage")
+
+	// ---- Deaths to unspotted enemies ----
+	// Deaths to a killer nobody on the victim's team had spotted that round
+	// (see infoDeaths).
+	info := infoDeaths(raw)
+	for i := range matchStats {
+		if c := info[matchStats[i].SteamID]; c != nil {
+			matchStats[i].UnspottedDeaths = c.unspotted
+			matchStats[i].SpottedDeaths = c.spotted
+		}
+	}
+
+	mark("info deaths")
+
+	// ---- Connection ----
+	// Mean and peak scoreboard ping over the parser's readings (see pingStats).
+	ping := pingStats(raw)
+	for i := range matchStats {
+		if c := ping[matchStats[i].SteamID]; c != nil && c.samples > 0 {
+			matchStats[i].PingSamples = c.samples
+			matchStats[i].AvgPingMs = float64(c.sumMs) / float64(c.samples)
+			matchStats[i].MaxPingMs = c.maxMs
+		}
+	}
+
+	mark("ping")
+
+	// ---- Duel loss reasons ----
+	// Factors behind each death to an enemy: flashed, isolated, out-utilitied
+	// or re-peeking (see duelLossReasons).
+	reasons := duelLossReasons(raw)
+	for i := range matchStats {
+		if c := reasons[matchStats[i].SteamID]; c != nil {
+			matchStats[i].LossFlashed = c.flashed
+			matchStats[i].LossIsolated = c.isolated
+			matchStats[i].LossUtilSupport = c.utilSupport
+			matchStats[i].LossRePeek = c.rePeek
+			matchStats[i].LossExplained = c.explained
+		}
+	}
+
+	mark("loss reasons")
+
 	return matchStats, allRoundStats, weaponStats, duelSegments, nil
 }
 
 // clutchResult holds the clutch outcome for a single player in a round.
 type clutchResult struct {
 	isClutch   bool
-	enemyCount int // max enemies alive when the clutch was detected
+	enemyCount int      // max enemies alive when the clutch was detected
+	startTick  int      // tick of the death that left the player last alive
+	enemies    []uint64 // enemies alive at startTick, ascending
+}
+
+// clutchDeath is one death of a round, in the order computeClutch replays them.
+type clutchDeath struct {
+	victimID uint64
+	tick     int
 }
 
 // computeClutch walks the kill list for a round and determines which players
 // entered a clutch situation (last alive on their team facing ≥1 enemy).
 // roundPlayers is the set of all player IDs who participated in the round.
-// victimOrder is the ordered list of victim IDs (kill order by tick ascending).
-// teamOf returns the team for a given player ID.
+// victimOrder is the ordered list of deaths (kill order by tick ascending).
+// teamOf returns the team for a given player ID. The clutch starts at the
+// death that first leaves the player alone; its tick and the enemies alive
+// then are kept for locating the moment in the demo.
 func computeClutch(
 	roundPlayers map[uint64]struct{},
-	victimOrder []uint64,
+	victimOrder []clutchDeath,
 	teamOf func(uint64) model.Team,
 ) map[uint64]clutchResult {
 	// Start with everyone alive.
@@ -1133,7 +1890,7 @@ func computeClutch(
 
 	results := make(map[uint64]clutchResult, len(roundPlayers))
 
-	checkClutch := func() {
+	checkClutch := func(tick int) {
 		// Count alive players per team.
 		teamAlive := make(map[model.Team]int)
 		for id, isAlive := range alive {
@@ -1161,6 +1918,15 @@ func computeClutch(
 			}
 			if myAlive == 1 && enemiesAlive >= 1 {
 				prev := results[id]
+				if !prev.isClutch {
+					prev.startTick = tick
+					for eid, eAlive := range alive {
+						if t := teamOf(eid); eAlive && t != myTeam && t != model.TeamUnknown && t != model.TeamSpectators {
+							prev.enemies = append(prev.enemies, eid)
+						}
+					}
+					sort.Slice(prev.enemies, func(i, j int) bool { return prev.enemies[i] < prev.enemies[j] })
+				}
 				prev.isClutch = true
 				if enemiesAlive > prev.enemyCount {
 					prev.enemyCount = enemiesAlive
@@ -1170,9 +1936,9 @@ func computeClutch(
 		}
 	}
 
-	for _, victimID := range victimOrder {
-		alive[victimID] = false
-		checkClutch()
+	for _, d := range victimOrder {
+		alive[d.victimID] = false
+		checkClutch(d.tick)
 	}
 
 	return results
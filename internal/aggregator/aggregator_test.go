@@ -1,7 +1,11 @@
 package aggregator
 
 import (
+	"math"
+	"reflect"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/pable/go-cs-metrics/internal/model"
 )
@@ -237,6 +241,56 @@ func TestKAST_Traded(t *testing.T) {
 	_ = roundStats
 }
 
+// TestKAST_Strict: surviving a lost round without dealing damage earns
+// standard KAST but not strict KAST; a won round or any damage keeps it.
+func TestKAST_Strict(t *testing.T) {
+	ids := []uint64{playerA, playerB, playerC}
+	alive := map[uint64]bool{playerA: true, playerC: true}
+	var kills []model.RawKill
+	var rounds []model.RawRound
+	for n, winner := range []model.Team{model.TeamCT, model.TeamT, model.TeamCT} {
+		rn := n + 1
+		kills = append(kills, model.RawKill{
+			Tick: rn*20000 + 1000, RoundNumber: rn,
+			KillerSteamID: playerA, VictimSteamID: playerB,
+			KillerTeam: model.TeamT, VictimTeam: model.TeamCT,
+		})
+		r := makeRound(rn, rn*20000+500, ids, alive)
+		r.WinnerTeam = winner
+		rounds = append(rounds, r)
+	}
+	raw := makeRaw(kills, rounds)
+	// playerC hurts playerB in round 3 only: round 1 is a save in a lost
+	// round, round 2 is won.
+	raw.Damages = []model.RawDamage{{
+		Tick: 3*20000 + 900, RoundNumber: 3,
+		AttackerSteamID: playerC, VictimSteamID: playerB,
+		AttackerTeam: model.TeamT,
+		HealthDamage: 20, Weapon: "ak47", HitGroup: "chest",
+	}}
+
+	matchStats, _, _, _, err := Aggregate(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[uint64][2]int{playerA: {3, 3}, playerC: {3, 2}}
+	found := 0
+	for _, ms := range matchStats {
+		w, ok := want[ms.SteamID]
+		if !ok {
+			continue
+		}
+		found++
+		if ms.KASTRounds != w[0] || ms.KASTStrictRounds != w[1] {
+			t.Errorf("player %d: KAST rounds standard/strict = %d/%d, want %d/%d",
+				ms.SteamID, ms.KASTRounds, ms.KASTStrictRounds, w[0], w[1])
+		}
+	}
+	if found != len(want) {
+		t.Errorf("found %d of %d players in match stats", found, len(want))
+	}
+}
+
 // TestOpeningKill: first kill after freezeEndTick is the opening kill.
 func TestOpeningKill(t *testing.T) {
 	// k0 happens before freeze end — should not count.
@@ -294,7 +348,8 @@ func TestOpeningKill(t *testing.T) {
 
 // TestCrosshairAggregation: first-sight events are aggregated into median and pct-under-5.
 func TestCrosshairAggregation(t *testing.T) {
-	round := makeRound(1, 500, []uint64{playerA, playerB}, map[uint64]bool{playerA: true})
+	// B survives: an enemy with no events who is never alive is a coach slot.
+	round := makeRound(1, 500, []uint64{playerA, playerB}, map[uint64]bool{playerA: true, playerB: true})
 	raw := makeRaw(nil, []model.RawRound{round})
 	// Two first-sight events for playerA: 3° and 7° → median = 5.0, 50% under 5°.
 	raw.FirstSights = []model.RawFirstSight{
@@ -417,6 +472,8 @@ func TestWeaponBucket(t *testing.T) {
 	}{
 		{"AK-47", "AK"},
 		{"M4A1-S", "M4"},
+		{"M4A1", "M4"},
+		{"MP9", "Other"},
 		{"M4A4", "M4"},
 		{"Galil AR", "Galil"},
 		{"FAMAS", "FAMAS"},
@@ -438,6 +495,42 @@ func TestWeaponBucket(t *testing.T) {
 	}
 }
 
+// TestWeaponBucketsCoverDemoinfocs: every weapon name demoinfocs produces has
+// an explicit bucket. On failure, add the names to weaponBuckets.
+func TestWeaponBucketsCoverDemoinfocs(t *testing.T) {
+	for _, name := range demoinfocsWeaponNames {
+		if _, ok := weaponBuckets[name]; !ok {
+			t.Errorf("demoinfocs weapon %q has no entry in weaponBuckets", name)
+		}
+	}
+}
+
+// TestUnmappedWeapons: events with weapons missing from the bucket table are
+// counted per weapon; known weapons and weaponless events are not.
+func TestUnmappedWeapons(t *testing.T) {
+	raw := makeRaw([]model.RawKill{
+		{Weapon: "AK-47"},
+		{Weapon: "CWeaponNewRifle"},
+	}, nil)
+	raw.Damages = []model.RawDamage{
+		{Weapon: "CWeaponNewRifle"},
+		{Weapon: "UNKNOWN"},
+		{Weapon: ""},
+	}
+	raw.WeaponFires = []model.RawWeaponFire{
+		{Weapon: "CWeaponNewRifle"},
+		{Weapon: "M4A1"},
+	}
+	got := UnmappedWeapons(raw)
+	want := []model.UnmappedWeapon{
+		{DemoHash: "testhash", Weapon: "CWeaponNewRifle", Events: 3},
+		{DemoHash: "testhash", Weapon: "UNKNOWN", Events: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UnmappedWeapons: want %+v, got %+v", want, got)
+	}
+}
+
 // TestDistanceBin: distance values map to correct bins, including edge cases.
 func TestDistanceBin(t *testing.T) {
 	cases := []struct {
@@ -530,6 +623,159 @@ func TestFHHSSegment(t *testing.T) {
 	}
 }
 
+// TestFHHSMovementSplit: first hits are split by the killer's horizontal
+// speed at the first shot in the duel window (≤ 34 u/s = still); a duel with
+// no weapon fire in the window lands in neither bucket.
+func TestFHHSMovementSplit(t *testing.T) {
+	type duel struct {
+		speed    float64
+		hitGroup string
+		fired    bool
+	}
+	duels := []duel{
+		{speed: 10, hitGroup: "head", fired: true},
+		{speed: 34, hitGroup: "chest", fired: true},
+		{speed: 180, hitGroup: "head", fired: true},
+		{speed: 220, hitGroup: "chest", fired: true},
+		{speed: 220, hitGroup: "chest", fired: true},
+		{hitGroup: "head"},
+	}
+	var kills []model.RawKill
+	var rounds []model.RawRound
+	var damages []model.RawDamage
+	var fires []model.RawWeaponFire
+	var sights []model.RawFirstSight
+	for i, d := range duels {
+		rn := i + 1
+		base := rn * 1000
+		kills = append(kills, model.RawKill{Tick: base + 100, RoundNumber: rn,
+			KillerSteamID: playerA, VictimSteamID: playerB,
+			KillerTeam: model.TeamT, VictimTeam: model.TeamCT, Weapon: "AK-47"})
+		rounds = append(rounds, makeRound(rn, base-500, []uint64{playerA, playerB}, map[uint64]bool{playerA: true}))
+		damages = append(damages, model.RawDamage{Tick: base + 60, RoundNumber: rn,
+			AttackerSteamID: playerA, VictimSteamID: playerB, AttackerTeam: model.TeamT,
+			HealthDamage: 100, Weapon: "AK-47", HitGroup: d.hitGroup})
+		if d.fired {
+			fires = append(fires, model.RawWeaponFire{Tick: base + 50, RoundNumber: rn,
+				ShooterID: playerA, Weapon: "AK-47", HorizontalSpeed: d.speed})
+		}
+		sights = append(sights, model.RawFirstSight{Tick: base, RoundNumber: rn,
+			ObserverID: playerA, EnemyID: playerB})
+	}
+	raw := makeRaw(kills, rounds)
+	raw.Damages = damages
+	raw.WeaponFires = fires
+	raw.FirstSights = sights
+
+	_, _, _, segs, err := Aggregate(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var hits, stillHits, stillHS, movingHits, movingHS int
+	for _, s := range segs {
+		if s.SteamID != playerA {
+			continue
+		}
+		hits += s.FirstHitCount
+		stillHits += s.StillFirstHits
+		stillHS += s.StillFirstHitHS
+		movingHits += s.MovingFirstHits
+		movingHS += s.MovingFirstHitHS
+	}
+	if hits != 6 {
+		t.Errorf("FirstHitCount = %d, want 6", hits)
+	}
+	if stillHits != 2 || stillHS != 1 {
+		t.Errorf("still first hits = %d (%d HS), want 2 (1 HS)", stillHits, stillHS)
+	}
+	if movingHits != 3 || movingHS != 1 {
+		t.Errorf("moving first hits = %d (%d HS), want 3 (1 HS)", movingHits, movingHS)
+	}
+}
+
+// TestDuelDistances: the TestFHHSSegment duel (1000 units ≈ 19.05m) is counted
+// at 19m with its first-hit headshot; a kill with no prior sight is not a duel.
+func TestDuelDistances(t *testing.T) {
+	kills := []model.RawKill{
+		{Tick: 1100, RoundNumber: 1, KillerSteamID: playerA, VictimSteamID: playerB,
+			KillerTeam: model.TeamT, VictimTeam: model.TeamCT, Weapon: "AK-47", IsHeadshot: true},
+		{Tick: 2100, RoundNumber: 2, KillerSteamID: playerA, VictimSteamID: playerB,
+			KillerTeam: model.TeamT, VictimTeam: model.TeamCT, Weapon: "AK-47"},
+	}
+	raw := makeRaw(kills, []model.RawRound{
+		makeRound(1, 500, []uint64{playerA, playerB}, map[uint64]bool{playerA: true}),
+		makeRound(2, 1500, []uint64{playerA, playerB}, map[uint64]bool{playerA: true}),
+	})
+	raw.Damages = []model.RawDamage{
+		{Tick: 1060, RoundNumber: 1, AttackerSteamID: playerA, VictimSteamID: playerB,
+			HealthDamage: 100, Weapon: "AK-47", HitGroup: "head", VictimPos: model.Vec3{X: 1000}},
+		{Tick: 2060, RoundNumber: 2, AttackerSteamID: playerA, VictimSteamID: playerB,
+			HealthDamage: 100, Weapon: "AK-47", HitGroup: "chest", VictimPos: model.Vec3{X: 200}},
+	}
+	raw.WeaponFires = []model.RawWeaponFire{
+		{Tick: 1050, RoundNumber: 1, ShooterID: playerA, Weapon: "AK-47"},
+		{Tick: 2050, RoundNumber: 2, ShooterID: playerA, Weapon: "AK-47"},
+	}
+	raw.FirstSights = []model.RawFirstSight{
+		{Tick: 1000, RoundNumber: 1, ObserverID: playerA, EnemyID: playerB},
+	}
+
+	got := DuelDistances(raw)
+	want := []model.PlayerDuelDistance{{DemoHash: "testhash", SteamID: playerA, WeaponBucket: "AK",
+		Meters: 19, DuelCount: 1, FirstHitCount: 1, FirstHitHSCount: 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DuelDistances = %+v, want %+v", got, want)
+	}
+}
+
+// TestQuantileBins: edges split a bucket's pooled duels into equal-sized
+// bins; sparse buckets get no edges and keep the fixed bins.
+func TestQuantileBins(t *testing.T) {
+	var base []model.PlayerDuelDistance
+	for m := 20; m < 40; m++ {
+		base = append(base, model.PlayerDuelDistance{WeaponBucket: "AWP", Meters: m, DuelCount: 10})
+	}
+	base = append(base, model.PlayerDuelDistance{WeaponBucket: "AK", Meters: 12, DuelCount: 5})
+
+	edges := QuantileEdges(base, 4, 100)
+	if want := []int{25, 30, 35}; !reflect.DeepEqual(edges["AWP"], want) {
+		t.Errorf("AWP edges = %v, want %v", edges["AWP"], want)
+	}
+	if _, ok := edges["AK"]; ok {
+		t.Errorf("AK edges = %v, want none (5 duels < 100)", edges["AK"])
+	}
+
+	rows := []model.PlayerDuelDistance{
+		{SteamID: playerA, WeaponBucket: "AWP", Meters: 22, DuelCount: 2, FirstHitCount: 2, FirstHitHSCount: 1},
+		{SteamID: playerA, WeaponBucket: "AWP", Meters: 24, DuelCount: 1, FirstHitCount: 1},
+		{SteamID: playerA, WeaponBucket: "AWP", Meters: 50, DuelCount: 1, FirstHitCount: 1, FirstHitHSCount: 1},
+		{SteamID: playerA, WeaponBucket: "AK", Meters: 12, DuelCount: 3, FirstHitCount: 3, FirstHitHSCount: 2},
+		{SteamID: playerB, WeaponBucket: "AK", Meters: 12, DuelCount: 9, FirstHitCount: 9},
+	}
+	got := make(map[string]model.PlayerDuelSegment)
+	for _, s := range QuantileSegments(playerA, rows, edges) {
+		got[s.WeaponBucket+" "+s.DistanceBin] = s
+	}
+	want := map[string][3]int{
+		"AWP 0-25m": {3, 3, 1},
+		"AWP 35m+":  {1, 1, 1},
+		"AK 10-15m": {3, 3, 2},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("segments = %+v, want keys %v", got, want)
+	}
+	for k, w := range want {
+		s, ok := got[k]
+		if !ok {
+			t.Errorf("segment %q missing; got %+v", k, got)
+			continue
+		}
+		if c := [3]int{s.DuelCount, s.FirstHitCount, s.FirstHitHSCount}; c != w {
+			t.Errorf("segment %q duels/hits/HS = %v, want %v", k, c, w)
+		}
+	}
+}
+
 // TestADR_Basic: damage is correctly rolled into ADR.
 func TestADR_Basic(t *testing.T) {
 	k1 := model.RawKill{
@@ -564,3 +810,2123 @@ func TestADR_Basic(t *testing.T) {
 		}
 	}
 }
+
+// ---- Low-HP enemies not finished ----
+
+func TestLowHPEnemiesNotFinished(t *testing.T) {
+	// Round 1: A drops B to 10 HP, teammate C finishes B → handed.
+	// Round 2: A drops B to 5 HP, B survives → wasted.
+	// Round 3: A drops B to 15 HP then kills B himself → neither.
+	// Round 4: A drops B to 40 HP, C kills B → not low enough, neither.
+	k1 := model.RawKill{Tick: 1100, RoundNumber: 1, KillerSteamID: playerC, VictimSteamID: playerB,
+		KillerTeam: model.TeamT, VictimTeam: model.TeamCT}
+	k3 := model.RawKill{Tick: 3100, RoundNumber: 3, KillerSteamID: playerA, VictimSteamID: playerB,
+		KillerTeam: model.TeamT, VictimTeam: model.TeamCT}
+	k4 := model.RawKill{Tick: 4100, RoundNumber: 4, KillerSteamID: playerC, VictimSteamID: playerB,
+		KillerTeam: model.TeamT, VictimTeam: model.TeamCT}
+	ids := []uint64{playerA, playerB, playerC}
+	rounds := []model.RawRound{
+		makeRound(1, 500, ids, map[uint64]bool{playerA: true, playerC: true}),
+		makeRound(2, 500, ids, map[uint64]bool{playerA: true, playerB: true, playerC: true}),
+		makeRound(3, 500, ids, map[uint64]bool{playerA: true, playerC: true}),
+		makeRound(4, 500, ids, map[uint64]bool{playerA: true, playerC: true}),
+	}
+	raw := makeRaw([]model.RawKill{k1, k3, k4}, rounds)
+	hit := func(tick, round, hpLeft int) model.RawDamage {
+		return model.RawDamage{Tick: tick, RoundNumber: round, AttackerSteamID: playerA, VictimSteamID: playerB,
+			AttackerTeam: model.TeamT, VictimTeam: model.TeamCT, HealthDamage: 100 - hpLeft, VictimHealth: hpLeft}
+	}
+	raw.Damages = []model.RawDamage{hit(1000, 1, 10), hit(2000, 2, 5), hit(3000, 3, 15), hit(4000, 4, 40)}
+
+	matchStats, _, _, _, err := Aggregate(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, ms := range matchStats {
+		if ms.SteamID != playerA {
+			continue
+		}
+		if ms.LowHPHanded != 1 {
+			t.Errorf("LowHPHanded: want 1, got %d", ms.LowHPHanded)
+		}
+		if ms.LowHPWasted != 1 {
+			t.Errorf("LowHPWasted: want 1, got %d", ms.LowHPWasted)
+		}
+		return
+	}
+	t.Fatal("playerA not found in matchStats")
+}
+
+func TestSpottedBeforeDeath(t *testing.T) {
+	// Round 1: C spots B 256 ticks before A kills him (A's own sighting is later) → 4000ms.
+	// Round 2: nobody spots B before the kill → excluded.
+	// Round 3: A spots B 64 ticks before the kill → 1000ms.
+	// Median of {1000, 4000} = 2500ms.
+	kill := func(tick, round int) model.RawKill {
+		return model.RawKill{Tick: tick, RoundNumber: round, KillerSteamID: playerA, VictimSteamID: playerB,
+			KillerTeam: model.TeamT, VictimTeam: model.TeamCT}
+	}
+	ids := []uint64{playerA, playerB, playerC}
+	rounds := []model.RawRound{
+		makeRound(1, 500, ids, map[uint64]bool{playerA: true, playerC: true}),
+		makeRound(2, 500, ids, map[uint64]bool{playerA: true, playerC: true}),
+		makeRound(3, 500, ids, map[uint64]bool{playerA: true, playerC: true}),
+	}
+	raw := makeRaw([]model.RawKill{kill(1300, 1), kill(2300, 2), kill(3300, 3)}, rounds)
+	raw.FirstSights = []model.RawFirstSight{
+		{Tick: 1044, RoundNumber: 1, ObserverID: playerC, EnemyID: playerB},
+		{Tick: 1200, RoundNumber: 1, ObserverID: playerA, EnemyID: playerB},
+		{Tick: 2400, RoundNumber: 2, ObserverID: playerA, EnemyID: playerB}, // after the kill
+		{Tick: 3236, RoundNumber: 3, ObserverID: playerA, EnemyID: playerB},
+	}
+
+	matchStats, _, _, _, err := Aggregate(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, ms := range matchStats {
+		if ms.SteamID != playerB {
+			continue
+		}
+		if ms.MedianSpottedBeforeDeathMs != 2500 {
+			t.Errorf("MedianSpottedBeforeDeathMs: want 2500, got %.1f", ms.MedianSpottedBeforeDeathMs)
+		}
+		return
+	}
+	t.Fatal("playerB not found in matchStats")
+}
+
+func TestObjectivePlay(t *testing.T) {
+	// Round 1: A (T) is killed mid-plant by B (CT) → plant denial for B.
+	// Round 2: B defuses with A alive nearby but never spotted → ninja defuse.
+	// Round 3: B defuses while A had him spotted → plain defuse.
+	denial := model.RawKill{Tick: 1100, RoundNumber: 1, KillerSteamID: playerB, VictimSteamID: playerA,
+		KillerTeam: model.TeamCT, VictimTeam: model.TeamT, VictimPlanting: true}
+	ids := []uint64{playerA, playerB}
+	rounds := []model.RawRound{
+		makeRound(1, 500, ids, map[uint64]bool{playerB: true}),
+		makeRound(2, 500, ids, map[uint64]bool{playerA: true, playerB: true}),
+		makeRound(3, 500, ids, map[uint64]bool{playerA: true, playerB: true}),
+	}
+	raw := makeRaw([]model.RawKill{denial}, rounds)
+	raw.Defuses = []model.RawDefuse{
+		{Tick: 2100, RoundNumber: 2, DefuserID: playerB, EnemiesAlive: 1, NearbyEnemies: 1},
+		{Tick: 3100, RoundNumber: 3, DefuserID: playerB, EnemiesAlive: 1, NearbyEnemies: 1, Spotted: true},
+	}
+
+	matchStats, _, _, _, err := Aggregate(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, ms := range matchStats {
+		if ms.SteamID != playerB {
+			continue
+		}
+		if ms.Defuses != 2 || ms.NinjaDefuses != 1 || ms.PlantDenials != 1 {
+			t.Errorf("defuses/ninja/denials: want 2/1/1, got %d/%d/%d", ms.Defuses, ms.NinjaDefuses, ms.PlantDenials)
+		}
+		return
+	}
+	t.Fatal("playerB not found in matchStats")
+}
+
+func TestMovingDeaths(t *testing.T) {
+	// Round 1: A's first hit on B (within 3s) lands while B moves at 200 u/s → moving death.
+	//          An earlier hit outside the window (B standing) is ignored.
+	// Round 2: A's first hit lands while B is at 10 u/s → sampled, not moving.
+	// Round 3: B dies with no hit from the killer in the window → not sampled.
+	kill := func(tick, round int) model.RawKill {
+		return model.RawKill{Tick: tick, RoundNumber: round, KillerSteamID: playerA, VictimSteamID: playerB,
+			KillerTeam: model.TeamT, VictimTeam: model.TeamCT, Weapon: "AK-47"}
+	}
+	hit := func(tick, round int, speed float64) model.RawDamage {
+		return model.RawDamage{Tick: tick, RoundNumber: round, AttackerSteamID: playerA, VictimSteamID: playerB,
+			AttackerTeam: model.TeamT, VictimTeam: model.TeamCT, HealthDamage: 30, Weapon: "AK-47", VictimSpeed: speed}
+	}
+	ids := []uint64{playerA, playerB}
+	rounds := []model.RawRound{
+		makeRound(1, 500, ids, map[uint64]bool{playerA: true}),
+		makeRound(2, 500, ids, map[uint64]bool{playerA: true}),
+		makeRound(3, 500, ids, map[uint64]bool{playerA: true}),
+	}
+	raw := makeRaw([]model.RawKill{kill(1500, 1), kill(2500, 2), kill(3500, 3)}, rounds)
+	raw.Damages = []model.RawDamage{
+		hit(1000, 1, 0), // > 3s (192 ticks) before the kill
+		hit(1400, 1, 200),
+		hit(1450, 1, 0),
+		hit(2400, 2, 10),
+	}
+
+	matchStats, _, _, _, err := Aggregate(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, ms := range matchStats {
+		if ms.SteamID != playerB {
+			continue
+		}
+		if ms.DeathSpeedSamples != 2 || ms.MovingDeaths != 1 {
+			t.Errorf("DeathSpeedSamples/MovingDeaths: want 2/1, got %d/%d", ms.DeathSpeedSamples, ms.MovingDeaths)
+		}
+		return
+	}
+	t.Fatal("playerB not found in matchStats")
+}
+
+func TestMultiKills(t *testing.T) {
+	// Round 1: one AWP shot kills B and, a tick later, C → collateral.
+	// Round 2: A sprays the AK: kills B, keeps firing and kills C (spotted before
+	//          B died) → spray transfer; then kills D, first spotted only after
+	//          C died → re-sighted, not a transfer.
+	// Round 3: kills 200 ticks apart (> 1.5s) → neither.
+	kill := func(tick, round int, victim uint64, weapon string) model.RawKill {
+		return model.RawKill{Tick: tick, RoundNumber: round, KillerSteamID: playerA, VictimSteamID: victim,
+			KillerTeam: model.TeamT, VictimTeam: model.TeamCT, Weapon: weapon}
+	}
+	fire := func(tick, round int, weapon string) model.RawWeaponFire {
+		return model.RawWeaponFire{Tick: tick, RoundNumber: round, ShooterID: playerA, Weapon: weapon}
+	}
+	sight := func(tick, round int, enemy uint64) model.RawFirstSight {
+		return model.RawFirstSight{Tick: tick, RoundNumber: round, ObserverID: playerA, EnemyID: enemy}
+	}
+	ids := []uint64{playerA, playerB, playerC, playerD}
+	alive := map[uint64]bool{playerA: true}
+	raw := makeRaw([]model.RawKill{
+		kill(1000, 1, playerB, "AWP"), kill(1001, 1, playerC, "AWP"),
+		kill(2010, 2, playerB, "AK-47"), kill(2050, 2, playerC, "AK-47"), kill(2080, 2, playerD, "AK-47"),
+		kill(3000, 3, playerB, "AK-47"), kill(3200, 3, playerC, "AK-47"),
+	}, []model.RawRound{makeRound(1, 500, ids, alive), makeRound(2, 500, ids, alive), makeRound(3, 500, ids, alive)})
+	raw.WeaponFires = []model.RawWeaponFire{fire(1000, 1, "AWP")}
+	for tick := 2000; tick <= 2080; tick += 10 {
+		raw.WeaponFires = append(raw.WeaponFires, fire(tick, 2, "AK-47"))
+	}
+	raw.WeaponFires = append(raw.WeaponFires, fire(3000, 3, "AK-47"), fire(3100, 3, "AK-47"), fire(3200, 3, "AK-47"))
+	raw.FirstSights = []model.RawFirstSight{
+		sight(1990, 2, playerB), sight(1995, 2, playerC), sight(2060, 2, playerD),
+		sight(2990, 3, playerB), sight(2995, 3, playerC),
+	}
+
+	matchStats, _, _, _, err := Aggregate(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, ms := range matchStats {
+		if ms.SteamID != playerA {
+			continue
+		}
+		if ms.CollateralKills != 1 || ms.SprayTransferKills != 1 {
+			t.Errorf("CollateralKills/SprayTransferKills: want 1/1, got %d/%d", ms.CollateralKills, ms.SprayTransferKills)
+		}
+		return
+	}
+	t.Fatal("playerA not found in matchStats")
+}
+
+func TestKillStatesAndWPA(t *testing.T) {
+	// Round 1 (2v2, CT win): A (CT) kills C, then C's teammate D kills B, then
+	// A kills D. A kill after the round ended is ignored.
+	endState := map[uint64]model.PlayerRoundEndState{
+		playerA: {SteamID64: playerA, IsAlive: true, Team: model.TeamCT},
+		playerB: {SteamID64: playerB, Team: model.TeamCT},
+		playerC: {SteamID64: playerC, Team: model.TeamT},
+		playerD: {SteamID64: playerD, Team: model.TeamT},
+	}
+	kill := func(tick int, killer, victim uint64, kt, vt model.Team) model.RawKill {
+		return model.RawKill{Tick: tick, RoundNumber: 1, KillerSteamID: killer, VictimSteamID: victim,
+			KillerTeam: kt, VictimTeam: vt, Weapon: "AK-47"}
+	}
+	raw := makeRaw([]model.RawKill{
+		kill(1000, playerA, playerC, model.TeamCT, model.TeamT),
+		kill(1200, playerD, playerB, model.TeamT, model.TeamCT),
+		kill(1400, playerA, playerD, model.TeamCT, model.TeamT),
+		kill(2000, playerA, playerD, model.TeamCT, model.TeamT),
+	}, []model.RawRound{{Number: 1, FreezeEndTick: 500, EndTick: 1500, WinnerTeam: model.TeamCT,
+		PlayerEndState: endState, BombPlantTick: 1100}})
+
+	states := KillStates(raw)
+	if len(states) != 3 {
+		t.Fatalf("expected 3 kill states, got %d", len(states))
+	}
+	if s := states[0]; s.CTAlive != 2 || s.TAlive != 2 || s.BombPlanted {
+		t.Errorf("first kill state = %+v, want 2v2 unplanted", s)
+	}
+	if s := states[2]; s.CTAlive != 1 || s.TAlive != 1 || !s.BombPlanted {
+		t.Errorf("last kill state = %+v, want 1v1 planted", s)
+	}
+
+	// With an empty table every state sits at the prior CT/(CT+T):
+	// 2v2 → 2v1 is 0.5 → 2/3 (+1/6 to A), 2v1 planted → 1v1 planted is 2/3 → 0.5
+	// (+1/6 to D), and 1v1 → 1v0 planted is an unseen state, prior 1 (+0.5 to A).
+	wpa := BuildWinProbTable(nil).KillWPA(states)
+	approx := func(got, want float64) bool { return math.Abs(got-want) < 1e-9 }
+	if !approx(wpa[playerA], 1.0/6+0.5) {
+		t.Errorf("WPA(A) = %.4f, want %.4f", wpa[playerA], 1.0/6+0.5)
+	}
+	if !approx(wpa[playerD], 1.0/6-0.5) {
+		t.Errorf("WPA(D) = %.4f, want %.4f", wpa[playerD], 1.0/6-0.5)
+	}
+
+	// Fitting on the round itself pulls every state it passed through toward a CT win.
+	tbl := BuildWinProbTable(states)
+	if p := tbl.CTWinProb(1, 1, true); p <= 0.5 {
+		t.Errorf("fitted P(CT | 1v1 planted) = %.3f, want > 0.5", p)
+	}
+	if p := tbl.CTWinProb(0, 3, false); p != 0 {
+		t.Errorf("P(CT | CT eliminated) = %.3f, want 0", p)
+	}
+}
+
+func TestRoundEndReason(t *testing.T) {
+	// Round won by T (makeRound default) via bomb: the killer's team (T) wins,
+	// the victim's (CT) loses, and both rows carry the end reason.
+	round := makeRound(1, 500, []uint64{playerA, playerB}, map[uint64]bool{playerA: true})
+	round.EndReason = model.EndReasonBomb
+	round.PlayerEndState[playerB] = model.PlayerRoundEndState{SteamID64: playerB, Team: model.TeamCT}
+	raw := makeRaw([]model.RawKill{{Tick: 1000, RoundNumber: 1, KillerSteamID: playerA, VictimSteamID: playerB,
+		KillerTeam: model.TeamT, VictimTeam: model.TeamCT, Weapon: "AK-47"}}, []model.RawRound{round})
+
+	_, roundStats, _, _, err := Aggregate(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(roundStats) != 2 {
+		t.Fatalf("expected 2 round rows, got %d", len(roundStats))
+	}
+	for _, rs := range roundStats {
+		if rs.EndReason != model.EndReasonBomb {
+			t.Errorf("player %d: EndReason = %q, want %q", rs.SteamID, rs.EndReason, model.EndReasonBomb)
+		}
+		if wantWon := rs.SteamID == playerA; rs.WonRound != wantWon {
+			t.Errorf("player %d: WonRound = %v, want %v", rs.SteamID, rs.WonRound, wantWon)
+		}
+	}
+}
+
+func TestRoundDeaths(t *testing.T) {
+	// Freeze ends at tick 500; B dies 640 ticks (10s) later and A survives.
+	round := makeRound(1, 500, []uint64{playerA, playerB}, map[uint64]bool{playerA: true})
+	raw := makeRaw([]model.RawKill{{Tick: 1140, RoundNumber: 1, KillerSteamID: playerA, VictimSteamID: playerB,
+		KillerTeam: model.TeamT, VictimTeam: model.TeamCT, Weapon: "AK-47"}}, []model.RawRound{round})
+
+	_, roundStats, _, _, err := Aggregate(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, rs := range roundStats {
+		switch rs.SteamID {
+		case playerA:
+			if rs.Deaths != 0 || rs.DeathTick != 0 || rs.DeathSec != 0 {
+				t.Errorf("A: Deaths/DeathTick/DeathSec = %d/%d/%v, want 0/0/0", rs.Deaths, rs.DeathTick, rs.DeathSec)
+			}
+		case playerB:
+			if rs.Deaths != 1 || rs.DeathTick != 1140 || rs.DeathSec != 10 {
+				t.Errorf("B: Deaths/DeathTick/DeathSec = %d/%d/%v, want 1/1140/10", rs.Deaths, rs.DeathTick, rs.DeathSec)
+			}
+		}
+	}
+}
+
+func TestMetricRegistry(t *testing.T) {
+	metrics := Metrics()
+	if len(metrics) == 0 {
+		t.Fatal("empty metric registry")
+	}
+	// Every version after 1 (which only introduced the stamp) must be
+	// explained by a metric it introduced or changed.
+	explained := make(map[int]bool)
+	seen := make(map[string]bool)
+	for _, m := range metrics {
+		if m.Name == "" || m.Group == "" || m.Definition == "" || len(m.Columns) == 0 {
+			t.Errorf("%q: name, group, definition and columns are required", m.Name)
+		}
+		if seen[m.Name] {
+			t.Errorf("%q: duplicate metric", m.Name)
+		}
+		seen[m.Name] = true
+		if m.Since < 0 || m.Since > PipelineVersion {
+			t.Errorf("%q: since v%d outside 0..v%d", m.Name, m.Since, PipelineVersion)
+		}
+		explained[m.Since] = true
+		prev := m.Since
+		for _, c := range m.Changes {
+			if c.Version <= prev || c.Version > PipelineVersion || c.Note == "" {
+				t.Errorf("%q: change v%d must follow v%d, be ≤ v%d and have a note", m.Name, c.Version, prev, PipelineVersion)
+			}
+			explained[c.Version] = true
+			prev = c.Version
+		}
+	}
+	for v := 2; v <= PipelineVersion; v++ {
+		if !explained[v] {
+			t.Errorf("pipeline v%d has no metric introduced or changed in the registry", v)
+		}
+	}
+}
+
+func TestScorelineSplits(t *testing.T) {
+	// A starts CT, B starts T; they swap sides at round 3. Results for A's
+	// team: W, L, W, none, W.
+	//   round 1: 0-0 tied, A's team wins
+	//   round 2: A leads 1-0 (B trails), B's team wins → 1-1; A kills B
+	//   round 3: tied after the swap, A's team (now T) wins → 2-1
+	//   round 4: no winner, not counted
+	//   round 5: A leads 2-1; A deals 50 damage to B
+	setTeams := func(r model.RawRound, a, b model.Team, winner model.Team) model.RawRound {
+		ea, eb := r.PlayerEndState[playerA], r.PlayerEndState[playerB]
+		ea.Team, eb.Team = a, b
+		r.PlayerEndState[playerA], r.PlayerEndState[playerB] = ea, eb
+		r.WinnerTeam = winner
+		return r
+	}
+	ids := []uint64{playerA, playerB}
+	alive := map[uint64]bool{playerA: true, playerB: true}
+	rounds := []model.RawRound{
+		setTeams(makeRound(1, 100, ids, alive), model.TeamCT, model.TeamT, model.TeamCT),
+		setTeams(makeRound(2, 20100, ids, alive), model.TeamCT, model.TeamT, model.TeamT),
+		setTeams(makeRound(3, 40100, ids, alive), model.TeamT, model.TeamCT, model.TeamT),
+		setTeams(makeRound(4, 60100, ids, alive), model.TeamT, model.TeamCT, model.TeamUnknown),
+		setTeams(makeRound(5, 80100, ids, alive), model.TeamT, model.TeamCT, model.TeamCT),
+	}
+	raw := makeRaw([]model.RawKill{
+		{Tick: 20500, RoundNumber: 2, KillerSteamID: playerA, VictimSteamID: playerB,
+			KillerTeam: model.TeamCT, VictimTeam: model.TeamT, Weapon: "AK-47"},
+	}, rounds)
+	raw.Damages = []model.RawDamage{
+		{Tick: 80500, RoundNumber: 5, AttackerSteamID: playerA, VictimSteamID: playerB,
+			AttackerTeam: model.TeamT, VictimTeam: model.TeamCT, HealthDamage: 50, VictimHealth: 50, Weapon: "AK-47"},
+	}
+
+	stats, _, _, _, err := Aggregate(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := make(map[uint64]model.PlayerMatchStats)
+	for _, s := range stats {
+		got[s.SteamID] = s
+	}
+	a, b := got[playerA], got[playerB]
+	if want := (model.ScorelineSplit{Rounds: 2, Kills: 1, Damage: 50}); a.Leading != want {
+		t.Errorf("A leading = %+v, want %+v", a.Leading, want)
+	}
+	if want := (model.ScorelineSplit{Rounds: 2}); a.Tied != want || b.Tied != want {
+		t.Errorf("tied = A %+v, B %+v, want %+v each", a.Tied, b.Tied, want)
+	}
+	if a.Trailing.Rounds != 0 || b.Leading.Rounds != 0 {
+		t.Errorf("A trailing %+v, B leading %+v, want none", a.Trailing, b.Leading)
+	}
+	if want := (model.ScorelineSplit{Rounds: 2, Deaths: 1}); b.Trailing != want {
+		t.Errorf("B trailing = %+v, want %+v", b.Trailing, want)
+	}
+}
+
+func TestSuppressSpectators(t *testing.T) {
+	// A kills B every round. C (coach) is on T but never alive; D sits on
+	// the spectator team; E is named but in no end state; F is an AFK player
+	// alive at round end and kept; G dies every round without shooting and
+	// is kept because a death is an event.
+	const (
+		coach    uint64 = 2001
+		spec     uint64 = 2002
+		ghost    uint64 = 2003
+		afk      uint64 = 2004
+		deadOnly uint64 = 2005
+	)
+	var rounds []model.RawRound
+	var kills []model.RawKill
+	for n := 1; n <= 3; n++ {
+		r := makeRound(n, n*10000, []uint64{playerA, playerB, coach, spec, afk, deadOnly}, map[uint64]bool{playerA: true, spec: true, afk: true})
+		es := r.PlayerEndState[spec]
+		es.Team = model.TeamSpectators
+		r.PlayerEndState[spec] = es
+		r.PlayerEquipValues = map[uint64]int{playerA: 4000, coach: 200}
+		rounds = append(rounds, r)
+		kills = append(kills,
+			model.RawKill{Tick: n*10000 + 100, RoundNumber: n, KillerSteamID: playerA, VictimSteamID: playerB, KillerTeam: model.TeamT, VictimTeam: model.TeamCT, Weapon: "AK-47"},
+			model.RawKill{Tick: n*10000 + 200, RoundNumber: n, KillerSteamID: playerA, VictimSteamID: deadOnly, KillerTeam: model.TeamT, VictimTeam: model.TeamCT, Weapon: "AK-47"})
+	}
+	raw := makeRaw(kills, rounds)
+	for id, name := range map[uint64]string{coach: "Coach", spec: "Caster", ghost: "Ghost", afk: "AFK"} {
+		raw.PlayerNames[id] = name
+	}
+	raw.FirstSights = []model.RawFirstSight{
+		{Tick: 10050, RoundNumber: 1, ObserverID: playerA, EnemyID: playerB},
+		{Tick: 10060, RoundNumber: 1, ObserverID: playerA, EnemyID: coach},
+	}
+
+	stats, _, _, _, err := Aggregate(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []model.SuppressedAccount{
+		{SteamID: coach, Name: "Coach", Reason: model.SuppressedNeverAlive, Rounds: 3},
+		{SteamID: spec, Name: "Caster", Reason: model.SuppressedSpectator, Rounds: 3},
+		{SteamID: ghost, Name: "Ghost", Reason: model.SuppressedNoRounds},
+	}
+	if !reflect.DeepEqual(raw.Suppressed, want) {
+		t.Errorf("Suppressed = %+v, want %+v", raw.Suppressed, want)
+	}
+	got := make(map[uint64]bool)
+	for _, s := range stats {
+		got[s.SteamID] = true
+	}
+	for _, id := range []uint64{playerA, playerB, afk, deadOnly} {
+		if !got[id] {
+			t.Errorf("player %d missing from stats", id)
+		}
+	}
+	for _, id := range []uint64{coach, spec, ghost} {
+		if got[id] {
+			t.Errorf("suppressed account %d still in stats", id)
+		}
+	}
+	if _, ok := raw.Rounds[0].PlayerEquipValues[coach]; ok || len(raw.FirstSights) != 1 {
+		t.Errorf("coach equipment value or first sight not removed: %v, %d sights", raw.Rounds[0].PlayerEquipValues, len(raw.FirstSights))
+	}
+	if again := SuppressSpectators(raw); again != nil {
+		t.Errorf("second SuppressSpectators = %+v, want nil", again)
+	}
+}
+
+func TestLoadoutEquipmentValue(t *testing.T) {
+	// A plays two rounds ($4750 then $800); B plays only round 1 with no
+	// recorded loadout. Round 2 has no B at all.
+	r1 := makeRound(1, 100, []uint64{playerA, playerB}, map[uint64]bool{playerA: true, playerB: true})
+	r1.PlayerEquipValues = map[uint64]int{playerA: 4750}
+	r2 := makeRound(2, 20100, []uint64{playerA}, map[uint64]bool{playerA: true})
+	r2.PlayerEquipValues = map[uint64]int{playerA: 800, playerB: 5000}
+	raw := makeRaw(nil, []model.RawRound{r1, r2})
+
+	stats, _, _, _, err := Aggregate(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := make(map[uint64]int)
+	for _, s := range stats {
+		got[s.SteamID] = s.EquipmentValue
+	}
+	if got[playerA] != 5550 {
+		t.Errorf("A equipment value = %d, want 5550", got[playerA])
+	}
+	if got[playerB] != 0 {
+		t.Errorf("B equipment value = %d, want 0 (round 2 not played)", got[playerB])
+	}
+}
+
+func TestPeekerDuels(t *testing.T) {
+	// A (CT) and B (T) meet once per round. Round 1: A peeks (moving) and
+	// kills B. Round 2: B holds and kills a peeking A. Round 3: both moving.
+	// Round 4: B never spotted A. Only rounds 1 and 2 count.
+	var rounds []model.RawRound
+	var kills []model.RawKill
+	var sights []model.RawFirstSight
+	speeds := [][2]float64{{200, 0}, {180, 5}, {200, 150}, {200, 0}}
+	for i, sp := range speeds {
+		n := i + 1
+		start := n * 10000
+		rounds = append(rounds, makeRound(n, start, []uint64{playerA, playerB}, map[uint64]bool{playerA: true, playerB: true}))
+		killer, victim, kt, vt := playerA, playerB, model.TeamCT, model.TeamT
+		if n == 2 {
+			killer, victim, kt, vt = playerB, playerA, model.TeamT, model.TeamCT
+		}
+		kills = append(kills, model.RawKill{Tick: start + 200, RoundNumber: n, KillerSteamID: killer, VictimSteamID: victim,
+			KillerTeam: kt, VictimTeam: vt, Weapon: "AK-47"})
+		sights = append(sights, model.RawFirstSight{Tick: start + 100, RoundNumber: n, ObserverID: playerA, EnemyID: playerB, ObserverSpeed: sp[0]})
+		if n != 4 {
+			sights = append(sights, model.RawFirstSight{Tick: start + 110, RoundNumber: n, ObserverID: playerB, EnemyID: playerA, ObserverSpeed: sp[1]})
+		}
+	}
+	raw := makeRaw(kills, rounds)
+	raw.FirstSights = sights
+
+	stats, _, _, _, err := Aggregate(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := make(map[uint64]model.PlayerMatchStats)
+	for _, s := range stats {
+		got[s.SteamID] = s
+	}
+	a, b := got[playerA], got[playerB]
+	if a.PeekDuels != 2 || a.PeekWins != 1 || a.HoldDuels != 0 || a.HoldWins != 0 {
+		t.Errorf("A peek/hold = %d/%d %d/%d, want 2/1 0/0", a.PeekDuels, a.PeekWins, a.HoldDuels, a.HoldWins)
+	}
+	if b.PeekDuels != 0 || b.PeekWins != 0 || b.HoldDuels != 2 || b.HoldWins != 1 {
+		t.Errorf("B peek/hold = %d/%d %d/%d, want 0/0 2/1", b.PeekDuels, b.PeekWins, b.HoldDuels, b.HoldWins)
+	}
+}
+
+func TestWeaponDiscipline(t *testing.T) {
+	// A (CT) kills B (T) once per round. B's loadout at death: round 1 rifle
+	// in hand, round 2 pistol with a rifle carried, round 3 knife with a
+	// rifle carried, round 4 pistol only (eco), round 5 the bomb with a rifle
+	// carried. Rounds 1, 2, 3 and 5 are primary deaths; 2 and 3 are wrong.
+	loadouts := []struct {
+		class   string
+		primary bool
+	}{
+		{model.WeaponClassPrimary, true},
+		{model.WeaponClassPistol, true},
+		{model.WeaponClassKnife, true},
+		{model.WeaponClassPistol, false},
+		{model.WeaponClassOther, true},
+	}
+	var rounds []model.RawRound
+	var kills []model.RawKill
+	for i, l := range loadouts {
+		n := i + 1
+		start := n * 10000
+		rounds = append(rounds, makeRound(n, start, []uint64{playerA, playerB}, map[uint64]bool{playerA: true, playerB: true}))
+		kills = append(kills, model.RawKill{Tick: start + 200, RoundNumber: n, KillerSteamID: playerA, VictimSteamID: playerB,
+			KillerTeam: model.TeamCT, VictimTeam: model.TeamT, Weapon: "AK-47",
+			VictimWeaponClass: l.class, VictimHasPrimary: l.primary})
+	}
+
+	stats, _, _, _, err := Aggregate(makeRaw(kills, rounds))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, s := range stats {
+		switch s.SteamID {
+		case playerA:
+			if s.PrimaryDeaths != 0 || s.WrongWeaponDeaths != 0 {
+				t.Errorf("A primary/wrong deaths = %d/%d, want 0/0", s.PrimaryDeaths, s.WrongWeaponDeaths)
+			}
+		case playerB:
+			if s.PrimaryDeaths != 4 || s.WrongWeaponDeaths != 2 {
+				t.Errorf("B primary/wrong deaths = %d/%d, want 4/2", s.PrimaryDeaths, s.WrongWeaponDeaths)
+			}
+		}
+	}
+}
+
+func TestDuelLossReasons(t *testing.T) {
+	// A and C (T) kill B (CT) once per round, 1000 ticks in.
+	// R1: a teammate near B, no utility → no factor.
+	// R2: B isolated → isolated.
+	// R3: A's own flash on B 1s before → flashed (not utility support).
+	// R4: C's flash on B 50 ticks before → flashed and utility support.
+	// R5: B kills C first, then dies with a teammate near → re-peek; C's
+	//     death is isolated (no teammate near).
+	// R6: C's flash on B 1.8s before → utility support, too early for flashed.
+	tps := int(tickRate)
+	flash := func(rn, tick int, thrower uint64) model.RawFlash {
+		return model.RawFlash{Tick: tick, RoundNumber: rn, AttackerSteamID: thrower, VictimSteamID: playerB,
+			AttackerTeam: model.TeamT, VictimTeam: model.TeamCT, FlashDuration: 2 * time.Second}
+	}
+	var rounds []model.RawRound
+	var kills []model.RawKill
+	var flashes []model.RawFlash
+	for n := 1; n <= 6; n++ {
+		start := n * 10000
+		kt := start + 1000
+		rounds = append(rounds, makeRound(n, start, []uint64{playerA, playerB, playerC}, map[uint64]bool{playerA: true}))
+		nearby := 1
+		if n == 2 {
+			nearby = 0
+		}
+		switch n {
+		case 3:
+			flashes = append(flashes, flash(n, kt-tps, playerA))
+		case 4:
+			flashes = append(flashes, flash(n, kt-50, playerC))
+		case 5:
+			kills = append(kills, model.RawKill{Tick: kt - 500, RoundNumber: n, KillerSteamID: playerB, VictimSteamID: playerC,
+				KillerTeam: model.TeamCT, VictimTeam: model.TeamT, Weapon: "M4A4"})
+		case 6:
+			flashes = append(flashes, flash(n, kt-tps*18/10, playerC))
+		}
+		kills = append(kills, model.RawKill{Tick: kt, RoundNumber: n, KillerSteamID: playerA, VictimSteamID: playerB,
+			KillerTeam: model.TeamT, VictimTeam: model.TeamCT, Weapon: "AK-47", NearbyVictimTeammates: nearby})
+	}
+	raw := makeRaw(kills, rounds)
+	raw.Flashes = flashes
+
+	stats, _, _, _, err := Aggregate(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	type reasons struct{ flashed, isolated, util, rePeek, explained int }
+	want := map[uint64]reasons{
+		playerA: {},
+		playerB: {flashed: 2, isolated: 1, util: 2, rePeek: 1, explained: 5},
+		playerC: {isolated: 1, explained: 1},
+	}
+	for _, s := range stats {
+		w, ok := want[s.SteamID]
+		if !ok {
+			continue
+		}
+		got := reasons{s.LossFlashed, s.LossIsolated, s.LossUtilSupport, s.LossRePeek, s.LossExplained}
+		if got != w {
+			t.Errorf("player %d loss reasons = %+v, want %+v", s.SteamID, got, w)
+		}
+	}
+}
+
+func TestAssistedDuels(t *testing.T) {
+	// A (CT) kills B (T) in three rounds after spotting him; teammate C (CT)
+	// hits B first each time. Round 1: 50 damage 1s before the kill
+	// (assisted). Round 2: 30 damage (below the threshold). Round 3: 50
+	// damage 6s before the kill (outside the window).
+	tps := int(tickRate)
+	type hit struct{ before, dmg int }
+	hits := []hit{{tps, 50}, {tps, 30}, {6 * tps, 50}}
+	var rounds []model.RawRound
+	var kills []model.RawKill
+	var sights []model.RawFirstSight
+	var damages []model.RawDamage
+	for i, h := range hits {
+		n := i + 1
+		start := n * 10000
+		killTick := start + 8*tps
+		rounds = append(rounds, makeRound(n, start, []uint64{playerA, playerB, playerC}, map[uint64]bool{playerA: true, playerC: true}))
+		kills = append(kills, model.RawKill{Tick: killTick, RoundNumber: n, KillerSteamID: playerA, VictimSteamID: playerB,
+			KillerTeam: model.TeamCT, VictimTeam: model.TeamT, Weapon: "AK-47"})
+		sights = append(sights, model.RawFirstSight{Tick: killTick - 50, RoundNumber: n, ObserverID: playerA, EnemyID: playerB})
+		damages = append(damages, model.RawDamage{Tick: killTick - h.before, RoundNumber: n, AttackerSteamID: playerC,
+			VictimSteamID: playerB, AttackerTeam: model.TeamCT, VictimTeam: model.TeamT, HealthDamage: h.dmg, Weapon: "M4A1"})
+	}
+	raw := makeRaw(kills, rounds)
+	raw.FirstSights = sights
+	raw.Damages = damages
+	raw.PlayerNames[playerC] = "teammate"
+	raw.PlayerTeams[playerC] = model.TeamCT
+
+	stats, _, _, _, err := Aggregate(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := make(map[uint64]model.PlayerMatchStats)
+	for _, s := range stats {
+		got[s.SteamID] = s
+	}
+	a, b := got[playerA], got[playerB]
+	if a.DuelWins != 3 || a.AssistedDuelWins != 1 {
+		t.Errorf("A duel wins = %d (%d assisted), want 3 (1 assisted)", a.DuelWins, a.AssistedDuelWins)
+	}
+	if b.DuelLosses != 3 || b.AssistedDuelLosses != 1 {
+		t.Errorf("B duel losses = %d (%d assisted), want 3 (1 assisted)", b.DuelLosses, b.AssistedDuelLosses)
+	}
+	if pct, ok := model.CleanDuelWinPct(a.DuelWins, a.DuelLosses, a.AssistedDuelWins, a.AssistedDuelLosses); !ok || pct != 100 {
+		t.Errorf("A clean duel win%% = %v (%v), want 100", pct, ok)
+	}
+	if _, ok := model.CleanDuelWinPct(1, 0, 1, 0); ok {
+		t.Error("clean duel win% with only assisted duels should be undefined")
+	}
+}
+
+func TestTimeline(t *testing.T) {
+	// Round 1 (ticks 1000-3000): A kills B, C plants, D defuses; round 2: a
+	// flash and a kill on the same tick as the round end.
+	r1 := model.RawRound{Number: 1, StartTick: 1000, FreezeEndTick: 1500, EndTick: 3000, WinnerTeam: model.TeamCT, EndReason: model.EndReasonDefuse}
+	r2 := model.RawRound{Number: 2, StartTick: 3100, FreezeEndTick: 3100, EndTick: 4000, WinnerTeam: model.TeamT, EndReason: model.EndReasonElimination}
+	kills := []model.RawKill{
+		{Tick: 4000, RoundNumber: 2, KillerSteamID: playerB, VictimSteamID: playerA, KillerTeam: model.TeamT, VictimTeam: model.TeamCT, Weapon: "Glock-18"},
+		{Tick: 1800, RoundNumber: 1, KillerSteamID: playerA, VictimSteamID: playerB, KillerTeam: model.TeamCT, VictimTeam: model.TeamT,
+			Weapon: "M4A4", IsHeadshot: true, KillerPos: model.Vec3{X: 1, Y: 2, Z: 3}, VictimPos: model.Vec3{X: 4, Y: 5, Z: 6}},
+	}
+	raw := makeRaw(kills, []model.RawRound{r1, r2})
+	raw.Flashes = []model.RawFlash{{Tick: 3500, RoundNumber: 2, AttackerSteamID: playerB, VictimSteamID: playerA,
+		AttackerTeam: model.TeamT, VictimTeam: model.TeamCT, FlashDuration: 1500 * time.Millisecond}}
+	raw.Plants = []model.RawPlant{{Tick: 2000, RoundNumber: 1, PlanterID: playerC, Site: "B", Pos: model.Vec3{X: 7}}}
+	raw.Defuses = []model.RawDefuse{{Tick: 2900, RoundNumber: 1, DefuserID: playerD, Site: "B"}}
+
+	got := Timeline(raw)
+	var order []string
+	for i, e := range got {
+		if e.Seq != i || e.DemoHash != raw.DemoHash {
+			t.Errorf("event %d: seq %d hash %q, want %d %q", i, e.Seq, e.DemoHash, i, raw.DemoHash)
+		}
+		order = append(order, strconv.Itoa(e.RoundNumber)+":"+e.Type)
+	}
+	want := []string{"1:round_start", "1:freeze_end", "1:kill", "1:plant", "1:defuse", "1:round_end",
+		"2:round_start", "2:flash", "2:kill", "2:round_end"}
+	if !reflect.DeepEqual(order, want) {
+		t.Fatalf("timeline order = %v, want %v", order, want)
+	}
+	if k := got[2]; k.ActorID != playerA || k.TargetID != playerB || !k.Headshot || k.ActorPos.Z != 3 || k.TargetPos.X != 4 {
+		t.Errorf("kill event = %+v, want A → B headshot with positions", k)
+	}
+	if p := got[3]; p.ActorID != playerC || p.ActorTeam != model.TeamT || p.Detail != "B" || p.ActorPos.X != 7 {
+		t.Errorf("plant event = %+v, want C (T) at site B", p)
+	}
+	if e := got[5]; e.ActorTeam != model.TeamCT || e.Detail != model.EndReasonDefuse {
+		t.Errorf("round end = %+v, want CT win by defuse", e)
+	}
+	if f := got[7]; f.DurationSec != 1.5 {
+		t.Errorf("flash duration = %v, want 1.5", f.DurationSec)
+	}
+}
+
+func TestWeaponKillDistance(t *testing.T) {
+	// A kills B at 1000 units and C at 500 with the AK; the third kill has no
+	// victim position and counts as a kill without a distance.
+	r := model.RawRound{Number: 1, StartTick: 0, FreezeEndTick: 0, EndTick: 5000, WinnerTeam: model.TeamCT}
+	kills := []model.RawKill{
+		{Tick: 100, RoundNumber: 1, KillerSteamID: playerA, VictimSteamID: playerB, KillerTeam: model.TeamCT, VictimTeam: model.TeamT,
+			Weapon: "AK-47", KillerPos: model.Vec3{X: 1, Y: 1}, VictimPos: model.Vec3{X: 1001, Y: 1}},
+		{Tick: 200, RoundNumber: 1, KillerSteamID: playerA, VictimSteamID: playerC, KillerTeam: model.TeamCT, VictimTeam: model.TeamT,
+			Weapon: "AK-47", KillerPos: model.Vec3{X: 1, Y: 1}, VictimPos: model.Vec3{X: 1, Y: 301, Z: 400}},
+		{Tick: 300, RoundNumber: 1, KillerSteamID: playerA, VictimSteamID: playerD, KillerTeam: model.TeamCT, VictimTeam: model.TeamT,
+			Weapon: "AK-47", KillerPos: model.Vec3{X: 1, Y: 1}},
+	}
+	_, _, weapons, _, err := Aggregate(makeRaw(kills, []model.RawRound{r}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range weapons {
+		if w.SteamID != playerA || w.Weapon != "AK-47" {
+			continue
+		}
+		if w.Kills != 3 || w.DistanceKills != 2 {
+			t.Fatalf("kills = %d, distance kills = %d; want 3, 2", w.Kills, w.DistanceKills)
+		}
+		if want := 1500 * unitsToMeters / 2; math.Abs(w.AvgKillDistanceM()-want) > 1e-9 {
+			t.Errorf("AvgKillDistanceM = %v, want %v", w.AvgKillDistanceM(), want)
+		}
+		return
+	}
+	t.Fatal("no AK-47 row for A")
+}
+
+func TestHeadHits(t *testing.T) {
+	// A (CT) hits B (T) with the AK twice on the head and twice on the body,
+	// once with the USP on the body; a knife hit, an HE and a team hit on C
+	// are not bullet hits on enemies but still count in their weapon rows.
+	hit := func(victim uint64, victimTeam model.Team, weapon, group string, utility bool) model.RawDamage {
+		return model.RawDamage{Tick: 100, RoundNumber: 1, AttackerSteamID: playerA, VictimSteamID: victim,
+			AttackerTeam: model.TeamCT, VictimTeam: victimTeam, HealthDamage: 20, Weapon: weapon, HitGroup: group, IsUtility: utility}
+	}
+	raw := makeRaw(nil, []model.RawRound{makeRound(1, 0, []uint64{playerA, playerB, playerC}, nil)})
+	raw.Damages = []model.RawDamage{
+		hit(playerB, model.TeamT, "AK-47", "head", false),
+		hit(playerB, model.TeamT, "AK-47", "chest", false),
+		hit(playerB, model.TeamT, "AK-47", "head", false),
+		hit(playerB, model.TeamT, "AK-47", "stomach", false),
+		hit(playerB, model.TeamT, "USP-S", "left_leg", false),
+		hit(playerB, model.TeamT, "Knife", "head", false),
+		hit(playerB, model.TeamT, "HE Grenade", "other", true),
+		hit(playerC, model.TeamCT, "AK-47", "head", false),
+	}
+	stats, _, weapons, _, err := Aggregate(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var a *model.PlayerMatchStats
+	for i := range stats {
+		if stats[i].SteamID == playerA {
+			a = &stats[i]
+		}
+	}
+	if a == nil {
+		t.Fatal("no stats row for A")
+	}
+	if a.BulletHits != 5 || a.HeadHits != 2 || a.HeadHitPct() != 40 {
+		t.Errorf("bullet hits = %d, head hits = %d (%.0f%%), want 5, 2 (40%%)", a.BulletHits, a.HeadHits, a.HeadHitPct())
+	}
+	want := map[string][2]int{"AK-47": {5, 3}, "USP-S": {1, 0}, "Knife": {1, 1}, "HE Grenade": {1, 0}}
+	for _, w := range weapons {
+		if w.SteamID != playerA {
+			continue
+		}
+		if got := [2]int{w.Hits, w.HeadHits}; got != want[w.Weapon] {
+			t.Errorf("%s hits/head hits = %v, want %v", w.Weapon, got, want[w.Weapon])
+		}
+		delete(want, w.Weapon)
+	}
+	if len(want) > 0 {
+		t.Errorf("missing weapon rows for A: %v", want)
+	}
+}
+
+func TestUtilityTiming(t *testing.T) {
+	// Round 1 (freeze end at 10s, 115s default round timer): A throws at 5s
+	// and 25s after freeze end (early), 40s (mid) and 100s (15s left, late);
+	// a throw during freeze time and one after the round ended are skipped.
+	// Round 2: A's throw 5s after the plant is late although only 35s in.
+	tps := int(tickRate)
+	r1 := makeRound(1, 10*tps, []uint64{playerA, playerB}, map[uint64]bool{playerB: true})
+	r1.EndTick = r1.FreezeEndTick + 110*tps
+	r2 := makeRound(2, r1.EndTick+10*tps, []uint64{playerA, playerB}, nil)
+	r2.StartTick = r1.EndTick
+	r2.BombPlantTick = r2.FreezeEndTick + 30*tps
+	throw := func(rnd model.RawRound, sec int) model.RawGrenadeThrow {
+		return model.RawGrenadeThrow{Tick: rnd.FreezeEndTick + sec*tps, RoundNumber: rnd.Number,
+			ThrowerID: playerA, Team: model.TeamT, Grenade: "Smoke Grenade"}
+	}
+	raw := makeRaw(nil, []model.RawRound{r1, r2})
+	raw.GrenadeThrows = []model.RawGrenadeThrow{
+		throw(r1, 5), throw(r1, 25), throw(r1, 40), throw(r1, 100),
+		throw(r1, -2), throw(r1, 112),
+		throw(r2, 35),
+	}
+	stats, _, _, _, err := Aggregate(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := 0
+	for _, s := range stats {
+		switch s.SteamID {
+		case playerA:
+			found++
+			if s.UtilThrowsEarly != 2 || s.UtilThrowsMid != 1 || s.UtilThrowsLate != 2 {
+				t.Errorf("early/mid/late = %d/%d/%d, want 2/1/2", s.UtilThrowsEarly, s.UtilThrowsMid, s.UtilThrowsLate)
+			}
+			if s.MedianUtilThrowSec != 35 {
+				t.Errorf("median throw sec = %v, want 35", s.MedianUtilThrowSec)
+			}
+		case playerB:
+			found++
+			if s.UtilThrows() != 0 {
+				t.Errorf("B throws = %d, want 0", s.UtilThrows())
+			}
+		}
+	}
+	if found != 2 {
+		t.Fatalf("got %d stats rows for A and B, want 2", found)
+	}
+}
+
+func TestEcoDamage(t *testing.T) {
+	// A (T) faces B (CT). Round 1 is the pistol round; in round 2 B saves
+	// (full eco), in round 3 B full-buys and in round 4 B forces. A's damage
+	// splits into 1 eco round (100) and 2 buy rounds (40 + 20). A buys from
+	// round 2 on, so all of B's non-pistol rounds are buy rounds.
+	alive := map[uint64]bool{playerA: true, playerB: true}
+	equip := []map[uint64]int{
+		{playerA: 800, playerB: 800},
+		{playerA: 4500, playerB: 500},
+		{playerA: 4500, playerB: 5000},
+		{playerA: 4500, playerB: 2500},
+	}
+	var rounds []model.RawRound
+	for i, e := range equip {
+		r := makeRound(i+1, 500+i*20000, []uint64{playerA, playerB}, alive)
+		b := r.PlayerEndState[playerB]
+		b.Team = model.TeamCT
+		r.PlayerEndState[playerB] = b
+		r.PlayerEquipValues = e
+		rounds = append(rounds, r)
+	}
+	hit := func(rn int, attacker, victim uint64, dmg int) model.RawDamage {
+		attackerTeam, victimTeam := model.TeamT, model.TeamCT
+		if attacker == playerB {
+			attackerTeam, victimTeam = victimTeam, attackerTeam
+		}
+		return model.RawDamage{Tick: rounds[rn-1].FreezeEndTick + 100, RoundNumber: rn,
+			AttackerSteamID: attacker, VictimSteamID: victim, AttackerTeam: attackerTeam, VictimTeam: victimTeam,
+			HealthDamage: dmg, VictimHealth: 100 - dmg, Weapon: "AK-47", HitGroup: "chest"}
+	}
+	raw := makeRaw(nil, rounds)
+	raw.Damages = []model.RawDamage{
+		hit(1, playerA, playerB, 30),
+		hit(2, playerA, playerB, 100),
+		hit(3, playerA, playerB, 40),
+		hit(4, playerA, playerB, 20),
+		hit(3, playerB, playerA, 10),
+	}
+	stats, _, _, _, err := Aggregate(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := 0
+	for _, s := range stats {
+		switch s.SteamID {
+		case playerA:
+			found++
+			if s.EcoRounds != 1 || s.EcoDamage != 100 || s.BuyRounds != 2 || s.BuyDamage != 60 {
+				t.Errorf("A eco %d/%d buy %d/%d, want eco 1/100 buy 2/60", s.EcoRounds, s.EcoDamage, s.BuyRounds, s.BuyDamage)
+			}
+			if s.EcoADR() != 100 || s.BuyADR() != 30 {
+				t.Errorf("A eco/buy ADR = %v/%v, want 100/30", s.EcoADR(), s.BuyADR())
+			}
+		case playerB:
+			found++
+			if s.EcoRounds != 0 || s.BuyRounds != 3 || s.BuyDamage != 10 {
+				t.Errorf("B eco %d buy %d/%d, want eco 0 buy 3/10", s.EcoRounds, s.BuyRounds, s.BuyDamage)
+			}
+		}
+	}
+	if found != 2 {
+		t.Fatalf("got %d stats rows for A and B, want 2", found)
+	}
+}
+
+func TestInfoDeaths(t *testing.T) {
+	// B (T) kills A (CT) in three rounds. Round 1: A spotted B before the
+	// kill. Round 2: teammate C spotted B (counts for A). Round 3: C spotted
+	// B only after the kill (unspotted). Round 4: C teamkills A (not counted).
+	ids := []uint64{playerA, playerB, playerC}
+	var rounds []model.RawRound
+	var kills []model.RawKill
+	for n := 1; n <= 4; n++ {
+		r := makeRound(n, n*10000, ids, map[uint64]bool{playerB: true, playerC: true})
+		rounds = append(rounds, r)
+		k := model.RawKill{Tick: n*10000 + 500, RoundNumber: n, KillerSteamID: playerB, VictimSteamID: playerA,
+			KillerTeam: model.TeamT, VictimTeam: model.TeamCT, Weapon: "AK-47"}
+		if n == 4 {
+			k.KillerSteamID, k.KillerTeam, k.Weapon = playerC, model.TeamCT, "M4A4"
+		}
+		kills = append(kills, k)
+	}
+	raw := makeRaw(kills, rounds)
+	raw.PlayerTeams[playerC] = model.TeamCT
+	raw.PlayerNames[playerC] = "teammate"
+	raw.FirstSights = []model.RawFirstSight{
+		{Tick: 10400, RoundNumber: 1, ObserverID: playerA, EnemyID: playerB},
+		{Tick: 20300, RoundNumber: 2, ObserverID: playerC, EnemyID: playerB},
+		{Tick: 30600, RoundNumber: 3, ObserverID: playerC, EnemyID: playerB},
+		{Tick: 30450, RoundNumber: 3, ObserverID: playerB, EnemyID: playerA},
+	}
+
+	stats, _, _, _, err := Aggregate(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range stats {
+		if s.SteamID != playerA {
+			continue
+		}
+		if s.UnspottedDeaths != 1 || s.SpottedDeaths != 2 {
+			t.Errorf("A unspotted/spotted deaths = %d/%d, want 1/2", s.UnspottedDeaths, s.SpottedDeaths)
+		}
+		if got := s.InfoDeathPct(); math.Abs(got-100.0/3) > 1e-9 {
+			t.Errorf("A info death%% = %v, want 33.3", got)
+		}
+		return
+	}
+	t.Fatal("no stats row for A")
+}
+
+func TestPing(t *testing.T) {
+	// A has three readings over two rounds (mean 110, high ping); B has one
+	// reading.
+	ids := []uint64{playerA, playerB}
+	kills := []model.RawKill{{Tick: 10500, RoundNumber: 1, KillerSteamID: playerB, VictimSteamID: playerA,
+		KillerTeam: model.TeamCT, VictimTeam: model.TeamT, Weapon: "AK-47"}}
+	raw := makeRaw(kills, []model.RawRound{makeRound(1, 10000, ids, nil), makeRound(2, 20000, ids, nil)})
+	raw.PingSamples = []model.RawPingSample{
+		{Tick: 10100, RoundNumber: 1, PlayerID: playerA, PingMs: 80},
+		{Tick: 10100, RoundNumber: 1, PlayerID: playerB, PingMs: 25},
+		{Tick: 10420, RoundNumber: 1, PlayerID: playerA, PingMs: 150},
+		{Tick: 20100, RoundNumber: 2, PlayerID: playerA, PingMs: 100},
+	}
+
+	stats, _, _, _, err := Aggregate(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID := make(map[uint64]model.PlayerMatchStats, len(stats))
+	for _, s := range stats {
+		byID[s.SteamID] = s
+	}
+	if a := byID[playerA]; a.PingSamples != 3 || math.Abs(a.AvgPingMs-110) > 1e-9 || a.MaxPingMs != 150 || !a.HighPing() {
+		t.Errorf("A ping = %d samples avg %v max %d high %v, want 3/110/150/true", a.PingSamples, a.AvgPingMs, a.MaxPingMs, a.HighPing())
+	}
+	if b := byID[playerB]; b.PingSamples != 1 || b.AvgPingMs != 25 || b.MaxPingMs != 25 || b.HighPing() {
+		t.Errorf("B ping = %d samples avg %v max %d high %v, want 1/25/25/false", b.PingSamples, b.AvgPingMs, b.MaxPingMs, b.HighPing())
+	}
+}
+
+func TestTradeChains(t *testing.T) {
+	const playerE, playerF uint64 = 1005, 1006
+	tps := int(tickRate)
+	kill := func(rn, sec int, killer uint64, killerTeam model.Team, victim uint64, victimTeam model.Team) model.RawKill {
+		return model.RawKill{Tick: sec * tps, RoundNumber: rn, KillerSteamID: killer, VictimSteamID: victim,
+			KillerTeam: killerTeam, VictimTeam: victimTeam, Weapon: "AK-47"}
+	}
+	ct, tt := model.TeamCT, model.TeamT
+	kills := []model.RawKill{
+		// Round 1: C kills A, B trades C, D re-trades B → T wins 2-for-1.
+		// D's later kill on F is 17s on and starts a chain with no trade.
+		kill(1, 1, playerC, tt, playerA, ct),
+		kill(1, 2, playerB, ct, playerC, tt),
+		kill(1, 3, playerD, tt, playerB, ct),
+		kill(1, 20, playerD, tt, playerF, ct),
+		// Round 2: A kills C and D, E trades A → CT wins 2-for-1. B's kill on
+		// E is past the window; the team kill is ignored.
+		kill(2, 1, playerA, ct, playerC, tt),
+		kill(2, 2, playerA, ct, playerD, tt),
+		kill(2, 3, playerF, ct, playerB, ct),
+		kill(2, 4, playerE, tt, playerA, ct),
+		kill(2, 15, playerB, ct, playerE, tt),
+	}
+	got := TradeChains(makeRaw(kills, nil))
+	want := []model.TradeChain{
+		{DemoHash: "testhash", RoundNumber: 1, StartTick: tps, EndTick: 3 * tps, Kills: 3, Trades: 2, FirstTeam: tt, CTKills: 1, TKills: 2},
+		{DemoHash: "testhash", RoundNumber: 2, StartTick: tps, EndTick: 4 * tps, Kills: 3, Trades: 1, FirstTeam: ct, CTKills: 2, TKills: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("TradeChains = %+v, want %+v", got, want)
+	}
+	if won, lost := got[0].Exchange(tt); won != 2 || lost != 1 {
+		t.Errorf("round 1 T exchange = %d-for-%d, want 2-for-1", won, lost)
+	}
+}
+
+// TestCompareFingerprints: candidates are ranked by spread-normalised
+// distance over shared features; too few shared features drops a candidate,
+// and the target never matches itself.
+func TestCompareFingerprints(t *testing.T) {
+	fp := func(id uint64, ttk, cs float64, akHS int) model.PlayerFingerprint {
+		p := model.PlayerFingerprint{SteamID: id, TTKMs: ttk, CounterStrafePct: cs,
+			FHHS: map[string]model.FHHSCount{}}
+		if akHS > 0 {
+			p.FHHS["AK"] = model.FHHSCount{FirstHits: 100, Headshots: akHS}
+		}
+		return p
+	}
+	target := fp(1, 300, 70, 40)
+	candidates := []model.PlayerFingerprint{
+		target,
+		fp(2, 500, 40, 20),
+		fp(3, 305, 71, 41), // the alt
+		fp(4, 400, 55, 30),
+		fp(5, 350, 0, 0), // TTK only
+	}
+	got := CompareFingerprints(target, candidates)
+	var ids []uint64
+	for _, m := range got {
+		ids = append(ids, m.Player.SteamID)
+		if m.Features != 3 {
+			t.Errorf("player %d: features = %d, want 3", m.Player.SteamID, m.Features)
+		}
+	}
+	if want := []uint64{3, 4, 2}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("ranking = %v, want %v", ids, want)
+	}
+	if got[0].Distance > 0.2 || got[2].Distance < 1 {
+		t.Errorf("distances = %.2f … %.2f, want alt < 0.2 and outlier > 1", got[0].Distance, got[2].Distance)
+	}
+}
+
+// TestTeamConcentration: Gini is 0 for an even split and (n−1)/n when one
+// player does everything; teams are split by side and players without
+// rounds are skipped.
+func TestTeamConcentration(t *testing.T) {
+	if g := Gini([]float64{10, 10, 10, 10}); g != 0 {
+		t.Errorf("Gini(even) = %v, want 0", g)
+	}
+	if g := Gini([]float64{0, 0, 0, 20}); math.Abs(g-0.75) > 1e-9 {
+		t.Errorf("Gini(one of four) = %v, want 0.75", g)
+	}
+	if g := Gini([]float64{1, 2, 3}); math.Abs(g-2.0/9) > 1e-9 {
+		t.Errorf("Gini(1,2,3) = %v, want 2/9", g)
+	}
+	if g, s := Gini(nil), TopShare([]float64{0, 0}); g != 0 || s != 0 {
+		t.Errorf("Gini(nil), TopShare(zeros) = %v, %v; want 0, 0", g, s)
+	}
+
+	stats := []model.PlayerMatchStats{
+		{Name: "a", Team: model.TeamCT, RoundsPlayed: 20, Kills: 30, TotalDamage: 3000},
+		{Name: "b", Team: model.TeamCT, RoundsPlayed: 20, Kills: 10, TotalDamage: 1000},
+		{Name: "c", Team: model.TeamT, RoundsPlayed: 20, Kills: 15, TotalDamage: 1500},
+		{Name: "d", Team: model.TeamT, RoundsPlayed: 20, Kills: 15, TotalDamage: 1500},
+		{Name: "coach", Team: model.TeamT},
+	}
+	got := TeamConcentration(stats)
+	if len(got) != 2 {
+		t.Fatalf("TeamConcentration returned %d teams, want 2", len(got))
+	}
+	ct, tt := got[0], got[1]
+	if ct.Team != model.TeamCT || ct.Players != 2 || ct.TopKiller != "a" || ct.TopDamager != "a" ||
+		math.Abs(ct.KillGini-0.25) > 1e-9 || ct.TopKillShare != 0.75 || ct.TopDamageShare != 0.75 {
+		t.Errorf("CT = %+v, want 2 players, Gini 0.25, top shares 0.75 by a", ct)
+	}
+	if tt.Team != model.TeamT || tt.Players != 2 || tt.KillGini != 0 || tt.DamageGini != 0 || tt.TopKillShare != 0.5 {
+		t.Errorf("T = %+v, want an even 2-player split", tt)
+	}
+}
+
+func TestClutchStart(t *testing.T) {
+	// A and B (CT) vs C and D (T), freeze end at 500. C kills A at 1140 (10s),
+	// leaving B alone against C and D; B kills C at 1460, leaving D alone
+	// against B; D kills B at 1780.
+	round := makeRound(1, 500, []uint64{playerA, playerB, playerC, playerD}, map[uint64]bool{playerD: true})
+	for _, id := range []uint64{playerA, playerB} {
+		es := round.PlayerEndState[id]
+		es.Team = model.TeamCT
+		round.PlayerEndState[id] = es
+	}
+	round.WinnerTeam = model.TeamT
+	kill := func(tick int, killer, victim uint64, kt, vt model.Team) model.RawKill {
+		return model.RawKill{Tick: tick, RoundNumber: 1, KillerSteamID: killer, VictimSteamID: victim,
+			KillerTeam: kt, VictimTeam: vt, Weapon: "AK-47"}
+	}
+	raw := makeRaw([]model.RawKill{
+		kill(1140, playerC, playerA, model.TeamT, model.TeamCT),
+		kill(1460, playerB, playerC, model.TeamCT, model.TeamT),
+		kill(1780, playerD, playerB, model.TeamT, model.TeamCT),
+	}, []model.RawRound{round})
+
+	_, roundStats, _, _, err := Aggregate(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := make(map[uint64]model.PlayerRoundStats)
+	for _, rs := range roundStats {
+		got[rs.SteamID] = rs
+	}
+	b := got[playerB]
+	if !b.IsInClutch || b.ClutchEnemyCount != 2 || b.ClutchStartTick != 1140 || b.ClutchStartSec != 10 ||
+		!reflect.DeepEqual(b.ClutchEnemies, []uint64{playerC, playerD}) {
+		t.Errorf("B clutch = %v 1v%d at %d (%vs) vs %v, want true 1v2 at 1140 (10s) vs [C D]",
+			b.IsInClutch, b.ClutchEnemyCount, b.ClutchStartTick, b.ClutchStartSec, b.ClutchEnemies)
+	}
+	d := got[playerD]
+	if !d.IsInClutch || d.ClutchEnemyCount != 1 || d.ClutchStartTick != 1460 ||
+		!reflect.DeepEqual(d.ClutchEnemies, []uint64{playerB}) {
+		t.Errorf("D clutch = %v 1v%d at %d vs %v, want true 1v1 at 1460 vs [B]",
+			d.IsInClutch, d.ClutchEnemyCount, d.ClutchStartTick, d.ClutchEnemies)
+	}
+	if a := got[playerA]; a.IsInClutch || a.ClutchStartTick != 0 || a.ClutchEnemies != nil {
+		t.Errorf("A: unexpected clutch %+v", a)
+	}
+}
+
+func TestDeathProfile(t *testing.T) {
+	// A (CT) dies to C's AK at 12 m (headshot) and to D's AWP with no shot in
+	// the 2 s window; B dies to C's HE. B's team kill and a world death are
+	// excluded.
+	raw := makeRaw([]model.RawKill{
+		{Tick: 1000, RoundNumber: 1, KillerSteamID: playerC, VictimSteamID: playerA,
+			KillerTeam: model.TeamT, VictimTeam: model.TeamCT, Weapon: "AK-47", IsHeadshot: true},
+		{Tick: 2000, RoundNumber: 2, KillerSteamID: playerD, VictimSteamID: playerA,
+			KillerTeam: model.TeamT, VictimTeam: model.TeamCT, Weapon: "AWP"},
+		{Tick: 2100, RoundNumber: 2, KillerSteamID: playerC, VictimSteamID: playerB,
+			KillerTeam: model.TeamT, VictimTeam: model.TeamCT, Weapon: "HE Grenade"},
+		{Tick: 3000, RoundNumber: 3, KillerSteamID: playerB, VictimSteamID: playerA,
+			KillerTeam: model.TeamCT, VictimTeam: model.TeamCT, Weapon: "AK-47"},
+		{Tick: 3100, RoundNumber: 3, KillerSteamID: 0, VictimSteamID: playerB,
+			VictimTeam: model.TeamCT, Weapon: "World"},
+	}, nil)
+	raw.WeaponFires = []model.RawWeaponFire{
+		{Tick: 990, RoundNumber: 1, ShooterID: playerC, AttackerPos: model.Vec3{X: 630}},
+		{Tick: 2000 - int(3*tickRate), RoundNumber: 2, ShooterID: playerD},
+		{Tick: 2050, RoundNumber: 2, ShooterID: playerC, AttackerPos: model.Vec3{X: 100}},
+	}
+	raw.Damages = []model.RawDamage{
+		{Tick: 1000, RoundNumber: 1, AttackerSteamID: playerC, VictimSteamID: playerA},
+		{Tick: 2000, RoundNumber: 2, AttackerSteamID: playerD, VictimSteamID: playerA},
+		{Tick: 2100, RoundNumber: 2, AttackerSteamID: playerC, VictimSteamID: playerB, IsUtility: true},
+	}
+
+	got := DeathProfile(raw)
+	want := []model.PlayerDeathSegment{
+		{DemoHash: "testhash", SteamID: playerA, WeaponBucket: "AK", DistanceBin: "10-15m", Deaths: 1, HeadshotDeaths: 1},
+		{DemoHash: "testhash", SteamID: playerA, WeaponBucket: "AWP", DistanceBin: "unknown", Deaths: 1},
+		{DemoHash: "testhash", SteamID: playerB, WeaponBucket: "Other", DistanceBin: "unknown", Deaths: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("DeathProfile returned %d segments, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("segment %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestDeathContexts(t *testing.T) {
+	// A dies three times: round 1 moving and isolated, right after killing D
+	// (2s earlier); round 2 holding, flashed 1s before with a teammate near;
+	// round 3 to an HE with a flash 4s before (outside the window) and an older
+	// bullet hit — movement unknown. B's team kill is excluded.
+	raw := makeRaw([]model.RawKill{
+		{Tick: 1000 - int(2*tickRate), RoundNumber: 1, KillerSteamID: playerA, VictimSteamID: playerD,
+			KillerTeam: model.TeamCT, VictimTeam: model.TeamT, Weapon: "AK-47", NearbyVictimTeammates: 1},
+		{Tick: 1000, RoundNumber: 1, KillerSteamID: playerC, VictimSteamID: playerA,
+			KillerTeam: model.TeamT, VictimTeam: model.TeamCT, Weapon: "AK-47"},
+		{Tick: 2000, RoundNumber: 2, KillerSteamID: playerC, VictimSteamID: playerA,
+			KillerTeam: model.TeamT, VictimTeam: model.TeamCT, Weapon: "AK-47", NearbyVictimTeammates: 2},
+		{Tick: 3000, RoundNumber: 3, KillerSteamID: playerC, VictimSteamID: playerA,
+			KillerTeam: model.TeamT, VictimTeam: model.TeamCT, Weapon: "HE Grenade"},
+		{Tick: 3100, RoundNumber: 3, KillerSteamID: playerB, VictimSteamID: playerA,
+			KillerTeam: model.TeamCT, VictimTeam: model.TeamCT, Weapon: "AK-47"},
+	}, nil)
+	raw.Damages = []model.RawDamage{
+		{Tick: 980, RoundNumber: 1, AttackerSteamID: playerC, VictimSteamID: playerA, VictimSpeed: 250},
+		{Tick: 1000, RoundNumber: 1, AttackerSteamID: playerC, VictimSteamID: playerA, VictimSpeed: 0},
+		{Tick: 1990, RoundNumber: 2, AttackerSteamID: playerC, VictimSteamID: playerA, VictimSpeed: 20},
+		{Tick: 3000 - int(4*tickRate), RoundNumber: 3, AttackerSteamID: playerC, VictimSteamID: playerA, VictimSpeed: 250},
+		{Tick: 3000, RoundNumber: 3, AttackerSteamID: playerC, VictimSteamID: playerA, IsUtility: true},
+	}
+	raw.Flashes = []model.RawFlash{
+		{Tick: 2000 - int(tickRate), RoundNumber: 2, AttackerSteamID: playerC, VictimSteamID: playerA, FlashDuration: 2},
+		{Tick: 3000 - int(4*tickRate), RoundNumber: 3, AttackerSteamID: playerC, VictimSteamID: playerA, FlashDuration: 2},
+	}
+
+	got := DeathContexts(raw)
+	want := []model.PlayerDeathContext{
+		{DemoHash: "testhash", SteamID: playerA, Movement: model.DeathHolding, Flashed: true, Deaths: 1},
+		{DemoHash: "testhash", SteamID: playerA, Movement: model.DeathMoving, Isolated: true, AfterKill: true, Deaths: 1},
+		{DemoHash: "testhash", SteamID: playerA, Movement: model.DeathMovementUnknown, Isolated: true, Deaths: 1},
+		{DemoHash: "testhash", SteamID: playerD, Movement: model.DeathMovementUnknown, Deaths: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("DeathContexts returned %d rows, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("row %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if l := want[1].Label(); l != "isolated, after a kill while moving" {
+		t.Errorf("Label = %q", l)
+	}
+}
+
+func TestTeamConflict(t *testing.T) {
+	// A is CT in both rounds' end state, but in round 1 a second slot on the
+	// same SteamID (coach / shared account) kills B and D for T. A has more T
+	// than CT kills, so the kill-count heuristic would put A on T.
+	kill := func(tick, round int, killer, victim uint64, kt, vt model.Team) model.RawKill {
+		return model.RawKill{Tick: tick, RoundNumber: round, KillerSteamID: killer, VictimSteamID: victim,
+			KillerTeam: kt, VictimTeam: vt, Weapon: "AK-47"}
+	}
+	endState := map[uint64]model.PlayerRoundEndState{
+		playerA: {SteamID64: playerA, Team: model.TeamCT},
+		playerC: {SteamID64: playerC, Team: model.TeamT},
+	}
+	raw := makeRaw([]model.RawKill{
+		kill(100, 1, playerA, playerC, model.TeamCT, model.TeamT),
+		kill(200, 1, playerA, playerB, model.TeamT, model.TeamCT),
+		kill(300, 1, playerA, playerD, model.TeamT, model.TeamCT),
+		kill(1100, 2, playerC, playerB, model.TeamT, model.TeamCT),
+	}, []model.RawRound{
+		{Number: 1, FreezeEndTick: 0, EndTick: 1000, WinnerTeam: model.TeamCT, PlayerEndState: endState},
+		{Number: 2, FreezeEndTick: 1000, EndTick: 2000, WinnerTeam: model.TeamT, PlayerEndState: endState},
+	})
+
+	matchStats, roundStats, _, _, err := Aggregate(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ms := range matchStats {
+		want := 0
+		if ms.SteamID == playerA {
+			want = 1
+			if ms.Team != model.TeamCT {
+				t.Errorf("A team = %v, want CT (by rounds, not kills)", ms.Team)
+			}
+		}
+		if ms.TeamConflictRounds != want {
+			t.Errorf("player %d: TeamConflictRounds = %d, want %d", ms.SteamID, ms.TeamConflictRounds, want)
+		}
+	}
+	for _, rs := range roundStats {
+		// B has no end state: its round team comes from that round's events.
+		if rs.SteamID == playerB && rs.Team != model.TeamCT {
+			t.Errorf("B round %d team = %v, want CT", rs.RoundNumber, rs.Team)
+		}
+		if rs.SteamID == playerA && (rs.Team != model.TeamCT || rs.WonRound != (rs.RoundNumber == 1)) {
+			t.Errorf("A round %d = team %v won %v, want CT won %v", rs.RoundNumber, rs.Team, rs.WonRound, rs.RoundNumber == 1)
+		}
+	}
+}
+
+func TestTimeToDamage(t *testing.T) {
+	// A sees C at tick 1000 and first hits them 160 ticks (2.5s) later without
+	// killing them; A sees D at 2000 and hits at 2032 (500ms) with the AWP. A's
+	// hit on B 6s after sighting is a re-peek and a hit before the sighting
+	// does not count.
+	raw := makeRaw([]model.RawKill{
+		{Tick: 2100, RoundNumber: 1, KillerSteamID: playerA, VictimSteamID: playerD,
+			KillerTeam: model.TeamCT, VictimTeam: model.TeamT, Weapon: "AWP"},
+	}, []model.RawRound{{Number: 1, FreezeEndTick: 0, EndTick: 5000, WinnerTeam: model.TeamCT}})
+	raw.PlayerTeams[playerB] = model.TeamT
+	raw.PlayerTeams[playerC] = model.TeamT
+	raw.FirstSights = []model.RawFirstSight{
+		{Tick: 1000, RoundNumber: 1, ObserverID: playerA, EnemyID: playerC},
+		{Tick: 2000, RoundNumber: 1, ObserverID: playerA, EnemyID: playerD},
+		{Tick: 3000, RoundNumber: 1, ObserverID: playerA, EnemyID: playerB},
+	}
+	hit := func(tick int, victim uint64, weapon string) model.RawDamage {
+		return model.RawDamage{Tick: tick, RoundNumber: 1, AttackerSteamID: playerA, VictimSteamID: victim,
+			AttackerTeam: model.TeamCT, VictimTeam: model.TeamT, HealthDamage: 20, Weapon: weapon}
+	}
+	raw.Damages = []model.RawDamage{
+		hit(900, playerC, "AK-47"),
+		hit(1160, playerC, "AK-47"),
+		hit(1200, playerC, "AK-47"),
+		hit(2032, playerD, "AWP"),
+		hit(3000+int(6*tickRate), playerB, "AK-47"),
+	}
+
+	got := TimeToDamage(raw)
+	want := []model.PlayerTimeToDamage{
+		{DemoHash: "testhash", SteamID: playerA, WeaponBucket: "AK", Samples: 1, MedianMs: 2500},
+		{DemoHash: "testhash", SteamID: playerA, WeaponBucket: "AWP", Samples: 1, MedianMs: 500},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("TimeToDamage returned %d rows, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("row %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	matchStats, _, _, _, err := Aggregate(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ms := range matchStats {
+		if ms.SteamID == playerA && (ms.TimeToDamageSamples != 2 || ms.MedianTimeToDamageMs != 1500) {
+			t.Errorf("A time to damage = %d samples, %.0fms; want 2, 1500ms", ms.TimeToDamageSamples, ms.MedianTimeToDamageMs)
+		}
+	}
+}
+
+func TestBursts(t *testing.T) {
+	// A taps the AK twice (20 ticks apart), then sprays 5 shots 6 ticks apart,
+	// switches to the Deagle for a 2-shot burst, and sprays 10 shots in round 2.
+	// The AWP shots are ignored; a shot in a new round starts a new burst.
+	raw := makeRaw(nil, []model.RawRound{
+		{Number: 1, FreezeEndTick: 0, EndTick: 5000, WinnerTeam: model.TeamCT},
+		{Number: 2, FreezeEndTick: 5000, EndTick: 10000, WinnerTeam: model.TeamCT},
+	})
+	fire := func(tick, round int, weapon string) model.RawWeaponFire {
+		return model.RawWeaponFire{Tick: tick, RoundNumber: round, ShooterID: playerA, Weapon: weapon}
+	}
+	raw.WeaponFires = []model.RawWeaponFire{
+		fire(100, 1, "AK-47"), fire(120, 1, "AK-47"),
+		fire(300, 1, "AWP"), fire(306, 1, "AWP"),
+	}
+	for i := 0; i < 5; i++ {
+		raw.WeaponFires = append(raw.WeaponFires, fire(500+6*i, 1, "AK-47"))
+	}
+	raw.WeaponFires = append(raw.WeaponFires, fire(530, 1, "Desert Eagle"), fire(540, 1, "Desert Eagle"))
+	for i := 0; i < 10; i++ {
+		raw.WeaponFires = append(raw.WeaponFires, fire(5100+6*i, 2, "AK-47"))
+	}
+
+	got := Bursts(raw)
+	want := []model.PlayerBurstStats{
+		{DemoHash: "testhash", SteamID: playerA, WeaponBucket: "AK", Taps: 2, Sprays: 1, PanicSprays: 1},
+		{DemoHash: "testhash", SteamID: playerA, WeaponBucket: "Deagle", ShortBursts: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Bursts returned %d rows, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("row %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	matchStats, _, _, _, err := Aggregate(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ms := range matchStats {
+		if ms.SteamID == playerA && (ms.BurstTaps != 2 || ms.BurstShort != 1 || ms.BurstSpray != 1 || ms.BurstPanic != 1) {
+			t.Errorf("A bursts = %d/%d/%d/%d, want 2/1/1/1", ms.BurstTaps, ms.BurstShort, ms.BurstSpray, ms.BurstPanic)
+		}
+	}
+}
+
+func TestLateRoundDiscipline(t *testing.T) {
+	// Round 1 (no plant, default 115s clock, late from tick 6080): A kills C
+	// early; at the late crossing CT is up 2v1 on its own clock, so A and B are
+	// in a play-for-time spot. D then kills B (a play-for-time death) and A
+	// kills D (late, but the clock was never on T's side).
+	// Round 2: the bomb explodes 30s after the plant, calibrating the bomb
+	// timer to 30s (late from plant + 10s). C kills A after the plant, leaving
+	// C and D up 2v1 with the bomb down; B kills C late (C's play-for-time death).
+	endState := map[uint64]model.PlayerRoundEndState{
+		playerA: {SteamID64: playerA, Team: model.TeamCT},
+		playerB: {SteamID64: playerB, Team: model.TeamCT},
+		playerC: {SteamID64: playerC, Team: model.TeamT},
+		playerD: {SteamID64: playerD, Team: model.TeamT},
+	}
+	kill := func(tick, round int, killer, victim uint64, kt, vt model.Team) model.RawKill {
+		return model.RawKill{Tick: tick, RoundNumber: round, KillerSteamID: killer, VictimSteamID: victim,
+			KillerTeam: kt, VictimTeam: vt, Weapon: "AK-47"}
+	}
+	raw := makeRaw([]model.RawKill{
+		kill(1000, 1, playerA, playerC, model.TeamCT, model.TeamT),
+		kill(6400, 1, playerD, playerB, model.TeamT, model.TeamCT),
+		kill(6500, 1, playerA, playerD, model.TeamCT, model.TeamT),
+		kill(10100, 2, playerC, playerA, model.TeamT, model.TeamCT),
+		kill(10700, 2, playerB, playerC, model.TeamCT, model.TeamT),
+	}, []model.RawRound{
+		{Number: 1, FreezeEndTick: 0, EndTick: 6500, WinnerTeam: model.TeamCT,
+			EndReason: model.EndReasonElimination, PlayerEndState: endState},
+		{Number: 2, FreezeEndTick: 7000, EndTick: 10000 + int(30*tickRate), WinnerTeam: model.TeamT,
+			EndReason: model.EndReasonBomb, PlayerEndState: endState, BombPlantTick: 10000},
+	})
+
+	states := KillStates(raw)
+	if len(states) != 5 {
+		t.Fatalf("expected 5 kill states, got %d", len(states))
+	}
+	if got := states[0].ClockSec; got != 115-1000/tickRate {
+		t.Errorf("round 1 first kill clock = %.3fs, want %.3fs", got, 115-1000/tickRate)
+	}
+	if got := states[4].ClockSec; got != 30-700/tickRate {
+		t.Errorf("round 2 post-plant kill clock = %.3fs, want %.3fs (bomb timer)", got, 30-700/tickRate)
+	}
+
+	matchStats, _, _, _, err := Aggregate(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type counts struct{ late, rounds, deaths int }
+	want := map[uint64]counts{
+		playerA: {0, 1, 0},
+		playerB: {1, 1, 1},
+		playerC: {1, 1, 1},
+		playerD: {1, 1, 0},
+	}
+	for _, ms := range matchStats {
+		w, ok := want[ms.SteamID]
+		if !ok {
+			continue
+		}
+		got := counts{ms.LateRoundDeaths, ms.PlayForTimeRounds, ms.PlayForTimeDeaths}
+		if got != w {
+			t.Errorf("player %d late/pft rounds/pft deaths = %+v, want %+v", ms.SteamID, got, w)
+		}
+	}
+}
+
+func TestAWPShotLedger(t *testing.T) {
+	// A fires the AWP four times: the first shot kills B and, a tick later,
+	// collaterals C (one kill); the second leaves D at 20 HP (body hit); the
+	// third hits nobody; the fourth is followed by damage 10 ticks (>100ms)
+	// later, which belongs to no shot (miss). A's AK damage is ignored.
+	raw := makeRaw([]model.RawKill{
+		{Tick: 1000, RoundNumber: 1, KillerSteamID: playerA, VictimSteamID: playerB,
+			KillerTeam: model.TeamCT, VictimTeam: model.TeamT, Weapon: "AWP"},
+	}, []model.RawRound{{Number: 1, FreezeEndTick: 0, EndTick: 5000, WinnerTeam: model.TeamCT}})
+	raw.PlayerTeams[playerC] = model.TeamT
+	raw.PlayerTeams[playerD] = model.TeamT
+	fire := func(tick int, weapon string) model.RawWeaponFire {
+		return model.RawWeaponFire{Tick: tick, RoundNumber: 1, ShooterID: playerA, Weapon: weapon}
+	}
+	hit := func(tick int, victim uint64, weapon string, healthLeft int) model.RawDamage {
+		return model.RawDamage{Tick: tick, RoundNumber: 1, AttackerSteamID: playerA, VictimSteamID: victim,
+			AttackerTeam: model.TeamCT, VictimTeam: model.TeamT, HealthDamage: 100 - healthLeft,
+			VictimHealth: healthLeft, Weapon: weapon}
+	}
+	raw.WeaponFires = []model.RawWeaponFire{
+		fire(1000, "AWP"), fire(2000, "AWP"), fire(2500, "AK-47"), fire(3000, "AWP"), fire(4000, "AWP"),
+	}
+	raw.Damages = []model.RawDamage{
+		hit(1000, playerB, "AWP", 0),
+		hit(1001, playerC, "AWP", 0),
+		hit(2002, playerD, "AWP", 20),
+		hit(2500, playerD, "AK-47", 0),
+		hit(4010, playerC, "AWP", 10),
+	}
+
+	matchStats, _, _, _, err := Aggregate(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ms := range matchStats {
+		if ms.SteamID != playerA {
+			continue
+		}
+		if ms.AWPShots != 4 || ms.AWPShotKills != 1 || ms.AWPShotBodyHits != 1 {
+			t.Errorf("AWP shots/kills/body hits = %d/%d/%d, want 4/1/1", ms.AWPShots, ms.AWPShotKills, ms.AWPShotBodyHits)
+		}
+		return
+	}
+	t.Fatal("playerA not found in matchStats")
+}
+
+func TestAWPRoundsFaced(t *testing.T) {
+	// Round 1: B (T) only fires the AWP. Round 2: B kills A (CT) with it.
+	// Round 3: A fires the AWP. Round 4: rifles only.
+	// A faced an AWP in rounds 1–2, B in round 3.
+	round := func(n int) model.RawRound {
+		return model.RawRound{Number: n, FreezeEndTick: n * 1000, EndTick: n*1000 + 900, WinnerTeam: model.TeamT,
+			PlayerEndState: map[uint64]model.PlayerRoundEndState{
+				playerA: {SteamID64: playerA, Team: model.TeamCT, IsAlive: n != 2},
+				playerB: {SteamID64: playerB, Team: model.TeamT, IsAlive: true},
+			}}
+	}
+	raw := makeRaw([]model.RawKill{
+		{Tick: 2100, RoundNumber: 2, KillerSteamID: playerB, VictimSteamID: playerA,
+			KillerTeam: model.TeamT, VictimTeam: model.TeamCT, Weapon: "AWP"},
+	}, []model.RawRound{round(1), round(2), round(3), round(4)})
+	raw.WeaponFires = []model.RawWeaponFire{
+		{Tick: 1100, RoundNumber: 1, ShooterID: playerB, Weapon: "AWP"},
+		{Tick: 3100, RoundNumber: 3, ShooterID: playerA, Weapon: "AWP"},
+		{Tick: 4100, RoundNumber: 4, ShooterID: playerB, Weapon: "AK-47"},
+	}
+
+	matchStats, _, _, _, err := Aggregate(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[uint64]int{playerA: 2, playerB: 1}
+	for _, ms := range matchStats {
+		if w, ok := want[ms.SteamID]; ok && ms.AWPRoundsFaced != w {
+			t.Errorf("player %d AWPRoundsFaced = %d, want %d", ms.SteamID, ms.AWPRoundsFaced, w)
+		}
+	}
+	if len(matchStats) != 2 {
+		t.Fatalf("expected 2 players, got %d", len(matchStats))
+	}
+}
+
+func TestDefensiveUtility(t *testing.T) {
+	// B (T) takes 40 from A's HE and 10 from their own molotov; B is blinded by
+	// A for 2.5s and by a teammate (C) for 1s, and A's zero-duration flash
+	// does not count.
+	raw := makeRaw([]model.RawKill{
+		{Tick: 1500, RoundNumber: 1, KillerSteamID: playerA, VictimSteamID: playerB,
+			KillerTeam: model.TeamCT, VictimTeam: model.TeamT, Weapon: "AK-47"},
+	}, []model.RawRound{{Number: 1, FreezeEndTick: 0, EndTick: 5000, WinnerTeam: model.TeamCT}})
+	raw.Damages = []model.RawDamage{
+		{Tick: 1000, RoundNumber: 1, AttackerSteamID: playerA, VictimSteamID: playerB,
+			AttackerTeam: model.TeamCT, VictimTeam: model.TeamT, HealthDamage: 40, Weapon: "HE Grenade", IsUtility: true},
+		{Tick: 1100, RoundNumber: 1, AttackerSteamID: playerB, VictimSteamID: playerB,
+			AttackerTeam: model.TeamT, VictimTeam: model.TeamT, HealthDamage: 10, Weapon: "Molotov", IsUtility: true},
+		{Tick: 1500, RoundNumber: 1, AttackerSteamID: playerA, VictimSteamID: playerB,
+			AttackerTeam: model.TeamCT, VictimTeam: model.TeamT, HealthDamage: 50, Weapon: "AK-47"},
+	}
+	raw.Flashes = []model.RawFlash{
+		{Tick: 900, RoundNumber: 1, AttackerSteamID: playerA, VictimSteamID: playerB,
+			AttackerTeam: model.TeamCT, VictimTeam: model.TeamT, FlashDuration: 2500 * time.Millisecond},
+		{Tick: 950, RoundNumber: 1, AttackerSteamID: playerC, VictimSteamID: playerB,
+			AttackerTeam: model.TeamT, VictimTeam: model.TeamT, FlashDuration: time.Second},
+		{Tick: 960, RoundNumber: 1, AttackerSteamID: playerA, VictimSteamID: playerB,
+			AttackerTeam: model.TeamCT, VictimTeam: model.TeamT},
+	}
+
+	matchStats, _, _, _, err := Aggregate(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ms := range matchStats {
+		if ms.SteamID != playerB {
+			continue
+		}
+		if ms.UtilityDamageTaken != 40 || ms.FlashesReceived != 1 || ms.BlindTimeReceivedSec != 2.5 {
+			t.Errorf("B util taken/flashed/blind = %d/%d/%.2fs, want 40/1/2.50s",
+				ms.UtilityDamageTaken, ms.FlashesReceived, ms.BlindTimeReceivedSec)
+		}
+		return
+	}
+	t.Fatal("playerB not found in matchStats")
+}
+
+func TestConsistency(t *testing.T) {
+	// Ratings ≈ 2.15 (boom), 1.08 and 0.20 (bust); ADR 120/75/30; KAST 90/70/40%.
+	// The zero-round match is skipped.
+	stats := []model.PlayerMatchStats{
+		{Kills: 30, Assists: 4, Deaths: 10, RoundsPlayed: 20, KASTRounds: 18, TotalDamage: 2400},
+		{Kills: 15, Assists: 4, Deaths: 15, RoundsPlayed: 20, KASTRounds: 14, TotalDamage: 1500},
+		{},
+		{Kills: 5, Assists: 2, Deaths: 18, RoundsPlayed: 20, KASTRounds: 8, TotalDamage: 600},
+	}
+	c := Consistency(stats)
+	if c.Matches != 3 || c.Booms != 1 || c.Busts != 1 {
+		t.Errorf("matches/booms/busts = %d/%d/%d, want 3/1/1", c.Matches, c.Booms, c.Busts)
+	}
+	if math.Abs(c.BoomBustPct()-200.0/3) > 0.01 {
+		t.Errorf("BoomBustPct = %.2f, want 66.67", c.BoomBustPct())
+	}
+	if math.Abs(c.ADRSD-45) > 0.01 || math.Abs(c.ADRIQR-45) > 0.01 {
+		t.Errorf("ADR sd/iqr = %.2f/%.2f, want 45/45", c.ADRSD, c.ADRIQR)
+	}
+	if math.Abs(c.KASTSD-25.17) > 0.01 || math.Abs(c.KASTIQR-25) > 0.01 {
+		t.Errorf("KAST sd/iqr = %.2f/%.2f, want 25.17/25", c.KASTSD, c.KASTIQR)
+	}
+	if c.RatingSD <= 0 || c.RatingIQR <= 0 {
+		t.Errorf("rating sd/iqr = %.2f/%.2f, want > 0", c.RatingSD, c.RatingIQR)
+	}
+
+	// Interpolated quartiles: Q1 = 4 (pos 1.75), Q3 = 5.5 (pos 5.25).
+	sd, iqr := spread([]float64{9, 2, 4, 4, 7, 4, 5, 5})
+	if math.Abs(sd-math.Sqrt(32.0/7)) > 1e-9 || iqr != 1.5 {
+		t.Errorf("spread = %.4f/%.2f, want %.4f/1.50", sd, iqr, math.Sqrt(32.0/7))
+	}
+	if sd, iqr := spread([]float64{1.1}); sd != 0 || iqr != 0 {
+		t.Errorf("single-value spread = %.2f/%.2f, want 0/0", sd, iqr)
+	}
+}
+
+// TestTilt: sessions are match dates; the match after a loss in the same
+// session counts toward the post-loss rating, but not across days.
+func TestTilt(t *testing.T) {
+	good := model.PlayerMatchStats{Kills: 30, Assists: 4, Deaths: 10, RoundsPlayed: 20, KASTRounds: 18, TotalDamage: 2400}
+	bad := model.PlayerMatchStats{Kills: 5, Assists: 2, Deaths: 18, RoundsPlayed: 20, KASTRounds: 8, TotalDamage: 600}
+	match := func(base model.PlayerMatchStats, date string, won int) model.PlayerMatchStats {
+		base.MatchDate, base.RoundsWon = date, won
+		return base
+	}
+	stats := []model.PlayerMatchStats{
+		match(good, "2025-01-01", 13), // W
+		match(bad, "2025-01-01", 5),   // L
+		match(bad, "2025-01-01", 5),   // L after L: streak of 2
+		match(bad, "2025-01-01", 10),  // draw after L
+		match(good, "2025-01-02", 4),  // L, new session
+		match(bad, "2025-01-03", 13),  // single-match day: not listed
+	}
+	tilt := Tilt(stats)
+	if len(tilt.Sessions) != 1 {
+		t.Fatalf("sessions = %+v, want 1", tilt.Sessions)
+	}
+	if s := tilt.Sessions[0]; s.Matches != 4 || s.Wins != 1 || s.Losses != 2 || s.LongestLossStreak != 2 {
+		t.Errorf("session = %+v, want 4 matches 1-2 with a 2-loss run", s)
+	}
+	if tilt.LossStreaks != 1 || tilt.AfterLoss != 2 || tilt.AfterWin != 1 {
+		t.Errorf("streaks/after loss/after win = %d/%d/%d, want 1/2/1", tilt.LossStreaks, tilt.AfterLoss, tilt.AfterWin)
+	}
+	want := bad.Rating()
+	if math.Abs(tilt.AfterLossRating-want) > 1e-9 || tilt.PostLossDelta() >= 0 {
+		t.Errorf("after-loss rating = %.2f (delta %.2f), want %.2f below baseline", tilt.AfterLossRating, tilt.PostLossDelta(), want)
+	}
+	if tilt.Tilted() {
+		t.Error("Tilted with 2 post-loss matches, want false (below TiltMinSamples)")
+	}
+}
+
+func TestDuelRoundContext(t *testing.T) {
+	// A beats B once per round. Round 1 opens the match and round 4 the
+	// second half (teams swap) on starting money: pistol. Round 2 is A's full
+	// buy against B's eco: anti-eco. Round 3 is a normal gun round, round 5
+	// swaps sides again but with overtime money (not a pistol round), and
+	// round 6 has no equipment values (gun).
+	type spec struct {
+		aTeam, bTeam model.Team
+		equip        map[uint64]int
+	}
+	specs := []spec{
+		{model.TeamT, model.TeamCT, map[uint64]int{playerA: 800, playerB: 850}},
+		{model.TeamT, model.TeamCT, map[uint64]int{playerA: 4700, playerB: 300}},
+		{model.TeamT, model.TeamCT, map[uint64]int{playerA: 4700, playerB: 4100}},
+		{model.TeamCT, model.TeamT, map[uint64]int{playerA: 800, playerB: 800}},
+		{model.TeamT, model.TeamCT, map[uint64]int{playerA: 6000, playerB: 5500}},
+		{model.TeamT, model.TeamCT, nil},
+	}
+	raw := makeRaw(nil, nil)
+	for i, sp := range specs {
+		rn := i + 1
+		raw.Rounds = append(raw.Rounds, model.RawRound{
+			Number: rn, FreezeEndTick: 0, EndTick: 5000, WinnerTeam: sp.aTeam,
+			PlayerEndState: map[uint64]model.PlayerRoundEndState{
+				playerA: {SteamID64: playerA, IsAlive: true, Team: sp.aTeam},
+				playerB: {SteamID64: playerB, Team: sp.bTeam},
+			},
+			PlayerEquipValues: sp.equip,
+		})
+		raw.Kills = append(raw.Kills, model.RawKill{Tick: 1000, RoundNumber: rn,
+			KillerSteamID: playerA, VictimSteamID: playerB,
+			KillerTeam: sp.aTeam, VictimTeam: sp.bTeam, Weapon: "AK-47"})
+		raw.FirstSights = append(raw.FirstSights, model.RawFirstSight{
+			Tick: 900, RoundNumber: rn, ObserverID: playerA, EnemyID: playerB})
+	}
+
+	_, _, _, segs, err := Aggregate(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	duels := make(map[string]int)
+	for _, s := range segs {
+		if s.SteamID == playerA {
+			duels[s.RoundContext] += s.DuelCount
+		}
+	}
+	want := map[string]int{model.RoundContextPistol: 2, model.RoundContextAntiEco: 1, model.RoundContextGun: 3}
+	for ctx, n := range want {
+		if duels[ctx] != n {
+			t.Errorf("%s duels = %d, want %d (all: %v)", ctx, duels[ctx], n, duels)
+		}
+	}
+}
+
+// ---- Momentum tests ----
+
+func TestMomentum(t *testing.T) {
+	// A and B swap sides after round 5. The winner's player kills the other in
+	// every round. First half: A's T side wins rounds 1–5, so rounds 4 and 5
+	// are A's streak rounds and B's bounce-back rounds. Second half: B's T side
+	// wins rounds 6–9 and A's CT side round 10. B's five straight first-half
+	// losses do not carry over: round 6 is not a bounce-back round.
+	var kills []model.RawKill
+	var rounds []model.RawRound
+	for rn := 1; rn <= 10; rn++ {
+		aTeam, bTeam := model.TeamT, model.TeamCT
+		if rn > 5 {
+			aTeam, bTeam = model.TeamCT, model.TeamT
+		}
+		winner, killer, victim := model.TeamT, playerA, playerB
+		switch {
+		case rn > 5 && rn < 10:
+			killer, victim = playerB, playerA
+		case rn == 10:
+			winner = model.TeamCT
+		}
+		teamOf := map[uint64]model.Team{playerA: aTeam, playerB: bTeam}
+		rounds = append(rounds, model.RawRound{
+			Number: rn, FreezeEndTick: 0, EndTick: 5000, WinnerTeam: winner,
+			PlayerEndState: map[uint64]model.PlayerRoundEndState{
+				killer: {SteamID64: killer, IsAlive: true, Team: teamOf[killer]},
+				victim: {SteamID64: victim, Team: teamOf[victim]},
+			},
+		})
+		kills = append(kills, model.RawKill{Tick: 1000, RoundNumber: rn,
+			KillerSteamID: killer, VictimSteamID: victim,
+			KillerTeam: teamOf[killer], VictimTeam: teamOf[victim], Weapon: "AK-47"})
+	}
+
+	stats, _, _, _, err := Aggregate(makeRaw(kills, rounds))
+	if err != nil {
+		t.Fatal(err)
+	}
+	type counts struct {
+		longest, streakRds, streakK, bounceRds, bounceK, bounceW, halfFK int
+	}
+	want := map[uint64]counts{
+		playerA: {longest: 5, streakRds: 2, streakK: 2, bounceRds: 2, bounceK: 1, bounceW: 1, halfFK: 1},
+		playerB: {longest: 4, streakRds: 2, streakK: 1, bounceRds: 2, bounceK: 0, bounceW: 0, halfFK: 1},
+	}
+	for _, s := range stats {
+		w, ok := want[s.SteamID]
+		if !ok {
+			continue
+		}
+		got := counts{s.LongestWinStreak, s.StreakRounds, s.StreakKills,
+			s.BounceRounds, s.BounceKills, s.BounceWins, s.HalfFirstKills}
+		if got != w {
+			t.Errorf("player %d momentum = %+v, want %+v", s.SteamID, got, w)
+		}
+		delete(want, s.SteamID)
+	}
+	if len(want) > 0 {
+		t.Errorf("missing stats for %v", want)
+	}
+}
+
+// ---- Position tests ----
+
+func TestPositions(t *testing.T) {
+	sample := func(tick, rn int, team model.Team, place string, x float64) model.RawPositionSample {
+		return model.RawPositionSample{Tick: tick, RoundNumber: rn, PlayerID: playerA, Team: team,
+			Pos: model.Vec3{X: x, Y: 100}, Place: place}
+	}
+	raw := makeRaw(nil, nil)
+	raw.PositionSamples = []model.RawPositionSample{
+		// Round 1: Connector twice, Window once → Connector.
+		sample(100, 1, model.TeamCT, "Connector", 10),
+		sample(200, 1, model.TeamCT, "Window", 50),
+		sample(300, 1, model.TeamCT, "Connector", 30),
+		// Round 2: tie → the later sample (Window).
+		sample(100, 2, model.TeamCT, "Connector", 10),
+		sample(200, 2, model.TeamCT, "Window", 70),
+		// Round 3: T side, no callout → grid cell.
+		sample(100, 3, model.TeamT, "", -600),
+	}
+
+	rows := Positions(raw)
+	want := []model.PlayerPositionStats{
+		{DemoHash: "testhash", SteamID: playerA, Side: model.TeamCT, Place: "Connector", Rounds: 1, AvgX: 20, AvgY: 100},
+		{DemoHash: "testhash", SteamID: playerA, Side: model.TeamCT, Place: "Window", Rounds: 1, AvgX: 70, AvgY: 100},
+		{DemoHash: "testhash", SteamID: playerA, Side: model.TeamT, Place: "grid -2,0", Rounds: 1, AvgX: -600, AvgY: 100},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("Positions = %+v, want %+v", rows, want)
+	}
+	for i := range want {
+		if rows[i] != want[i] {
+			t.Errorf("row %d = %+v, want %+v", i, rows[i], want[i])
+		}
+	}
+
+	// Profiles across demos: 6 CT rounds on Mirage — Connector 3, Window 2,
+	// Jungle 1 → "Connector/Window"; an even five-way T split → "mixed".
+	profiles := PositionProfiles([]model.PlayerPositionStats{
+		{MapName: "de_mirage", SteamID: playerA, Side: model.TeamCT, Place: "Connector", Rounds: 2},
+		{MapName: "de_mirage", SteamID: playerA, Side: model.TeamCT, Place: "Connector", Rounds: 1},
+		{MapName: "de_mirage", SteamID: playerA, Side: model.TeamCT, Place: "Window", Rounds: 2},
+		{MapName: "de_mirage", SteamID: playerA, Side: model.TeamCT, Place: "Jungle", Rounds: 1},
+		{MapName: "de_mirage", SteamID: playerA, Side: model.TeamT, Place: "A Ramp", Rounds: 1},
+		{MapName: "de_mirage", SteamID: playerA, Side: model.TeamT, Place: "Palace", Rounds: 1},
+		{MapName: "de_mirage", SteamID: playerA, Side: model.TeamT, Place: "Mid", Rounds: 1},
+		{MapName: "de_mirage", SteamID: playerA, Side: model.TeamT, Place: "B Apartments", Rounds: 1},
+		{MapName: "de_mirage", SteamID: playerA, Side: model.TeamT, Place: "Underpass", Rounds: 1},
+	})
+	if len(profiles) != 2 {
+		t.Fatalf("PositionProfiles = %+v, want 2 profiles", profiles)
+	}
+	ct, tp := profiles[0], profiles[1]
+	if ct.Side != model.TeamCT || ct.Rounds != 6 || ct.Label != "Connector/Window" || ct.Places[0] != (model.PlaceShare{Place: "Connector", Rounds: 3}) {
+		t.Errorf("CT profile = %+v", ct)
+	}
+	if tp.Side != model.TeamT || tp.Rounds != 5 || tp.Label != "mixed" {
+		t.Errorf("T profile = %+v", tp)
+	}
+}
+
+// ---- Utility synergy tests ----
+
+func TestUtilitySynergy(t *testing.T) {
+	// A and C (T) against B and D (CT), two rounds.
+	// R1: A kills B 100 ticks after C's flash on B → off flash.
+	//     A kills D through C's smoke; A's own flash on D does not count → off smoke.
+	// R2: A kills B 3s after C's flash, through A's own smoke → nothing.
+	//     A kills D right after C's flash, through C's smoke → both, one off-utility kill.
+	endState := map[uint64]model.PlayerRoundEndState{
+		playerA: {SteamID64: playerA, IsAlive: true, Team: model.TeamT},
+		playerB: {SteamID64: playerB, Team: model.TeamCT},
+		playerC: {SteamID64: playerC, IsAlive: true, Team: model.TeamT},
+		playerD: {SteamID64: playerD, Team: model.TeamCT},
+	}
+	kill := func(rn, tick int, victim uint64, smokeBy uint64) model.RawKill {
+		return model.RawKill{Tick: tick, RoundNumber: rn, KillerSteamID: playerA, VictimSteamID: victim,
+			KillerTeam: model.TeamT, VictimTeam: model.TeamCT, Weapon: "AK-47",
+			ThroughSmoke: smokeBy != 0, SmokeThrowerID: smokeBy}
+	}
+	flash := func(rn, tick int, thrower, victim uint64) model.RawFlash {
+		return model.RawFlash{Tick: tick, RoundNumber: rn, AttackerSteamID: thrower, VictimSteamID: victim,
+			AttackerTeam: model.TeamT, VictimTeam: model.TeamCT, FlashDuration: 2 * time.Second}
+	}
+	raw := makeRaw([]model.RawKill{
+		kill(1, 1000, playerB, 0),
+		kill(1, 2000, playerD, playerC),
+		kill(2, 1000+3*int(tickRate), playerB, playerA),
+		kill(2, 2000, playerD, playerC),
+	}, []model.RawRound{
+		{Number: 1, EndTick: 5000, WinnerTeam: model.TeamT, PlayerEndState: endState},
+		{Number: 2, EndTick: 5000, WinnerTeam: model.TeamT, PlayerEndState: endState},
+	})
+	raw.Flashes = []model.RawFlash{
+		flash(1, 900, playerC, playerB),
+		flash(1, 1950, playerA, playerD),
+		flash(2, 999, playerC, playerB),
+		flash(2, 1999, playerC, playerD),
+	}
+
+	stats, _, _, _, err := Aggregate(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range stats {
+		if s.SteamID != playerA {
+			continue
+		}
+		if s.PlayedOffFlashKills != 2 || s.PlayedOffSmokeKills != 2 || s.PlayedOffUtilityKills != 3 {
+			t.Errorf("played off flash/smoke/utility = %d/%d/%d, want 2/2/3",
+				s.PlayedOffFlashKills, s.PlayedOffSmokeKills, s.PlayedOffUtilityKills)
+		}
+		return
+	}
+	t.Fatal("no stats for player A")
+}
+
+// TestTeamEconomy: each side's equipment is summed over its players and
+// classed by the per-player average; pistol rounds come from the side starts
+// and rounds without equipment values are skipped.
+func TestTeamEconomy(t *testing.T) {
+	rnd := func(n int, winner model.Team, equip map[uint64]int) model.RawRound {
+		return model.RawRound{
+			Number: n, WinnerTeam: winner,
+			PlayerEndState: map[uint64]model.PlayerRoundEndState{
+				playerA: {SteamID64: playerA, Team: model.TeamT},
+				playerB: {SteamID64: playerB, Team: model.TeamT},
+				playerC: {SteamID64: playerC, Team: model.TeamCT},
+				playerD: {SteamID64: playerD, Team: model.TeamCT},
+			},
+			PlayerEquipValues: equip,
+		}
+	}
+	raw := makeRaw(nil, []model.RawRound{
+		rnd(1, model.TeamT, map[uint64]int{playerA: 800, playerB: 850, playerC: 900, playerD: 700}),
+		rnd(2, model.TeamT, map[uint64]int{playerA: 3000, playerB: 2500, playerC: 1200, playerD: 1500}),
+		rnd(3, model.TeamCT, map[uint64]int{playerA: 400, playerB: 200, playerC: 5000, playerD: 4200}),
+		rnd(4, model.TeamCT, nil),
+	})
+
+	got := TeamEconomy(raw)
+	want := []model.TeamRoundEconomy{
+		{DemoHash: "testhash", RoundNumber: 1, Team: model.TeamCT, Players: 2, EquipValue: 1600, RoundType: model.TeamRoundPistol},
+		{DemoHash: "testhash", RoundNumber: 1, Team: model.TeamT, Players: 2, EquipValue: 1650, RoundType: model.TeamRoundPistol, Won: true},
+		{DemoHash: "testhash", RoundNumber: 2, Team: model.TeamCT, Players: 2, EquipValue: 2700, RoundType: model.TeamRoundSemiEco},
+		{DemoHash: "testhash", RoundNumber: 2, Team: model.TeamT, Players: 2, EquipValue: 5500, RoundType: model.TeamRoundForce, Won: true},
+		{DemoHash: "testhash", RoundNumber: 3, Team: model.TeamCT, Players: 2, EquipValue: 9200, RoundType: model.TeamRoundFullBuy, Won: true},
+		{DemoHash: "testhash", RoundNumber: 3, Team: model.TeamT, Players: 2, EquipValue: 600, RoundType: model.TeamRoundFullEco},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TeamEconomy =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+// TestDiagnostics: Aggregate records one timing per pass in run order, Timed
+// appends the helpers after it, and Diagnostics counts the raw events.
+func TestDiagnostics(t *testing.T) {
+	kills, round := buildTradeScenario(64)
+	raw := makeRaw(kills, []model.RawRound{round})
+	raw.WeaponFires = []model.RawWeaponFire{{Tick: 990, RoundNumber: 1, ShooterID: playerB, Weapon: "AK-47"}}
+	raw.PreLiveRounds = 2
+	raw.PassTimings = []model.PassTiming{{Pass: "parse", Ms: 1500}}
+
+	if _, _, _, _, err := Aggregate(raw); err != nil {
+		t.Fatalf("Aggregate: %v", err)
+	}
+	Timed(raw, "timeline", Timeline)
+
+	var passes []string
+	for _, p := range raw.PassTimings {
+		if p.Ms < 0 {
+			t.Errorf("pass %q took %.3f ms", p.Pass, p.Ms)
+		}
+		passes = append(passes, p.Pass)
+	}
+	if len(passes) < 4 || passes[0] != "parse" || passes[1] != "spectators" ||
+		passes[len(passes)-2] != "loss reasons" || passes[len(passes)-1] != "timeline" {
+		t.Errorf("passes = %v, want parse, spectators, …, loss reasons, timeline", passes)
+	}
+	seen := make(map[string]bool)
+	for _, p := range passes {
+		if seen[p] {
+			t.Errorf("pass %q recorded twice", p)
+		}
+		seen[p] = true
+	}
+
+	d := Diagnostics(raw)
+	if d.DemoHash != "testhash" || d.Rounds != 1 || d.Kills != 2 || d.WeaponFires != 1 || d.FirstSights != 0 || d.PreLiveRounds != 2 {
+		t.Errorf("Diagnostics = %+v", d)
+	}
+	if len(d.Passes) != len(passes) || d.TotalMs() < 1500 {
+		t.Errorf("Diagnostics passes = %d (total %.1f ms), want %d (≥ 1500 ms)", len(d.Passes), d.TotalMs(), len(passes))
+	}
+}
+
+func TestHighlightRounds(t *testing.T) {
+	rounds := []model.PlayerRoundStats{
+		{DemoHash: "a", RoundNumber: 1, Kills: 2},                                                        // not a highlight
+		{DemoHash: "a", RoundNumber: 2, Kills: 4},                                                        // 4k: score 4
+		{DemoHash: "a", RoundNumber: 3, Kills: 1, IsInClutch: true, WonRound: true, ClutchEnemyCount: 3}, // 1v3 won: score 7
+		{DemoHash: "b", RoundNumber: 4, Kills: 3, IsInClutch: true, ClutchEnemyCount: 2},                 // lost clutch, 3k: score 3
+		{DemoHash: "b", RoundNumber: 5, Kills: 0, IsInClutch: true, WonRound: true, ClutchEnemyCount: 1}, // 1v1 won (bomb): score 2
+		{DemoHash: "b", RoundNumber: 6, Kills: 4},                                                        // ties round 2, kept after it
+	}
+	got := HighlightRounds(rounds, 0)
+	want := []model.HighlightRound{
+		{DemoHash: "a", RoundNumber: 3, Kills: 1, ClutchVs: 3},
+		{DemoHash: "a", RoundNumber: 2, Kills: 4},
+		{DemoHash: "b", RoundNumber: 6, Kills: 4},
+		{DemoHash: "b", RoundNumber: 4, Kills: 3},
+		{DemoHash: "b", RoundNumber: 5, ClutchVs: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("HighlightRounds = %+v\nwant %+v", got, want)
+	}
+	if got := HighlightRounds(rounds, 2); len(got) != 2 || got[1].RoundNumber != 2 {
+		t.Errorf("HighlightRounds(n=2) = %+v, want rounds 3 and 2", got)
+	}
+	if got := HighlightRounds(rounds[:1], 5); len(got) != 0 {
+		t.Errorf("HighlightRounds without highlights = %+v, want none", got)
+	}
+}
+
+func TestLobbyComparison(t *testing.T) {
+	// Four players with rounds (ADR 60/80/80/100, TTK 500/400/600/0) and a
+	// spectator row without rounds, which is left out.
+	stats := []model.PlayerMatchStats{
+		{SteamID: 1, Name: "focus", RoundsPlayed: 20, TotalDamage: 1600, Kills: 10, Deaths: 10, MedianTTKMs: 500},
+		{SteamID: 2, RoundsPlayed: 20, TotalDamage: 1200, Kills: 8, Deaths: 12, MedianTTKMs: 400},
+		{SteamID: 3, RoundsPlayed: 20, TotalDamage: 1600, Kills: 12, Deaths: 8, MedianTTKMs: 600},
+		{SteamID: 4, RoundsPlayed: 20, TotalDamage: 2000, Kills: 15, Deaths: 5},
+		{SteamID: 5, TotalDamage: 9999},
+	}
+	c, ok := LobbyComparison(stats, 1)
+	if !ok || c.Players != 4 || c.Name != "focus" {
+		t.Fatalf("LobbyComparison = %+v, %v; want 4 players for focus", c, ok)
+	}
+	byName := make(map[string]model.LobbyMetric)
+	for _, m := range c.Metrics {
+		byName[m.Name] = m
+	}
+	if _, ok := byName["Match win%"]; ok {
+		t.Error("Match win% is the same for a whole team and should be skipped")
+	}
+	// ADR 80: beats 60, ties 80, loses to 100 → rank 2, percentile 50.
+	adr := byName["ADR"]
+	if adr.Value != 80 || adr.LobbyAvg != 80 || adr.LobbyN != 4 || adr.Rank != 2 || adr.Percentile != 50 {
+		t.Errorf("ADR = %+v, want value 80, avg 80 over 4, rank 2, pctl 50", adr)
+	}
+	// Lower TTK is better; the player without a TTK is left out.
+	ttk := byName["Median TTK"]
+	if ttk.LobbyN != 3 || ttk.Rank != 2 || ttk.Percentile != 50 || ttk.LobbyAvg != 500 {
+		t.Errorf("Median TTK = %+v, want avg 500 over 3, rank 2, pctl 50", ttk)
+	}
+	// No crosshair encounters for the focus player: no value.
+	if x := byName["Crosshair median"]; x.HasValue || x.Rank != 0 {
+		t.Errorf("Crosshair median = %+v, want no value", x)
+	}
+	if _, ok := LobbyComparison(stats, 5); ok {
+		t.Error("a player without rounds should have no comparison")
+	}
+}
+
+func TestProgress(t *testing.T) {
+	// 13 matches: one without rounds (skipped), then 6 previous with ADR
+	// 60–65, then 6 recent with ADR 90–95. Median TTK drops from 600 to 400 ms.
+	stats := []model.PlayerMatchStats{{MatchDate: "2024-01-01"}}
+	for i := 0; i < 12; i++ {
+		s := model.PlayerMatchStats{
+			MatchDate:    "2024-02-" + strconv.Itoa(10+i),
+			RoundsPlayed: 20,
+			TotalDamage:  20 * (60 + i%6),
+			Kills:        10,
+			Deaths:       10,
+			MedianTTKMs:  600,
+		}
+		if i >= 6 {
+			s.TotalDamage += 20 * 30
+			s.MedianTTKMs = 400
+		}
+		stats = append(stats, s)
+	}
+
+	p := Progress(stats, 6)
+	if p.Recent != 6 || p.Previous != 6 {
+		t.Fatalf("windows = %d recent, %d previous, want 6 and 6", p.Recent, p.Previous)
+	}
+	if p.PreviousFrom != "2024-02-10" || p.RecentTo != "2024-02-21" {
+		t.Errorf("dates = %s..%s, want 2024-02-10..2024-02-21", p.PreviousFrom, p.RecentTo)
+	}
+	byName := make(map[string]model.ProgressMetric)
+	for _, m := range p.Metrics {
+		byName[m.Name] = m
+	}
+	adr := byName["ADR"]
+	if math.Abs(adr.Previous-62.5) > 1e-9 || math.Abs(adr.Recent-92.5) > 1e-9 {
+		t.Errorf("ADR = %.2f → %.2f, want 62.5 → 92.5", adr.Previous, adr.Recent)
+	}
+	if !adr.Improved() || !adr.Significant() {
+		t.Errorf("ADR improved=%v significant=%v (t=%.2f), want both", adr.Improved(), adr.Significant(), adr.T)
+	}
+	// No spread in either window: no t statistic, so not significant.
+	ttk := byName["Median TTK"]
+	if !ttk.Improved() || ttk.Significant() || ttk.T != 0 {
+		t.Errorf("Median TTK improved=%v significant=%v t=%.2f, want improved, not significant, t=0", ttk.Improved(), ttk.Significant(), ttk.T)
+	}
+	if kd := byName["K/D"]; kd.Delta() != 0 || kd.Significant() {
+		t.Errorf("K/D delta=%.2f significant=%v, want unchanged", kd.Delta(), kd.Significant())
+	}
+	// Undefined for every match: no samples in either window.
+	if cs := byName["Counter-strafe%"]; cs.PreviousN != 0 || cs.RecentN != 0 {
+		t.Errorf("Counter-strafe%% N = %d/%d, want 0/0", cs.PreviousN, cs.RecentN)
+	}
+
+	// A larger window leaves a short previous window.
+	p = Progress(stats, 10)
+	if p.Recent != 10 || p.Previous != 2 {
+		t.Errorf("window 10: %d recent, %d previous, want 10 and 2", p.Recent, p.Previous)
+	}
+	if adr := p.Metrics[2]; adr.Significant() {
+		t.Errorf("window 10: ADR significant with %d previous matches", adr.PreviousN)
+	}
+	if p = Progress(stats, 20); p.Previous != 0 || p.Recent != 12 {
+		t.Errorf("window 20: %d recent, %d previous, want 12 and 0", p.Recent, p.Previous)
+	}
+}
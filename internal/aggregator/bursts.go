@@ -0,0 +1,118 @@
+package aggregator
+
+import (
+	"sort"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// burstGapSec is the longest pause between two shots of the same weapon that
+// still counts as one burst. Full-auto rifles fire every ~0.1s, so a 0.2s gap
+// joins held-down sprays while a deliberate re-tap starts a new burst.
+const burstGapSec = 0.2
+
+// burstCounts is a burst-length histogram: taps (1 shot), short bursts (2–3),
+// sprays (4–9) and panic sprays (10+).
+type burstCounts struct {
+	taps, short, sprays, panic int
+}
+
+func (c *burstCounts) add(shots int) {
+	switch {
+	case shots >= 10:
+		c.panic++
+	case shots >= 4:
+		c.sprays++
+	case shots >= 2:
+		c.short++
+	default:
+		c.taps++
+	}
+}
+
+// burst is one run of consecutive shots by a player with one weapon.
+type burst struct {
+	playerID uint64
+	bucket   string
+	shots    int
+}
+
+// bursts splits every player's weapon fires into bursts: consecutive shots in
+// the same round with the same weapon, each at most burstGapSec after the
+// previous one. Bolt-action snipers (AWP, Scout) are skipped — every shot is a
+// tap by construction.
+func bursts(raw *model.RawMatch) []burst {
+	tps := raw.TicksPerSecond
+	if tps == 0 {
+		tps = 64.0
+	}
+	maxGap := int(burstGapSec * tps)
+
+	byShooter := make(map[uint64][]model.RawWeaponFire)
+	for _, wf := range raw.WeaponFires {
+		if b := weaponBucket(wf.Weapon); b == "AWP" || b == "Scout" {
+			continue
+		}
+		byShooter[wf.ShooterID] = append(byShooter[wf.ShooterID], wf)
+	}
+	ids := make([]uint64, 0, len(byShooter))
+	for id := range byShooter {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var out []burst
+	for _, id := range ids {
+		fires := byShooter[id]
+		sort.SliceStable(fires, func(i, j int) bool { return fires[i].Tick < fires[j].Tick })
+		cur := burst{playerID: id, bucket: weaponBucket(fires[0].Weapon), shots: 1}
+		for i := 1; i < len(fires); i++ {
+			prev, wf := fires[i-1], fires[i]
+			if wf.RoundNumber == prev.RoundNumber && wf.Weapon == prev.Weapon && wf.Tick-prev.Tick <= maxGap {
+				cur.shots++
+				continue
+			}
+			out = append(out, cur)
+			cur = burst{playerID: id, bucket: weaponBucket(wf.Weapon), shots: 1}
+		}
+		out = append(out, cur)
+	}
+	return out
+}
+
+// Bursts returns each player's burst-length histogram per weapon bucket, to
+// spot over-spraying habits with a given weapon class.
+func Bursts(raw *model.RawMatch) []model.PlayerBurstStats {
+	type key struct {
+		playerID uint64
+		bucket   string
+	}
+	counts := make(map[key]*burstCounts)
+	for _, b := range bursts(raw) {
+		k := key{b.playerID, b.bucket}
+		if counts[k] == nil {
+			counts[k] = &burstCounts{}
+		}
+		counts[k].add(b.shots)
+	}
+
+	out := make([]model.PlayerBurstStats, 0, len(counts))
+	for k, c := range counts {
+		out = append(out, model.PlayerBurstStats{
+			DemoHash:     raw.DemoHash,
+			SteamID:      k.playerID,
+			WeaponBucket: k.bucket,
+			Taps:         c.taps,
+			ShortBursts:  c.short,
+			Sprays:       c.sprays,
+			PanicSprays:  c.panic,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].SteamID != out[j].SteamID {
+			return out[i].SteamID < out[j].SteamID
+		}
+		return out[i].WeaponBucket < out[j].WeaponBucket
+	})
+	return out
+}
@@ -0,0 +1,81 @@
+package aggregator
+
+import (
+	"sort"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// Gini returns the Gini coefficient of values: the mean absolute difference
+// between every pair, divided by twice the mean. It is 0 when all values are
+// equal and (n−1)/n when one value holds the whole sum; 0 for fewer than two
+// values or a zero sum. Negative values are not expected.
+func Gini(values []float64) float64 {
+	n := len(values)
+	if n < 2 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	var sum, weighted float64
+	for i, v := range sorted {
+		sum += v
+		weighted += float64(i+1) * v
+	}
+	if sum == 0 {
+		return 0
+	}
+	return 2*weighted/(float64(n)*sum) - float64(n+1)/float64(n)
+}
+
+// TopShare returns the largest value's share of the sum of values, or 0
+// when the sum is 0.
+func TopShare(values []float64) float64 {
+	var sum, top float64
+	for _, v := range values {
+		sum += v
+		if v > top {
+			top = v
+		}
+	}
+	if sum == 0 {
+		return 0
+	}
+	return top / sum
+}
+
+// TeamConcentration returns each team's kill and damage concentration in one
+// match, CT then T (by the side each player spent most rounds on). Players
+// without a round played are left out; ties for top player go to the first
+// in stats order.
+func TeamConcentration(stats []model.PlayerMatchStats) []model.TeamConcentration {
+	var out []model.TeamConcentration
+	for _, team := range []model.Team{model.TeamCT, model.TeamT} {
+		c := model.TeamConcentration{Team: team}
+		var kills, damage []float64
+		topKills, topDamage := -1, -1
+		for _, s := range stats {
+			if s.Team != team || s.RoundsPlayed == 0 {
+				continue
+			}
+			c.Players++
+			kills = append(kills, float64(s.Kills))
+			damage = append(damage, float64(s.TotalDamage))
+			if s.Kills > topKills {
+				topKills, c.TopKiller = s.Kills, s.Name
+			}
+			if s.TotalDamage > topDamage {
+				topDamage, c.TopDamager = s.TotalDamage, s.Name
+			}
+		}
+		if c.Players == 0 {
+			continue
+		}
+		c.KillGini = Gini(kills)
+		c.DamageGini = Gini(damage)
+		c.TopKillShare = TopShare(kills)
+		c.TopDamageShare = TopShare(damage)
+		out = append(out, c)
+	}
+	return out
+}
@@ -0,0 +1,72 @@
+package aggregator
+
+import (
+	"math"
+	"sort"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// Consistency measures how much a player's per-match rating, ADR and KAST%
+// vary across stats (one row per match): sample standard deviation and
+// interquartile range of each, plus boom (rating ≥ model.BoomRating) and bust
+// (≤ model.BustRating) game counts. Matches with no rounds played are skipped.
+func Consistency(stats []model.PlayerMatchStats) model.PlayerConsistency {
+	var ratings, adrs, kasts []float64
+	var c model.PlayerConsistency
+	for i := range stats {
+		s := &stats[i]
+		if s.RoundsPlayed == 0 {
+			continue
+		}
+		r := s.Rating()
+		ratings = append(ratings, r)
+		adrs = append(adrs, s.ADR())
+		kasts = append(kasts, s.KASTPct())
+		switch {
+		case r >= model.BoomRating:
+			c.Booms++
+		case r <= model.BustRating:
+			c.Busts++
+		}
+	}
+	c.Matches = len(ratings)
+	c.RatingSD, c.RatingIQR = spread(ratings)
+	c.ADRSD, c.ADRIQR = spread(adrs)
+	c.KASTSD, c.KASTIQR = spread(kasts)
+	return c
+}
+
+// spread returns the sample standard deviation and interquartile range of
+// values, or zeros with fewer than two values. values is sorted in place.
+func spread(values []float64) (sd, iqr float64) {
+	n := len(values)
+	if n < 2 {
+		return 0, 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(n)
+	var ss float64
+	for _, v := range values {
+		ss += (v - mean) * (v - mean)
+	}
+	sort.Float64s(values)
+	return math.Sqrt(ss / float64(n-1)), quantile(values, 0.75) - quantile(values, 0.25)
+}
+
+// quantile returns the q-quantile (0-1) of sorted values, interpolating
+// linearly between the closest ranks.
+func quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	pos := q * float64(len(sorted)-1)
+	lo := int(pos)
+	if lo+1 >= len(sorted) {
+		return sorted[lo]
+	}
+	return sorted[lo] + (pos-float64(lo))*(sorted[lo+1]-sorted[lo])
+}
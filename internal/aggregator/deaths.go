@@ -0,0 +1,240 @@
+package aggregator
+
+import (
+	"math"
+	"sort"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// deathPosWindowSec bounds how long before a kill the killer's last shot may
+// be to serve as the killer's position for the death distance.
+const deathPosWindowSec = 2.0
+
+// DeathProfile returns each player's deaths to enemies grouped by the killer's
+// weapon bucket and the killer-to-victim distance bin — the "died to"
+// counterpart of the duel segments. World deaths, suicides and team kills are
+// excluded. The distance uses the killer's last shot in the deathPosWindowSec
+// before the kill and the victim's position at the killer's last hit on them;
+// it is "unknown" when either is missing or the last hit was utility.
+func DeathProfile(raw *model.RawMatch) []model.PlayerDeathSegment {
+	tps := raw.TicksPerSecond
+	if tps == 0 {
+		tps = 64.0
+	}
+	window := int(deathPosWindowSec * tps)
+
+	type shooterRound struct {
+		id    uint64
+		round int
+	}
+	fires := make(map[shooterRound][]model.RawWeaponFire)
+	for _, wf := range raw.WeaponFires {
+		k := shooterRound{wf.ShooterID, wf.RoundNumber}
+		fires[k] = append(fires[k], wf)
+	}
+	for k := range fires {
+		sort.Slice(fires[k], func(i, j int) bool { return fires[k][i].Tick < fires[k][j].Tick })
+	}
+	type pairRound struct {
+		attacker, victim uint64
+		round            int
+	}
+	hits := make(map[pairRound][]model.RawDamage)
+	for _, d := range raw.Damages {
+		k := pairRound{d.AttackerSteamID, d.VictimSteamID, d.RoundNumber}
+		hits[k] = append(hits[k], d)
+	}
+
+	type segKey struct {
+		victim uint64
+		bucket string
+		bin    string
+	}
+	segs := make(map[segKey]*model.PlayerDeathSegment)
+	for _, kill := range raw.Kills {
+		if kill.KillerSteamID == 0 || kill.KillerSteamID == kill.VictimSteamID || kill.KillerTeam == kill.VictimTeam {
+			continue
+		}
+
+		var lastHit *model.RawDamage
+		pair := hits[pairRound{kill.KillerSteamID, kill.VictimSteamID, kill.RoundNumber}]
+		for i := range pair {
+			if pair[i].Tick <= kill.Tick && (lastHit == nil || pair[i].Tick >= lastHit.Tick) {
+				lastHit = &pair[i]
+			}
+		}
+		var lastFire *model.RawWeaponFire
+		list := fires[shooterRound{kill.KillerSteamID, kill.RoundNumber}]
+		for i := len(list) - 1; i >= 0; i-- {
+			if list[i].Tick <= kill.Tick {
+				if kill.Tick-list[i].Tick <= window {
+					lastFire = &list[i]
+				}
+				break
+			}
+		}
+
+		distM := -1.0
+		if lastHit != nil && !lastHit.IsUtility && lastFire != nil {
+			dx := lastFire.AttackerPos.X - lastHit.VictimPos.X
+			dy := lastFire.AttackerPos.Y - lastHit.VictimPos.Y
+			dz := lastFire.AttackerPos.Z - lastHit.VictimPos.Z
+			distM = math.Sqrt(dx*dx+dy*dy+dz*dz) * unitsToMeters
+		}
+
+		k := segKey{kill.VictimSteamID, weaponBucket(kill.Weapon), distanceBin(distM)}
+		if segs[k] == nil {
+			segs[k] = &model.PlayerDeathSegment{
+				DemoHash:     raw.DemoHash,
+				SteamID:      k.victim,
+				WeaponBucket: k.bucket,
+				DistanceBin:  k.bin,
+			}
+		}
+		segs[k].Deaths++
+		if kill.IsHeadshot {
+			segs[k].HeadshotDeaths++
+		}
+	}
+
+	out := make([]model.PlayerDeathSegment, 0, len(segs))
+	for _, s := range segs {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].SteamID != out[j].SteamID {
+			return out[i].SteamID < out[j].SteamID
+		}
+		if out[i].WeaponBucket != out[j].WeaponBucket {
+			return out[i].WeaponBucket < out[j].WeaponBucket
+		}
+		return out[i].DistanceBin < out[j].DistanceBin
+	})
+	return out
+}
+
+// deathContextWindowSec is how far before a death DeathContexts looks.
+const deathContextWindowSec = 3.0
+
+// DeathContexts returns each player's deaths to enemies counted by what they
+// were doing in the deathContextWindowSec before dying: moving or holding at
+// the killer's first bullet hit in the window (unknown without one), flashed,
+// isolated (no alive teammate within 512 units at the kill) and whether they
+// had killed an enemy themselves. Exclusions as in DeathProfile.
+func DeathContexts(raw *model.RawMatch) []model.PlayerDeathContext {
+	tps := raw.TicksPerSecond
+	if tps == 0 {
+		tps = 64.0
+	}
+	window := int(deathContextWindowSec * tps)
+
+	type pairRound struct {
+		attacker, victim uint64
+		round            int
+	}
+	hits := make(map[pairRound][]model.RawDamage)
+	for _, d := range raw.Damages {
+		if d.IsUtility {
+			continue
+		}
+		k := pairRound{d.AttackerSteamID, d.VictimSteamID, d.RoundNumber}
+		hits[k] = append(hits[k], d)
+	}
+	type playerRound struct {
+		id    uint64
+		round int
+	}
+	flashed := make(map[playerRound][]int)
+	for _, fl := range raw.Flashes {
+		if fl.FlashDuration > 0 {
+			k := playerRound{fl.VictimSteamID, fl.RoundNumber}
+			flashed[k] = append(flashed[k], fl.Tick)
+		}
+	}
+	killed := make(map[playerRound][]int)
+	for _, k := range raw.Kills {
+		if k.KillerSteamID != 0 && k.KillerTeam != k.VictimTeam {
+			pk := playerRound{k.KillerSteamID, k.RoundNumber}
+			killed[pk] = append(killed[pk], k.Tick)
+		}
+	}
+	inWindow := func(ticks []int, from, to int) bool {
+		for _, t := range ticks {
+			if t >= from && t <= to {
+				return true
+			}
+		}
+		return false
+	}
+
+	type ctxKey struct {
+		victim                       uint64
+		movement                     string
+		flashed, isolated, afterKill bool
+	}
+	counts := make(map[ctxKey]int)
+	for _, kill := range raw.Kills {
+		if kill.KillerSteamID == 0 || kill.KillerSteamID == kill.VictimSteamID || kill.KillerTeam == kill.VictimTeam {
+			continue
+		}
+		from := kill.Tick - window
+		var first *model.RawDamage
+		pair := hits[pairRound{kill.KillerSteamID, kill.VictimSteamID, kill.RoundNumber}]
+		for i := range pair {
+			if pair[i].Tick >= from && pair[i].Tick <= kill.Tick && (first == nil || pair[i].Tick < first.Tick) {
+				first = &pair[i]
+			}
+		}
+		movement := model.DeathMovementUnknown
+		if first != nil {
+			movement = model.DeathHolding
+			if first.VictimSpeed > csThreshold {
+				movement = model.DeathMoving
+			}
+		}
+		pr := playerRound{kill.VictimSteamID, kill.RoundNumber}
+		counts[ctxKey{
+			victim:    kill.VictimSteamID,
+			movement:  movement,
+			flashed:   inWindow(flashed[pr], from, kill.Tick),
+			isolated:  kill.NearbyVictimTeammates == 0,
+			afterKill: inWindow(killed[pr], from, kill.Tick),
+		}]++
+	}
+
+	out := make([]model.PlayerDeathContext, 0, len(counts))
+	for k, n := range counts {
+		out = append(out, model.PlayerDeathContext{
+			DemoHash:  raw.DemoHash,
+			SteamID:   k.victim,
+			Movement:  k.movement,
+			Flashed:   k.flashed,
+			Isolated:  k.isolated,
+			AfterKill: k.afterKill,
+			Deaths:    n,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return deathContextLess(out[i], out[j]) })
+	return out
+}
+
+// deathContextLess orders death contexts by player, movement, then flags.
+func deathContextLess(a, b model.PlayerDeathContext) bool {
+	if a.SteamID != b.SteamID {
+		return a.SteamID < b.SteamID
+	}
+	if a.Movement != b.Movement {
+		return a.Movement < b.Movement
+	}
+	key := func(c model.PlayerDeathContext) int {
+		n := 0
+		for i, f := range []bool{c.Flashed, c.Isolated, c.AfterKill} {
+			if f {
+				n |= 1 << i
+			}
+		}
+		return n
+	}
+	return key(a) < key(b)
+}
@@ -0,0 +1,50 @@
+package aggregator
+
+import (
+	"time"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// passClock returns a function that appends the time since its previous call
+// (or since passClock) to raw.PassTimings under the given pass name.
+func passClock(raw *model.RawMatch) func(pass string) {
+	last := time.Now()
+	return func(pass string) {
+		now := time.Now()
+		raw.PassTimings = append(raw.PassTimings, model.PassTiming{Pass: pass, Ms: msSince(last, now)})
+		last = now
+	}
+}
+
+// msSince returns the milliseconds from start to end, to the microsecond.
+func msSince(start, end time.Time) float64 {
+	return float64(end.Sub(start).Microseconds()) / 1000
+}
+
+// Timed runs one of the raw-based helpers (DeathProfile, Timeline, …) and
+// records its wall time in raw.PassTimings under pass.
+func Timed[T any](raw *model.RawMatch, pass string, fn func(*model.RawMatch) T) T {
+	start := time.Now()
+	out := fn(raw)
+	raw.PassTimings = append(raw.PassTimings, model.PassTiming{Pass: pass, Ms: msSince(start, time.Now())})
+	return out
+}
+
+// Diagnostics returns the raw event counts of a parsed demo and the pass
+// timings recorded so far (parse, Aggregate's passes, Timed helpers).
+func Diagnostics(raw *model.RawMatch) model.DemoDiagnostics {
+	return model.DemoDiagnostics{
+		DemoHash:           raw.DemoHash,
+		Rounds:             len(raw.Rounds),
+		Kills:              len(raw.Kills),
+		Damages:            len(raw.Damages),
+		Flashes:            len(raw.Flashes),
+		WeaponFires:        len(raw.WeaponFires),
+		FirstSights:        len(raw.FirstSights),
+		PositionSamples:    len(raw.PositionSamples),
+		PreLiveRounds:      raw.PreLiveRounds,
+		ServerFrameDropPct: raw.ServerFrameDropPct,
+		Passes:             append([]model.PassTiming(nil), raw.PassTimings...),
+	}
+}
@@ -0,0 +1,228 @@
+package aggregator
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+const (
+	// maxDuelDistanceM caps the whole-meter distances kept per duel; longer
+	// duels are counted at the cap.
+	maxDuelDistanceM = 60
+	// QuantileBinCount is the number of distance bins quantile edges split a
+	// weapon bucket's duels into.
+	QuantileBinCount = 5
+	// MinQuantileDuels is the number of pooled duels a weapon bucket needs
+	// before quantile edges are derived for it; sparser buckets keep the fixed
+	// bins.
+	MinQuantileDuels = 100
+)
+
+// DuelDistances returns each player's won duels counted per weapon bucket and
+// whole-meter distance, with their first-hit and first-hit headshot counts.
+// Duels are the ones behind the duel segments (a kill preceded by the killer's
+// first sight of the victim) and the distance is measured the same way; duels
+// with no measurable distance are left out. The rows are the fine-grained
+// source for quantile distance bins (see QuantileSegments).
+func DuelDistances(raw *model.RawMatch) []model.PlayerDuelDistance {
+	type sightKey struct {
+		obsID, enemyID uint64
+		round          int
+	}
+	sights := make(map[sightKey]int)
+	for _, fs := range raw.FirstSights {
+		k := sightKey{fs.ObserverID, fs.EnemyID, fs.RoundNumber}
+		if _, ok := sights[k]; !ok {
+			sights[k] = fs.Tick
+		}
+	}
+	type pairRound struct {
+		attacker, victim uint64
+		round            int
+	}
+	hits := make(map[pairRound][]model.RawDamage)
+	for _, d := range raw.Damages {
+		if d.IsUtility {
+			continue
+		}
+		k := pairRound{d.AttackerSteamID, d.VictimSteamID, d.RoundNumber}
+		hits[k] = append(hits[k], d)
+	}
+	for k := range hits {
+		sort.Slice(hits[k], func(i, j int) bool { return hits[k][i].Tick < hits[k][j].Tick })
+	}
+	type shooterRound struct {
+		id    uint64
+		round int
+	}
+	fires := make(map[shooterRound][]model.RawWeaponFire)
+	for _, wf := range raw.WeaponFires {
+		k := shooterRound{wf.ShooterID, wf.RoundNumber}
+		fires[k] = append(fires[k], wf)
+	}
+	for k := range fires {
+		sort.Slice(fires[k], func(i, j int) bool { return fires[k][i].Tick < fires[k][j].Tick })
+	}
+
+	type distKey struct {
+		playerID uint64
+		bucket   string
+		meters   int
+	}
+	acc := make(map[distKey]*model.PlayerDuelDistance)
+	for _, kill := range raw.Kills {
+		sightTick, ok := sights[sightKey{kill.KillerSteamID, kill.VictimSteamID, kill.RoundNumber}]
+		if !ok || sightTick > kill.Tick {
+			continue
+		}
+		// The victim's position at the first hit and the killer's at the first
+		// shot in [sight, kill], as for the duel segments.
+		var firstHit *model.RawDamage
+		duelHits := hits[pairRound{kill.KillerSteamID, kill.VictimSteamID, kill.RoundNumber}]
+		for i := range duelHits {
+			if duelHits[i].Tick >= sightTick && duelHits[i].Tick <= kill.Tick {
+				firstHit = &duelHits[i]
+				break
+			}
+		}
+		var firstFire *model.RawWeaponFire
+		duelFires := fires[shooterRound{kill.KillerSteamID, kill.RoundNumber}]
+		for i := range duelFires {
+			if duelFires[i].Tick >= sightTick && duelFires[i].Tick <= kill.Tick {
+				firstFire = &duelFires[i]
+				break
+			}
+		}
+		if firstHit == nil || firstFire == nil {
+			continue
+		}
+		dx := firstFire.AttackerPos.X - firstHit.VictimPos.X
+		dy := firstFire.AttackerPos.Y - firstHit.VictimPos.Y
+		dz := firstFire.AttackerPos.Z - firstHit.VictimPos.Z
+		meters := min(int(math.Sqrt(dx*dx+dy*dy+dz*dz)*unitsToMeters), maxDuelDistanceM)
+
+		k := distKey{kill.KillerSteamID, weaponBucket(kill.Weapon), meters}
+		if acc[k] == nil {
+			acc[k] = &model.PlayerDuelDistance{
+				DemoHash: raw.DemoHash, SteamID: k.playerID, WeaponBucket: k.bucket, Meters: meters,
+			}
+		}
+		r := acc[k]
+		r.DuelCount++
+		r.FirstHitCount++
+		if firstHit.HitGroup == "head" {
+			r.FirstHitHSCount++
+		}
+	}
+
+	out := make([]model.PlayerDuelDistance, 0, len(acc))
+	for _, r := range acc {
+		out = append(out, *r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].SteamID != out[j].SteamID {
+			return out[i].SteamID < out[j].SteamID
+		}
+		if out[i].WeaponBucket != out[j].WeaponBucket {
+			return out[i].WeaponBucket < out[j].WeaponBucket
+		}
+		return out[i].Meters < out[j].Meters
+	})
+	return out
+}
+
+// QuantileEdges pools duel distance rows (typically from the baseline corpus)
+// per weapon bucket and returns, for each bucket with at least minDuels duels,
+// the whole-meter edges splitting its duels into bins bins of roughly equal
+// size. Edges are the ascending upper bounds (exclusive) of every bin but the
+// last; heavy ties can merge bins, so a bucket may get fewer than bins bins.
+func QuantileEdges(rows []model.PlayerDuelDistance, bins, minDuels int) map[string][]int {
+	hist := make(map[string]map[int]int)
+	total := make(map[string]int)
+	for _, r := range rows {
+		if hist[r.WeaponBucket] == nil {
+			hist[r.WeaponBucket] = make(map[int]int)
+		}
+		hist[r.WeaponBucket][r.Meters] += r.DuelCount
+		total[r.WeaponBucket] += r.DuelCount
+	}
+
+	out := make(map[string][]int)
+	for bucket, h := range hist {
+		n := total[bucket]
+		if n < minDuels || n == 0 || bins < 2 {
+			continue
+		}
+		meters := make([]int, 0, len(h))
+		for m := range h {
+			meters = append(meters, m)
+		}
+		sort.Ints(meters)
+		var edges []int
+		cum, next := 0, 1
+		for i, m := range meters {
+			cum += h[m]
+			// An edge after the last distance would leave the final bin empty.
+			for next < bins && float64(cum) >= float64(next)*float64(n)/float64(bins) {
+				if i < len(meters)-1 && (len(edges) == 0 || edges[len(edges)-1] < m+1) {
+					edges = append(edges, m+1)
+				}
+				next++
+			}
+		}
+		if len(edges) > 0 {
+			out[bucket] = edges
+		}
+	}
+	return out
+}
+
+// quantileBin returns the label of the bin holding meters under edges, e.g.
+// "7-12m" or "31m+".
+func quantileBin(meters int, edges []int) string {
+	lo := 0
+	for _, e := range edges {
+		if meters < e {
+			return fmt.Sprintf("%d-%dm", lo, e)
+		}
+		lo = e
+	}
+	return fmt.Sprintf("%dm+", lo)
+}
+
+// QuantileSegments regroups one player's duel distance rows into duel
+// segments, binning each weapon bucket by its edges from QuantileEdges and
+// falling back to the fixed bins for buckets without edges. Only the counts
+// are filled: the distance rows carry no angles or exposure times, so the
+// medians stay 0.
+func QuantileSegments(steamID uint64, rows []model.PlayerDuelDistance, edges map[string][]int) []model.PlayerDuelSegment {
+	type segKey struct{ bucket, bin string }
+	acc := make(map[segKey]*model.PlayerDuelSegment)
+	var order []segKey
+	for _, r := range rows {
+		if r.SteamID != steamID {
+			continue
+		}
+		bin := distanceBin(float64(r.Meters))
+		if e, ok := edges[r.WeaponBucket]; ok {
+			bin = quantileBin(r.Meters, e)
+		}
+		k := segKey{r.WeaponBucket, bin}
+		if acc[k] == nil {
+			acc[k] = &model.PlayerDuelSegment{SteamID: steamID, WeaponBucket: k.bucket, DistanceBin: k.bin}
+			order = append(order, k)
+		}
+		s := acc[k]
+		s.DuelCount += r.DuelCount
+		s.FirstHitCount += r.FirstHitCount
+		s.FirstHitHSCount += r.FirstHitHSCount
+	}
+	out := make([]model.PlayerDuelSegment, 0, len(order))
+	for _, k := range order {
+		out = append(out, *acc[k])
+	}
+	return out
+}
@@ -0,0 +1,52 @@
+package aggregator
+
+import (
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// ecoDamageCounts holds one player's rounds and damage split by the enemy
+// side's economy class.
+type ecoDamageCounts struct {
+	ecoRounds, ecoDamage int
+	buyRounds, buyDamage int
+}
+
+// ecoDamage splits each player's rounds and damage by the class of the enemy
+// side's economy (see TeamEconomy): full and semi ecos are eco rounds, forces
+// and full buys buy rounds. Pistol rounds and rounds without a known enemy
+// economy are in neither. Damage padded against saving sides shows up as an
+// eco ADR well above the buy ADR.
+func ecoDamage(raw *model.RawMatch, roundStats []model.PlayerRoundStats) map[uint64]*ecoDamageCounts {
+	type sideRound struct {
+		round int
+		team  model.Team
+	}
+	class := make(map[sideRound]string)
+	for _, e := range TeamEconomy(raw) {
+		class[sideRound{e.RoundNumber, e.Team}] = e.RoundType
+	}
+
+	out := make(map[uint64]*ecoDamageCounts)
+	for _, rs := range roundStats {
+		enemy := model.TeamCT
+		if rs.Team == model.TeamCT {
+			enemy = model.TeamT
+		} else if rs.Team != model.TeamT {
+			continue
+		}
+		c := out[rs.SteamID]
+		if c == nil {
+			c = &ecoDamageCounts{}
+			out[rs.SteamID] = c
+		}
+		switch class[sideRound{rs.RoundNumber, enemy}] {
+		case model.TeamRoundFullEco, model.TeamRoundSemiEco:
+			c.ecoRounds++
+			c.ecoDamage += rs.Damage
+		case model.TeamRoundForce, model.TeamRoundFullBuy:
+			c.buyRounds++
+			c.buyDamage += rs.Damage
+		}
+	}
+	return out
+}
@@ -0,0 +1,74 @@
+package aggregator
+
+import (
+	"sort"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// Per-player average equipment values (USD, freeze-end) bounding the team
+// economy classes; see teamRoundType.
+const (
+	teamSemiEcoMin = 1000
+	teamForceMin   = 2000
+	teamFullBuyMin = 4000
+)
+
+// teamRoundType classifies one side's round from its summed equipment value
+// over players: pistol rounds first, then by the per-player average so a side
+// playing short-handed isn't pushed into a poorer class.
+func teamRoundType(pistol bool, equip, players int) string {
+	if pistol {
+		return model.TeamRoundPistol
+	}
+	avg := equip / players
+	switch {
+	case avg >= teamFullBuyMin:
+		return model.TeamRoundFullBuy
+	case avg >= teamForceMin:
+		return model.TeamRoundForce
+	case avg >= teamSemiEcoMin:
+		return model.TeamRoundSemiEco
+	}
+	return model.TeamRoundFullEco
+}
+
+// TeamEconomy returns both sides' economy for every decided round of raw:
+// the summed freeze-end equipment value of each side's players, its class
+// (see the model.TeamRound* constants) and whether the side won. Pistol rounds
+// are the ones behind the pistol duel context. Rounds without a CT or T winner
+// and sides with no known equipment values (older demos) are left out.
+func TeamEconomy(raw *model.RawMatch) []model.TeamRoundEconomy {
+	pistol := pistolRounds(raw)
+	var out []model.TeamRoundEconomy
+	for _, rnd := range raw.Rounds {
+		if rnd.WinnerTeam != model.TeamCT && rnd.WinnerTeam != model.TeamT {
+			continue
+		}
+		for _, side := range []model.Team{model.TeamCT, model.TeamT} {
+			equip, players := 0, 0
+			for id, ps := range rnd.PlayerEndState {
+				v, ok := rnd.PlayerEquipValues[id]
+				if ps.Team != side || !ok {
+					continue
+				}
+				equip += v
+				players++
+			}
+			if players == 0 {
+				continue
+			}
+			out = append(out, model.TeamRoundEconomy{
+				DemoHash:    raw.DemoHash,
+				RoundNumber: rnd.Number,
+				Team:        side,
+				Players:     players,
+				EquipValue:  equip,
+				RoundType:   teamRoundType(pistol[rnd.Number], equip, players),
+				Won:         rnd.WinnerTeam == side,
+			})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].RoundNumber < out[j].RoundNumber })
+	return out
+}
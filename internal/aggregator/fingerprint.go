@@ -0,0 +1,103 @@
+package aggregator
+
+import (
+	"math"
+	"sort"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// fingerprintFeature extracts one comparable value from a fingerprint,
+// reporting false when the player has too few samples for it.
+type fingerprintFeature func(p model.PlayerFingerprint) (float64, bool)
+
+// fingerprintFeatures returns the features compared by CompareFingerprints:
+// TTK, counter-strafe %, crosshair median angle and % under 5°, and FHHS per
+// weapon bucket for every bucket any player has.
+func fingerprintFeatures(players []model.PlayerFingerprint) []fingerprintFeature {
+	features := []fingerprintFeature{
+		func(p model.PlayerFingerprint) (float64, bool) { return p.TTKMs, p.TTKMs > 0 },
+		func(p model.PlayerFingerprint) (float64, bool) { return p.CounterStrafePct, p.CounterStrafePct > 0 },
+		func(p model.PlayerFingerprint) (float64, bool) {
+			return p.CrosshairMedianDeg, p.CrosshairSamples >= model.FingerprintMinSamples
+		},
+		func(p model.PlayerFingerprint) (float64, bool) {
+			return p.CrosshairPctUnder5, p.CrosshairSamples >= model.FingerprintMinSamples
+		},
+	}
+	seen := make(map[string]bool)
+	var buckets []string
+	for _, p := range players {
+		for b := range p.FHHS {
+			if !seen[b] {
+				seen[b] = true
+				buckets = append(buckets, b)
+			}
+		}
+	}
+	sort.Strings(buckets)
+	for _, b := range buckets {
+		features = append(features, func(p model.PlayerFingerprint) (float64, bool) {
+			c := p.FHHS[b]
+			return c.Pct(), c.FirstHits >= model.FingerprintMinSamples
+		})
+	}
+	return features
+}
+
+// CompareFingerprints scores every candidate against target, most similar
+// first. Each feature's spread is the sample standard deviation over target and
+// candidates with samples for it; features with fewer than three such
+// players or no spread are skipped. Candidates sharing fewer than
+// model.FingerprintMinFeatures features with target are left out, as is
+// target itself.
+func CompareFingerprints(target model.PlayerFingerprint, candidates []model.PlayerFingerprint) []model.FingerprintMatch {
+	all := append([]model.PlayerFingerprint{target}, candidates...)
+	features := fingerprintFeatures(all)
+
+	sds := make([]float64, len(features))
+	for i, f := range features {
+		var vals []float64
+		for _, p := range all {
+			if v, ok := f(p); ok {
+				vals = append(vals, v)
+			}
+		}
+		if len(vals) >= 3 {
+			sds[i], _ = spread(vals)
+		}
+	}
+
+	var out []model.FingerprintMatch
+	for _, c := range candidates {
+		if c.SteamID == target.SteamID {
+			continue
+		}
+		m := model.FingerprintMatch{Player: c}
+		var sum float64
+		for i, f := range features {
+			if sds[i] == 0 {
+				continue
+			}
+			tv, ok1 := f(target)
+			cv, ok2 := f(c)
+			if !ok1 || !ok2 {
+				continue
+			}
+			sum += math.Abs(tv-cv) / sds[i]
+			m.Features++
+		}
+		if m.Features < model.FingerprintMinFeatures {
+			continue
+		}
+		m.Distance = sum / float64(m.Features)
+		out = append(out, m)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Distance != out[j].Distance {
+			return out[i].Distance < out[j].Distance
+		}
+		return out[i].Player.SteamID < out[j].Player.SteamID
+	})
+	return out
+}
@@ -0,0 +1,56 @@
+// Command genweapons writes weapon_names_gen.go in the aggregator package: the
+// sorted set of names demoinfocs's EquipmentType.String() produces. Run it
+// through `go generate ./internal/aggregator` (or `make generate`) after
+// bumping demoinfocs; TestWeaponBucketsCoverDemoinfocs then lists any name the
+// weapon bucket table is missing.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/markus-wa/demoinfocs-golang/v4/pkg/demoinfocs/common"
+)
+
+// maxEquipmentType bounds the scan; demoinfocs numbers equipment by class in
+// hundreds (pistols 1xx … grenades 5xx).
+const maxEquipmentType = 1000
+
+// outFile is written relative to the package being generated.
+const outFile = "weapon_names_gen.go"
+
+func main() {
+	seen := make(map[string]bool)
+	for t := common.EquipmentType(0); t < maxEquipmentType; t++ {
+		if name := t.String(); name != "" && t != common.EqUnknown {
+			seen[name] = true
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for n := range seen {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by genweapons; DO NOT EDIT.\n\n")
+	b.WriteString("package aggregator\n\n")
+	b.WriteString("// demoinfocsWeaponNames is every EquipmentType name demoinfocs produces.\n")
+	b.WriteString("var demoinfocsWeaponNames = []string{\n")
+	for _, n := range names {
+		fmt.Fprintf(&b, "\t%q,\n", n)
+	}
+	b.WriteString("}\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatalf("format: %v", err)
+	}
+	if err := os.WriteFile(outFile, src, 0o644); err != nil {
+		log.Fatalf("write %s: %v", outFile, err)
+	}
+}
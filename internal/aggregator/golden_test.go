@@ -0,0 +1,196 @@
+package aggregator
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// update rewrites the aggregator goldens from the current passes:
+//
+//	go test ./internal/aggregator -run TestGolden -update
+var update = flag.Bool("update", false, "rewrite testdata/golden golden files")
+
+// goldenDir holds synthetic RawMatch fixtures (<name>.raw.json) and the
+// pinned Aggregate output for each (<name>.golden.json).
+const goldenDir = "testdata/golden"
+
+// goldenOutput is everything Aggregate returns for one fixture, sorted so
+// the file does not depend on map iteration order.
+type goldenOutput struct {
+	MatchStats   []model.PlayerMatchStats
+	RoundStats   []model.PlayerRoundStats
+	WeaponStats  []model.PlayerWeaponStats
+	DuelSegments []model.PlayerDuelSegment
+}
+
+// readFixture loads the RawMatch fixture in path.
+func readFixture(path string) (*model.RawMatch, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw model.RawMatch
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return &raw, nil
+}
+
+// aggregateGolden runs Aggregate on the fixture in path and returns its
+// output as indented JSON.
+func aggregateGolden(path string) ([]byte, error) {
+	raw, err := readFixture(path)
+	if err != nil {
+		return nil, err
+	}
+	var out goldenOutput
+	out.MatchStats, out.RoundStats, out.WeaponStats, out.DuelSegments, err = Aggregate(raw)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(out.MatchStats, func(a, b model.PlayerMatchStats) int { return cmp.Compare(a.SteamID, b.SteamID) })
+	slices.SortFunc(out.RoundStats, func(a, b model.PlayerRoundStats) int {
+		return cmp.Or(cmp.Compare(a.RoundNumber, b.RoundNumber), cmp.Compare(a.SteamID, b.SteamID))
+	})
+	slices.SortFunc(out.WeaponStats, func(a, b model.PlayerWeaponStats) int {
+		return cmp.Or(cmp.Compare(a.SteamID, b.SteamID), cmp.Compare(a.Weapon, b.Weapon))
+	})
+	slices.SortFunc(out.DuelSegments, func(a, b model.PlayerDuelSegment) int {
+		return cmp.Or(cmp.Compare(a.SteamID, b.SteamID), cmp.Compare(a.RoundContext, b.RoundContext),
+			cmp.Compare(a.WeaponBucket, b.WeaponBucket), cmp.Compare(a.DistanceBin, b.DistanceBin))
+	})
+	return json.MarshalIndent(out, "", "  ")
+}
+
+// firstDiff returns the 1-based number and both versions of the first line
+// where got and want differ.
+func firstDiff(got, want []byte) (int, string, string) {
+	g, w := strings.Split(string(got), "\n"), strings.Split(string(want), "\n")
+	for i := 0; i < max(len(g), len(w)); i++ {
+		var gl, wl string
+		if i < len(g) {
+			gl = g[i]
+		}
+		if i < len(w) {
+			wl = w[i]
+		}
+		if gl != wl {
+			return i + 1, gl, wl
+		}
+	}
+	return 0, "", ""
+}
+
+// TestGolden pins the full Aggregate output for each synthetic fixture, so
+// a change in one pass that moves another pass's numbers (trade flags,
+// KAST, clutch starts, …) shows up as a golden diff to review.
+func TestGolden(t *testing.T) {
+	fixtures, err := filepath.Glob(filepath.Join(goldenDir, "*.raw.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) == 0 {
+		t.Fatalf("no fixtures in %s", goldenDir)
+	}
+	for _, fixture := range fixtures {
+		name := strings.TrimSuffix(filepath.Base(fixture), ".raw.json")
+		t.Run(name, func(t *testing.T) {
+			got, err := aggregateGolden(fixture)
+			if err != nil {
+				t.Fatalf("aggregate %s: %v", fixture, err)
+			}
+			golden := filepath.Join(goldenDir, name+".golden.json")
+			if *update {
+				if err := os.WriteFile(golden, append(got, '\n'), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("read golden (run with -update to create it): %v", err)
+			}
+			got, want = bytes.TrimSpace(got), bytes.TrimSpace(want)
+			if !bytes.Equal(got, want) {
+				line, g, w := firstDiff(got, want)
+				t.Errorf("output differs from %s at line %d:\n  got:  %s\n  want: %s\ninspect the change (git diff after -update) and re-run with -update if intended",
+					golden, line, strings.TrimSpace(g), strings.TrimSpace(w))
+			}
+		})
+	}
+}
+
+// TestAggregateRounds: keeping every round reproduces Aggregate, and two
+// complementary subsets of the scrim fixture add up to the whole match —
+// including the scoreline split, which needs the score of the rounds left
+// out — without turning the first kept round into a pistol round.
+func TestAggregateRounds(t *testing.T) {
+	path := filepath.Join(goldenDir, "scrim.raw.json")
+	load := func() *model.RawMatch {
+		raw, err := readFixture(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return raw
+	}
+	byID := func(stats []model.PlayerMatchStats) map[uint64]model.PlayerMatchStats {
+		out := make(map[uint64]model.PlayerMatchStats, len(stats))
+		for _, s := range stats {
+			out[s.SteamID] = s
+		}
+		return out
+	}
+
+	full, _, _, _, err := Aggregate(load())
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, _, _, _, err := AggregateRounds(load(), func(model.RawRound) bool { return true })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(byID(all), byID(full)) {
+		t.Error("AggregateRounds keeping every round differs from Aggregate")
+	}
+
+	raw := load()
+	early, _, _, _, err := AggregateRounds(raw, func(r model.RawRound) bool { return r.Number <= 4 })
+	if err != nil {
+		t.Fatal(err)
+	}
+	late, _, _, lateSegs, err := AggregateRounds(raw, func(r model.RawRound) bool { return r.Number > 4 })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(raw.Rounds) != 8 || raw.MatchRounds != nil {
+		t.Errorf("raw changed: %d rounds, MatchRounds %v", len(raw.Rounds), raw.MatchRounds != nil)
+	}
+	e, l := byID(early), byID(late)
+	for id, f := range byID(full) {
+		a, b := e[id], l[id]
+		if a.Kills+b.Kills != f.Kills || a.Deaths+b.Deaths != f.Deaths || a.TotalDamage+b.TotalDamage != f.TotalDamage ||
+			a.RoundsPlayed+b.RoundsPlayed != f.RoundsPlayed {
+			t.Errorf("%d: K/D/dmg/rounds %d/%d/%d/%d + %d/%d/%d/%d, want %d/%d/%d/%d", id,
+				a.Kills, a.Deaths, a.TotalDamage, a.RoundsPlayed, b.Kills, b.Deaths, b.TotalDamage, b.RoundsPlayed,
+				f.Kills, f.Deaths, f.TotalDamage, f.RoundsPlayed)
+		}
+		if a.Leading.Add(b.Leading) != f.Leading || a.Tied.Add(b.Tied) != f.Tied || a.Trailing.Add(b.Trailing) != f.Trailing {
+			t.Errorf("%d: scoreline splits of the subsets do not add up to the match", id)
+		}
+	}
+	for _, seg := range lateSegs {
+		if seg.RoundContext == model.RoundContextPistol {
+			t.Errorf("rounds 5-8 have a pistol duel segment: %+v", seg)
+		}
+	}
+}
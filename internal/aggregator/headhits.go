@@ -0,0 +1,43 @@
+package aggregator
+
+import (
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// headHitCounts holds one player's bullet hits on enemies and how many of
+// them landed on the head.
+type headHitCounts struct {
+	hits, headHits int
+}
+
+// bulletHit reports whether d is a bullet hit on an enemy: utility, knife
+// and Zeus damage, team damage and damage with no attacker are excluded.
+func bulletHit(d model.RawDamage) bool {
+	if d.AttackerSteamID == 0 || d.IsUtility || d.AttackerTeam == d.VictimTeam {
+		return false
+	}
+	return d.Weapon != "Knife" && d.Weapon != "Zeus x27"
+}
+
+// headHits counts, per attacker, the bullet hits on enemies and those with
+// the head hit group. HS% only sees the killing shot, so a player with a high
+// head-hit share but a low HS% lands head hits and lets body shots finish —
+// a finishing problem rather than an aim problem.
+func headHits(raw *model.RawMatch) map[uint64]*headHitCounts {
+	out := make(map[uint64]*headHitCounts)
+	for _, d := range raw.Damages {
+		if !bulletHit(d) {
+			continue
+		}
+		c := out[d.AttackerSteamID]
+		if c == nil {
+			c = &headHitCounts{}
+			out[d.AttackerSteamID] = c
+		}
+		c.hits++
+		if d.HitGroup == "head" {
+			c.headHits++
+		}
+	}
+	return out
+}
@@ -0,0 +1,121 @@
+package aggregator
+
+import (
+	"sort"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// damageDelayMaxSec bounds the delay between first sighting an enemy and first
+// damaging them that still counts as one engagement. Later damage comes from a
+// re-peek, not from holding fire on the first contact.
+const damageDelayMaxSec = 5.0
+
+// damageDelay is one sighting-to-first-damage sample.
+type damageDelay struct {
+	playerID uint64
+	bucket   string // weapon bucket of the first damaging hit
+	ms       float64
+}
+
+// damageDelays pairs every first sighting of an enemy with the observer's
+// first bullet damage to that enemy in the same round, at or after the
+// sighting and within damageDelayMaxSec. Kills are not required, so
+// engagements the enemy survived count too. Sightings without such damage,
+// team damage and utility damage are skipped.
+func damageDelays(raw *model.RawMatch) []damageDelay {
+	tps := raw.TicksPerSecond
+	if tps == 0 {
+		tps = 64.0
+	}
+	maxTicks := int(damageDelayMaxSec * tps)
+
+	type pairRound struct {
+		attacker, victim uint64
+		round            int
+	}
+	sights := make(map[pairRound]int) // → earliest sighting tick
+	for _, fs := range raw.FirstSights {
+		k := pairRound{fs.ObserverID, fs.EnemyID, fs.RoundNumber}
+		if t, ok := sights[k]; !ok || fs.Tick < t {
+			sights[k] = fs.Tick
+		}
+	}
+	hits := make(map[pairRound][]model.RawDamage)
+	for _, d := range raw.Damages {
+		if d.IsUtility || d.AttackerSteamID == 0 || d.AttackerTeam == d.VictimTeam {
+			continue
+		}
+		k := pairRound{d.AttackerSteamID, d.VictimSteamID, d.RoundNumber}
+		hits[k] = append(hits[k], d)
+	}
+
+	keys := make([]pairRound, 0, len(sights))
+	for k := range sights {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.round != b.round {
+			return a.round < b.round
+		}
+		if a.attacker != b.attacker {
+			return a.attacker < b.attacker
+		}
+		return a.victim < b.victim
+	})
+
+	var out []damageDelay
+	for _, k := range keys {
+		sightTick := sights[k]
+		var first *model.RawDamage
+		for i, d := range hits[k] {
+			if d.Tick >= sightTick && (first == nil || d.Tick < first.Tick) {
+				first = &hits[k][i]
+			}
+		}
+		if first == nil || first.Tick-sightTick > maxTicks {
+			continue
+		}
+		out = append(out, damageDelay{
+			playerID: k.attacker,
+			bucket:   weaponBucket(first.Weapon),
+			ms:       float64(first.Tick-sightTick) / tps * 1000,
+		})
+	}
+	return out
+}
+
+// TimeToDamage returns each player's median delay from first sighting an enemy
+// to first damaging them, per weapon bucket — a hesitation measure that, unlike
+// exposure time, also covers engagements that did not end in a kill.
+func TimeToDamage(raw *model.RawMatch) []model.PlayerTimeToDamage {
+	type key struct {
+		playerID uint64
+		bucket   string
+	}
+	samples := make(map[key][]float64)
+	for _, d := range damageDelays(raw) {
+		k := key{d.playerID, d.bucket}
+		samples[k] = append(samples[k], d.ms)
+	}
+
+	out := make([]model.PlayerTimeToDamage, 0, len(samples))
+	for k, ms := range samples {
+		sort.Float64s(ms)
+		out = append(out, model.PlayerTimeToDamage{
+			DemoHash:     raw.DemoHash,
+			SteamID:      k.playerID,
+			WeaponBucket: k.bucket,
+			Samples:      len(ms),
+			MedianMs:     median(ms),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].SteamID != out[j].SteamID {
+			return out[i].SteamID < out[j].SteamID
+		}
+		return out[i].WeaponBucket < out[j].WeaponBucket
+	})
+	return out
+}
@@ -0,0 +1,41 @@
+package aggregator
+
+import (
+	"sort"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// HighlightMinKills is the kill count that makes a round a highlight on its
+// own; a won clutch is a highlight with any number of kills.
+const HighlightMinKills = 3
+
+// HighlightRounds picks a player's standout rounds from rounds (one row per
+// round, any number of demos): rounds with HighlightMinKills or more kills and
+// won clutches. They are ranked by kills plus two per enemy faced in a won
+// clutch — a 1v3 win outranks a plain 4k — then by kills, and the first n are
+// returned (all with n ≤ 0). Ties keep the order of rounds.
+func HighlightRounds(rounds []model.PlayerRoundStats, n int) []model.HighlightRound {
+	var out []model.HighlightRound
+	for _, r := range rounds {
+		h := model.HighlightRound{DemoHash: r.DemoHash, RoundNumber: r.RoundNumber, Kills: r.Kills}
+		if r.IsInClutch && r.WonRound {
+			h.ClutchVs = r.ClutchEnemyCount
+		}
+		if h.Kills < HighlightMinKills && h.ClutchVs == 0 {
+			continue
+		}
+		out = append(out, h)
+	}
+	score := func(h model.HighlightRound) int { return h.Kills + 2*h.ClutchVs }
+	sort.SliceStable(out, func(i, j int) bool {
+		if si, sj := score(out[i]), score(out[j]); si != sj {
+			return si > sj
+		}
+		return out[i].Kills > out[j].Kills
+	})
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
@@ -0,0 +1,50 @@
+package aggregator
+
+import (
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// infoDeathCounts holds one player's deaths to enemies split by whether
+// anyone on the player's team had spotted the killer that round.
+type infoDeathCounts struct {
+	unspotted, spotted int
+}
+
+// infoDeaths classifies every death to an enemy by the killer's first
+// sightings that round: if no player on the victim's team (the victim
+// included) had spotted the killer at or before the kill tick, the death is
+// an information failure — the killer was never on the radar. Teamkills and
+// deaths without a killer are skipped.
+func infoDeaths(raw *model.RawMatch) map[uint64]*infoDeathCounts {
+	type sightKey struct {
+		enemyID uint64
+		roundN  int
+	}
+	// First sightings are always of an enemy, so any sighting of the killer
+	// comes from the victim's side.
+	firstSeen := make(map[sightKey]int) // → earliest tick the enemy was spotted
+	for _, fs := range raw.FirstSights {
+		k := sightKey{fs.EnemyID, fs.RoundNumber}
+		if t, ok := firstSeen[k]; !ok || fs.Tick < t {
+			firstSeen[k] = fs.Tick
+		}
+	}
+
+	out := make(map[uint64]*infoDeathCounts)
+	for _, k := range raw.Kills {
+		if k.KillerSteamID == 0 || k.KillerSteamID == k.VictimSteamID || k.KillerTeam == k.VictimTeam {
+			continue
+		}
+		c := out[k.VictimSteamID]
+		if c == nil {
+			c = &infoDeathCounts{}
+			out[k.VictimSteamID] = c
+		}
+		if t, ok := firstSeen[sightKey{k.KillerSteamID, k.RoundNumber}]; ok && t <= k.Tick {
+			c.spotted++
+		} else {
+			c.unspotted++
+		}
+	}
+	return out
+}
@@ -0,0 +1,70 @@
+package aggregator
+
+import (
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// lobbySkip names progress metrics left out of the lobby comparison: match
+// win% is the same for every player on a team, so ranking it says nothing.
+var lobbySkip = map[string]bool{"Match win%": true}
+
+// LobbyComparison compares one player with everyone else in the same match
+// on the progress report card metrics (stats is one row per player). The
+// lobby average includes the focus player; players with no rounds played or
+// no value for a metric are left out of that metric. The second result is
+// false when steamID did not play the match.
+func LobbyComparison(stats []model.PlayerMatchStats, steamID uint64) (model.LobbyComparison, bool) {
+	var focus *model.PlayerMatchStats
+	var played []*model.PlayerMatchStats
+	for i := range stats {
+		if stats[i].RoundsPlayed == 0 {
+			continue
+		}
+		played = append(played, &stats[i])
+		if stats[i].SteamID == steamID {
+			focus = &stats[i]
+		}
+	}
+	if focus == nil {
+		return model.LobbyComparison{}, false
+	}
+	c := model.LobbyComparison{SteamID: steamID, Name: focus.Name, Players: len(played)}
+	for _, m := range progressMetrics {
+		if lobbySkip[m.name] {
+			continue
+		}
+		lm := model.LobbyMetric{Name: m.name, Format: m.format, HigherIsBetter: m.higher}
+		v, ok := m.value(focus)
+		if !ok {
+			c.Metrics = append(c.Metrics, lm)
+			continue
+		}
+		lm.Value, lm.HasValue = v, true
+		var values []float64
+		var beaten, tied int
+		for _, s := range played {
+			o, ok := m.value(s)
+			if !ok {
+				continue
+			}
+			values = append(values, o)
+			if s == focus {
+				continue
+			}
+			switch {
+			case o == v:
+				tied++
+			case (v > o) == m.higher:
+				beaten++
+			}
+		}
+		lm.LobbyAvg = mean(values)
+		lm.LobbyN = len(values)
+		lm.Rank = len(values) - beaten - tied
+		if others := len(values) - 1; others > 0 {
+			lm.Percentile = (float64(beaten) + float64(tied)/2) / float64(others) * 100
+		}
+		c.Metrics = append(c.Metrics, lm)
+	}
+	return c, true
+}
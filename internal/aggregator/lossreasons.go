@@ -0,0 +1,96 @@
+package aggregator
+
+import (
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// lossFlashedSec is how long before a death a flash on the victim counts as
+// a contributing factor of the lost duel.
+const lossFlashedSec = 1.5
+
+// lossReasonCounts holds one player's lost duels by contributing factor. A
+// loss can have several factors; explained counts losses with at least one.
+type lossReasonCounts struct {
+	flashed, isolated, utilSupport, rePeek, explained int
+}
+
+// duelLossReasons tags each death to an enemy with the factors that
+// contributed to losing the duel: the victim was blinded by any flash in the
+// lossFlashedSec before the kill, no alive teammate was within 512 units
+// (isolated), the killer played off a teammate's flash or smoke (see
+// newUtilitySupport), or the victim had already killed an enemy earlier in
+// the round and re-peeked. World deaths, suicides and team kills are skipped.
+func duelLossReasons(raw *model.RawMatch) map[uint64]*lossReasonCounts {
+	tps := raw.TicksPerSecond
+	if tps == 0 {
+		tps = 64.0
+	}
+	window := int(lossFlashedSec * tps)
+
+	type playerRound struct {
+		id    uint64
+		round int
+	}
+	flashed := make(map[playerRound][]int)
+	for _, fl := range raw.Flashes {
+		if fl.FlashDuration > 0 {
+			k := playerRound{fl.VictimSteamID, fl.RoundNumber}
+			flashed[k] = append(flashed[k], fl.Tick)
+		}
+	}
+	killed := make(map[playerRound][]int)
+	for _, k := range raw.Kills {
+		if k.KillerSteamID != 0 && k.KillerTeam != k.VictimTeam {
+			pk := playerRound{k.KillerSteamID, k.RoundNumber}
+			killed[pk] = append(killed[pk], k.Tick)
+		}
+	}
+	support := newUtilitySupport(raw)
+
+	out := make(map[uint64]*lossReasonCounts)
+	for _, k := range raw.Kills {
+		if k.KillerSteamID == 0 || k.KillerSteamID == k.VictimSteamID || k.KillerTeam == k.VictimTeam {
+			continue
+		}
+		pr := playerRound{k.VictimSteamID, k.RoundNumber}
+		isFlashed := false
+		for _, t := range flashed[pr] {
+			if t <= k.Tick && k.Tick-t <= window {
+				isFlashed = true
+				break
+			}
+		}
+		isRePeek := false
+		for _, t := range killed[pr] {
+			if t < k.Tick {
+				isRePeek = true
+				break
+			}
+		}
+		offFlash, offSmoke := support(k)
+		isSupported := offFlash || offSmoke
+		isIsolated := k.NearbyVictimTeammates == 0
+
+		c := out[k.VictimSteamID]
+		if c == nil {
+			c = &lossReasonCounts{}
+			out[k.VictimSteamID] = c
+		}
+		if isFlashed {
+			c.flashed++
+		}
+		if isIsolated {
+			c.isolated++
+		}
+		if isSupported {
+			c.utilSupport++
+		}
+		if isRePeek {
+			c.rePeek++
+		}
+		if isFlashed || isIsolated || isSupported || isRePeek {
+			c.explained++
+		}
+	}
+	return out
+}
@@ -0,0 +1,316 @@
+package aggregator
+
+import (
+	"slices"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// metricRegistry is the structured changelog behind the `metrics` command:
+// one entry per reported metric with its exact definition, windows, stored
+// columns and the pipeline versions that introduced or changed it. When a
+// change bumps PipelineVersion, add the new metric here or append a
+// MetricChange to every metric whose stored values it alters.
+var metricRegistry = []model.MetricDef{
+	// General
+	{Name: "K / A / D", Group: "General",
+		Definition: "Kills, assists and deaths from kill events; self-kills and world deaths give no kill.",
+		Columns:    []string{"player_match_stats.kills", "player_match_stats.assists", "player_match_stats.deaths"},
+		Changes: []model.MetricChange{
+			{Version: 10, Note: "rounds attributed to the team the player was on that round (SteamIDs seen on both teams)"},
+			{Version: 30, Note: "coach and spectator slots with no kill, damage, shot or flash are no longer stored as players"},
+			{Version: 37, Note: "knife rounds and restarts before the match went live are discarded; rounds renumbered from the live start"},
+			{Version: 40, Note: "bots (SteamID 0) are no longer stored as one merged player; events with no real player dropped"},
+		}},
+	{Name: "HS%", Group: "General",
+		Definition: "headshot_kills / kills × 100.",
+		Columns:    []string{"player_match_stats.headshot_kills", "player_match_stats.kills"}},
+	{Name: "HEAD_HIT%", Group: "General",
+		Definition: "head_hits / bullet_hits × 100: share of bullet hits on enemies that landed on the head (knife, Zeus and utility excluded). Per weapon: head_hits / hits over every hit with it. Unlike HS% every hit counts, so a high HEAD_HIT% with a low HS% means head hits that body shots finished.",
+		Columns:    []string{"player_match_stats.bullet_hits", "player_match_stats.head_hits", "player_weapon_stats.head_hits", "player_weapon_stats.hits"},
+		Since:      41},
+	{Name: "ADR", Group: "General",
+		Definition: "total_damage / rounds_played; damage is capped at the victim's remaining health (overkill not counted).",
+		Columns:    []string{"player_match_stats.total_damage", "player_match_stats.rounds_played"},
+		Changes:    []model.MetricChange{{Version: 37, Note: "pre-live rounds (knife round, restarts) no longer counted"}}},
+	{Name: "ADR_BUY / ADR_ECO", Group: "General",
+		Definition: "ADR split by the enemy side's team economy class that round: buy_damage / buy_rounds against a force or full buy, eco_damage / eco_rounds against a full or semi eco. Pistol rounds count in neither. An ADR_ECO far above ADR_BUY means damage padded on save rounds.",
+		Columns:    []string{"player_match_stats.buy_rounds", "player_match_stats.buy_damage", "player_match_stats.eco_rounds", "player_match_stats.eco_damage", "team_round_economy.round_type"},
+		Since:      43},
+	{Name: "KAST%", Group: "General",
+		Definition: "Share of rounds with a kill, assist, survival, or a death traded by a teammate.",
+		Window:     "trade: 5s",
+		Columns:    []string{"player_match_stats.kast_rounds", "player_match_stats.rounds_played"}},
+	{Name: "KAST% (strict)", Group: "General",
+		Definition: "KAST% that does not count surviving a lost round without dealing damage (a save), as some stats sites define it. Shown in place of KAST% with --kast strict; RATING and KAST_SD keep the standard definition.",
+		Window:     "trade: 5s",
+		Columns:    []string{"player_match_stats.kast_strict_rounds", "player_match_stats.rounds_played"},
+		Since:      46},
+	{Name: "RATING", Group: "General",
+		Definition: "Rating 2.0 proxy: 0.0073·KAST% + 0.3591·KPR − 0.5329·DPR + 0.2372·Impact + 0.0032·ADR + 0.1587, Impact = 2.13·KPR + 0.42·APR − 0.41. Computed at report time, not stored.",
+		Columns:    []string{"player_match_stats.kast_rounds", "player_match_stats.kills", "player_match_stats.deaths", "player_match_stats.assists", "player_match_stats.total_damage"}},
+	{Name: "W%", Group: "General",
+		Definition: "Rounds the player's team won / rounds played.",
+		Columns:    []string{"player_match_stats.rounds_won", "player_round_stats.won_round"},
+		Changes: []model.MetricChange{
+			{Version: 10, Note: "round wins follow the team the player was on that round"},
+			{Version: 37, Note: "pre-live rounds (knife round, restarts) no longer counted"},
+		}},
+	{Name: "PING", Group: "General",
+		Definition: "Mean and peak scoreboard ping (m_iPing, ms) over readings every 5s from freeze end to the round end, dead players included. A mean of 100ms or more flags the match as played on a poor connection. Demos record no per-player packet loss; the server's dropped-frame share is kept per demo in the parse diagnostics.",
+		Window:     "one reading per player every 5s of live round time",
+		Columns:    []string{"player_match_stats.ping_samples", "player_match_stats.avg_ping_ms", "player_match_stats.max_ping_ms", "demo_diagnostics.server_frame_drop_pct"},
+		Since:      45},
+	{Name: "TEAM_CONFLICT", Group: "General",
+		Definition: "Rounds in which the SteamID was seen on both teams (coach slot or shared account); those rounds are attributed round by round.",
+		Columns:    []string{"player_match_stats.team_conflict_rounds"},
+		Since:      10},
+
+	// Entry frags and trades
+	{Name: "OPEN_K / OPEN_D", Group: "Entry Frags",
+		Definition: "Rounds where the player got / suffered the first kill after freeze time ended.",
+		Columns:    []string{"player_match_stats.opening_kills", "player_match_stats.opening_deaths"}},
+	{Name: "TRADE_K / TRADE_D", Group: "Trades",
+		Definition: "Kills of an enemy who had just killed a teammate / deaths whose killer a teammate then killed.",
+		Window:     "5s between the two kills",
+		Columns:    []string{"player_match_stats.trade_kills", "player_match_stats.trade_deaths"}},
+	{Name: "TRADE_DELAY", Group: "Trades",
+		Definition: "Median ms from the teammate's death to the player's trade kill, and from the player's death to the teammate's trade kill.",
+		Window:     "5s",
+		Columns:    []string{"player_match_stats.median_trade_kill_delay_ms", "player_match_stats.median_trade_death_delay_ms"}},
+
+	// Utility
+	{Name: "FA", Group: "Utility",
+		Definition: "Kills assisted by the player's flash (AssistedFlash on the kill event).",
+		Columns:    []string{"player_match_stats.flash_assists"}},
+	{Name: "UTIL_DMG", Group: "Utility",
+		Definition: "Health damage dealt with HE grenades, molotovs and incendiaries.",
+		Columns:    []string{"player_match_stats.utility_damage"}},
+	{Name: "UNUSED_UTIL", Group: "Utility",
+		Definition: "Non-flash grenades (HE, molotov, incendiary, smoke, decoy) still in inventory at round end.",
+		Columns:    []string{"player_match_stats.unused_utility"}},
+	{Name: "EFF_FLASH", Group: "Utility",
+		Definition: "The player's flashes that blinded an enemy who was then killed by the player's team.",
+		Window:     "1.5s after the flash",
+		Columns:    []string{"player_match_stats.effective_flashes"}},
+	{Name: "UTIL_TAKEN / FLASHED / BLIND_S", Group: "Utility",
+		Definition: "Enemy HE/molotov/incendiary damage received, enemy flashes that blinded the player, and total blind seconds from them; own and team utility excluded.",
+		Columns:    []string{"player_match_stats.utility_damage_taken", "player_match_stats.flashes_received", "player_match_stats.blind_time_received_sec"},
+		Since:      14},
+	{Name: "FLASH_K / SMOKE_K", Group: "Utility Synergy",
+		Definition: "Kills on a victim blinded by a teammate's flash / kills through a smoke a teammate threw; UTIL_K counts either once.",
+		Window:     "2s after the teammate's flash blinded the victim",
+		Columns:    []string{"player_match_stats.played_off_flash_kills", "player_match_stats.played_off_smoke_kills", "player_match_stats.played_off_utility_kills"},
+		Since:      21},
+	{Name: "EARLY / MID / LATE", Group: "Utility Timing",
+		Definition: "Grenades thrown by round phase: early (setup) within 25s of freeze end, late once the bomb is planted or with 20s or less on the round timer, mid otherwise; SETUP% = early / all throws. MED_SEC is the median seconds after freeze end of all throws. Throws during freeze time or after the round ended are skipped.",
+		Window:     "early: 25s after freeze end; late: ≤ 20s on the calibrated round timer",
+		Columns:    []string{"player_match_stats.util_throws_early", "player_match_stats.util_throws_mid", "player_match_stats.util_throws_late", "player_match_stats.median_util_throw_sec"},
+		Since:      42},
+
+	// Crosshair placement and duels
+	{Name: "XHAIR_MED", Group: "Crosshair Placement",
+		Definition: "Median angle between the crosshair and the enemy's head at first sight (m_bSpottedByMask transition); pitch/yaw medians split it; % under 5° alongside.",
+		Window:     "5°",
+		Columns:    []string{"player_match_stats.crosshair_median_deg", "player_match_stats.crosshair_pct_under5", "player_match_stats.crosshair_median_pitch_deg", "player_match_stats.crosshair_median_yaw_deg"}},
+	{Name: "DUEL_W / DUEL_L", Group: "Duel Engine",
+		Definition: "Kills where the killer had sighted the victim before the kill tick / all deaths.",
+		Columns:    []string{"player_match_stats.duel_wins", "player_match_stats.duel_losses"}},
+	{Name: "ASSIST_W / CLEAN_W%", Group: "Duel Engine",
+		Definition: "Assisted duel: a teammate of the killer dealt ≥ 41 damage (utility included) to the victim before the kill. ASSIST_W = assisted duel wins; CLEAN_W% = clean wins / (clean wins + clean losses), assisted wins and losses left out.",
+		Window:     "teammate damage: 5s before the kill",
+		Columns:    []string{"player_match_stats.assisted_duel_wins", "player_match_stats.assisted_duel_losses", "player_match_stats.duel_wins", "player_match_stats.duel_losses"},
+		Since:      31},
+	{Name: "EXPO_WIN / EXPO_LOSS", Group: "Duel Engine",
+		Definition: "Median ms from first sight to the kill, for duels won / from the victim's first sight of the killer to the death (0 = never spotted the killer).",
+		Columns:    []string{"player_match_stats.median_exposure_win_ms", "player_match_stats.median_exposure_loss_ms"}},
+	{Name: "SPOTTED", Group: "Duel Engine",
+		Definition: "Median ms from the first time any enemy spotted the player in a round to the player's death that round, over deaths after being spotted.",
+		Columns:    []string{"player_match_stats.median_spotted_before_death_ms"},
+		Since:      3},
+	{Name: "INFO_DEATH%", Group: "Duel Engine",
+		Definition: "Share of deaths to an enemy where nobody on the player's team (the player included) had spotted the killer that round before the kill: information failures rather than lost fights against a known threat. Teamkills and deaths without a killer are not counted.",
+		Columns:    []string{"player_match_stats.unspotted_deaths", "player_match_stats.spotted_deaths"},
+		Since:      44},
+	{Name: "TTDMG", Group: "Duel Engine",
+		Definition: "Median ms from first sighting an enemy to the player's first bullet damage on them in the same round, kills or not; utility damage ignored.",
+		Window:     "damage more than 5s after the sighting ignored (re-peek)",
+		Columns:    []string{"player_match_stats.median_time_to_damage_ms", "player_match_stats.time_to_damage_samples", "player_time_to_damage.median_ms"},
+		Since:      11,
+		Changes:    []model.MetricChange{{Version: 25, Note: "M4A1-S moved from the Other to the M4 weapon bucket"}}},
+	{Name: "HITS_TO_KILL", Group: "Duel Engine",
+		Definition: "Median bullet hits needed to complete a kill.",
+		Columns:    []string{"player_match_stats.median_hits_to_kill"}},
+	{Name: "FHHS%", Group: "Duel Engine",
+		Definition: "Share of won duels whose first hit was to the head, per weapon bucket × distance bin segment.",
+		Window:     "fixed distance bins; quantile bins per weapon with CSMETRICS_DISTANCE_BINS=quantile",
+		Columns:    []string{"player_match_stats.first_hit_hs_rate", "player_duel_segments.first_hit_hs_count", "player_duel_segments.first_hit_count"},
+		Changes: []model.MetricChange{
+			{Version: 18, Note: "segments split by round context (pistol / anti-eco / gun)"},
+			{Version: 23, Note: "won-duel distances stored per meter for quantile bins (player_duel_distances)"},
+			{Version: 25, Note: "M4A1-S moved from the Other to the M4 weapon bucket"},
+		}},
+	{Name: "STILL_FHHS% / MOVING_FHHS%", Group: "Duel Engine",
+		Definition: "FHHS% split by the killer's horizontal speed at the first shot in the duel window: still (counter-strafed) or moving; STILL% = share of first hits fired still. Duels without a shot sample are in neither.",
+		Window:     "still ≤ 34 u/s",
+		Columns:    []string{"player_duel_segments.still_first_hits", "player_duel_segments.still_first_hit_hs", "player_duel_segments.moving_first_hits", "player_duel_segments.moving_first_hit_hs"},
+		Since:      35},
+	{Name: "CORRECTION", Group: "Duel Engine",
+		Definition: "Median angle between the killer's view at first sight and at the first shot; % under 2° alongside.",
+		Window:     "2°",
+		Columns:    []string{"player_match_stats.median_correction_deg", "player_match_stats.pct_correction_under2_deg"}},
+	{Name: "DIED_TO", Group: "Duel Engine",
+		Definition: "Deaths by the killer's weapon bucket × killer-to-victim distance bin, with headshot deaths.",
+		Columns:    []string{"player_death_segments.deaths", "player_death_segments.headshot_deaths"},
+		Since:      9,
+		Changes:    []model.MetricChange{{Version: 25, Note: "M4A1-S moved from the Other to the M4 weapon bucket"}}},
+	{Name: "DEATH_CONTEXT", Group: "Duel Engine",
+		Definition: "Each death to an enemy tagged by what the player was doing before it: moving or holding at the killer's first bullet hit (unknown without one), flashed, isolated (no alive teammate within 512 units at the kill) and after a kill (had killed an enemy); shares of deaths per player.",
+		Window:     "3s before the death; moving > 34 u/s",
+		Columns:    []string{"player_death_contexts.movement", "player_death_contexts.flashed", "player_death_contexts.isolated", "player_death_contexts.after_kill", "player_death_contexts.deaths"},
+		Since:      36},
+
+	// AWP
+	{Name: "AWP_D / DRY% / REPEEK% / ISOLATED%", Group: "AWP Death Classifier",
+		Definition: "Deaths to an AWP, classed (non-exclusively) as dry (no flash on the victim), re-peek (victim already had a kill that round) and isolated (no alive teammate near the victim).",
+		Window:     "dry: 3s before the kill; isolated: 512 units",
+		Columns:    []string{"player_match_stats.awp_deaths", "player_match_stats.awp_deaths_dry", "player_match_stats.awp_deaths_repeek", "player_match_stats.awp_deaths_isolated"}},
+	{Name: "AWP_RDS / AWP_D%", Group: "AWP Death Classifier",
+		Definition: "Rounds in which an enemy fired, damaged or killed with an AWP; AWP_D% = AWP deaths / those rounds.",
+		Columns:    []string{"player_match_stats.awp_rounds_faced"},
+		Since:      13},
+	{Name: "AWP_SHOTS / HIT% / BODY% / KILL%", Group: "AWP Death Classifier",
+		Definition: "Every AWP shot paired with the shooter's AWP damage to enemies and classed as a kill, a non-lethal body hit, or a miss.",
+		Window:     "100ms after the shot",
+		Columns:    []string{"player_match_stats.awp_shots", "player_match_stats.awp_shot_kills", "player_match_stats.awp_shot_body_hits"},
+		Since:      12},
+
+	// Aim timing
+	{Name: "ROLE", Group: "Aim Timing",
+		Definition: "First match of: AWPer (AWP kills / kills), Entry (opening kills per round), Support (flash assists or utility damage per round), else Rifler.",
+		Window:     "AWPer > 30%; Entry > 0.12/round; Support > 0.08 FA/round or > 15 utility damage/round",
+		Columns:    []string{"player_match_stats.role"}},
+	{Name: "TTK / TTD", Group: "Aim Timing",
+		Definition: "Median ms from the first shot fired to the kill (attacker) / from the enemy's first shot to the death (victim), multi-hit kills only.",
+		Window:     "3s before the kill",
+		Columns:    []string{"player_match_stats.median_ttk_ms", "player_match_stats.median_ttd_ms"}},
+	{Name: "ONE_TAP%", Group: "Aim Timing",
+		Definition: "Kills where the first shot in the window was the kill shot / kills.",
+		Window:     "3s before the kill",
+		Columns:    []string{"player_match_stats.one_tap_kills"}},
+	{Name: "CS%", Group: "Aim Timing",
+		Definition: "Share of shots fired while stopped or counter-strafed.",
+		Window:     "horizontal speed ≤ 34 u/s",
+		Columns:    []string{"player_match_stats.counter_strafe_pct"}},
+	{Name: "MOVING_D%", Group: "Aim Timing",
+		Definition: "Share of deaths where the victim was moving at the killer's first hit of the fatal engagement.",
+		Window:     "first hit within 3s before death; moving = horizontal speed > 34 u/s",
+		Columns:    []string{"player_match_stats.moving_deaths", "player_match_stats.death_speed_samples"},
+		Since:      5},
+	{Name: "SPRAY_TR / COLLAT", Group: "Aim Timing",
+		Definition: "Kills chained off the previous one with the same weapon while firing on an already-spotted enemy / kills by the same bullet as the previous kill.",
+		Window:     "spray transfer: 1.5s; collateral: ≤ 2 ticks, no shot in between",
+		Columns:    []string{"player_match_stats.spray_transfer_kills", "player_match_stats.collateral_kills"},
+		Since:      8},
+	{Name: "BURST_MIX", Group: "Aim Timing",
+		Definition: "Runs of consecutive same-weapon shots binned as tap / 2-3 / 4-9 / 10+; AWP and Scout excluded.",
+		Window:     "shots ≤ 200ms apart",
+		Columns:    []string{"player_match_stats.burst_taps", "player_match_stats.burst_short", "player_match_stats.burst_spray", "player_match_stats.burst_panic", "player_burst_stats"},
+		Since:      16,
+		Changes:    []model.MetricChange{{Version: 25, Note: "M4A1-S moved from the Other to the M4 weapon bucket"}}},
+
+	// Round play
+	{Name: "LOWHP_HAND / LOWHP_WASTE", Group: "Low-HP Enemies",
+		Definition: "Enemies the player's hit left low and the player did not kill that round: finished by a teammate / survived or died to anything else.",
+		Window:     "1–19 HP",
+		Columns:    []string{"player_match_stats.low_hp_handed", "player_match_stats.low_hp_wasted"},
+		Since:      2},
+	{Name: "DEFUSES / NINJA / PLANT_DENY", Group: "Objective Play",
+		Definition: "Bombs defused; defuses with an enemy alive nearby that never spotted the defuser during the defuse; enemies killed mid-plant.",
+		Window:     "ninja: enemy within 1000 units",
+		Columns:    []string{"player_match_stats.defuses", "player_match_stats.ninja_defuses", "player_match_stats.plant_denials"},
+		Since:      4},
+	{Name: "END", Group: "Round End Reasons",
+		Definition: "Reason on the RoundEnd event: elimination, bomb, defuse, time, surrender or other.",
+		Columns:    []string{"player_round_stats.end_reason"},
+		Since:      7},
+	{Name: "AVG_DIST", Group: "Weapon Breakdown",
+		Definition: "Mean killer–victim distance in meters at the kill, per weapon, over kills with both positions known; shown by `db weapons`.",
+		Columns:    []string{"player_weapon_stats.distance_kills", "player_weapon_stats.kill_distance_sum_m"},
+		Since:      33},
+	{Name: "TRADE_CHAINS", Group: "Trades",
+		Definition: "Trade chain: a run of enemy kills in a round where each kill trades a player who killed in the chain (trade, re-trade) or is another kill by a chain killer, within the trade window; only chains with a trade count. Per team: chains won, even and lost by kills, and the exchange outcomes (2-for-1, 1-for-2, ...).",
+		Window:     "each kill within 5s of the linked player's latest chain kill",
+		Columns:    []string{"round_trade_chains.kills", "round_trade_chains.trades", "round_trade_chains.ct_kills", "round_trade_chains.t_kills"},
+		Since:      34},
+	{Name: "TIMELINE", Group: "Round Timeline",
+		Definition: "Per-round event stream for `timeline`: round start, freeze end and round end (winner, reason), kills with killer and victim positions, flashes with seconds blind, bomb plants and defuses with site and position.",
+		Columns:    []string{"round_events.type", "round_events.tick", "round_events.actor_x", "round_events.target_x"},
+		Since:      32},
+	{Name: "CLUTCH_1vN", Group: "Round Context",
+		Definition: "Player left as the last alive on their team with N ≥ 1 enemies alive (N = the most enemies alive during the clutch); the start tick, clock and opponents are the death that left them alone.",
+		Columns:    []string{"player_round_stats.is_in_clutch", "player_round_stats.clutch_enemy_count", "player_round_stats.clutch_start_tick", "player_round_stats.clutch_start_sec", "player_round_stats.clutch_enemies"},
+		Changes:    []model.MetricChange{{Version: 26, Note: "clutch start tick, seconds after freeze end and opponents recorded"}}},
+	{Name: "DIED", Group: "Round Context",
+		Definition: "Deaths in the round and the seconds after freeze end of the death.",
+		Columns:    []string{"player_round_stats.deaths", "player_round_stats.death_tick", "player_round_stats.death_sec"},
+		Since:      22},
+	{Name: "BUY", Group: "Round Context",
+		Definition: "Per-player buy type from freeze-end equipment value: eco / half / force / full.",
+		Columns:    []string{"player_round_stats.buy_type"}},
+	{Name: "TEAM_ECONOMY", Group: "Team Economy",
+		Definition: "Each side's round classed from its summed freeze-end equipment value per player: pistol, full-eco, semi-eco, force, full-buy.",
+		Window:     "per player: < $1,000 full-eco, < $2,000 semi-eco, < $4,000 force; pistol = first round of a half with nobody at $2,000+",
+		Columns:    []string{"team_round_economy.equip_value", "team_round_economy.round_type"},
+		Since:      24},
+	{Name: "LATE_D / PFT_D%", Group: "Late-Round Discipline",
+		Definition: "Deaths with little left on the active clock (round timer before a plant, bomb timer after); play-for-time deaths / rounds alive with the clock on the player's side and their side up in players.",
+		Window:     "≤ 20s left on the clock",
+		Columns:    []string{"player_match_stats.late_round_deaths", "player_match_stats.play_for_time_rounds", "player_match_stats.play_for_time_deaths", "round_kill_states.clock_remaining_sec"},
+		Since:      17},
+	{Name: "MOMENTUM", Group: "Momentum",
+		Definition: "Kills and damage in streak rounds (after team round wins) and bounce-back rounds (after losses), bounce-back wins, the longest run, and halves opened by the player's kill; runs reset every half.",
+		Window:     "streak / bounce-back: ≥ 3 straight team round results",
+		Columns:    []string{"player_match_stats.longest_win_streak", "player_match_stats.streak_rounds", "player_match_stats.bounce_rounds", "player_match_stats.bounce_wins", "player_match_stats.half_first_kills"},
+		Since:      19},
+	{Name: "LEAD / TIED / TRAIL", Group: "Scoreline Splits",
+		Definition: "Rounds, kills, deaths and damage in the player's decided rounds, split by their team's match score when the round started (ahead, level, behind); K/D and ADR per state. Teams are followed across side swaps.",
+		Columns: []string{"player_match_stats.leading_rounds", "player_match_stats.leading_kills", "player_match_stats.leading_deaths", "player_match_stats.leading_damage",
+			"player_match_stats.tied_rounds", "player_match_stats.tied_kills", "player_match_stats.tied_deaths", "player_match_stats.tied_damage",
+			"player_match_stats.trailing_rounds", "player_match_stats.trailing_kills", "player_match_stats.trailing_deaths", "player_match_stats.trailing_damage"},
+		Since: 27},
+	{Name: "K/$1K / DMG/$1K", Group: "Loadout Efficiency",
+		Definition: "Kills and damage per $1000 of the player's own freeze-end equipment value (weapons, armor, utility), summed over rounds played.",
+		Columns:    []string{"player_match_stats.equipment_value", "player_match_stats.kills", "player_match_stats.total_damage"},
+		Since:      28},
+	{Name: "PEEK_W% / HOLD_W%", Group: "Peeker's Advantage",
+		Definition: "Kills between two players who had both spotted each other, split by who was moving at their own first sight: the moving player peeked, the still one held; kills with both or neither moving are not counted. Win share as peeker and as holder, against the corpus peeker win share per demo tier.",
+		Window:     "moving = horizontal speed > 34 u/s at first sight",
+		Columns:    []string{"player_match_stats.peek_duels", "player_match_stats.peek_wins", "player_match_stats.hold_duels", "player_match_stats.hold_wins"},
+		Since:      29},
+	{Name: "WRONG%", Group: "Weapon Discipline",
+		Definition: "Deaths to enemies while a rifle, SMG, heavy weapon or sniper was in the inventory, and the share of them where the weapon in hand at the kill was a pistol, knife or grenade — caught switching, buying time or walking with the knife out. Deaths holding the bomb or a Zeus count only in the denominator.",
+		Columns:    []string{"player_match_stats.primary_deaths", "player_match_stats.wrong_weapon_deaths"},
+		Since:      38},
+	{Name: "LOSS_WHY", Group: "Duel Loss Reasons",
+		Definition: "Each death to an enemy tagged with the factors behind the lost duel: FLASHED (blinded by any flash in the 1.5 s before), ISOLATED (no alive teammate within 512 units), UTIL (the killer played off a teammate's flash or smoke) and REPEEK (had already killed an enemy that round). A loss can have several factors; NONE is the share with none of them — a straight aim duel lost.",
+		Window:     "flash within 1.5 s before the death; teammate flash within 2 s for UTIL",
+		Columns:    []string{"player_match_stats.loss_flashed", "player_match_stats.loss_isolated", "player_match_stats.loss_util_support", "player_match_stats.loss_repeek", "player_match_stats.loss_explained"},
+		Since:      39},
+	{Name: "POSITIONS", Group: "Positions",
+		Definition: "Each round's most sampled callout per alive player, counted per map and side (512-unit grid cell when the demo has no callouts).",
+		Window:     "samples 10, 15 and 20s after freeze end",
+		Columns:    []string{"player_positions.rounds"},
+		Since:      20},
+	{Name: "WPA", Group: "Round Impact",
+		Definition: "Win probability added by the player's kills per round; states (players alive per side, bomb planted) fitted from every stored demo.",
+		Columns:    []string{"round_kill_states"},
+		Since:      6,
+		Changes:    []model.MetricChange{{Version: 15, Note: "killer weapon stored with each kill state"}}},
+}
+
+// Metrics returns the metric registry: every reported metric with its
+// definition, windows, stored columns and version history.
+func Metrics() []model.MetricDef {
+	return slices.Clone(metricRegistry)
+}
@@ -0,0 +1,86 @@
+package aggregator
+
+import (
+	"sort"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// momentumStreak is the number of straight team round results (wins or
+// losses) that puts the next round on a win streak or makes it a bounce-back
+// round.
+const momentumStreak = 3
+
+// momentumCounts holds one player's streak and bounce-back counts for a match.
+type momentumCounts struct {
+	longestWin     int // longest run of team round wins within a half
+	streakRounds   int // rounds played after ≥ momentumStreak straight team wins
+	streakKills    int
+	streakDamage   int
+	bounceRounds   int // rounds played after ≥ momentumStreak straight team losses
+	bounceKills    int
+	bounceDamage   int
+	bounceWins     int // bounce-back rounds the team won
+	halfFirstKills int // halves opened by this player's kill
+}
+
+// momentum walks each player's decided rounds in order and tracks their team's
+// run of round results. Runs reset at the start of every half (see
+// sideStarts): the pistol round and the side swap break momentum. A round
+// played while the team had won the previous momentumStreak or more rounds is
+// a streak round; one played after as many straight losses is a bounce-back
+// round. The opening kill of a half's first round is that half's first kill.
+func momentum(raw *model.RawMatch, roundStats []model.PlayerRoundStats) map[uint64]*momentumCounts {
+	starts := sideStarts(raw)
+	decided := make(map[int]bool, len(raw.Rounds))
+	for _, rnd := range raw.Rounds {
+		decided[rnd.Number] = rnd.WinnerTeam == model.TeamCT || rnd.WinnerTeam == model.TeamT
+	}
+
+	byPlayer := make(map[uint64][]model.PlayerRoundStats)
+	for _, rs := range roundStats {
+		if decided[rs.RoundNumber] {
+			byPlayer[rs.SteamID] = append(byPlayer[rs.SteamID], rs)
+		}
+	}
+
+	out := make(map[uint64]*momentumCounts, len(byPlayer))
+	for id, rounds := range byPlayer {
+		sort.Slice(rounds, func(i, j int) bool { return rounds[i].RoundNumber < rounds[j].RoundNumber })
+		c := &momentumCounts{}
+		wins, losses := 0, 0
+		for i, rs := range rounds {
+			if starts[rs.RoundNumber] || (i > 0 && rs.Team != rounds[i-1].Team) {
+				wins, losses = 0, 0
+			}
+			if starts[rs.RoundNumber] && rs.IsOpeningKill {
+				c.halfFirstKills++
+			}
+			switch {
+			case wins >= momentumStreak:
+				c.streakRounds++
+				c.streakKills += rs.Kills
+				c.streakDamage += rs.Damage
+			case losses >= momentumStreak:
+				c.bounceRounds++
+				c.bounceKills += rs.Kills
+				c.bounceDamage += rs.Damage
+				if rs.WonRound {
+					c.bounceWins++
+				}
+			}
+			if rs.WonRound {
+				wins++
+				losses = 0
+				if wins > c.longestWin {
+					c.longestWin = wins
+				}
+			} else {
+				losses++
+				wins = 0
+			}
+		}
+		out[id] = c
+	}
+	return out
+}
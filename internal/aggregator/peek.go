@@ -0,0 +1,66 @@
+package aggregator
+
+import (
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// peekMovingSpeed is the horizontal speed (Hammer units/s) above which a
+// player counts as moving at their first sight of the enemy — the same line
+// as counter-strafing and moving deaths.
+const peekMovingSpeed = 34.0
+
+// peekCounts holds one player's duels as the peeker and as the holder.
+type peekCounts struct {
+	peeks, peekWins int
+	holds, holdWins int
+}
+
+// peekerDuels classifies every enemy kill in which killer and victim had both
+// spotted each other before the kill tick. The player moving at their own
+// first sight of the other is the peeker, the still one the holder; kills
+// where both or neither were moving are not counted.
+func peekerDuels(raw *model.RawMatch) map[uint64]*peekCounts {
+	type sightKey struct {
+		obsID, enemyID uint64
+		round          int
+	}
+	sights := make(map[sightKey]model.RawFirstSight, len(raw.FirstSights))
+	for _, fs := range raw.FirstSights {
+		k := sightKey{fs.ObserverID, fs.EnemyID, fs.RoundNumber}
+		if _, ok := sights[k]; !ok {
+			sights[k] = fs
+		}
+	}
+
+	out := make(map[uint64]*peekCounts)
+	get := func(id uint64) *peekCounts {
+		if out[id] == nil {
+			out[id] = &peekCounts{}
+		}
+		return out[id]
+	}
+	for _, k := range raw.Kills {
+		if k.KillerSteamID == 0 || k.KillerSteamID == k.VictimSteamID || k.KillerTeam == k.VictimTeam {
+			continue
+		}
+		ks, ok1 := sights[sightKey{k.KillerSteamID, k.VictimSteamID, k.RoundNumber}]
+		vs, ok2 := sights[sightKey{k.VictimSteamID, k.KillerSteamID, k.RoundNumber}]
+		if !ok1 || !ok2 || ks.Tick > k.Tick || vs.Tick > k.Tick {
+			continue
+		}
+		killerMoving, victimMoving := ks.ObserverSpeed > peekMovingSpeed, vs.ObserverSpeed > peekMovingSpeed
+		switch {
+		case killerMoving && !victimMoving:
+			killer := get(k.KillerSteamID)
+			killer.peeks++
+			killer.peekWins++
+			get(k.VictimSteamID).holds++
+		case victimMoving && !killerMoving:
+			killer := get(k.KillerSteamID)
+			killer.holds++
+			killer.holdWins++
+			get(k.VictimSteamID).peeks++
+		}
+	}
+	return out
+}
@@ -0,0 +1,27 @@
+package aggregator
+
+import (
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// pingCounts holds one player's scoreboard ping readings.
+type pingCounts struct {
+	samples, sumMs, maxMs int
+}
+
+// pingStats sums each player's ping readings (raw.PingSamples) for the match
+// mean and keeps the peak. Demos parsed before ping was read have none.
+func pingStats(raw *model.RawMatch) map[uint64]*pingCounts {
+	out := make(map[uint64]*pingCounts)
+	for _, s := range raw.PingSamples {
+		c := out[s.PlayerID]
+		if c == nil {
+			c = &pingCounts{}
+			out[s.PlayerID] = c
+		}
+		c.samples++
+		c.sumMs += s.PingMs
+		c.maxMs = max(c.maxMs, s.PingMs)
+	}
+	return out
+}
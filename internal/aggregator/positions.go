@@ -0,0 +1,168 @@
+package aggregator
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+const (
+	// positionGridUnits is the cell size, in Hammer units, of the grid used as
+	// the position label when a demo carries no nav-mesh place names.
+	positionGridUnits = 512.0
+	// mainPositionShare is the share of a side's rounds a position needs to
+	// appear in a profile's label.
+	mainPositionShare = 0.25
+	// maxLabelPositions caps the positions joined into a profile's label.
+	maxLabelPositions = 2
+)
+
+// positionPlace returns the label of a sample's position: the callout, or a
+// grid cell when the callout is missing.
+func positionPlace(s model.RawPositionSample) string {
+	if s.Place != "" {
+		return s.Place
+	}
+	return fmt.Sprintf("grid %d,%d",
+		int(math.Floor(s.Pos.X/positionGridUnits)), int(math.Floor(s.Pos.Y/positionGridUnits)))
+}
+
+// Positions returns, per player and side, the number of rounds the player set
+// up at each position. A round's position is the most sampled place among
+// the player's early-round samples (the later one on a tie).
+func Positions(raw *model.RawMatch) []model.PlayerPositionStats {
+	type roundKey struct {
+		playerID uint64
+		round    int
+	}
+	byRound := make(map[roundKey][]model.RawPositionSample)
+	for _, s := range raw.PositionSamples {
+		if s.Team != model.TeamCT && s.Team != model.TeamT {
+			continue
+		}
+		k := roundKey{s.PlayerID, s.RoundNumber}
+		byRound[k] = append(byRound[k], s)
+	}
+
+	type posKey struct {
+		playerID uint64
+		side     model.Team
+		place    string
+	}
+	type accum struct {
+		rounds, samples int
+		sumX, sumY      float64
+	}
+	acc := make(map[posKey]*accum)
+	for k, samples := range byRound {
+		sort.Slice(samples, func(i, j int) bool { return samples[i].Tick < samples[j].Tick })
+		counts := make(map[string]int)
+		best, bestN := "", 0
+		for _, s := range samples {
+			place := positionPlace(s)
+			counts[place]++
+			if counts[place] >= bestN {
+				best, bestN = place, counts[place]
+			}
+		}
+		pk := posKey{k.playerID, samples[len(samples)-1].Team, best}
+		if acc[pk] == nil {
+			acc[pk] = &accum{}
+		}
+		a := acc[pk]
+		a.rounds++
+		for _, s := range samples {
+			if positionPlace(s) == best {
+				a.samples++
+				a.sumX += s.Pos.X
+				a.sumY += s.Pos.Y
+			}
+		}
+	}
+
+	out := make([]model.PlayerPositionStats, 0, len(acc))
+	for k, a := range acc {
+		out = append(out, model.PlayerPositionStats{
+			DemoHash: raw.DemoHash,
+			SteamID:  k.playerID,
+			Side:     k.side,
+			Place:    k.place,
+			Rounds:   a.rounds,
+			AvgX:     a.sumX / float64(a.samples),
+			AvgY:     a.sumY / float64(a.samples),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].SteamID != out[j].SteamID {
+			return out[i].SteamID < out[j].SteamID
+		}
+		if out[i].Side != out[j].Side {
+			return out[i].Side > out[j].Side
+		}
+		if out[i].Rounds != out[j].Rounds {
+			return out[i].Rounds > out[j].Rounds
+		}
+		return out[i].Place < out[j].Place
+	})
+	return out
+}
+
+// PositionProfiles sums position rows (from any number of demos, with
+// MapName set) into one profile per player, map and side. Places are ordered
+// by rounds; the label joins the places holding at least mainPositionShare
+// of the side's rounds (at most maxLabelPositions), or is "mixed".
+func PositionProfiles(rows []model.PlayerPositionStats) []model.PlayerPositionProfile {
+	type profileKey struct {
+		steamID uint64
+		mapName string
+		side    model.Team
+	}
+	rounds := make(map[profileKey]map[string]int)
+	for _, r := range rows {
+		k := profileKey{r.SteamID, r.MapName, r.Side}
+		if rounds[k] == nil {
+			rounds[k] = make(map[string]int)
+		}
+		rounds[k][r.Place] += r.Rounds
+	}
+
+	out := make([]model.PlayerPositionProfile, 0, len(rounds))
+	for k, places := range rounds {
+		p := model.PlayerPositionProfile{SteamID: k.steamID, MapName: k.mapName, Side: k.side}
+		for place, n := range places {
+			p.Places = append(p.Places, model.PlaceShare{Place: place, Rounds: n})
+			p.Rounds += n
+		}
+		sort.Slice(p.Places, func(i, j int) bool {
+			if p.Places[i].Rounds != p.Places[j].Rounds {
+				return p.Places[i].Rounds > p.Places[j].Rounds
+			}
+			return p.Places[i].Place < p.Places[j].Place
+		})
+		var main []string
+		for _, pl := range p.Places {
+			if len(main) == maxLabelPositions || float64(pl.Rounds) < mainPositionShare*float64(p.Rounds) {
+				break
+			}
+			main = append(main, pl.Place)
+		}
+		p.Label = "mixed"
+		if len(main) > 0 {
+			p.Label = strings.Join(main, "/")
+		}
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].SteamID != out[j].SteamID {
+			return out[i].SteamID < out[j].SteamID
+		}
+		if out[i].MapName != out[j].MapName {
+			return out[i].MapName < out[j].MapName
+		}
+		return out[i].Side > out[j].Side
+	})
+	return out
+}
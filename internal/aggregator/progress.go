@@ -0,0 +1,127 @@
+package aggregator
+
+import (
+	"math"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// progressMetric is one row of the progress report card: how to read the
+// metric from a match, and whether it is defined for that match.
+type progressMetric struct {
+	name   string
+	format string
+	higher bool
+	value  func(s *model.PlayerMatchStats) (float64, bool)
+}
+
+// ratio returns num/den × scale, undefined when den is 0.
+func ratio(num, den int, scale float64) (float64, bool) {
+	if den == 0 {
+		return 0, false
+	}
+	return float64(num) / float64(den) * scale, true
+}
+
+var progressMetrics = []progressMetric{
+	{"Rating", "%.2f", true, func(s *model.PlayerMatchStats) (float64, bool) { return s.Rating(), true }},
+	{"K/D", "%.2f", true, func(s *model.PlayerMatchStats) (float64, bool) { return s.KDRatio(), true }},
+	{"ADR", "%.1f", true, func(s *model.PlayerMatchStats) (float64, bool) { return s.ADR(), true }},
+	{"KAST%", "%.1f%%", true, func(s *model.PlayerMatchStats) (float64, bool) { return s.KASTPct(), true }},
+	{"HS%", "%.1f%%", true, func(s *model.PlayerMatchStats) (float64, bool) { return ratio(s.HeadshotKills, s.Kills, 100) }},
+	{"Match win%", "%.0f%%", true, func(s *model.PlayerMatchStats) (float64, bool) {
+		if matchResult(s) == 1 {
+			return 100, true
+		}
+		return 0, true
+	}},
+	{"Opening win%", "%.1f%%", true, func(s *model.PlayerMatchStats) (float64, bool) {
+		return ratio(s.OpeningKills, s.OpeningKills+s.OpeningDeaths, 100)
+	}},
+	{"Duel win%", "%.1f%%", true, func(s *model.PlayerMatchStats) (float64, bool) {
+		return ratio(s.DuelWins, s.DuelWins+s.DuelLosses, 100)
+	}},
+	{"Trade kills/rd", "%.2f", true, func(s *model.PlayerMatchStats) (float64, bool) { return ratio(s.TradeKills, s.RoundsPlayed, 1) }},
+	{"Traded deaths%", "%.1f%%", true, func(s *model.PlayerMatchStats) (float64, bool) { return ratio(s.TradeDeaths, s.Deaths, 100) }},
+	{"Utility dmg/rd", "%.1f", true, func(s *model.PlayerMatchStats) (float64, bool) { return ratio(s.UtilityDamage, s.RoundsPlayed, 1) }},
+	{"Flash assists/rd", "%.2f", true, func(s *model.PlayerMatchStats) (float64, bool) { return ratio(s.FlashAssists, s.RoundsPlayed, 1) }},
+	{"Crosshair median", "%.1f°", false, func(s *model.PlayerMatchStats) (float64, bool) {
+		return s.CrosshairMedianDeg, s.CrosshairEncounters > 0
+	}},
+	{"Median TTK", "%.0f ms", false, func(s *model.PlayerMatchStats) (float64, bool) { return s.MedianTTKMs, s.MedianTTKMs > 0 }},
+	{"Counter-strafe%", "%.1f%%", true, func(s *model.PlayerMatchStats) (float64, bool) {
+		return s.CounterStrafePercent, s.CounterStrafePercent > 0
+	}},
+}
+
+// Progress compares a player's most recent window matches (stats is one row
+// per match, chronological) with the up to window matches before them. Each
+// metric is the mean of its per-match values, tested with Welch's t on those
+// values. Matches with no rounds played are skipped. With no match before
+// the recent window, Previous is 0 and every metric has PreviousN 0.
+func Progress(stats []model.PlayerMatchStats, window int) model.PlayerProgress {
+	var played []*model.PlayerMatchStats
+	for i := range stats {
+		if stats[i].RoundsPlayed > 0 {
+			played = append(played, &stats[i])
+		}
+	}
+	split := max(len(played)-window, 0)
+	recent := played[split:]
+	previous := played[max(split-window, 0):split]
+
+	p := model.PlayerProgress{Window: window, Recent: len(recent), Previous: len(previous)}
+	if len(recent) > 0 {
+		p.RecentFrom, p.RecentTo = recent[0].MatchDate, recent[len(recent)-1].MatchDate
+	}
+	if len(previous) > 0 {
+		p.PreviousFrom, p.PreviousTo = previous[0].MatchDate, previous[len(previous)-1].MatchDate
+	}
+	values := func(m progressMetric, matches []*model.PlayerMatchStats) []float64 {
+		var out []float64
+		for _, s := range matches {
+			if v, ok := m.value(s); ok {
+				out = append(out, v)
+			}
+		}
+		return out
+	}
+	for _, m := range progressMetrics {
+		prev, rec := values(m, previous), values(m, recent)
+		p.Metrics = append(p.Metrics, model.ProgressMetric{
+			Name:           m.name,
+			Format:         m.format,
+			HigherIsBetter: m.higher,
+			Previous:       mean(prev),
+			Recent:         mean(rec),
+			PreviousN:      len(prev),
+			RecentN:        len(rec),
+			T:              welchT(prev, rec),
+		})
+	}
+	return p
+}
+
+// welchT returns Welch's t statistic for mean(b) − mean(a), or 0 when either
+// sample has fewer than two values or neither has any spread.
+func welchT(a, b []float64) float64 {
+	if len(a) < 2 || len(b) < 2 {
+		return 0
+	}
+	va, vb := variance(a), variance(b)
+	se := math.Sqrt(va/float64(len(a)) + vb/float64(len(b)))
+	if se == 0 {
+		return 0
+	}
+	return (mean(b) - mean(a)) / se
+}
+
+// variance returns the sample variance of values (n − 1 denominator).
+func variance(values []float64) float64 {
+	m := mean(values)
+	var ss float64
+	for _, v := range values {
+		ss += (v - m) * (v - m)
+	}
+	return ss / float64(len(values)-1)
+}
@@ -0,0 +1,200 @@
+package aggregator
+
+import (
+	"sort"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+const (
+	// defaultRoundTimeSec is the competitive/Premier round timer (1:55) used
+	// when no round in the demo ran out of time to calibrate it.
+	defaultRoundTimeSec = 115.0
+	// defaultBombTimerSec is the C4 fuse used when no bomb exploded in the demo.
+	defaultBombTimerSec = 40.0
+	// lateRoundSec is the time left on the active clock (round timer before a
+	// plant, bomb timer after) at or below which a round counts as late.
+	lateRoundSec = 20.0
+)
+
+// roundClock models the clock a round is played against: the round timer from
+// freeze end until a plant, then the bomb timer from the plant.
+type roundClock struct {
+	tps      float64
+	roundSec float64
+	bombSec  float64
+}
+
+// newRoundClock calibrates the round and bomb timers from the demo itself —
+// the median length of rounds that ended on time (freeze end → end) and of
+// bombs that exploded (plant → end) — falling back to the competitive defaults
+// when no such round exists.
+func newRoundClock(raw *model.RawMatch) roundClock {
+	tps := raw.TicksPerSecond
+	if tps == 0 {
+		tps = 64.0
+	}
+	var roundLens, bombLens []float64
+	for _, rnd := range raw.Rounds {
+		switch {
+		case rnd.EndReason == model.EndReasonTime && rnd.BombPlantTick == 0 && rnd.EndTick > rnd.FreezeEndTick:
+			roundLens = append(roundLens, float64(rnd.EndTick-rnd.FreezeEndTick)/tps)
+		case rnd.EndReason == model.EndReasonBomb && rnd.BombPlantTick > 0 && rnd.EndTick > rnd.BombPlantTick:
+			bombLens = append(bombLens, float64(rnd.EndTick-rnd.BombPlantTick)/tps)
+		}
+	}
+	c := roundClock{tps: tps, roundSec: defaultRoundTimeSec, bombSec: defaultBombTimerSec}
+	if len(roundLens) > 0 {
+		sort.Float64s(roundLens)
+		c.roundSec = median(roundLens)
+	}
+	if len(bombLens) > 0 {
+		sort.Float64s(bombLens)
+		c.bombSec = median(bombLens)
+	}
+	return c
+}
+
+// planted reports whether the bomb was down at tick in rnd.
+func planted(rnd model.RawRound, tick int) bool {
+	return rnd.BombPlantTick > 0 && tick >= rnd.BombPlantTick
+}
+
+// remaining returns the seconds left on the active clock at tick: the bomb
+// timer once planted, otherwise the round timer. Never negative.
+func (c roundClock) remaining(rnd model.RawRound, tick int) float64 {
+	var left float64
+	if planted(rnd, tick) {
+		left = c.bombSec - float64(tick-rnd.BombPlantTick)/c.tps
+	} else {
+		left = c.roundSec - float64(tick-rnd.FreezeEndTick)/c.tps
+	}
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+// lateTicks returns the ticks at which the active clock crosses lateRoundSec:
+// on the round timer (unless the bomb was planted first) and on the bomb timer.
+func (c roundClock) lateTicks(rnd model.RawRound) []int {
+	var out []int
+	roundLate := rnd.FreezeEndTick + int((c.roundSec-lateRoundSec)*c.tps)
+	if !planted(rnd, roundLate) {
+		out = append(out, roundLate)
+	}
+	if rnd.BombPlantTick > 0 {
+		out = append(out, rnd.BombPlantTick+int((c.bombSec-lateRoundSec)*c.tps))
+	}
+	return out
+}
+
+// timeFavors reports whether running down the clock wins the round for team:
+// CT before a plant, T after one.
+func timeFavors(team model.Team, isPlanted bool) bool {
+	if isPlanted {
+		return team == model.TeamT
+	}
+	return team == model.TeamCT
+}
+
+// lateRoundCounts holds one player's late-round discipline counts for a match.
+type lateRoundCounts struct {
+	lateDeaths int // deaths with ≤ lateRoundSec on the active clock
+	pftRounds  int // rounds alive in a play-for-time spot
+	pftDeaths  int // deaths in a play-for-time spot
+}
+
+// lateRoundDiscipline replays every decided round's alive counts against the
+// round clock. A "play-for-time" spot is one where the clock is late, it runs
+// in the player's side's favor and their side has more players alive: the
+// round is won by not taking fights. Players alive in such a spot at a late-
+// clock crossing or after any kill have faced it that round; dying in it is a
+// play-for-time death. Alive counts start from the round's end state, as in
+// KillStates.
+func lateRoundDiscipline(raw *model.RawMatch) map[uint64]*lateRoundCounts {
+	clock := newRoundClock(raw)
+	killsByRound := make(map[int][]model.RawKill)
+	for _, k := range raw.Kills {
+		killsByRound[k.RoundNumber] = append(killsByRound[k.RoundNumber], k)
+	}
+	out := make(map[uint64]*lateRoundCounts)
+	counts := func(id uint64) *lateRoundCounts {
+		if out[id] == nil {
+			out[id] = &lateRoundCounts{}
+		}
+		return out[id]
+	}
+
+	for _, rnd := range raw.Rounds {
+		if rnd.WinnerTeam != model.TeamCT && rnd.WinnerTeam != model.TeamT {
+			continue
+		}
+		alive := make(map[uint64]model.Team, len(rnd.PlayerEndState))
+		for id, ps := range rnd.PlayerEndState {
+			if ps.Team == model.TeamCT || ps.Team == model.TeamT {
+				alive[id] = ps.Team
+			}
+		}
+		playForTime := func(team model.Team, tick int) bool {
+			if clock.remaining(rnd, tick) > lateRoundSec || !timeFavors(team, planted(rnd, tick)) {
+				return false
+			}
+			var own, enemy int
+			for _, t := range alive {
+				if t == team {
+					own++
+				} else {
+					enemy++
+				}
+			}
+			return enemy > 0 && own > enemy
+		}
+		faced := make(map[uint64]bool)
+		check := func(tick int) {
+			for _, team := range []model.Team{model.TeamCT, model.TeamT} {
+				if !playForTime(team, tick) {
+					continue
+				}
+				for id, t := range alive {
+					if t == team {
+						faced[id] = true
+					}
+				}
+			}
+		}
+
+		late := clock.lateTicks(rnd)
+		li := 0
+		for _, k := range killsByRound[rnd.Number] {
+			if rnd.EndTick > 0 && k.Tick > rnd.EndTick {
+				continue
+			}
+			for li < len(late) && late[li] <= k.Tick {
+				check(late[li])
+				li++
+			}
+			if team, ok := alive[k.VictimSteamID]; ok {
+				c := counts(k.VictimSteamID)
+				if clock.remaining(rnd, k.Tick) <= lateRoundSec {
+					c.lateDeaths++
+				}
+				if playForTime(team, k.Tick) {
+					c.pftDeaths++
+					faced[k.VictimSteamID] = true
+				}
+				delete(alive, k.VictimSteamID)
+			}
+			check(k.Tick)
+		}
+		for ; li < len(late); li++ {
+			if rnd.EndTick == 0 || late[li] <= rnd.EndTick {
+				check(late[li])
+			}
+		}
+		for id := range faced {
+			counts(id).pftRounds++
+		}
+	}
+	return out
+}
@@ -0,0 +1,108 @@
+package aggregator
+
+import (
+	"sort"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// buyType classifies a player's freeze-end equipment value as a full buy,
+// force buy, half buy or eco.
+func buyType(equip int) string {
+	switch {
+	case equip >= 4500:
+		return "full"
+	case equip >= 2000:
+		return "force"
+	case equip >= 1000:
+		return "half"
+	}
+	return "eco"
+}
+
+// matchRounds returns a sorted copy of every round of the match: raw.Rounds,
+// or raw.MatchRounds when raw holds a round subset (see AggregateRounds).
+func matchRounds(raw *model.RawMatch) []model.RawRound {
+	src := raw.Rounds
+	if raw.MatchRounds != nil {
+		src = raw.MatchRounds
+	}
+	rounds := make([]model.RawRound, len(src))
+	copy(rounds, src)
+	sort.Slice(rounds, func(i, j int) bool { return rounds[i].Number < rounds[j].Number })
+	return rounds
+}
+
+// sideStarts returns the rounds that start a half: the first round of the
+// demo and every round in which some player's team differs from the previous
+// round (halftime and each overtime half).
+func sideStarts(raw *model.RawMatch) map[int]bool {
+	rounds := matchRounds(raw)
+
+	out := make(map[int]bool)
+	for i, rnd := range rounds {
+		if i == 0 {
+			out[rnd.Number] = true
+			continue
+		}
+		prev := rounds[i-1]
+		for id, ps := range rnd.PlayerEndState {
+			if before, ok := prev.PlayerEndState[id]; ok && before.Team != ps.Team &&
+				(ps.Team == model.TeamCT || ps.Team == model.TeamT) &&
+				(before.Team == model.TeamCT || before.Team == model.TeamT) {
+				out[rnd.Number] = true
+				break
+			}
+		}
+	}
+	return out
+}
+
+// pistolRounds returns the pistol rounds of raw: every round that starts a
+// side (see sideStarts), unless someone already has a force buy's worth of
+// equipment — overtime halves switch sides too, but start with full money.
+func pistolRounds(raw *model.RawMatch) map[int]bool {
+	starts := sideStarts(raw)
+	out := make(map[int]bool)
+	for _, rnd := range raw.Rounds {
+		if !starts[rnd.Number] {
+			continue
+		}
+		gun := false
+		for _, equip := range rnd.PlayerEquipValues {
+			if equip >= 2000 {
+				gun = true
+				break
+			}
+		}
+		if !gun {
+			out[rnd.Number] = true
+		}
+	}
+	return out
+}
+
+// newDuelContexts returns a function giving the round context of a duel the
+// killer won against the victim in round rn: pistol on pistol rounds,
+// anti-eco when the killer had a force or full buy and the victim was on an
+// eco (both equipment values known), gun otherwise.
+func newDuelContexts(raw *model.RawMatch) func(rn int, killerID, victimID uint64) string {
+	pistol := pistolRounds(raw)
+	equipByRound := make(map[int]map[uint64]int, len(raw.Rounds))
+	for _, rnd := range raw.Rounds {
+		equipByRound[rnd.Number] = rnd.PlayerEquipValues
+	}
+	return func(rn int, killerID, victimID uint64) string {
+		if pistol[rn] {
+			return model.RoundContextPistol
+		}
+		killerEquip, kok := equipByRound[rn][killerID]
+		victimEquip, vok := equipByRound[rn][victimID]
+		if kok && vok && buyType(victimEquip) == "eco" {
+			if b := buyType(killerEquip); b == "full" || b == "force" {
+				return model.RoundContextAntiEco
+			}
+		}
+		return model.RoundContextGun
+	}
+}
@@ -0,0 +1,71 @@
+package aggregator
+
+import (
+	"fmt"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// AggregateRounds runs every Aggregate pass on the rounds of raw for which
+// keep returns true, so half splits, pistol-round or scoreline analysis can
+// reuse the whole pipeline on a subset of rounds instead of re-implementing
+// passes. Round numbers are unchanged, and the passes that depend on the
+// round sequence — half starts, pistol rounds, the running score — still see
+// every round of the match (model.RawMatch.MatchRounds). Momentum runs follow
+// only the kept rounds.
+//
+// Like Aggregate, it first removes coach and spectator slots from raw, judged
+// over the whole match; raw is otherwise left unchanged and the subset's pass
+// timings are not recorded on it.
+func AggregateRounds(raw *model.RawMatch, keep func(model.RawRound) bool) ([]model.PlayerMatchStats, []model.PlayerRoundStats, []model.PlayerWeaponStats, []model.PlayerDuelSegment, error) {
+	if raw == nil {
+		return nil, nil, nil, nil, fmt.Errorf("nil RawMatch")
+	}
+	SuppressSpectators(raw)
+	sub := RoundSubset(raw, keep)
+	return aggregate(sub, passClock(sub))
+}
+
+// RoundSubset returns a copy of raw holding only the rounds for which keep
+// returns true and the events of those rounds, with MatchRounds set to every
+// round of the match. The roster and match metadata are shared with raw.
+func RoundSubset(raw *model.RawMatch, keep func(model.RawRound) bool) *model.RawMatch {
+	sub := *raw
+	sub.MatchRounds = raw.MatchRounds
+	if sub.MatchRounds == nil {
+		sub.MatchRounds = raw.Rounds
+	}
+	sub.PassTimings = nil
+
+	kept := make(map[int]bool)
+	sub.Rounds = nil
+	for _, r := range raw.Rounds {
+		if keep(r) {
+			kept[r.Number] = true
+			sub.Rounds = append(sub.Rounds, r)
+		}
+	}
+	sub.Kills = inRounds(raw.Kills, kept, func(k model.RawKill) int { return k.RoundNumber })
+	sub.Damages = inRounds(raw.Damages, kept, func(d model.RawDamage) int { return d.RoundNumber })
+	sub.Flashes = inRounds(raw.Flashes, kept, func(f model.RawFlash) int { return f.RoundNumber })
+	sub.FirstSights = inRounds(raw.FirstSights, kept, func(fs model.RawFirstSight) int { return fs.RoundNumber })
+	sub.WeaponFires = inRounds(raw.WeaponFires, kept, func(wf model.RawWeaponFire) int { return wf.RoundNumber })
+	sub.Defuses = inRounds(raw.Defuses, kept, func(d model.RawDefuse) int { return d.RoundNumber })
+	sub.Plants = inRounds(raw.Plants, kept, func(p model.RawPlant) int { return p.RoundNumber })
+	sub.PositionSamples = inRounds(raw.PositionSamples, kept, func(p model.RawPositionSample) int { return p.RoundNumber })
+	sub.GrenadeThrows = inRounds(raw.GrenadeThrows, kept, func(g model.RawGrenadeThrow) int { return g.RoundNumber })
+	sub.PingSamples = inRounds(raw.PingSamples, kept, func(s model.RawPingSample) int { return s.RoundNumber })
+	return &sub
+}
+
+// inRounds returns the events of s whose round (read by round) is in kept,
+// in their original order.
+func inRounds[T any](s []T, kept map[int]bool, round func(T) int) []T {
+	var out []T
+	for _, e := range s {
+		if kept[round(e)] {
+			out = append(out, e)
+		}
+	}
+	return out
+}
@@ -0,0 +1,73 @@
+package aggregator
+
+import (
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// scorelineCounts holds one player's output per scoreboard state for a match.
+type scorelineCounts struct {
+	leading, tied, trailing model.ScorelineSplit
+}
+
+// scorelineSplits replays the match score round by round and files each
+// player's decided rounds under their team's standing when the round started:
+// leading, tied or trailing. Teams are followed across side swaps (see
+// sideStarts), so the score belongs to the team, not the side. Rounds without
+// a winner neither count nor change the score.
+func scorelineSplits(raw *model.RawMatch, roundStats []model.PlayerRoundStats) map[uint64]*scorelineCounts {
+	rounds := matchRounds(raw)
+	starts := sideStarts(raw)
+
+	// Score before each decided round, by the side each team is on then.
+	type score struct{ ct, t int }
+	before := make(map[int]score, len(rounds))
+	startedCT, startedT := 0, 0 // wins of the team that started on CT / T
+	swapped := false
+	for i, rnd := range rounds {
+		if i > 0 && starts[rnd.Number] {
+			swapped = !swapped
+		}
+		if rnd.WinnerTeam != model.TeamCT && rnd.WinnerTeam != model.TeamT {
+			continue
+		}
+		ctTeamWins, tTeamWins := &startedCT, &startedT
+		if swapped {
+			ctTeamWins, tTeamWins = &startedT, &startedCT
+		}
+		before[rnd.Number] = score{*ctTeamWins, *tTeamWins}
+		if rnd.WinnerTeam == model.TeamCT {
+			*ctTeamWins++
+		} else {
+			*tTeamWins++
+		}
+	}
+
+	out := make(map[uint64]*scorelineCounts)
+	for _, rs := range roundStats {
+		sc, ok := before[rs.RoundNumber]
+		if !ok || (rs.Team != model.TeamCT && rs.Team != model.TeamT) {
+			continue
+		}
+		own, opp := sc.ct, sc.t
+		if rs.Team == model.TeamT {
+			own, opp = opp, own
+		}
+		c := out[rs.SteamID]
+		if c == nil {
+			c = &scorelineCounts{}
+			out[rs.SteamID] = c
+		}
+		split := &c.tied
+		switch {
+		case own > opp:
+			split = &c.leading
+		case own < opp:
+			split = &c.trailing
+		}
+		split.Rounds++
+		split.Kills += rs.Kills
+		split.Deaths += rs.Deaths
+		split.Damage += rs.Damage
+	}
+	return out
+}
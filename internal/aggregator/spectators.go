@@ -0,0 +1,111 @@
+package aggregator
+
+import (
+	"sort"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// SuppressSpectators removes coach and spectator slots from raw and returns
+// them, also recording them on raw.Suppressed. An account is only considered
+// when it has no kill, death, assist, damage, shot, flash or grenade throw of
+// its own, so no stat of a real player can be dropped; it is then removed if
+// it has no round-end state at all, was on the spectator (or no) team in most of its
+// rounds, or was never alive at a round end. Its names, teams, round-end
+// states, loadout values, first sights and position samples are deleted.
+// Aggregate calls it first; calling it again finds nothing.
+func SuppressSpectators(raw *model.RawMatch) []model.SuppressedAccount {
+	active := make(map[uint64]bool)
+	for _, k := range raw.Kills {
+		active[k.KillerSteamID] = true
+		active[k.VictimSteamID] = true
+		active[k.AssisterSteamID] = true
+	}
+	for _, d := range raw.Damages {
+		active[d.AttackerSteamID] = true
+		active[d.VictimSteamID] = true
+	}
+	for _, wf := range raw.WeaponFires {
+		active[wf.ShooterID] = true
+	}
+	for _, f := range raw.Flashes {
+		active[f.AttackerSteamID] = true
+	}
+	for _, g := range raw.GrenadeThrows {
+		active[g.ThrowerID] = true
+	}
+
+	type seen struct{ rounds, spectator, alive int }
+	accounts := make(map[uint64]*seen)
+	for id := range raw.PlayerNames {
+		accounts[id] = &seen{}
+	}
+	for _, r := range raw.Rounds {
+		for id, es := range r.PlayerEndState {
+			a := accounts[id]
+			if a == nil {
+				a = &seen{}
+				accounts[id] = a
+			}
+			a.rounds++
+			if es.Team == model.TeamSpectators || es.Team == model.TeamUnknown {
+				a.spectator++
+			}
+			if es.IsAlive {
+				a.alive++
+			}
+		}
+	}
+
+	drop := make(map[uint64]bool)
+	var out []model.SuppressedAccount
+	for id, a := range accounts {
+		if id == 0 || active[id] {
+			continue
+		}
+		var reason string
+		switch {
+		case a.rounds == 0:
+			reason = model.SuppressedNoRounds
+		case a.spectator*2 > a.rounds:
+			reason = model.SuppressedSpectator
+		case a.alive == 0:
+			reason = model.SuppressedNeverAlive
+		default:
+			continue
+		}
+		drop[id] = true
+		out = append(out, model.SuppressedAccount{SteamID: id, Name: raw.PlayerNames[id], Reason: reason, Rounds: a.rounds})
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].SteamID < out[j].SteamID })
+
+	for id := range drop {
+		delete(raw.PlayerNames, id)
+		delete(raw.PlayerTeams, id)
+	}
+	for _, r := range raw.Rounds {
+		for id := range drop {
+			delete(r.PlayerEndState, id)
+			delete(r.PlayerEquipValues, id)
+		}
+	}
+	sights := raw.FirstSights[:0]
+	for _, fs := range raw.FirstSights {
+		if !drop[fs.ObserverID] && !drop[fs.EnemyID] {
+			sights = append(sights, fs)
+		}
+	}
+	raw.FirstSights = sights
+	samples := raw.PositionSamples[:0]
+	for _, ps := range raw.PositionSamples {
+		if !drop[ps.PlayerID] {
+			samples = append(samples, ps)
+		}
+	}
+	raw.PositionSamples = samples
+	raw.Suppressed = append(raw.Suppressed, out...)
+	return out
+}
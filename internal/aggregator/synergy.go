@@ -0,0 +1,89 @@
+package aggregator
+
+import (
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// playedOffFlashSec is the longest delay between a teammate's flash blinding
+// the victim and the kill for the kill to count as played off that flash.
+const playedOffFlashSec = 2.0
+
+// synergyCounts holds one player's kills played off teammates' utility.
+type synergyCounts struct {
+	offFlash, offSmoke, offUtility int
+}
+
+// newUtilitySupport returns a function reporting whether a kill on an enemy
+// followed a teammate's utility: the victim was blinded by a teammate's flash
+// (not the killer's own) at most playedOffFlashSec before the kill, or the
+// kill went through a smoke a teammate threw.
+func newUtilitySupport(raw *model.RawMatch) func(k model.RawKill) (offFlash, offSmoke bool) {
+	tps := raw.TicksPerSecond
+	if tps == 0 {
+		tps = 64.0
+	}
+	window := int(playedOffFlashSec * tps)
+
+	type roundVictim struct {
+		round    int
+		victimID uint64
+	}
+	flashes := make(map[roundVictim][]model.RawFlash)
+	for _, f := range raw.Flashes {
+		if f.AttackerTeam == f.VictimTeam {
+			continue
+		}
+		k := roundVictim{f.RoundNumber, f.VictimSteamID}
+		flashes[k] = append(flashes[k], f)
+	}
+
+	// End states give the smoke thrower's side in the kill's round (sides swap
+	// at halftime).
+	endStates := make(map[int]map[uint64]model.PlayerRoundEndState, len(raw.Rounds))
+	for _, rnd := range raw.Rounds {
+		endStates[rnd.Number] = rnd.PlayerEndState
+	}
+
+	return func(k model.RawKill) (offFlash, offSmoke bool) {
+		if k.KillerSteamID == 0 || k.KillerTeam == k.VictimTeam {
+			return false, false
+		}
+		for _, f := range flashes[roundVictim{k.RoundNumber, k.VictimSteamID}] {
+			if f.AttackerSteamID != k.KillerSteamID && f.AttackerTeam == k.KillerTeam &&
+				f.Tick <= k.Tick && k.Tick-f.Tick <= window {
+				offFlash = true
+				break
+			}
+		}
+		offSmoke = k.ThroughSmoke && k.SmokeThrowerID != 0 && k.SmokeThrowerID != k.KillerSteamID &&
+			endStates[k.RoundNumber][k.SmokeThrowerID].Team == k.KillerTeam
+		return offFlash, offSmoke
+	}
+}
+
+// utilitySynergy credits each kill on an enemy that followed a teammate's
+// utility (see newUtilitySupport). A kill that was both played off a flash
+// and through a smoke counts once in offUtility.
+func utilitySynergy(raw *model.RawMatch) map[uint64]*synergyCounts {
+	support := newUtilitySupport(raw)
+	out := make(map[uint64]*synergyCounts)
+	for _, k := range raw.Kills {
+		offFlash, offSmoke := support(k)
+		if !offFlash && !offSmoke {
+			continue
+		}
+		c := out[k.KillerSteamID]
+		if c == nil {
+			c = &synergyCounts{}
+			out[k.KillerSteamID] = c
+		}
+		if offFlash {
+			c.offFlash++
+		}
+		if offSmoke {
+			c.offSmoke++
+		}
+		c.offUtility++
+	}
+	return out
+}
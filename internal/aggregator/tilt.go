@@ -0,0 +1,94 @@
+package aggregator
+
+import "github.com/pable/go-cs-metrics/internal/model"
+
+// matchResult returns 1 for a won match, -1 for a lost one and 0 for a draw
+// or a match with no rounds, by the player's rounds won.
+func matchResult(s *model.PlayerMatchStats) int {
+	switch {
+	case s.RoundsPlayed == 0 || s.RoundsWon*2 == s.RoundsPlayed:
+		return 0
+	case s.RoundsWon*2 > s.RoundsPlayed:
+		return 1
+	}
+	return -1
+}
+
+// Tilt groups a player's matches (one row per match, chronological) into
+// sessions by match date and measures how they play after a result within a
+// session: the mean rating of the match following a loss and following a
+// win, against the mean rating over all matches. Losing streaks are runs of
+// at least model.LossStreakMin lost matches within one session. Matches with
+// no rounds played are skipped; a draw ends a losing streak.
+func Tilt(stats []model.PlayerMatchStats) model.PlayerTilt {
+	var t model.PlayerTilt
+	var all, afterLoss, afterWin []float64
+	var cur *model.PlayerSession
+	var sessionRatings []float64
+	prev, streak := 0, 0
+
+	flush := func() {
+		if cur != nil && cur.Matches >= 2 {
+			cur.AvgRating = mean(sessionRatings)
+			t.Sessions = append(t.Sessions, *cur)
+		}
+	}
+	for i := range stats {
+		s := &stats[i]
+		if s.RoundsPlayed == 0 {
+			continue
+		}
+		if cur == nil || cur.Date != s.MatchDate {
+			flush()
+			cur = &model.PlayerSession{Date: s.MatchDate}
+			sessionRatings = sessionRatings[:0]
+			prev, streak = 0, 0
+		}
+		r := s.Rating()
+		all = append(all, r)
+		sessionRatings = append(sessionRatings, r)
+		switch prev {
+		case -1:
+			afterLoss = append(afterLoss, r)
+		case 1:
+			afterWin = append(afterWin, r)
+		}
+
+		cur.Matches++
+		res := matchResult(s)
+		switch res {
+		case 1:
+			cur.Wins++
+		case -1:
+			cur.Losses++
+		}
+		if res == -1 {
+			streak++
+			if streak == model.LossStreakMin {
+				t.LossStreaks++
+			}
+			cur.LongestLossStreak = max(cur.LongestLossStreak, streak)
+		} else {
+			streak = 0
+		}
+		prev = res
+	}
+	flush()
+
+	t.BaselineRating = mean(all)
+	t.AfterLoss, t.AfterLossRating = len(afterLoss), mean(afterLoss)
+	t.AfterWin, t.AfterWinRating = len(afterWin), mean(afterWin)
+	return t
+}
+
+// mean returns the arithmetic mean of values, or 0 when empty.
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
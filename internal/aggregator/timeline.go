@@ -0,0 +1,76 @@
+package aggregator
+
+import (
+	"sort"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// timelineRank groups events within a round: the round start and freeze end
+// come first and the round end last, whatever their ticks; every other event
+// ranks 2.
+var timelineRank = map[string]int{
+	model.TimelineRoundStart: 0,
+	model.TimelineFreezeEnd:  1,
+	model.TimelineRoundEnd:   3,
+}
+
+// Timeline returns raw's round-by-round event stream: round start, freeze
+// end and round end boundaries, kills with both players' positions, flashes,
+// bomb plants and defuses. Events are ordered by round, timelineRank and
+// tick, and numbered by Seq in that order. Rounds without a freeze end tick
+// have no freeze_end event.
+func Timeline(raw *model.RawMatch) []model.TimelineEvent {
+	var out []model.TimelineEvent
+	add := func(e model.TimelineEvent) {
+		e.DemoHash = raw.DemoHash
+		out = append(out, e)
+	}
+	for _, r := range raw.Rounds {
+		add(model.TimelineEvent{RoundNumber: r.Number, Tick: r.StartTick, Type: model.TimelineRoundStart})
+		if r.FreezeEndTick > r.StartTick {
+			add(model.TimelineEvent{RoundNumber: r.Number, Tick: r.FreezeEndTick, Type: model.TimelineFreezeEnd})
+		}
+		add(model.TimelineEvent{RoundNumber: r.Number, Tick: r.EndTick, Type: model.TimelineRoundEnd,
+			ActorTeam: r.WinnerTeam, Detail: r.EndReason})
+	}
+	for _, k := range raw.Kills {
+		add(model.TimelineEvent{RoundNumber: k.RoundNumber, Tick: k.Tick, Type: model.TimelineKill,
+			ActorID: k.KillerSteamID, TargetID: k.VictimSteamID, ActorTeam: k.KillerTeam, TargetTeam: k.VictimTeam,
+			ActorPos: k.KillerPos, TargetPos: k.VictimPos, Weapon: k.Weapon, Headshot: k.IsHeadshot})
+	}
+	for _, f := range raw.Flashes {
+		add(model.TimelineEvent{RoundNumber: f.RoundNumber, Tick: f.Tick, Type: model.TimelineFlash,
+			ActorID: f.AttackerSteamID, TargetID: f.VictimSteamID, ActorTeam: f.AttackerTeam, TargetTeam: f.VictimTeam,
+			DurationSec: f.FlashDuration.Seconds()})
+	}
+	for _, p := range raw.Plants {
+		add(model.TimelineEvent{RoundNumber: p.RoundNumber, Tick: p.Tick, Type: model.TimelinePlant,
+			ActorID: p.PlanterID, ActorTeam: model.TeamT, ActorPos: p.Pos, Detail: p.Site})
+	}
+	for _, d := range raw.Defuses {
+		add(model.TimelineEvent{RoundNumber: d.RoundNumber, Tick: d.Tick, Type: model.TimelineDefuse,
+			ActorID: d.DefuserID, ActorTeam: model.TeamCT, ActorPos: d.Pos, Detail: d.Site})
+	}
+
+	rank := func(e model.TimelineEvent) int {
+		if r, ok := timelineRank[e.Type]; ok {
+			return r
+		}
+		return 2
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.RoundNumber != b.RoundNumber {
+			return a.RoundNumber < b.RoundNumber
+		}
+		if ra, rb := rank(a), rank(b); ra != rb {
+			return ra < rb
+		}
+		return a.Tick < b.Tick
+	})
+	for i := range out {
+		out[i].Seq = i
+	}
+	return out
+}
@@ -0,0 +1,87 @@
+package aggregator
+
+import (
+	"sort"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// TradeChains returns raw's trade chains (see model.TradeChain) in round and
+// tick order. Kills are walked per round in tick order; a kill joins the
+// first open chain in which its victim, or its killer, killed within the
+// trade window (5s, as for trade kills) before it, and starts a new chain
+// otherwise. World kills and team kills are skipped.
+func TradeChains(raw *model.RawMatch) []model.TradeChain {
+	window := int(5.0 * raw.TicksPerSecond)
+
+	byRound := make(map[int][]model.RawKill)
+	for _, k := range raw.Kills {
+		if k.KillerSteamID == 0 || k.KillerTeam == k.VictimTeam {
+			continue
+		}
+		byRound[k.RoundNumber] = append(byRound[k.RoundNumber], k)
+	}
+	rounds := make([]int, 0, len(byRound))
+	for rn := range byRound {
+		rounds = append(rounds, rn)
+	}
+	sort.Ints(rounds)
+
+	type openChain struct {
+		chain    model.TradeChain
+		lastKill map[uint64]int // chain killer → tick of their latest chain kill
+	}
+	var out []model.TradeChain
+	for _, rn := range rounds {
+		kills := byRound[rn]
+		sort.SliceStable(kills, func(i, j int) bool { return kills[i].Tick < kills[j].Tick })
+
+		var chains []*openChain
+		for _, k := range kills {
+			var joined *openChain
+			trade := false
+			for _, c := range chains {
+				if t, ok := c.lastKill[k.VictimSteamID]; ok && k.Tick-t <= window {
+					joined, trade = c, true
+					break
+				}
+				if t, ok := c.lastKill[k.KillerSteamID]; ok && k.Tick-t <= window {
+					joined = c
+					break
+				}
+			}
+			if joined == nil {
+				joined = &openChain{
+					chain: model.TradeChain{DemoHash: raw.DemoHash, RoundNumber: rn,
+						StartTick: k.Tick, FirstTeam: k.KillerTeam},
+					lastKill: make(map[uint64]int),
+				}
+				chains = append(chains, joined)
+			}
+			c := &joined.chain
+			c.EndTick = k.Tick
+			c.Kills++
+			if trade {
+				c.Trades++
+			}
+			switch k.KillerTeam {
+			case model.TeamCT:
+				c.CTKills++
+			case model.TeamT:
+				c.TKills++
+			}
+			joined.lastKill[k.KillerSteamID] = k.Tick
+		}
+
+		idx := 0
+		for _, c := range chains {
+			if c.chain.Trades == 0 {
+				continue
+			}
+			c.chain.Index = idx
+			idx++
+			out = append(out, c.chain)
+		}
+	}
+	return out
+}
@@ -0,0 +1,58 @@
+package aggregator
+
+import (
+	"sort"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// utilEarlySec is how long after freeze end a grenade throw counts as
+// early-round (setup) utility.
+const utilEarlySec = 25.0
+
+// utilTimingCounts holds one player's grenade throws by round phase and the
+// seconds after freeze end of each throw.
+type utilTimingCounts struct {
+	early, mid, late int
+	secs             []float64
+}
+
+// utilityTiming classifies every grenade throw against the round clock (see
+// roundClock): early (setup) within utilEarlySec of freeze end, late once the
+// bomb is down or with lateRoundSec or less on the round timer, mid
+// (reactive, mid-round) otherwise. Throws outside a known round's freeze end
+// to end — during freeze time or after the round was decided — are skipped.
+func utilityTiming(raw *model.RawMatch) map[uint64]*utilTimingCounts {
+	clock := newRoundClock(raw)
+	rounds := make(map[int]model.RawRound, len(raw.Rounds))
+	for _, r := range raw.Rounds {
+		rounds[r.Number] = r
+	}
+
+	out := make(map[uint64]*utilTimingCounts)
+	for _, g := range raw.GrenadeThrows {
+		rnd, ok := rounds[g.RoundNumber]
+		if !ok || g.Tick < rnd.FreezeEndTick || (rnd.EndTick > 0 && g.Tick > rnd.EndTick) {
+			continue
+		}
+		c := out[g.ThrowerID]
+		if c == nil {
+			c = &utilTimingCounts{}
+			out[g.ThrowerID] = c
+		}
+		sec := float64(g.Tick-rnd.FreezeEndTick) / clock.tps
+		c.secs = append(c.secs, sec)
+		switch {
+		case planted(rnd, g.Tick) || clock.remaining(rnd, g.Tick) <= lateRoundSec:
+			c.late++
+		case sec <= utilEarlySec:
+			c.early++
+		default:
+			c.mid++
+		}
+	}
+	for _, c := range out {
+		sort.Float64s(c.secs)
+	}
+	return out
+}
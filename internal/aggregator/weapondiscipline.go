@@ -0,0 +1,43 @@
+package aggregator
+
+import (
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// disciplineCounts holds one player's deaths with a primary in the inventory
+// and how many of them were caught on the wrong weapon.
+type disciplineCounts struct {
+	primaryDeaths, wrongDeaths int
+}
+
+// wrongWeapon reports whether class is a weapon a player carrying a primary
+// should not be caught holding in a fight.
+func wrongWeapon(class string) bool {
+	return class == model.WeaponClassPistol || class == model.WeaponClassKnife || class == model.WeaponClassGrenade
+}
+
+// weaponDiscipline counts, per victim, the deaths to enemies while carrying a
+// primary and those where the weapon in hand at the kill was a pistol, knife
+// or grenade. Deaths holding the bomb, a Zeus or nothing known are counted
+// only in the denominator; world deaths, suicides and team kills are skipped.
+func weaponDiscipline(raw *model.RawMatch) map[uint64]*disciplineCounts {
+	out := make(map[uint64]*disciplineCounts)
+	for _, k := range raw.Kills {
+		if k.KillerSteamID == 0 || k.KillerSteamID == k.VictimSteamID || k.KillerTeam == k.VictimTeam {
+			continue
+		}
+		if !k.VictimHasPrimary {
+			continue
+		}
+		c := out[k.VictimSteamID]
+		if c == nil {
+			c = &disciplineCounts{}
+			out[k.VictimSteamID] = c
+		}
+		c.primaryDeaths++
+		if wrongWeapon(k.VictimWeaponClass) {
+			c.wrongDeaths++
+		}
+	}
+	return out
+}
@@ -0,0 +1,115 @@
+package aggregator
+
+//go:generate go run ./genweapons
+
+import (
+	"sort"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// weaponBuckets maps every weapon name demoinfocs produces (its
+// EquipmentType.String(), listed in demoinfocsWeaponNames) to the broad
+// category used for FHHS segments, bursts and death profiles. Weapons that
+// don't warrant a category of their own map to "Other" explicitly, so a name
+// missing from this table is a new or renamed weapon rather than an SMG;
+// TestWeaponBucketsCoverDemoinfocs fails until it is added here.
+var weaponBuckets = map[string]string{
+	"AK-47":        "AK",
+	"M4A1":         "M4", // demoinfocs' name for the M4A1-S
+	"M4A1-S":       "M4",
+	"M4A4":         "M4",
+	"Galil AR":     "Galil",
+	"FAMAS":        "FAMAS",
+	"AUG":          "ScopedRifle",
+	"SG 553":       "ScopedRifle",
+	"AWP":          "AWP",
+	"SSG 08":       "Scout",
+	"Desert Eagle": "Deagle",
+
+	"USP-S":         "Pistol",
+	"Glock-18":      "Pistol",
+	"P250":          "Pistol",
+	"Five-SeveN":    "Pistol",
+	"Tec-9":         "Pistol",
+	"CZ75 Auto":     "Pistol",
+	"P2000":         "Pistol",
+	"Dual Berettas": "Pistol",
+	"R8 Revolver":   "Pistol",
+
+	// SMGs, heavies and auto-snipers.
+	"MP7":       "Other",
+	"MP9":       "Other",
+	"MP5-SD":    "Other",
+	"MAC-10":    "Other",
+	"UMP-45":    "Other",
+	"P90":       "Other",
+	"PP-Bizon":  "Other",
+	"Nova":      "Other",
+	"XM1014":    "Other",
+	"Sawed-Off": "Other",
+	"MAG-7":     "Other",
+	"M249":      "Other",
+	"Negev":     "Other",
+	"SCAR-20":   "Other",
+	"G3SG1":     "Other",
+
+	// Knife, utility, gear and the world.
+	"Knife":              "Other",
+	"Zeus x27":           "Other",
+	"HE Grenade":         "Other",
+	"Molotov":            "Other",
+	"Incendiary Grenade": "Other",
+	"Flashbang":          "Other",
+	"Smoke Grenade":      "Other",
+	"Decoy Grenade":      "Other",
+	"C4":                 "Other",
+	"Kevlar Vest":        "Other",
+	"Kevlar + Helmet":    "Other",
+	"Defuse Kit":         "Other",
+	"World":              "Other",
+}
+
+// weaponBucket maps a weapon name (as returned by demoinfocs .String()) to a
+// broad category bucket used for FHHS segment grouping. For example, "M4A1"
+// (the M4A1-S) and "M4A4" both map to "M4". Weapons that do not match any
+// known category are placed in the "Other" bucket.
+func weaponBucket(weapon string) string {
+	if b, ok := weaponBuckets[weapon]; ok {
+		return b
+	}
+	return "Other"
+}
+
+// UnmappedWeapons counts the kills, damage events and shots fired in raw with
+// each weapon name missing from the bucket table — typically a weapon Valve
+// added after the table was last synced, or demoinfocs' "UNKNOWN". Events
+// without a weapon are ignored. Sorted by events, most first.
+func UnmappedWeapons(raw *model.RawMatch) []model.UnmappedWeapon {
+	counts := make(map[string]int)
+	note := func(weapon string) {
+		if _, ok := weaponBuckets[weapon]; !ok && weapon != "" {
+			counts[weapon]++
+		}
+	}
+	for _, k := range raw.Kills {
+		note(k.Weapon)
+	}
+	for _, d := range raw.Damages {
+		note(d.Weapon)
+	}
+	for _, wf := range raw.WeaponFires {
+		note(wf.Weapon)
+	}
+	out := make([]model.UnmappedWeapon, 0, len(counts))
+	for w, n := range counts {
+		out = append(out, model.UnmappedWeapon{DemoHash: raw.DemoHash, Weapon: w, Events: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Events != out[j].Events {
+			return out[i].Events > out[j].Events
+		}
+		return out[i].Weapon < out[j].Weapon
+	})
+	return out
+}
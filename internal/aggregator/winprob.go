@@ -0,0 +1,169 @@
+package aggregator
+
+import "github.com/pable/go-cs-metrics/internal/model"
+
+// winProbPrior is the pseudo-count used to shrink sparse win-probability
+// states toward the man-advantage prior CT/(CT+T).
+const winProbPrior = 4.0
+
+// KillStates returns the pre-kill round state (players alive per side, bomb
+// planted) for every kill in a decided round, in tick order within each round.
+// Alive counts start from the players on each side in the round's end state and
+// drop with each death; kills after the round ended are skipped. The time left
+// on the active round or bomb clock is modeled by roundClock.
+func KillStates(raw *model.RawMatch) []model.KillState {
+	clock := newRoundClock(raw)
+	killsByRound := make(map[int][]model.RawKill)
+	for _, k := range raw.Kills {
+		killsByRound[k.RoundNumber] = append(killsByRound[k.RoundNumber], k)
+	}
+
+	var out []model.KillState
+	for _, rnd := range raw.Rounds {
+		if rnd.WinnerTeam != model.TeamCT && rnd.WinnerTeam != model.TeamT {
+			continue
+		}
+		var ctAlive, tAlive int
+		for _, ps := range rnd.PlayerEndState {
+			switch ps.Team {
+			case model.TeamCT:
+				ctAlive++
+			case model.TeamT:
+				tAlive++
+			}
+		}
+		for _, k := range killsByRound[rnd.Number] {
+			if rnd.EndTick > 0 && k.Tick > rnd.EndTick {
+				continue
+			}
+			if ctAlive <= 0 || tAlive <= 0 {
+				break
+			}
+			out = append(out, model.KillState{
+				DemoHash:      raw.DemoHash,
+				RoundNumber:   rnd.Number,
+				Tick:          k.Tick,
+				KillerSteamID: k.KillerSteamID,
+				VictimSteamID: k.VictimSteamID,
+				KillerTeam:    k.KillerTeam,
+				VictimTeam:    k.VictimTeam,
+				CTAlive:       ctAlive,
+				TAlive:        tAlive,
+				BombPlanted:   rnd.BombPlantTick > 0 && k.Tick >= rnd.BombPlantTick,
+				WinnerTeam:    rnd.WinnerTeam,
+				Weapon:        k.Weapon,
+				ClockSec:      clock.remaining(rnd, k.Tick),
+			})
+			switch k.VictimTeam {
+			case model.TeamCT:
+				ctAlive--
+			case model.TeamT:
+				tAlive--
+			}
+		}
+	}
+	return out
+}
+
+// winProbKey identifies one round state in the win-probability table.
+type winProbKey struct {
+	ct, t   int
+	planted bool
+}
+
+// WinProbTable is an empirical model of P(CT wins the round | state), fitted
+// from stored kill states. The zero value falls back to the prior everywhere.
+type WinProbTable struct {
+	ctWins map[winProbKey]float64
+	n      map[winProbKey]float64
+}
+
+// clampAlive caps alive counts at 5 so odd lobby sizes share the 5v5 states.
+func clampAlive(n int) int {
+	if n > 5 {
+		return 5
+	}
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+// postKill returns the state after the kill recorded in ks.
+func postKill(ks model.KillState) winProbKey {
+	key := winProbKey{ct: clampAlive(ks.CTAlive), t: clampAlive(ks.TAlive), planted: ks.BombPlanted}
+	switch ks.VictimTeam {
+	case model.TeamCT:
+		key.ct = clampAlive(ks.CTAlive - 1)
+	case model.TeamT:
+		key.t = clampAlive(ks.TAlive - 1)
+	}
+	return key
+}
+
+// BuildWinProbTable fits the win-probability table from kill states. Each
+// distinct state a round passes through (before and after every kill) counts
+// once toward that state's CT win rate.
+func BuildWinProbTable(states []model.KillState) WinProbTable {
+	tbl := WinProbTable{ctWins: make(map[winProbKey]float64), n: make(map[winProbKey]float64)}
+	type roundKey struct {
+		demo  string
+		round int
+	}
+	seen := make(map[roundKey]map[winProbKey]bool)
+	for _, ks := range states {
+		rk := roundKey{ks.DemoHash, ks.RoundNumber}
+		if seen[rk] == nil {
+			seen[rk] = make(map[winProbKey]bool)
+		}
+		pre := winProbKey{ct: clampAlive(ks.CTAlive), t: clampAlive(ks.TAlive), planted: ks.BombPlanted}
+		for _, key := range []winProbKey{pre, postKill(ks)} {
+			if seen[rk][key] {
+				continue
+			}
+			seen[rk][key] = true
+			tbl.n[key]++
+			if ks.WinnerTeam == model.TeamCT {
+				tbl.ctWins[key]++
+			}
+		}
+	}
+	return tbl
+}
+
+// CTWinProb returns the smoothed probability that CT wins from a state. A side
+// with nobody left has lost, except that eliminating T after a plant still
+// leaves CT to defuse and is taken from the data.
+func (tbl WinProbTable) CTWinProb(ctAlive, tAlive int, planted bool) float64 {
+	key := winProbKey{ct: clampAlive(ctAlive), t: clampAlive(tAlive), planted: planted}
+	switch {
+	case key.ct == 0:
+		return 0
+	case key.t == 0 && !planted:
+		return 1
+	}
+	prior := float64(key.ct) / float64(key.ct+key.t)
+	return (tbl.ctWins[key] + winProbPrior*prior) / (tbl.n[key] + winProbPrior)
+}
+
+// KillWPA returns each player's win probability added by kills: the killer is
+// credited with the swing in their side's win probability and the victim is
+// debited the same amount. World and team kills debit the victim only.
+func (tbl WinProbTable) KillWPA(states []model.KillState) map[uint64]float64 {
+	wpa := make(map[uint64]float64)
+	for _, ks := range states {
+		before := tbl.CTWinProb(ks.CTAlive, ks.TAlive, ks.BombPlanted)
+		post := postKill(ks)
+		after := tbl.CTWinProb(post.ct, post.t, post.planted)
+		// Swing from the victim side's perspective (always ≤ 0 in a fitted table).
+		swing := after - before
+		if ks.VictimTeam == model.TeamT {
+			swing = -swing
+		}
+		wpa[ks.VictimSteamID] += swing
+		if ks.KillerSteamID != 0 && ks.KillerTeam != ks.VictimTeam {
+			wpa[ks.KillerSteamID] -= swing
+		}
+	}
+	return wpa
+}
@@ -0,0 +1,142 @@
+// Package config resolves where csmetrics keeps its data on each platform and
+// moves data left in the legacy ~/.csmetrics directory to the new location.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// appDir is the per-application directory name under the platform data root.
+const appDir = "csmetrics"
+
+// dbFile is the metrics database file name inside the data directory.
+const dbFile = "metrics.db"
+
+// DataDir returns the platform data directory for csmetrics:
+//   - Linux and other Unix: $XDG_DATA_HOME/csmetrics, or ~/.local/share/csmetrics
+//   - macOS: ~/Library/Application Support/csmetrics
+//   - Windows: %APPDATA%\csmetrics
+func DataDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil && runtime.GOOS != "windows" {
+		return "", fmt.Errorf("resolve home directory: %w", err)
+	}
+	return dataDir(runtime.GOOS, os.Getenv, home)
+}
+
+// dataDir is DataDir for an explicit platform, environment and home directory.
+func dataDir(goos string, getenv func(string) string, home string) (string, error) {
+	switch goos {
+	case "windows":
+		if appData := getenv("APPDATA"); appData != "" {
+			return filepath.Join(appData, appDir), nil
+		}
+		if home == "" {
+			return "", errors.New("neither %APPDATA% nor the home directory is set")
+		}
+		return filepath.Join(home, "AppData", "Roaming", appDir), nil
+	case "darwin", "ios":
+		return filepath.Join(home, "Library", "Application Support", appDir), nil
+	default:
+		// The XDG spec requires an absolute path; relative values are ignored.
+		if xdg := getenv("XDG_DATA_HOME"); filepath.IsAbs(xdg) {
+			return filepath.Join(xdg, appDir), nil
+		}
+		return filepath.Join(home, ".local", "share", appDir), nil
+	}
+}
+
+// DefaultDBPath returns the default metrics database path inside DataDir.
+func DefaultDBPath() (string, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, dbFile), nil
+}
+
+// LegacyDBPath returns the database path used before the platform data
+// directory, ~/.csmetrics/metrics.db.
+func LegacyDBPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("resolve home directory: %w", err)
+	}
+	return filepath.Join(home, ".csmetrics", dbFile), nil
+}
+
+// MigrateLegacyDB moves the database at legacy to dst, together with its
+// SQLite -wal and -shm side files, when legacy exists and dst does not. It
+// reports whether anything was moved. Files are renamed when possible and
+// copied then removed across filesystems; on failure dst is left absent so the
+// legacy database stays authoritative.
+func MigrateLegacyDB(legacy, dst string) (bool, error) {
+	if legacy == dst {
+		return false, nil
+	}
+	if _, err := os.Stat(legacy); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("stat legacy database: %w", err)
+	}
+	if _, err := os.Stat(dst); err == nil {
+		return false, nil
+	} else if !os.IsNotExist(err) {
+		return false, fmt.Errorf("stat database: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return false, fmt.Errorf("create data directory: %w", err)
+	}
+
+	// Side files first: the main file appearing at dst is what marks the
+	// migration as done. On failure, files already moved are moved back.
+	var moved []string
+	for _, suffix := range []string{"-wal", "-shm", ""} {
+		src := legacy + suffix
+		if _, err := os.Stat(src); os.IsNotExist(err) {
+			continue
+		}
+		if err := moveFile(src, dst+suffix); err != nil {
+			for _, s := range moved {
+				moveFile(dst+s, legacy+s)
+			}
+			return false, fmt.Errorf("move %s: %w", src, err)
+		}
+		moved = append(moved, suffix)
+	}
+	return true, nil
+}
+
+// moveFile renames src to dst, falling back to copy and remove when a rename
+// is not possible (e.g. across filesystems).
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	in.Close()
+	return os.Remove(src)
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDataDir(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(k string) string { return vars[k] }
+	}
+	cases := []struct {
+		name string
+		goos string
+		env  map[string]string
+		home string
+		want string
+	}{
+		{"linux default", "linux", nil, "/home/u", "/home/u/.local/share/csmetrics"},
+		{"linux xdg", "linux", map[string]string{"XDG_DATA_HOME": "/data"}, "/home/u", "/data/csmetrics"},
+		{"linux relative xdg ignored", "linux", map[string]string{"XDG_DATA_HOME": "data"}, "/home/u", "/home/u/.local/share/csmetrics"},
+		{"freebsd xdg", "freebsd", map[string]string{"XDG_DATA_HOME": "/xdg"}, "/home/u", "/xdg/csmetrics"},
+		{"darwin", "darwin", map[string]string{"XDG_DATA_HOME": "/data"}, "/Users/u", "/Users/u/Library/Application Support/csmetrics"},
+		{"ios", "ios", nil, "/var/mobile", "/var/mobile/Library/Application Support/csmetrics"},
+		{"windows appdata", "windows", map[string]string{"APPDATA": `C:\Users\u\AppData\Roaming`}, "", filepath.Join(`C:\Users\u\AppData\Roaming`, "csmetrics")},
+		{"windows home fallback", "windows", nil, "/home/u", "/home/u/AppData/Roaming/csmetrics"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := dataDir(c.goos, env(c.env), c.home)
+			if err != nil {
+				t.Fatalf("dataDir: %v", err)
+			}
+			if want := filepath.FromSlash(c.want); got != want {
+				t.Errorf("dataDir = %q, want %q", got, want)
+			}
+		})
+	}
+
+	if _, err := dataDir("windows", env(nil), ""); err == nil {
+		t.Error("windows without APPDATA or home: want an error")
+	}
+}
+
+// writeFiles creates each path with its own name as content.
+func writeFiles(t *testing.T, paths ...string) {
+	t.Helper()
+	for _, p := range paths {
+		if err := os.WriteFile(p, []byte(filepath.Base(p)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// assertFile fails unless path exists with want as content.
+func assertFile(t *testing.T, path, want string) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("%s: %v", path, err)
+		return
+	}
+	if string(got) != want {
+		t.Errorf("%s = %q, want %q", path, got, want)
+	}
+}
+
+// assertAbsent fails if path exists.
+func assertAbsent(t *testing.T, path string) {
+	t.Helper()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("%s exists, want it absent (stat err %v)", path, err)
+	}
+}
+
+func TestMigrateLegacyDB(t *testing.T) {
+	t.Run("moves database and side files", func(t *testing.T) {
+		dir := t.TempDir()
+		legacy := filepath.Join(dir, ".csmetrics", "metrics.db")
+		dst := filepath.Join(dir, "share", "csmetrics", "metrics.db")
+		if err := os.MkdirAll(filepath.Dir(legacy), 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeFiles(t, legacy, legacy+"-wal", legacy+"-shm")
+
+		moved, err := MigrateLegacyDB(legacy, dst)
+		if err != nil || !moved {
+			t.Fatalf("MigrateLegacyDB = %v, %v; want true, nil", moved, err)
+		}
+		for _, suffix := range []string{"", "-wal", "-shm"} {
+			assertFile(t, dst+suffix, "metrics.db"+suffix)
+			assertAbsent(t, legacy+suffix)
+		}
+
+		moved, err = MigrateLegacyDB(legacy, dst)
+		if err != nil || moved {
+			t.Errorf("second run = %v, %v; want false, nil", moved, err)
+		}
+	})
+
+	t.Run("no side files", func(t *testing.T) {
+		dir := t.TempDir()
+		legacy := filepath.Join(dir, "old.db")
+		dst := filepath.Join(dir, "new", "metrics.db")
+		writeFiles(t, legacy)
+		if moved, err := MigrateLegacyDB(legacy, dst); err != nil || !moved {
+			t.Fatalf("MigrateLegacyDB = %v, %v; want true, nil", moved, err)
+		}
+		assertFile(t, dst, "old.db")
+		assertAbsent(t, dst+"-wal")
+		assertAbsent(t, legacy)
+	})
+
+	t.Run("no legacy database", func(t *testing.T) {
+		dir := t.TempDir()
+		dst := filepath.Join(dir, "new", "metrics.db")
+		if moved, err := MigrateLegacyDB(filepath.Join(dir, "missing.db"), dst); err != nil || moved {
+			t.Fatalf("MigrateLegacyDB = %v, %v; want false, nil", moved, err)
+		}
+		assertAbsent(t, filepath.Dir(dst))
+	})
+
+	t.Run("same path", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "metrics.db")
+		writeFiles(t, path)
+		if moved, err := MigrateLegacyDB(path, path); err != nil || moved {
+			t.Fatalf("MigrateLegacyDB = %v, %v; want false, nil", moved, err)
+		}
+		assertFile(t, path, "metrics.db")
+	})
+
+	t.Run("existing target is left alone", func(t *testing.T) {
+		dir := t.TempDir()
+		legacy := filepath.Join(dir, "legacy.db")
+		dst := filepath.Join(dir, "metrics.db")
+		writeFiles(t, legacy, legacy+"-wal", dst)
+
+		moved, err := MigrateLegacyDB(legacy, dst)
+		if err != nil || moved {
+			t.Fatalf("MigrateLegacyDB = %v, %v; want false, nil", moved, err)
+		}
+		assertFile(t, dst, "metrics.db")
+		assertFile(t, legacy, "legacy.db")
+		assertFile(t, legacy+"-wal", "legacy.db-wal")
+		assertAbsent(t, dst+"-wal")
+	})
+
+	t.Run("failure rolls back moved files", func(t *testing.T) {
+		dir := t.TempDir()
+		legacy := filepath.Join(dir, "legacy.db")
+		dst := filepath.Join(dir, "new", "metrics.db")
+		writeFiles(t, legacy, legacy+"-wal", legacy+"-shm")
+		// A directory where the -shm file goes makes its move fail after
+		// the -wal file has already been moved.
+		if err := os.MkdirAll(filepath.Join(dst+"-shm", "block"), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		moved, err := MigrateLegacyDB(legacy, dst)
+		if err == nil || moved {
+			t.Fatalf("MigrateLegacyDB = %v, %v; want false and an error", moved, err)
+		}
+		assertAbsent(t, dst)
+		assertAbsent(t, dst+"-wal")
+		for _, suffix := range []string{"", "-wal", "-shm"} {
+			assertFile(t, legacy+suffix, "legacy.db"+suffix)
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// configFile is the settings file name inside the data directory.
+const configFile = "config.json"
+
+// Feature is an optional, high-volume dataset stored per parsed demo. Each one
+// can be switched off in the config file to trade analysis depth for a
+// smaller database.
+type Feature struct {
+	Name    string // key in the config file's "storage" object
+	Table   string // table the dataset is stored in
+	Default bool   // stored when the config file does not mention it
+	Summary string // what the data feeds, shown by "db"
+}
+
+// Features lists the optional datasets in the order "db" shows them.
+var Features = []Feature{
+	{Name: "positions", Table: "player_positions", Default: true,
+		Summary: "early-round positions (player, analyze)"},
+	{Name: "duel_distances", Table: "player_duel_distances", Default: true,
+		Summary: "won duels per meter (player distance bins)"},
+	{Name: "kill_states", Table: "round_kill_states", Default: true,
+		Summary: "man-advantage state at each kill (analyze, export)"},
+	{Name: "timeline", Table: "round_events", Default: true,
+		Summary: "round events (timeline)"},
+	{Name: "first_sights", Table: "player_first_sights", Default: false,
+		Summary: "every player's first sights (sights); when off, only " +
+			"CSMETRICS_SIGHT_PLAYERS are kept"},
+}
+
+// LookupFeature returns the Feature named name.
+func LookupFeature(name string) (Feature, bool) {
+	for _, f := range Features {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Feature{}, false
+}
+
+// Config is the user settings file. Only the keys the user has set are
+// written back; everything else keeps its default.
+type Config struct {
+	// Storage switches optional datasets on or off by Feature name.
+	Storage map[string]bool `json:"storage,omitempty"`
+}
+
+// DefaultConfigPath returns the default config file path inside DataDir.
+func DefaultConfigPath() (string, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, configFile), nil
+}
+
+// Load reads the config file at path. A missing file is not an error and
+// yields the defaults.
+func Load(path string) (Config, error) {
+	var c Config
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return c, fmt.Errorf("read config: %w", err)
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return c, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	for name := range c.Storage {
+		if _, ok := LookupFeature(name); !ok {
+			return c, fmt.Errorf("config %s: unknown storage feature %q", path, name)
+		}
+	}
+	return c, nil
+}
+
+// Save writes c to path as indented JSON, creating the directory if needed.
+func (c Config) Save(path string) error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create config dir: %w", err)
+	}
+	if err := os.WriteFile(path, append(b, '\n'), 0644); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	return nil
+}
+
+// Stores reports whether the named dataset is stored: the config file's
+// setting when present, otherwise the feature's default. Unknown names are
+// never stored.
+func (c Config) Stores(name string) bool {
+	if on, ok := c.Storage[name]; ok {
+		return on
+	}
+	f, ok := LookupFeature(name)
+	return ok && f.Default
+}
+
+// SetStore switches the named dataset on or off.
+func (c *Config) SetStore(name string, on bool) error {
+	if _, ok := LookupFeature(name); !ok {
+		return fmt.Errorf("unknown storage feature %q", name)
+	}
+	if c.Storage == nil {
+		c.Storage = make(map[string]bool)
+	}
+	c.Storage[name] = on
+	return nil
+}
@@ -27,17 +27,28 @@ func NewClient(apiKey string) *Client {
 
 // Player holds the fields we need from the /players endpoint.
 type Player struct {
-	PlayerID string `json:"player_id"`
-	Nickname string `json:"nickname"`
-	Games    struct {
+	PlayerID  string `json:"player_id"`
+	Nickname  string `json:"nickname"`
+	SteamID64 string `json:"steam_id_64"`
+	Games     struct {
 		CS2 struct {
-			SkillLevel int    `json:"skill_level"`
-			FaceitELO  int    `json:"faceit_elo"`
-			Region     string `json:"region"`
+			SkillLevel   int    `json:"skill_level"`
+			FaceitELO    int    `json:"faceit_elo"`
+			Region       string `json:"region"`
+			GamePlayerID string `json:"game_player_id"`
 		} `json:"cs2"`
 	} `json:"games"`
 }
 
+// CS2SteamID returns the Steam ID64 of the player's CS2 account, falling back
+// to the Steam account linked to the FACEIT profile. Empty when neither is set.
+func (p *Player) CS2SteamID() string {
+	if p.Games.CS2.GamePlayerID != "" {
+		return p.Games.CS2.GamePlayerID
+	}
+	return p.SteamID64
+}
+
 // MatchHistoryItem is one entry from /players/{id}/history.
 type MatchHistoryItem struct {
 	MatchID    string `json:"match_id"`
@@ -3,7 +3,12 @@
 // statistics, and summary records used for storage and display.
 package model
 
-import "time"
+import (
+	"math"
+	"path/filepath"
+	"strings"
+	"time"
+)
 
 // Team represents which side a player is on.
 type Team int
@@ -29,27 +34,57 @@ func (t Team) String() string {
 
 // ---- Raw events emitted by the parser ----
 
+// NoPlayer is the SteamID64 of every actor that is not a real player: the
+// world (fall damage, the bomb) and bots, which all share it. After parsing
+// it never appears in the roster (PlayerNames, PlayerTeams, round end states
+// and equipment values) nor on per-player events (shots, first sights,
+// position samples); on kills, damage and flashes it marks the side that was
+// not a player, and events with no player on either side are dropped.
+const NoPlayer uint64 = 0
+
 // RawKill represents a single kill event extracted from a demo tick stream.
 type RawKill struct {
-	Tick, RoundNumber               int
-	KillerSteamID, VictimSteamID   uint64
-	AssisterSteamID                 uint64 // 0 if none
-	KillerTeam, VictimTeam          Team
-	Weapon                          string
-	IsHeadshot, AssistedFlash       bool
-	NearbyVictimTeammates           int // alive teammates of victim within 512 units at kill tick (0 = isolated)
+	Tick, RoundNumber            int
+	KillerSteamID, VictimSteamID uint64
+	AssisterSteamID              uint64 // 0 if none
+	KillerTeam, VictimTeam       Team
+	Weapon                       string
+	IsHeadshot, AssistedFlash    bool
+	NearbyVictimTeammates        int    // alive teammates of victim within 512 units at kill tick (0 = isolated)
+	VictimPlanting               bool   // victim was mid-plant when killed (plant denial)
+	ThroughSmoke                 bool   // the kill shot went through a smoke
+	SmokeThrowerID               uint64 // thrower of the active smoke nearest the shot line; 0 if unknown
+	KillerPos, VictimPos         Vec3   // world positions at the kill tick
+
+	// Victim's loadout at the kill tick: the weapon in hand (name and
+	// WeaponClass*; empty if none) and whether a primary was in the inventory.
+	VictimWeapon      string
+	VictimWeaponClass string
+	VictimHasPrimary  bool
 }
 
+// Weapon classes of RawKill.VictimWeaponClass.
+const (
+	WeaponClassPrimary = "primary" // rifles, SMGs, heavy weapons and snipers
+	WeaponClassPistol  = "pistol"
+	WeaponClassKnife   = "knife"
+	WeaponClassGrenade = "grenade"
+	WeaponClassOther   = "other" // C4, Zeus and other equipment
+)
+
 // RawDamage represents a single damage event (PlayerHurt) from the demo.
 type RawDamage struct {
-	Tick, RoundNumber                   int
-	AttackerSteamID, VictimSteamID     uint64
-	AttackerTeam                        Team
-	HealthDamage                        int
-	Weapon                              string
-	IsUtility                           bool   // HE/molotov/incendiary
-	HitGroup                            string // "head", "chest", "stomach", "left_arm", "right_arm", "left_leg", "right_leg", "other"
-	VictimPos                           Vec3   // victim world position at hurt tick
+	Tick, RoundNumber              int
+	AttackerSteamID, VictimSteamID uint64
+	AttackerTeam                   Team
+	HealthDamage                   int
+	VictimHealth                   int // victim health remaining after this hit
+	VictimTeam                     Team
+	Weapon                         string
+	IsUtility                      bool    // HE/molotov/incendiary
+	HitGroup                       string  // "head", "chest", "stomach", "left_arm", "right_arm", "left_leg", "right_leg", "other"
+	VictimPos                      Vec3    // victim world position at hurt tick
+	VictimSpeed                    float64 // victim horizontal speed (Hammer units/s) at hurt tick
 }
 
 // RawFlash represents a flashbang blind event from the demo.
@@ -69,11 +104,30 @@ type PlayerRoundEndState struct {
 	GrenadeCount int
 }
 
+// Round end reasons recorded on RawRound.EndReason and PlayerRoundStats.EndReason.
+const (
+	EndReasonElimination = "elimination" // one side eliminated
+	EndReasonBomb        = "bomb"        // bomb exploded
+	EndReasonDefuse      = "defuse"      // bomb defused
+	EndReasonTime        = "time"        // round timer ran out with no plant
+	EndReasonSurrender   = "surrender"   // a team surrendered
+	EndReasonOther       = "other"       // anything else (draws, hostage modes)
+)
+
+// Round contexts a won duel is segmented by (PlayerDuelSegment.RoundContext),
+// so pistol-round and anti-eco duels don't dilute gun-round FHHS.
+const (
+	RoundContextPistol  = "pistol"   // first round of a regulation half
+	RoundContextAntiEco = "anti-eco" // killer on a force/full buy, victim on an eco
+	RoundContextGun     = "gun"      // every other duel
+)
+
 // RawRound holds metadata for a single round, including tick boundaries,
 // the winning team, and the end-of-round state for every participant.
 type RawRound struct {
 	Number, StartTick, FreezeEndTick, EndTick int
 	WinnerTeam                                Team
+	EndReason                                 string // one of the EndReason* constants
 	PlayerEndState                            map[uint64]PlayerRoundEndState
 	PlayerEquipValues                         map[uint64]int // USD equipment value per player at freeze-end
 	BombPlantTick                             int            // tick when bomb was planted; 0 if not planted this round
@@ -92,6 +146,27 @@ type RawFirstSight struct {
 	// Absolute observer view angles at first-sight tick (used for pre-shot correction).
 	ObserverPitchDeg float64
 	ObserverYawDeg   float64
+	ObserverSpeed    float64 // observer horizontal speed (Hammer units/s) at first-sight tick
+}
+
+// RawDefuse is emitted by the parser for each completed bomb defuse, with a
+// snapshot of the defending (T) side's awareness during the defuse.
+type RawDefuse struct {
+	Tick, RoundNumber int
+	DefuserID         uint64
+	EnemiesAlive      int    // enemies alive at defuse completion
+	NearbyEnemies     int    // alive enemies within 1000 units of the defuser at completion
+	Spotted           bool   // any alive enemy had the defuser spotted at some tick of the defuse
+	Site              string // "A", "B", or "" when unknown
+	Pos               Vec3   // defuser world position at completion
+}
+
+// RawPlant is emitted by the parser for each completed bomb plant.
+type RawPlant struct {
+	Tick, RoundNumber int
+	PlanterID         uint64
+	Site              string // "A", "B", or "" when unknown
+	Pos               Vec3   // planter world position at the plant
 }
 
 // Vec3 is a 3D world-space position in Hammer units.
@@ -109,23 +184,74 @@ type RawWeaponFire struct {
 	HorizontalSpeed float64 // shooter horizontal speed (Hammer units/s) at fire tick
 }
 
+// RawGrenadeThrow is emitted by the parser each time a player throws a
+// grenade (the projectile leaving the hand).
+type RawGrenadeThrow struct {
+	Tick, RoundNumber int
+	ThrowerID         uint64
+	Team              Team
+	Grenade           string // weapon name: "Smoke Grenade", "Flashbang", "HE Grenade", "Molotov", ...
+}
+
+// RawPingSample is a player's scoreboard ping (m_iPing, in ms) read by the
+// parser at a fixed interval during each round, dead players included.
+type RawPingSample struct {
+	Tick, RoundNumber int
+	PlayerID          uint64
+	PingMs            int
+}
+
+// RawPositionSample is emitted by the parser for each alive player at fixed
+// times early in a round (after freeze end), for position inference.
+type RawPositionSample struct {
+	Tick, RoundNumber int
+	PlayerID          uint64
+	Team              Team
+	Pos               Vec3
+	Place             string // map callout from the nav mesh (m_szLastPlaceName); "" when unknown
+}
+
 // RawMatch is the fully parsed representation of a single demo file.
 // It contains all tick-level events and metadata needed by the aggregator.
 type RawMatch struct {
-	DemoHash    string
-	MapName     string
-	MatchDate   string
-	MatchType   string
-	Tickrate    float64
-	TicksPerSecond float64
-	Rounds      []RawRound
-	Kills       []RawKill
-	Damages     []RawDamage
-	Flashes     []RawFlash
-	FirstSights []RawFirstSight
-	WeaponFires []RawWeaponFire
-	PlayerNames map[uint64]string
-	PlayerTeams map[uint64]Team
+	DemoHash        string
+	MapName         string
+	MatchDate       string
+	MatchType       string
+	Tickrate        float64
+	TicksPerSecond  float64
+	Rounds          []RawRound
+	Kills           []RawKill
+	Damages         []RawDamage
+	Flashes         []RawFlash
+	FirstSights     []RawFirstSight
+	WeaponFires     []RawWeaponFire
+	Defuses         []RawDefuse
+	Plants          []RawPlant
+	PositionSamples []RawPositionSample
+	GrenadeThrows   []RawGrenadeThrow
+	PingSamples     []RawPingSample
+	PlayerNames     map[uint64]string
+	PlayerTeams     map[uint64]Team
+	Suppressed      []SuppressedAccount // accounts removed by aggregator.SuppressSpectators
+	// Rounds discarded before the match went live (knife rounds, restarts);
+	// round numbers start at 1 from the first live round.
+	PreLiveRounds int
+	// Events dropped because no real player took part: bots and the world
+	// share SteamID 0 (see NoPlayer). Roster entries for SteamID 0 are not
+	// counted.
+	EntityEventsDropped int
+	// Mean share of server frames dropped (host_frame_dropped_pct_x10 / 10 of
+	// the demo's net_Tick messages), 0-100. Demos carry no per-player packet
+	// loss; this is the server side of a connection problem.
+	ServerFrameDropPct float64
+	// Wall time of the parse and of each aggregation pass, in run order.
+	// Not part of the parser contract (varies run to run).
+	PassTimings []PassTiming `json:"-"`
+	// Every round of the match when Rounds and the events hold only a subset
+	// of them (see aggregator.AggregateRounds); nil for a whole match. Half
+	// starts and the running score are read from it.
+	MatchRounds []RawRound `json:"-"`
 }
 
 // ---- Aggregated metrics ----
@@ -161,6 +287,9 @@ type PlayerMatchStats struct {
 
 	// KAST
 	KASTRounds int // rounds where K or A or S or T
+	// KASTStrictRounds is KASTRounds without survived lost rounds in which
+	// the player dealt no damage (saves). 0 before pipeline v46.
+	KASTStrictRounds int
 
 	// Unused utility at round end
 	UnusedUtility int
@@ -175,11 +304,22 @@ type PlayerMatchStats struct {
 	// Duel engine (Module 1)
 	DuelWins             int
 	DuelLosses           int
+	AssistedDuelWins     int // duel wins where a teammate dealt ≥ 41 damage to the victim in the 5 s before the kill
+	AssistedDuelLosses   int // duel losses where a teammate of the killer did the same
 	MedianExposureWinMs  float64
 	MedianExposureLossMs float64
 	MedianHitsToKill     float64
 	FirstHitHSRate       float64 // % of kill-duels where first bullet hit was to head
 
+	// Overexposure: median ms from the first enemy sighting of this player to
+	// their death, over deaths where an enemy had spotted them that round.
+	MedianSpottedBeforeDeathMs float64
+
+	// Hesitation: median ms from first sighting an enemy to first bullet damage
+	// on them (within 5s), over all engagements including those without a kill.
+	TimeToDamageSamples  int
+	MedianTimeToDamageMs float64
+
 	// Pre-shot correction (Module 1 completion)
 	MedianCorrectionDeg    float64
 	PctCorrectionUnder2Deg float64
@@ -189,10 +329,27 @@ type PlayerMatchStats struct {
 	AWPDeathsDry      int // no flash on victim in last 3s
 	AWPDeathsRePeek   int // victim had a kill earlier same round
 	AWPDeathsIsolated int // NearbyVictimTeammates == 0
+	// AWPRoundsFaced counts rounds in which at least one enemy used an AWP
+	// (fired it, damaged or killed with it): the exposure AWPDeaths is
+	// normalized by.
+	AWPRoundsFaced int
+
+	// AWP shot ledger: each AWP shot is paired with the shooter's AWP damage to
+	// enemies within 100ms and classed as a kill, a non-lethal (body) hit, or a
+	// miss (AWPShots − AWPShotKills − AWPShotBodyHits).
+	AWPShots        int
+	AWPShotKills    int
+	AWPShotBodyHits int
 
 	// Flash quality (Module 5)
 	EffectiveFlashes int // your flashes where blinded enemy died to your team within 1.5s
 
+	// Utility synergy: kills played off a teammate's utility (the killer's own
+	// utility is not counted — see FlashAssists/EffectiveFlashes for that).
+	PlayedOffFlashKills   int // kills within 2s of a teammate's flash blinding the victim
+	PlayedOffSmokeKills   int // kills through a smoke a teammate threw
+	PlayedOffUtilityKills int // kills that were either (counted once)
+
 	// Role and aim timing metrics
 	Role                  string  // "AWPer" | "Entry" | "Support" | "Rifler"
 	MedianTTKMs           float64 // median ms first shot fired → kill, multi-hit kills only (attacker POV)
@@ -200,10 +357,135 @@ type PlayerMatchStats struct {
 	OneTapKills           int     // kills where the first shot in the 3s window was the kill shot
 	CounterStrafePercent  float64 // % of shots fired while horizontal speed ≤ 34 u/s
 
+	// Movement on being shot (defensive counterpart to counter-strafe %)
+	DeathSpeedSamples int // deaths with a killer hit in the 3s before death (victim speed known)
+	MovingDeaths      int // of those, deaths where victim speed at the first hit was > 34 u/s
+
+	// Multi-kills within one engagement
+	CollateralKills    int // kills by the same bullet as the previous kill (collats, wallbang collats)
+	SprayTransferKills int // kills ≤1.5s after the previous one, same weapon, firing throughout, victim already spotted
+
+	// Burst length: runs of shots ≤ 200ms apart with one weapon (AWP/Scout excluded)
+	BurstTaps  int // single shots
+	BurstShort int // 2–3 shot bursts
+	BurstSpray int // 4–9 shot sprays
+	BurstPanic int // 10+ shot sprays
+
 	// Round outcome and trade timing
 	RoundsWon               int     // rounds where player's team won
 	MedianTradeKillDelayMs  float64 // median ms from teammate's death to player's trade kill
 	MedianTradeDeathDelayMs float64 // median ms from player's death to teammate's trade kill
+
+	// Objective play
+	Defuses      int // bombs defused
+	NinjaDefuses int // defuses completed with enemies nearby that never spotted the defuser
+	PlantDenials int // enemies killed while mid-plant
+
+	// Low-HP enemies not finished (left at 1–19 HP by this player's hit, not killed by this player)
+	LowHPHanded int // finished by a teammate in the same round
+	LowHPWasted int // survived the round or died to a non-teammate cause
+
+	// Defensive utility: enemy grenades absorbed (self and team utility excluded)
+	UtilityDamageTaken   int     // HE/molotov/incendiary health damage received from enemies
+	FlashesReceived      int     // enemy flashes that blinded this player
+	BlindTimeReceivedSec float64 // total blind duration from enemy flashes, seconds
+
+	// Late-round discipline, against the modeled round/bomb clock. A
+	// play-for-time spot is ≤ 20s left, the clock favoring the player's side
+	// (CT before a plant, T after) and the side up in players alive.
+	LateRoundDeaths   int // deaths with ≤ 20s left on the active clock
+	PlayForTimeRounds int // rounds alive in a play-for-time spot
+	PlayForTimeDeaths int // deaths in a play-for-time spot
+
+	// Momentum: the player's team's run of round results within a half. A
+	// streak round follows ≥ 3 straight team wins, a bounce-back round ≥ 3
+	// straight losses; runs reset at every half.
+	LongestWinStreak int // longest run of team round wins
+	StreakRounds     int // rounds played on a win streak
+	StreakKills      int
+	StreakDamage     int
+	BounceRounds     int // bounce-back rounds played
+	BounceKills      int
+	BounceDamage     int
+	BounceWins       int // bounce-back rounds the team won
+	HalfFirstKills   int // halves (overtime included) opened by this player's kill
+
+	// Scoreline splits: the player's decided rounds by their team's match
+	// score when the round started (ahead, level, behind).
+	Leading, Tied, Trailing ScorelineSplit
+
+	// Loadout efficiency: freeze-end equipment value (USD) summed over the
+	// player's rounds, the denominator of kills and damage per $1000.
+	EquipmentValue int
+
+	// Peeker's advantage: kills between two players who had both spotted
+	// each other, where exactly one was moving at their first sight — the
+	// peeker; the other held. Wins are the duels this player got the kill in.
+	PeekDuels, PeekWins int
+	HoldDuels, HoldWins int
+
+	// Weapon discipline: deaths to enemies while carrying a primary, and of
+	// those the deaths caught holding a pistol, knife or grenade instead.
+	PrimaryDeaths     int
+	WrongWeaponDeaths int
+
+	// Duel loss reasons: deaths to enemies by contributing factor (a loss can
+	// have several). LossExplained counts losses with at least one factor.
+	LossFlashed     int // blinded by a flash in the 1.5 s before the death
+	LossIsolated    int // no alive teammate within 512 units at the kill
+	LossUtilSupport int // the killer played off a teammate's flash or smoke
+	LossRePeek      int // had already killed an enemy earlier in the round
+	LossExplained   int
+
+	// Head-hit share: bullet hits on enemies (knife, Zeus and utility
+	// excluded) and those that landed on the head. Unlike HS%, every hit
+	// counts, not just the killing one.
+	BulletHits int
+	HeadHits   int
+
+	// Utility timing: grenades thrown by phase of the round clock — early
+	// (setup, the first 25 s after freeze end), late (post-plant or 20 s or
+	// less on the round timer) and mid (everything between) — and the median
+	// seconds after freeze end of all throws.
+	UtilThrowsEarly    int
+	UtilThrowsMid      int
+	UtilThrowsLate     int
+	MedianUtilThrowSec float64
+
+	// Eco damage split: rounds and damage by the enemy side's economy class —
+	// eco (full or semi eco) or buy (force or full buy). Pistol rounds and
+	// rounds without an enemy economy count in neither, so ADR over all rounds
+	// can sit outside both.
+	EcoRounds, EcoDamage int
+	BuyRounds, BuyDamage int
+
+	// Info deaths: deaths to an enemy split by whether anyone on the player's
+	// team had spotted the killer that round before the kill. Unspotted
+	// deaths are information failures (the killer was never on the radar).
+	UnspottedDeaths, SpottedDeaths int
+
+	// Connection: mean and peak scoreboard ping over PingSamples readings
+	// (see RawPingSample); 0 samples for demos parsed before ping was read.
+	PingSamples int
+	AvgPingMs   float64
+	MaxPingMs   int
+
+	// Data quality: rounds in which this SteamID was seen on both teams (coach
+	// slot or shared account in a broken demo). Those rounds are attributed by
+	// the per-round team; any non-zero value makes the row suspect.
+	TeamConflictRounds int
+
+	// Provenance
+	PipelineVersion int // aggregator.PipelineVersion that computed this row; 0 if stored before versioning
+}
+
+// MovingDeathPct returns the percentage (0-100) of speed-sampled deaths where
+// the player was moving above counter-strafe speed when first hit.
+func (s *PlayerMatchStats) MovingDeathPct() float64 {
+	if s.DeathSpeedSamples == 0 {
+		return 0
+	}
+	return float64(s.MovingDeaths) / float64(s.DeathSpeedSamples) * 100
 }
 
 // KDRatio returns the kill-to-death ratio. If deaths is 0, kills is returned.
@@ -222,6 +504,57 @@ func (s *PlayerMatchStats) HSPercent() float64 {
 	return float64(s.HeadshotKills) / float64(s.Kills) * 100
 }
 
+// UtilThrows returns the grenades thrown in the match across all phases.
+func (s *PlayerMatchStats) UtilThrows() int {
+	return s.UtilThrowsEarly + s.UtilThrowsMid + s.UtilThrowsLate
+}
+
+// EcoADR returns the average damage per round against an eco or semi-eco
+// side, or 0 without such rounds.
+func (s *PlayerMatchStats) EcoADR() float64 {
+	if s.EcoRounds == 0 {
+		return 0
+	}
+	return float64(s.EcoDamage) / float64(s.EcoRounds)
+}
+
+// BuyADR returns the average damage per round against a forcing or fully
+// bought side, or 0 without such rounds.
+func (s *PlayerMatchStats) BuyADR() float64 {
+	if s.BuyRounds == 0 {
+		return 0
+	}
+	return float64(s.BuyDamage) / float64(s.BuyRounds)
+}
+
+// HighPingMs is the mean ping from which a match counts as played on a poor
+// connection, so its stats may reflect lag rather than form.
+const HighPingMs = 100
+
+// HighPing reports whether the player's mean ping in the match reached
+// HighPingMs.
+func (s *PlayerMatchStats) HighPing() bool {
+	return s.PingSamples > 0 && s.AvgPingMs >= HighPingMs
+}
+
+// InfoDeathPct returns deaths to unspotted enemies as a percentage (0-100)
+// of deaths to enemies, or 0 without any.
+func (s *PlayerMatchStats) InfoDeathPct() float64 {
+	if n := s.UnspottedDeaths + s.SpottedDeaths; n > 0 {
+		return float64(s.UnspottedDeaths) / float64(n) * 100
+	}
+	return 0
+}
+
+// HeadHitPct returns head hits as a percentage (0-100) of bullet hits on
+// enemies, or 0 when there are none.
+func (s *PlayerMatchStats) HeadHitPct() float64 {
+	if s.BulletHits == 0 {
+		return 0
+	}
+	return float64(s.HeadHits) / float64(s.BulletHits) * 100
+}
+
 // ADR returns the average damage per round.
 func (s *PlayerMatchStats) ADR() float64 {
 	if s.RoundsPlayed == 0 {
@@ -239,6 +572,20 @@ func (s *PlayerMatchStats) KASTPct() float64 {
 	return float64(s.KASTRounds) / float64(s.RoundsPlayed) * 100
 }
 
+// KASTStrictPct returns the strict KAST percentage (0-100), which does not
+// count survived lost rounds without damage.
+func (s *PlayerMatchStats) KASTStrictPct() float64 {
+	if s.RoundsPlayed == 0 {
+		return 0
+	}
+	return float64(s.KASTStrictRounds) / float64(s.RoundsPlayed) * 100
+}
+
+// Rating returns the Rating 2.0 proxy for this match.
+func (s *PlayerMatchStats) Rating() float64 {
+	return RatingProxy(s.Kills, s.Assists, s.Deaths, s.RoundsPlayed, s.KASTRounds, s.TotalDamage)
+}
+
 // PlayerRoundStats holds per-round breakdown stats for a single player,
 // tracking kills, assists, damage, and KAST-qualifying events within one round.
 type PlayerRoundStats struct {
@@ -262,13 +609,133 @@ type PlayerRoundStats struct {
 	Assists int
 	Damage  int
 
+	// Deaths counts the player's deaths in the round (0 or 1 barring respawns).
+	// DeathTick is the tick of the first one and DeathSec its time after
+	// freeze end; both are 0 when the player survived.
+	Deaths    int
+	DeathTick int
+	DeathSec  float64
+
 	UnusedUtility int
 	BuyType       string // "full" ≥$4500 | "force" ≥$2000 | "half" ≥$1000 | "eco" <$1000
 
 	IsPostPlant      bool // bomb was planted at some point this round
 	IsInClutch       bool // player was last alive on their team with ≥1 enemy alive
 	ClutchEnemyCount int  // max enemies alive when player entered clutch (0 if not clutch)
-	WonRound         bool // player's team won this round
+	// ClutchStartTick is the tick of the death that left the player last
+	// alive, ClutchStartSec its time after freeze end, and ClutchEnemies the
+	// enemies alive at that moment; all zero/empty outside a clutch.
+	ClutchStartTick int
+	ClutchStartSec  float64
+	ClutchEnemies   []uint64
+	WonRound        bool   // player's team won this round
+	EndReason       string // how the round ended (EndReason* constants; "" for older demos)
+}
+
+// RoundOutcome is the winner and end reason of one round of a match.
+// StartedCTSide is the side the team that started the match on CT played that
+// round, so halves can be told apart by side switches.
+type RoundOutcome struct {
+	RoundNumber   int
+	WinnerTeam    Team
+	EndReason     string
+	StartedCTSide Team
+}
+
+// Team economy classes of one side's round (TeamRoundEconomy.RoundType), from
+// poorest to richest.
+const (
+	TeamRoundPistol  = "pistol"   // first round of a half, nobody on a rifle budget
+	TeamRoundFullEco = "full-eco" // under $1,000 per player
+	TeamRoundSemiEco = "semi-eco" // $1,000–$1,999 per player
+	TeamRoundForce   = "force"    // $2,000–$3,999 per player
+	TeamRoundFullBuy = "full-buy" // $4,000+ per player
+)
+
+// TeamRoundTypes lists the team economy classes in display order.
+var TeamRoundTypes = []string{TeamRoundPistol, TeamRoundFullEco, TeamRoundSemiEco, TeamRoundForce, TeamRoundFullBuy}
+
+// UnmappedWeapon is a weapon name seen in a demo that the weapon bucket table
+// doesn't know, so its events were grouped under "Other".
+type UnmappedWeapon struct {
+	DemoHash string
+	Weapon   string // demoinfocs name, or the entity class for unknown equipment
+	Events   int    // kills, damage events and shots fired with it
+}
+
+// SuppressedAccount is a SteamID dropped from a demo's stats because it never
+// took part in the match: a coach or spectator slot listed among the playing
+// participants, with no kill, damage, shot or flash of its own.
+type SuppressedAccount struct {
+	SteamID uint64
+	Name    string
+	Reason  string // one of the Suppressed* constants
+	Rounds  int    // rounds with a round-end state for the account
+}
+
+// Reasons recorded on SuppressedAccount.Reason.
+const (
+	SuppressedNoRounds   = "no rounds"      // listed as playing but in no round-end state
+	SuppressedSpectator  = "spectator team" // on the spectator (or no) team in most of its rounds
+	SuppressedNeverAlive = "never alive"    // never alive at a round end
+)
+
+// TeamRoundEconomy is one side's summed freeze-end equipment value in one
+// decided round of a match, its economy class and whether it won the round.
+type TeamRoundEconomy struct {
+	DemoHash    string
+	RoundNumber int
+	Team        Team
+	Players     int // players with a known equipment value
+	EquipValue  int // summed USD equipment value at freeze-end
+	RoundType   string
+	Won         bool
+}
+
+// TradeChain is one exchange of kills in a round: the first kill and every
+// later enemy kill linked to the chain within the trade window, either by
+// killing a player who already killed in the chain (a trade or re-trade) or
+// by a chain killer killing again. Only chains with at least one trade are
+// kept. Index numbers a round's chains from 0 in tick order.
+type TradeChain struct {
+	DemoHash    string
+	RoundNumber int
+	Index       int
+	StartTick   int
+	EndTick     int
+	Kills       int
+	Trades      int  // kills that trade an earlier chain kill
+	FirstTeam   Team // side that got the chain's first kill
+	CTKills     int
+	TKills      int
+}
+
+// Exchange returns the kills side got and gave up in the chain.
+func (c TradeChain) Exchange(side Team) (won, lost int) {
+	if side == TeamCT {
+		return c.CTKills, c.TKills
+	}
+	return c.TKills, c.CTKills
+}
+
+// TopHeavyShare is the top player's share of a team's damage at or above
+// which the team is flagged as leaning on one star (an even five-player split
+// is 0.20).
+const TopHeavyShare = 0.35
+
+// TeamConcentration measures how evenly a team's kills and damage are spread
+// over its players in one match, or decay-weighted across matches. A Gini of
+// 0 means every player contributed equally; the maximum for n players is
+// (n−1)/n, reached when one player did everything.
+type TeamConcentration struct {
+	Team           Team
+	Players        int
+	KillGini       float64
+	DamageGini     float64
+	TopKillShare   float64 // top fragger's share of the team's kills, 0–1
+	TopDamageShare float64 // top damage dealer's share of the team's damage, 0–1
+	TopKiller      string  // name of the top fragger (single match only)
+	TopDamager     string  // name of the top damage dealer (single match only)
 }
 
 // PlayerClutchMatchStats holds per-match clutch attempt/win counts broken down
@@ -311,6 +778,12 @@ type PlayerWeaponStats struct {
 	Deaths        int
 	Damage        int
 	Hits          int
+	HeadHits      int // hits (of Hits) that landed on the head
+
+	// Kill distance: kills with both positions known (pipeline v33+) and the
+	// sum of their killer–victim distances in meters.
+	DistanceKills    int
+	KillDistanceSumM float64
 }
 
 // HSPercent returns the headshot kill percentage (0-100) for this weapon.
@@ -321,6 +794,15 @@ func (s *PlayerWeaponStats) HSPercent() float64 {
 	return float64(s.HeadshotKills) / float64(s.Kills) * 100
 }
 
+// HeadHitPct returns the share of hits (0-100) that landed on the head for
+// this weapon.
+func (s *PlayerWeaponStats) HeadHitPct() float64 {
+	if s.Hits == 0 {
+		return 0
+	}
+	return float64(s.HeadHits) / float64(s.Hits) * 100
+}
+
 // AvgDamagePerHit returns the average health damage dealt per hit for this weapon.
 func (s *PlayerWeaponStats) AvgDamagePerHit() float64 {
 	if s.Hits == 0 {
@@ -329,6 +811,88 @@ func (s *PlayerWeaponStats) AvgDamagePerHit() float64 {
 	return float64(s.Damage) / float64(s.Hits)
 }
 
+// AvgKillDistanceM returns the mean killer–victim distance in meters over
+// kills with a known distance, or 0 when there are none.
+func (s *PlayerWeaponStats) AvgKillDistanceM() float64 {
+	if s.DistanceKills == 0 {
+		return 0
+	}
+	return s.KillDistanceSumM / float64(s.DistanceKills)
+}
+
+// DatasetFootprint is the storage used by one optional dataset (a config
+// storage feature) for the `db` overview. Bytes counts the table's pages and
+// those of its indexes; Demos is the number of demos with at least one row.
+type DatasetFootprint struct {
+	Feature string
+	Table   string
+	Summary string
+	Stored  bool // switched on for newly parsed demos
+	Rows    int
+	Bytes   int64
+	Demos   int
+}
+
+// BytesPerDemo returns the mean storage per demo that has rows, or 0 when
+// none do.
+func (f DatasetFootprint) BytesPerDemo() int64 {
+	if f.Demos == 0 {
+		return 0
+	}
+	return f.Bytes / int64(f.Demos)
+}
+
+// WeaponMetaRow is player_weapon_stats summed over every player for one
+// weapon: per match date from storage, or folded over a period for the
+// `db weapons` meta report.
+type WeaponMetaRow struct {
+	Weapon           string
+	MatchDate        string // empty once folded over several dates
+	Demos            int
+	Kills            int
+	HeadshotKills    int
+	Damage           int
+	Hits             int
+	DistanceKills    int
+	KillDistanceSumM float64
+}
+
+// Add folds o's counts into r.
+func (r *WeaponMetaRow) Add(o WeaponMetaRow) {
+	r.Demos += o.Demos
+	r.Kills += o.Kills
+	r.HeadshotKills += o.HeadshotKills
+	r.Damage += o.Damage
+	r.Hits += o.Hits
+	r.DistanceKills += o.DistanceKills
+	r.KillDistanceSumM += o.KillDistanceSumM
+}
+
+// HSPercent returns the headshot kill percentage (0-100).
+func (r WeaponMetaRow) HSPercent() float64 {
+	if r.Kills == 0 {
+		return 0
+	}
+	return float64(r.HeadshotKills) / float64(r.Kills) * 100
+}
+
+// AvgKillDistanceM returns the mean kill distance in meters over kills with a
+// known distance, or 0 when there are none.
+func (r WeaponMetaRow) AvgKillDistanceM() float64 {
+	if r.DistanceKills == 0 {
+		return 0
+	}
+	return r.KillDistanceSumM / float64(r.DistanceKills)
+}
+
+// WeaponMetaWindow is one period of the `db weapons` trend: per-weapon totals
+// of the demos dated From through To (inclusive) and the period's kills.
+type WeaponMetaWindow struct {
+	From, To string
+	Kills    int
+	Weapons  map[string]WeaponMetaRow
+}
+
 // PlayerAggregate holds stats for a single player aggregated across all stored demos.
 type PlayerAggregate struct {
 	SteamID uint64
@@ -340,30 +904,338 @@ type PlayerAggregate struct {
 	HeadshotKills                      int
 	TotalDamage, RoundsPlayed          int
 	KASTRounds                         int
+	KASTStrictRounds                   int
 	FlashAssists, EffectiveFlashes     int
+	PlayedOffFlashKills                int
+	PlayedOffSmokeKills                int
+	PlayedOffUtilityKills              int
 	OpeningKills, OpeningDeaths        int
 	TradeKills, TradeDeaths            int
 	DuelWins, DuelLosses               int
+	AssistedDuelWins                   int
+	AssistedDuelLosses                 int
 	AWPDeaths, AWPDeathsDry            int
 	AWPDeathsRePeek, AWPDeathsIsolated int
+	AWPRoundsFaced                     int
+	AWPShots, AWPShotKills             int
+	AWPShotBodyHits                    int
 
 	// Float stats — average of per-match medians (approximate).
 	AvgExpoWinMs     float64
 	AvgExpoLossMs    float64
 	AvgCorrectionDeg float64
 	AvgHitsToKill    float64
+	AvgSpottedBeforeDeathMs float64 // avg of per-match MedianSpottedBeforeDeathMs
+	AvgTimeToDamageMs       float64 // avg of per-match MedianTimeToDamageMs (matches with samples)
 
 	// Role and aim timing
-	Role                   string
-	AvgTTKMs               float64
-	AvgTTDMs               float64
-	OneTapKills            int
-	AvgCounterStrafePct    float64
+	Role                string
+	AvgTTKMs            float64
+	AvgTTDMs            float64
+	OneTapKills         int
+	AvgCounterStrafePct float64
+	DeathSpeedSamples   int
+	MovingDeaths        int
+	CollateralKills     int
+	SprayTransferKills  int
+
+	// Burst length histogram — summed.
+	BurstTaps, BurstShort, BurstSpray, BurstPanic int
 
 	// Round outcome and trade timing
 	RoundsWon                  int
 	AvgTradeKillDelayMs        float64
 	AvgTradeDeathDelayMs       float64
+
+	// Low-HP enemies not finished — summed.
+	LowHPHanded, LowHPWasted int
+
+	// Defensive utility — summed.
+	UtilityDamageTaken, FlashesReceived int
+	BlindTimeReceivedSec                float64
+
+	// Late-round discipline — summed.
+	LateRoundDeaths, PlayForTimeRounds, PlayForTimeDeaths int
+
+	// Momentum — summed; LongestWinStreak is the maximum.
+	LongestWinStreak                        int
+	StreakRounds, StreakKills, StreakDamage int
+	BounceRounds, BounceKills, BounceDamage int
+	BounceWins, HalfFirstKills              int
+
+	// Scoreline splits — summed.
+	Leading, Tied, Trailing ScorelineSplit
+
+	// Loadout efficiency — freeze-end equipment value summed.
+	EquipmentValue int
+
+	// Peeker's advantage — summed.
+	PeekDuels, PeekWins int
+	HoldDuels, HoldWins int
+
+	// Weapon discipline — summed.
+	PrimaryDeaths, WrongWeaponDeaths int
+
+	// Duel loss reasons — summed.
+	LossFlashed, LossIsolated, LossUtilSupport, LossRePeek, LossExplained int
+
+	// Head-hit share — summed.
+	BulletHits, HeadHits int
+
+	// Utility timing — throws summed; AvgUtilThrowSec averages the per-match
+	// medians over matches with a throw.
+	UtilThrowsEarly, UtilThrowsMid, UtilThrowsLate int
+	AvgUtilThrowSec                                float64
+
+	// Eco damage split — summed.
+	EcoRounds, EcoDamage int
+	BuyRounds, BuyDamage int
+
+	// Info deaths — summed.
+	UnspottedDeaths, SpottedDeaths int
+
+	// Connection: samples summed, mean ping weighted by samples, peak ping
+	// the highest of any match.
+	PingSamples int
+	AvgPingMs   float64
+	MaxPingMs   int
+
+	// Match-to-match spread of rating, ADR and KAST%.
+	Consistency PlayerConsistency
+}
+
+// ScorelineSplit is a player's output over the rounds played in one
+// scoreboard state (their team leading, tied or trailing).
+type ScorelineSplit struct {
+	Rounds, Kills, Deaths, Damage int
+}
+
+// Add returns the sum of s and o.
+func (s ScorelineSplit) Add(o ScorelineSplit) ScorelineSplit {
+	return ScorelineSplit{s.Rounds + o.Rounds, s.Kills + o.Kills, s.Deaths + o.Deaths, s.Damage + o.Damage}
+}
+
+// PeekerBaseline is the corpus-wide peeker's advantage for one demo tier:
+// classified duels (one moving peeker, one holder) and how many the peeker won.
+type PeekerBaseline struct {
+	Tier              string // demos.tier; "" for untiered demos
+	Duels, PeekerWins int
+}
+
+// PeekerWinPct returns the peeker's share of duels won, or 0 with no duels.
+func (b PeekerBaseline) PeekerWinPct() float64 {
+	if b.Duels == 0 {
+		return 0
+	}
+	return float64(b.PeekerWins) / float64(b.Duels) * 100
+}
+
+// PlayerConsistency is the spread of a player's per-match rating, ADR and
+// KAST% across the matches of an aggregate. Spreads are 0 with fewer than two
+// matches.
+type PlayerConsistency struct {
+	Matches             int // matches with at least one round played
+	RatingSD, RatingIQR float64
+	ADRSD, ADRIQR       float64
+	KASTSD, KASTIQR     float64 // KAST% points
+	Booms, Busts        int     // matches rated ≥ BoomRating / ≤ BustRating
+}
+
+// BoomRating and BustRating are the per-match ratings at or beyond which a
+// match counts as a boom or a bust game.
+const (
+	BoomRating = 1.30
+	BustRating = 0.70
+)
+
+// BoomBustPct returns the boom-bust index: the percentage (0-100) of matches
+// that were boom or bust games. Low = steady, high = feast-or-famine.
+func (c PlayerConsistency) BoomBustPct() float64 {
+	if c.Matches == 0 {
+		return 0
+	}
+	return float64(c.Booms+c.Busts) / float64(c.Matches) * 100
+}
+
+// PlayerSession is one day's matches for a player: a session, since match
+// dates carry no time of day.
+type PlayerSession struct {
+	Date                  string
+	Matches, Wins, Losses int
+	LongestLossStreak     int     // most consecutive lost matches within the session
+	AvgRating             float64 // mean per-match rating
+}
+
+// PlayerTilt is how a player performs after losing a match within a session
+// compared with their overall rating: the tilt indicator.
+type PlayerTilt struct {
+	Sessions        []PlayerSession // sessions with at least two matches, chronological
+	LossStreaks     int             // runs of LossStreakMin+ lost matches within one session
+	AfterLoss       int             // matches played after a loss in the same session
+	AfterLossRating float64         // mean rating of those matches
+	AfterWin        int             // matches played after a win in the same session
+	AfterWinRating  float64         // mean rating of those matches
+	BaselineRating  float64         // mean rating over all matches
+}
+
+// Tilt thresholds: LossStreakMin lost matches in a row within a session form a
+// losing streak; a player is flagged as tilting when their rating after a
+// loss is at least TiltRatingDrop below their baseline over at least
+// TiltMinSamples post-loss matches.
+const (
+	LossStreakMin  = 2
+	TiltRatingDrop = 0.10
+	TiltMinSamples = 3
+)
+
+// PostLossDelta returns the mean rating after a loss minus the baseline
+// rating, or 0 with no post-loss matches.
+func (t PlayerTilt) PostLossDelta() float64 {
+	if t.AfterLoss == 0 {
+		return 0
+	}
+	return t.AfterLossRating - t.BaselineRating
+}
+
+// Tilted reports whether the post-loss rating drop reaches TiltRatingDrop
+// over at least TiltMinSamples post-loss matches.
+func (t PlayerTilt) Tilted() bool {
+	return t.AfterLoss >= TiltMinSamples && t.PostLossDelta() <= -TiltRatingDrop
+}
+
+// PlayerProgress is a player's report card diff: each major metric's mean
+// per-match value over their most recent matches against the window of
+// matches before them.
+type PlayerProgress struct {
+	Window                   int // matches per window asked for
+	Recent, Previous         int // matches in each window; Previous can be shorter
+	RecentFrom, RecentTo     string
+	PreviousFrom, PreviousTo string
+	Metrics                  []ProgressMetric
+}
+
+// ProgressMetric is one metric of a PlayerProgress. Matches where the metric
+// is undefined (no opening duels, no crosshair encounters, …) are left out of
+// its means.
+type ProgressMetric struct {
+	Name           string
+	Format         string // fmt verb for the values, e.g. "%.2f" or "%.1f%%"
+	HigherIsBetter bool
+	Previous       float64 // mean per-match value over the previous window
+	Recent         float64 // mean per-match value over the recent window
+	PreviousN      int     // matches with a value in the previous window
+	RecentN        int     // matches with a value in the recent window
+	T              float64 // Welch's t statistic of Recent − Previous; 0 without spread
+}
+
+// Progress significance: a change is flagged when |T| reaches
+// ProgressSignificantT (about p < 0.05 two-sided) with at least
+// ProgressMinMatches valued matches in each window.
+const (
+	ProgressSignificantT = 2.0
+	ProgressMinMatches   = 5
+)
+
+// Delta returns Recent − Previous.
+func (m ProgressMetric) Delta() float64 {
+	return m.Recent - m.Previous
+}
+
+// Improved reports whether the change goes in the metric's better direction.
+func (m ProgressMetric) Improved() bool {
+	if m.HigherIsBetter {
+		return m.Delta() > 0
+	}
+	return m.Delta() < 0
+}
+
+// Significant reports whether the change is unlikely to be noise.
+func (m ProgressMetric) Significant() bool {
+	return m.PreviousN >= ProgressMinMatches && m.RecentN >= ProgressMinMatches &&
+		math.Abs(m.T) >= ProgressSignificantT
+}
+
+// LobbyComparison sets one player's numbers in a match against the lobby:
+// everyone in that match with a round played.
+type LobbyComparison struct {
+	SteamID uint64
+	Name    string
+	Players int // players with a round played, the focus player included
+	Metrics []LobbyMetric
+}
+
+// LobbyMetric is one metric of a LobbyComparison. HasValue is false when the
+// metric is undefined for the focus player (no kills for HS%, no opening
+// duels, …); the other fields are then zero.
+type LobbyMetric struct {
+	Name           string
+	Format         string // fmt verb for the values, e.g. "%.2f" or "%.1f%%"
+	HigherIsBetter bool
+	HasValue       bool
+	Value          float64 // the focus player's value
+	LobbyAvg       float64 // mean over the LobbyN players with a value
+	LobbyN         int
+	Rank           int     // 1 = best in the lobby; ties share the better rank
+	Percentile     float64 // share of the other valued players beaten (ties count half), 0-100
+}
+
+// Diff returns Value − LobbyAvg.
+func (m LobbyMetric) Diff() float64 {
+	return m.Value - m.LobbyAvg
+}
+
+// PlayerFingerprint is a player's mechanical profile across all stored
+// matches, compared by the fingerprint command to flag probable alt
+// accounts. Zero values mean no samples.
+
+type PlayerFingerprint struct {
+	SteamID            uint64
+	Name               string
+	Matches            int
+	TTKMs              float64              // mean of per-match median TTK (matches with samples)
+	CounterStrafePct   float64              // mean of per-match counter-strafe % (matches with shots)
+	CrosshairMedianDeg float64              // per-match median angle, weighted by encounters
+	CrosshairPctUnder5 float64              // per-match % under 5°, weighted by encounters
+	CrosshairSamples   int                  // crosshair encounters summed
+	FHHS               map[string]FHHSCount // by weapon bucket
+}
+// FHHSCount holds first-hit and first-hit headshot counts for one segment.
+type FHHSCount struct {
+	FirstHits, Headshots int
+}
+
+// Pct returns the first-hit headshot rate (0-100), or 0 with no first hits.
+func (c FHHSCount) Pct() float64 {
+	if c.FirstHits == 0 {
+		return 0
+	}
+	return float64(c.Headshots) / float64(c.FirstHits) * 100
+}
+
+// FingerprintMatch is one tracked player's distance from a target
+// fingerprint: the mean over shared features of the absolute difference in
+// units of that feature's spread across all tracked players.
+type FingerprintMatch struct {
+	Player   PlayerFingerprint
+	Features int     // features both players have samples for
+	Distance float64 // mean spread-normalised difference; lower is more similar
+}
+
+// Fingerprint sample floors: a crosshair or FHHS feature counts only with at
+// least FingerprintMinSamples encounters or first hits, and a candidate is
+// scored only over at least FingerprintMinFeatures shared features.
+const (
+	FingerprintMinSamples  = 20
+	FingerprintMinFeatures = 3
+)
+
+// MovingDeathPct returns the aggregate percentage (0-100) of speed-sampled
+// deaths taken while moving above counter-strafe speed.
+func (a *PlayerAggregate) MovingDeathPct() float64 {
+	if a.DeathSpeedSamples == 0 {
+		return 0
+	}
+	return float64(a.MovingDeaths) / float64(a.DeathSpeedSamples) * 100
 }
 
 // KDRatio returns the aggregate kill-to-death ratio across all matches.
@@ -382,6 +1254,47 @@ func (a *PlayerAggregate) HSPercent() float64 {
 	return float64(a.HeadshotKills) / float64(a.Kills) * 100
 }
 
+// UtilThrows returns the grenades thrown across all matches and phases.
+func (a *PlayerAggregate) UtilThrows() int {
+	return a.UtilThrowsEarly + a.UtilThrowsMid + a.UtilThrowsLate
+}
+
+// EcoADR returns the aggregate average damage per round against an eco or
+// semi-eco side, or 0 without such rounds.
+func (a *PlayerAggregate) EcoADR() float64 {
+	if a.EcoRounds == 0 {
+		return 0
+	}
+	return float64(a.EcoDamage) / float64(a.EcoRounds)
+}
+
+// BuyADR returns the aggregate average damage per round against a forcing or
+// fully bought side, or 0 without such rounds.
+func (a *PlayerAggregate) BuyADR() float64 {
+	if a.BuyRounds == 0 {
+		return 0
+	}
+	return float64(a.BuyDamage) / float64(a.BuyRounds)
+}
+
+// InfoDeathPct returns the aggregate deaths to unspotted enemies as a
+// percentage (0-100) of deaths to enemies, or 0 without any.
+func (a *PlayerAggregate) InfoDeathPct() float64 {
+	if n := a.UnspottedDeaths + a.SpottedDeaths; n > 0 {
+		return float64(a.UnspottedDeaths) / float64(n) * 100
+	}
+	return 0
+}
+
+// HeadHitPct returns the aggregate head hits as a percentage (0-100) of
+// bullet hits on enemies, or 0 when there are none.
+func (a *PlayerAggregate) HeadHitPct() float64 {
+	if a.BulletHits == 0 {
+		return 0
+	}
+	return float64(a.HeadHits) / float64(a.BulletHits) * 100
+}
+
 // ADR returns the aggregate average damage per round.
 func (a *PlayerAggregate) ADR() float64 {
 	if a.RoundsPlayed == 0 {
@@ -398,6 +1311,43 @@ func (a *PlayerAggregate) KASTPct() float64 {
 	return float64(a.KASTRounds) / float64(a.RoundsPlayed) * 100
 }
 
+// KASTStrictPct returns the aggregate strict KAST percentage (0-100).
+func (a *PlayerAggregate) KASTStrictPct() float64 {
+	if a.RoundsPlayed == 0 {
+		return 0
+	}
+	return float64(a.KASTStrictRounds) / float64(a.RoundsPlayed) * 100
+}
+
+// CleanDuelWinPct returns the duel win percentage (0-100) over clean 1v1
+// duels — wins and losses without a teammate damage assist — and false when
+// there are none.
+func CleanDuelWinPct(wins, losses, assistedWins, assistedLosses int) (float64, bool) {
+	cleanW, cleanL := wins-assistedWins, losses-assistedLosses
+	if cleanW+cleanL <= 0 {
+		return 0, false
+	}
+	return float64(cleanW) / float64(cleanW+cleanL) * 100, true
+}
+
+// Rating returns the Rating 2.0 proxy over all aggregated rounds.
+func (a *PlayerAggregate) Rating() float64 {
+	return RatingProxy(a.Kills, a.Assists, a.Deaths, a.RoundsPlayed, a.KASTRounds, a.TotalDamage)
+}
+
+// PlayerAWPMapStats holds a player's AWP death classification on one map,
+// summed across the selected demos.
+type PlayerAWPMapStats struct {
+	SteamID uint64
+	Name    string
+	MapName string
+	Matches int
+
+	AWPDeaths, AWPDeathsDry            int
+	AWPDeathsRePeek, AWPDeathsIsolated int
+	AWPRoundsFaced                     int
+}
+
 // PlayerMapSideAggregate holds stats for a single player on one map and one side (CT or T),
 // aggregated across all stored demos.
 type PlayerMapSideAggregate struct {
@@ -413,6 +1363,20 @@ type PlayerMapSideAggregate struct {
 	KASTRounds             int
 	OpeningKills, OpeningDeaths int
 	TradeKills, TradeDeaths int
+
+	// Duel engine — counts summed, exposure averaged over per-match medians (approximate).
+	DuelWins, DuelLosses           int
+	AvgExpoWinMs, AvgExpoLossMs    float64
+	FirstHitCount, FirstHitHSCount int // summed over the duel segments of these matches
+}
+
+// FHHSPct returns the first-hit headshot rate (0-100) for this map/side,
+// pooled from duel segment counts.
+func (a *PlayerMapSideAggregate) FHHSPct() float64 {
+	if a.FirstHitCount == 0 {
+		return 0
+	}
+	return float64(a.FirstHitHSCount) / float64(a.FirstHitCount) * 100
 }
 
 // KDRatio returns the kill-to-death ratio for this map/side combination.
@@ -485,10 +1449,136 @@ func (s *PlayerSideStats) KASTPct() float64 {
 	return float64(s.KASTRounds) / float64(s.RoundsPlayed) * 100
 }
 
-// PlayerDuelSegment holds FHHS stats for one (weapon_bucket, distance_bin) segment per player per demo.
+// PlayerHalfStats holds one player's totals for a single regulation half of a
+// match, derived from player_round_stats. Half 1 covers the rounds before the
+// player's first side switch; half 2 runs until the next switch, so overtime
+// rounds are excluded.
+type PlayerHalfStats struct {
+	DemoHash string
+	SteamID  uint64
+	Half     int // 1 or 2
+
+	Kills, Assists, Deaths      int
+	TotalDamage, RoundsPlayed   int
+	KASTRounds                  int
+	OpeningKills, OpeningDeaths int
+}
+
+// ADR returns the average damage per round for this half.
+func (s *PlayerHalfStats) ADR() float64 {
+	if s.RoundsPlayed == 0 {
+		return 0
+	}
+	return float64(s.TotalDamage) / float64(s.RoundsPlayed)
+}
+
+// KASTPct returns the KAST percentage (0-100) for this half.
+func (s *PlayerHalfStats) KASTPct() float64 {
+	if s.RoundsPlayed == 0 {
+		return 0
+	}
+	return float64(s.KASTRounds) / float64(s.RoundsPlayed) * 100
+}
+
+// Rating returns the Rating 2.0 proxy for this half.
+func (s *PlayerHalfStats) Rating() float64 {
+	return RatingProxy(s.Kills, s.Assists, s.Deaths, s.RoundsPlayed, s.KASTRounds, s.TotalDamage)
+}
+
+// EntrySuccessPct returns opening kills as a percentage (0-100) of opening
+// duels taken (kills + deaths).
+func (s *PlayerHalfStats) EntrySuccessPct() float64 {
+	n := s.OpeningKills + s.OpeningDeaths
+	if n == 0 {
+		return 0
+	}
+	return float64(s.OpeningKills) / float64(n) * 100
+}
+
+// PlayerHalfSplit sums a player's first- and second-half stats across matches.
+// Only matches in which both halves were played contribute.
+type PlayerHalfSplit struct {
+	SteamID uint64
+	Name    string
+	Matches int
+	First   PlayerHalfStats
+	Second  PlayerHalfStats
+}
+
+// SegmentReference holds pooled FHHS for one (weapon_bucket, distance_bin)
+// segment across other players, used as the comparison point for a player's
+// own segment.
+type SegmentReference struct {
+	WeaponBucket    string
+	DistanceBin     string
+	FirstHitCount   int
+	FirstHitHSCount int
+	AvgSightDeg     float64 // mean of per-demo median sight deviations (0 if none)
+	AvgCorrDeg      float64 // mean of per-demo median correction angles (0 if none)
+	Baseline        bool    // pooled from baseline demos only
+}
+
+// FHHSPct returns the pooled first-hit headshot rate as a percentage.
+func (r SegmentReference) FHHSPct() float64 {
+	if r.FirstHitCount == 0 {
+		return 0
+	}
+	return float64(r.FirstHitHSCount) / float64(r.FirstHitCount) * 100
+}
+
+// PracticeItem is one weak duel segment selected for practice, with the drill
+// that targets it.
+type PracticeItem struct {
+	WeaponBucket string
+	DistanceBin  string
+	DuelCount    int
+	FirstHits    int
+	FHHSPct      float64 // player's first-hit headshot rate in this segment
+	RefFHHSPct   float64 // reference rate for the same segment
+	HSLost       float64 // (ref − player) × first hits: headshots below reference
+	SightDeg     float64
+	CorrDeg      float64
+	TTDmgMs      float64 // player's median time to damage with this weapon bucket (0 if too few samples)
+	RefTTDmgMs   float64 // reference time to damage for the bucket (0 if too few samples)
+	Focus        string  // "placement" | "correction" | "hesitation" | "first bullet"
+	Drill        string
+	Minutes      int
+}
+
+// HighlightRound is a standout round of one player: a multi-kill or a won
+// clutch. MapName and MatchDate are filled in by the caller from the demo.
+type HighlightRound struct {
+	DemoHash    string
+	MapName     string
+	MatchDate   string
+	RoundNumber int
+	Kills       int
+	ClutchVs    int // enemies alive when a won clutch began; 0 otherwise
+}
+
+// RatingProxy computes the community approximation of HLTV Rating 2.0.
+//
+//	Impact = 2.13*KPR + 0.42*APR − 0.41
+//	Rating ≈ 0.0073*KAST% + 0.3591*KPR − 0.5329*DPR + 0.2372*Impact + 0.0032*ADR + 0.1587
+func RatingProxy(kills, assists, deaths, rounds, kastRounds, damage int) float64 {
+	if rounds == 0 {
+		return 0
+	}
+	kpr := float64(kills) / float64(rounds)
+	apr := float64(assists) / float64(rounds)
+	dpr := float64(deaths) / float64(rounds)
+	kast := 100.0 * float64(kastRounds) / float64(rounds)
+	adr := float64(damage) / float64(rounds)
+	impact := 2.13*kpr + 0.42*apr - 0.41
+	return 0.0073*kast + 0.3591*kpr - 0.5329*dpr + 0.2372*impact + 0.0032*adr + 0.1587
+}
+
+// PlayerDuelSegment holds FHHS stats for one (round_context, weapon_bucket,
+// distance_bin) segment per player per demo.
 type PlayerDuelSegment struct {
 	DemoHash        string
 	SteamID         uint64
+	RoundContext    string  // RoundContext* constant; "" for demos parsed before round contexts
 	WeaponBucket    string  // e.g. "AK", "M4", "AWP", "Deagle", "Pistol", "Other"
 	DistanceBin     string  // e.g. "10-15m", "unknown"
 	DuelCount       int     // duels won in this segment (with a first-sight)
@@ -497,6 +1587,242 @@ type PlayerDuelSegment struct {
 	MedianCorrDeg   float64 // median pre-shot correction angle (degrees)
 	MedianSightDeg  float64 // median first-sight angular deviation (degrees)
 	MedianExpoWinMs float64 // median exposure time for won duels (ms)
+	// First hits split by the shooter's horizontal speed at the first shot:
+	// still = counter-strafed (≤ 34 u/s), moving = faster. Duels without a
+	// weapon-fire sample are in neither; all are 0 before pipeline v35.
+	StillFirstHits   int
+	StillFirstHitHS  int
+	MovingFirstHits  int
+	MovingFirstHitHS int
+}
+
+// PlayerDuelDistance counts one player's won duels at one whole-meter
+// distance (floored, capped at 60m) for one weapon bucket of a demo — the
+// fine-grained source quantile distance bins are cut from.
+type PlayerDuelDistance struct {
+	DemoHash        string
+	SteamID         uint64
+	WeaponBucket    string
+	Meters          int
+	DuelCount       int
+	FirstHitCount   int
+	FirstHitHSCount int
+}
+
+// PlayerTimeToDamage is one player's median delay from first sighting an enemy
+// to first damaging them, for one weapon bucket of a demo.
+type PlayerTimeToDamage struct {
+	DemoHash     string
+	SteamID      uint64
+	WeaponBucket string // bucket of the weapon that dealt the first damage
+	Samples      int
+	MedianMs     float64
+}
+
+// PlayerBurstStats is one player's burst-length histogram for one weapon
+// bucket of a demo: runs of shots at most 200ms apart, by length.
+type PlayerBurstStats struct {
+	DemoHash     string
+	SteamID      uint64
+	WeaponBucket string
+	Taps         int // 1 shot
+	ShortBursts  int // 2–3 shots
+	Sprays       int // 4–9 shots
+	PanicSprays  int // 10+ shots
+}
+
+// PlayerPositionStats counts the rounds one player set up at one position on
+// one side of a demo. The position is a map callout, or a coarse grid cell
+// when the demo has no place names.
+type PlayerPositionStats struct {
+	DemoHash string
+	MapName  string // populated when queried (JOIN with demos.map_name)
+	SteamID  uint64
+	Side     Team
+	Place    string
+	Rounds   int
+	AvgX     float64 // mean early-round position at this place, Hammer units
+	AvgY     float64
+}
+
+// PlayerPositionProfile is a player's early-round positions on one map and
+// side across demos, most played first.
+type PlayerPositionProfile struct {
+	SteamID uint64
+	MapName string
+	Side    Team
+	Rounds  int
+	Places  []PlaceShare
+	Label   string // main positions joined by "/", e.g. "Connector/Window"; "mixed" when none dominates
+}
+
+// PlaceShare is one position of a PlayerPositionProfile.
+type PlaceShare struct {
+	Place  string
+	Rounds int
+}
+
+// PlayerDeathSegment counts one player's deaths to enemies in one (killer
+// weapon bucket, distance bin) segment of a demo — the "died to" profile.
+type PlayerDeathSegment struct {
+	DemoHash       string
+	SteamID        uint64 // the player who died
+	WeaponBucket   string // killer's weapon bucket, as in PlayerDuelSegment
+	DistanceBin    string // killer-to-victim distance at the kill, "unknown" when not measurable
+	Deaths         int
+	HeadshotDeaths int
+}
+
+// Movement states of a PlayerDeathContext: the victim's horizontal speed at
+// the killer's first bullet hit in the context window.
+const (
+	DeathMoving          = "moving"  // above the 34 u/s counter-strafe threshold
+	DeathHolding         = "holding" // at or below it
+	DeathMovementUnknown = "unknown" // no bullet hit from the killer in the window
+)
+
+// PlayerDeathContext counts one player's deaths to enemies in a demo that
+// share a pre-death context: what the player was doing in the few seconds
+// before dying.
+type PlayerDeathContext struct {
+	DemoHash  string
+	SteamID   uint64 // the player who died
+	Movement  string // Death* movement state
+	Flashed   bool   // blinded by a flash in the window
+	Isolated  bool   // no alive teammate within 512 units at the kill
+	AfterKill bool   // had killed an enemy in the window (just traded or entried)
+	Deaths    int
+}
+
+// Label describes the context in words, e.g. "isolated while moving" or
+// "teammate near, flashed, after a kill while holding".
+func (c PlayerDeathContext) Label() string {
+	parts := []string{"teammate near"}
+	if c.Isolated {
+		parts[0] = "isolated"
+	}
+	if c.Flashed {
+		parts = append(parts, "flashed")
+	}
+	if c.AfterKill {
+		parts = append(parts, "after a kill")
+	}
+	label := strings.Join(parts, ", ")
+	if c.Movement != DeathMovementUnknown {
+		label += " while " + c.Movement
+	}
+	return label
+}
+
+// KillState records the round state immediately before one kill, used to fit
+// the round win-probability model and to credit win-probability-added (WPA).
+type KillState struct {
+	DemoHash      string
+	RoundNumber   int
+	Tick          int
+	KillerSteamID uint64 // 0 for world/bomb/fall deaths
+	VictimSteamID uint64
+	KillerTeam    Team
+	VictimTeam    Team
+	CTAlive       int     // CT players alive before the kill
+	TAlive        int     // T players alive before the kill
+	BombPlanted   bool    // bomb was planted before the kill
+	WinnerTeam    Team    // team that won the round
+	Weapon        string  // killer's weapon; empty for states stored before it was recorded
+	ClockSec      float64 // seconds left on the round timer, or the bomb timer once planted; -1 if not recorded
+}
+
+// Map pool coverage statuses for MapPoolEntry.Status, worst first.
+const (
+	MapPoolNoData = "no data" // not played in the window
+	MapPoolStale  = "stale"   // last played longer ago than the staleness limit
+	MapPoolThin   = "thin"    // played recently, but fewer matches than required
+	MapPoolOK     = "ok"
+)
+
+// MapPoolEntry is a roster's coverage of one map over a look-back window:
+// how often and how well it was played, and how long ago.
+type MapPoolEntry struct {
+	MapName       string
+	InPool        bool   // map is in the requested (default: active duty) pool
+	Matches       int    // qualifying demos on the map in the window
+	Wins, Ties    int    // matches won / drawn by the roster
+	CTWins        int    // roster CT rounds won
+	CTRounds      int    // roster CT rounds played
+	TWins         int    // roster T rounds won
+	TRounds       int    // roster T rounds played
+	LastPlayed    string // "YYYY-MM-DD" of the latest match; empty when none
+	DaysSince     int    // days from LastPlayed to the reference date; -1 when never played
+	RecentMatches int    // matches within the staleness limit of the reference date
+	Status        string // MapPoolNoData, MapPoolStale, MapPoolThin or MapPoolOK
+}
+
+// BombSiteEntry is a roster's plant record at one bomb site of one map (see
+// the map-pool command). T counts are rounds the roster planted at the site;
+// CT counts are rounds it conceded a plant there.
+type BombSiteEntry struct {
+	MapName  string
+	Site     string // "A" or "B"
+	TPlants  int
+	TWins    int // T plant rounds won
+	CTPlants int
+	CTWins   int // CT rounds won after conceding the plant (retake or hold-out)
+}
+
+// PredictionTeam is one side of a naive match prediction, read from a team
+// export (see the predict command).
+type PredictionTeam struct {
+	Team        string
+	Rating      float64 // mean of the exported player ratings
+	Players     int     // exported player ratings
+	HasMap      bool    // the export has the predicted map
+	MapWinPct   float64 // map win rate shrunk toward 0.50 by matches played; 0.50 without the map
+	MapMatches  int
+	DemoCount   int
+	LatestMatch string // latest_match_date of the export
+}
+
+// MatchPrediction is a naive win-probability estimate for team A against
+// team B: a logistic term on the rating difference plus a log5 term on the
+// map win rates, added on the log-odds scale.
+type MatchPrediction struct {
+	Map        string // display name; empty when predicted without a map
+	A, B       PredictionTeam
+	RatingProb float64 // P(A wins) from the rating difference alone
+	MapProb    float64 // P(A wins) from the map win rates alone (0.50 without a map)
+	WinProb    float64 // combined P(A wins)
+}
+
+// Round timeline event types (TimelineEvent.Type), in the order they occur
+// within a round.
+const (
+	TimelineRoundStart = "round_start"
+	TimelineFreezeEnd  = "freeze_end"
+	TimelineKill       = "kill"
+	TimelineFlash      = "flash"
+	TimelinePlant      = "plant"
+	TimelineDefuse     = "defuse"
+	TimelineRoundEnd   = "round_end"
+)
+
+// TimelineEvent is one entry of a match's round-by-round event stream, stored
+// in round_events and exported by `timeline --json`. Fields that do not apply
+// to an event type are zero.
+type TimelineEvent struct {
+	DemoHash    string
+	Seq         int // position in the match's stream; orders events on the same tick
+	RoundNumber int
+	Tick        int
+	Type        string // one of the Timeline* constants
+	// Actor is the killer, flasher, planter or defuser; Target the victim or
+	// flashed player. For round_end, ActorTeam is the winning side.
+	ActorID, TargetID     uint64
+	ActorTeam, TargetTeam Team
+	ActorPos, TargetPos   Vec3    // kill: both; plant/defuse: actor only
+	Weapon                string  // kill weapon
+	Headshot              bool    // kill was a headshot
+	DurationSec           float64 // flash: seconds blind
+	Detail                string  // plant/defuse: bomb site; round_end: end reason
 }
 
 // MatchSummary is a lightweight record for list/show commands.
@@ -511,4 +1837,156 @@ type MatchSummary struct {
 	Tier       string // e.g. "pro", "semi-pro", "faceit-5"; empty for personal matches
 	IsBaseline bool   // true for reference corpus demos
 	EventID    string // event identifier from demoget (e.g. "iem_cologne_2025"); empty if unknown
+
+	PipelineVersion int // aggregator.PipelineVersion that produced the stats; 0 if stored before versioning
+
+	Source DemoSource // where the demo came from; zero for demos stored before provenance was recorded
+}
+
+// Demo origins of DemoSource.Origin.
+const (
+	SourceFile   = "file"   // parsed from a local .dem file
+	SourceFACEIT = "faceit" // downloaded from a FACEIT match by fetch or baseline build
+)
+
+// DemoSource records where a stored demo came from, so a match can be traced
+// back to its source for a re-download or a deeper review.
+type DemoSource struct {
+	Origin    string // Source* constant
+	Path      string // absolute path of the parsed .dem; empty for downloads (the temp file is removed)
+	URL       string // match page, e.g. the FACEIT room
+	ShareCode string // CS2 match share code (CSGO-xxxxx-…)
+	MatchID   string // external match ID: FACEIT match ID or Valve match ID
+}
+
+// IsZero reports whether nothing is known about the demo's source.
+func (s DemoSource) IsZero() bool {
+	return s == DemoSource{}
+}
+
+// Or returns s with its empty fields taken from old, so a re-parse or re-tag
+// that knows less about a demo keeps what an earlier write recorded.
+func (s DemoSource) Or(old DemoSource) DemoSource {
+	pick := func(v, o string) string {
+		if v == "" {
+			return o
+		}
+		return v
+	}
+	return DemoSource{
+		Origin:    pick(s.Origin, old.Origin),
+		Path:      pick(s.Path, old.Path),
+		URL:       pick(s.URL, old.URL),
+		ShareCode: pick(s.ShareCode, old.ShareCode),
+		MatchID:   pick(s.MatchID, old.MatchID),
+	}
+}
+
+// Label is a short one-line description for listings: the origin followed by
+// the match ID, or the file name for local demos ("faceit 1-4b2c…",
+// "file nuke_scrim.dem"); empty when nothing is known.
+func (s DemoSource) Label() string {
+	var ref string
+	switch {
+	case s.MatchID != "":
+		ref = s.MatchID
+	case s.ShareCode != "":
+		ref = s.ShareCode
+	case s.Path != "":
+		ref = filepath.Base(s.Path)
+	case s.URL != "":
+		ref = s.URL
+	}
+	return strings.TrimSpace(s.Origin + " " + ref)
+}
+
+// BaselineQuota is a tier's target demo count for "baseline build" and its
+// progress.
+type BaselineQuota struct {
+	Tier      string
+	Target    int
+	Stored    int    // is_baseline demos stored with this tier
+	Tried     int    // demo sources recorded for this tier, any status
+	Failed    int    // sources whose last attempt failed
+	UpdatedAt string // RFC3339 time the target was last set
+}
+
+// Remaining returns the number of demos still needed to reach the target.
+func (q BaselineQuota) Remaining() int {
+	return max(q.Target-q.Stored, 0)
+}
+
+// PassTiming is the wall time of one parse or aggregation pass over a demo.
+type PassTiming struct {
+	Pass string
+	Ms   float64
+}
+
+// DemoDiagnostics records how a demo's parse went: its raw event counts and
+// the time spent in each pass. Few weapon fires or first sights per round
+// compared with other demos usually point to a parser regression.
+type DemoDiagnostics struct {
+	DemoHash        string
+	Rounds          int
+	Kills           int
+	Damages         int
+	Flashes         int
+	WeaponFires     int
+	FirstSights     int
+	PositionSamples int
+	PreLiveRounds   int
+	// Mean share of server frames dropped, 0-100 (RawMatch.ServerFrameDropPct).
+	ServerFrameDropPct float64
+	Passes             []PassTiming // in run order
+}
+
+// TotalMs returns the summed time of every pass.
+func (d DemoDiagnostics) TotalMs() float64 {
+	var t float64
+	for _, p := range d.Passes {
+		t += p.Ms
+	}
+	return t
+}
+
+// TrashedDemo is a soft-deleted demo held in the trash until it is restored
+// or purged.
+type TrashedDemo struct {
+	DemoHash        string
+	MapName         string
+	MatchDate       string
+	PipelineVersion int
+	Rows            int    // child stats rows kept with the demo
+	DeletedAt       string // RFC3339
+	DeletedBy       string
+}
+
+// AuditEntry is one demo-level write recorded in the audit log.
+type AuditEntry struct {
+	ID              int64
+	At              string // RFC3339
+	Action          string // insert, replace, retag, merge, delete, restore or purge
+	DemoHash        string
+	PipelineVersion int
+	Actor           string
+	Detail          string
+}
+
+// MetricDef documents one metric for the `metrics` command: what it measures,
+// the windows and thresholds it uses, and the pipeline versions that
+// introduced or changed it.
+type MetricDef struct {
+	Name       string         // column name as shown in the reports, e.g. "KAST%"
+	Group      string         // report section, e.g. "Trades"
+	Definition string         // exact definition of the value
+	Window     string         // time windows, distances and thresholds; empty when none
+	Columns    []string       // stored columns it is computed from ("table.column")
+	Since      int            // pipeline version that introduced it; 0 = before versioning
+	Changes    []MetricChange // later changes that alter stored values, oldest first
+}
+
+// MetricChange records a pipeline version that changed how a metric is computed.
+type MetricChange struct {
+	Version int
+	Note    string
 }
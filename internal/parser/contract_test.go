@@ -0,0 +1,314 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// update rewrites the contract goldens from the current backends:
+//
+//	go test ./internal/parser -run TestContract -update
+var update = flag.Bool("update", false, "rewrite testdata/contract golden files")
+
+// contractDir holds demo fixtures (<name>.dem) and their pinned RawMatch
+// output (<name>.golden.json). Real demos are large and optional; the
+// synthetic fixtures (see syntheticBackend) are always present.
+const contractDir = "testdata/contract"
+
+// syntheticMagic starts a synthetic contract fixture: a .dem file holding,
+// after the magic, the JSON of a syntheticFixture instead of a demo stream.
+const syntheticMagic = "CSMSYNT\x00"
+
+// formatSynthetic is the DemoFormat of synthetic fixtures.
+const formatSynthetic DemoFormat = "synthetic"
+
+// syntheticFixture is what a backend recorded from a demo before trimming:
+// every event, and the round the match went live from.
+type syntheticFixture struct {
+	LiveFrom int
+	Raw      model.RawMatch
+}
+
+// syntheticBackend is a test Backend replaying syntheticFixture files, so
+// the contract test runs ParseDemo end to end — format detection, backend
+// choice, pre-live trimming, the SteamID 0 filter, hash and match type —
+// without a demo in the repository.
+type syntheticBackend struct{}
+
+func (syntheticBackend) Name() string               { return "synthetic" }
+func (syntheticBackend) Supports(f DemoFormat) bool { return f == formatSynthetic }
+
+// Parse implements Backend: it decodes the fixture and trims the pre-live
+// rounds like demoinfocsV4 does.
+func (syntheticBackend) Parse(r io.Reader) (*model.RawMatch, error) {
+	head := make([]byte, len(syntheticMagic))
+	if _, err := io.ReadFull(r, head); err != nil || string(head) != syntheticMagic {
+		return nil, fmt.Errorf("not a synthetic fixture")
+	}
+	var fx syntheticFixture
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&fx); err != nil {
+		return nil, fmt.Errorf("decode fixture: %w", err)
+	}
+	fx.Raw.PreLiveRounds = trimPreLive(&fx.Raw, fx.LiveFrom)
+	return &fx.Raw, nil
+}
+
+// withSyntheticBackend registers syntheticBackend and its magic for the
+// rest of the test.
+func withSyntheticBackend(t *testing.T) {
+	t.Helper()
+	saved := backends
+	backends = append(append([]Backend(nil), backends...), syntheticBackend{})
+	demoMagic[syntheticMagic] = formatSynthetic
+	t.Cleanup(func() {
+		backends = saved
+		delete(demoMagic, syntheticMagic)
+	})
+}
+
+func TestDetectFormat(t *testing.T) {
+	cases := []struct {
+		head string
+		want DemoFormat
+	}{
+		{"PBDEMS2\x00\x12\x34", FormatCS2},
+		{"HL2DEMO\x00", FormatCSGO},
+		{"PBDEMS", FormatUnknown},
+		{"", FormatUnknown},
+	}
+	for _, c := range cases {
+		if got := detectFormat([]byte(c.head)); got != c.want {
+			t.Errorf("detectFormat(%q) = %s, want %s", c.head, got, c.want)
+		}
+	}
+}
+
+// TestBackendFor: backends are chosen by format unless CSMETRICS_PARSER
+// names one; unknown formats and names are errors.
+func TestBackendFor(t *testing.T) {
+	t.Setenv(backendEnv, "")
+	for _, f := range []DemoFormat{FormatCS2, FormatCSGO} {
+		b, err := backendFor(f)
+		if err != nil || b.Name() != "demoinfocs-v4" {
+			t.Errorf("backendFor(%s) = %v, %v; want demoinfocs-v4", f, b, err)
+		}
+	}
+	if _, err := backendFor(FormatUnknown); err == nil {
+		t.Error("backendFor(unknown) succeeded, want an error")
+	}
+
+	t.Setenv(backendEnv, "demoinfocs-v4")
+	if b, err := backendFor(FormatUnknown); err != nil || b.Name() != "demoinfocs-v4" {
+		t.Errorf("forced backendFor(unknown) = %v, %v; want demoinfocs-v4", b, err)
+	}
+	t.Setenv(backendEnv, "haste")
+	if _, err := backendFor(FormatCS2); err == nil {
+		t.Error("backendFor with an unknown forced backend succeeded, want an error")
+	}
+}
+
+// restartedMatch is a scrim that went live after a knife round and a
+// live-on-three restart: round 1 is the knife round, round 2 the restart
+// blip, rounds 3 and 4 the first two live rounds. Player 9 only took part
+// in the knife round.
+func restartedMatch() *model.RawMatch {
+	end := func(ids ...uint64) map[uint64]model.PlayerRoundEndState {
+		m := make(map[uint64]model.PlayerRoundEndState)
+		for _, id := range ids {
+			m[id] = model.PlayerRoundEndState{SteamID64: id, IsAlive: true}
+		}
+		return m
+	}
+	return &model.RawMatch{
+		Rounds: []model.RawRound{
+			{Number: 1, PlayerEndState: end(1, 2, 9)},
+			{Number: 2, PlayerEndState: end(1, 2)},
+			{Number: 3, PlayerEndState: end(1, 2)},
+			{Number: 4, PlayerEndState: end(1, 2)},
+		},
+		Kills: []model.RawKill{
+			{Tick: 100, RoundNumber: 1, KillerSteamID: 9, VictimSteamID: 2, Weapon: "Knife"},
+			{Tick: 900, RoundNumber: 3, KillerSteamID: 1, VictimSteamID: 2, Weapon: "Glock-18"},
+			{Tick: 1500, RoundNumber: 4, KillerSteamID: 2, VictimSteamID: 1, Weapon: "Knife"},
+		},
+		Damages: []model.RawDamage{
+			{Tick: 100, RoundNumber: 1, AttackerSteamID: 9, VictimSteamID: 2, Weapon: "Knife"},
+			{Tick: 900, RoundNumber: 3, AttackerSteamID: 1, VictimSteamID: 2, Weapon: "Glock-18"},
+			{Tick: 1400, RoundNumber: 4, AttackerSteamID: 2, VictimSteamID: 1, Weapon: "USP-S"},
+		},
+		WeaponFires:     []model.RawWeaponFire{{Tick: 890, RoundNumber: 3, ShooterID: 1}},
+		PositionSamples: []model.RawPositionSample{{Tick: 50, RoundNumber: 1, PlayerID: 9}, {Tick: 850, RoundNumber: 3, PlayerID: 1}},
+		GrenadeThrows:   []model.RawGrenadeThrow{{Tick: 60, RoundNumber: 1, ThrowerID: 9}, {Tick: 860, RoundNumber: 3, ThrowerID: 1}},
+		PingSamples:     []model.RawPingSample{{Tick: 40, RoundNumber: 1, PlayerID: 9, PingMs: 30}, {Tick: 840, RoundNumber: 3, PlayerID: 1, PingMs: 45}},
+		PlayerNames:     map[uint64]string{1: "a", 2: "b", 9: "knifer"},
+		PlayerTeams:     map[uint64]model.Team{1: model.TeamCT, 2: model.TeamT, 9: model.TeamT},
+	}
+}
+
+// TestTrimPreLive: rounds before the detected live start and leading
+// knife-only rounds are discarded, the rest renumbered from 1, and players
+// seen only before the start forgotten.
+func TestTrimPreLive(t *testing.T) {
+	raw := restartedMatch()
+	if n := trimPreLive(raw, 3); n != 2 {
+		t.Fatalf("trimPreLive(restart at 3) = %d, want 2 discarded", n)
+	}
+	if len(raw.Rounds) != 2 || raw.Rounds[0].Number != 1 || raw.Rounds[1].Number != 2 {
+		t.Errorf("rounds = %+v, want 1 and 2", raw.Rounds)
+	}
+	if len(raw.Kills) != 2 || raw.Kills[0].Tick != 900 || raw.Kills[0].RoundNumber != 1 || raw.Kills[1].RoundNumber != 2 {
+		t.Errorf("kills = %+v, want the two live kills in rounds 1 and 2", raw.Kills)
+	}
+	if len(raw.Damages) != 2 || len(raw.WeaponFires) != 1 || raw.WeaponFires[0].RoundNumber != 1 {
+		t.Errorf("damages %+v / weapon fires %+v not trimmed", raw.Damages, raw.WeaponFires)
+	}
+	if len(raw.PositionSamples) != 1 || raw.PositionSamples[0].PlayerID != 1 {
+		t.Errorf("position samples = %+v, want the live sample only", raw.PositionSamples)
+	}
+	if len(raw.GrenadeThrows) != 1 || raw.GrenadeThrows[0].RoundNumber != 1 {
+		t.Errorf("grenade throws = %+v, want the live throw renumbered to round 1", raw.GrenadeThrows)
+	}
+	if len(raw.PingSamples) != 1 || raw.PingSamples[0].PlayerID != 1 || raw.PingSamples[0].RoundNumber != 1 {
+		t.Errorf("ping samples = %+v, want the live reading renumbered to round 1", raw.PingSamples)
+	}
+	if _, ok := raw.PlayerNames[9]; ok {
+		t.Error("knife-round-only player kept in PlayerNames")
+	}
+	if _, ok := raw.PlayerTeams[9]; ok {
+		t.Error("knife-round-only player kept in PlayerTeams")
+	}
+	if len(raw.PlayerNames) != 2 {
+		t.Errorf("PlayerNames = %v, want players 1 and 2", raw.PlayerNames)
+	}
+
+	// No restart detected: the knife round alone is still dropped; the blip
+	// round without kills is not knife-only and stays.
+	raw = restartedMatch()
+	if n := trimPreLive(raw, 0); n != 1 || raw.Rounds[0].Number != 1 || len(raw.Kills) != 2 {
+		t.Errorf("trimPreLive(no restart) = %d, rounds %+v, kills %+v; want the knife round dropped", n, raw.Rounds, raw.Kills)
+	}
+
+	// A live round with a gun hit is kept even if its only kill is a knife kill.
+	raw = restartedMatch()
+	raw.Kills, raw.Damages = raw.Kills[2:], raw.Damages[2:]
+	raw.Kills[0].RoundNumber, raw.Damages[0].RoundNumber = 1, 1
+	if n := trimPreLive(raw, 1); n != 0 || len(raw.Rounds) != 4 {
+		t.Errorf("trimPreLive(live from 1) = %d with %d rounds, want nothing discarded", n, len(raw.Rounds))
+	}
+}
+
+// TestFilterEntities: SteamID 0 (bots, the world) leaves the roster and the
+// per-player events, and interaction events are dropped only when no real
+// player took part.
+func TestFilterEntities(t *testing.T) {
+	bot := model.NoPlayer
+	raw := &model.RawMatch{
+		Rounds: []model.RawRound{{
+			Number: 1,
+			PlayerEndState: map[uint64]model.PlayerRoundEndState{
+				1: {SteamID64: 1, IsAlive: true}, bot: {SteamID64: bot, IsAlive: true},
+			},
+			PlayerEquipValues: map[uint64]int{1: 4700, bot: 5200},
+		}},
+		Kills: []model.RawKill{
+			{Tick: 100, RoundNumber: 1, KillerSteamID: 1, VictimSteamID: bot},
+			{Tick: 200, RoundNumber: 1, KillerSteamID: bot, VictimSteamID: 1},
+			{Tick: 300, RoundNumber: 1, KillerSteamID: bot, VictimSteamID: bot},
+		},
+		Damages: []model.RawDamage{
+			{Tick: 90, RoundNumber: 1, AttackerSteamID: 1, VictimSteamID: bot},
+			{Tick: 290, RoundNumber: 1, AttackerSteamID: bot, VictimSteamID: bot},
+		},
+		Flashes:     []model.RawFlash{{Tick: 50, RoundNumber: 1, AttackerSteamID: bot, VictimSteamID: bot, FlashDuration: 2}},
+		WeaponFires: []model.RawWeaponFire{{Tick: 89, RoundNumber: 1, ShooterID: 1}, {Tick: 199, RoundNumber: 1, ShooterID: bot}},
+		FirstSights: []model.RawFirstSight{
+			{Tick: 80, RoundNumber: 1, ObserverID: 1, EnemyID: bot},
+			{Tick: 80, RoundNumber: 1, ObserverID: 1, EnemyID: 2},
+		},
+		PositionSamples: []model.RawPositionSample{{RoundNumber: 1, PlayerID: bot}, {RoundNumber: 1, PlayerID: 1}},
+		GrenadeThrows:   []model.RawGrenadeThrow{{Tick: 70, RoundNumber: 1, ThrowerID: bot}, {Tick: 75, RoundNumber: 1, ThrowerID: 1}},
+		PingSamples:     []model.RawPingSample{{Tick: 60, RoundNumber: 1, PlayerID: bot}, {Tick: 60, RoundNumber: 1, PlayerID: 1, PingMs: 35}},
+		Plants:          []model.RawPlant{{Tick: 250, RoundNumber: 1, PlanterID: bot, Site: "A"}},
+		PlayerNames:     map[uint64]string{1: "a", bot: "BOT Albert"},
+		PlayerTeams:     map[uint64]model.Team{1: model.TeamCT, bot: model.TeamT},
+	}
+	if n := filterEntities(raw); n != 8 {
+		t.Errorf("filterEntities = %d, want 8 dropped", n)
+	}
+	if _, ok := raw.PlayerNames[bot]; ok || len(raw.PlayerTeams) != 1 {
+		t.Errorf("roster %v / %v still lists SteamID 0", raw.PlayerNames, raw.PlayerTeams)
+	}
+	r := raw.Rounds[0]
+	if _, ok := r.PlayerEndState[bot]; ok || len(r.PlayerEquipValues) != 1 {
+		t.Errorf("round 1 end states %v / equipment %v still list SteamID 0", r.PlayerEndState, r.PlayerEquipValues)
+	}
+	if len(raw.Kills) != 2 || raw.Kills[0].Tick != 100 || raw.Kills[1].Tick != 200 {
+		t.Errorf("kills = %+v, want the two with a real player", raw.Kills)
+	}
+	if len(raw.Damages) != 1 || len(raw.Flashes) != 0 {
+		t.Errorf("damages %+v / flashes %+v, want only the player's hit", raw.Damages, raw.Flashes)
+	}
+	if len(raw.WeaponFires) != 1 || len(raw.FirstSights) != 1 || raw.FirstSights[0].EnemyID != 2 || len(raw.PositionSamples) != 1 ||
+		len(raw.GrenadeThrows) != 1 || len(raw.PingSamples) != 1 {
+		t.Errorf("fires %+v / sights %+v / positions %+v / throws %+v / pings %+v still hold SteamID 0",
+			raw.WeaponFires, raw.FirstSights, raw.PositionSamples, raw.GrenadeThrows, raw.PingSamples)
+	}
+	if len(raw.Plants) != 1 {
+		t.Errorf("plants = %+v, want the bot's plant kept as a round fact", raw.Plants)
+	}
+}
+
+// TestContract parses every fixture demo and compares the RawMatch with its
+// golden file, so a backend upgrade or swap that changes the raw events
+// fails here before it reaches the stored metrics. MatchDate comes from the
+// file's mtime and is not pinned.
+func TestContract(t *testing.T) {
+	t.Setenv(backendEnv, "")
+	withSyntheticBackend(t)
+	demos, err := filepath.Glob(filepath.Join(contractDir, "*.dem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(demos) == 0 {
+		t.Fatalf("no fixture demos in %s", contractDir)
+	}
+	for _, demo := range demos {
+		name := strings.TrimSuffix(filepath.Base(demo), ".dem")
+		t.Run(name, func(t *testing.T) {
+			raw, err := ParseDemo(demo, "Contract")
+			if err != nil {
+				t.Fatalf("ParseDemo: %v", err)
+			}
+			raw.MatchDate = ""
+			got, err := json.MarshalIndent(raw, "", "  ")
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			golden := filepath.Join(contractDir, name+".golden.json")
+			if *update {
+				if err := os.WriteFile(golden, append(got, '\n'), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("read golden (run with -update to create it): %v", err)
+			}
+			if !bytes.Equal(bytes.TrimSpace(got), bytes.TrimSpace(want)) {
+				t.Errorf("RawMatch differs from %s; inspect the change and re-run with -update if intended", golden)
+			}
+		})
+	}
+}
@@ -0,0 +1,848 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	demoinfocs "github.com/markus-wa/demoinfocs-golang/v4/pkg/demoinfocs"
+	common "github.com/markus-wa/demoinfocs-golang/v4/pkg/demoinfocs/common"
+	"github.com/markus-wa/demoinfocs-golang/v4/pkg/demoinfocs/events"
+	"github.com/markus-wa/demoinfocs-golang/v4/pkg/demoinfocs/msgs2"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// demoinfocsV4 is the Backend built on demoinfocs-golang v4, which reads
+// both CS2 and CS:GO demos.
+type demoinfocsV4 struct{}
+
+// Name implements Backend.
+func (demoinfocsV4) Name() string { return "demoinfocs-v4" }
+
+// Supports implements Backend.
+func (demoinfocsV4) Supports(f DemoFormat) bool {
+	return f == FormatCS2 || f == FormatCSGO
+}
+
+// positionSampleSecs are the times after freeze end at which every alive
+// player's position is sampled — the early-round setup window.
+var positionSampleSecs = []float64{10, 15, 20}
+
+// pingSampleSecs is the interval at which every connected player's
+// scoreboard ping is read, from freeze end to the round end.
+const pingSampleSecs = 5.0
+
+// activeSmoke is a smoke grenade that has popped and not yet faded.
+type activeSmoke struct {
+	throwerID uint64
+	pos       model.Vec3
+}
+
+// segmentDist returns the distance from p to the segment a→b.
+func segmentDist(p, a, b model.Vec3) float64 {
+	abX, abY, abZ := b.X-a.X, b.Y-a.Y, b.Z-a.Z
+	t := 0.0
+	if l := abX*abX + abY*abY + abZ*abZ; l > 0 {
+		t = ((p.X-a.X)*abX + (p.Y-a.Y)*abY + (p.Z-a.Z)*abZ) / l
+		t = math.Max(0, math.Min(1, t))
+	}
+	dX, dY, dZ := p.X-(a.X+t*abX), p.Y-(a.Y+t*abY), p.Z-(a.Z+t*abZ)
+	return math.Sqrt(dX*dX + dY*dY + dZ*dZ)
+}
+
+// pairKey identifies a (observer, enemy) pair for spotted-state deduplication.
+type pairKey struct{ obs, enemy uint64 }
+
+// Source 2 player model eye-height and head-hitbox offsets (in Hammer units).
+// Used to reconstruct eye and head positions when PositionEyes() is unavailable.
+const (
+	standingEyeHeight = 64.0625 // eye height above origin when standing
+	crouchEyeHeight   = 46.0469 // eye height above origin when crouching
+	headAboveEye      = 8.0     // vertical offset from eye level to head-hitbox center
+)
+
+// headZ returns the world-space Z coordinate of an enemy's head center.
+// PositionEyes() panics on Source 2 demos, so eye height is computed manually.
+func headZ(p *common.Player) float64 {
+	eyeOffset := standingEyeHeight
+	if p.IsDucking() {
+		eyeOffset = crouchEyeHeight
+	}
+	return p.Position().Z + eyeOffset + headAboveEye
+}
+
+// crosshairAngles returns total angular deviation, pitch deviation, and yaw deviation
+// between the observer's crosshair direction and the direction to the enemy's head.
+//
+// Coordinate convention (Source 2 / CS2):
+//   - ViewDirectionX() = yaw,   0–360°, 0=East (+X), 90=North (+Y)
+//   - ViewDirectionY() = pitch, 270–90°, where 270 ≡ −90 (looking down);
+//     normalize by subtracting 360 when > 180
+//   - Forward vector: fwdX = cos(pitch)*cos(yaw), fwdY = cos(pitch)*sin(yaw),
+//     fwdZ = -sin(pitch)  (positive pitch → looking down → Z component negative)
+//
+// NOTE: This formula should be validated against a known demo with independently
+// verifiable crosshair data before treating the absolute values as ground truth.
+func crosshairAngles(observer, enemy *common.Player) (total, pitch, yaw float64) {
+	// Observer eye position (PositionEyes() panics on Source 2 — compute manually).
+	eyePos := observer.Position()
+	if observer.IsDucking() {
+		eyePos.Z += crouchEyeHeight
+	} else {
+		eyePos.Z += standingEyeHeight
+	}
+
+	// Enemy head position.
+	headPos := enemy.Position()
+	headPos.Z = headZ(enemy)
+
+	// Raw direction from eye to head (not yet normalized — we need raw for atan2).
+	dxRaw := headPos.X - eyePos.X
+	dyRaw := headPos.Y - eyePos.Y
+	dzRaw := headPos.Z - eyePos.Z
+	distXY := math.Sqrt(dxRaw*dxRaw + dyRaw*dyRaw)
+	dist := math.Sqrt(dxRaw*dxRaw + dyRaw*dyRaw + dzRaw*dzRaw)
+	if dist < 1e-6 {
+		return 0, 0, 0
+	}
+
+	// Yaw and pitch to enemy (world-space angles).
+	yawToEnemy := math.Atan2(dyRaw, dxRaw) * 180 / math.Pi
+	if yawToEnemy < 0 {
+		yawToEnemy += 360
+	}
+	pitchToEnemy := math.Atan2(dzRaw, distXY) * 180 / math.Pi // positive = upward
+
+	// Observer angles.
+	observerYaw := float64(observer.ViewDirectionX())
+	observerPitch := float64(observer.ViewDirectionY())
+	if observerPitch > 180 {
+		observerPitch -= 360 // normalize: 270 → −90 (looking down)
+	}
+	// Source2 convention: positive pitch = looking down → negate for math
+	observerPitch = -observerPitch
+
+	// Yaw deviation wrapped to [0, 180].
+	yawDev := math.Abs(yawToEnemy - observerYaw)
+	if yawDev > 180 {
+		yawDev = 360 - yawDev
+	}
+
+	// Pitch deviation (absolute).
+	pitchDev := math.Abs(pitchToEnemy - observerPitch)
+
+	// Total angular deviation via dot product of unit forward vectors.
+	dx := dxRaw / dist
+	dy := dyRaw / dist
+	dz := dzRaw / dist
+
+	yawR := observerYaw * math.Pi / 180
+	pitchR := (-observerPitch) * math.Pi / 180 // undo our negation for vector math
+	fwdX := math.Cos(pitchR) * math.Cos(yawR)
+	fwdY := math.Cos(pitchR) * math.Sin(yawR)
+	fwdZ := -math.Sin(pitchR)
+
+	dot := fwdX*dx + fwdY*dy + fwdZ*dz
+	if dot > 1 {
+		dot = 1
+	} else if dot < -1 {
+		dot = -1
+	}
+	total = math.Acos(dot) * 180 / math.Pi
+
+	return total, pitchDev, yawDev
+}
+
+// hitGroupName maps a demoinfocs HitGroup to a string label.
+func hitGroupName(hg events.HitGroup) string {
+	switch hg {
+	case events.HitGroupHead:
+		return "head"
+	case events.HitGroupChest:
+		return "chest"
+	case events.HitGroupStomach:
+		return "stomach"
+	case events.HitGroupLeftArm:
+		return "left_arm"
+	case events.HitGroupRightArm:
+		return "right_arm"
+	case events.HitGroupLeftLeg:
+		return "left_leg"
+	case events.HitGroupRightLeg:
+		return "right_leg"
+	default:
+		return "other"
+	}
+}
+
+// weaponName returns the demoinfocs name of w. Equipment demoinfocs doesn't
+// know (a weapon Valve added later) is named by its entity class, e.g.
+// "CWeaponNewRifle", rather than "UNKNOWN", so the unmapped-weapon diagnostics
+// can tell new weapons apart.
+func weaponName(w *common.Equipment) string {
+	if w.Type == common.EqUnknown && w.Entity != nil {
+		return w.Entity.ServerClass().Name()
+	}
+	return w.Type.String()
+}
+
+// weaponClass maps w to one of the model.WeaponClass* values.
+func weaponClass(w *common.Equipment) string {
+	switch w.Class() {
+	case common.EqClassRifle, common.EqClassSMG, common.EqClassHeavy:
+		return model.WeaponClassPrimary
+	case common.EqClassPistols:
+		return model.WeaponClassPistol
+	case common.EqClassGrenade:
+		return model.WeaponClassGrenade
+	}
+	if w.Type == common.EqKnife {
+		return model.WeaponClassKnife
+	}
+	return model.WeaponClassOther
+}
+
+// isUtilityOrKnifeWeapon returns true for weapons that should be skipped in WeaponFire handling.
+func isUtilityOrKnifeWeapon(t common.EquipmentType) bool {
+	return t == common.EqHE || t == common.EqMolotov || t == common.EqIncendiary ||
+		t == common.EqFlash || t == common.EqSmoke || t == common.EqDecoy ||
+		t == common.EqKnife
+}
+
+// Parse implements Backend: it walks every frame of the demo, extracting
+// kills, damage, flashes, weapon fires, first-sight crosshair angles and
+// early-round positions.
+func (demoinfocsV4) Parse(r io.Reader) (*model.RawMatch, error) {
+	p := demoinfocs.NewParser(r)
+	defer p.Close()
+
+	raw := &model.RawMatch{
+		PlayerNames: make(map[uint64]string),
+		PlayerTeams: make(map[uint64]model.Team),
+	}
+
+	var (
+		roundNumber          int
+		roundStartTick       int
+		freezeEndTick        int
+		currentEquipVals     map[uint64]int
+		currentBombPlantTick int
+
+		// Bomb interaction state: the player currently planting / defusing, and
+		// whether any enemy has spotted the defuser since the defuse started.
+		planterID      uint64
+		plantAbortID   uint64
+		plantAbortTick int
+		defuserID      uint64
+		defuserSpotted bool
+
+		// Early-round position sampling: positionSampleSecs[nextSample] is the
+		// next sample time; sampling runs from freeze end to the round end.
+		sampling   bool
+		nextSample int
+		// Tick of the next ping reading while sampling.
+		nextPingTick int
+
+		// Server frame drop share summed over net_Tick messages (CS2 only).
+		frameDropSum   float64
+		frameDropTicks int
+
+		// Smokes currently on the map, by grenade entity ID.
+		smokes = make(map[int]activeSmoke)
+
+		// Match start detection: a restart (knife round over, live on three)
+		// sets restartPending; the next round is then the earliest candidate
+		// for the live round 1. lastZeroRound is the last round that began
+		// with the server's rounds-played counter at 0, trusted only once the
+		// counter has been seen above 0.
+		restartPending bool
+		liveFrom       int
+		lastZeroRound  int
+		roundCountSeen bool
+	)
+
+	// seenThisRound tracks (observer, enemy) pairs already recorded in the current round
+	// so each pair only generates one RawFirstSight event per round.
+	seenThisRound := make(map[pairKey]bool)
+
+	// RoundStart: record start tick, bump round counter, reset spotted tracking.
+	p.RegisterEventHandler(func(e events.RoundStart) {
+		if p.GameState().IsWarmupPeriod() {
+			return
+		}
+		roundNumber++
+		if restartPending {
+			liveFrom, restartPending = roundNumber, false
+		}
+		if p.GameState().TotalRoundsPlayed() > 0 {
+			roundCountSeen = true
+		} else {
+			lastZeroRound = roundNumber
+		}
+		roundStartTick = p.GameState().IngameTick()
+		freezeEndTick = roundStartTick // will be updated by RoundFreezetimeEnd
+		seenThisRound = make(map[pairKey]bool)
+		currentEquipVals = nil
+		currentBombPlantTick = 0
+		planterID, plantAbortID, defuserID = 0, 0, 0
+		sampling = false
+		smokes = make(map[int]activeSmoke)
+	})
+
+	// MatchStart (begin_new_match) and m_bHasMatchStarted turning on mark a
+	// restart: everything before the following round is pre-live.
+	p.RegisterEventHandler(func(e events.MatchStart) {
+		restartPending = true
+	})
+	p.RegisterEventHandler(func(e events.MatchStartedChanged) {
+		if e.NewIsStarted && !e.OldIsStarted {
+			restartPending = true
+		}
+	})
+
+	// BombPlanted: record the tick when the bomb was planted this round.
+	p.RegisterEventHandler(func(e events.BombPlanted) {
+		currentBombPlantTick = p.CurrentFrame()
+		planterID = 0
+		if roundNumber == 0 || e.Player == nil {
+			return
+		}
+		pos := e.Player.Position()
+		raw.Plants = append(raw.Plants, model.RawPlant{
+			Tick:        p.GameState().IngameTick(),
+			RoundNumber: roundNumber,
+			PlanterID:   e.Player.SteamID64,
+			Site:        bombsiteName(e.Site),
+			Pos:         model.Vec3{X: pos.X, Y: pos.Y, Z: pos.Z},
+		})
+	})
+
+	// Plant begin/abort: track the planter so a kill mid-plant can be flagged.
+	// The abort can fire on the same tick as the planter's death, before the
+	// Kill event, so the last abort is remembered for that tick.
+	p.RegisterEventHandler(func(e events.BombPlantBegin) {
+		if e.Player != nil {
+			planterID = e.Player.SteamID64
+		}
+	})
+	p.RegisterEventHandler(func(e events.BombPlantAborted) {
+		if e.Player != nil && e.Player.SteamID64 == planterID {
+			plantAbortID, plantAbortTick = planterID, p.GameState().IngameTick()
+		}
+		planterID = 0
+	})
+
+	// Defuse start/abort/complete: the frame loop marks defuserSpotted while a
+	// defuse is in progress; BombDefused emits a RawDefuse.
+	p.RegisterEventHandler(func(e events.BombDefuseStart) {
+		if e.Player != nil {
+			defuserID, defuserSpotted = e.Player.SteamID64, false
+		}
+	})
+	p.RegisterEventHandler(func(e events.BombDefuseAborted) {
+		defuserID = 0
+	})
+	p.RegisterEventHandler(func(e events.BombDefused) {
+		if roundNumber == 0 || e.Player == nil {
+			return
+		}
+		def := model.RawDefuse{
+			Tick:        p.GameState().IngameTick(),
+			RoundNumber: roundNumber,
+			DefuserID:   e.Player.SteamID64,
+			Spotted:     defuserSpotted && defuserID == e.Player.SteamID64,
+			Site:        bombsiteName(e.Site),
+		}
+		defPos := e.Player.Position()
+		def.Pos = model.Vec3{X: defPos.X, Y: defPos.Y, Z: defPos.Z}
+		for _, pl := range p.GameState().Participants().Playing() {
+			if pl == nil || !pl.IsAlive() || pl.Team == e.Player.Team {
+				continue
+			}
+			def.EnemiesAlive++
+			if e.Player.IsSpottedBy(pl) {
+				def.Spotted = true
+			}
+			d := pl.Position().Sub(defPos)
+			if math.Sqrt(float64(d.X*d.X+d.Y*d.Y+d.Z*d.Z)) <= 1000 {
+				def.NearbyEnemies++
+			}
+		}
+		raw.Defuses = append(raw.Defuses, def)
+		defuserID = 0
+	})
+
+	// RoundFreezetimeEnd: record the tick after freeze ends and snapshot equipment values.
+	p.RegisterEventHandler(func(e events.RoundFreezetimeEnd) {
+		if roundNumber == 0 {
+			return
+		}
+		freezeEndTick = p.GameState().IngameTick()
+		sampling, nextSample = true, 0
+		nextPingTick = freezeEndTick
+		equipVals := make(map[uint64]int)
+		for _, pl := range p.GameState().Participants().Playing() {
+			if pl == nil || pl.SteamID64 == 0 {
+				continue
+			}
+			equipVals[pl.SteamID64] = pl.EquipmentValueFreezeTimeEnd()
+		}
+		currentEquipVals = equipVals
+	})
+
+	// RoundEnd: snapshot state, record round metadata.
+	p.RegisterEventHandler(func(e events.RoundEnd) {
+		if roundNumber == 0 {
+			return
+		}
+		sampling = false
+		endTick := p.GameState().IngameTick()
+		winnerTeam := teamFromCommon(e.Winner)
+
+		endState := make(map[uint64]model.PlayerRoundEndState)
+		for _, pl := range p.GameState().Participants().Playing() {
+			if pl == nil || pl.SteamID64 == 0 {
+				continue
+			}
+			grenCount := 0
+			for _, weap := range pl.Weapons() {
+				if weap != nil && weap.Type.Class() == common.EqClassGrenade &&
+					weap.Type != common.EqFlash { // flashes counted separately
+					grenCount++
+				}
+			}
+			endState[pl.SteamID64] = model.PlayerRoundEndState{
+				SteamID64:    pl.SteamID64,
+				IsAlive:      pl.IsAlive(),
+				Team:         teamFromCommon(pl.Team),
+				GrenadeCount: grenCount,
+			}
+			// Update name/team maps.
+			raw.PlayerNames[pl.SteamID64] = pl.Name
+			raw.PlayerTeams[pl.SteamID64] = teamFromCommon(pl.Team)
+		}
+
+		raw.Rounds = append(raw.Rounds, model.RawRound{
+			Number:            roundNumber,
+			StartTick:         roundStartTick,
+			FreezeEndTick:     freezeEndTick,
+			EndTick:           endTick,
+			WinnerTeam:        winnerTeam,
+			EndReason:         endReason(e.Reason),
+			PlayerEndState:    endState,
+			PlayerEquipValues: currentEquipVals,
+			BombPlantTick:     currentBombPlantTick,
+		})
+	})
+
+	// Smoke start/expire: track active smokes so a kill through smoke can be
+	// credited to the smoke's thrower.
+	p.RegisterEventHandler(func(e events.SmokeStart) {
+		var throwerID uint64
+		if e.Thrower != nil {
+			throwerID = e.Thrower.SteamID64
+		}
+		smokes[e.GrenadeEntityID] = activeSmoke{
+			throwerID: throwerID,
+			pos:       model.Vec3{X: e.Position.X, Y: e.Position.Y, Z: e.Position.Z},
+		}
+	})
+	p.RegisterEventHandler(func(e events.SmokeExpired) {
+		delete(smokes, e.GrenadeEntityID)
+	})
+
+	// Kill events.
+	p.RegisterEventHandler(func(e events.Kill) {
+		if roundNumber == 0 {
+			return
+		}
+		if e.Killer == nil || e.Victim == nil {
+			return
+		}
+		var assisterID uint64
+		if e.Assister != nil {
+			assisterID = e.Assister.SteamID64
+		}
+		var weapName string
+		if e.Weapon != nil {
+			weapName = weaponName(e.Weapon)
+		}
+
+		kill := model.RawKill{
+			Tick:            p.GameState().IngameTick(),
+			RoundNumber:     roundNumber,
+			KillerSteamID:   e.Killer.SteamID64,
+			VictimSteamID:   e.Victim.SteamID64,
+			AssisterSteamID: assisterID,
+			KillerTeam:      teamFromCommon(e.Killer.Team),
+			VictimTeam:      teamFromCommon(e.Victim.Team),
+			Weapon:          weapName,
+			IsHeadshot:      e.IsHeadshot,
+			AssistedFlash:   e.AssistedFlash,
+		}
+		kp, vp := e.Killer.Position(), e.Victim.Position()
+		kill.KillerPos = model.Vec3{X: kp.X, Y: kp.Y, Z: kp.Z}
+		kill.VictimPos = model.Vec3{X: vp.X, Y: vp.Y, Z: vp.Z}
+
+		// Victim's loadout: weapon in hand and whether a primary was carried.
+		if w := e.Victim.ActiveWeapon(); w != nil {
+			kill.VictimWeapon = weaponName(w)
+			kill.VictimWeaponClass = weaponClass(w)
+		}
+		for _, w := range e.Victim.Weapons() {
+			if w != nil && weaponClass(w) == model.WeaponClassPrimary {
+				kill.VictimHasPrimary = true
+				break
+			}
+		}
+
+		// Through smoke: the smoke is the active one nearest the shot line.
+		if e.ThroughSmoke {
+			kill.ThroughSmoke = true
+			best := math.Inf(1)
+			for _, s := range smokes {
+				if d := segmentDist(s.pos, kill.KillerPos, kill.VictimPos); d < best {
+					best, kill.SmokeThrowerID = d, s.throwerID
+				}
+			}
+		}
+
+		victimID := e.Victim.SteamID64
+		kill.VictimPlanting = e.Victim.IsPlanting || victimID == planterID ||
+			(victimID == plantAbortID && kill.Tick == plantAbortTick)
+
+		// Count alive teammates of victim within 512 units for AWP death classifier.
+		if e.Weapon != nil && e.Weapon.Type == common.EqAWP {
+			victimPos := e.Victim.Position()
+			count := 0
+			for _, pl := range p.GameState().Participants().Playing() {
+				if pl == nil || !pl.IsAlive() || pl.Team != e.Victim.Team || pl.SteamID64 == e.Victim.SteamID64 {
+					continue
+				}
+				d := pl.Position().Sub(victimPos)
+				if math.Sqrt(float64(d.X*d.X+d.Y*d.Y+d.Z*d.Z)) <= 512 {
+					count++
+				}
+			}
+			kill.NearbyVictimTeammates = count
+		}
+
+		raw.Kills = append(raw.Kills, kill)
+
+		// Update player name/team.
+		raw.PlayerNames[e.Killer.SteamID64] = e.Killer.Name
+		raw.PlayerNames[e.Victim.SteamID64] = e.Victim.Name
+		raw.PlayerTeams[e.Killer.SteamID64] = teamFromCommon(e.Killer.Team)
+		raw.PlayerTeams[e.Victim.SteamID64] = teamFromCommon(e.Victim.Team)
+	})
+
+	// PlayerHurt (damage) events.
+	p.RegisterEventHandler(func(e events.PlayerHurt) {
+		if roundNumber == 0 {
+			return
+		}
+		if e.Attacker == nil || e.Player == nil {
+			return
+		}
+		if e.Attacker.SteamID64 == e.Player.SteamID64 {
+			return // ignore self-damage
+		}
+		var weapName string
+		isUtil := false
+		if e.Weapon != nil {
+			weapName = weaponName(e.Weapon)
+			isUtil = isUtilityWeapon(e.Weapon.Type)
+		}
+
+		vp := e.Player.Position()
+		vv := e.Player.Velocity()
+		raw.Damages = append(raw.Damages, model.RawDamage{
+			Tick:            p.GameState().IngameTick(),
+			RoundNumber:     roundNumber,
+			AttackerSteamID: e.Attacker.SteamID64,
+			VictimSteamID:   e.Player.SteamID64,
+			AttackerTeam:    teamFromCommon(e.Attacker.Team),
+			HealthDamage:    e.HealthDamage,
+			VictimHealth:    e.Health,
+			VictimTeam:      teamFromCommon(e.Player.Team),
+			Weapon:          weapName,
+			IsUtility:       isUtil,
+			HitGroup:        hitGroupName(e.HitGroup),
+			VictimPos:       model.Vec3{X: vp.X, Y: vp.Y, Z: vp.Z},
+			VictimSpeed:     math.Sqrt(vv.X*vv.X + vv.Y*vv.Y),
+		})
+	})
+
+	// PlayerFlashed events.
+	p.RegisterEventHandler(func(e events.PlayerFlashed) {
+		if roundNumber == 0 {
+			return
+		}
+		if e.Attacker == nil || e.Player == nil {
+			return
+		}
+		dur := e.FlashDuration()
+		if dur <= 0 {
+			return
+		}
+
+		raw.Flashes = append(raw.Flashes, model.RawFlash{
+			Tick:            p.GameState().IngameTick(),
+			RoundNumber:     roundNumber,
+			AttackerSteamID: e.Attacker.SteamID64,
+			VictimSteamID:   e.Player.SteamID64,
+			AttackerTeam:    teamFromCommon(e.Attacker.Team),
+			VictimTeam:      teamFromCommon(e.Player.Team),
+			FlashDuration:   dur,
+		})
+	})
+
+	// WeaponFire events (for pre-shot correction).
+	p.RegisterEventHandler(func(e events.WeaponFire) {
+		if roundNumber == 0 {
+			return
+		}
+		if p.GameState().IsWarmupPeriod() {
+			return
+		}
+		if e.Shooter == nil || e.Shooter.SteamID64 == 0 {
+			return
+		}
+		if e.Weapon == nil || isUtilityOrKnifeWeapon(e.Weapon.Type) {
+			return
+		}
+
+		yaw := float64(e.Shooter.ViewDirectionX())
+		pitch := float64(e.Shooter.ViewDirectionY())
+		if pitch > 180 {
+			pitch -= 360 // normalize
+		}
+
+		sp := e.Shooter.Position()
+		vel := e.Shooter.Velocity()
+		hSpeed := math.Sqrt(vel.X*vel.X + vel.Y*vel.Y)
+		raw.WeaponFires = append(raw.WeaponFires, model.RawWeaponFire{
+			Tick:            p.GameState().IngameTick(),
+			RoundNumber:     roundNumber,
+			ShooterID:       e.Shooter.SteamID64,
+			Weapon:          weaponName(e.Weapon),
+			PitchDeg:        pitch,
+			YawDeg:          yaw,
+			AttackerPos:     model.Vec3{X: sp.X, Y: sp.Y, Z: sp.Z},
+			HorizontalSpeed: hSpeed,
+		})
+	})
+
+	// Grenade throws: one event per projectile leaving a player's hand.
+	p.RegisterEventHandler(func(e events.GrenadeProjectileThrow) {
+		if roundNumber == 0 || p.GameState().IsWarmupPeriod() {
+			return
+		}
+		g := e.Projectile
+		if g == nil || g.Thrower == nil || g.WeaponInstance == nil {
+			return
+		}
+		raw.GrenadeThrows = append(raw.GrenadeThrows, model.RawGrenadeThrow{
+			Tick:        p.GameState().IngameTick(),
+			RoundNumber: roundNumber,
+			ThrowerID:   g.Thrower.SteamID64,
+			Team:        teamFromCommon(g.Thrower.Team),
+			Grenade:     weaponName(g.WeaponInstance),
+		})
+	})
+
+	// net_Tick carries the server's frame timing; the share of frames it
+	// dropped is the only loss figure a demo records.
+	p.RegisterNetMessageHandler(func(m *msgs2.CNETMsg_Tick) {
+		if roundNumber == 0 || m.HostFrameDroppedPctX10 == nil {
+			return
+		}
+		frameDropSum += float64(m.GetHostFrameDroppedPctX10()) / 10
+		frameDropTicks++
+	})
+
+	// Frame-walk loop: fires registered event handlers each frame AND lets us
+	// inspect live game state for spotted-flag transitions every tick.
+	for {
+		ok, err := p.ParseNextFrame()
+		if err != nil {
+			return nil, fmt.Errorf("parse demo: %w", err)
+		}
+
+		if roundNumber > 0 {
+			tick := p.GameState().IngameTick()
+			players := p.GameState().Participants().Playing()
+
+			// Defuse awareness: did any living enemy see the defuser this tick?
+			if defuserID != 0 && !defuserSpotted {
+				for _, defuser := range players {
+					if defuser == nil || defuser.SteamID64 != defuserID {
+						continue
+					}
+					for _, enemy := range players {
+						if enemy != nil && enemy.IsAlive() && enemy.Team != defuser.Team && defuser.IsSpottedBy(enemy) {
+							defuserSpotted = true
+							break
+						}
+					}
+				}
+			}
+
+			// Early-round positions, with the nav-mesh callout when available.
+			if sampling && nextSample < len(positionSampleSecs) {
+				tps := p.TickRate()
+				if tps == 0 {
+					tps = 64.0
+				}
+				if tick >= freezeEndTick+int(positionSampleSecs[nextSample]*tps) {
+					for _, pl := range players {
+						if pl == nil || pl.SteamID64 == 0 || !pl.IsAlive() {
+							continue
+						}
+						pos := pl.Position()
+						raw.PositionSamples = append(raw.PositionSamples, model.RawPositionSample{
+							Tick:        tick,
+							RoundNumber: roundNumber,
+							PlayerID:    pl.SteamID64,
+							Team:        teamFromCommon(pl.Team),
+							Pos:         model.Vec3{X: pos.X, Y: pos.Y, Z: pos.Z},
+							Place:       pl.LastPlaceName(),
+						})
+					}
+					nextSample++
+				}
+			}
+
+			// Scoreboard ping of every connected player, dead or alive.
+			if sampling && tick >= nextPingTick {
+				for _, pl := range players {
+					if pl == nil || pl.SteamID64 == 0 || !pl.IsConnected {
+						continue
+					}
+					raw.PingSamples = append(raw.PingSamples, model.RawPingSample{
+						Tick:        tick,
+						RoundNumber: roundNumber,
+						PlayerID:    pl.SteamID64,
+						PingMs:      pl.Ping(),
+					})
+				}
+				tps := p.TickRate()
+				if tps == 0 {
+					tps = 64.0
+				}
+				nextPingTick = tick + int(pingSampleSecs*tps)
+			}
+
+			for _, observer := range players {
+				if observer == nil || observer.SteamID64 == 0 || !observer.IsAlive() {
+					continue
+				}
+				for _, enemy := range players {
+					if enemy == nil || enemy.SteamID64 == 0 || !enemy.IsAlive() {
+						continue
+					}
+					if enemy.Team == observer.Team {
+						continue
+					}
+					key := pairKey{observer.SteamID64, enemy.SteamID64}
+					if seenThisRound[key] {
+						continue
+					}
+					if enemy.IsSpottedBy(observer) {
+						totalDeg, pitchDeg, yawDeg := crosshairAngles(observer, enemy)
+						obsPitch := float64(observer.ViewDirectionY())
+						if obsPitch > 180 {
+							obsPitch -= 360
+						}
+						ov := observer.Velocity()
+						raw.FirstSights = append(raw.FirstSights, model.RawFirstSight{
+							Tick:             tick,
+							RoundNumber:      roundNumber,
+							ObserverID:       observer.SteamID64,
+							EnemyID:          enemy.SteamID64,
+							AngleDeg:         totalDeg,
+							PitchDeg:         pitchDeg,
+							YawDeg:           yawDeg,
+							ObserverPitchDeg: obsPitch,
+							ObserverYawDeg:   float64(observer.ViewDirectionX()),
+							ObserverSpeed:    math.Sqrt(ov.X*ov.X + ov.Y*ov.Y),
+						})
+						seenThisRound[key] = true
+					}
+				}
+			}
+		}
+
+		if !ok {
+			break
+		}
+	}
+
+	if roundCountSeen && lastZeroRound > liveFrom {
+		liveFrom = lastZeroRound
+	}
+	raw.PreLiveRounds = trimPreLive(raw, liveFrom)
+	if frameDropTicks > 0 {
+		raw.ServerFrameDropPct = frameDropSum / float64(frameDropTicks)
+	}
+
+	// Extract header metadata.
+	header := p.Header()
+	raw.MapName = header.MapName
+	raw.Tickrate = p.TickRate()
+	raw.TicksPerSecond = p.TickRate()
+
+	return raw, nil
+}
+
+// teamFromCommon converts a demoinfocs common.Team value to the internal model.Team enum.
+func teamFromCommon(t common.Team) model.Team {
+	switch t {
+	case common.TeamTerrorists:
+		return model.TeamT
+	case common.TeamCounterTerrorists:
+		return model.TeamCT
+	case common.TeamSpectators:
+		return model.TeamSpectators
+	default:
+		return model.TeamUnknown
+	}
+}
+
+// isUtilityWeapon returns true for grenade-type equipment (HE, molotov, incendiary)
+// that should be flagged as utility damage in PlayerHurt events.
+func isUtilityWeapon(t common.EquipmentType) bool {
+	return t == common.EqHE || t == common.EqMolotov || t == common.EqIncendiary
+}
+
+// endReason maps a demoinfocs round end reason to one of the model.EndReason*
+// constants.
+func endReason(r events.RoundEndReason) string {
+	switch r {
+	case events.RoundEndReasonCTWin, events.RoundEndReasonTerroristsWin:
+		return model.EndReasonElimination
+	case events.RoundEndReasonTargetBombed:
+		return model.EndReasonBomb
+	case events.RoundEndReasonBombDefused:
+		return model.EndReasonDefuse
+	case events.RoundEndReasonTargetSaved:
+		return model.EndReasonTime
+	case events.RoundEndReasonTerroristsSurrender, events.RoundEndReasonCTSurrender:
+		return model.EndReasonSurrender
+	default:
+		return model.EndReasonOther
+	}
+}
+
+// bombsiteName returns "A" or "B" for a bomb event site, "" when unknown.
+func bombsiteName(s events.Bombsite) string {
+	switch s {
+	case events.BombsiteA:
+		return "A"
+	case events.BombsiteB:
+		return "B"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,312 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// Dashboard is one frame of the dashboard command: a tracked player's
+// cross-match aggregate, their matches in chronological order, duel segments
+// merged across demos and clutch totals.
+type Dashboard struct {
+	Agg     model.PlayerAggregate
+	Matches []model.PlayerMatchStats  // chronological, oldest first
+	Segs    []model.PlayerDuelSegment // merged by weapon bucket × distance bin
+	Clutch  model.PlayerClutchMatchStats
+	Updated time.Time
+}
+
+// dashboardBins are the fixed distance bins, in column order, of the FHHS
+// heat-grid.
+var dashboardBins = []string{"0-5m", "5-10m", "10-15m", "15-20m", "20-30m", "30m+"}
+
+// sparkBlocks are the bar glyphs of a sparkline, lowest to highest.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// dashboardCardWidth is the outer width of one aggregate card.
+const dashboardCardWidth = 16
+
+// RenderDashboard draws d as a frame of at most width × height cells: a
+// header, aggregate cards, rating and ADR sparklines, the FHHS heat-grid and
+// as many recent matches as fit. A height of 0 draws every match and no key
+// help (a one-off frame).
+func RenderDashboard(d Dashboard, width, height int) string {
+	width = max(width, 40)
+	var lines []string
+	add := func(ls ...string) { lines = append(lines, ls...) }
+
+	a := d.Agg
+	header := " " + strconv.FormatUint(a.SteamID, 10)
+	if a.Name != "" {
+		header = " " + color.New(color.Bold).Sprint(a.Name) + " " + header
+	}
+	add(fitLine(fmt.Sprintf("%s  %d matches  updated %s", header, a.Matches, d.Updated.Format("15:04:05")), width))
+	if height > 0 {
+		add(fitLine(color.New(color.Faint).Sprint(" q quit  r refresh"), width))
+	}
+	add("")
+	if len(d.Matches) == 0 {
+		add(" no matches stored for this player yet — the dashboard redraws when the database changes")
+		return strings.Join(lines, "\n")
+	}
+	add(dashboardCards(d, width)...)
+	add("")
+	add(dashboardTrend(d.Matches, width)...)
+	add("")
+	add(dashboardHeatGrid(d.Segs, width)...)
+	add("")
+
+	// Recent matches fill what is left of the screen, newest first.
+	room := height - len(lines) - 2
+	if height <= 0 {
+		room = len(d.Matches) + 2
+	}
+	add(dashboardRecent(d.Matches, room, width)...)
+
+	if height > 0 && len(lines) > height {
+		lines = lines[:height]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// dashboardCards lays out the aggregate cards in as many rows as the width
+// needs.
+func dashboardCards(d Dashboard, width int) []string {
+	a := d.Agg
+	c := a.Consistency
+	var hits, hsHits int
+	for _, s := range d.Segs {
+		hits += s.FirstHitCount
+		hsHits += s.FirstHitHSCount
+	}
+	fhhs := "—"
+	if hits > 0 {
+		fhhs = fmt.Sprintf("%.0f%%", float64(hsHits)/float64(hits)*100)
+	}
+	clutch := "—"
+	if n := d.Clutch.TotalAttempts(); n > 0 {
+		clutch = fmt.Sprintf("%.0f%%", float64(d.Clutch.TotalWins())/float64(n)*100)
+	}
+	type card struct{ title, value, sub string }
+	cards := []card{
+		{"RATING", colorRating(a.Rating()), fmt.Sprintf("IQR %.2f", c.RatingIQR)},
+		{"K/D", colorKD(a.KDRatio()), fmt.Sprintf("%d-%d", a.Kills, a.Deaths)},
+		{"ADR", fmt.Sprintf("%.1f", a.ADR()), fmt.Sprintf("SD %.1f", c.ADRSD)},
+		{"KAST%", fmt.Sprintf("%.0f%%", kastPct(a.KASTRounds, a.KASTStrictRounds, a.RoundsPlayed)), fmt.Sprintf("SD %.1f", c.KASTSD)},
+		{"HS%", fmt.Sprintf("%.0f%%", a.HSPercent()), fmt.Sprintf("%d HS kills", a.HeadshotKills)},
+		{"FHHS%", fhhs, fmt.Sprintf("N=%d", hits)},
+		{"ENTRY", fmt.Sprintf("%d-%d", a.OpeningKills, a.OpeningDeaths), "kills-deaths"},
+		{"CLUTCH", clutch, fmt.Sprintf("%d/%d won", d.Clutch.TotalWins(), d.Clutch.TotalAttempts())},
+	}
+
+	perRow := max(width/dashboardCardWidth, 1)
+	inner := dashboardCardWidth - 4
+	var out []string
+	for start := 0; start < len(cards); start += perRow {
+		row := cards[start:min(start+perRow, len(cards))]
+		rows := make([]string, 5)
+		for _, cd := range row {
+			rows[0] += "╭" + strings.Repeat("─", dashboardCardWidth-2) + "╮"
+			rows[1] += "│ " + padVisible(color.New(color.Faint).Sprint(cd.title), inner) + " │"
+			rows[2] += "│ " + padVisible(color.New(color.Bold).Sprint(cd.value), inner) + " │"
+			rows[3] += "│ " + padVisible(truncVisible(cd.sub, inner), inner) + " │"
+			rows[4] += "╰" + strings.Repeat("─", dashboardCardWidth-2) + "╯"
+		}
+		out = append(out, rows...)
+	}
+	return out
+}
+
+// dashboardTrend draws rating and ADR sparklines over the most recent
+// matches that fit the width, oldest on the left.
+func dashboardTrend(matches []model.PlayerMatchStats, width int) []string {
+	const label = 16 // " RATING  0.8–1.4 "
+	n := min(len(matches), max(width-label-2, 1))
+	recent := matches[len(matches)-n:]
+	rating := make([]float64, len(recent))
+	adr := make([]float64, len(recent))
+	for i := range recent {
+		rating[i] = recent[i].Rating()
+		adr[i] = recent[i].ADR()
+	}
+	title := color.New(color.Bold).Sprintf(" Trend (last %d matches)", n)
+	return []string{
+		title,
+		fitLine(sparkRow("RATING", rating, "%.2f"), width),
+		fitLine(sparkRow("ADR", adr, "%.0f"), width),
+	}
+}
+
+// sparkRow formats one labeled sparkline with its range.
+func sparkRow(label string, vals []float64, format string) string {
+	if len(vals) == 0 {
+		return fmt.Sprintf(" %-7s —", label)
+	}
+	lo, hi := vals[0], vals[0]
+	for _, v := range vals {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+	return fmt.Sprintf(" %-7s %s  %s–%s", label, sparkline(vals, lo, hi),
+		fmt.Sprintf(format, lo), fmt.Sprintf(format, hi))
+}
+
+// sparkline maps vals onto block glyphs between lo and hi; a flat series
+// sits in the middle.
+func sparkline(vals []float64, lo, hi float64) string {
+	var b strings.Builder
+	top := len(sparkBlocks) - 1
+	for _, v := range vals {
+		i := top / 2
+		if hi > lo {
+			i = int((v - lo) / (hi - lo) * float64(top))
+		}
+		b.WriteRune(sparkBlocks[i])
+	}
+	return b.String()
+}
+
+// dashboardHeatGrid draws first-hit headshot rates per weapon bucket ×
+// distance bin, colored against the player's overall FHHS: green at 5 points
+// or more above, red at 5 or more below, yellow between; faint under 20 first
+// hits, · where there are none.
+func dashboardHeatGrid(segs []model.PlayerDuelSegment, width int) []string {
+	title := color.New(color.Bold).Sprint(" FHHS heat-grid (weapon × distance)")
+	type key struct{ bucket, bin string }
+	cells := make(map[key]model.PlayerDuelSegment)
+	buckets := make(map[string]bool)
+	var hits, hsHits int
+	for _, s := range segs {
+		if s.DistanceBin == "unknown" || s.FirstHitCount == 0 {
+			continue
+		}
+		cells[key{s.WeaponBucket, s.DistanceBin}] = s
+		buckets[s.WeaponBucket] = true
+		hits += s.FirstHitCount
+		hsHits += s.FirstHitHSCount
+	}
+	if len(buckets) == 0 {
+		return []string{title, " " + missingHint("first-hit duels", 0)}
+	}
+	overall := float64(hsHits) / float64(hits) * 100
+
+	order := make([]string, 0, len(buckets))
+	for b := range buckets {
+		order = append(order, b)
+	}
+	sort.Slice(order, func(i, j int) bool {
+		oi, oj := bucketOrder(order[i]), bucketOrder(order[j])
+		if oi != oj {
+			return oi < oj
+		}
+		return order[i] < order[j]
+	})
+
+	const cellW = 8
+	header := fmt.Sprintf(" %-12s", "")
+	for _, bin := range dashboardBins {
+		header += fmt.Sprintf("%*s", cellW, bin)
+	}
+	out := []string{title, fitLine(color.New(color.Faint).Sprint(header), width)}
+	for _, b := range order {
+		line := fmt.Sprintf(" %-12s", b)
+		for _, bin := range dashboardBins {
+			s, ok := cells[key{b, bin}]
+			if !ok {
+				line += fmt.Sprintf("%*s", cellW, "·")
+				continue
+			}
+			pct := float64(s.FirstHitHSCount) / float64(s.FirstHitCount) * 100
+			txt := fmt.Sprintf("%*s", cellW, fmt.Sprintf("%.0f%%", pct))
+			switch {
+			case s.FirstHitCount < 20:
+				txt = color.New(color.Faint).Sprint(txt)
+			case pct >= overall+5:
+				txt = color.GreenString(txt)
+			case pct <= overall-5:
+				txt = color.RedString(txt)
+			default:
+				txt = color.YellowString(txt)
+			}
+			line += txt
+		}
+		out = append(out, fitLine(line, width))
+	}
+	return append(out, fitLine(color.New(color.Faint).Sprintf(" overall %.0f%% over %d first hits; faint = under 20", overall, hits), width))
+}
+
+// dashboardRecent lists up to room of the most recent matches, newest first.
+func dashboardRecent(matches []model.PlayerMatchStats, room, width int) []string {
+	if room < 3 || len(matches) == 0 {
+		return nil
+	}
+	n := min(len(matches), room-2)
+	out := []string{
+		color.New(color.Bold).Sprint(" Recent matches"),
+		fitLine(color.New(color.Faint).Sprintf(" %-10s  %-10s  %-7s  %-7s  %6s  %s", "DATE", "MAP", "RESULT", "K-D", "ADR", "RATING"), width),
+	}
+	for i := len(matches) - 1; i >= len(matches)-n; i-- {
+		s := matches[i]
+		lost := s.RoundsPlayed - s.RoundsWon
+		result := fmt.Sprintf("%d-%d", s.RoundsWon, lost)
+		switch {
+		case s.RoundsWon > lost:
+			result = color.GreenString("%-7s", "W "+result)
+		case s.RoundsWon < lost:
+			result = color.RedString("%-7s", "L "+result)
+		default:
+			result = fmt.Sprintf("%-7s", "D "+result)
+		}
+		line := fmt.Sprintf(" %-10s  %-10s  %s  %-7s  %6.1f  %s", s.MatchDate,
+			truncVisible(strings.TrimPrefix(s.MapName, "de_"), 10), result,
+			fmt.Sprintf("%d-%d", s.Kills, s.Deaths), s.ADR(), colorRating(s.Rating()))
+		out = append(out, fitLine(line, width))
+	}
+	return out
+}
+
+// colorRating formats a Rating 2.0 proxy value, green at 1.00 and above and
+// red below.
+func colorRating(r float64) string {
+	s := fmt.Sprintf("%.2f", r)
+	if r >= 1.0 {
+		return color.GreenString(s)
+	}
+	return color.RedString(s)
+}
+
+// visibleLen is the number of runes of s shown on screen, ignoring color codes.
+func visibleLen(s string) int {
+	return utf8.RuneCountInString(ansiRE.ReplaceAllString(s, ""))
+}
+
+// padVisible right-pads s with spaces to n visible cells.
+func padVisible(s string, n int) string {
+	if l := visibleLen(s); l < n {
+		return s + strings.Repeat(" ", n-l)
+	}
+	return s
+}
+
+// truncVisible cuts an uncolored s to at most n runes.
+func truncVisible(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// fitLine cuts a line wider than width, dropping its colors when it has to.
+func fitLine(s string, width int) string {
+	if visibleLen(s) <= width {
+		return s
+	}
+	return truncVisible(ansiRE.ReplaceAllString(s, ""), width)
+}
@@ -0,0 +1,166 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/fatih/color"
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// Diagnostic counts below lowEventRatio of the reference median per round are
+// flagged, once at least minDiagnosticRefs other demos have diagnostics.
+const (
+	lowEventRatio     = 0.5
+	minDiagnosticRefs = 5
+	// passTimingRows is how many of the slowest passes the timing table lists.
+	passTimingRows = 10
+	// highFrameDropPct is the share of dropped server frames (0-100) from
+	// which a demo is flagged as played on a struggling server.
+	highFrameDropPct = 2.0
+)
+
+// diagnosticEvent is one raw event count of a demo's diagnostics.
+type diagnosticEvent struct {
+	name    string
+	count   func(model.DemoDiagnostics) int
+	checked bool // flagged when low; flashes vary too much with the economy
+}
+
+var diagnosticEvents = []diagnosticEvent{
+	{"kills", func(d model.DemoDiagnostics) int { return d.Kills }, true},
+	{"damages", func(d model.DemoDiagnostics) int { return d.Damages }, true},
+	{"weapon fires", func(d model.DemoDiagnostics) int { return d.WeaponFires }, true},
+	{"first sights", func(d model.DemoDiagnostics) int { return d.FirstSights }, true},
+	{"flashes", func(d model.DemoDiagnostics) int { return d.Flashes }, false},
+	{"position samples", func(d model.DemoDiagnostics) int { return d.PositionSamples }, false},
+}
+
+// perRound returns n divided by the demo's round count (0 without rounds).
+func perRound(n int, d model.DemoDiagnostics) float64 {
+	if d.Rounds == 0 {
+		return 0
+	}
+	return float64(n) / float64(d.Rounds)
+}
+
+// referenceMedian returns the median per-round rate of ev over the reference
+// demos other than d (demos without rounds are skipped), and how many there
+// were.
+func referenceMedian(ev diagnosticEvent, d model.DemoDiagnostics, ref []model.DemoDiagnostics) (float64, int) {
+	var rates []float64
+	for _, r := range ref {
+		if r.DemoHash == d.DemoHash || r.Rounds == 0 {
+			continue
+		}
+		rates = append(rates, perRound(ev.count(r), r))
+	}
+	if len(rates) == 0 {
+		return 0, 0
+	}
+	sort.Float64s(rates)
+	n := len(rates)
+	if n%2 == 1 {
+		return rates[n/2], n
+	}
+	return (rates[n/2-1] + rates[n/2]) / 2, n
+}
+
+// lowEvent reports whether ev's per-round rate in d is anomalously low
+// against the reference demos.
+func lowEvent(ev diagnosticEvent, d model.DemoDiagnostics, median float64, n int) bool {
+	return ev.checked && d.Rounds > 0 && n >= minDiagnosticRefs && median > 0 &&
+		perRound(ev.count(d), d) < lowEventRatio*median
+}
+
+// DiagnosticsWarnings describes the event counts of a parsed demo that are
+// anomalously low per round compared with the stored demos in ref (the demo
+// itself is ignored if present), one line per event. Few weapon fires or
+// first sights usually mean a parser regression rather than an odd match.
+func DiagnosticsWarnings(d model.DemoDiagnostics, ref []model.DemoDiagnostics) []string {
+	var out []string
+	for _, ev := range diagnosticEvents {
+		median, n := referenceMedian(ev, d, ref)
+		if lowEvent(ev, d, median, n) {
+			out = append(out, fmt.Sprintf("few %s: %.1f/round vs median %.1f over %d demos — possible parser regression",
+				ev.name, perRound(ev.count(d), d), median, n))
+		}
+	}
+	return out
+}
+
+// PrintParseDiagnosticsTable prints a parsed demo's raw event counts with
+// their per-round rate against the median of the stored demos in ref, then
+// its slowest parse and aggregation passes.
+func PrintParseDiagnosticsTable(w io.Writer, d model.DemoDiagnostics, ref []model.DemoDiagnostics) {
+	table := TableData{
+		Title: "Parse Diagnostics",
+		Description: "COUNT=raw events the parser produced  /ROUND=per live round  MEDIAN=median /ROUND over the other stored demos\n" +
+			fmt.Sprintf("LOW=below %.0f%% of the median (needs %d reference demos); few fires or first sights usually mean a parser regression",
+				lowEventRatio*100, minDiagnosticRefs),
+	}
+	table.Headers = []string{"EVENT", "COUNT", "/ROUND", "MEDIAN", "FLAG"}
+	refs := 0
+	for _, ev := range diagnosticEvents {
+		median, n := referenceMedian(ev, d, ref)
+		refs = n
+		medianCell, flag := "-", ""
+		if n > 0 {
+			medianCell = fmt.Sprintf("%.1f", median)
+		}
+		if lowEvent(ev, d, median, n) {
+			flag = color.RedString("LOW")
+		}
+		table.Append(ev.name, strconv.Itoa(ev.count(d)), fmt.Sprintf("%.1f", perRound(ev.count(d), d)), medianCell, flag)
+	}
+	table.Notes = append(table.Notes, fmt.Sprintf("%d live rounds (%d pre-live discarded); reference: %d stored demos",
+		d.Rounds, d.PreLiveRounds, refs))
+	table.Notes = append(table.Notes, fmt.Sprintf("server frames dropped: %.2f%% (flagged from %.0f%%; demos carry no per-player packet loss)",
+		d.ServerFrameDropPct, highFrameDropPct))
+	emit(w, table)
+
+	printPassTimingTable(w, d)
+}
+
+// printPassTimingTable prints the slowest passes of a demo's parse and
+// aggregation with their share of the total.
+func printPassTimingTable(w io.Writer, d model.DemoDiagnostics) {
+	if len(d.Passes) == 0 {
+		return
+	}
+	total := d.TotalMs()
+	passes := append([]model.PassTiming(nil), d.Passes...)
+	sort.SliceStable(passes, func(i, j int) bool { return passes[i].Ms > passes[j].Ms })
+
+	table := TableData{
+		Title:       "Pass Timings (slowest first)",
+		Description: "MS=wall time of the parse or aggregation pass  SHARE=of the total across all passes",
+	}
+	table.Headers = []string{"PASS", "MS", "SHARE"}
+	shown := passes
+	if len(shown) > passTimingRows {
+		shown = shown[:passTimingRows]
+	}
+	for _, p := range shown {
+		table.Append(p.Pass, fmt.Sprintf("%.1f", p.Ms), fmtPct(p.Ms, total))
+	}
+	if rest := passes[len(shown):]; len(rest) > 0 {
+		var ms float64
+		for _, p := range rest {
+			ms += p.Ms
+		}
+		table.Notes = append(table.Notes, fmt.Sprintf("%d faster passes: %.1f ms", len(rest), ms))
+	}
+	table.Notes = append(table.Notes, fmt.Sprintf("total: %.1f ms over %d passes", total, len(passes)))
+	emit(w, table)
+}
+
+// fmtPct formats part as a percentage of total.
+func fmtPct(part, total float64) string {
+	if total <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%%", 100*part/total)
+}
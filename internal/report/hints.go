@@ -0,0 +1,78 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// dataVersion is the pipeline version of the stats being printed (the oldest
+// one when several demos are pooled), or -1 when the caller didn't say.
+var dataVersion = -1
+
+// SetDataVersion records the pipeline version the displayed stats were
+// produced with, so tables without data can tell a demo parsed before the
+// metric existed apart from a match where it simply never happened.
+func SetDataVersion(v int) {
+	dataVersion = v
+}
+
+// OldestPipelineVersion returns the lowest PipelineVersion among stats, or -1
+// when stats is empty.
+func OldestPipelineVersion(stats []model.PlayerMatchStats) int {
+	oldest := -1
+	for _, s := range stats {
+		if oldest < 0 || s.PipelineVersion < oldest {
+			oldest = s.PipelineVersion
+		}
+	}
+	return oldest
+}
+
+// reparseHint is the remedy appended to every stale-data hint.
+const reparseHint = "re-parse with `parse --force`"
+
+// missingHint explains an empty table. what names the missing data ("AWP
+// shots"); since is the pipeline version that started recording it, 0 for
+// data every version has. Stale data gets the version it needs and the
+// remedy; current data just says there was none.
+func missingHint(what string, since int) string {
+	switch {
+	case dataVersion >= 0 && dataVersion < since:
+		return fmt.Sprintf("no %s: needs pipeline ≥ v%d, data is from v%d — %s", what, since, dataVersion, reparseHint)
+	case dataVersion < 0 && since > 0:
+		return fmt.Sprintf("no %s (demos parsed before pipeline v%d lack it — %s)", what, since, reparseHint)
+	}
+	return fmt.Sprintf("no %s recorded", what)
+}
+
+// emitMissing prints a table that has no data as its title and a one-line hint.
+func emitMissing(w io.Writer, title, what string, since int) {
+	emit(w, TableData{Title: title, Hint: missingHint(what, since)})
+}
+
+// staleColumn names a column and the pipeline version that introduced it.
+type staleColumn struct {
+	name  string
+	since int
+}
+
+// staleNote returns a note naming the columns of cols the displayed data is
+// too old to fill, or "" when the data is current or its version unknown.
+func staleNote(cols ...staleColumn) string {
+	if dataVersion < 0 {
+		return ""
+	}
+	var stale []string
+	for _, c := range cols {
+		if dataVersion < c.since {
+			stale = append(stale, fmt.Sprintf("%s needs v%d", c.name, c.since))
+		}
+	}
+	if len(stale) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Note: %s; data is from pipeline v%d — %s.", strings.Join(stale, ", "), dataVersion, reparseHint)
+}
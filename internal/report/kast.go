@@ -0,0 +1,68 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+)
+
+// KAST definitions selectable with --kast.
+const (
+	// KASTStandard counts every round with a kill, assist, survival or trade.
+	KASTStandard = "standard"
+	// KASTStrict does not count surviving a lost round without dealing damage
+	// (a save), as some stats sites do.
+	KASTStrict = "strict"
+)
+
+// KASTModes lists the accepted --kast values.
+var KASTModes = []string{KASTStandard, KASTStrict}
+
+// kastMode is the definition KAST% columns show.
+var kastMode = KASTStandard
+
+// kastStrictSince is the pipeline version that stores strict KAST rounds.
+const kastStrictSince = 46
+
+// kastStaleColumn notes a strict KAST% on data stored before it existed.
+var kastStaleColumn = staleColumn{"KAST% (strict)", kastStrictSince}
+
+// SetKAST selects the KAST definition of the KAST% columns in the
+// performance overviews, the trend table and the dashboard. Side, half and
+// map tables and the KAST_SD spread keep the standard definition.
+func SetKAST(mode string) error {
+	for _, m := range KASTModes {
+		if mode == m {
+			kastMode = mode
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown KAST definition %q (want one of: %s)", mode, strings.Join(KASTModes, ", "))
+}
+
+// kastPct returns the KAST percentage under the selected definition, from the
+// standard and strict KAST round counts.
+func kastPct(rounds, strictRounds, played int) float64 {
+	if played == 0 {
+		return 0
+	}
+	if kastMode == KASTStrict {
+		rounds = strictRounds
+	}
+	return float64(rounds) / float64(played) * 100
+}
+
+// kastLegend describes the KAST% column under the selected definition.
+func kastLegend() string {
+	if kastMode == KASTStrict {
+		return "KAST%=rounds with a Kill/Assist/Survival/Trade, no-damage survivals of lost rounds excluded (strict)"
+	}
+	return "KAST%=rounds with a Kill/Assist/Survival/Trade"
+}
+
+// kastNote returns the stale-data note for a strict KAST% column, or "".
+func kastNote() string {
+	if kastMode != KASTStrict {
+		return ""
+	}
+	return staleNote(kastStaleColumn)
+}
@@ -0,0 +1,134 @@
+package report
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// identityColumns are always kept by column selection so rows stay attributable.
+var identityColumns = map[string]bool{" ": true, "NAME": true, "PLAYER": true}
+
+// columnFilter and sortColumn hold the --columns / --sort-by settings applied
+// to every emitted table.
+var (
+	columnFilter []string
+	sortColumn   string
+	sortAsc      bool
+)
+
+// SetColumns restricts tables to the named columns (case-insensitive), in the
+// order given, plus the marker and player-name columns. A table that has none
+// of the named columns is left whole. An empty list shows every column.
+func SetColumns(cols []string) {
+	columnFilter = nil
+	for _, c := range cols {
+		if c = strings.ToUpper(strings.TrimSpace(c)); c != "" {
+			columnFilter = append(columnFilter, c)
+		}
+	}
+}
+
+// SetSortBy orders the rows of per-player tables by the named column,
+// highest first, or lowest first with an ":asc" suffix (e.g. "ADR",
+// "rating:asc"). Tables without the column keep their order. Empty disables.
+func SetSortBy(spec string) {
+	col, dir, _ := strings.Cut(strings.TrimSpace(spec), ":")
+	sortColumn = strings.ToUpper(col)
+	sortAsc = strings.EqualFold(dir, "asc")
+}
+
+// shape applies the column selection and sort order to t.
+func shape(t TableData) TableData {
+	if sortColumn != "" && t.Sortable {
+		if i := columnIndex(t.Headers, sortColumn); i >= 0 {
+			t = sortRows(t, i, sortAsc)
+		}
+	}
+	if len(columnFilter) > 0 {
+		t = selectColumns(t, columnFilter)
+	}
+	return t
+}
+
+// columnIndex returns the index of the header equal to col, ignoring case, or -1.
+func columnIndex(headers []string, col string) int {
+	for i, h := range headers {
+		if strings.EqualFold(h, col) {
+			return i
+		}
+	}
+	return -1
+}
+
+// selectColumns keeps the identity columns (in place) followed by the
+// requested columns in request order. Tables with no requested column are
+// returned unchanged.
+func selectColumns(t TableData, cols []string) TableData {
+	var keep []int
+	for i, h := range t.Headers {
+		if identityColumns[h] {
+			keep = append(keep, i)
+		}
+	}
+	matched := false
+	for _, c := range cols {
+		if i := columnIndex(t.Headers, c); i >= 0 && !identityColumns[t.Headers[i]] {
+			keep = append(keep, i)
+			matched = true
+		}
+	}
+	if !matched {
+		return t
+	}
+	return pickColumns(t, keep)
+}
+
+// sortRows stably sorts t.Rows by column i. Numeric cells ("52%", "310ms",
+// "1.4°", "1.12") compare by value; missing values ("—") always sort last;
+// other text compares alphabetically.
+func sortRows(t TableData, i int, asc bool) TableData {
+	rows := append([][]string(nil), t.Rows...)
+	sort.SliceStable(rows, func(a, b int) bool {
+		va, oka := cellNumber(rows[a], i)
+		vb, okb := cellNumber(rows[b], i)
+		switch {
+		case oka && okb:
+			if asc {
+				return va < vb
+			}
+			return va > vb
+		case oka != okb:
+			return oka // numbers before missing/text
+		}
+		sa, sb := cellText(rows[a], i), cellText(rows[b], i)
+		if sa == "—" || sb == "—" {
+			return sb == "—" && sa != "—"
+		}
+		if asc {
+			return sa < sb
+		}
+		return sa > sb
+	})
+	t.Rows = rows
+	return t
+}
+
+// cellText returns the color-stripped cell i of row, or "" when out of range.
+func cellText(row []string, i int) string {
+	if i >= len(row) {
+		return ""
+	}
+	return plain(row[i])
+}
+
+// cellNumber parses cell i of row as a number after stripping color codes and
+// a unit suffix. ok is false for missing or non-numeric cells.
+func cellNumber(row []string, i int) (float64, bool) {
+	s := cellText(row, i)
+	for _, suffix := range []string{"%", "ms", "°"} {
+		s = strings.TrimSuffix(s, suffix)
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	return v, err == nil
+}
@@ -0,0 +1,117 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+)
+
+// withLayout sets --columns and --sort-by for one test and restores them after.
+func withLayout(t *testing.T, cols []string, sortBy string) {
+	t.Helper()
+	oldCols, oldSort, oldAsc := columnFilter, sortColumn, sortAsc
+	t.Cleanup(func() { columnFilter, sortColumn, sortAsc = oldCols, oldSort, oldAsc })
+	SetColumns(cols)
+	SetSortBy(sortBy)
+}
+
+// playerTable is a sortable per-player table with a marker column.
+func playerTable() TableData {
+	return TableData{
+		Headers: []string{" ", "NAME", "K", "ADR", "KAST%"},
+		Rows: [][]string{
+			{"", "alpha", "20", "81.5", "70%"},
+			{"▶", "bravo", "25", "102.0", "9%"},
+			{"", "charlie", "20", "—", "75%"},
+			{"", "delta", "\x1b[32m31\x1b[0m", "64.0", "—"},
+		},
+		Sortable: true,
+	}
+}
+
+// column returns cell i of every row.
+func column(t TableData, i int) []string {
+	out := make([]string, len(t.Rows))
+	for r, row := range t.Rows {
+		out[r] = plain(row[i])
+	}
+	return out
+}
+
+func TestShapeColumns(t *testing.T) {
+	cases := []struct {
+		name        string
+		cols        []string
+		wantHeaders []string
+	}{
+		{"no selection", nil, []string{" ", "NAME", "K", "ADR", "KAST%"}},
+		{"request order, identity kept in place", []string{"kast%", " adr "}, []string{" ", "NAME", "KAST%", "ADR"}},
+		{"unknown columns are ignored", []string{"HS%", "adr", "bogus"}, []string{" ", "NAME", "ADR"}},
+		{"only unknown columns leave the table whole", []string{"HS%", "bogus"}, []string{" ", "NAME", "K", "ADR", "KAST%"}},
+		{"identity columns alone do not select", []string{"name"}, []string{" ", "NAME", "K", "ADR", "KAST%"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			withLayout(t, c.cols, "")
+			got := shape(playerTable())
+			if !reflect.DeepEqual(got.Headers, c.wantHeaders) {
+				t.Fatalf("headers = %q, want %q", got.Headers, c.wantHeaders)
+			}
+			for _, row := range got.Rows {
+				if len(row) != len(c.wantHeaders) {
+					t.Errorf("row %q has %d cells, want %d", row, len(row), len(c.wantHeaders))
+				}
+			}
+			if i := columnIndex(got.Headers, "NAME"); !reflect.DeepEqual(column(got, i), []string{"alpha", "bravo", "charlie", "delta"}) {
+				t.Errorf("names = %q, rows reordered or dropped", column(got, i))
+			}
+		})
+	}
+}
+
+func TestShapeSort(t *testing.T) {
+	cases := []struct {
+		name   string
+		sortBy string
+		want   []string // NAME column after sorting
+	}{
+		{"highest first by default", "K", []string{"delta", "bravo", "alpha", "charlie"}},
+		{"ascending, ties keep their order", "k:asc", []string{"alpha", "charlie", "bravo", "delta"}},
+		{"descending ties keep their order", "k:desc", []string{"delta", "bravo", "alpha", "charlie"}},
+		{"numbers before missing, descending", "ADR", []string{"bravo", "alpha", "delta", "charlie"}},
+		{"numbers before missing, ascending", "adr:asc", []string{"delta", "alpha", "bravo", "charlie"}},
+		{"units compare by value", "KAST%", []string{"charlie", "alpha", "bravo", "delta"}},
+		{"text column", "name:asc", []string{"alpha", "bravo", "charlie", "delta"}},
+		{"unknown column keeps the order", "HS%", []string{"alpha", "bravo", "charlie", "delta"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			withLayout(t, nil, c.sortBy)
+			if got := column(shape(playerTable()), 1); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("sorted by %q = %q, want %q", c.sortBy, got, c.want)
+			}
+		})
+	}
+
+	t.Run("tables not marked sortable keep their order", func(t *testing.T) {
+		withLayout(t, nil, "K")
+		tbl := playerTable()
+		tbl.Sortable = false
+		if got := column(shape(tbl), 1); !reflect.DeepEqual(got, []string{"alpha", "bravo", "charlie", "delta"}) {
+			t.Errorf("rows = %q, want the original order", got)
+		}
+	})
+
+	t.Run("sort then select", func(t *testing.T) {
+		withLayout(t, []string{"ADR"}, "K:asc")
+		got := shape(playerTable())
+		want := [][]string{
+			{"", "alpha", "81.5"},
+			{"", "charlie", "—"},
+			{"▶", "bravo", "102.0"},
+			{"", "delta", "64.0"},
+		}
+		if !reflect.DeepEqual(got.Rows, want) {
+			t.Errorf("rows = %q, want %q", got.Rows, want)
+		}
+	})
+}
@@ -1,5 +1,6 @@
-// Package report formats and prints player, match, and aggregate statistics
-// as terminal tables using tablewriter.
+// Package report formats player, match, and aggregate statistics as tables.
+// Each Print* function builds a TableData and emits it through the active
+// Renderer (terminal tables by default; CSV, JSON, or HTML via SetFormat).
 package report
 
 import (
@@ -10,10 +11,9 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
-	"github.com/olekukonko/tablewriter"
-	"github.com/olekukonko/tablewriter/tw"
 	"github.com/pable/go-cs-metrics/internal/model"
 )
 
@@ -25,7 +25,7 @@ var Verbose = true
 // explanation of the columns that follow.
 func printSection(w io.Writer, title, desc string) {
 	fmt.Fprintf(w, "\n%s\n", color.New(color.Bold).Sprintf("--- %s ---", title))
-	if Verbose {
+	if Verbose && desc != "" {
 		fmt.Fprintf(w, "%s\n", desc)
 	}
 }
@@ -69,33 +69,325 @@ func colorRoundFlag(flag string) string {
 	}
 }
 
-// PrintMatchSummary prints a one-line summary header for the match.
+// PrintMatchSummary prints a one-line summary header for the match, followed
+// by a line with the demo's source when one is recorded. Non-terminal formats
+// get it as a one-row "Match" table instead.
 func PrintMatchSummary(w io.Writer, s model.MatchSummary) {
-	fmt.Fprintf(w, "\nMap: %s  |  Date: %s  |  Type: %s  |  Score: %s %d – %s %d  |  Hash: %s\n\n",
+	if !isTerminal() {
+		table := TableData{
+			Title:   "Match",
+			Headers: []string{"MAP", "DATE", "TYPE", "CT", "T", "HASH", "SOURCE", "PATH", "URL", "SHARE_CODE", "MATCH_ID"},
+		}
+		src := s.Source
+		table.Append(s.MapName, s.MatchDate, s.MatchType, strconv.Itoa(s.CTScore), strconv.Itoa(s.TScore), s.DemoHash[:12],
+			src.Origin, src.Path, src.URL, src.ShareCode, src.MatchID)
+		emit(w, table)
+		return
+	}
+	fmt.Fprintf(w, "\nMap: %s  |  Date: %s  |  Type: %s  |  Score: %s %d – %s %d  |  Hash: %s\n",
 		s.MapName, s.MatchDate, s.MatchType,
 		color.CyanString("CT"), s.CTScore,
 		color.YellowString("T"), s.TScore,
 		s.DemoHash[:12])
+	if line := sourceLine(s.Source); line != "" {
+		fmt.Fprintf(w, "Source: %s\n", line)
+	}
+	fmt.Fprintln(w)
+}
+
+// sourceLine joins the recorded fields of a demo's source for the terminal
+// summary header; empty when nothing is recorded.
+func sourceLine(src model.DemoSource) string {
+	var parts []string
+	for _, f := range []struct{ label, v string }{
+		{"", src.Origin}, {"path", src.Path}, {"url", src.URL},
+		{"share code", src.ShareCode}, {"match id", src.MatchID},
+	} {
+		switch {
+		case f.v == "":
+		case f.label == "":
+			parts = append(parts, f.v)
+		default:
+			parts = append(parts, f.label+" "+f.v)
+		}
+	}
+	return strings.Join(parts, "  |  ")
+}
+
+// overtimeHalfRounds is the overtime half length assumed when a match ends
+// before its first overtime side switch.
+const overtimeHalfRounds = 3
+
+// matchSides is the side played by the team that started on CT in each stored
+// round, with the half lengths found from its side switches: the first switch
+// ends the first regulation half (12 rounds in CS2, 15 in MR15 CS:GO), the
+// first switch after regulation ends the first overtime half. Teams keep their
+// side from the end of regulation into overtime, so those halves are split by
+// length rather than by a switch.
+type matchSides struct {
+	side    map[int]model.Team
+	regHalf int // rounds in a regulation half; 0 when the sides never switch
+	otHalf  int // rounds in an overtime half; 0 until an overtime switch is seen
+}
+
+// newMatchSides reads the sides from the outcomes' StartedCTSide.
+func newMatchSides(outcomes []model.RoundOutcome) matchSides {
+	m := matchSides{side: make(map[int]model.Team, len(outcomes))}
+	prev := model.TeamUnknown
+	for _, o := range outcomes {
+		if o.StartedCTSide == model.TeamUnknown {
+			continue
+		}
+		m.side[o.RoundNumber] = o.StartedCTSide
+		if prev != model.TeamUnknown && o.StartedCTSide != prev {
+			switch {
+			case m.regHalf == 0:
+				m.regHalf = o.RoundNumber - 1
+			case m.otHalf == 0 && o.RoundNumber > 2*m.regHalf+1:
+				m.otHalf = o.RoundNumber - 2*m.regHalf - 1
+			}
+		}
+		prev = o.StartedCTSide
+	}
+	return m
+}
+
+// half returns the 0-based half of round n: 0 and 1 for regulation, 2 onwards
+// for overtime halves. Everything is the first half when the sides never
+// switch.
+func (m matchSides) half(n int) int {
+	switch {
+	case m.regHalf == 0 || n <= m.regHalf:
+		return 0
+	case n <= 2*m.regHalf:
+		return 1
+	}
+	ot := m.otHalf
+	if ot == 0 {
+		ot = overtimeHalfRounds
+	}
+	return 2 + (n-2*m.regHalf-1)/ot
+}
+
+// sideOf returns the side of the team that started on CT in round n: the
+// stored side, else that of the latest earlier stored round of the same half,
+// else CT for the first half and T for the second.
+func (m matchSides) sideOf(n int) model.Team {
+	h := m.half(n)
+	for r := n; r >= 1 && m.half(r) == h; r-- {
+		if s, ok := m.side[r]; ok {
+			return s
+		}
+	}
+	if h == 1 {
+		return model.TeamT
+	}
+	return model.TeamCT
+}
+
+// halfLabel names a half for the progression strip: H1, H2, OT1a, OT1b, ...
+func halfLabel(half int) string {
+	if half < 2 {
+		return fmt.Sprintf("H%d", half+1)
+	}
+	ot := half - 2
+	return fmt.Sprintf("OT%d%c", ot/2+1, 'a'+ot%2)
+}
+
+// progressionHalf is one half of the round progression strip.
+type progressionHalf struct {
+	half       int
+	side       model.Team // side of the team that started on CT
+	marks      string     // ✓ won / ✗ lost / · no stored outcome, per round
+	wins, loss int        // running score after the half, from that team's view
+}
+
+// roundProgression groups outcomes into halves from the view of the team that
+// started on CT, splitting them at that team's stored side switches (see
+// matchSides).
+func roundProgression(outcomes []model.RoundOutcome) []progressionHalf {
+	if len(outcomes) == 0 {
+		return nil
+	}
+	sides := newMatchSides(outcomes)
+	winner := make(map[int]model.Team, len(outcomes))
+	last := 0
+	for _, o := range outcomes {
+		winner[o.RoundNumber] = o.WinnerTeam
+		if o.RoundNumber > last {
+			last = o.RoundNumber
+		}
+	}
+	var out []progressionHalf
+	wins, loss := 0, 0
+	for n := 1; n <= last; n++ {
+		h := sides.half(n)
+		if len(out) == 0 || out[len(out)-1].half != h {
+			out = append(out, progressionHalf{half: h, side: sides.sideOf(n)})
+		}
+		cur := &out[len(out)-1]
+		w, ok := winner[n]
+		switch {
+		case !ok:
+			cur.marks += "·"
+		case w == sides.sideOf(n):
+			wins++
+			cur.marks += color.GreenString("✓")
+		default:
+			loss++
+			cur.marks += color.RedString("✗")
+		}
+		cur.wins, cur.loss = wins, loss
+	}
+	return out
+}
+
+// PrintRoundProgression prints the round-by-round result strip under the match
+// summary: ✓/✗ per round for the team that started on CT, with the running
+// score after each half. Prints nothing when no round outcomes are stored.
+func PrintRoundProgression(w io.Writer, outcomes []model.RoundOutcome) {
+	halves := roundProgression(outcomes)
+	if len(halves) == 0 {
+		return
+	}
+	if !isTerminal() {
+		table := TableData{
+			Title:       "Round Progression",
+			Description: "Round results for the team that started on CT: ✓=won ✗=lost ·=no stored outcome; SCORE=running score after the half",
+			Headers:     []string{"HALF", "SIDE", "ROUNDS", "SCORE"},
+		}
+		for _, h := range halves {
+			table.Append(halfLabel(h.half), h.side.String(), h.marks, fmt.Sprintf("%d-%d", h.wins, h.loss))
+		}
+		emit(w, table)
+		return
+	}
+	parts := make([]string, len(halves))
+	for i, h := range halves {
+		parts[i] = fmt.Sprintf("%s %s %d-%d", colorSide(h.side.String()), h.marks, h.wins, h.loss)
+	}
+	fmt.Fprintf(w, "Rounds (team that started %s):  %s\n\n", colorSide("CT"), strings.Join(parts, "  │  "))
 }
 
 // PrintPlayerRosterTable prints a compact name → SteamID64 listing so the user
 // can identify which ID to pass to commands like "rounds <hash> <steamid>".
 func PrintPlayerRosterTable(w io.Writer, stats []model.PlayerMatchStats) {
-	fmt.Fprintf(w, "Players (use SteamID with: rounds <hash-prefix> <steamid>)\n")
-	table := tablewriter.NewTable(w, tablewriter.WithConfig(tablewriter.Config{
-		Row: tw.CellConfig{
-			Alignment: tw.CellAlignment{Global: tw.AlignLeft},
-		},
-		Header: tw.CellConfig{
-			Alignment: tw.CellAlignment{Global: tw.AlignLeft},
-		},
-	}))
-	table.Header("TEAM", "NAME", "STEAM_ID")
+	table := TableData{
+		Title:   "Players (use SteamID with: rounds <hash-prefix> <steamid>)",
+		Headers: []string{"TEAM", "NAME", "STEAM_ID", "PING"},
+		Inline:  true,
+	}
 	for _, s := range stats {
-		table.Append(colorSide(s.Team.String()), s.Name, strconv.FormatUint(s.SteamID, 10))
+		name := s.Name
+		if s.TeamConflictRounds > 0 {
+			name += color.YellowString(" ⚠")
+		}
+		table.Append(colorSide(s.Team.String()), name, strconv.FormatUint(s.SteamID, 10), pingCell(s))
 	}
-	table.Render()
-	fmt.Fprintln(w)
+	for _, msg := range TeamConflictWarnings(stats) {
+		table.Notes = append(table.Notes, "⚠ data quality: "+msg)
+	}
+	for _, msg := range ConnectionWarnings(stats) {
+		table.Notes = append(table.Notes, "⚠ connection: "+msg)
+	}
+	emit(w, table)
+}
+
+// pingCell formats a player's mean and peak ping as "avg (max)" in ms, yellow
+// from model.HighPingMs, or "—" for demos parsed before ping was read.
+func pingCell(s model.PlayerMatchStats) string {
+	if s.PingSamples == 0 {
+		return "—"
+	}
+	cell := fmt.Sprintf("%.0f (%d)", s.AvgPingMs, s.MaxPingMs)
+	if s.HighPing() {
+		return color.YellowString(cell)
+	}
+	return cell
+}
+
+// ConnectionWarnings describes each player whose mean ping reached
+// model.HighPingMs, so their numbers in the match may reflect lag.
+func ConnectionWarnings(stats []model.PlayerMatchStats) []string {
+	var out []string
+	for _, s := range stats {
+		if s.HighPing() {
+			out = append(out, fmt.Sprintf("%s (%d) averaged %.0f ms ping (peak %d ms); duels and reactions may reflect lag rather than form",
+				s.Name, s.SteamID, s.AvgPingMs, s.MaxPingMs))
+		}
+	}
+	return out
+}
+
+// TeamConflictWarnings describes each player whose SteamID was seen on both
+// teams within a round (coach slot or shared account in a broken demo).
+func TeamConflictWarnings(stats []model.PlayerMatchStats) []string {
+	var out []string
+	for _, s := range stats {
+		if s.TeamConflictRounds > 0 {
+			out = append(out, fmt.Sprintf("%s (%d) seen on both teams in %d round(s); rounds attributed by per-round team, stats may be unreliable",
+				s.Name, s.SteamID, s.TeamConflictRounds))
+		}
+	}
+	return out
+}
+
+// SuppressedAccountWarnings describes each coach or spectator slot left out
+// of a demo's stats (see aggregator.SuppressSpectators).
+func SuppressedAccountWarnings(rows []model.SuppressedAccount) []string {
+	out := make([]string, 0, len(rows))
+	for _, r := range rows {
+		name := r.Name
+		if name == "" {
+			name = "(unnamed)"
+		}
+		out = append(out, fmt.Sprintf("%s (%d) excluded from stats as a coach/spectator slot: %s in %d round(s), no kills, damage or shots",
+			name, r.SteamID, r.Reason, r.Rounds))
+	}
+	return out
+}
+
+// PreLiveRoundWarnings describes the rounds a demo recorded before the match
+// went live (see model.RawMatch.PreLiveRounds), as one line, or none.
+func PreLiveRoundWarnings(n int) []string {
+	if n == 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf("%d round(s) before the match went live (knife round, restarts) discarded; round 1 is the first live round", n)}
+}
+
+// ServerFrameDropWarnings describes a demo whose server dropped at least
+// highFrameDropPct of its frames (see model.RawMatch.ServerFrameDropPct), as
+// one line, or none.
+func ServerFrameDropWarnings(pct float64) []string {
+	if pct < highFrameDropPct {
+		return nil
+	}
+	return []string{fmt.Sprintf("server dropped %.1f%% of frames; every player's timing stats may be affected", pct)}
+}
+
+// EntityEventWarnings describes the events dropped because no real player
+// took part (see model.RawMatch.EntityEventsDropped), as one line, or none.
+func EntityEventWarnings(n int) []string {
+	if n == 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf("%d event(s) between bots or the world (SteamID 0) dropped; kills and damage between a player and a bot are kept", n)}
+}
+
+// UnmappedWeaponWarnings describes the weapons of a demo that have no weapon
+// bucket (see aggregator.UnmappedWeapons), as one line, or none when all are
+// known.
+func UnmappedWeaponWarnings(rows []model.UnmappedWeapon) []string {
+	if len(rows) == 0 {
+		return nil
+	}
+	parts := make([]string, len(rows))
+	for i, r := range rows {
+		parts[i] = fmt.Sprintf("%s (%d events)", r.Weapon, r.Events)
+	}
+	return []string{fmt.Sprintf("weapons with no bucket, counted as \"Other\": %s; add them to weaponBuckets (internal/aggregator/weapons.go)",
+		strings.Join(parts, ", "))}
 }
 
 // PrintPlayerTable prints the player stats table to stdout.
@@ -106,25 +398,23 @@ func PrintPlayerTable(stats []model.PlayerMatchStats, focusSteamID uint64) {
 
 // PrintPlayerTableTo writes the table to the provided writer.
 func PrintPlayerTableTo(w io.Writer, stats []model.PlayerMatchStats, focusSteamID uint64) {
-	printSection(w, "Performance Overview",
-		"K=Kills  A=Assists  D=Deaths  K/D=kill-death ratio  HS%=headshot kill %  ADR=avg damage per round\n"+
-			"KAST%=rounds with a Kill/Assist/Survival/Trade  ROLE=heuristic role (AWPer/Entry/Support/Rifler)\n"+
-			"ENTRY_K/D=first kill/death of the round  TRADE_K/D=kill traded within 5s\n"+
-			"FA=flash assists  EFF_FLASH=blinded enemy died to your team within 1.5s\n"+
-			"UTIL_DMG=HE/molotov damage  XHAIR_MED=median crosshair deviation at first sight (lower = better pre-aim)")
-	table := tablewriter.NewTable(w, tablewriter.WithConfig(tablewriter.Config{
-		Row: tw.CellConfig{
-			Alignment: tw.CellAlignment{Global: tw.AlignRight},
-		},
-		Header: tw.CellConfig{
-			Alignment: tw.CellAlignment{Global: tw.AlignCenter},
-		},
-	}))
-
-	table.Header(
-		" ", "NAME", "ROLE", "K", "A", "D", "K/D", "HS%", "ADR", "KAST%",
+	table := TableData{
+		Title:    "Performance Overview",
+		Sortable: true,
+		Description: "K=Kills  A=Assists  D=Deaths  K/D=kill-death ratio  HS%=headshot kill %  ADR=avg damage per round\n" +
+			headHitLegend + ecoADRLegend +
+			kastLegend() + "  RATING=Rating 2.0 proxy  ROLE=heuristic role (AWPer/Entry/Support/Rifler)\n" +
+			"ENTRY_K/D=first kill/death of the round  TRADE_K/D=kill traded within 5s\n" +
+			"FA=flash assists  EFF_FLASH=blinded enemy died to your team within 1.5s\n" +
+			"UTIL_DMG=HE/molotov damage  XHAIR_MED=median crosshair deviation at first sight (lower = better pre-aim)\n" +
+			"LOWHP_HAND=enemies you left under 20 HP that a teammate finished  LOWHP_WASTE=same, but the enemy survived (or died to something else)",
+	}
+
+	table.Headers = []string{
+		" ", "NAME", "ROLE", "K", "A", "D", "K/D", "HS%", "HEAD_HIT%", "ADR", "ADR_BUY", "ADR_ECO", "KAST%", "RATING",
 		"ENTRY_K", "ENTRY_D", "TRADE_K", "TRADE_D", "FA", "EFF_FLASH", "UTIL_DMG", "XHAIR_MED",
-	)
+		"LOWHP_HAND", "LOWHP_WASTE",
+	}
 
 	for _, s := range stats {
 		marker := " "
@@ -148,8 +438,12 @@ func PrintPlayerTableTo(w io.Writer, stats []model.PlayerMatchStats, focusSteamI
 			strconv.Itoa(s.Deaths),
 			colorKD(s.KDRatio()),
 			fmt.Sprintf("%.0f%%", s.HSPercent()),
+			headHitCell(s.BulletHits, s.HeadHitPct()),
 			fmt.Sprintf("%.1f", s.ADR()),
-			fmt.Sprintf("%.0f%%", s.KASTPct()),
+			roundADRCell(s.BuyRounds, s.BuyADR()),
+			roundADRCell(s.EcoRounds, s.EcoADR()),
+			fmt.Sprintf("%.0f%%", kastPct(s.KASTRounds, s.KASTStrictRounds, s.RoundsPlayed)),
+			fmt.Sprintf("%.2f", s.Rating()),
 			strconv.Itoa(s.OpeningKills),
 			strconv.Itoa(s.OpeningDeaths),
 			strconv.Itoa(s.TradeKills),
@@ -158,9 +452,45 @@ func PrintPlayerTableTo(w io.Writer, stats []model.PlayerMatchStats, focusSteamI
 			strconv.Itoa(s.EffectiveFlashes),
 			strconv.Itoa(s.UtilityDamage),
 			xhairStr,
+			strconv.Itoa(s.LowHPHanded),
+			strconv.Itoa(s.LowHPWasted),
 		)
 	}
-	table.Render()
+	if n := staleNote(headHitStaleColumn, ecoADRStaleColumn); n != "" {
+		table.Notes = append(table.Notes, n)
+	}
+	if n := kastNote(); n != "" {
+		table.Notes = append(table.Notes, n)
+	}
+	emit(w, table)
+}
+
+// headHitLegend explains the HEAD_HIT% column of the overview tables.
+const headHitLegend = "HEAD_HIT%=bullet hits on enemies that landed on the head (every hit, not just the kill; high HEAD_HIT% with low HS% = finishing problem, not aim)\n"
+
+// headHitStaleColumn notes HEAD_HIT% on data stored before it was recorded.
+var headHitStaleColumn = staleColumn{"HEAD_HIT%", 41}
+
+// ecoADRLegend explains the ADR_BUY and ADR_ECO columns of the overview tables.
+const ecoADRLegend = "ADR_BUY/ADR_ECO=ADR in rounds the enemy forced or bought / saved (full or semi eco; pistol rounds in neither) — ADR_ECO far above ADR_BUY = damage padded on saves\n"
+
+// ecoADRStaleColumn notes ADR_BUY and ADR_ECO on data stored before the split.
+var ecoADRStaleColumn = staleColumn{"ADR_BUY/ADR_ECO", 43}
+
+// roundADRCell formats an ADR over a subset of rounds, "—" without rounds.
+func roundADRCell(rounds int, adr float64) string {
+	if rounds == 0 {
+		return "—"
+	}
+	return fmt.Sprintf("%.1f", adr)
+}
+
+// headHitCell formats a head-hit share, "—" without bullet hits.
+func headHitCell(hits int, pct float64) string {
+	if hits == 0 {
+		return "—"
+	}
+	return fmt.Sprintf("%.0f%%", pct)
 }
 
 // PrintPlayerSideTable prints per-side (CT/T) basic stats for all players in a match.
@@ -170,15 +500,13 @@ func PrintPlayerSideTable(w io.Writer, sides []model.PlayerSideStats, focusSteam
 	if len(sides) == 0 {
 		return
 	}
-	printSection(w, "Per-Side Breakdown",
-		"Stats split by CT and T halves for each player in this match.\n"+
-			"K/A/D and ADR derived from round-level data. KAST/ENTRY/TRADE as per Performance Overview.")
-	table := tablewriter.NewTable(w, tablewriter.WithConfig(tablewriter.Config{
-		Row:    tw.CellConfig{Alignment: tw.CellAlignment{Global: tw.AlignRight}},
-		Header: tw.CellConfig{Alignment: tw.CellAlignment{Global: tw.AlignCenter}},
-	}))
-	table.Header(" ", "NAME", "SIDE", "K", "A", "D", "K/D", "ADR", "KAST%",
-		"ENTRY_K", "ENTRY_D", "TRADE_K", "TRADE_D")
+	table := TableData{
+		Title: "Per-Side Breakdown",
+		Description: "Stats split by CT and T halves for each player in this match.\n" +
+			"K/A/D and ADR derived from round-level data. KAST/ENTRY/TRADE as per Performance Overview.",
+	}
+	table.Headers = []string{" ", "NAME", "SIDE", "K", "A", "D", "K/D", "ADR", "KAST%",
+		"ENTRY_K", "ENTRY_D", "TRADE_K", "TRADE_D"}
 
 	var lastID uint64
 	for _, s := range sides {
@@ -207,26 +535,52 @@ func PrintPlayerSideTable(w io.Writer, sides []model.PlayerSideStats, focusSteam
 			strconv.Itoa(s.TradeDeaths),
 		)
 	}
-	table.Render()
+	emit(w, table)
+}
+
+// assistedDuelLegend explains the assisted-duel columns of the duel tables.
+const assistedDuelLegend = "ASSIST_W=wins where a teammate had done ≥ 41 damage to the victim in the 5s before  CLEAN_W%=win rate over clean 1v1 duels (assisted wins and losses left out)\n"
+
+// infoDeathLegend explains the INFO_DEATH% column of the duel tables.
+const infoDeathLegend = "INFO_DEATH%=deaths to an enemy nobody on your team had spotted that round (information failure, not a lost fight)\n"
+
+// infoDeathStaleColumn notes INFO_DEATH% on data stored before it was recorded.
+var infoDeathStaleColumn = staleColumn{"INFO_DEATH%", 44}
+
+// infoDeathCell formats the share of deaths to unspotted enemies, "—" without
+// deaths to an enemy.
+func infoDeathCell(unspotted, spotted int, pct float64) string {
+	if unspotted+spotted == 0 {
+		return "—"
+	}
+	return fmt.Sprintf("%.0f%%", pct)
+}
+
+// cleanDuelCell formats the clean 1v1 duel win rate, or "—" without clean duels.
+func cleanDuelCell(wins, losses, assistedWins, assistedLosses int) string {
+	pct, ok := model.CleanDuelWinPct(wins, losses, assistedWins, assistedLosses)
+	if !ok {
+		return "—"
+	}
+	return fmt.Sprintf("%.0f%%", pct)
 }
 
 // PrintDuelTable prints the duel intelligence table.
-// Columns: PLAYER | W | L | EXPO_WIN | EXPO_LOSS | HITS/K | 1ST_HS% | CORRECTION | <2°%
+// Columns: PLAYER | W | L | ASSIST_W | CLEAN_W% | EXPO_WIN | EXPO_LOSS | SPOTTED | INFO_DEATH% | TTDMG | HITS/K | 1ST_HS% | CORRECTION | <2°%
 func PrintDuelTable(w io.Writer, stats []model.PlayerMatchStats, focusSteamID uint64) {
-	printSection(w, "Duel Intelligence",
-		"W/L=duel wins and losses  EXPO_WIN=median ms from enemy visible to your kill (lower = faster)\n"+
-			"EXPO_LOSS=same for duels lost  HITS/K=median bullets to kill  1ST_HS%=% of won duels where first shot hit the head\n"+
-			"CORRECTION=degrees of crosshair adjustment before first shot (<2° ≈ pre-aimed)  <2°%=share of duels with correction under 2°")
-	table := tablewriter.NewTable(w, tablewriter.WithConfig(tablewriter.Config{
-		Row: tw.CellConfig{
-			Alignment: tw.CellAlignment{Global: tw.AlignRight},
-		},
-		Header: tw.CellConfig{
-			Alignment: tw.CellAlignment{Global: tw.AlignCenter},
-		},
-	}))
-
-	table.Header(" ", "PLAYER", "W", "L", "EXPO_WIN", "EXPO_LOSS", "HITS/K", "1ST_HS%", "CORRECTION", "<2°%")
+	table := TableData{
+		Title:    "Duel Intelligence",
+		Sortable: true,
+		Description: "W/L=duel wins and losses  EXPO_WIN=median ms from enemy visible to your kill (lower = faster)\n" +
+			assistedDuelLegend +
+			"EXPO_LOSS=same for duels lost  SPOTTED=median ms from first enemy sighting of you to your death (high = overexposed)\n" +
+			infoDeathLegend +
+			"TTDMG=median ms from first seeing an enemy to first damaging them, kills or not (high = hesitating)\n" +
+			"HITS/K=median bullets to kill  1ST_HS%=% of won duels where first shot hit the head\n" +
+			"CORRECTION=degrees of crosshair adjustment before first shot (<2° ≈ pre-aimed)  <2°%=share of duels with correction under 2°",
+	}
+
+	table.Headers = []string{" ", "PLAYER", "W", "L", "ASSIST_W", "CLEAN_W%", "EXPO_WIN", "EXPO_LOSS", "SPOTTED", "INFO_DEATH%", "TTDMG", "HITS/K", "1ST_HS%", "CORRECTION", "<2°%"}
 
 	for _, s := range stats {
 		marker := " "
@@ -242,6 +596,14 @@ func PrintDuelTable(w io.Writer, stats []model.PlayerMatchStats, focusSteamID ui
 		if s.DuelLosses > 0 {
 			expoLoss = fmt.Sprintf("%.0fms", s.MedianExposureLossMs)
 		}
+		spotted := "—"
+		if s.MedianSpottedBeforeDeathMs > 0 {
+			spotted = fmt.Sprintf("%.0fms", s.MedianSpottedBeforeDeathMs)
+		}
+		ttdmg := "—"
+		if s.TimeToDamageSamples > 0 {
+			ttdmg = fmt.Sprintf("%.0fms", s.MedianTimeToDamageMs)
+		}
 		hitsK := "—"
 		if s.MedianHitsToKill > 0 {
 			hitsK = fmt.Sprintf("%.1f", s.MedianHitsToKill)
@@ -264,34 +626,51 @@ func PrintDuelTable(w io.Writer, stats []model.PlayerMatchStats, focusSteamID ui
 			s.Name,
 			strconv.Itoa(s.DuelWins),
 			strconv.Itoa(s.DuelLosses),
+			strconv.Itoa(s.AssistedDuelWins),
+			cleanDuelCell(s.DuelWins, s.DuelLosses, s.AssistedDuelWins, s.AssistedDuelLosses),
 			expoWin,
 			expoLoss,
+			spotted,
+			infoDeathCell(s.UnspottedDeaths, s.SpottedDeaths, s.InfoDeathPct()),
+			ttdmg,
 			hitsK,
 			firstHS,
 			corr,
 			under2,
 		)
 	}
-	table.Render()
+	if n := staleNote(staleColumn{"ASSIST_W/CLEAN_W%", 31}, infoDeathStaleColumn); n != "" {
+		table.Notes = append(table.Notes, n)
+	}
+	emit(w, table)
+}
+
+// awpDeathsDescription is the legend shared by the per-match and aggregate AWP
+// death tables.
+const awpDeathsDescription = "AWP_D=total deaths to AWP  AWP_RDS=rounds in which an enemy used an AWP\n" +
+	"AWP_D%=AWP_D/AWP_RDS, AWP deaths per round with an enemy AWP (comparable across opponents that AWP more or less)\n" +
+	"DRY%=victim had no flash in last 3s (fully avoidable peek)\n" +
+	"REPEEK%=victim had a kill earlier that round (punished for aggressive re-peek)\n" +
+	"ISOLATED%=no teammates within 512 units at kill tick (taken without support)"
+
+// awpDeathRate formats AWP deaths per AWP round faced, or "—" without exposure.
+func awpDeathRate(deaths, roundsFaced int) string {
+	if roundsFaced == 0 {
+		return "—"
+	}
+	return fmt.Sprintf("%.0f%%", float64(deaths)/float64(roundsFaced)*100)
 }
 
 // PrintAWPTable prints the AWP death classification table.
-// Columns: PLAYER | AWP_D | DRY% | REPEEK% | ISOLATED%
+// Columns: PLAYER | AWP_D | AWP_RDS | AWP_D% | DRY% | REPEEK% | ISOLATED%
 func PrintAWPTable(w io.Writer, stats []model.PlayerMatchStats, focusSteamID uint64) {
-	printSection(w, "AWP Deaths",
-		"AWP_D=total deaths to AWP  DRY%=victim had no flash in last 3s (fully avoidable peek)\n"+
-			"REPEEK%=victim had a kill earlier that round (punished for aggressive re-peek)\n"+
-			"ISOLATED%=no teammates within 512 units at kill tick (taken without support)")
-	table := tablewriter.NewTable(w, tablewriter.WithConfig(tablewriter.Config{
-		Row: tw.CellConfig{
-			Alignment: tw.CellAlignment{Global: tw.AlignRight},
-		},
-		Header: tw.CellConfig{
-			Alignment: tw.CellAlignment{Global: tw.AlignCenter},
-		},
-	}))
-
-	table.Header(" ", "PLAYER", "AWP_D", "DRY%", "REPEEK%", "ISOLATED%")
+	table := TableData{
+		Title:       "AWP Deaths",
+		Sortable:    true,
+		Description: awpDeathsDescription,
+	}
+
+	table.Headers = []string{" ", "PLAYER", "AWP_D", "AWP_RDS", "AWP_D%", "DRY%", "REPEEK%", "ISOLATED%"}
 
 	for _, s := range stats {
 		marker := " "
@@ -312,28 +691,41 @@ func PrintAWPTable(w io.Writer, stats []model.PlayerMatchStats, focusSteamID uin
 			marker,
 			s.Name,
 			strconv.Itoa(s.AWPDeaths),
+			strconv.Itoa(s.AWPRoundsFaced),
+			awpDeathRate(s.AWPDeaths, s.AWPRoundsFaced),
 			dryPct,
 			repeekPct,
 			isolatedPct,
 		)
 	}
-	table.Render()
+	emit(w, table)
 }
 
 // PrintPlayerAggregateOverview prints overall performance stats aggregated across all demos.
 func PrintPlayerAggregateOverview(w io.Writer, aggs []model.PlayerAggregate) {
-	printSection(w, "Performance Overview",
-		"K=Kills  A=Assists  D=Deaths  K/D=kill-death ratio  HS%=headshot kill %  ADR=avg damage per round\n"+
-			"KAST%=rounds with a Kill/Assist/Survival/Trade  ENTRY_K/D=first kill/death of the round\n"+
-			"TRADE_K/D=kill traded within 5s  FA=flash assists  EFF_FLASH=blinded enemy died to your team within 1.5s")
-	table := tablewriter.NewTable(w, tablewriter.WithConfig(tablewriter.Config{
-		Row:    tw.CellConfig{Alignment: tw.CellAlignment{Global: tw.AlignRight}},
-		Header: tw.CellConfig{Alignment: tw.CellAlignment{Global: tw.AlignCenter}},
-	}))
-	table.Header("PLAYER", "MATCHES", "K", "A", "D", "K/D", "HS%", "ADR", "KAST%",
-		"ENTRY_K", "ENTRY_D", "TRADE_K", "TRADE_D", "FA", "EFF_FLASH")
+	table := TableData{
+		Title:    "Performance Overview",
+		Sortable: true,
+		Description: "K=Kills  A=Assists  D=Deaths  K/D=kill-death ratio  HS%=headshot kill %  ADR=avg damage per round\n" +
+			headHitLegend + ecoADRLegend +
+			kastLegend() + "  RATING=Rating 2.0 proxy over all rounds  ENTRY_K/D=first kill/death of the round\n" +
+			"TRADE_K/D=kill traded within 5s  FA=flash assists  EFF_FLASH=blinded enemy died to your team within 1.5s\n" +
+			"LOWHP_HAND=enemies you left under 20 HP that a teammate finished  LOWHP_WASTE=same, but the enemy survived (or died to something else)\n" +
+			"ADR_SD/KAST_SD/RTG_SD=std dev of the per-match value  RTG_IQR=interquartile range of per-match rating (lower = more consistent)\n" +
+			"BOOM_BUST=% of matches rated ≥1.30 or ≤0.70 (high = feast-or-famine)  — = fewer than 2 matches",
+	}
+	table.Headers = []string{"PLAYER", "MATCHES", "K", "A", "D", "K/D", "HS%", "HEAD_HIT%", "ADR", "ADR_BUY", "ADR_ECO", "ADR_SD", "KAST%", "KAST_SD",
+		"RATING", "RTG_SD", "RTG_IQR", "BOOM_BUST",
+		"ENTRY_K", "ENTRY_D", "TRADE_K", "TRADE_D", "FA", "EFF_FLASH", "LOWHP_HAND", "LOWHP_WASTE"}
 
 	for _, a := range aggs {
+		c := a.Consistency
+		spread := func(v float64, format string) string {
+			if c.Matches < 2 {
+				return "—"
+			}
+			return fmt.Sprintf(format, v)
+		}
 		table.Append(
 			a.Name,
 			strconv.Itoa(a.Matches),
@@ -342,30 +734,49 @@ func PrintPlayerAggregateOverview(w io.Writer, aggs []model.PlayerAggregate) {
 			strconv.Itoa(a.Deaths),
 			colorKD(a.KDRatio()),
 			fmt.Sprintf("%.0f%%", a.HSPercent()),
+			headHitCell(a.BulletHits, a.HeadHitPct()),
 			fmt.Sprintf("%.1f", a.ADR()),
-			fmt.Sprintf("%.0f%%", a.KASTPct()),
+			roundADRCell(a.BuyRounds, a.BuyADR()),
+			roundADRCell(a.EcoRounds, a.EcoADR()),
+			spread(c.ADRSD, "%.1f"),
+			fmt.Sprintf("%.0f%%", kastPct(a.KASTRounds, a.KASTStrictRounds, a.RoundsPlayed)),
+			spread(c.KASTSD, "%.1f"),
+			fmt.Sprintf("%.2f", a.Rating()),
+			spread(c.RatingSD, "%.2f"),
+			spread(c.RatingIQR, "%.2f"),
+			spread(c.BoomBustPct(), "%.0f%%"),
 			strconv.Itoa(a.OpeningKills),
 			strconv.Itoa(a.OpeningDeaths),
 			strconv.Itoa(a.TradeKills),
 			strconv.Itoa(a.TradeDeaths),
 			strconv.Itoa(a.FlashAssists),
 			strconv.Itoa(a.EffectiveFlashes),
+			strconv.Itoa(a.LowHPHanded),
+			strconv.Itoa(a.LowHPWasted),
 		)
 	}
-	table.Render()
+	if n := staleNote(headHitStaleColumn, ecoADRStaleColumn); n != "" {
+		table.Notes = append(table.Notes, n)
+	}
+	if n := kastNote(); n != "" {
+		table.Notes = append(table.Notes, n)
+	}
+	emit(w, table)
 }
 
 // PrintPlayerAggregateDuelTable prints duel engine stats aggregated across all demos.
 func PrintPlayerAggregateDuelTable(w io.Writer, aggs []model.PlayerAggregate) {
-	printSection(w, "Duel Intelligence",
-		"W/L=duel wins and losses (summed)  AVG_EXPO_WIN=avg of per-match median ms from enemy visible to your kill\n"+
-			"AVG_EXPO_LOSS=same for duels lost  AVG_HITS/K=avg of per-match median bullets to kill\n"+
-			"AVG_CORR=avg of per-match median pre-shot crosshair correction in degrees")
-	table := tablewriter.NewTable(w, tablewriter.WithConfig(tablewriter.Config{
-		Row:    tw.CellConfig{Alignment: tw.CellAlignment{Global: tw.AlignRight}},
-		Header: tw.CellConfig{Alignment: tw.CellAlignment{Global: tw.AlignCenter}},
-	}))
-	table.Header("PLAYER", "W", "L", "AVG_EXPO_WIN", "AVG_EXPO_LOSS", "AVG_HITS/K", "AVG_CORR")
+	table := TableData{
+		Title:    "Duel Intelligence",
+		Sortable: true,
+		Description: "W/L=duel wins and losses (summed)  AVG_EXPO_WIN=avg of per-match median ms from enemy visible to your kill\n" +
+			assistedDuelLegend +
+			"AVG_EXPO_LOSS=same for duels lost  AVG_SPOTTED=avg of per-match median ms from first enemy sighting of you to your death\n" +
+			infoDeathLegend +
+			"AVG_TTDMG=avg of per-match median ms from first seeing an enemy to first damaging them (high = hesitating)\n" +
+			"AVG_HITS/K=avg of per-match median bullets to kill  AVG_CORR=avg of per-match median pre-shot crosshair correction in degrees",
+	}
+	table.Headers = []string{"PLAYER", "W", "L", "ASSIST_W", "CLEAN_W%", "AVG_EXPO_WIN", "AVG_EXPO_LOSS", "AVG_SPOTTED", "INFO_DEATH%", "AVG_TTDMG", "AVG_HITS/K", "AVG_CORR"}
 
 	for _, a := range aggs {
 		expoWin := "—"
@@ -376,6 +787,14 @@ func PrintPlayerAggregateDuelTable(w io.Writer, aggs []model.PlayerAggregate) {
 		if a.AvgExpoLossMs > 0 {
 			expoLoss = fmt.Sprintf("%.0fms", a.AvgExpoLossMs)
 		}
+		spotted := "—"
+		if a.AvgSpottedBeforeDeathMs > 0 {
+			spotted = fmt.Sprintf("%.0fms", a.AvgSpottedBeforeDeathMs)
+		}
+		ttdmg := "—"
+		if a.AvgTimeToDamageMs > 0 {
+			ttdmg = fmt.Sprintf("%.0fms", a.AvgTimeToDamageMs)
+		}
 		hitsK := "—"
 		if a.AvgHitsToKill > 0 {
 			hitsK = fmt.Sprintf("%.1f", a.AvgHitsToKill)
@@ -388,26 +807,31 @@ func PrintPlayerAggregateDuelTable(w io.Writer, aggs []model.PlayerAggregate) {
 			a.Name,
 			strconv.Itoa(a.DuelWins),
 			strconv.Itoa(a.DuelLosses),
+			strconv.Itoa(a.AssistedDuelWins),
+			cleanDuelCell(a.DuelWins, a.DuelLosses, a.AssistedDuelWins, a.AssistedDuelLosses),
 			expoWin,
 			expoLoss,
+			spotted,
+			infoDeathCell(a.UnspottedDeaths, a.SpottedDeaths, a.InfoDeathPct()),
+			ttdmg,
 			hitsK,
 			corr,
 		)
 	}
-	table.Render()
+	if n := staleNote(staleColumn{"ASSIST_W/CLEAN_W%", 31}, infoDeathStaleColumn); n != "" {
+		table.Notes = append(table.Notes, n)
+	}
+	emit(w, table)
 }
 
 // PrintPlayerAggregateAWPTable prints AWP death classification aggregated across all demos.
 func PrintPlayerAggregateAWPTable(w io.Writer, aggs []model.PlayerAggregate) {
-	printSection(w, "AWP Deaths",
-		"AWP_D=total deaths to AWP  DRY%=victim had no flash in last 3s (fully avoidable peek)\n"+
-			"REPEEK%=victim had a kill earlier that round (punished for aggressive re-peek)\n"+
-			"ISOLATED%=no teammates within 512 units at kill tick (taken without support)")
-	table := tablewriter.NewTable(w, tablewriter.WithConfig(tablewriter.Config{
-		Row:    tw.CellConfig{Alignment: tw.CellAlignment{Global: tw.AlignRight}},
-		Header: tw.CellConfig{Alignment: tw.CellAlignment{Global: tw.AlignCenter}},
-	}))
-	table.Header("PLAYER", "AWP_D", "DRY%", "REPEEK%", "ISOLATED%")
+	table := TableData{
+		Title:       "AWP Deaths",
+		Sortable:    true,
+		Description: awpDeathsDescription,
+	}
+	table.Headers = []string{"PLAYER", "AWP_D", "AWP_RDS", "AWP_D%", "DRY%", "REPEEK%", "ISOLATED%"}
 
 	for _, a := range aggs {
 		dryPct, repeekPct, isolatedPct := "—", "—", "—"
@@ -416,9 +840,129 @@ func PrintPlayerAggregateAWPTable(w io.Writer, aggs []model.PlayerAggregate) {
 			repeekPct = fmt.Sprintf("%.0f%%", float64(a.AWPDeathsRePeek)/float64(a.AWPDeaths)*100)
 			isolatedPct = fmt.Sprintf("%.0f%%", float64(a.AWPDeathsIsolated)/float64(a.AWPDeaths)*100)
 		}
-		table.Append(a.Name, strconv.Itoa(a.AWPDeaths), dryPct, repeekPct, isolatedPct)
+		table.Append(a.Name, strconv.Itoa(a.AWPDeaths), strconv.Itoa(a.AWPRoundsFaced),
+			awpDeathRate(a.AWPDeaths, a.AWPRoundsFaced), dryPct, repeekPct, isolatedPct)
+	}
+	emit(w, table)
+}
+
+// PrintPlayerAggregateAWPMapTable prints the aggregate AWP death
+// classification split by map, so a map where AWP deaths pile up isn't hidden
+// in the overall rate. Maps where the player neither died to nor faced an
+// AWP are left out.
+// Columns: PLAYER | MAP | MATCHES | AWP_D | SHARE | AWP_RDS | AWP_D% | DRY% | REPEEK% | ISOLATED%
+func PrintPlayerAggregateAWPMapTable(w io.Writer, rows []model.PlayerAWPMapStats) {
+	table := TableData{
+		Title:    "AWP Deaths by Map",
+		Sortable: true,
+		Description: "SHARE=this map's share of the player's AWP deaths across all maps\n" +
+			awpDeathsDescription,
+	}
+	table.Headers = []string{"PLAYER", "MAP", "MATCHES", "AWP_D", "SHARE", "AWP_RDS", "AWP_D%", "DRY%", "REPEEK%", "ISOLATED%"}
+
+	totals := make(map[uint64]int)
+	for _, r := range rows {
+		totals[r.SteamID] += r.AWPDeaths
+	}
+	for _, r := range rows {
+		if r.AWPDeaths == 0 && r.AWPRoundsFaced == 0 {
+			continue
+		}
+		share, dryPct, repeekPct, isolatedPct := "—", "—", "—", "—"
+		if r.AWPDeaths > 0 {
+			share = fmt.Sprintf("%.0f%%", float64(r.AWPDeaths)/float64(totals[r.SteamID])*100)
+			dryPct = fmt.Sprintf("%.0f%%", float64(r.AWPDeathsDry)/float64(r.AWPDeaths)*100)
+			repeekPct = fmt.Sprintf("%.0f%%", float64(r.AWPDeathsRePeek)/float64(r.AWPDeaths)*100)
+			isolatedPct = fmt.Sprintf("%.0f%%", float64(r.AWPDeathsIsolated)/float64(r.AWPDeaths)*100)
+		}
+		table.Append(r.Name, r.MapName, strconv.Itoa(r.Matches), strconv.Itoa(r.AWPDeaths), share,
+			strconv.Itoa(r.AWPRoundsFaced), awpDeathRate(r.AWPDeaths, r.AWPRoundsFaced),
+			dryPct, repeekPct, isolatedPct)
+	}
+	if len(table.Rows) == 0 {
+		emitMissing(w, table.Title, "AWP deaths", 0)
+		return
+	}
+	emit(w, table)
+}
+
+// awpShotsDescription is the legend shared by the per-match and aggregate AWP
+// shot tables.
+const awpShotsDescription = "SHOTS=AWP shots fired  KILL=shots that killed  BODY=shots that hit without killing  MISS=shots that dealt no enemy damage\n" +
+	"HIT%=(KILL+BODY)/SHOTS  BODY%=BODY/(KILL+BODY), hits that failed to kill  KILL%=KILL/SHOTS\n" +
+	"A shot is credited with AWP damage to an enemy landing within 100ms of it."
+
+// awpShotCells formats the SHOTS..KILL% cells of the AWP shot tables.
+func awpShotCells(shots, kills, bodyHits int) []string {
+	hits := kills + bodyHits
+	hitPct, bodyPct, killPct := "—", "—", "—"
+	if shots > 0 {
+		hitPct = fmt.Sprintf("%.0f%%", float64(hits)/float64(shots)*100)
+		killPct = fmt.Sprintf("%.0f%%", float64(kills)/float64(shots)*100)
+	}
+	if hits > 0 {
+		bodyPct = fmt.Sprintf("%.0f%%", float64(bodyHits)/float64(hits)*100)
+	}
+	return []string{
+		strconv.Itoa(shots),
+		strconv.Itoa(kills),
+		strconv.Itoa(bodyHits),
+		strconv.Itoa(shots - hits),
+		hitPct,
+		bodyPct,
+		killPct,
+	}
+}
+
+// PrintAWPShotsTable prints the per-shot AWP ledger for players who fired the
+// AWP. A hint is printed when no one did.
+// Columns: PLAYER | SHOTS | KILL | BODY | MISS | HIT% | BODY% | KILL%
+func PrintAWPShotsTable(w io.Writer, stats []model.PlayerMatchStats, focusSteamID uint64) {
+	table := TableData{
+		Title:       "AWP Shots",
+		Sortable:    true,
+		Description: awpShotsDescription,
+	}
+	table.Headers = []string{" ", "PLAYER", "SHOTS", "KILL", "BODY", "MISS", "HIT%", "BODY%", "KILL%"}
+
+	for _, s := range stats {
+		if s.AWPShots == 0 {
+			continue
+		}
+		marker := " "
+		if focusSteamID != 0 && s.SteamID == focusSteamID {
+			marker = color.CyanString(">")
+		}
+		table.Append(append([]string{marker, s.Name}, awpShotCells(s.AWPShots, s.AWPShotKills, s.AWPShotBodyHits)...)...)
+	}
+	if len(table.Rows) == 0 {
+		emitMissing(w, table.Title, "AWP shots", 12)
+		return
+	}
+	emit(w, table)
+}
+
+// PrintPlayerAggregateAWPShotsTable prints the AWP shot ledger summed across
+// matches, for players who fired the AWP.
+func PrintPlayerAggregateAWPShotsTable(w io.Writer, aggs []model.PlayerAggregate) {
+	table := TableData{
+		Title:       "AWP Shots",
+		Sortable:    true,
+		Description: awpShotsDescription,
+	}
+	table.Headers = []string{"PLAYER", "SHOTS", "KILL", "BODY", "MISS", "HIT%", "BODY%", "KILL%"}
+
+	for _, a := range aggs {
+		if a.AWPShots == 0 {
+			continue
+		}
+		table.Append(append([]string{a.Name}, awpShotCells(a.AWPShots, a.AWPShotKills, a.AWPShotBodyHits)...)...)
 	}
-	table.Render()
+	if len(table.Rows) == 0 {
+		emitMissing(w, table.Title, "AWP shots", 12)
+		return
+	}
+	emit(w, table)
 }
 
 // PrintPlayerMapSideTable prints per-map CT/T split stats aggregated across all demos.
@@ -426,17 +970,31 @@ func PrintPlayerMapSideTable(w io.Writer, aggs []model.PlayerMapSideAggregate) {
 	if len(aggs) == 0 {
 		return
 	}
-	printSection(w, "Performance by Map & Side",
-		"Stats split by map and side (CT/T). M=matches on that combination.\n"+
-			"All other columns match the Performance Overview definitions.")
-	table := tablewriter.NewTable(w, tablewriter.WithConfig(tablewriter.Config{
-		Row:    tw.CellConfig{Alignment: tw.CellAlignment{Global: tw.AlignRight}},
-		Header: tw.CellConfig{Alignment: tw.CellAlignment{Global: tw.AlignCenter}},
-	}))
-	table.Header("NAME", "MAP", "SIDE", "M", "K", "D", "K/D", "HS%", "ADR", "KAST%",
-		"ENTRY_K", "ENTRY_D", "TRADE_K", "TRADE_D")
+	table := TableData{
+		Title:    "Performance by Map & Side",
+		Sortable: true,
+		Description: "Stats split by map and side (CT/T). M=matches on that combination.\n" +
+			"DUEL_W/L=duel wins and losses  AVG_EXPO_WIN/LOSS=avg of per-match median ms from enemy visible to the duel's end\n" +
+			"FHHS%=first-hit headshot rate pooled over duel segments (hits=first hits it is based on)\n" +
+			"All other columns match the Performance Overview definitions.",
+	}
+	table.Headers = []string{"NAME", "MAP", "SIDE", "M", "K", "D", "K/D", "HS%", "ADR", "KAST%",
+		"ENTRY_K", "ENTRY_D", "TRADE_K", "TRADE_D",
+		"DUEL_W", "DUEL_L", "AVG_EXPO_WIN", "AVG_EXPO_LOSS", "FHHS%", "HITS"}
 
 	for _, a := range aggs {
+		expoWin := "—"
+		if a.AvgExpoWinMs > 0 {
+			expoWin = fmt.Sprintf("%.0fms", a.AvgExpoWinMs)
+		}
+		expoLoss := "—"
+		if a.AvgExpoLossMs > 0 {
+			expoLoss = fmt.Sprintf("%.0fms", a.AvgExpoLossMs)
+		}
+		fhhs := "—"
+		if a.FirstHitCount > 0 {
+			fhhs = fmt.Sprintf("%.0f%%", a.FHHSPct())
+		}
 		table.Append(
 			a.Name,
 			a.MapName,
@@ -452,29 +1010,101 @@ func PrintPlayerMapSideTable(w io.Writer, aggs []model.PlayerMapSideAggregate) {
 			strconv.Itoa(a.OpeningDeaths),
 			strconv.Itoa(a.TradeKills),
 			strconv.Itoa(a.TradeDeaths),
+			strconv.Itoa(a.DuelWins),
+			strconv.Itoa(a.DuelLosses),
+			expoWin,
+			expoLoss,
+			fhhs,
+			strconv.Itoa(a.FirstHitCount),
+		)
+	}
+	emit(w, table)
+}
+
+// PrintPlayerHalfSplitTable prints first- vs second-half performance summed
+// across matches, with a delta row per player (second half minus first).
+func PrintPlayerHalfSplitTable(w io.Writer, splits []model.PlayerHalfSplit) {
+	if len(splits) == 0 {
+		return
+	}
+	table := TableData{
+		Title: "Half Split",
+		Description: "H1/H2=regulation halves (split at side switch, overtime excluded)  M=matches with both halves played\n" +
+			"RATING=Rating 2.0 proxy  ENTRY%=opening kills / opening duels  Δ=H2 − H1 (negative = fades, positive = slow starter)",
+	}
+	table.Headers = []string{"NAME", "HALF", "M", "RND", "RATING", "ADR", "KAST%", "ENTRY_K", "ENTRY_D", "ENTRY%"}
+
+	entry := func(h *model.PlayerHalfStats) string {
+		if h.OpeningKills+h.OpeningDeaths == 0 {
+			return "—"
+		}
+		return fmt.Sprintf("%.0f%%", h.EntrySuccessPct())
+	}
+	delta := func(v float64, format string) string {
+		s := fmt.Sprintf("%+"+format, v)
+		switch {
+		case v > 0:
+			return color.GreenString(s)
+		case v < 0:
+			return color.RedString(s)
+		}
+		return s
+	}
+
+	for _, sp := range splits {
+		for _, h := range []*model.PlayerHalfStats{&sp.First, &sp.Second} {
+			table.Append(
+				sp.Name,
+				fmt.Sprintf("H%d", h.Half),
+				strconv.Itoa(sp.Matches),
+				strconv.Itoa(h.RoundsPlayed),
+				fmt.Sprintf("%.2f", h.Rating()),
+				fmt.Sprintf("%.1f", h.ADR()),
+				fmt.Sprintf("%.0f%%", h.KASTPct()),
+				strconv.Itoa(h.OpeningKills),
+				strconv.Itoa(h.OpeningDeaths),
+				entry(h),
+			)
+		}
+		entryDelta := "—"
+		if sp.First.OpeningKills+sp.First.OpeningDeaths > 0 && sp.Second.OpeningKills+sp.Second.OpeningDeaths > 0 {
+			entryDelta = delta(sp.Second.EntrySuccessPct()-sp.First.EntrySuccessPct(), ".0f")
+		}
+		table.Append(
+			sp.Name,
+			"Δ",
+			"",
+			"",
+			delta(sp.Second.Rating()-sp.First.Rating(), ".2f"),
+			delta(sp.Second.ADR()-sp.First.ADR(), ".1f"),
+			delta(sp.Second.KASTPct()-sp.First.KASTPct(), ".0f"),
+			"",
+			"",
+			entryDelta,
 		)
 	}
-	table.Render()
+	emit(w, table)
 }
 
-// binOrder returns a sort key for distance bin strings (ascending distance).
+// binOrder returns a sort key for distance bin strings (ascending distance):
+// the bin's lower bound in meters, for the fixed bins ("10-15m", "30m+") and
+// quantile bins ("7-12m") alike. Unparseable bins ("unknown") sort last.
 func binOrder(bin string) int {
-	switch bin {
-	case "0-5m":
-		return 0
-	case "5-10m":
-		return 1
-	case "10-15m":
-		return 2
-	case "15-20m":
-		return 3
-	case "20-30m":
-		return 4
-	case "30m+":
-		return 5
-	default:
-		return 6
+	lo, ok := binLowerBound(bin)
+	if !ok {
+		return math.MaxInt
 	}
+	return lo
+}
+
+// binLowerBound parses the lower bound in meters of a distance bin label.
+func binLowerBound(bin string) (int, bool) {
+	end := strings.IndexAny(bin, "-m")
+	if end <= 0 {
+		return 0, false
+	}
+	lo, err := strconv.Atoi(bin[:end])
+	return lo, err == nil
 }
 
 // bucketOrder returns a sort key for weapon bucket strings.
@@ -535,20 +1165,24 @@ func isRifleBucket(b string) bool {
 	return b == "AK" || b == "M4" || b == "Galil" || b == "FAMAS" || b == "ScopedRifle"
 }
 
-// isMidRangeBin reports whether b represents a mid-range engagement distance
-// (10-15m, 15-20m, or 20-30m).
+// isMidRangeBin reports whether b represents a mid-range engagement distance:
+// a bin starting at 10m or more but below 30m (10-15m, 15-20m, or 20-30m
+// among the fixed bins).
 func isMidRangeBin(b string) bool {
-	return b == "10-15m" || b == "15-20m" || b == "20-30m"
+	lo, ok := binLowerBound(b)
+	return ok && lo >= 10 && lo < 30
 }
 
 // PrintFHHSTable prints the First-Hit Headshot Rate segmented by weapon + distance.
 // Priority bins (high sample, low FHHS relative to overall, mid-range rifle) are marked with "*".
 // If focusSteamID is non-zero, only rows for that player are shown.
 func PrintFHHSTable(w io.Writer, segs []model.PlayerDuelSegment, players []model.PlayerMatchStats, focusSteamID uint64) {
-	printSection(w, "First-Hit Headshot Rate (FHHS)",
-		"FHHS%=% of won duels where first shot hit the head (higher = better aim transfer on first contact)\n"+
-			"N(hits)=sample count  FLAG=OK(≥50)/LOW(≥20)/VERY_LOW(<20) reliability  95% CI=Wilson confidence interval\n"+
-			"MED_CORR=median pre-shot crosshair correction in degrees  *=weakest stable high-sample bin")
+	table := TableData{
+		Title: "First-Hit Headshot Rate (FHHS)",
+		Description: "FHHS%=% of won duels where first shot hit the head (higher = better aim transfer on first contact)\n" +
+			"N(hits)=sample count  FLAG=OK(≥50)/LOW(≥20)/VERY_LOW(<20) reliability  95% CI=Wilson confidence interval\n" +
+			"MED_CORR=median pre-shot crosshair correction in degrees  *=weakest stable high-sample bin",
+	}
 	// Build name and overall-FHHS lookup.
 	nameByID := make(map[uint64]string, len(players))
 	overallFHHS := make(map[uint64]float64, len(players))
@@ -566,6 +1200,7 @@ func PrintFHHSTable(w io.Writer, segs []model.PlayerDuelSegment, players []model
 		relevant = append(relevant, s)
 	}
 	if len(relevant) == 0 {
+		emitMissing(w, table.Title, "first-hit duels", 0)
 		return
 	}
 
@@ -582,17 +1217,7 @@ func PrintFHHSTable(w io.Writer, segs []model.PlayerDuelSegment, players []model
 		return binOrder(a.DistanceBin) < binOrder(b.DistanceBin)
 	})
 
-	table := tablewriter.NewTable(w, tablewriter.WithConfig(tablewriter.Config{
-		Row: tw.CellConfig{
-			Alignment: tw.CellAlignment{Global: tw.AlignRight},
-		},
-		Header: tw.CellConfig{
-			Alignment: tw.CellAlignment{Global: tw.AlignCenter},
-		},
-	}))
-	table.Header(" ", "PLAYER", "WEAPON", "DISTANCE", "N(hits)", "FHHS%", "95% CI", "MED_CORR", "FLAG")
-
-	var priorityLines []string
+	table.Headers = []string{" ", "PLAYER", "WEAPON", "DISTANCE", "N(hits)", "FHHS%", "95% CI", "MED_CORR", "FLAG"}
 
 	for _, s := range relevant {
 		fhhs := 0.0
@@ -624,9 +1249,9 @@ func PrintFHHSTable(w io.Writer, segs []model.PlayerDuelSegment, players []model
 		if isPriority {
 			marker = color.YellowString("*")
 			name := nameByID[s.SteamID]
-			priorityLines = append(priorityLines,
-				fmt.Sprintf("%s %s@%s is your weakest stable bin: %.0f%% FHHS (N=%d).",
-					name, s.WeaponBucket, s.DistanceBin, fhhs, s.FirstHitCount))
+			table.Notes = append(table.Notes,
+				fmt.Sprintf("  %s %s %s@%s is your weakest stable bin: %.0f%% FHHS (N=%d).",
+					color.YellowString("*"), name, s.WeaponBucket, s.DistanceBin, fhhs, s.FirstHitCount))
 		}
 
 		name := nameByID[s.SteamID]
@@ -646,57 +1271,494 @@ func PrintFHHSTable(w io.Writer, segs []model.PlayerDuelSegment, players []model
 			colorFlag(flag),
 		)
 	}
-	table.Render()
-
-	if len(priorityLines) > 0 {
-		fmt.Fprintln(w, "\nPriority bins:")
-		for _, line := range priorityLines {
-			fmt.Fprintf(w, "  %s %s\n", color.YellowString("*"), line)
-		}
-		fmt.Fprintln(w)
+	if len(table.Notes) > 0 {
+		table.Notes = append([]string{"Priority bins:"}, table.Notes...)
 	}
+	emit(w, table)
 }
 
-// wilsonCI computes the 95% Wilson score confidence interval for a proportion.
-// Returns (lo, hi) as fractions in [0, 1].
-func wilsonCI(hits, n int) (lo, hi float64) {
-	if n == 0 {
-		return 0, 1
+// roundContextOrder returns the display order of a duel round context; ""
+// (demos parsed before round contexts) sorts last.
+func roundContextOrder(c string) int {
+	switch c {
+	case model.RoundContextPistol:
+		return 0
+	case model.RoundContextAntiEco:
+		return 1
+	case model.RoundContextGun:
+		return 2
 	}
-	z := 1.96
-	p := float64(hits) / float64(n)
-	nf := float64(n)
-	denom := 1 + z*z/nf
-	center := (p + z*z/(2*nf)) / denom
-	half := z * math.Sqrt(p*(1-p)/nf+z*z/(4*nf*nf)) / denom
-	return math.Max(0, center-half), math.Min(1, center+half)
+	return 3
 }
 
-// PrintAimTimingTable prints the TTK, TTD, and Counter-Strafe % table.
-// If focusSteamID is non-zero, that player's row is highlighted with ">".
-// Rows where all three values are zero are shown as "—".
-func PrintAimTimingTable(w io.Writer, stats []model.PlayerMatchStats, focusSteamID uint64) {
-	// Only show if at least one player has data.
-	hasData := false
-	for _, s := range stats {
-		if s.MedianTTKMs > 0 || s.MedianTTDMs > 0 || s.OneTapKills > 0 {
-			hasData = true
-			break
-		}
+// PrintFHHSByRoundContextTable prints each player's first-hit headshot rate
+// per round context (pistol / anti-eco / gun), pooled over weapon buckets and
+// distance bins, next to the rifle-only rate, so pistol rounds and unarmored
+// eco targets can be told apart from gun-round aim.
+func PrintFHHSByRoundContextTable(w io.Writer, segs []model.PlayerDuelSegment, players []model.PlayerMatchStats) {
+	type key struct {
+		steamID uint64
+		context string
 	}
-	if !hasData {
+	type counts struct{ duels, hits, hs, rifleHits, rifleHS int }
+	pooled := make(map[key]*counts)
+	for _, s := range segs {
+		k := key{s.SteamID, s.RoundContext}
+		if pooled[k] == nil {
+			pooled[k] = &counts{}
+		}
+		c := pooled[k]
+		c.duels += s.DuelCount
+		c.hits += s.FirstHitCount
+		c.hs += s.FirstHitHSCount
+		if isRifleBucket(s.WeaponBucket) {
+			c.rifleHits += s.FirstHitCount
+			c.rifleHS += s.FirstHitHSCount
+		}
+	}
+	if len(pooled) == 0 {
+		emitMissing(w, "FHHS by Round Context", "round-context duel segments", 18)
+		return
+	}
+	keys := make([]key, 0, len(pooled))
+	for k := range pooled {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].steamID != keys[j].steamID {
+			return keys[i].steamID < keys[j].steamID
+		}
+		return roundContextOrder(keys[i].context) < roundContextOrder(keys[j].context)
+	})
+	nameByID := make(map[uint64]string, len(players))
+	for _, p := range players {
+		nameByID[p.SteamID] = p.Name
+	}
+
+	table := TableData{
+		Title: "FHHS by Round Context",
+		Description: "pistol=first round of a half  anti-eco=your force/full buy vs an eco  gun=every other duel  unknown=demos parsed before pipeline v18\n" +
+			"DUELS=duels won  N(hits)=duels where the first shot hit  FHHS%=first hit was a headshot  RIFLE_N/RIFLE_FHHS%=same, rifles only",
+	}
+	table.Headers = []string{"PLAYER", "CONTEXT", "DUELS", "N(hits)", "FHHS%", "95% CI", "RIFLE_N", "RIFLE_FHHS%"}
+	rate := func(hs, n int) string {
+		if n == 0 {
+			return "—"
+		}
+		return fmt.Sprintf("%.0f%%", float64(hs)/float64(n)*100)
+	}
+	for _, k := range keys {
+		c := pooled[k]
+		name := nameByID[k.steamID]
+		if name == "" {
+			name = strconv.FormatUint(k.steamID, 10)
+		}
+		context := k.context
+		if context == "" {
+			context = "unknown"
+		}
+		ci := "—"
+		if c.hits > 0 {
+			lo, hi := wilsonCI(c.hs, c.hits)
+			ci = fmt.Sprintf("%.0f–%.0f%%", lo*100, hi*100)
+		}
+		table.Append(name, context, strconv.Itoa(c.duels), strconv.Itoa(c.hits), rate(c.hs, c.hits), ci,
+			strconv.Itoa(c.rifleHits), rate(c.rifleHS, c.rifleHits))
+	}
+	emit(w, table)
+}
+
+// PrintFHHSByMovementTable prints each player's first-hit headshot rate
+// split by movement at the first shot — still (counter-strafed, ≤ 34 u/s)
+// vs moving — pooled over weapon buckets, distance bins and round contexts,
+// so running sprays do not blur the set-shot aim signal.
+func PrintFHHSByMovementTable(w io.Writer, segs []model.PlayerDuelSegment, players []model.PlayerMatchStats) {
+	const title = "FHHS by Movement"
+	type counts struct{ stillHits, stillHS, movingHits, movingHS int }
+	pooled := make(map[uint64]*counts)
+	for _, s := range segs {
+		if s.StillFirstHits+s.MovingFirstHits == 0 {
+			continue
+		}
+		if pooled[s.SteamID] == nil {
+			pooled[s.SteamID] = &counts{}
+		}
+		c := pooled[s.SteamID]
+		c.stillHits += s.StillFirstHits
+		c.stillHS += s.StillFirstHitHS
+		c.movingHits += s.MovingFirstHits
+		c.movingHS += s.MovingFirstHitHS
+	}
+	if len(pooled) == 0 {
+		emitMissing(w, title, "movement-tagged first hits", 35)
+		return
+	}
+	ids := make([]uint64, 0, len(pooled))
+	for id := range pooled {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	nameByID := make(map[uint64]string, len(players))
+	for _, p := range players {
+		nameByID[p.SteamID] = p.Name
+	}
+
+	table := TableData{
+		Title: title,
+		Description: "still=first shot fired at ≤ 34 u/s (counter-strafed)  moving=faster  STILL%=share of first hits fired still\n" +
+			"N=duels won where the first shot hit  FHHS%=that hit was a headshot  Δ=STILL − MOVING FHHS (pp)",
+	}
+	table.Headers = []string{"PLAYER", "STILL%", "STILL_N", "STILL_FHHS%", "95% CI", "MOVING_N", "MOVING_FHHS%", "95% CI", "Δ"}
+	rate := func(hs, n int) string {
+		if n == 0 {
+			return "—"
+		}
+		return fmt.Sprintf("%.0f%%", float64(hs)/float64(n)*100)
+	}
+	ci := func(hs, n int) string {
+		if n == 0 {
+			return "—"
+		}
+		lo, hi := wilsonCI(hs, n)
+		return fmt.Sprintf("%.0f–%.0f%%", lo*100, hi*100)
+	}
+	for _, id := range ids {
+		c := pooled[id]
+		name := nameByID[id]
+		if name == "" {
+			name = strconv.FormatUint(id, 10)
+		}
+		delta := "—"
+		if c.stillHits > 0 && c.movingHits > 0 {
+			d := (float64(c.stillHS)/float64(c.stillHits) - float64(c.movingHS)/float64(c.movingHits)) * 100
+			delta = fmt.Sprintf("%+.0f", d)
+		}
+		table.Append(name, rate(c.stillHits, c.stillHits+c.movingHits),
+			strconv.Itoa(c.stillHits), rate(c.stillHS, c.stillHits), ci(c.stillHS, c.stillHits),
+			strconv.Itoa(c.movingHits), rate(c.movingHS, c.movingHits), ci(c.movingHS, c.movingHits),
+			delta)
+	}
+	emit(w, table)
+}
+
+// PrintDeathContextTable prints what each player was doing before their
+// deaths — moving or holding, flashed, isolated, just after a kill — as shares
+// of their deaths to enemies, with the most common combination as a note.
+// rows are per-player context counts (already merged across demos).
+func PrintDeathContextTable(w io.Writer, rows []model.PlayerDeathContext, players []model.PlayerMatchStats) {
+	const title = "Death Profile"
+	type counts struct {
+		deaths, moving, holding, flashed, isolated, afterKill int
+		top                                                   model.PlayerDeathContext
+	}
+	byPlayer := make(map[uint64]*counts)
+	for _, r := range rows {
+		if r.Deaths == 0 {
+			continue
+		}
+		c := byPlayer[r.SteamID]
+		if c == nil {
+			c = &counts{}
+			byPlayer[r.SteamID] = c
+		}
+		c.deaths += r.Deaths
+		switch r.Movement {
+		case model.DeathMoving:
+			c.moving += r.Deaths
+		case model.DeathHolding:
+			c.holding += r.Deaths
+		}
+		if r.Flashed {
+			c.flashed += r.Deaths
+		}
+		if r.Isolated {
+			c.isolated += r.Deaths
+		}
+		if r.AfterKill {
+			c.afterKill += r.Deaths
+		}
+		if r.Deaths > c.top.Deaths {
+			c.top = r
+		}
+	}
+	if len(byPlayer) == 0 {
+		emitMissing(w, title, "pre-death contexts", 36)
+		return
+	}
+	ids := make([]uint64, 0, len(byPlayer))
+	for id := range byPlayer {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	nameByID := make(map[uint64]string, len(players))
+	for _, p := range players {
+		nameByID[p.SteamID] = p.Name
+	}
+
+	table := TableData{
+		Title: title,
+		Description: "what you were doing in the 3s before dying to an enemy, as % of those deaths (flags overlap)\n" +
+			"MOVING/HOLDING=speed above / at or below 34 u/s at the killer's first bullet hit (neither without one)  FLASHED=blinded by a flash\n" +
+			"ISOLATED=no alive teammate within 512 units at the kill  AFTER_KILL=you had killed an enemy  TOP_CTX=most common combination (named below)",
+	}
+	table.Headers = []string{"PLAYER", "DEATHS", "MOVING%", "HOLDING%", "FLASHED%", "ISOLATED%", "AFTER_KILL%", "TOP_CTX%"}
+	share := func(n, of int) string { return fmt.Sprintf("%.0f%%", float64(n)/float64(of)*100) }
+	for _, id := range ids {
+		c := byPlayer[id]
+		name := nameByID[id]
+		if name == "" {
+			name = strconv.FormatUint(id, 10)
+		}
+		table.Append(name, strconv.Itoa(c.deaths), share(c.moving, c.deaths), share(c.holding, c.deaths),
+			share(c.flashed, c.deaths), share(c.isolated, c.deaths), share(c.afterKill, c.deaths),
+			share(c.top.Deaths, c.deaths))
+		table.Notes = append(table.Notes, fmt.Sprintf("  %s: %s of deaths were %s.",
+			name, share(c.top.Deaths, c.deaths), c.top.Label()))
+	}
+	emit(w, table)
+}
+
+// PrintDeathProfileTable prints what each player died to: deaths grouped by
+// the killer's weapon bucket and distance bin, most frequent first. players
+// supplies names; if focusSteamID is non-zero, only that player is shown.
+func PrintDeathProfileTable(w io.Writer, segs []model.PlayerDeathSegment, players []model.PlayerMatchStats, focusSteamID uint64) {
+	nameByID := make(map[uint64]string, len(players))
+	for _, p := range players {
+		nameByID[p.SteamID] = p.Name
+	}
+	totals := make(map[uint64]int)
+	var relevant []model.PlayerDeathSegment
+	for _, s := range segs {
+		if focusSteamID != 0 && s.SteamID != focusSteamID {
+			continue
+		}
+		relevant = append(relevant, s)
+		totals[s.SteamID] += s.Deaths
+	}
+	if len(relevant) == 0 {
+		emitMissing(w, "Died To (enemy weapon × distance)", "deaths with a known killer weapon", 9)
+		return
+	}
+
+	// Sort: by player SteamID, then deaths descending, then weapon and distance order.
+	sort.Slice(relevant, func(i, j int) bool {
+		a, b := relevant[i], relevant[j]
+		if a.SteamID != b.SteamID {
+			return a.SteamID < b.SteamID
+		}
+		if a.Deaths != b.Deaths {
+			return a.Deaths > b.Deaths
+		}
+		oa, ob := bucketOrder(a.WeaponBucket), bucketOrder(b.WeaponBucket)
+		if oa != ob {
+			return oa < ob
+		}
+		return binOrder(a.DistanceBin) < binOrder(b.DistanceBin)
+	})
+
+	table := TableData{
+		Title: "Died To (enemy weapon × distance)",
+		Description: "Deaths to enemies grouped by the killer's weapon bucket and distance at the kill (world deaths and team kills excluded)\n" +
+			"SHARE%=share of the player's deaths  HS%=deaths that were headshots  DISTANCE=unknown for utility kills or when positions were not captured",
+	}
+	table.Headers = []string{"PLAYER", "WEAPON", "DISTANCE", "DEATHS", "SHARE%", "HS%"}
+	for _, s := range relevant {
+		name := nameByID[s.SteamID]
+		if name == "" {
+			name = strconv.FormatUint(s.SteamID, 10)
+		}
+		table.Append(
+			name,
+			s.WeaponBucket,
+			s.DistanceBin,
+			strconv.Itoa(s.Deaths),
+			fmt.Sprintf("%.0f%%", float64(s.Deaths)/float64(totals[s.SteamID])*100),
+			fmt.Sprintf("%.0f%%", float64(s.HeadshotDeaths)/float64(s.Deaths)*100),
+		)
+	}
+	emit(w, table)
+}
+
+// PrintTimeToDamageTable prints each player's time from first sighting an enemy
+// to first damaging them, per weapon bucket (rows merged across demos).
+func PrintTimeToDamageTable(w io.Writer, rows []model.PlayerTimeToDamage, players []model.PlayerMatchStats) {
+	if len(rows) == 0 {
+		emitMissing(w, "Time to Damage (by weapon)", "time-to-damage samples", 11)
+		return
+	}
+	nameByID := make(map[uint64]string, len(players))
+	for _, p := range players {
+		nameByID[p.SteamID] = p.Name
+	}
+	sorted := append([]model.PlayerTimeToDamage(nil), rows...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.SteamID != b.SteamID {
+			return a.SteamID < b.SteamID
+		}
+		return bucketOrder(a.WeaponBucket) < bucketOrder(b.WeaponBucket)
+	})
+
+	table := TableData{
+		Title: "Time to Damage (by weapon)",
+		Description: "TTDMG=median ms from first seeing an enemy to your first bullet damage on them, kills or not (damage within 5s)\n" +
+			"N=engagements sampled  WEAPON=bucket of the weapon that dealt the first damage  high TTDMG = hesitating or holding fire",
+	}
+	table.Headers = []string{"PLAYER", "WEAPON", "N", "TTDMG"}
+	for _, r := range sorted {
+		name := nameByID[r.SteamID]
+		if name == "" {
+			name = strconv.FormatUint(r.SteamID, 10)
+		}
+		table.Append(name, r.WeaponBucket, strconv.Itoa(r.Samples), fmt.Sprintf("%.0fms", r.MedianMs))
+	}
+	emit(w, table)
+}
+
+// PrintBurstTable prints each player's burst-length mix per weapon bucket
+// (rows merged across demos).
+func PrintBurstTable(w io.Writer, rows []model.PlayerBurstStats, players []model.PlayerMatchStats) {
+	if len(rows) == 0 {
+		emitMissing(w, "Burst Length (by weapon)", "bursts", 16)
+		return
+	}
+	nameByID := make(map[uint64]string, len(players))
+	for _, p := range players {
+		nameByID[p.SteamID] = p.Name
+	}
+	sorted := append([]model.PlayerBurstStats(nil), rows...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.SteamID != b.SteamID {
+			return a.SteamID < b.SteamID
+		}
+		return bucketOrder(a.WeaponBucket) < bucketOrder(b.WeaponBucket)
+	})
+
+	table := TableData{
+		Title: "Burst Length (by weapon)",
+		Description: "A burst is a run of shots with one weapon, each ≤ 200ms after the previous one (AWP/Scout excluded)\n" +
+			"BURSTS=bursts fired  TAP=1 shot  2-3/4-9/10+=% of bursts of that length  high 10+ = over-spraying",
+	}
+	table.Headers = []string{"PLAYER", "WEAPON", "BURSTS", "TAP", "2-3", "4-9", "10+"}
+	for _, r := range sorted {
+		total := r.Taps + r.ShortBursts + r.Sprays + r.PanicSprays
+		if total == 0 {
+			continue
+		}
+		name := nameByID[r.SteamID]
+		if name == "" {
+			name = strconv.FormatUint(r.SteamID, 10)
+		}
+		pct := func(n int) string { return fmt.Sprintf("%.0f%%", float64(n)/float64(total)*100) }
+		table.Append(name, r.WeaponBucket, strconv.Itoa(total),
+			pct(r.Taps), pct(r.ShortBursts), pct(r.Sprays), pct(r.PanicSprays))
+	}
+	emit(w, table)
+}
+
+// PrintPositionTable prints each player's early-round positions per map and
+// side: the label of their main spots and the most played positions with
+// their share of rounds. Shows a hint when no demo carries position samples.
+func PrintPositionTable(w io.Writer, profiles []model.PlayerPositionProfile, players []model.PlayerMatchStats) {
+	if len(profiles) == 0 {
+		emitMissing(w, "Positions (by map and side)", "position samples", 20)
+		return
+	}
+	nameByID := make(map[uint64]string, len(players))
+	for _, p := range players {
+		nameByID[p.SteamID] = p.Name
+	}
+
+	table := TableData{
+		Title: "Positions (by map and side)",
+		Description: "Where the player sets up 10–20s after freeze end: the most sampled callout per round (grid cell when the demo has no callouts)\n" +
+			"RDS=rounds sampled  SPOT=positions held in ≥ 25% of rounds (\"mixed\" if none)  TOP=most played positions with their share of rounds",
+	}
+	table.Headers = []string{"PLAYER", "MAP", "SIDE", "RDS", "SPOT", "TOP"}
+	for _, p := range profiles {
+		name := nameByID[p.SteamID]
+		if name == "" {
+			name = strconv.FormatUint(p.SteamID, 10)
+		}
+		var top []string
+		for i, pl := range p.Places {
+			if i == 3 {
+				break
+			}
+			top = append(top, fmt.Sprintf("%s %.0f%%", pl.Place, float64(pl.Rounds)/float64(p.Rounds)*100))
+		}
+		table.Append(name, strings.TrimPrefix(p.MapName, "de_"), p.Side.String(),
+			strconv.Itoa(p.Rounds), p.Label, strings.Join(top, ", "))
+	}
+	emit(w, table)
+}
+
+// wilsonCI computes the 95% Wilson score confidence interval for a proportion.
+// Returns (lo, hi) as fractions in [0, 1].
+func wilsonCI(hits, n int) (lo, hi float64) {
+	if n == 0 {
+		return 0, 1
+	}
+	z := 1.96
+	p := float64(hits) / float64(n)
+	nf := float64(n)
+	denom := 1 + z*z/nf
+	center := (p + z*z/(2*nf)) / denom
+	half := z * math.Sqrt(p*(1-p)/nf+z*z/(4*nf*nf)) / denom
+	return math.Max(0, center-half), math.Min(1, center+half)
+}
+
+// burstMixDescription explains the BURST_MIX column shared by the per-match
+// and aggregate aim tables.
+const burstMixDescription = "BURST_MIX=% of bursts that were taps / 2-3 / 4-9 / 10+ shots (shots ≤ 200ms apart, same weapon; AWP/Scout excluded)"
+
+// burstMix formats a burst-length histogram as percentage shares, e.g.
+// "55/30/12/3", or "—" when no bursts were recorded.
+func burstMix(taps, short, spray, panic int) string {
+	total := taps + short + spray + panic
+	if total == 0 {
+		return "—"
+	}
+	pct := func(n int) float64 { return float64(n) / float64(total) * 100 }
+	return fmt.Sprintf("%.0f/%.0f/%.0f/%.0f", pct(taps), pct(short), pct(spray), pct(panic))
+}
+
+// aimStaleColumns are the aim timing columns added after the first pipeline
+// version, noted when the displayed data predates them.
+var aimStaleColumns = []staleColumn{{"MOVING_D%", 5}, {"SPRAY_TR/COLLAT", 8}, {"BURST_MIX", 16}}
+
+// PrintAimTimingTable prints the TTK, TTD, Counter-Strafe %, moving-death %,
+// and multi-kill (spray transfer / collateral) table.
+// If focusSteamID is non-zero, that player's row is highlighted with ">".
+// Rows where all three values are zero are shown as "—".
+func PrintAimTimingTable(w io.Writer, stats []model.PlayerMatchStats, focusSteamID uint64) {
+	// Hint instead of a table of dashes when no player has data.
+	hasData := false
+	for _, s := range stats {
+		if s.MedianTTKMs > 0 || s.MedianTTDMs > 0 || s.OneTapKills > 0 {
+			hasData = true
+			break
+		}
+	}
+	if !hasData {
+		emitMissing(w, "Aim Timing & Movement", "aim timing samples", 0)
 		return
 	}
-	printSection(w, "Aim Timing & Movement",
-		"MEDIAN_TTK=median ms from first shot fired → kill, multi-hit kills only (lower = faster finisher)\n"+
-			"MEDIAN_TTD=median ms from enemy's first shot → your death, multi-hit only (lower = died faster)\n"+
-			"ONE_TAP%=% of kills where the first shot fired in a 3s window was the killing shot\n"+
-			"CS%=% of shots fired while horizontal speed ≤ 34 u/s (counter-strafed)")
-	table := tablewriter.NewTable(w, tablewriter.WithConfig(tablewriter.Config{
-		Row:    tw.CellConfig{Alignment: tw.CellAlignment{Global: tw.AlignRight}},
-		Header: tw.CellConfig{Alignment: tw.CellAlignment{Global: tw.AlignCenter}},
-	}))
-	table.Header(" ", "PLAYER", "MEDIAN_TTK", "MEDIAN_TTD", "ONE_TAP%", "CS%")
+	table := TableData{
+		Title:    "Aim Timing & Movement",
+		Sortable: true,
+		Description: "MEDIAN_TTK=median ms from first shot fired → kill, multi-hit kills only (lower = faster finisher)\n" +
+			"MEDIAN_TTD=median ms from enemy's first shot → your death, multi-hit only (lower = died faster)\n" +
+			"ONE_TAP%=% of kills where the first shot fired in a 3s window was the killing shot\n" +
+			"CS%=% of shots fired while horizontal speed ≤ 34 u/s (counter-strafed)\n" +
+			"MOVING_D%=% of deaths where you were moving > 34 u/s when the killer first hit you (high = caught mid-movement)\n" +
+			"SPRAY_TR=kills ≤1.5s after your previous kill with the same weapon, firing throughout, on an enemy already spotted\n" +
+			"COLLAT=kills by the same bullet as your previous kill (incl. wallbang collaterals)\n" +
+			burstMixDescription,
+	}
+	table.Headers = []string{" ", "PLAYER", "MEDIAN_TTK", "MEDIAN_TTD", "ONE_TAP%", "CS%", "MOVING_D%", "SPRAY_TR", "COLLAT", "BURST_MIX"}
+	anyCS := false
 
 	for _, s := range stats {
 		marker := " "
@@ -719,21 +1781,32 @@ func PrintAimTimingTable(w io.Writer, stats []model.PlayerMatchStats, focusSteam
 		if s.CounterStrafePercent > 0 {
 			csStr = fmt.Sprintf("%.0f%%", s.CounterStrafePercent)
 		}
-		table.Append(marker, s.Name, ttkStr, ttdStr, oneTapStr, csStr)
+		movingStr := "—"
+		if s.DeathSpeedSamples > 0 {
+			movingStr = fmt.Sprintf("%.0f%%", s.MovingDeathPct())
+		}
+		table.Append(marker, s.Name, ttkStr, ttdStr, oneTapStr, csStr, movingStr,
+			strconv.Itoa(s.SprayTransferKills), strconv.Itoa(s.CollateralKills),
+			burstMix(s.BurstTaps, s.BurstShort, s.BurstSpray, s.BurstPanic))
+		anyCS = anyCS || s.CounterStrafePercent > 0
+	}
+	if !anyCS {
+		table.Notes = append(table.Notes, "Note: CS% needs shot velocity samples; none were recorded for this demo.")
 	}
-	table.Render()
+	if n := staleNote(aimStaleColumns...); n != "" {
+		table.Notes = append(table.Notes, n)
+	}
+	emit(w, table)
 }
 
 // PrintTrendTable prints a chronological per-match performance table for a player.
 func PrintTrendTable(w io.Writer, stats []model.PlayerMatchStats) {
-	printSection(w, "Performance Trend",
-		"Per-match stats in chronological order.\n"+
-			"DATE=match date  MAP=map  RD=rounds played  KPR=kills/round  ADR=avg damage/round  KAST=KAST%")
-	table := tablewriter.NewTable(w, tablewriter.WithConfig(tablewriter.Config{
-		Row:    tw.CellConfig{Alignment: tw.CellAlignment{Global: tw.AlignRight}},
-		Header: tw.CellConfig{Alignment: tw.CellAlignment{Global: tw.AlignCenter}},
-	}))
-	table.Header("DATE", "MAP", "RD", "K", "A", "D", "K/D", "KPR", "ADR", "KAST%")
+	table := TableData{
+		Title: "Performance Trend",
+		Description: "Per-match stats in chronological order.\n" +
+			"DATE=match date  MAP=map  RD=rounds played  KPR=kills/round  ADR=avg damage/round  " + kastLegend(),
+	}
+	table.Headers = []string{"DATE", "MAP", "RD", "K", "A", "D", "K/D", "KPR", "ADR", "KAST%"}
 
 	for _, s := range stats {
 		mapDisplay := strings.TrimPrefix(s.MapName, "de_")
@@ -751,10 +1824,13 @@ func PrintTrendTable(w io.Writer, stats []model.PlayerMatchStats) {
 			colorKD(s.KDRatio()),
 			kpr,
 			fmt.Sprintf("%.1f", s.ADR()),
-			fmt.Sprintf("%.0f%%", s.KASTPct()),
+			fmt.Sprintf("%.0f%%", kastPct(s.KASTRounds, s.KASTStrictRounds, s.RoundsPlayed)),
 		)
 	}
-	table.Render()
+	if n := kastNote(); n != "" {
+		table.Notes = append(table.Notes, n)
+	}
+	emit(w, table)
 }
 
 // PrintAimTrendTable prints a chronological per-match aim timing table for a player.
@@ -768,17 +1844,16 @@ func PrintAimTrendTable(w io.Writer, stats []model.PlayerMatchStats) {
 		}
 	}
 	if !hasData {
+		emitMissing(w, "Aim Timing Trend", "aim timing samples", 0)
 		return
 	}
-	printSection(w, "Aim Timing Trend",
-		"Per-match aim timing in chronological order.\n"+
-			"MEDIAN_TTK/TTD=ms from first shot fired to kill/death (multi-hit only)\n"+
-			"ONE_TAP%=% of kills that were one-taps  CS%=% of shots fired while counter-strafed (speed ≤ 34 u/s)")
-	table := tablewriter.NewTable(w, tablewriter.WithConfig(tablewriter.Config{
-		Row:    tw.CellConfig{Alignment: tw.CellAlignment{Global: tw.AlignRight}},
-		Header: tw.CellConfig{Alignment: tw.CellAlignment{Global: tw.AlignCenter}},
-	}))
-	table.Header("DATE", "MAP", "RD", "MEDIAN_TTK", "MEDIAN_TTD", "ONE_TAP%", "CS%")
+	table := TableData{
+		Title: "Aim Timing Trend",
+		Description: "Per-match aim timing in chronological order.\n" +
+			"MEDIAN_TTK/TTD=ms from first shot fired to kill/death (multi-hit only)\n" +
+			"ONE_TAP%=% of kills that were one-taps  CS%=% of shots fired while counter-strafed (speed ≤ 34 u/s)",
+	}
+	table.Headers = []string{"DATE", "MAP", "RD", "MEDIAN_TTK", "MEDIAN_TTD", "ONE_TAP%", "CS%"}
 
 	for _, s := range stats {
 		mapDisplay := strings.TrimPrefix(s.MapName, "de_")
@@ -808,7 +1883,7 @@ func PrintAimTrendTable(w io.Writer, stats []model.PlayerMatchStats) {
 			csStr,
 		)
 	}
-	table.Render()
+	emit(w, table)
 }
 
 // clutchCell formats a single 1vN cell as "W/A (P%)" with color based on win rate.
@@ -830,8 +1905,8 @@ func clutchCell(wins, attempts int) string {
 }
 
 // PrintMatchClutchTable prints per-player clutch W/A counts for a single match.
-// Players with no clutch situations are shown as "—". Skips rendering if no player
-// had a clutch situation in the match.
+// Players with no clutch situations are shown as "—". Shows a hint instead if no
+// player had a clutch situation in the match.
 func PrintMatchClutchTable(w io.Writer, stats []model.PlayerMatchStats, clutch map[uint64]*model.PlayerClutchMatchStats) {
 	hasData := false
 	for _, s := range stats {
@@ -841,16 +1916,15 @@ func PrintMatchClutchTable(w io.Writer, stats []model.PlayerMatchStats, clutch m
 		}
 	}
 	if !hasData {
+		emitMissing(w, "Clutch", "clutch situations", 0)
 		return
 	}
-	printSection(w, "Clutch",
-		"Clutch situations this match. W/A (%) = wins/attempts per enemy count.\n"+
-			"Green = all won, yellow = partial, red = none won.")
-	table := tablewriter.NewTable(w, tablewriter.WithConfig(tablewriter.Config{
-		Row:    tw.CellConfig{Alignment: tw.CellAlignment{Global: tw.AlignRight}},
-		Header: tw.CellConfig{Alignment: tw.CellAlignment{Global: tw.AlignCenter}},
-	}))
-	table.Header("PLAYER", "1v1", "1v2", "1v3", "1v4", "1v5", "TOTAL")
+	table := TableData{
+		Title: "Clutch",
+		Description: "Clutch situations this match. W/A (%) = wins/attempts per enemy count.\n" +
+			"Green = all won, yellow = partial, red = none won.",
+	}
+	table.Headers = []string{"PLAYER", "1v1", "1v2", "1v3", "1v4", "1v5", "TOTAL"}
 
 	for _, s := range stats {
 		c := clutch[s.SteamID]
@@ -866,7 +1940,45 @@ func PrintMatchClutchTable(w io.Writer, stats []model.PlayerMatchStats, clutch m
 			clutchCell(c.TotalWins(), c.TotalAttempts()),
 		)
 	}
-	table.Render()
+	emit(w, table)
+}
+
+// PrintObjectiveTable prints bomb objective plays for a single match: defuses,
+// ninja defuses, and plant denials. Only players with at least one objective
+// play are listed; shows a hint if there are none.
+func PrintObjectiveTable(w io.Writer, stats []model.PlayerMatchStats, focusSteamID uint64) {
+	var rows []model.PlayerMatchStats
+	for _, s := range stats {
+		if s.Defuses+s.PlantDenials > 0 {
+			rows = append(rows, s)
+		}
+	}
+	if len(rows) == 0 {
+		emitMissing(w, "Objective Play", "defuses or plant denials", 4)
+		return
+	}
+	table := TableData{
+		Title:    "Objective Play",
+		Sortable: true,
+		Description: "DEFUSES=bombs defused  NINJA=defuses completed with enemies alive within 1000 units that never spotted the defuser\n" +
+			"PLANT_DENY=enemies killed while mid-plant",
+	}
+	table.Headers = []string{" ", "PLAYER", "DEFUSES", "NINJA", "PLANT_DENY"}
+
+	for _, s := range rows {
+		marker := " "
+		if focusSteamID != 0 && s.SteamID == focusSteamID {
+			marker = color.CyanString(">")
+		}
+		table.Append(
+			marker,
+			s.Name,
+			strconv.Itoa(s.Defuses),
+			strconv.Itoa(s.NinjaDefuses),
+			strconv.Itoa(s.PlantDenials),
+		)
+	}
+	emit(w, table)
 }
 
 // PrintPlayerAggregateClutchTable prints clutch W/A counts aggregated across all demos
@@ -886,16 +1998,15 @@ func PrintPlayerAggregateClutchTable(w io.Writer, aggs []model.PlayerAggregate,
 		}
 	}
 	if !hasData {
+		emitMissing(w, "Clutch (Aggregate)", "clutch situations", 0)
 		return
 	}
-	printSection(w, "Clutch (Aggregate)",
-		"Clutch situations aggregated across all matches. W/A = wins/attempts per enemy count.\n"+
-			"Green = all won, yellow = partial, red = none won.")
-	table := tablewriter.NewTable(w, tablewriter.WithConfig(tablewriter.Config{
-		Row:    tw.CellConfig{Alignment: tw.CellAlignment{Global: tw.AlignRight}},
-		Header: tw.CellConfig{Alignment: tw.CellAlignment{Global: tw.AlignCenter}},
-	}))
-	table.Header("PLAYER", "1v1", "1v2", "1v3", "1v4", "1v5", "TOTAL")
+	table := TableData{
+		Title: "Clutch (Aggregate)",
+		Description: "Clutch situations aggregated across all matches. W/A = wins/attempts per enemy count.\n" +
+			"Green = all won, yellow = partial, red = none won.",
+	}
+	table.Headers = []string{"PLAYER", "1v1", "1v2", "1v3", "1v4", "1v5", "TOTAL"}
 
 	for _, a := range aggs {
 		c := byID[a.SteamID]
@@ -911,12 +2022,13 @@ func PrintPlayerAggregateClutchTable(w io.Writer, aggs []model.PlayerAggregate,
 			clutchCell(c.TotalWins(), c.TotalAttempts()),
 		)
 	}
-	table.Render()
+	emit(w, table)
 }
 
 // PrintClutchTrendTable prints a chronological per-match clutch breakdown for a player.
 // Each row shows W/A (wins/attempts) per enemy count (1v1–1v5) for matches that had
-// at least one clutch situation. Skips matches with no clutch data.
+// at least one clutch situation. Skips matches with no clutch data, and shows a
+// hint when none has any.
 func PrintClutchTrendTable(w io.Writer, stats []model.PlayerMatchStats, clutchMap map[string]*model.PlayerClutchMatchStats) {
 	hasData := false
 	for _, s := range stats {
@@ -926,16 +2038,15 @@ func PrintClutchTrendTable(w io.Writer, stats []model.PlayerMatchStats, clutchMa
 		}
 	}
 	if !hasData {
+		emitMissing(w, "Clutch Trend", "clutch situations", 0)
 		return
 	}
-	printSection(w, "Clutch Trend",
-		"Per-match clutch situations in chronological order. W/A = wins/attempts per enemy count.\n"+
-			"Green = all won, yellow = partial, red = none won. TOTAL includes win rate %.")
-	table := tablewriter.NewTable(w, tablewriter.WithConfig(tablewriter.Config{
-		Row:    tw.CellConfig{Alignment: tw.CellAlignment{Global: tw.AlignRight}},
-		Header: tw.CellConfig{Alignment: tw.CellAlignment{Global: tw.AlignCenter}},
-	}))
-	table.Header("DATE", "MAP", "1v1", "1v2", "1v3", "1v4", "1v5", "TOTAL")
+	table := TableData{
+		Title: "Clutch Trend",
+		Description: "Per-match clutch situations in chronological order. W/A = wins/attempts per enemy count.\n" +
+			"Green = all won, yellow = partial, red = none won. TOTAL includes win rate %.",
+	}
+	table.Headers = []string{"DATE", "MAP", "1v1", "1v2", "1v3", "1v4", "1v5", "TOTAL"}
 
 	for _, s := range stats {
 		c := clutchMap[s.DemoHash]
@@ -952,7 +2063,7 @@ func PrintClutchTrendTable(w io.Writer, stats []model.PlayerMatchStats, clutchMa
 			clutchCell(c.TotalWins(), c.TotalAttempts()),
 		)
 	}
-	table.Render()
+	emit(w, table)
 }
 
 // PrintRoundDetailTable prints a per-round drill-down table for a single player in a match.
@@ -960,16 +2071,17 @@ func PrintRoundDetailTable(w io.Writer, stats []model.PlayerRoundStats, playerNa
 	if len(stats) == 0 {
 		return
 	}
-	printSection(w, fmt.Sprintf("%s — %s — %d rounds", playerName, mapName, len(stats)),
-		"SIDE=CT or T  BUY=buy type (full/force/half/eco)  K/A/DMG=kills/assists/damage\n"+
-			"KAST=✓ if earned KAST that round  FLAGS=OPEN_K/OPEN_D/TRADE_K/TRADE_D/POST_PLT/CLUTCH_1vN")
-	table := tablewriter.NewTable(w, tablewriter.WithConfig(tablewriter.Config{
-		Row:    tw.CellConfig{Alignment: tw.CellAlignment{Global: tw.AlignRight}},
-		Header: tw.CellConfig{Alignment: tw.CellAlignment{Global: tw.AlignCenter}},
-	}))
-	table.Header("RD", "SIDE", "BUY", "K", "A", "DMG", "KAST", "FLAGS")
+	table := TableData{
+		Title: fmt.Sprintf("%s — %s — %d rounds", playerName, mapName, len(stats)),
+		Description: "SIDE=CT or T  BUY=buy type (full/force/half/eco)  K/A/DMG=kills/assists/damage\n" +
+			"DIED=seconds after freeze end the player died (— if survived or not recorded)\n" +
+			"KAST=✓ if earned KAST that round  END=W/L and how the round ended (elim/bomb/defuse/time)\n" +
+			"FLAGS=OPEN_K/OPEN_D/TRADE_K/TRADE_D/POST_PLT/CLUTCH_1vN",
+	}
+	table.Headers = []string{"RD", "SIDE", "BUY", "K", "A", "DMG", "DIED", "KAST", "END", "FLAGS"}
 
 	buyCount := make(map[string]int)
+	lossCount := make(map[string]int)
 	for _, s := range stats {
 		buyType := s.BuyType
 		if buyType == "" {
@@ -1003,6 +2115,21 @@ func PrintRoundDetailTable(w io.Writer, stats []model.PlayerRoundStats, playerNa
 		}
 		flagStr := strings.Join(flags, ",")
 
+		diedStr := "—"
+		if s.Deaths > 0 {
+			diedStr = fmt.Sprintf("%.0fs", s.DeathSec)
+		}
+
+		endStr := "—"
+		if s.EndReason != "" {
+			if s.WonRound {
+				endStr = color.GreenString("W " + shortEndReason(s.EndReason))
+			} else {
+				endStr = color.RedString("L " + shortEndReason(s.EndReason))
+				lossCount[s.EndReason]++
+			}
+		}
+
 		table.Append(
 			strconv.Itoa(s.RoundNumber),
 			colorSide(s.Team.String()),
@@ -1010,46 +2137,1391 @@ func PrintRoundDetailTable(w io.Writer, stats []model.PlayerRoundStats, playerNa
 			strconv.Itoa(s.Kills),
 			strconv.Itoa(s.Assists),
 			strconv.Itoa(s.Damage),
+			diedStr,
 			kastStr,
+			endStr,
 			flagStr,
 		)
 	}
-	table.Render()
 
 	// Buy profile summary.
 	total := len(stats)
-	fmt.Fprintf(w, "\nBuy Profile: ")
+	var buy strings.Builder
+	buy.WriteString("Buy Profile: ")
 	for _, bt := range []string{"full", "force", "half", "eco"} {
 		n := buyCount[bt]
-		fmt.Fprintf(w, "%s=%d (%.0f%%)  ", bt, n, float64(n)/float64(total)*100)
+		fmt.Fprintf(&buy, "%s=%d (%.0f%%)  ", bt, n, float64(n)/float64(total)*100)
 	}
-	fmt.Fprintln(w)
-}
+	table.Notes = append(table.Notes, buy.String())
 
-// PrintPlayerAggregateAimTable prints TTK/TTD/one-tap stats aggregated across all demos.
-func PrintPlayerAggregateAimTable(w io.Writer, aggs []model.PlayerAggregate) {
-	hasData := false
-	for _, a := range aggs {
-		if a.AvgTTKMs > 0 || a.AvgTTDMs > 0 || a.OneTapKills > 0 || a.Role != "" {
-			hasData = true
-			break
+	// Loss reasons summary (only when end reasons were recorded).
+	if len(lossCount) > 0 {
+		var losses strings.Builder
+		losses.WriteString("Losses by Reason: ")
+		for _, reason := range endReasonOrder {
+			if n := lossCount[reason]; n > 0 {
+				fmt.Fprintf(&losses, "%s=%d  ", shortEndReason(reason), n)
+			}
 		}
+		table.Notes = append(table.Notes, losses.String())
 	}
-	if !hasData {
+	emit(w, table)
+}
+
+// endReasonOrder is the display order of round end reasons.
+var endReasonOrder = []string{
+	model.EndReasonElimination, model.EndReasonBomb, model.EndReasonDefuse,
+	model.EndReasonTime, model.EndReasonSurrender, model.EndReasonOther,
+}
+
+// shortEndReason abbreviates a round end reason for table cells.
+func shortEndReason(reason string) string {
+	if reason == model.EndReasonElimination {
+		return "elim"
+	}
+	return reason
+}
+
+// defensiveUtilityDescription is the legend shared by the per-match and
+// aggregate defensive utility tables.
+const defensiveUtilityDescription = "UTIL_TAKEN=HE/molotov/incendiary damage received from enemies  UTIL/RD=per round played\n" +
+	"FLASHED=times blinded by an enemy flash  BLIND_S=total seconds blind from enemy flashes  BLIND/RD=seconds per round\n" +
+	"Self and team utility are excluded. Lower is better: it measures how well enemy utility is avoided."
+
+// defensiveUtilityCells formats the UTIL_TAKEN..BLIND/RD cells.
+func defensiveUtilityCells(utilTaken, flashed int, blindSec float64, rounds int) []string {
+	utilPerRound, blindPerRound := "—", "—"
+	if rounds > 0 {
+		utilPerRound = fmt.Sprintf("%.1f", float64(utilTaken)/float64(rounds))
+		blindPerRound = fmt.Sprintf("%.2f", blindSec/float64(rounds))
+	}
+	return []string{
+		strconv.Itoa(utilTaken),
+		utilPerRound,
+		strconv.Itoa(flashed),
+		fmt.Sprintf("%.1f", blindSec),
+		blindPerRound,
+	}
+}
+
+// PrintClutchTimelineTable lists clutches round by round with where each one
+// starts: the round clock and tick of the death that left the clutcher alone,
+// the enemies alive then, the result, and a demo_gototick console command
+// jumping lead seconds earlier. names resolves SteamIDs; tickrate converts
+// the lead to ticks (64 when unknown).
+// Columns: RD | PLAYER | SIDE | VS | START | TICK | ENEMIES | RESULT | GOTO
+func PrintClutchTimelineTable(w io.Writer, clutches []model.PlayerRoundStats, names map[uint64]string, mapName string, tickrate, lead float64) {
+	title := fmt.Sprintf("Clutches — %s", mapName)
+	if len(clutches) == 0 {
+		emitMissing(w, title, "clutch situations", 0)
 		return
 	}
-	printSection(w, "Aim Timing & Movement (Aggregate)",
-		"ROLE=most common heuristic role across matches\n"+
-			"AVG_TTK/AVG_TTD=average of per-match median ms from first shot fired, multi-hit kills only\n"+
-			"ONE_TAP%=one-tap kills as % of total kills across all matches\n"+
-			"AVG_CS%=average per-match counter-strafe % (shots at horizontal speed ≤ 34 u/s)")
-	table := tablewriter.NewTable(w, tablewriter.WithConfig(tablewriter.Config{
-		Row:    tw.CellConfig{Alignment: tw.CellAlignment{Global: tw.AlignRight}},
-		Header: tw.CellConfig{Alignment: tw.CellAlignment{Global: tw.AlignCenter}},
-	}))
-	table.Header("PLAYER", "ROLE", "AVG_TTK", "AVG_TTD", "ONE_TAP%", "AVG_CS%")
+	if tickrate <= 0 {
+		tickrate = 64
+	}
+	table := TableData{
+		Title: title,
+		Description: "VS=enemies alive when the player was left alone  START=round clock (after freeze end) at that moment  TICK=its demo tick\n" +
+			"ENEMIES=those enemies  RESULT=round won/lost and whether the clutcher survived\n" +
+			fmt.Sprintf("GOTO=demo playback console command jumping to %.0fs before the start", lead),
+	}
+	table.Headers = []string{"RD", "PLAYER", "SIDE", "VS", "START", "TICK", "ENEMIES", "RESULT", "GOTO"}
 
-	for _, a := range aggs {
+	name := func(id uint64) string {
+		if n, ok := names[id]; ok {
+			return n
+		}
+		return strconv.FormatUint(id, 10)
+	}
+	for _, c := range clutches {
+		startStr, tickStr, gotoStr := "—", "—", "—"
+		if c.ClutchStartTick > 0 {
+			sec := int(c.ClutchStartSec)
+			startStr = fmt.Sprintf("%d:%02d", sec/60, sec%60)
+			tickStr = strconv.Itoa(c.ClutchStartTick)
+			gotoStr = fmt.Sprintf("demo_gototick %d", max(c.ClutchStartTick-int(lead*tickrate), 0))
+		}
+		enemies := make([]string, len(c.ClutchEnemies))
+		for i, id := range c.ClutchEnemies {
+			enemies[i] = name(id)
+		}
+		enemyStr := "—"
+		if len(enemies) > 0 {
+			enemyStr = strings.Join(enemies, ", ")
+		}
+		result := "lost"
+		if c.WonRound {
+			result = color.GreenString("won")
+		}
+		if c.Survived {
+			result += ", survived"
+		}
+		table.Append(strconv.Itoa(c.RoundNumber), name(c.SteamID), colorSide(c.Team.String()),
+			fmt.Sprintf("1v%d", c.ClutchEnemyCount), startStr, tickStr, enemyStr, result, gotoStr)
+	}
+	if n := staleNote(staleColumn{"START/TICK/ENEMIES/GOTO", 26}); n != "" {
+		table.Notes = append(table.Notes, n)
+	}
+	emit(w, table)
+}
+
+// PrintDefensiveUtilityTable prints enemy utility damage and flash blindness
+// received per player. Shows a hint when no one took either (e.g. demos stored
+// before these were recorded).
+// Columns: PLAYER | UTIL_TAKEN | UTIL/RD | FLASHED | BLIND_S | BLIND/RD
+func PrintDefensiveUtilityTable(w io.Writer, stats []model.PlayerMatchStats, focusSteamID uint64) {
+	hasData := false
+	for _, s := range stats {
+		if s.UtilityDamageTaken > 0 || s.FlashesReceived > 0 {
+			hasData = true
+			break
+		}
+	}
+	if !hasData {
+		emitMissing(w, "Defensive Utility", "utility damage taken or flashes received", 14)
+		return
+	}
+	table := TableData{
+		Title:       "Defensive Utility",
+		Sortable:    true,
+		Description: defensiveUtilityDescription,
+	}
+	table.Headers = []string{" ", "PLAYER", "UTIL_TAKEN", "UTIL/RD", "FLASHED", "BLIND_S", "BLIND/RD"}
+
+	for _, s := range stats {
+		marker := " "
+		if focusSteamID != 0 && s.SteamID == focusSteamID {
+			marker = color.CyanString(">")
+		}
+		table.Append(append([]string{marker, s.Name},
+			defensiveUtilityCells(s.UtilityDamageTaken, s.FlashesReceived, s.BlindTimeReceivedSec, s.RoundsPlayed)...)...)
+	}
+	emit(w, table)
+}
+
+// PrintPlayerAggregateDefensiveUtilityTable prints enemy utility damage and
+// flash blindness received, summed across matches.
+func PrintPlayerAggregateDefensiveUtilityTable(w io.Writer, aggs []model.PlayerAggregate) {
+	hasData := false
+	for _, a := range aggs {
+		if a.UtilityDamageTaken > 0 || a.FlashesReceived > 0 {
+			hasData = true
+			break
+		}
+	}
+	if !hasData {
+		emitMissing(w, "Defensive Utility", "utility damage taken or flashes received", 14)
+		return
+	}
+	table := TableData{
+		Title:       "Defensive Utility",
+		Sortable:    true,
+		Description: defensiveUtilityDescription,
+	}
+	table.Headers = []string{"PLAYER", "UTIL_TAKEN", "UTIL/RD", "FLASHED", "BLIND_S", "BLIND/RD"}
+
+	for _, a := range aggs {
+		table.Append(append([]string{a.Name},
+			defensiveUtilityCells(a.UtilityDamageTaken, a.FlashesReceived, a.BlindTimeReceivedSec, a.RoundsPlayed)...)...)
+	}
+	emit(w, table)
+}
+
+// utilitySynergyDescription is the legend shared by the per-match and
+// aggregate utility synergy tables.
+const utilitySynergyDescription = "Kills played off a teammate's utility — the other side of flash assists\n" +
+	"OFF_FLASH=kills within 2s of a teammate's flash blinding the victim  OFF_SMOKE=kills through a teammate's smoke\n" +
+	"OFF_UTIL=kills that were either (counted once)  OFF_UTIL%=OFF_UTIL / K  FA=your own flash assists"
+
+// utilitySynergyCells formats the K..FA cells.
+func utilitySynergyCells(kills, offFlash, offSmoke, offUtility, flashAssists int) []string {
+	pct := "—"
+	if kills > 0 {
+		pct = fmt.Sprintf("%.0f%%", float64(offUtility)/float64(kills)*100)
+	}
+	return []string{strconv.Itoa(kills), strconv.Itoa(offFlash), strconv.Itoa(offSmoke),
+		strconv.Itoa(offUtility), pct, strconv.Itoa(flashAssists)}
+}
+
+// PrintUtilitySynergyTable prints kills each player played off teammates'
+// flashes and smokes. Shows a hint when no one did (e.g. demos stored before
+// these were recorded).
+// Columns: PLAYER | K | OFF_FLASH | OFF_SMOKE | OFF_UTIL | OFF_UTIL% | FA
+func PrintUtilitySynergyTable(w io.Writer, stats []model.PlayerMatchStats, focusSteamID uint64) {
+	hasData := false
+	for _, s := range stats {
+		if s.PlayedOffUtilityKills > 0 {
+			hasData = true
+			break
+		}
+	}
+	if !hasData {
+		emitMissing(w, "Utility Synergy", "utility synergy events", 21)
+		return
+	}
+	table := TableData{
+		Title:       "Utility Synergy",
+		Sortable:    true,
+		Description: utilitySynergyDescription,
+	}
+	table.Headers = []string{" ", "PLAYER", "K", "OFF_FLASH", "OFF_SMOKE", "OFF_UTIL", "OFF_UTIL%", "FA"}
+
+	for _, s := range stats {
+		marker := " "
+		if focusSteamID != 0 && s.SteamID == focusSteamID {
+			marker = color.CyanString(">")
+		}
+		table.Append(append([]string{marker, s.Name},
+			utilitySynergyCells(s.Kills, s.PlayedOffFlashKills, s.PlayedOffSmokeKills, s.PlayedOffUtilityKills, s.FlashAssists)...)...)
+	}
+	emit(w, table)
+}
+
+// PrintPlayerAggregateUtilitySynergyTable prints kills played off teammates'
+// utility summed across matches.
+func PrintPlayerAggregateUtilitySynergyTable(w io.Writer, aggs []model.PlayerAggregate) {
+	hasData := false
+	for _, a := range aggs {
+		if a.PlayedOffUtilityKills > 0 {
+			hasData = true
+			break
+		}
+	}
+	if !hasData {
+		emitMissing(w, "Utility Synergy", "utility synergy events", 21)
+		return
+	}
+	table := TableData{
+		Title:       "Utility Synergy",
+		Sortable:    true,
+		Description: utilitySynergyDescription,
+	}
+	table.Headers = []string{"PLAYER", "K", "OFF_FLASH", "OFF_SMOKE", "OFF_UTIL", "OFF_UTIL%", "FA"}
+
+	for _, a := range aggs {
+		table.Append(append([]string{a.Name},
+			utilitySynergyCells(a.Kills, a.PlayedOffFlashKills, a.PlayedOffSmokeKills, a.PlayedOffUtilityKills, a.FlashAssists)...)...)
+	}
+	emit(w, table)
+}
+
+// utilityTimingDescription is the legend shared by the per-match and
+// aggregate utility timing tables.
+const utilityTimingDescription = "Grenades thrown by phase of the round clock (throws in freeze time or after the round ended are skipped)\n" +
+	"EARLY=within 25s of freeze end (setup)  LATE=after the plant or with ≤ 20s on the round timer  MID=everything between\n" +
+	"SETUP%=EARLY / THROWS  MED_SEC=median seconds after freeze end of all throws"
+
+// utilityTimingCells formats the THROWS..MED_SEC cells.
+func utilityTimingCells(early, mid, late int, medSec float64) []string {
+	throws := early + mid + late
+	setup, med := "—", "—"
+	if throws > 0 {
+		setup = fmt.Sprintf("%.0f%%", float64(early)/float64(throws)*100)
+		med = fmt.Sprintf("%.0f", medSec)
+	}
+	return []string{strconv.Itoa(throws), strconv.Itoa(early), strconv.Itoa(mid), strconv.Itoa(late), setup, med}
+}
+
+// PrintUtilityTimingTable prints when in the round each player threw their
+// grenades. Shows a hint when no throw was recorded (e.g. demos stored before
+// grenade throws were captured).
+// Columns: PLAYER | THROWS | EARLY | MID | LATE | SETUP% | MED_SEC
+func PrintUtilityTimingTable(w io.Writer, stats []model.PlayerMatchStats, focusSteamID uint64) {
+	hasData := false
+	for i := range stats {
+		if stats[i].UtilThrows() > 0 {
+			hasData = true
+			break
+		}
+	}
+	if !hasData {
+		emitMissing(w, "Utility Timing", "grenade throws", 42)
+		return
+	}
+	table := TableData{
+		Title:       "Utility Timing",
+		Sortable:    true,
+		Description: utilityTimingDescription,
+	}
+	table.Headers = []string{" ", "PLAYER", "THROWS", "EARLY", "MID", "LATE", "SETUP%", "MED_SEC"}
+
+	for _, s := range stats {
+		marker := " "
+		if focusSteamID != 0 && s.SteamID == focusSteamID {
+			marker = color.CyanString(">")
+		}
+		table.Append(append([]string{marker, s.Name},
+			utilityTimingCells(s.UtilThrowsEarly, s.UtilThrowsMid, s.UtilThrowsLate, s.MedianUtilThrowSec)...)...)
+	}
+	emit(w, table)
+}
+
+// PrintPlayerAggregateUtilityTimingTable prints grenade throws by round phase
+// summed across matches; MED_SEC is the mean of the per-match medians.
+func PrintPlayerAggregateUtilityTimingTable(w io.Writer, aggs []model.PlayerAggregate) {
+	hasData := false
+	for i := range aggs {
+		if aggs[i].UtilThrows() > 0 {
+			hasData = true
+			break
+		}
+	}
+	if !hasData {
+		emitMissing(w, "Utility Timing", "grenade throws", 42)
+		return
+	}
+	table := TableData{
+		Title:       "Utility Timing",
+		Sortable:    true,
+		Description: utilityTimingDescription,
+	}
+	table.Headers = []string{"PLAYER", "THROWS", "EARLY", "MID", "LATE", "SETUP%", "MED_SEC"}
+
+	for _, a := range aggs {
+		table.Append(append([]string{a.Name},
+			utilityTimingCells(a.UtilThrowsEarly, a.UtilThrowsMid, a.UtilThrowsLate, a.AvgUtilThrowSec)...)...)
+	}
+	table.Notes = append(table.Notes, "MED_SEC is the mean of the per-match medians")
+	emit(w, table)
+}
+
+// lateRoundDescription is the legend shared by the per-match and aggregate
+// late-round discipline tables.
+const lateRoundDescription = "Clock = round timer before a plant, bomb timer after (calibrated from rounds that ran out / exploded, else 1:55 and 40s)\n" +
+	"LATE_D=deaths with ≤ 20s left on the clock\n" +
+	"PFT_RDS=rounds alive in a play-for-time spot: ≤ 20s left, clock on your side (CT pre-plant, T post-plant), your side up in players\n" +
+	"PFT_D=deaths in those spots  PFT_D%=PFT_D / PFT_RDS (lower is better: the round was won by not taking fights)"
+
+// lateRoundCells formats the LATE_D..PFT_D% cells.
+func lateRoundCells(lateDeaths, pftRounds, pftDeaths int) []string {
+	rate := "—"
+	if pftRounds > 0 {
+		rate = fmt.Sprintf("%.0f%%", float64(pftDeaths)/float64(pftRounds)*100)
+	}
+	return []string{strconv.Itoa(lateDeaths), strconv.Itoa(pftRounds), strconv.Itoa(pftDeaths), rate}
+}
+
+// PrintLateRoundTable prints late-round deaths and play-for-time discipline
+// per player. Shows a hint when no one reached a late-round spot (e.g. demos
+// stored before these were recorded).
+// Columns: PLAYER | LATE_D | PFT_RDS | PFT_D | PFT_D%
+func PrintLateRoundTable(w io.Writer, stats []model.PlayerMatchStats, focusSteamID uint64) {
+	hasData := false
+	for _, s := range stats {
+		if s.LateRoundDeaths > 0 || s.PlayForTimeRounds > 0 {
+			hasData = true
+			break
+		}
+	}
+	if !hasData {
+		emitMissing(w, "Late-Round Discipline", "late-round samples", 17)
+		return
+	}
+	table := TableData{
+		Title:       "Late-Round Discipline",
+		Sortable:    true,
+		Description: lateRoundDescription,
+	}
+	table.Headers = []string{" ", "PLAYER", "LATE_D", "PFT_RDS", "PFT_D", "PFT_D%"}
+
+	for _, s := range stats {
+		marker := " "
+		if focusSteamID != 0 && s.SteamID == focusSteamID {
+			marker = color.CyanString(">")
+		}
+		table.Append(append([]string{marker, s.Name},
+			lateRoundCells(s.LateRoundDeaths, s.PlayForTimeRounds, s.PlayForTimeDeaths)...)...)
+	}
+	emit(w, table)
+}
+
+// PrintPlayerAggregateLateRoundTable prints late-round deaths and
+// play-for-time discipline summed across matches.
+func PrintPlayerAggregateLateRoundTable(w io.Writer, aggs []model.PlayerAggregate) {
+	hasData := false
+	for _, a := range aggs {
+		if a.LateRoundDeaths > 0 || a.PlayForTimeRounds > 0 {
+			hasData = true
+			break
+		}
+	}
+	if !hasData {
+		emitMissing(w, "Late-Round Discipline", "late-round samples", 17)
+		return
+	}
+	table := TableData{
+		Title:       "Late-Round Discipline",
+		Sortable:    true,
+		Description: lateRoundDescription,
+	}
+	table.Headers = []string{"PLAYER", "LATE_D", "PFT_RDS", "PFT_D", "PFT_D%"}
+
+	for _, a := range aggs {
+		table.Append(append([]string{a.Name},
+			lateRoundCells(a.LateRoundDeaths, a.PlayForTimeRounds, a.PlayForTimeDeaths)...)...)
+	}
+	emit(w, table)
+}
+
+// weaponDisciplineDescription is the legend shared by the per-match and
+// aggregate weapon discipline tables.
+const weaponDisciplineDescription = "PRIM_D=deaths to enemies with a rifle, SMG, heavy weapon or sniper in the inventory\n" +
+	"WRONG_D=of those, deaths holding a pistol, knife or grenade at the kill (caught switching or walking with the knife out)\n" +
+	"WRONG%=WRONG_D / PRIM_D (lower is better; the bomb and the Zeus are not counted as wrong)"
+
+// weaponDisciplineCells formats the PRIM_D..WRONG% cells.
+func weaponDisciplineCells(primaryDeaths, wrongDeaths int) []string {
+	rate := "—"
+	if primaryDeaths > 0 {
+		rate = fmt.Sprintf("%.0f%%", float64(wrongDeaths)/float64(primaryDeaths)*100)
+	}
+	return []string{strconv.Itoa(primaryDeaths), strconv.Itoa(wrongDeaths), rate}
+}
+
+// PrintWeaponDisciplineTable prints each player's deaths caught on the wrong
+// weapon while carrying a primary. Shows a hint when no death with a primary
+// was recorded (e.g. demos stored before the victim's loadout was captured).
+// Columns: PLAYER | PRIM_D | WRONG_D | WRONG%
+func PrintWeaponDisciplineTable(w io.Writer, stats []model.PlayerMatchStats, focusSteamID uint64) {
+	hasData := false
+	for _, s := range stats {
+		if s.PrimaryDeaths > 0 {
+			hasData = true
+			break
+		}
+	}
+	if !hasData {
+		emitMissing(w, "Weapon Discipline", "victim loadouts", 38)
+		return
+	}
+	table := TableData{
+		Title:       "Weapon Discipline",
+		Sortable:    true,
+		Description: weaponDisciplineDescription,
+	}
+	table.Headers = []string{" ", "PLAYER", "PRIM_D", "WRONG_D", "WRONG%"}
+
+	for _, s := range stats {
+		marker := " "
+		if focusSteamID != 0 && s.SteamID == focusSteamID {
+			marker = color.CyanString(">")
+		}
+		table.Append(append([]string{marker, s.Name},
+			weaponDisciplineCells(s.PrimaryDeaths, s.WrongWeaponDeaths)...)...)
+	}
+	emit(w, table)
+}
+
+// PrintPlayerAggregateWeaponDisciplineTable prints deaths caught on the wrong
+// weapon summed across matches.
+func PrintPlayerAggregateWeaponDisciplineTable(w io.Writer, aggs []model.PlayerAggregate) {
+	hasData := false
+	for _, a := range aggs {
+		if a.PrimaryDeaths > 0 {
+			hasData = true
+			break
+		}
+	}
+	if !hasData {
+		emitMissing(w, "Weapon Discipline", "victim loadouts", 38)
+		return
+	}
+	table := TableData{
+		Title:       "Weapon Discipline",
+		Sortable:    true,
+		Description: weaponDisciplineDescription,
+	}
+	table.Headers = []string{"PLAYER", "PRIM_D", "WRONG_D", "WRONG%"}
+
+	for _, a := range aggs {
+		table.Append(append([]string{a.Name},
+			weaponDisciplineCells(a.PrimaryDeaths, a.WrongWeaponDeaths)...)...)
+	}
+	emit(w, table)
+}
+
+// duelLossReasonDescription is the legend shared by the per-match and
+// aggregate duel loss reason tables.
+const duelLossReasonDescription = "DUEL_L=duels lost (deaths)  factors are not exclusive — one loss can count in several columns\n" +
+	"FLASHED=blinded by a flash in the 1.5s before dying  ISOLATED=no alive teammate within 512u at the death\n" +
+	"UTIL=the killer played off a teammate's flash or smoke  REPEEK=had already killed an enemy that round\n" +
+	"NONE=losses with none of the factors (a straight aim duel lost)  TOP=most frequent factor"
+
+// duelLossReasonCells formats the DUEL_L..TOP cells: each factor as a share
+// of the losses, and the most frequent one.
+func duelLossReasonCells(losses, flashed, isolated, utilSupport, rePeek, explained int) []string {
+	pct := func(n int) string {
+		if losses == 0 {
+			return "—"
+		}
+		return fmt.Sprintf("%.0f%%", float64(n)/float64(losses)*100)
+	}
+	top, topN := "—", 0
+	for _, f := range []struct {
+		name string
+		n    int
+	}{{"flashed", flashed}, {"isolated", isolated}, {"util", utilSupport}, {"re-peek", rePeek}} {
+		if f.n > topN {
+			top, topN = f.name, f.n
+		}
+	}
+	return []string{strconv.Itoa(losses), pct(flashed), pct(isolated), pct(utilSupport), pct(rePeek),
+		pct(max(losses-explained, 0)), top}
+}
+
+// PrintDuelLossReasonTable prints the factors behind each player's lost
+// duels. Shows a hint when no loss has a factor recorded (e.g. demos stored
+// before loss reasons were computed).
+// Columns: PLAYER | DUEL_L | FLASHED | ISOLATED | UTIL | REPEEK | NONE | TOP
+func PrintDuelLossReasonTable(w io.Writer, stats []model.PlayerMatchStats, focusSteamID uint64) {
+	hasData := false
+	for _, s := range stats {
+		if s.LossExplained > 0 {
+			hasData = true
+			break
+		}
+	}
+	if !hasData {
+		emitMissing(w, "Duel Loss Reasons", "duel loss reasons", 39)
+		return
+	}
+	table := TableData{
+		Title:       "Duel Loss Reasons",
+		Sortable:    true,
+		Description: duelLossReasonDescription,
+	}
+	table.Headers = []string{" ", "PLAYER", "DUEL_L", "FLASHED", "ISOLATED", "UTIL", "REPEEK", "NONE", "TOP"}
+
+	for _, s := range stats {
+		marker := " "
+		if focusSteamID != 0 && s.SteamID == focusSteamID {
+			marker = color.CyanString(">")
+		}
+		table.Append(append([]string{marker, s.Name},
+			duelLossReasonCells(s.DuelLosses, s.LossFlashed, s.LossIsolated, s.LossUtilSupport, s.LossRePeek, s.LossExplained)...)...)
+	}
+	emit(w, table)
+}
+
+// PrintPlayerAggregateDuelLossReasonTable prints the factors behind lost
+// duels summed across matches.
+func PrintPlayerAggregateDuelLossReasonTable(w io.Writer, aggs []model.PlayerAggregate) {
+	hasData := false
+	for _, a := range aggs {
+		if a.LossExplained > 0 {
+			hasData = true
+			break
+		}
+	}
+	if !hasData {
+		emitMissing(w, "Duel Loss Reasons", "duel loss reasons", 39)
+		return
+	}
+	table := TableData{
+		Title:       "Duel Loss Reasons",
+		Sortable:    true,
+		Description: duelLossReasonDescription,
+	}
+	table.Headers = []string{"PLAYER", "DUEL_L", "FLASHED", "ISOLATED", "UTIL", "REPEEK", "NONE", "TOP"}
+
+	for _, a := range aggs {
+		table.Append(append([]string{a.Name},
+			duelLossReasonCells(a.DuelLosses, a.LossFlashed, a.LossIsolated, a.LossUtilSupport, a.LossRePeek, a.LossExplained)...)...)
+	}
+	emit(w, table)
+}
+
+// momentumDescription is the legend shared by the per-match, aggregate and
+// trend momentum tables.
+const momentumDescription = "Runs of team round results within a half (reset at halftime and each overtime half)\n" +
+	"BEST_RUN=longest team round-win run  ADR=all rounds\n" +
+	"STREAK_*=rounds after ≥ 3 straight team wins  BOUNCE_*=rounds after ≥ 3 straight losses (bounce-back)\n" +
+	"BOUNCE_W%=bounce-back rounds won  HALF_FK=halves opened by this player's kill"
+
+// momentumCells formats the ADR..HALF_FK cells.
+func momentumCells(damage, rounds, longest, streakRounds, streakKills, streakDamage,
+	bounceRounds, bounceKills, bounceDamage, bounceWins, halfFirstKills int) []string {
+	perRound := func(n, rds int, format string) string {
+		if rds == 0 {
+			return "—"
+		}
+		return fmt.Sprintf(format, float64(n)/float64(rds))
+	}
+	winPct := "—"
+	if bounceRounds > 0 {
+		winPct = fmt.Sprintf("%.0f%%", float64(bounceWins)/float64(bounceRounds)*100)
+	}
+	return []string{
+		perRound(damage, rounds, "%.1f"),
+		strconv.Itoa(longest),
+		strconv.Itoa(streakRounds),
+		perRound(streakKills, streakRounds, "%.2f"),
+		perRound(streakDamage, streakRounds, "%.1f"),
+		strconv.Itoa(bounceRounds),
+		perRound(bounceKills, bounceRounds, "%.2f"),
+		perRound(bounceDamage, bounceRounds, "%.1f"),
+		winPct,
+		strconv.Itoa(halfFirstKills),
+	}
+}
+
+// momentumHeaders are the column headers produced by momentumCells.
+var momentumHeaders = []string{"ADR", "BEST_RUN", "STREAK_RD", "STREAK_KPR", "STREAK_ADR",
+	"BOUNCE_RD", "BOUNCE_KPR", "BOUNCE_ADR", "BOUNCE_W%", "HALF_FK"}
+
+// PrintMomentumTable prints each player's output on team win streaks and in
+// bounce-back rounds after losing streaks. Shows a hint when no player has a
+// recorded run (e.g. demos stored before these were recorded).
+// Columns: PLAYER | ADR | BEST_RUN | STREAK_RD..STREAK_ADR | BOUNCE_RD..BOUNCE_W% | HALF_FK
+func PrintMomentumTable(w io.Writer, stats []model.PlayerMatchStats, focusSteamID uint64) {
+	hasData := false
+	for _, s := range stats {
+		if s.LongestWinStreak > 0 {
+			hasData = true
+			break
+		}
+	}
+	if !hasData {
+		emitMissing(w, "Momentum", "momentum rounds", 19)
+		return
+	}
+	table := TableData{
+		Title:       "Momentum",
+		Sortable:    true,
+		Description: momentumDescription,
+	}
+	table.Headers = append([]string{" ", "PLAYER"}, momentumHeaders...)
+
+	for _, s := range stats {
+		marker := " "
+		if focusSteamID != 0 && s.SteamID == focusSteamID {
+			marker = color.CyanString(">")
+		}
+		table.Append(append([]string{marker, s.Name},
+			momentumCells(s.TotalDamage, s.RoundsPlayed, s.LongestWinStreak,
+				s.StreakRounds, s.StreakKills, s.StreakDamage,
+				s.BounceRounds, s.BounceKills, s.BounceDamage, s.BounceWins, s.HalfFirstKills)...)...)
+	}
+	emit(w, table)
+}
+
+// PrintPlayerAggregateMomentumTable prints streak and bounce-back output
+// summed across matches; BEST_RUN is the longest run in any match.
+func PrintPlayerAggregateMomentumTable(w io.Writer, aggs []model.PlayerAggregate) {
+	hasData := false
+	for _, a := range aggs {
+		if a.LongestWinStreak > 0 {
+			hasData = true
+			break
+		}
+	}
+	if !hasData {
+		emitMissing(w, "Momentum", "momentum rounds", 19)
+		return
+	}
+	table := TableData{
+		Title:       "Momentum",
+		Sortable:    true,
+		Description: momentumDescription,
+	}
+	table.Headers = append([]string{"PLAYER"}, momentumHeaders...)
+
+	for _, a := range aggs {
+		table.Append(append([]string{a.Name},
+			momentumCells(a.TotalDamage, a.RoundsPlayed, a.LongestWinStreak,
+				a.StreakRounds, a.StreakKills, a.StreakDamage,
+				a.BounceRounds, a.BounceKills, a.BounceDamage, a.BounceWins, a.HalfFirstKills)...)...)
+	}
+	emit(w, table)
+}
+
+// PrintMomentumTrendTable prints a chronological per-match momentum table for
+// a player. It is only rendered if at least one match has a recorded run.
+func PrintMomentumTrendTable(w io.Writer, stats []model.PlayerMatchStats) {
+	hasData := false
+	for _, s := range stats {
+		if s.LongestWinStreak > 0 {
+			hasData = true
+			break
+		}
+	}
+	if !hasData {
+		emitMissing(w, "Momentum Trend", "momentum rounds", 19)
+		return
+	}
+	table := TableData{
+		Title:       "Momentum Trend",
+		Description: "Per-match momentum in chronological order.\n" + momentumDescription,
+	}
+	table.Headers = append([]string{"DATE", "MAP", "RD"}, momentumHeaders...)
+
+	for _, s := range stats {
+		table.Append(append([]string{s.MatchDate, strings.TrimPrefix(s.MapName, "de_"), strconv.Itoa(s.RoundsPlayed)},
+			momentumCells(s.TotalDamage, s.RoundsPlayed, s.LongestWinStreak,
+				s.StreakRounds, s.StreakKills, s.StreakDamage,
+				s.BounceRounds, s.BounceKills, s.BounceDamage, s.BounceWins, s.HalfFirstKills)...)...)
+	}
+	emit(w, table)
+}
+
+// scorelineDescription is the legend shared by the per-match and aggregate
+// scoreline split tables.
+const scorelineDescription = "Decided rounds split by the team's match score when the round started (teams followed across side swaps)\n" +
+	"LEAD_*=team ahead  TIED_*=level  TRAIL_*=team behind  *_RD=rounds  K/D and ADR over those rounds\n" +
+	"TRAIL_ΔADR=trailing ADR minus ADR over all split rounds (red at ≤ −10: output drops when behind)"
+
+// scorelineHeaders are the column headers produced by scorelineCells.
+var scorelineHeaders = []string{"LEAD_RD", "LEAD_K/D", "LEAD_ADR", "TIED_RD", "TIED_K/D", "TIED_ADR",
+	"TRAIL_RD", "TRAIL_K/D", "TRAIL_ADR", "TRAIL_ΔADR"}
+
+// scorelineCells formats the LEAD_RD..TRAIL_ΔADR cells.
+func scorelineCells(leading, tied, trailing model.ScorelineSplit) []string {
+	var cells []string
+	for _, sp := range []model.ScorelineSplit{leading, tied, trailing} {
+		if sp.Rounds == 0 {
+			cells = append(cells, "0", "—", "—")
+			continue
+		}
+		kd := float64(sp.Kills)
+		if sp.Deaths > 0 {
+			kd /= float64(sp.Deaths)
+		}
+		cells = append(cells, strconv.Itoa(sp.Rounds), colorKD(kd), fmt.Sprintf("%.1f", float64(sp.Damage)/float64(sp.Rounds)))
+	}
+	all := leading.Add(tied).Add(trailing)
+	delta := "—"
+	if trailing.Rounds > 0 {
+		d := float64(trailing.Damage)/float64(trailing.Rounds) - float64(all.Damage)/float64(all.Rounds)
+		delta = fmt.Sprintf("%+.1f", d)
+		if d <= -10 {
+			delta = color.RedString(delta)
+		}
+	}
+	return append(cells, delta)
+}
+
+// PrintScorelineTable prints each player's K/D and ADR with their team
+// leading, tied and trailing in the match score. Shows a hint when no player
+// has split rounds (e.g. demos stored before these were recorded).
+// Columns: PLAYER | LEAD_RD..LEAD_ADR | TIED_RD..TIED_ADR | TRAIL_RD..TRAIL_ΔADR
+func PrintScorelineTable(w io.Writer, stats []model.PlayerMatchStats, focusSteamID uint64) {
+	hasData := false
+	for _, s := range stats {
+		if s.Leading.Rounds+s.Tied.Rounds+s.Trailing.Rounds > 0 {
+			hasData = true
+			break
+		}
+	}
+	if !hasData {
+		emitMissing(w, "Scoreline Splits", "scoreline splits", 27)
+		return
+	}
+	table := TableData{
+		Title:       "Scoreline Splits",
+		Sortable:    true,
+		Description: scorelineDescription,
+	}
+	table.Headers = append([]string{" ", "PLAYER"}, scorelineHeaders...)
+
+	for _, s := range stats {
+		marker := " "
+		if focusSteamID != 0 && s.SteamID == focusSteamID {
+			marker = color.CyanString(">")
+		}
+		table.Append(append([]string{marker, s.Name}, scorelineCells(s.Leading, s.Tied, s.Trailing)...)...)
+	}
+	emit(w, table)
+}
+
+// PrintPlayerAggregateScorelineTable prints scoreline splits summed across
+// matches.
+func PrintPlayerAggregateScorelineTable(w io.Writer, aggs []model.PlayerAggregate) {
+	hasData := false
+	for _, a := range aggs {
+		if a.Leading.Rounds+a.Tied.Rounds+a.Trailing.Rounds > 0 {
+			hasData = true
+			break
+		}
+	}
+	if !hasData {
+		emitMissing(w, "Scoreline Splits", "scoreline splits", 27)
+		return
+	}
+	table := TableData{
+		Title:       "Scoreline Splits",
+		Sortable:    true,
+		Description: scorelineDescription,
+	}
+	table.Headers = append([]string{"PLAYER"}, scorelineHeaders...)
+
+	for _, a := range aggs {
+		table.Append(append([]string{a.Name}, scorelineCells(a.Leading, a.Tied, a.Trailing)...)...)
+	}
+	emit(w, table)
+}
+
+// loadoutDescription is the legend shared by the per-match and aggregate
+// loadout efficiency tables.
+const loadoutDescription = "Output per $1000 of the player's own freeze-end loadout (weapons, armor, utility), summed over rounds played\n" +
+	"AVG_LOADOUT=mean loadout value per round  K/$1K=kills per $1000  DMG/$1K=damage per $1000\n" +
+	"Cheap rounds weigh little: compare players with similar AVG_LOADOUT (e.g. who deserves the AWP)"
+
+// loadoutHeaders are the column headers produced by loadoutCells.
+var loadoutHeaders = []string{"RD", "AVG_LOADOUT", "K", "K/$1K", "DMG", "DMG/$1K"}
+
+// loadoutCells formats the RD..DMG/$1K cells.
+func loadoutCells(rounds, kills, damage, equipValue int) []string {
+	avg, kPer, dmgPer := "—", "—", "—"
+	if rounds > 0 {
+		avg = fmt.Sprintf("$%d", equipValue/rounds)
+	}
+	if equipValue > 0 {
+		k := float64(equipValue) / 1000
+		kPer = fmt.Sprintf("%.2f", float64(kills)/k)
+		dmgPer = fmt.Sprintf("%.1f", float64(damage)/k)
+	}
+	return []string{strconv.Itoa(rounds), avg, strconv.Itoa(kills), kPer, strconv.Itoa(damage), dmgPer}
+}
+
+// PrintLoadoutEfficiencyTable prints each player's kills and damage per $1000
+// of equipment they carried out of freeze time.
+func PrintLoadoutEfficiencyTable(w io.Writer, stats []model.PlayerMatchStats, focusSteamID uint64) {
+	hasData := false
+	for _, s := range stats {
+		if s.EquipmentValue > 0 {
+			hasData = true
+			break
+		}
+	}
+	if !hasData {
+		emitMissing(w, "Loadout Efficiency", "loadout values", 28)
+		return
+	}
+	table := TableData{
+		Title:       "Loadout Efficiency",
+		Sortable:    true,
+		Description: loadoutDescription,
+	}
+	table.Headers = append([]string{" ", "PLAYER"}, loadoutHeaders...)
+
+	for _, s := range stats {
+		marker := " "
+		if focusSteamID != 0 && s.SteamID == focusSteamID {
+			marker = color.CyanString(">")
+		}
+		table.Append(append([]string{marker, s.Name}, loadoutCells(s.RoundsPlayed, s.Kills, s.TotalDamage, s.EquipmentValue)...)...)
+	}
+	emit(w, table)
+}
+
+// PrintPlayerAggregateLoadoutEfficiencyTable prints loadout efficiency summed
+// across matches.
+func PrintPlayerAggregateLoadoutEfficiencyTable(w io.Writer, aggs []model.PlayerAggregate) {
+	hasData := false
+	for _, a := range aggs {
+		if a.EquipmentValue > 0 {
+			hasData = true
+			break
+		}
+	}
+	if !hasData {
+		emitMissing(w, "Loadout Efficiency", "loadout values", 28)
+		return
+	}
+	table := TableData{
+		Title:       "Loadout Efficiency",
+		Sortable:    true,
+		Description: loadoutDescription,
+	}
+	table.Headers = append([]string{"PLAYER"}, loadoutHeaders...)
+
+	for _, a := range aggs {
+		table.Append(append([]string{a.Name}, loadoutCells(a.RoundsPlayed, a.Kills, a.TotalDamage, a.EquipmentValue)...)...)
+	}
+	emit(w, table)
+}
+
+// tierLabel names a demos.tier value for display.
+func tierLabel(tier string) string {
+	if tier == "" {
+		return "(none)"
+	}
+	return tier
+}
+
+// pooledPeekerBaseline sums the per-tier baselines into one corpus-wide row.
+func pooledPeekerBaseline(baselines []model.PeekerBaseline) model.PeekerBaseline {
+	all := model.PeekerBaseline{Tier: "all"}
+	for _, b := range baselines {
+		all.Duels += b.Duels
+		all.PeekerWins += b.PeekerWins
+	}
+	return all
+}
+
+// PrintPlayerAggregatePeekTable prints each player's win share as the peeker
+// and as the holder of mutual-sight duels, against the corpus holder win
+// share; the per-tier corpus baselines follow as notes.
+func PrintPlayerAggregatePeekTable(w io.Writer, aggs []model.PlayerAggregate, baselines []model.PeekerBaseline) {
+	hasData := false
+	for _, a := range aggs {
+		if a.PeekDuels+a.HoldDuels > 0 {
+			hasData = true
+			break
+		}
+	}
+	if !hasData {
+		emitMissing(w, "Peeker's Advantage", "classified peek duels", 29)
+		return
+	}
+	all := pooledPeekerBaseline(baselines)
+	table := TableData{
+		Title:    "Peeker's Advantage",
+		Sortable: true,
+		Description: "Kills between two players who had both spotted each other; the one moving (> 34 u/s) at their first sight peeked, the still one held\n" +
+			"PEEKS/HOLDS=duels as peeker/holder  PEEK_W%/HOLD_W%=share won  BASE_HOLD_W%=holder win share over every stored demo\n" +
+			"ΔHOLD=HOLD_W% minus BASE_HOLD_W% (red at ≤ −5: loses specifically as the holder)",
+	}
+	table.Headers = []string{"PLAYER", "PEEKS", "PEEK_W%", "HOLDS", "HOLD_W%", "BASE_HOLD_W%", "ΔHOLD"}
+
+	pct := func(won, n int) string {
+		if n == 0 {
+			return "—"
+		}
+		return fmt.Sprintf("%.0f%%", float64(won)/float64(n)*100)
+	}
+	base := "—"
+	if all.Duels > 0 {
+		base = fmt.Sprintf("%.0f%%", 100-all.PeekerWinPct())
+	}
+	for _, a := range aggs {
+		delta := "—"
+		if a.HoldDuels > 0 && all.Duels > 0 {
+			d := float64(a.HoldWins)/float64(a.HoldDuels)*100 - (100 - all.PeekerWinPct())
+			delta = fmt.Sprintf("%+.1f", d)
+			if d <= -5 {
+				delta = color.RedString(delta)
+			}
+		}
+		table.Append(a.Name, strconv.Itoa(a.PeekDuels), pct(a.PeekWins, a.PeekDuels),
+			strconv.Itoa(a.HoldDuels), pct(a.HoldWins, a.HoldDuels), base, delta)
+	}
+	if len(baselines) > 0 {
+		parts := make([]string, 0, len(baselines)+1)
+		parts = append(parts, fmt.Sprintf("all %.0f%% (N=%d)", all.PeekerWinPct(), all.Duels))
+		for _, b := range baselines {
+			parts = append(parts, fmt.Sprintf("%s %.0f%% (N=%d)", tierLabel(b.Tier), b.PeekerWinPct(), b.Duels))
+		}
+		table.Notes = append(table.Notes, "Corpus peeker win share by tier: "+strings.Join(parts, "; "))
+	}
+	emit(w, table)
+}
+
+// PrintPeekerBaselineTable prints the corpus peeker's advantage per demo tier
+// with a pooled row.
+func PrintPeekerBaselineTable(w io.Writer, baselines []model.PeekerBaseline) {
+	if len(baselines) == 0 {
+		emitMissing(w, "Peeker's Advantage", "classified peek duels", 29)
+		return
+	}
+	table := TableData{
+		Title: "Peeker's Advantage",
+		Description: "Mutual-sight kills where exactly one player was moving (> 34 u/s) at their first sight, over every stored demo\n" +
+			"DUELS=classified duels  PEEKER_W%=share won by the moving player  HOLDER_W%=share won by the still one",
+	}
+	table.Headers = []string{"TIER", "DUELS", "PEEKER_W%", "HOLDER_W%"}
+	row := func(b model.PeekerBaseline) {
+		table.Append(tierLabel(b.Tier), strconv.Itoa(b.Duels),
+			fmt.Sprintf("%.1f%%", b.PeekerWinPct()), fmt.Sprintf("%.1f%%", 100-b.PeekerWinPct()))
+	}
+	for _, b := range baselines {
+		row(b)
+	}
+	row(pooledPeekerBaseline(baselines))
+	emit(w, table)
+}
+
+// PrintTiltTable prints a player's multi-match sessions (one match date each)
+// with their win/loss record and losing streaks, followed by the tilt
+// indicator: the rating after a loss against the player's baseline. Only
+// rendered when some session has at least two matches.
+func PrintTiltTable(w io.Writer, t model.PlayerTilt) {
+	if len(t.Sessions) == 0 {
+		return
+	}
+	table := TableData{
+		Title: "Sessions & Tilt",
+		Description: fmt.Sprintf("Match dates with at least two matches, in stored order within a day (dates carry no time).\n"+
+			"W-L=matches won-lost by rounds  LOSS_RUN=most lost matches in a row  RATING=mean per-match rating\n"+
+			"Tilt: mean rating of the match after a loss (same session) vs the baseline; flagged at -%.2f over %d+ matches",
+			model.TiltRatingDrop, model.TiltMinSamples),
+	}
+	table.Headers = []string{"DATE", "MATCHES", "W-L", "LOSS_RUN", "RATING"}
+	for _, ss := range t.Sessions {
+		run := "—"
+		if ss.LongestLossStreak > 0 {
+			run = strconv.Itoa(ss.LongestLossStreak)
+		}
+		table.Append(ss.Date, strconv.Itoa(ss.Matches), fmt.Sprintf("%d-%d", ss.Wins, ss.Losses),
+			run, fmt.Sprintf("%.2f", ss.AvgRating))
+	}
+
+	note := fmt.Sprintf("Losing streaks (%d+ in a session): %d  Baseline rating: %.2f", model.LossStreakMin, t.LossStreaks, t.BaselineRating)
+	if t.AfterWin > 0 {
+		note += fmt.Sprintf("  After a win: %.2f (%d)", t.AfterWinRating, t.AfterWin)
+	}
+	table.Notes = append(table.Notes, note)
+	switch {
+	case t.AfterLoss == 0:
+		table.Notes = append(table.Notes, "Tilt: — (no match played after a loss in the same session)")
+	case t.AfterLoss < model.TiltMinSamples:
+		table.Notes = append(table.Notes, fmt.Sprintf("Tilt: — after a loss %.2f (%+.2f) over %d match(es), too few to call",
+			t.AfterLossRating, t.PostLossDelta(), t.AfterLoss))
+	case t.Tilted():
+		table.Notes = append(table.Notes, color.RedString("Tilt: yes — after a loss %.2f (%+.2f) over %d matches",
+			t.AfterLossRating, t.PostLossDelta(), t.AfterLoss))
+	default:
+		table.Notes = append(table.Notes, color.GreenString("Tilt: no — after a loss %.2f (%+.2f) over %d matches",
+			t.AfterLossRating, t.PostLossDelta(), t.AfterLoss))
+	}
+	emit(w, table)
+}
+
+// PrintProgressTable prints a player's report card diff: each metric's mean
+// per match over the recent window against the previous one, with the change,
+// an arrow colored by whether it is an improvement, and the significance flag.
+func PrintProgressTable(w io.Writer, p model.PlayerProgress) {
+	table := TableData{
+		Title: fmt.Sprintf("Progress: last %d vs previous %d matches", p.Recent, p.Previous),
+		Description: fmt.Sprintf("RECENT %s – %s   PREVIOUS %s – %s\n", p.RecentFrom, p.RecentTo, p.PreviousFrom, p.PreviousTo) +
+			"PREV/RECENT=mean per match  Δ=recent − previous  TREND=▲ up / ▼ down (green=better, red=worse)  N=matches with a value (prev/recent)\n" +
+			fmt.Sprintf("T=Welch's t on the per-match values  SIG=|T| ≥ %.0f with %d+ matches in each window: likely a real change, not noise",
+				model.ProgressSignificantT, model.ProgressMinMatches),
+	}
+	table.Headers = []string{"METRIC", "PREV", "RECENT", "Δ", "TREND", "N", "T", "SIG"}
+	var better, worse int
+	for _, m := range p.Metrics {
+		prev, rec, delta, trend := "—", "—", "—", ""
+		if m.PreviousN > 0 {
+			prev = fmt.Sprintf(m.Format, m.Previous)
+		}
+		if m.RecentN > 0 {
+			rec = fmt.Sprintf(m.Format, m.Recent)
+		}
+		if m.PreviousN > 0 && m.RecentN > 0 {
+			delta = fmt.Sprintf(strings.Replace(m.Format, "%", "%+", 1), m.Delta())
+			switch {
+			case m.Delta() == 0:
+				trend = "="
+			case m.Delta() > 0 && m.Improved():
+				trend = color.GreenString("▲")
+			case m.Delta() > 0:
+				trend = color.RedString("▲")
+			case m.Improved():
+				trend = color.GreenString("▼")
+			default:
+				trend = color.RedString("▼")
+			}
+		}
+		sig := ""
+		if m.Significant() {
+			if m.Improved() {
+				sig, better = color.GreenString("SIG"), better+1
+			} else {
+				sig, worse = color.RedString("SIG"), worse+1
+			}
+		}
+		table.Append(m.Name, prev, rec, delta, trend,
+			fmt.Sprintf("%d/%d", m.PreviousN, m.RecentN), fmt.Sprintf("%+.1f", m.T), sig)
+	}
+	if p.Recent < p.Window || p.Previous < p.Window {
+		table.Notes = append(table.Notes, fmt.Sprintf("--window %d: only %d recent and %d previous match(es) stored", p.Window, p.Recent, p.Previous))
+	}
+	table.Notes = append(table.Notes, fmt.Sprintf("Significant changes: %d better, %d worse of %d metrics", better, worse, len(p.Metrics)))
+	emit(w, table)
+}
+
+// Lobby percentile bands: the top and bottom quarter of the lobby are colored
+// and counted in the Focus vs Lobby note.
+const (
+	lobbyTopPct    = 75.0
+	lobbyBottomPct = 25.0
+)
+
+// PrintLobbyComparisonTable prints the focus player's main metrics in one
+// match next to the lobby average, with their rank and percentile among the
+// players in the match.
+func PrintLobbyComparisonTable(w io.Writer, c model.LobbyComparison) {
+	table := TableData{
+		Title: fmt.Sprintf("Focus vs Lobby: %s", c.Name),
+		Description: fmt.Sprintf("How %s compares with the %d players in this match.\n", c.Name, c.Players) +
+			"PLAYER=focus player's value  LOBBY_AVG=mean over every player with a value (focus included)  DIFF=player − lobby average\n" +
+			"RANK=place in the lobby (1 = best; lower is better for crosshair and TTK)  PCTL=share of the other players beaten, ties count half\n" +
+			fmt.Sprintf("green=top quarter (PCTL ≥ %.0f)  red=bottom quarter (PCTL ≤ %.0f)  — = no value for the focus player", lobbyTopPct, lobbyBottomPct),
+	}
+	table.Headers = []string{"METRIC", "PLAYER", "LOBBY_AVG", "DIFF", "RANK", "PCTL"}
+	var top, bottom, ranked int
+	for _, m := range c.Metrics {
+		if !m.HasValue {
+			table.Append(m.Name, "—", "—", "—", "—", "—")
+			continue
+		}
+		rank, pctl := "—", "—"
+		if m.LobbyN > 1 {
+			ranked++
+			rank = fmt.Sprintf("%d/%d", m.Rank, m.LobbyN)
+			pctl = fmt.Sprintf("%.0f", m.Percentile)
+			switch {
+			case m.Percentile >= lobbyTopPct:
+				pctl, top = color.GreenString(pctl), top+1
+			case m.Percentile <= lobbyBottomPct:
+				pctl, bottom = color.RedString(pctl), bottom+1
+			}
+		}
+		diff := m.Diff()
+		if math.Abs(diff) < 1e-9 {
+			diff = 0 // no "-0.0" from float noise
+		}
+		table.Append(m.Name,
+			fmt.Sprintf(m.Format, m.Value),
+			fmt.Sprintf(m.Format, m.LobbyAvg),
+			fmt.Sprintf(strings.Replace(m.Format, "%", "%+", 1), diff),
+			rank, pctl)
+	}
+	table.Notes = append(table.Notes, fmt.Sprintf("%s: top quarter of the lobby on %d, bottom quarter on %d of %d metrics", c.Name, top, bottom, ranked))
+	emit(w, table)
+}
+
+// PrintRoundEndReasonTable prints how each side won its rounds in one match.
+// Shows a hint when the demo predates end-reason capture.
+func PrintRoundEndReasonTable(w io.Writer, outcomes []model.RoundOutcome) {
+	type counts map[string]int
+	bySide := map[model.Team]counts{model.TeamCT: {}, model.TeamT: {}}
+	hasData := false
+	for _, o := range outcomes {
+		if o.EndReason == "" {
+			continue
+		}
+		if c, ok := bySide[o.WinnerTeam]; ok {
+			c[o.EndReason]++
+			hasData = true
+		}
+	}
+	if !hasData {
+		emitMissing(w, "Round End Reasons", "round end reasons", 7)
+		return
+	}
+	table := TableData{
+		Title: "Round End Reasons",
+		Description: "How each side won its rounds: ELIM=all enemies killed  BOMB=bomb exploded  DEFUSE=bomb defused\n" +
+			"TIME=timer ran out without a plant  OTHER=surrender, draw, or anything else",
+	}
+	table.Headers = []string{"SIDE", "WON", "ELIM", "BOMB", "DEFUSE", "TIME", "OTHER"}
+	cell := func(n int) string {
+		if n == 0 {
+			return "—"
+		}
+		return strconv.Itoa(n)
+	}
+	for _, side := range []model.Team{model.TeamCT, model.TeamT} {
+		c := bySide[side]
+		won := 0
+		for _, n := range c {
+			won += n
+		}
+		table.Append(
+			colorSide(side.String()),
+			strconv.Itoa(won),
+			cell(c[model.EndReasonElimination]),
+			cell(c[model.EndReasonBomb]),
+			cell(c[model.EndReasonDefuse]),
+			cell(c[model.EndReasonTime]),
+			cell(c[model.EndReasonSurrender]+c[model.EndReasonOther]),
+		)
+	}
+	emit(w, table)
+}
+
+// PrintTeamEconomyTable prints each team's round wins per economy class in one
+// match, following the teams across halves by the sides in outcomes like the
+// round progression strip. Shows a hint when the demo predates team economy
+// capture.
+func PrintTeamEconomyTable(w io.Writer, rounds []model.TeamRoundEconomy, outcomes []model.RoundOutcome) {
+	if len(rounds) == 0 {
+		emitMissing(w, "Team Economy", "team equipment values", 24)
+		return
+	}
+	type tally struct{ wins, total int }
+	// Index 0 is the team that started on CT, 1 the team that started on T.
+	var teams [2]map[string]*tally
+	for i := range teams {
+		teams[i] = make(map[string]*tally)
+	}
+	sides := newMatchSides(outcomes)
+	for _, r := range rounds {
+		team := 1
+		if r.Team == sides.sideOf(r.RoundNumber) {
+			team = 0
+		}
+		t := teams[team][r.RoundType]
+		if t == nil {
+			t = &tally{}
+			teams[team][r.RoundType] = t
+		}
+		t.total++
+		if r.Won {
+			t.wins++
+		}
+	}
+	table := TableData{
+		Title: "Team Economy",
+		Description: "Rounds won/played per economy class, from each side's summed freeze-end equipment value (per-player average):\n" +
+			"PISTOL=first round of a half  FULL-ECO=<$1000  SEMI-ECO=$1000-1999  FORCE=$2000-3999  FULL-BUY=$4000+",
+	}
+	table.Headers = []string{"TEAM", "PISTOL", "FULL-ECO", "SEMI-ECO", "FORCE", "FULL-BUY"}
+	for i, label := range []string{"Started CT", "Started T"} {
+		row := []string{label}
+		for _, rt := range model.TeamRoundTypes {
+			t := teams[i][rt]
+			if t == nil {
+				row = append(row, "—")
+				continue
+			}
+			row = append(row, fmt.Sprintf("%d/%d (%.0f%%)", t.wins, t.total, 100*float64(t.wins)/float64(t.total)))
+		}
+		table.Append(row...)
+	}
+	emit(w, table)
+}
+
+// PrintTradeChainTable prints each team's trade chain exchanges in one match,
+// following the teams across halves like the team economy table: chains won,
+// even and lost by kills, kills for and against, and how often each exchange
+// outcome ("2-for-1") occurred. Shows a hint when the demo predates trade
+// chain capture or has no chains.
+func PrintTradeChainTable(w io.Writer, chains []model.TradeChain, outcomes []model.RoundOutcome) {
+	if len(chains) == 0 {
+		emitMissing(w, "Trade Chains", "trade chains", 34)
+		return
+	}
+	type tally struct {
+		chains, won, even, lost int
+		kills, deaths           int
+		outcomes                map[[2]int]int
+	}
+	// Index 0 is the team that started on CT, 1 the team that started on T.
+	var teams [2]tally
+	for i := range teams {
+		teams[i].outcomes = make(map[[2]int]int)
+	}
+	matchSides := newMatchSides(outcomes)
+	for _, c := range chains {
+		sides := []model.Team{model.TeamCT, model.TeamT}
+		if matchSides.sideOf(c.RoundNumber) == model.TeamT {
+			sides[0], sides[1] = model.TeamT, model.TeamCT
+		}
+		for i, side := range sides {
+			got, gave := c.Exchange(side)
+			t := &teams[i]
+			t.chains++
+			t.kills += got
+			t.deaths += gave
+			t.outcomes[[2]int{got, gave}]++
+			switch {
+			case got > gave:
+				t.won++
+			case got == gave:
+				t.even++
+			default:
+				t.lost++
+			}
+		}
+	}
+	table := TableData{
+		Title: "Trade Chains",
+		Description: "Chain=enemy kills in a round linked within 5s by trades, re-trades and multi-kills of chain killers (≥ 1 trade)\n" +
+			"WON/EVEN/LOST=chains where the team got more/as many/fewer kills  K-D=chain kills for-against\n" +
+			"EXCHANGES=outcomes as kills-for-deaths (2-for-1 = two kills for one death), most frequent first",
+	}
+	table.Headers = []string{"TEAM", "CHAINS", "WON", "EVEN", "LOST", "K-D", "EXCHANGES"}
+	for i, label := range []string{"Started CT", "Started T"} {
+		t := teams[i]
+		type outcome struct {
+			got, gave, n int
+		}
+		var outs []outcome
+		for k, n := range t.outcomes {
+			outs = append(outs, outcome{k[0], k[1], n})
+		}
+		sort.Slice(outs, func(a, b int) bool {
+			if outs[a].n != outs[b].n {
+				return outs[a].n > outs[b].n
+			}
+			return outs[a].got-outs[a].gave > outs[b].got-outs[b].gave
+		})
+		var parts []string
+		for _, o := range outs {
+			parts = append(parts, fmt.Sprintf("%d-for-%d ×%d", o.got, o.gave, o.n))
+		}
+		table.Append(label, strconv.Itoa(t.chains), strconv.Itoa(t.won), strconv.Itoa(t.even), strconv.Itoa(t.lost),
+			fmt.Sprintf("%d-%d", t.kills, t.deaths), strings.Join(parts, ", "))
+	}
+	emit(w, table)
+}
+
+// PrintTeamConcentrationTable prints how evenly each team's kills and damage
+// were spread over its players in one match: Gini coefficients and the top
+// player's share, flagging teams whose top damage share reaches
+// model.TopHeavyShare.
+func PrintTeamConcentrationTable(w io.Writer, teams []model.TeamConcentration) {
+	if len(teams) == 0 {
+		return
+	}
+	table := TableData{
+		Title: "Team Concentration",
+		Description: "TEAM=side most rounds were played on  GINI=0 all players equal, 0.80 one of five did everything\n" +
+			fmt.Sprintf("TOP_K%%/TOP_DMG%%=top player's share of the team's kills/damage (20%% = even split; ⚠ at %.0f%%+ damage)", 100*model.TopHeavyShare),
+		Headers: []string{"TEAM", "PLAYERS", "KILL_GINI", "TOP_K%", "TOP_KILLER", "DMG_GINI", "TOP_DMG%", "TOP_DAMAGER"},
+	}
+	for _, c := range teams {
+		topDmg := fmt.Sprintf("%.0f%%", 100*c.TopDamageShare)
+		if c.TopDamageShare >= model.TopHeavyShare {
+			topDmg = color.YellowString(topDmg + " ⚠")
+		}
+		table.Append(colorSide(c.Team.String()), strconv.Itoa(c.Players),
+			fmt.Sprintf("%.2f", c.KillGini), fmt.Sprintf("%.0f%%", 100*c.TopKillShare), c.TopKiller,
+			fmt.Sprintf("%.2f", c.DamageGini), topDmg, c.TopDamager)
+	}
+	emit(w, table)
+}
+
+// PrintPlayerAggregateAimTable prints TTK/TTD/one-tap stats aggregated across all demos.
+func PrintPlayerAggregateAimTable(w io.Writer, aggs []model.PlayerAggregate) {
+	hasData := false
+	for _, a := range aggs {
+		if a.AvgTTKMs > 0 || a.AvgTTDMs > 0 || a.OneTapKills > 0 || a.Role != "" {
+			hasData = true
+			break
+		}
+	}
+	if !hasData {
+		emitMissing(w, "Aim Timing & Movement (Aggregate)", "aim timing samples", 0)
+		return
+	}
+	table := TableData{
+		Title:    "Aim Timing & Movement (Aggregate)",
+		Sortable: true,
+		Description: "ROLE=most common heuristic role across matches\n" +
+			"AVG_TTK/AVG_TTD=average of per-match median ms from first shot fired, multi-hit kills only\n" +
+			"ONE_TAP%=one-tap kills as % of total kills across all matches\n" +
+			"AVG_CS%=average per-match counter-strafe % (shots at horizontal speed ≤ 34 u/s)\n" +
+			"MOVING_D%=% of deaths across all matches where you were moving > 34 u/s when the killer first hit you\n" +
+			"SPRAY_TR=spray-transfer kills across all matches  COLLAT=collateral kills across all matches\n" +
+			burstMixDescription + " (all matches)",
+	}
+	table.Headers = []string{"PLAYER", "ROLE", "AVG_TTK", "AVG_TTD", "ONE_TAP%", "AVG_CS%", "MOVING_D%", "SPRAY_TR", "COLLAT", "BURST_MIX"}
+
+	for _, a := range aggs {
 		role := a.Role
 		if role == "" {
 			role = "Rifler"
@@ -1070,32 +3542,36 @@ func PrintPlayerAggregateAimTable(w io.Writer, aggs []model.PlayerAggregate) {
 		if a.AvgCounterStrafePct > 0 {
 			csStr = fmt.Sprintf("%.0f%%", a.AvgCounterStrafePct)
 		}
-		table.Append(a.Name, role, ttkStr, ttdStr, oneTapStr, csStr)
+		movingStr := "—"
+		if a.DeathSpeedSamples > 0 {
+			movingStr = fmt.Sprintf("%.0f%%", a.MovingDeathPct())
+		}
+		table.Append(a.Name, role, ttkStr, ttdStr, oneTapStr, csStr, movingStr,
+			strconv.Itoa(a.SprayTransferKills), strconv.Itoa(a.CollateralKills),
+			burstMix(a.BurstTaps, a.BurstShort, a.BurstSpray, a.BurstPanic))
+	}
+	if n := staleNote(aimStaleColumns...); n != "" {
+		table.Notes = append(table.Notes, n)
 	}
-	table.Render()
+	emit(w, table)
 }
 
 // PrintWeaponTable prints a per-weapon breakdown table.
 // If focusSteamID is non-zero, only rows for that player are shown.
 func PrintWeaponTable(w io.Writer, stats []model.PlayerWeaponStats, players []model.PlayerMatchStats, focusSteamID uint64) {
-	printSection(w, "Weapon Breakdown",
-		"K=kills with this weapon  HS%=headshot kill %  A=assists  D=deaths  DAMAGE=total damage dealt\n"+
-			"HITS=total hits landed  DMG/HIT=average damage per hit")
+	table := TableData{
+		Title:    "Weapon Breakdown",
+		Sortable: true,
+		Description: "K=kills with this weapon  HS%=headshot kill %  A=assists  D=deaths  DAMAGE=total damage dealt\n" +
+			"HITS=total hits landed  HEAD_HIT%=share of HITS on the head  DMG/HIT=average damage per hit",
+	}
 	// Build name lookup.
 	nameByID := make(map[uint64]string, len(players))
 	for _, p := range players {
 		nameByID[p.SteamID] = p.Name
 	}
 
-	table := tablewriter.NewTable(w, tablewriter.WithConfig(tablewriter.Config{
-		Row: tw.CellConfig{
-			Alignment: tw.CellAlignment{Global: tw.AlignRight},
-		},
-		Header: tw.CellConfig{
-			Alignment: tw.CellAlignment{Global: tw.AlignCenter},
-		},
-	}))
-	table.Header("PLAYER", "WEAPON", "K", "HS%", "A", "D", "DAMAGE", "HITS", "DMG/HIT")
+	table.Headers = []string{"PLAYER", "WEAPON", "K", "HS%", "A", "D", "DAMAGE", "HITS", "HEAD_HIT%", "DMG/HIT"}
 
 	for i := range stats {
 		s := &stats[i]
@@ -1115,8 +3591,683 @@ func PrintWeaponTable(w io.Writer, stats []model.PlayerWeaponStats, players []mo
 			strconv.Itoa(s.Deaths),
 			strconv.Itoa(s.Damage),
 			strconv.Itoa(s.Hits),
+			headHitCell(s.Hits, s.HeadHitPct()),
 			fmt.Sprintf("%.1f", s.AvgDamagePerHit()),
 		)
 	}
-	table.Render()
+	if n := staleNote(headHitStaleColumn); n != "" {
+		table.Notes = append(table.Notes, n)
+	}
+	emit(w, table)
+}
+
+// PrintFirstSightBinsTable prints a histogram of stored first-sight crosshair
+// angles for one player in one match, bucketed by the ascending edges in degrees.
+// A final open-ended bucket catches angles above the last edge.
+func PrintFirstSightBinsTable(w io.Writer, sights []model.RawFirstSight, edges []float64, playerName, mapName string) {
+	if len(sights) == 0 {
+		return
+	}
+	table := TableData{
+		Title: fmt.Sprintf("%s — %s — %d first sights", playerName, mapName, len(sights)),
+		Description: "BUCKET=crosshair angle to enemy head at first sight (degrees)  N=sights in bucket\n" +
+			"%=share of all sights  CUM%=share at or below the bucket's upper edge  MED_PITCH/MED_YAW=median split",
+	}
+	table.Headers = []string{"BUCKET", "N", "%", "CUM%", "MED_PITCH", "MED_YAW"}
+
+	type bucket struct{ pitch, yaw []float64 }
+	buckets := make([]bucket, len(edges)+1)
+	for _, fs := range sights {
+		i := sort.SearchFloat64s(edges, fs.AngleDeg)
+		// SearchFloat64s returns the first edge ≥ angle, so an angle equal to an
+		// edge lands in the bucket that edge closes.
+		buckets[i].pitch = append(buckets[i].pitch, fs.PitchDeg)
+		buckets[i].yaw = append(buckets[i].yaw, fs.YawDeg)
+	}
+
+	medianOf := func(vals []float64) string {
+		if len(vals) == 0 {
+			return "—"
+		}
+		s := append([]float64(nil), vals...)
+		sort.Float64s(s)
+		n := len(s)
+		if n%2 == 1 {
+			return fmt.Sprintf("%.1f°", s[n/2])
+		}
+		return fmt.Sprintf("%.1f°", (s[n/2-1]+s[n/2])/2)
+	}
+
+	total := float64(len(sights))
+	cum := 0
+	for i, b := range buckets {
+		var label string
+		switch {
+		case i == 0:
+			label = fmt.Sprintf("≤%g°", edges[0])
+		case i == len(edges):
+			label = fmt.Sprintf(">%g°", edges[i-1])
+		default:
+			label = fmt.Sprintf("%g–%g°", edges[i-1], edges[i])
+		}
+		n := len(b.pitch)
+		cum += n
+		table.Append(
+			label,
+			strconv.Itoa(n),
+			fmt.Sprintf("%.1f%%", float64(n)/total*100),
+			fmt.Sprintf("%.1f%%", float64(cum)/total*100),
+			medianOf(b.pitch),
+			medianOf(b.yaw),
+		)
+	}
+	emit(w, table)
+}
+
+// PrintFirstSightRowsTable prints every stored first-sight event for one player
+// in one match, ordered as given (round, then tick).
+func PrintFirstSightRowsTable(w io.Writer, sights []model.RawFirstSight, enemyNames map[uint64]string) {
+	if len(sights) == 0 {
+		return
+	}
+	table := TableData{
+		Title: "First Sights",
+		Description: "RD=round  TICK=first-sight tick  ENEMY=player first seen  ANGLE=total crosshair deviation\n" +
+			"PITCH/YAW=vertical/horizontal deviation  VIEW=observer pitch/yaw at first sight",
+	}
+	table.Headers = []string{"RD", "TICK", "ENEMY", "ANGLE", "PITCH", "YAW", "VIEW"}
+	for _, fs := range sights {
+		enemy, ok := enemyNames[fs.EnemyID]
+		if !ok {
+			enemy = strconv.FormatUint(fs.EnemyID, 10)
+		}
+		table.Append(
+			strconv.Itoa(fs.RoundNumber),
+			strconv.Itoa(fs.Tick),
+			enemy,
+			fmt.Sprintf("%.1f°", fs.AngleDeg),
+			fmt.Sprintf("%.1f°", fs.PitchDeg),
+			fmt.Sprintf("%.1f°", fs.YawDeg),
+			fmt.Sprintf("%.1f/%.1f", fs.ObserverPitchDeg, fs.ObserverYawDeg),
+		)
+	}
+	emit(w, table)
+}
+
+// PrintPracticePlanTable prints the deterministic practice routine: one row per
+// weak duel segment, ordered by headshots lost against the reference.
+func PrintPracticePlanTable(w io.Writer, items []model.PracticeItem, playerName string) {
+	if len(items) == 0 {
+		return
+	}
+	total := 0
+	for _, it := range items {
+		total += it.Minutes
+	}
+	table := TableData{
+		Title: fmt.Sprintf("Practice Plan — %s — %d min", playerName, total),
+		Description: "FHHS%=first-hit headshot rate in this weapon × distance segment  REF%=other players' pooled rate\n" +
+			"HS_LOST=(REF% − FHHS%) × first hits  SIGHT°/CORR°=median crosshair / correction angle  FOCUS=what the drill targets\n" +
+			"TTDMG=median ms from first seeing an enemy to first damage with this weapon, reference in parentheses",
+	}
+	table.Headers = []string{"#", "WEAPON", "RANGE", "DUELS", "FHHS%", "REF%", "HS_LOST", "SIGHT°", "CORR°", "TTDMG", "FOCUS", "MIN", "DRILL"}
+	deg := func(v float64) string {
+		if v == 0 {
+			return "—"
+		}
+		return fmt.Sprintf("%.1f", v)
+	}
+	ttdmg := func(v, ref float64) string {
+		switch {
+		case v == 0:
+			return "—"
+		case ref == 0:
+			return fmt.Sprintf("%.0fms", v)
+		}
+		return fmt.Sprintf("%.0fms (%.0f)", v, ref)
+	}
+	for i, it := range items {
+		table.Append(
+			strconv.Itoa(i+1),
+			it.WeaponBucket,
+			it.DistanceBin,
+			strconv.Itoa(it.DuelCount),
+			color.RedString("%.1f%%", it.FHHSPct),
+			fmt.Sprintf("%.1f%%", it.RefFHHSPct),
+			fmt.Sprintf("%.1f", it.HSLost),
+			deg(it.SightDeg),
+			deg(it.CorrDeg),
+			ttdmg(it.TTDmgMs, it.RefTTDmgMs),
+			it.Focus,
+			strconv.Itoa(it.Minutes),
+			it.Drill,
+		)
+	}
+	emit(w, table)
+}
+
+// PrintMetricDefinitionsTable lists metric definitions from the registry with
+// their windows, stored columns and version history. current is the running
+// binary's pipeline version.
+// Columns: METRIC | GROUP | SINCE | CHANGED | WINDOW | COLUMNS | DEFINITION
+func PrintMetricDefinitionsTable(w io.Writer, metrics []model.MetricDef, current int) {
+	title := fmt.Sprintf("Metric Definitions — pipeline v%d", current)
+	if len(metrics) == 0 {
+		emit(w, TableData{Title: title, Hint: "no metrics match the filter"})
+		return
+	}
+	table := TableData{
+		Title: title,
+		Description: "SINCE=pipeline version that introduced the metric (v0 = before versioning)\n" +
+			"CHANGED=later versions that changed its stored values (see `metrics --changelog`)\n" +
+			"Demos stored by an older version than SINCE or a CHANGED entry need `parse --force` to match current numbers",
+	}
+	table.Headers = []string{"METRIC", "GROUP", "SINCE", "CHANGED", "WINDOW", "COLUMNS", "DEFINITION"}
+	for _, m := range metrics {
+		changed := make([]string, len(m.Changes))
+		for i, c := range m.Changes {
+			changed[i] = fmt.Sprintf("v%d", c.Version)
+		}
+		table.Append(m.Name, m.Group, fmt.Sprintf("v%d", m.Since), orDash(strings.Join(changed, ", ")),
+			orDash(m.Window), strings.Join(m.Columns, ", "), m.Definition)
+	}
+	emit(w, table)
+}
+
+// PrintMetricChangelogTable lists the registry's version history oldest first:
+// one row per metric introduced or changed by each pipeline version.
+// Columns: VERSION | METRIC | CHANGE
+func PrintMetricChangelogTable(w io.Writer, metrics []model.MetricDef, current int) {
+	type entry struct {
+		version      int
+		metric, note string
+	}
+	var entries []entry
+	for _, m := range metrics {
+		if m.Since > 0 {
+			entries = append(entries, entry{m.Since, m.Name, "introduced"})
+		}
+		for _, c := range m.Changes {
+			entries = append(entries, entry{c.Version, m.Name, c.Note})
+		}
+	}
+	title := fmt.Sprintf("Metric Changelog — pipeline v%d", current)
+	if len(entries) == 0 {
+		emit(w, TableData{Title: title, Hint: "no metric changes match the filter"})
+		return
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].version < entries[j].version })
+
+	table := TableData{
+		Title:       title,
+		Description: "Each pipeline version that introduced or changed a metric; stored stats carry the version that produced them (`list --outdated`)",
+	}
+	table.Headers = []string{"VERSION", "METRIC", "CHANGE"}
+	for _, e := range entries {
+		table.Append(fmt.Sprintf("v%d", e.version), e.metric, e.note)
+	}
+	emit(w, table)
+}
+
+// PrintTimelineTable prints a match's round events in order, one row each:
+// round boundaries, kills, flashes, plants and defuses.
+// Columns: RD | TIME | TICK | EVENT | DETAIL
+func PrintTimelineTable(w io.Writer, events []model.TimelineEvent, names map[uint64]string, mapName string, tickrate float64) {
+	title := fmt.Sprintf("Round Timeline — %s", mapName)
+	if len(events) == 0 {
+		emitMissing(w, title, "round events", 32)
+		return
+	}
+	if tickrate <= 0 {
+		tickrate = 64
+	}
+	table := TableData{
+		Title: title,
+		Description: "TIME=seconds from the round's freeze end (negative during freeze time)  TICK=demo tick\n" +
+			"Kills show killer → victim with weapon (HS = headshot); flashes the seconds blind; round ends the winner and reason",
+	}
+	table.Headers = []string{"RD", "TIME", "TICK", "EVENT", "DETAIL"}
+
+	name := func(id uint64, team model.Team) string {
+		n, ok := names[id]
+		if !ok {
+			n = strconv.FormatUint(id, 10)
+		}
+		if team == model.TeamCT || team == model.TeamT {
+			n += " (" + colorSide(team.String()) + ")"
+		}
+		return n
+	}
+	freezeEnd := make(map[int]int)
+	for _, e := range events {
+		if _, ok := freezeEnd[e.RoundNumber]; !ok && e.Type == model.TimelineRoundStart {
+			freezeEnd[e.RoundNumber] = e.Tick
+		}
+		if e.Type == model.TimelineFreezeEnd {
+			freezeEnd[e.RoundNumber] = e.Tick
+		}
+	}
+	for _, e := range events {
+		var detail string
+		switch e.Type {
+		case model.TimelineKill:
+			detail = fmt.Sprintf("%s → %s  %s", name(e.ActorID, e.ActorTeam), name(e.TargetID, e.TargetTeam), e.Weapon)
+			if e.Headshot {
+				detail += " HS"
+			}
+		case model.TimelineFlash:
+			detail = fmt.Sprintf("%s → %s  %.1fs", name(e.ActorID, e.ActorTeam), name(e.TargetID, e.TargetTeam), e.DurationSec)
+		case model.TimelinePlant, model.TimelineDefuse:
+			detail = name(e.ActorID, e.ActorTeam)
+			if e.Detail != "" {
+				detail += "  site " + e.Detail
+			}
+		case model.TimelineRoundEnd:
+			detail = colorSide(e.ActorTeam.String()) + " win"
+			if e.Detail != "" {
+				detail += "  " + e.Detail
+			}
+		}
+		sec := float64(e.Tick-freezeEnd[e.RoundNumber]) / tickrate
+		table.Append(strconv.Itoa(e.RoundNumber), fmt.Sprintf("%.1f", sec), strconv.Itoa(e.Tick), e.Type, orDash(detail))
+	}
+	emit(w, table)
+}
+
+// colorMapPoolStatus wraps a map pool status in red (no data, stale), yellow
+// (thin) or green (ok).
+func colorMapPoolStatus(status string) string {
+	switch status {
+	case model.MapPoolNoData, model.MapPoolStale:
+		return color.RedString(status)
+	case model.MapPoolThin:
+		return color.YellowString(status)
+	default:
+		return color.GreenString(status)
+	}
+}
+
+// PrintMapPoolTable prints a roster's coverage of each map: pool maps first in
+// the given order, then maps played outside the pool. staleDays and minMatches
+// are the limits behind the status column; ref names the reference date.
+// Columns: MAP | MATCHES | W-L-T | WIN% | CT% | T% | LAST | DAYS | RECENT | STATUS
+func PrintMapPoolTable(w io.Writer, team string, entries []model.MapPoolEntry, ref string, staleDays, minMatches int) {
+	title := "Map Pool Coverage"
+	if team != "" {
+		title += " — " + team
+	}
+	table := TableData{
+		Title: title,
+		Description: fmt.Sprintf("MATCHES=qualifying demos in the window  WIN%%=matches won (draws count half)  CT%%/T%%=roster round win rate per side\n"+
+			"LAST=latest match  DAYS=days from LAST to %s  RECENT=matches in the %d days before %s\n"+
+			"STATUS: no data=not played in the window  stale=DAYS > %d  thin=RECENT < %d  ok=otherwise", ref, staleDays, ref, staleDays, minMatches),
+	}
+	table.Headers = []string{"MAP", "MATCHES", "W-L-T", "WIN%", "CT%", "T%", "LAST", "DAYS", "RECENT", "STATUS"}
+	pct := func(won, total int) string {
+		if total == 0 {
+			return "—"
+		}
+		return fmt.Sprintf("%.0f%%", float64(won)/float64(total)*100)
+	}
+	var gaps, offPool []string
+	for _, e := range entries {
+		if !e.InPool {
+			offPool = append(offPool, fmt.Sprintf("%s (%d)", e.MapName, e.Matches))
+			continue
+		}
+		if e.Status != model.MapPoolOK {
+			gaps = append(gaps, e.MapName)
+		}
+		if e.Matches == 0 {
+			table.Append(e.MapName, "0", "—", "—", "—", "—", "—", "—", "0", colorMapPoolStatus(e.Status))
+			continue
+		}
+		losses := e.Matches - e.Wins - e.Ties
+		table.Append(e.MapName, strconv.Itoa(e.Matches),
+			fmt.Sprintf("%d-%d-%d", e.Wins, losses, e.Ties),
+			pct(2*e.Wins+e.Ties, 2*e.Matches),
+			pct(e.CTWins, e.CTRounds), pct(e.TWins, e.TRounds),
+			e.LastPlayed, strconv.Itoa(e.DaysSince), strconv.Itoa(e.RecentMatches),
+			colorMapPoolStatus(e.Status))
+	}
+	if len(gaps) > 0 {
+		table.Notes = append(table.Notes, "Practice gaps: "+strings.Join(gaps, ", "))
+	} else {
+		table.Notes = append(table.Notes, "No practice gaps: every pool map is covered")
+	}
+	if len(offPool) > 0 {
+		table.Notes = append(table.Notes, "Also played (outside the pool): "+strings.Join(offPool, ", "))
+	}
+	emit(w, table)
+}
+
+// PrintBombSiteTable prints a roster's bomb site preference per map: the share
+// of its T-side plants at each site and how often those rounds were won, and
+// the share of the plants it conceded on CT at each site and how often it
+// still won. Entries come grouped by map in report order.
+// Columns: MAP | SITE | T_PLANTS | T_SHARE | T_WIN% | CT_LOST | CT_SHARE | CT_WIN%
+func PrintBombSiteTable(w io.Writer, team string, entries []model.BombSiteEntry) {
+	title := "Bomb Sites"
+	if team != "" {
+		title += " — " + team
+	}
+	if len(entries) == 0 {
+		emitMissing(w, title, "plant sites", 32)
+		return
+	}
+	table := TableData{
+		Title: title,
+		Description: "T_PLANTS=roster T rounds with the bomb planted at SITE  T_SHARE=site's share of the map's T plants  T_WIN%=those rounds won\n" +
+			"CT_LOST=roster CT rounds with the bomb planted at SITE  CT_SHARE=site's share of the map's conceded plants  CT_WIN%=those rounds still won",
+	}
+	table.Headers = []string{"MAP", "SITE", "T_PLANTS", "T_SHARE", "T_WIN%", "CT_LOST", "CT_SHARE", "CT_WIN%"}
+	pct := func(n, total int) string {
+		if total == 0 {
+			return "—"
+		}
+		return fmt.Sprintf("%.0f%%", float64(n)/float64(total)*100)
+	}
+	tTotal := make(map[string]int)
+	ctTotal := make(map[string]int)
+	for _, e := range entries {
+		tTotal[e.MapName] += e.TPlants
+		ctTotal[e.MapName] += e.CTPlants
+	}
+	for _, e := range entries {
+		table.Append(e.MapName, e.Site,
+			strconv.Itoa(e.TPlants), pct(e.TPlants, tTotal[e.MapName]), pct(e.TWins, e.TPlants),
+			strconv.Itoa(e.CTPlants), pct(e.CTPlants, ctTotal[e.MapName]), pct(e.CTWins, e.CTPlants))
+	}
+	emit(w, table)
+}
+
+// PrintPredictionTable prints the inputs of a naive match prediction per
+// team, then each term's probability and the combined estimate for team A.
+func PrintPredictionTable(w io.Writer, p model.MatchPrediction) {
+	title := "Match Prediction — " + p.A.Team + " vs " + p.B.Team
+	if p.Map != "" {
+		title += " on " + p.Map
+	}
+	table := TableData{
+		Title: title,
+		Description: "RATING=mean exported player rating (PLAYERS rated)  MAP_WIN%=map win rate shrunk toward 50% with 4 pseudo-matches\n" +
+			"MATCHES=matches on the map in the export  DEMOS=qualifying demos  LATEST=latest match in the export",
+	}
+	table.Headers = []string{"TEAM", "RATING", "PLAYERS", "MAP_WIN%", "MATCHES", "DEMOS", "LATEST"}
+	for _, t := range []model.PredictionTeam{p.A, p.B} {
+		mapWin, matches := "—", "—"
+		if t.HasMap {
+			mapWin, matches = fmt.Sprintf("%.0f%%", t.MapWinPct*100), strconv.Itoa(t.MapMatches)
+		} else if p.Map != "" {
+			mapWin, matches = "50% (no data)", "0"
+		}
+		table.Append(t.Team, fmt.Sprintf("%.2f", t.Rating), strconv.Itoa(t.Players), mapWin, matches,
+			strconv.Itoa(t.DemoCount), t.LatestMatch)
+	}
+	table.Notes = append(table.Notes, fmt.Sprintf("Rating term: %s %+.2f → %s %.0f%%",
+		p.A.Team, p.A.Rating-p.B.Rating, p.A.Team, p.RatingProb*100))
+	if p.Map != "" {
+		table.Notes = append(table.Notes, fmt.Sprintf("Map term (log5): %s %.0f%%", p.A.Team, p.MapProb*100))
+	}
+	table.Notes = append(table.Notes, fmt.Sprintf("Estimate: %s %.0f%% – %s %.0f%% (naive; a sanity check, not a simulation)",
+		p.A.Team, p.WinProb*100, p.B.Team, (1-p.WinProb)*100))
+	emit(w, table)
+}
+
+// PrintWeaponMetaTable prints database-wide weapon usage: every weapon with at
+// least minKills kills in weapons (summed over all players, any order), by
+// kills. KILL% is the weapon's share of all kills in weapons, including the
+// hidden ones; demos is the number of demos in scope.
+func PrintWeaponMetaTable(w io.Writer, scope string, weapons []model.WeaponMetaRow, demos, minKills int) {
+	title := "Weapon Meta"
+	if scope != "" {
+		title += " — " + scope
+	}
+	table := TableData{
+		Title: title,
+		Description: "KILLS=kills with the weapon by every player  KILL%=share of all kills  HS%=headshot kills\n" +
+			"AVG_DIST=mean killer–victim distance at the kill (m)  DMG/HIT=health damage per hit  DEMOS=demos with the weapon used",
+		Sortable: true,
+	}
+	table.Headers = []string{"WEAPON", "KILLS", "KILL%", "HS%", "AVG_DIST", "DMG/HIT", "DEMOS"}
+	sorted := append([]model.WeaponMetaRow(nil), weapons...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Kills > sorted[j].Kills })
+	var kills, distKills, hidden int
+	for _, r := range sorted {
+		kills += r.Kills
+		distKills += r.DistanceKills
+	}
+	for _, r := range sorted {
+		if r.Kills < minKills {
+			hidden++
+			continue
+		}
+		dist := "—"
+		if r.DistanceKills > 0 {
+			dist = fmt.Sprintf("%.1f", r.AvgKillDistanceM())
+		}
+		dmgHit := "—"
+		if r.Hits > 0 {
+			dmgHit = fmt.Sprintf("%.1f", float64(r.Damage)/float64(r.Hits))
+		}
+		table.Append(r.Weapon, strconv.Itoa(r.Kills),
+			fmt.Sprintf("%.1f%%", float64(r.Kills)/float64(max(kills, 1))*100),
+			fmt.Sprintf("%.0f%%", r.HSPercent()), dist, dmgHit, strconv.Itoa(r.Demos))
+	}
+	if len(table.Rows) == 0 {
+		table.Hint = fmt.Sprintf("No weapon has %d+ kills in these demos (lower --min-kills)", minKills)
+		emit(w, table)
+		return
+	}
+	table.Notes = append(table.Notes, fmt.Sprintf("%d demos, %d kills", demos, kills))
+	if hidden > 0 {
+		table.Notes = append(table.Notes, fmt.Sprintf("%d weapons with fewer than %d kills not shown", hidden, minKills))
+	}
+	if kills > 0 && distKills < kills {
+		table.Notes = append(table.Notes, fmt.Sprintf("AVG_DIST covers %.0f%% of kills; demos parsed before pipeline v33 have no kill distance (re-parse with parse --force)",
+			float64(distKills)/float64(kills)*100))
+	}
+	emit(w, table)
+}
+
+// PrintWeaponMetaTrendTable prints each weapon's share of kills per period,
+// oldest period first, and the change from the first period with kills to
+// the last. Periods without kills show "—".
+func PrintWeaponMetaTrendTable(w io.Writer, weapons []string, windows []model.WeaponMetaWindow) {
+	table := TableData{
+		Title:       "Weapon Meta Trend",
+		Description: "Share of each period's kills per weapon; CHANGE=last period minus first (percentage points)",
+	}
+	table.Headers = []string{"WEAPON"}
+	for _, win := range windows {
+		table.Headers = append(table.Headers, weaponMetaPeriod(win))
+	}
+	table.Headers = append(table.Headers, "CHANGE")
+	for _, weapon := range weapons {
+		row := []string{weapon}
+		first, last := -1.0, -1.0
+		for _, win := range windows {
+			if win.Kills == 0 {
+				row = append(row, "—")
+				continue
+			}
+			share := float64(win.Weapons[weapon].Kills) / float64(win.Kills) * 100
+			if first < 0 {
+				first = share
+			}
+			last = share
+			row = append(row, fmt.Sprintf("%.1f%%", share))
+		}
+		change := "—"
+		if first >= 0 {
+			change = fmt.Sprintf("%+.1f", last-first)
+		}
+		table.Append(append(row, change)...)
+	}
+	emit(w, table)
+}
+
+// PrintStorageFootprintTable prints the optional datasets (config storage
+// features) with their on/off state, rows and size in the database, so depth
+// can be weighed against database size. demos is the number of stored demos;
+// fileBytes the database size.
+func PrintStorageFootprintTable(w io.Writer, rows []model.DatasetFootprint, demos int, fileBytes int64) {
+	table := TableData{
+		Title: "Optional Datasets",
+		Description: "STORED=kept for newly parsed demos (db storage <feature> on|off)  SIZE=table and index pages\n" +
+			"DEMOS=demos with rows  PER_DEMO=SIZE / DEMOS, the cost of each demo parsed with the feature on  SHARE=SIZE / database size",
+	}
+	table.Headers = []string{"FEATURE", "STORED", "ROWS", "SIZE", "DEMOS", "PER_DEMO", "SHARE", "DATA"}
+	var total int64
+	for _, r := range rows {
+		stored := "off"
+		if r.Stored {
+			stored = "on"
+		}
+		perDemo, share := "—", "—"
+		if r.Demos > 0 {
+			perDemo = sizeLabel(r.BytesPerDemo())
+		}
+		if fileBytes > 0 {
+			share = fmt.Sprintf("%.0f%%", float64(r.Bytes)/float64(fileBytes)*100)
+		}
+		table.Append(r.Feature, stored, strconv.Itoa(r.Rows), sizeLabel(r.Bytes),
+			strconv.Itoa(r.Demos), perDemo, share, r.Summary)
+		total += r.Bytes
+	}
+	table.Notes = append(table.Notes, fmt.Sprintf("%d demos, database %s, optional datasets %s",
+		demos, sizeLabel(fileBytes), sizeLabel(total)))
+	table.Notes = append(table.Notes, "switching a feature off affects demos parsed afterwards; re-parse with parse --force to drop or fill in stored rows")
+	emit(w, table)
+}
+
+// sizeLabel formats a byte count in KB below one megabyte and MB above.
+func sizeLabel(n int64) string {
+	if n < 1<<20 {
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+}
+
+// weaponMetaPeriod labels a trend period "Oct 6 → Feb 2 2025" (the terminal
+// header formatting spaces out hyphens in ISO dates), or From…To when a date
+// does not parse.
+func weaponMetaPeriod(win model.WeaponMetaWindow) string {
+	from, err1 := time.Parse("2006-01-02", win.From)
+	to, err2 := time.Parse("2006-01-02", win.To)
+	if err1 != nil || err2 != nil {
+		return win.From + "…" + win.To
+	}
+	return from.Format("Jan 2") + " → " + to.Format("Jan 2 2006")
+}
+
+// orDash returns s, or "—" when s is empty.
+func orDash(s string) string {
+	if s == "" {
+		return "—"
+	}
+	return s
+}
+
+// PrintFingerprintTable prints target's fingerprint followed by the top most
+// similar tracked players. A distance up to probable flags a probable alt
+// account, up to possible a possible one; excluded counts players skipped for
+// sharing a demo with target.
+func PrintFingerprintTable(w io.Writer, target model.PlayerFingerprint, matches []model.FingerprintMatch,
+	top int, probable, possible float64, excluded int) {
+	table := TableData{
+		Title: fmt.Sprintf("Fingerprint — %s (%d)", target.Name, target.SteamID),
+		Description: "TTK=mean median ms first shot → kill  CS%=shots fired counter-strafed  XHAIR=median crosshair angle at first sight (°)\n" +
+			"<5°=first sights under 5°  FHHS_<BUCKET>=first-hit headshot rate per weapon bucket\n" +
+			"FEATURES=features both players have samples for  DIST=mean difference in standard deviations across tracked players",
+	}
+	var buckets []string
+	for b, c := range target.FHHS {
+		if c.FirstHits >= model.FingerprintMinSamples {
+			buckets = append(buckets, b)
+		}
+	}
+	sort.Strings(buckets)
+	table.Headers = []string{"PLAYER", "STEAMID", "MATCHES", "TTK", "CS%", "XHAIR", "<5°"}
+	for _, b := range buckets {
+		table.Headers = append(table.Headers, "FHHS_"+strings.ToUpper(b))
+	}
+	table.Headers = append(table.Headers, "FEATURES", "DIST", "VERDICT")
+
+	row := func(p model.PlayerFingerprint, features, dist, verdict string) {
+		cells := []string{p.Name, strconv.FormatUint(p.SteamID, 10), strconv.Itoa(p.Matches),
+			fingerprintCell(p.TTKMs, p.TTKMs > 0, "%.0f"),
+			fingerprintCell(p.CounterStrafePct, p.CounterStrafePct > 0, "%.0f%%"),
+			fingerprintCell(p.CrosshairMedianDeg, p.CrosshairSamples >= model.FingerprintMinSamples, "%.1f"),
+			fingerprintCell(p.CrosshairPctUnder5, p.CrosshairSamples >= model.FingerprintMinSamples, "%.0f%%")}
+		for _, b := range buckets {
+			c := p.FHHS[b]
+			cells = append(cells, fingerprintCell(c.Pct(), c.FirstHits >= model.FingerprintMinSamples, "%.0f%%"))
+		}
+		table.Append(append(cells, features, dist, verdict)...)
+	}
+	row(target, "—", "—", "target")
+	flagged := 0
+	for i, m := range matches {
+		if i == top {
+			break
+		}
+		verdict := "—"
+		switch {
+		case m.Distance <= probable:
+			verdict = color.RedString("probable alt")
+			flagged++
+		case m.Distance <= possible:
+			verdict = color.YellowString("possible")
+			flagged++
+		}
+		row(m.Player, strconv.Itoa(m.Features), fmt.Sprintf("%.2f", m.Distance), verdict)
+	}
+	if len(matches) == 0 {
+		table.Notes = append(table.Notes, fmt.Sprintf("No tracked player shares %d+ features with this player", model.FingerprintMinFeatures))
+	} else {
+		table.Notes = append(table.Notes, fmt.Sprintf("%d players compared, %d within DIST %.2f (probable) / %.2f (possible)",
+			len(matches), flagged, probable, possible))
+	}
+	if excluded > 0 {
+		table.Notes = append(table.Notes, fmt.Sprintf("%d players who shared a match with this player were not compared (one person cannot play both accounts at once)", excluded))
+	}
+	table.Notes = append(table.Notes,
+		"Caveat: a similar fingerprint is a lead, not proof. Players of the same level and role converge on similar numbers,",
+		"and a few matches give noisy medians. Check names, match dates and VODs before acting on a flag.")
+	emit(w, table)
+}
+
+// fingerprintCell formats v, or "—" when the player has too few samples.
+func fingerprintCell(v float64, ok bool, format string) string {
+	if !ok {
+		return "—"
+	}
+	return fmt.Sprintf(format, v)
+}
+
+// PrintBaselineQuotaTable prints each tier's baseline corpus target and
+// progress for the baseline command.
+func PrintBaselineQuotaTable(w io.Writer, quotas []model.BaselineQuota) {
+	table := TableData{
+		Title: "Baseline Quotas",
+		Description: "STORED=baseline demos stored with the tier  TARGET=demos wanted (baseline build --target)\n" +
+			"TRIED=matches and files tried by baseline build  FAILED=last attempt failed (retried next run)",
+	}
+	if len(quotas) == 0 {
+		table.Hint = "No baseline quotas set (run baseline build --tier <tier> --target <N>)"
+		emit(w, table)
+		return
+	}
+	table.Headers = []string{"TIER", "STORED", "TARGET", "PROGRESS", "REMAINING", "TRIED", "FAILED", "UPDATED"}
+	for _, q := range quotas {
+		progress := fmt.Sprintf("%.0f%%", float64(q.Stored)/float64(max(q.Target, 1))*100)
+		remaining := strconv.Itoa(q.Remaining())
+		if q.Remaining() == 0 {
+			remaining = color.GreenString("done")
+		}
+		table.Append(q.Tier, strconv.Itoa(q.Stored), strconv.Itoa(q.Target), progress, remaining,
+			strconv.Itoa(q.Tried), strconv.Itoa(q.Failed), q.UpdatedAt)
+	}
+	emit(w, table)
 }
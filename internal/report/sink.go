@@ -0,0 +1,264 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/olekukonko/tablewriter/tw"
+)
+
+// TableData is one report table in renderer-neutral form. Every Print*
+// function builds a TableData and hands it to the active Renderer, so new
+// output formats only need a new Renderer, not a copy of each table.
+type TableData struct {
+	Title       string     // section title
+	Description string     // column legend; terminal output shows it only when Verbose
+	Headers     []string   // column names
+	Rows        [][]string // cells; may carry terminal color codes
+	Notes       []string   // summary lines printed after the table
+	// Hint explains why a table has no data (see missingHint); a table with a
+	// hint renders as its title and that one line, with no headers or rows.
+	Hint string
+	// Inline marks a compact listing (the player roster): the terminal prints
+	// the title as a plain caption with left-aligned cells instead of a section.
+	Inline bool
+	// Sortable marks one-row-per-entity tables whose rows may be reordered by
+	// SetSortBy; grouped or chronological tables leave it false.
+	Sortable bool
+}
+
+// Append adds one row of cells.
+func (t *TableData) Append(cells ...string) {
+	t.Rows = append(t.Rows, cells)
+}
+
+// Renderer writes a TableData to an output sink.
+type Renderer interface {
+	Render(w io.Writer, t TableData) error
+}
+
+// renderers maps each --format value to its Renderer.
+var renderers = map[string]Renderer{
+	"table": TerminalRenderer{},
+	"csv":   CSVRenderer{},
+	"json":  JSONRenderer{},
+	"html":  HTMLRenderer{},
+}
+
+// Formats lists the accepted SetFormat values in display order.
+var Formats = []string{"table", "csv", "json", "html"}
+
+// active is the renderer used by every Print* function.
+var active Renderer = TerminalRenderer{}
+
+// SetFormat selects the renderer for all subsequent report output.
+func SetFormat(format string) error {
+	r, ok := renderers[format]
+	if !ok {
+		return fmt.Errorf("unknown format %q (want one of: %s)", format, strings.Join(Formats, ", "))
+	}
+	active = r
+	return nil
+}
+
+// SetRenderer installs a custom renderer, e.g. to capture tables in tests or
+// write them to another sink.
+func SetRenderer(r Renderer) {
+	active = r
+}
+
+// emit applies the column selection and sort order, then renders t with the
+// active renderer. Write errors are reported on stderr
+// rather than returned, matching the fire-and-forget Print* API.
+func emit(w io.Writer, t TableData) {
+	if err := active.Render(w, shape(t)); err != nil {
+		fmt.Fprintf(os.Stderr, "warn: render %q: %v\n", t.Title, err)
+	}
+}
+
+// isTerminal reports whether the active renderer is the terminal one, for the
+// few outputs that are free text rather than tables in that format.
+func isTerminal() bool {
+	_, ok := active.(TerminalRenderer)
+	return ok
+}
+
+// ansiRE matches SGR color escape sequences emitted by fatih/color.
+var ansiRE = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// plain strips terminal color codes and padding (e.g. the blank focus-marker
+// column) from a cell.
+func plain(s string) string {
+	return strings.TrimSpace(ansiRE.ReplaceAllString(s, ""))
+}
+
+// plainCells applies plain to every cell of a row.
+func plainCells(row []string) []string {
+	out := make([]string, len(row))
+	for i, c := range row {
+		out[i] = plain(c)
+	}
+	return out
+}
+
+// plainRows returns t.Rows with plain applied to every cell.
+func plainRows(t TableData) [][]string {
+	out := make([][]string, len(t.Rows))
+	for i, row := range t.Rows {
+		out[i] = plainCells(row)
+	}
+	return out
+}
+
+// TerminalRenderer prints tables with tablewriter: a bold section title, the
+// column legend when Verbose, right-aligned cells, and trailing notes. Tables
+// wider than the terminal are split or trimmed according to SetOverflow.
+type TerminalRenderer struct{}
+
+// Render implements Renderer.
+func (TerminalRenderer) Render(w io.Writer, t TableData) error {
+	if t.Hint != "" {
+		printSection(w, t.Title, "")
+		fmt.Fprintf(w, "%s\n", color.New(color.Faint).Sprint(t.Hint))
+		return nil
+	}
+	rowAlign, headerAlign := tw.AlignRight, tw.AlignCenter
+	if t.Inline {
+		rowAlign, headerAlign = tw.AlignLeft, tw.AlignLeft
+		fmt.Fprintf(w, "%s\n", t.Title)
+	} else {
+		printSection(w, t.Title, t.Description)
+	}
+	parts := fitWidth(t, terminalWidth(w))
+	for _, part := range parts {
+		table := tablewriter.NewTable(w, tablewriter.WithConfig(tablewriter.Config{
+			Row:    tw.CellConfig{Alignment: tw.CellAlignment{Global: rowAlign}},
+			Header: tw.CellConfig{Alignment: tw.CellAlignment{Global: headerAlign}},
+		}))
+		table.Header(part.Headers)
+		for _, row := range part.Rows {
+			if err := table.Append(row); err != nil {
+				return err
+			}
+		}
+		if err := table.Render(); err != nil {
+			return err
+		}
+	}
+	t = parts[len(parts)-1]
+	if len(t.Notes) > 0 {
+		fmt.Fprintln(w)
+		for _, n := range t.Notes {
+			fmt.Fprintln(w, n)
+		}
+	}
+	if t.Inline {
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+// CSVRenderer writes each table as a one-field title record, the header
+// record, and the data rows, followed by a blank line. Notes are omitted; a
+// table without data writes its hint as a one-field record instead of rows.
+type CSVRenderer struct{}
+
+// Render implements Renderer.
+func (CSVRenderer) Render(w io.Writer, t TableData) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{t.Title}); err != nil {
+		return err
+	}
+	if t.Hint != "" {
+		if err := cw.Write([]string{t.Hint}); err != nil {
+			return err
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintln(w)
+		return err
+	}
+	if err := cw.Write(plainCells(t.Headers)); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(plainRows(t)); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// jsonTable is the JSON shape of one table.
+type jsonTable struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	Headers     []string   `json:"headers"`
+	Rows        [][]string `json:"rows"`
+	Notes       []string   `json:"notes,omitempty"`
+	Hint        string     `json:"hint,omitempty"`
+}
+
+// JSONRenderer writes each table as one JSON object per line (JSON Lines).
+type JSONRenderer struct{}
+
+// Render implements Renderer.
+func (JSONRenderer) Render(w io.Writer, t TableData) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(jsonTable{
+		Title:       t.Title,
+		Description: t.Description,
+		Headers:     plainCells(t.Headers),
+		Rows:        plainRows(t),
+		Notes:       plainCells(t.Notes),
+		Hint:        t.Hint,
+	})
+}
+
+// HTMLRenderer writes each table as a <section> fragment with a heading, the
+// legend, the table, and the notes. Fragments can be concatenated into a page.
+type HTMLRenderer struct{}
+
+// Render implements Renderer.
+func (HTMLRenderer) Render(w io.Writer, t TableData) error {
+	var b strings.Builder
+	b.WriteString("<section>\n")
+	fmt.Fprintf(&b, "<h2>%s</h2>\n", html.EscapeString(t.Title))
+	if t.Description != "" {
+		fmt.Fprintf(&b, "<p>%s</p>\n", strings.ReplaceAll(html.EscapeString(t.Description), "\n", "<br>\n"))
+	}
+	if t.Hint != "" {
+		fmt.Fprintf(&b, "<p class=\"hint\">%s</p>\n</section>\n", html.EscapeString(t.Hint))
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	b.WriteString("<table>\n<thead><tr>")
+	for _, h := range plainCells(t.Headers) {
+		fmt.Fprintf(&b, "<th>%s</th>", html.EscapeString(h))
+	}
+	b.WriteString("</tr></thead>\n<tbody>\n")
+	for _, row := range plainRows(t) {
+		b.WriteString("<tr>")
+		for _, c := range row {
+			fmt.Fprintf(&b, "<td>%s</td>", html.EscapeString(c))
+		}
+		b.WriteString("</tr>\n")
+	}
+	b.WriteString("</tbody>\n</table>\n")
+	for _, n := range plainCells(t.Notes) {
+		fmt.Fprintf(&b, "<p>%s</p>\n", html.EscapeString(n))
+	}
+	b.WriteString("</section>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
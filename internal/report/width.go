@@ -0,0 +1,197 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/olekukonko/tablewriter/tw"
+	"golang.org/x/term"
+)
+
+// Overflow modes for tables wider than the terminal.
+const (
+	OverflowSplit = "split" // stack the columns into several narrower tables
+	OverflowHide  = "hide"  // drop trailing (lowest-priority) columns and note them
+	OverflowWrap  = "wrap"  // render as-is and let the terminal wrap
+)
+
+// OverflowModes lists the accepted SetOverflow values in display order.
+var OverflowModes = []string{OverflowSplit, OverflowHide, OverflowWrap}
+
+// widthOverride and overflowMode hold the --width / --overflow settings used by
+// the terminal renderer.
+var (
+	widthOverride int
+	overflowMode  = OverflowSplit
+)
+
+// SetWidth fixes the terminal width used for table layout. Zero detects it
+// from the output terminal; piped output is then never reflowed.
+func SetWidth(n int) {
+	widthOverride = n
+}
+
+// SetOverflow selects how the terminal renderer handles tables wider than the
+// terminal: split, hide, or wrap.
+func SetOverflow(mode string) error {
+	for _, m := range OverflowModes {
+		if mode == m {
+			overflowMode = mode
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown overflow mode %q (want one of: %s)", mode, strings.Join(OverflowModes, ", "))
+}
+
+// keyColumns identify a row within grouped tables (per side, map, weapon...).
+// Split layouts repeat them, with the identity columns, in every part.
+var keyColumns = map[string]bool{
+	"SIDE": true, "MAP": true, "WEAPON": true, "HALF": true, "DATE": true, "RD": true,
+}
+
+// terminalWidth returns the width available on w: the --width override, the
+// size of w when it is a terminal, or 0 (unlimited) otherwise.
+func terminalWidth(w io.Writer) int {
+	if widthOverride > 0 {
+		return widthOverride
+	}
+	f, ok := w.(*os.File)
+	if !ok || !term.IsTerminal(int(f.Fd())) {
+		return 0
+	}
+	cols, _, err := term.GetSize(int(f.Fd()))
+	if err != nil {
+		return 0
+	}
+	return cols
+}
+
+// columnWidths returns the display width of each column: the widest of its
+// header (as tablewriter formats it, e.g. "KAST%" becomes "KAST %") and cells,
+// ignoring color codes.
+func columnWidths(t TableData) []int {
+	widths := make([]int, len(t.Headers))
+	measure := func(i int, s string) {
+		for _, line := range strings.Split(ansiRE.ReplaceAllString(s, ""), "\n") {
+			if n := utf8.RuneCountInString(line); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	for i, h := range t.Headers {
+		measure(i, tw.Title(strings.Join(tw.SplitCamelCase(h), tw.Space)))
+	}
+	for _, row := range t.Rows {
+		for i, c := range row {
+			if i < len(widths) {
+				measure(i, c)
+			}
+		}
+	}
+	return widths
+}
+
+// tableWidth is the rendered width of the given columns: each cell is padded
+// by one space on either side and every column adds one border character.
+func tableWidth(widths []int, cols []int) int {
+	n := 1
+	for _, i := range cols {
+		n += widths[i] + 3
+	}
+	return n
+}
+
+// fitWidth lays t out for a terminal of the given width. Tables that fit, or
+// any table when width is 0 or the mode is wrap, come back unchanged. In split
+// mode the columns are packed left to right into stacked tables that each
+// repeat the identity and key columns; in hide mode trailing columns are
+// dropped and listed in a note.
+func fitWidth(t TableData, width int) []TableData {
+	if width <= 0 || overflowMode == OverflowWrap || len(t.Headers) == 0 {
+		return []TableData{t}
+	}
+	widths := columnWidths(t)
+	all := make([]int, len(t.Headers))
+	for i := range all {
+		all[i] = i
+	}
+	if tableWidth(widths, all) <= width {
+		return []TableData{t}
+	}
+
+	var fixed, rest []int
+	for i, h := range t.Headers {
+		if identityColumns[h] || keyColumns[h] {
+			fixed = append(fixed, i)
+		} else {
+			rest = append(rest, i)
+		}
+	}
+
+	if overflowMode == OverflowHide {
+		keep := append([]int(nil), fixed...)
+		var hidden []string
+		for _, i := range rest {
+			if len(hidden) == 0 && tableWidth(widths, append(keep, i)) <= width {
+				keep = append(keep, i)
+			} else {
+				hidden = append(hidden, t.Headers[i])
+			}
+		}
+		if len(hidden) == 0 || len(keep) == len(fixed) {
+			return []TableData{t}
+		}
+		out := pickColumns(t, keep)
+		out.Notes = append(append([]string(nil), t.Notes...),
+			fmt.Sprintf("hidden to fit %d columns: %s (widen the terminal, use --columns, or --overflow split)",
+				width, strings.Join(hidden, ", ")))
+		return []TableData{out}
+	}
+
+	var groups [][]int
+	cur := append([]int(nil), fixed...)
+	for _, i := range rest {
+		if len(cur) > len(fixed) && tableWidth(widths, append(cur, i)) > width {
+			groups = append(groups, cur)
+			cur = append([]int(nil), fixed...)
+		}
+		cur = append(cur, i)
+	}
+	groups = append(groups, cur)
+	if len(groups) == 1 {
+		return []TableData{t}
+	}
+	parts := make([]TableData, len(groups))
+	for g, cols := range groups {
+		parts[g] = pickColumns(t, cols)
+		if g < len(groups)-1 {
+			parts[g].Notes = nil
+		}
+	}
+	return parts
+}
+
+// pickColumns returns a copy of t restricted to the column indices cols, in order.
+func pickColumns(t TableData, cols []int) TableData {
+	pick := func(row []string) []string {
+		out := make([]string, 0, len(cols))
+		for _, i := range cols {
+			if i < len(row) {
+				out = append(out, row[i])
+			} else {
+				out = append(out, "")
+			}
+		}
+		return out
+	}
+	t.Headers = pick(t.Headers)
+	rows := make([][]string, len(t.Rows))
+	for r, row := range t.Rows {
+		rows[r] = pick(row)
+	}
+	t.Rows = rows
+	return t
+}
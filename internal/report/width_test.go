@@ -0,0 +1,133 @@
+package report
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// withOverflow sets --overflow for one test and restores it after.
+func withOverflow(t *testing.T, mode string) {
+	t.Helper()
+	old := overflowMode
+	t.Cleanup(func() { overflowMode = old })
+	if err := SetOverflow(mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// wideTable is a per-map table 59 columns wide: NAME and MAP take 20, each
+// stat column 13 more.
+func wideTable() TableData {
+	return TableData{
+		Headers: []string{"NAME", "MAP", "C1", "C2", "C3"},
+		Rows: [][]string{
+			{"player", "de_nuke", "1111111111", "2222222222", "3333333333"},
+			{"other", "de_nuke", "\x1b[32m11\x1b[0m", "22", "33"},
+		},
+		Notes: []string{"a note"},
+	}
+}
+
+// headers returns the headers of every part.
+func headers(parts []TableData) [][]string {
+	out := make([][]string, len(parts))
+	for i, p := range parts {
+		out[i] = p.Headers
+	}
+	return out
+}
+
+func TestFitWidthUnchanged(t *testing.T) {
+	cases := []struct {
+		name  string
+		mode  string
+		width int
+	}{
+		{"no limit", OverflowSplit, 0},
+		{"negative width", OverflowSplit, -1},
+		{"negative width, hide", OverflowHide, -1},
+		{"wrap", OverflowWrap, 20},
+		{"fits exactly", OverflowSplit, 59},
+		{"fits exactly, hide", OverflowHide, 59},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			withOverflow(t, c.mode)
+			got := fitWidth(wideTable(), c.width)
+			if len(got) != 1 || !reflect.DeepEqual(got[0], wideTable()) {
+				t.Errorf("fitWidth(%d) = %q, want the table unchanged", c.width, headers(got))
+			}
+		})
+	}
+}
+
+func TestFitWidthSplit(t *testing.T) {
+	withOverflow(t, OverflowSplit)
+
+	t.Run("key columns repeat in every part", func(t *testing.T) {
+		got := fitWidth(wideTable(), 46)
+		want := [][]string{{"NAME", "MAP", "C1", "C2"}, {"NAME", "MAP", "C3"}}
+		if !reflect.DeepEqual(headers(got), want) {
+			t.Fatalf("headers = %q, want %q", headers(got), want)
+		}
+		if row := got[1].Rows[1]; !reflect.DeepEqual(row, []string{"other", "de_nuke", "33"}) {
+			t.Errorf("second part row = %q", row)
+		}
+		if got[0].Notes != nil || !reflect.DeepEqual(got[1].Notes, []string{"a note"}) {
+			t.Errorf("notes = %q / %q, want them on the last part only", got[0].Notes, got[1].Notes)
+		}
+	})
+
+	t.Run("a column wider than the limit gets its own part", func(t *testing.T) {
+		got := fitWidth(wideTable(), 25)
+		want := [][]string{{"NAME", "MAP", "C1"}, {"NAME", "MAP", "C2"}, {"NAME", "MAP", "C3"}}
+		if !reflect.DeepEqual(headers(got), want) {
+			t.Errorf("headers = %q, want %q", headers(got), want)
+		}
+	})
+
+	t.Run("no key columns", func(t *testing.T) {
+		tbl := wideTable()
+		tbl.Headers = []string{"A", "B", "C1", "C2", "C3"}
+		got := fitWidth(tbl, 30)
+		want := [][]string{{"A", "B"}, {"C1", "C2"}, {"C3"}}
+		if !reflect.DeepEqual(headers(got), want) {
+			t.Errorf("headers = %q, want %q", headers(got), want)
+		}
+	})
+}
+
+func TestFitWidthHide(t *testing.T) {
+	withOverflow(t, OverflowHide)
+
+	t.Run("trailing columns are hidden and noted", func(t *testing.T) {
+		got := fitWidth(wideTable(), 46)
+		if len(got) != 1 || !reflect.DeepEqual(got[0].Headers, []string{"NAME", "MAP", "C1", "C2"}) {
+			t.Fatalf("headers = %q, want C3 hidden", headers(got))
+		}
+		notes := got[0].Notes
+		if len(notes) != 2 || notes[0] != "a note" || !strings.Contains(notes[1], "hidden to fit 46 columns: C3") {
+			t.Errorf("notes = %q, want the original note and the hidden C3", notes)
+		}
+	})
+
+	t.Run("columns after a hidden one are hidden too", func(t *testing.T) {
+		tbl := wideTable()
+		tbl.Rows[0][3] = strings.Repeat("2", 30)
+		got := fitWidth(tbl, 46)
+		if len(got) != 1 || !reflect.DeepEqual(got[0].Headers, []string{"NAME", "MAP", "C1"}) {
+			t.Fatalf("headers = %q, want C2 and C3 hidden", headers(got))
+		}
+		if note := got[0].Notes[len(got[0].Notes)-1]; !strings.Contains(note, ": C2, C3 ") {
+			t.Errorf("note = %q, want C2 and C3 listed", note)
+		}
+	})
+
+	t.Run("the table comes back whole when no column fits", func(t *testing.T) {
+		got := fitWidth(wideTable(), 25)
+		if len(got) != 1 || !reflect.DeepEqual(got[0], wideTable()) {
+			t.Errorf("fitWidth(25) = %q, want the table unchanged", headers(got))
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package steam
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// steamID64Base is the SteamID64 of account 0 in the public universe for
+// individual accounts; a SteamID64 is this base plus the 32-bit account ID.
+const steamID64Base = 76561197960265728
+
+// steamID64Max is the SteamID64 of the highest 32-bit account ID.
+const steamID64Max = steamID64Base + 1<<32 - 1
+
+var (
+	// steamID2Re matches "STEAM_X:Y:Z" (X is the universe, 0 or 1 in practice).
+	steamID2Re = regexp.MustCompile(`^STEAM_[0-5]:([01]):(\d{1,10})$`)
+	// steamID3Re matches "[U:1:W]", with both brackets or neither.
+	steamID3Re = regexp.MustCompile(`^(?:\[U:1:(\d{1,10})\]|U:1:(\d{1,10}))$`)
+	// digitsRe matches a bare number.
+	digitsRe = regexp.MustCompile(`^\d+$`)
+	// profileURLRe matches a community profile URL and captures its kind
+	// ("profiles" or "id") and key.
+	profileURLRe = regexp.MustCompile(`^(?:https?://)?(?:www\.)?steamcommunity\.com/(profiles|id)/([^/?#]+)/?(?:[?#].*)?$`)
+	// vanityRe matches a custom profile URL name.
+	vanityRe = regexp.MustCompile(`^[A-Za-z0-9_-]{2,32}$`)
+)
+
+// ParseSteamID returns the SteamID64 written in s, which may be a SteamID64
+// of an individual account (steamID64Base up to steamID64Max), a steamID3 ("[U:1:22202]"), a steamID2 ("STEAM_0:0:11101") or a
+// steamcommunity.com/profiles/ URL holding one of those. Vanity names and
+// steamcommunity.com/id/ URLs need a Web API lookup: ParseSteamID fails on
+// them and VanityName returns the name to resolve.
+func ParseSteamID(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	if m := profileURLRe.FindStringSubmatch(s); m != nil {
+		if m[1] == "id" {
+			return 0, fmt.Errorf("%q is a custom profile URL; it needs a Steam Web API lookup", s)
+		}
+		key, err := url.PathUnescape(m[2])
+		if err != nil {
+			return 0, fmt.Errorf("profile URL %q: %w", s, err)
+		}
+		s = key
+	}
+	if digitsRe.MatchString(s) {
+		id, err := strconv.ParseUint(s, 10, 64)
+		if err != nil || id < steamID64Base || id > steamID64Max {
+			return 0, fmt.Errorf("%q is not an individual account SteamID64 (%d to %d)", s, uint64(steamID64Base), uint64(steamID64Max))
+		}
+		return id, nil
+	}
+	if m := steamID3Re.FindStringSubmatch(s); m != nil {
+		account, err := strconv.ParseUint(m[1]+m[2], 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("steamID3 %q: account ID out of range", s)
+		}
+		return steamID64Base + account, nil
+	}
+	if m := steamID2Re.FindStringSubmatch(strings.ToUpper(s)); m != nil {
+		low, _ := strconv.ParseUint(m[1], 10, 64)
+		half, err := strconv.ParseUint(m[2], 10, 31)
+		if err != nil {
+			return 0, fmt.Errorf("steamID2 %q: account number out of range", s)
+		}
+		return steamID64Base + half*2 + low, nil
+	}
+	return 0, fmt.Errorf("%q is not a SteamID64, steamID3, steamID2 or profile URL", s)
+}
+
+// VanityName returns the custom profile name in s — a
+// steamcommunity.com/id/<name> URL or a bare name — when s is not a SteamID
+// ParseSteamID can read. A bare number is never a name: it is taken as a
+// mistyped SteamID64.
+func VanityName(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if _, err := ParseSteamID(s); err == nil {
+		return "", false
+	}
+	if m := profileURLRe.FindStringSubmatch(s); m != nil {
+		if m[1] != "id" {
+			return "", false
+		}
+		name, err := url.PathUnescape(m[2])
+		if err != nil {
+			return "", false
+		}
+		s = name
+	} else if digitsRe.MatchString(s) {
+		return "", false
+	}
+	if !vanityRe.MatchString(s) || strings.HasPrefix(strings.ToUpper(s), "STEAM_") {
+		return "", false
+	}
+	return s, true
+}
+
+// ResolveVanityURL returns the SteamID64 of the profile with the custom URL
+// name (steamcommunity.com/id/<name>).
+func (c *Client) ResolveVanityURL(name string) (uint64, error) {
+	params := url.Values{
+		"key":       {c.apiKey},
+		"vanityurl": {name},
+	}
+	endpoint := "https://api.steampowered.com/ISteamUser/ResolveVanityURL/v1/?" + params.Encode()
+
+	resp, err := c.httpClient.Get(endpoint) //nolint:gosec
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusForbidden, http.StatusUnauthorized:
+		return 0, fmt.Errorf("steam: API key rejected (HTTP %d)", resp.StatusCode)
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return 0, fmt.Errorf("steam: rate limited by Valve API (HTTP %d) — wait a minute and retry", resp.StatusCode)
+	default:
+		snippet := string(body)
+		if len(snippet) > 200 {
+			snippet = snippet[:200]
+		}
+		return 0, fmt.Errorf("steam: HTTP %d: %s", resp.StatusCode, snippet)
+	}
+
+	var result struct {
+		Response struct {
+			SteamID string `json:"steamid"`
+			Success int    `json:"success"`
+			Message string `json:"message"`
+		} `json:"response"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return 0, fmt.Errorf("steam: decode response: %w", err)
+	}
+	if result.Response.Success != 1 {
+		return 0, fmt.Errorf("steam: no profile with custom URL %q", name)
+	}
+	id, err := strconv.ParseUint(result.Response.SteamID, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("steam: bad steamid %q in response: %w", result.Response.SteamID, err)
+	}
+	return id, nil
+}
@@ -0,0 +1,92 @@
+package steam
+
+import "testing"
+
+func TestParseSteamID(t *testing.T) {
+	const want = 76561197960287930 // account 22202
+	ok := []struct {
+		name, in string
+		want     uint64
+	}{
+		{"steamid64", "76561197960287930", want},
+		{"steamid64 spaces", "  76561197960287930\n", want},
+		{"steamid64 lowest", "76561197960265728", steamID64Base},
+		{"steamid64 highest", "76561202255233023", steamID64Max},
+		{"steamid3 brackets", "[U:1:22202]", want},
+		{"steamid3 bare", "U:1:22202", want},
+		{"steamid2", "STEAM_0:0:11101", want},
+		{"steamid2 odd", "STEAM_1:1:11101", want + 1},
+		{"steamid2 lower case", "steam_0:0:11101", want},
+		{"profile url", "https://steamcommunity.com/profiles/76561197960287930", want},
+		{"profile url no scheme", "steamcommunity.com/profiles/76561197960287930/", want},
+		{"profile url www query", "http://www.steamcommunity.com/profiles/76561197960287930?l=en", want},
+		{"profile url steamid3", "https://steamcommunity.com/profiles/[U:1:22202]", want},
+	}
+	for _, c := range ok {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := ParseSteamID(c.in)
+			if err != nil {
+				t.Fatalf("ParseSteamID(%q): %v", c.in, err)
+			}
+			if got != c.want {
+				t.Errorf("ParseSteamID(%q) = %d, want %d", c.in, got, c.want)
+			}
+		})
+	}
+
+	bad := []struct{ name, in string }{
+		{"empty", ""},
+		{"zero", "0"},
+		{"small number", "123"},
+		{"below individual range", "76561197960265727"},
+		{"above individual range", "76561202255233024"},
+		{"overflows uint64", "99999999999999999999"},
+		{"negative", "-76561197960287930"},
+		{"steamid3 open bracket", "[U:1:22202"},
+		{"steamid3 close bracket", "U:1:22202]"},
+		{"steamid3 account overflow", "[U:1:4294967296]"},
+		{"steamid3 other universe", "[U:2:22202]"},
+		{"steamid2 bad y", "STEAM_0:2:11101"},
+		{"steamid2 overflow", "STEAM_0:0:2147483648"},
+		{"custom url", "https://steamcommunity.com/id/gabelogannewell"},
+		{"vanity", "gabelogannewell"},
+		{"other site", "https://example.com/profiles/76561197960287930"},
+	}
+	for _, c := range bad {
+		t.Run(c.name, func(t *testing.T) {
+			if got, err := ParseSteamID(c.in); err == nil {
+				t.Errorf("ParseSteamID(%q) = %d, want an error", c.in, got)
+			}
+		})
+	}
+}
+
+func TestVanityName(t *testing.T) {
+	cases := []struct {
+		in, want string
+		ok       bool
+	}{
+		{"gabelogannewell", "gabelogannewell", true},
+		{" s1mple ", "s1mple", true},
+		{"https://steamcommunity.com/id/gabelogannewell/", "gabelogannewell", true},
+		{"steamcommunity.com/id/s1mple?l=en", "s1mple", true},
+		{"https://steamcommunity.com/id/1234", "1234", true},
+		{"76561197960287930", "", false},
+		{"123", "", false},
+		{"[U:1:22202]", "", false},
+		{"[U:1:22202", "", false},
+		{"STEAM_0:0:11101", "", false},
+		{"STEAM_9", "", false},
+		{"https://steamcommunity.com/profiles/76561197960287930", "", false},
+		{"https://steamcommunity.com/profiles/notanid", "", false},
+		{"a", "", false},
+		{"has space", "", false},
+		{"", "", false},
+	}
+	for _, c := range cases {
+		got, ok := VanityName(c.in)
+		if got != c.want || ok != c.ok {
+			t.Errorf("VanityName(%q) = %q, %v; want %q, %v", c.in, got, ok, c.want, c.ok)
+		}
+	}
+}
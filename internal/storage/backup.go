@@ -0,0 +1,371 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// childTables lists the per-demo stats tables copied alongside each demo row
+// by MergeFrom. Every table has a demo_hash column referencing demos(hash).
+var childTables = []string{
+	"player_match_stats",
+	"player_round_stats",
+	"player_weapon_stats",
+	"player_duel_segments",
+	"player_death_segments",
+	"player_death_contexts",
+	"player_time_to_damage",
+	"player_burst_stats",
+	"player_duel_distances",
+	"player_positions",
+	"player_first_sights",
+	"round_kill_states",
+	"team_round_economy",
+	"round_events",
+	"round_trade_chains",
+	"demo_unmapped_weapons",
+	"demo_diagnostics",
+	"demo_pass_timings",
+}
+
+// MergeResult summarises a MergeFrom call.
+type MergeResult struct {
+	DemosInserted int // demos present in the source but not in this DB
+	DemosSkipped  int // demos already stored here (deduplicated by hash), including kept conflicts
+	DemosReplaced int // conflicting demos replaced by the source's newer pipeline version
+	RowsInserted  int // child stats rows copied for inserted and replaced demos
+	Conflicts     []MergeConflict
+}
+
+// MergeConflict describes a demo stored in both databases whose stats rows
+// differ, typically because the two copies were aggregated by different
+// pipeline versions.
+type MergeConflict struct {
+	Hash          string
+	MapName       string
+	MatchDate     string
+	LocalVersion  int
+	SourceVersion int
+	TookSource    bool // true if the source copy replaced the local one
+}
+
+// Snapshot writes a consistent, compacted copy of the database to path using
+// SQLite's VACUUM INTO. The target file must not already exist.
+func (db *DB) Snapshot(path string) error {
+	if _, err := db.conn.Exec("VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("vacuum into %s: %w", path, err)
+	}
+	return nil
+}
+
+// MergeFrom attaches another csmetrics SQLite file and copies every demo whose
+// hash is not already stored here, together with its stats rows. Demos stored
+// in both databases with identical stats are skipped. When the stats differ the
+// demo is reported as a conflict and the copy with the higher pipeline version
+// wins; on a tie the local copy is kept. Only columns present in both databases
+// are copied, so a source created by an older build merges cleanly (missing
+// columns take their schema defaults). Every copied demo is audited as a merge;
+// the source's own audit log and trash are not copied. No statement writes to
+// the attached file.
+func (db *DB) MergeFrom(path string) (MergeResult, error) {
+	var res MergeResult
+	ctx := context.Background()
+
+	// The merge is one write transaction; queue it with this process's others.
+	db.writeMu.Lock()
+	defer db.writeMu.Unlock()
+
+	// ATTACH is per-connection, so pin one connection for the whole merge.
+	conn, err := db.conn.Conn(ctx)
+	if err != nil {
+		return res, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS src", path); err != nil {
+		return res, fmt.Errorf("attach %s: %w", path, err)
+	}
+	defer conn.ExecContext(ctx, "DETACH DATABASE src") //nolint:errcheck
+
+	// Resolve column lists up front; the pinned connection is owned by the
+	// transaction once it begins.
+	shared := make(map[string][]string, len(childTables)+1)
+	for _, table := range append([]string{"demos"}, childTables...) {
+		cols, err := sharedColumns(ctx, conn, table)
+		if err != nil {
+			return res, err
+		}
+		shared[table] = cols
+	}
+	if len(shared["demos"]) == 0 {
+		return res, fmt.Errorf("%s has no demos table; not a csmetrics database", path)
+	}
+
+	var duplicates int
+	if err := conn.QueryRowContext(ctx, `
+		SELECT COUNT(1) FROM src.demos WHERE hash IN (SELECT hash FROM main.demos)`,
+	).Scan(&duplicates); err != nil {
+		return res, fmt.Errorf("count duplicate demos: %w", err)
+	}
+
+	conflicts, err := findMergeConflicts(ctx, conn, shared)
+	if err != nil {
+		return res, err
+	}
+	res.Conflicts = conflicts
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return res, err
+	}
+	defer tx.Rollback()
+
+	// Local copies that lose a conflict are deleted first so the source copy
+	// is then inserted exactly like a new demo.
+	for _, c := range conflicts {
+		if !c.TookSource {
+			continue
+		}
+		for _, table := range childTables {
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM main.%s WHERE demo_hash = ?", table), c.Hash); err != nil {
+				return res, fmt.Errorf("replace %s %s: %w", table, c.Hash, err)
+			}
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM main.demos WHERE hash = ?", c.Hash); err != nil {
+			return res, fmt.Errorf("replace demo %s: %w", c.Hash, err)
+		}
+		res.DemosReplaced++
+	}
+	res.DemosSkipped = duplicates - res.DemosReplaced
+
+	// Snapshot the set of new hashes before copying anything so demos and
+	// their stats rows are selected against the same list.
+	if _, err := tx.ExecContext(ctx, `
+		CREATE TEMP TABLE merge_new AS
+		SELECT hash FROM src.demos WHERE hash NOT IN (SELECT hash FROM main.demos)`); err != nil {
+		return res, fmt.Errorf("collect new demos: %w", err)
+	}
+
+	colList := strings.Join(shared["demos"], ", ")
+	r, err := tx.ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO main.demos(%s)
+		SELECT %s FROM src.demos WHERE hash IN (SELECT hash FROM temp.merge_new)`,
+		colList, colList))
+	if err != nil {
+		return res, fmt.Errorf("merge demos: %w", err)
+	}
+	n, _ := r.RowsAffected()
+	res.DemosInserted = int(n) - res.DemosReplaced
+
+	for _, table := range childTables {
+		cols := shared[table]
+		if len(cols) == 0 {
+			continue
+		}
+		colList := strings.Join(cols, ", ")
+		r, err := tx.ExecContext(ctx, fmt.Sprintf(`
+			INSERT OR IGNORE INTO main.%s(%s)
+			SELECT %s FROM src.%s WHERE demo_hash IN (SELECT hash FROM temp.merge_new)`,
+			table, colList, colList, table))
+		if err != nil {
+			return res, fmt.Errorf("merge %s: %w", table, err)
+		}
+		n, _ := r.RowsAffected()
+		res.RowsInserted += int(n)
+	}
+
+	if err := db.auditMerge(tx, path, conflicts); err != nil {
+		return res, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return res, err
+	}
+	// A rollback discards the temp table with the rest of the transaction;
+	// after a commit it has to be dropped explicitly.
+	if _, err := conn.ExecContext(ctx, "DROP TABLE temp.merge_new"); err != nil {
+		return res, fmt.Errorf("drop merge_new: %w", err)
+	}
+	return res, nil
+}
+
+// auditMerge records one merge entry per demo copied in by MergeFrom, noting
+// the local version a replaced conflict had.
+func (db *DB) auditMerge(tx *sql.Tx, path string, conflicts []MergeConflict) error {
+	replaced := make(map[string]int)
+	for _, c := range conflicts {
+		if c.TookSource {
+			replaced[c.Hash] = c.LocalVersion
+		}
+	}
+	rows, err := tx.Query(`
+		SELECT hash, pipeline_version FROM main.demos
+		WHERE hash IN (SELECT hash FROM temp.merge_new) ORDER BY hash`)
+	if err != nil {
+		return fmt.Errorf("audit merge: %w", err)
+	}
+	type merged struct {
+		hash    string
+		version int
+	}
+	var demos []merged
+	for rows.Next() {
+		var m merged
+		if err := rows.Scan(&m.hash, &m.version); err != nil {
+			rows.Close()
+			return err
+		}
+		demos = append(demos, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, m := range demos {
+		detail := "from " + filepath.Base(path)
+		if v, ok := replaced[m.hash]; ok {
+			detail += fmt.Sprintf(", replaced local v%d", v)
+		}
+		if err := db.audit(tx, AuditMerge, m.hash, m.version, detail); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findMergeConflicts returns the demos stored in both databases whose rows in
+// any child stats table differ on the shared columns, ordered by match date.
+func findMergeConflicts(ctx context.Context, conn *sql.Conn, shared map[string][]string) ([]MergeConflict, error) {
+	hashes := make(map[string]bool)
+	for _, table := range childTables {
+		// Timings depend on the machine that parsed the demo.
+		if table == "demo_pass_timings" {
+			continue
+		}
+		// The version stamp itself is not a stats difference.
+		var cols []string
+		for _, c := range shared[table] {
+			if c != "pipeline_version" {
+				cols = append(cols, c)
+			}
+		}
+		if len(cols) == 0 {
+			continue
+		}
+		colList := strings.Join(cols, ", ")
+		rows, err := conn.QueryContext(ctx, fmt.Sprintf(`
+			SELECT demo_hash FROM (
+				SELECT %[1]s FROM src.%[2]s WHERE demo_hash IN (SELECT hash FROM main.demos)
+				EXCEPT
+				SELECT %[1]s FROM main.%[2]s
+			)
+			UNION
+			SELECT demo_hash FROM (
+				SELECT %[1]s FROM main.%[2]s WHERE demo_hash IN (SELECT hash FROM src.demos)
+				EXCEPT
+				SELECT %[1]s FROM src.%[2]s
+			)`, colList, table))
+		if err != nil {
+			return nil, fmt.Errorf("compare %s: %w", table, err)
+		}
+		for rows.Next() {
+			var h string
+			if err := rows.Scan(&h); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			hashes[h] = true
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	if len(hashes) == 0 {
+		return nil, nil
+	}
+
+	// Sources written before pipeline versioning lack the column; treat them
+	// as version 0 so any versioned local copy wins.
+	srcVersion := "0"
+	for _, c := range shared["demos"] {
+		if c == "pipeline_version" {
+			srcVersion = "s.pipeline_version"
+		}
+	}
+	args := make([]interface{}, 0, len(hashes))
+	for h := range hashes {
+		args = append(args, h)
+	}
+	rows, err := conn.QueryContext(ctx, fmt.Sprintf(`
+		SELECT m.hash, m.map_name, m.match_date, m.pipeline_version, %s
+		FROM main.demos m JOIN src.demos s ON s.hash = m.hash
+		WHERE m.hash IN (%s)
+		ORDER BY m.match_date, m.hash`, srcVersion, placeholders(len(args))), args...)
+	if err != nil {
+		return nil, fmt.Errorf("conflict versions: %w", err)
+	}
+	defer rows.Close()
+
+	var out []MergeConflict
+	for rows.Next() {
+		var c MergeConflict
+		if err := rows.Scan(&c.Hash, &c.MapName, &c.MatchDate, &c.LocalVersion, &c.SourceVersion); err != nil {
+			return nil, err
+		}
+		c.TookSource = c.SourceVersion > c.LocalVersion
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// sharedColumns returns the columns of table that exist in both the main and
+// the attached "src" schema, in main's declaration order. Returns nil if the
+// table is missing from the source.
+func sharedColumns(ctx context.Context, conn *sql.Conn, table string) ([]string, error) {
+	mainCols, err := tableColumns(ctx, conn, "main", table)
+	if err != nil {
+		return nil, err
+	}
+	srcCols, err := tableColumns(ctx, conn, "src", table)
+	if err != nil {
+		return nil, err
+	}
+	inSrc := make(map[string]bool, len(srcCols))
+	for _, c := range srcCols {
+		inSrc[c] = true
+	}
+	var out []string
+	for _, c := range mainCols {
+		if inSrc[c] {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+// tableColumns returns the column names of schema.table via PRAGMA table_info.
+func tableColumns(ctx context.Context, conn *sql.Conn, schema, table string) ([]string, error) {
+	rows, err := conn.QueryContext(ctx, fmt.Sprintf("PRAGMA %s.table_info(%s)", schema, table))
+	if err != nil {
+		return nil, fmt.Errorf("table info %s.%s: %w", schema, table, err)
+	}
+	defer rows.Close()
+
+	var cols []string
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, typ        string
+			dflt             sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dflt, &pk); err != nil {
+			return nil, err
+		}
+		cols = append(cols, name)
+	}
+	return cols, rows.Err()
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// Baseline source statuses recorded by "baseline build".
+const (
+	BaselineStored    = "stored"    // parsed and stored as a baseline demo of the tier
+	BaselineDuplicate = "duplicate" // demo already in the DB (any tier); not counted
+	BaselineSkipped   = "skipped"   // filtered out: wrong level or map, unfinished, no demo
+	BaselineFailed    = "failed"    // download or parse error; retried on the next run
+)
+
+// BaselineSource is one demo source tried by "baseline build".
+type BaselineSource struct {
+	SourceID string // "faceit:<match id>" or "file:<quick hash>"
+	Tier     string
+	Anchor   string // anchor player the source was found through; empty for files
+	Status   string // one of the Baseline* statuses
+	DemoHash string
+	Detail   string // skip reason or error
+}
+
+// SetBaselineQuota creates or updates tier's target demo count.
+func (db *DB) SetBaselineQuota(tier string, target int) error {
+	return db.withTx(func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			INSERT INTO baseline_quotas(tier, target, updated_at) VALUES (?, ?, ?)
+			ON CONFLICT(tier) DO UPDATE SET target = excluded.target, updated_at = excluded.updated_at`,
+			tier, target, time.Now().UTC().Format(time.RFC3339))
+		return err
+	})
+}
+
+// GetBaselineQuotas returns every tier with a quota, with its progress,
+// ordered by tier. An empty tier returns all tiers; otherwise only that one.
+func (db *DB) GetBaselineQuotas(tier string) ([]model.BaselineQuota, error) {
+	rows, err := db.conn.Query(`
+		SELECT q.tier, q.target,
+		       (SELECT COUNT(*) FROM demos d WHERE d.is_baseline = 1 AND d.tier = q.tier),
+		       (SELECT COUNT(*) FROM baseline_sources s WHERE s.tier = q.tier),
+		       (SELECT COUNT(*) FROM baseline_sources s WHERE s.tier = q.tier AND s.status = ?),
+		       q.updated_at
+		FROM baseline_quotas q
+		WHERE ? = '' OR q.tier = ?
+		ORDER BY q.tier`, BaselineFailed, tier, tier)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []model.BaselineQuota
+	for rows.Next() {
+		var q model.BaselineQuota
+		if err := rows.Scan(&q.Tier, &q.Target, &q.Stored, &q.Tried, &q.Failed, &q.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, q)
+	}
+	return out, rows.Err()
+}
+
+// BaselineSourceDone reports whether sourceID was already tried with a final
+// status (anything but failed), so it must not be fetched again.
+func (db *DB) BaselineSourceDone(sourceID string) (bool, error) {
+	var status string
+	err := db.conn.QueryRow(`SELECT status FROM baseline_sources WHERE source_id = ?`, sourceID).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return status != BaselineFailed, nil
+}
+
+// RecordBaselineSource stores the outcome of trying s, replacing any earlier
+// attempt.
+func (db *DB) RecordBaselineSource(s BaselineSource) error {
+	return db.withTx(func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			INSERT OR REPLACE INTO baseline_sources(source_id, tier, anchor, status, demo_hash, detail, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			s.SourceID, s.Tier, s.Anchor, s.Status, s.DemoHash, s.Detail, time.Now().UTC().Format(time.RFC3339))
+		return err
+	})
+}
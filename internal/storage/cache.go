@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// GetAggregateCache returns the payload cached for a player under
+// filtersHash by pipeline version, and whether there was one.
+func (db *DB) GetAggregateCache(steamID uint64, filtersHash string, version int) ([]byte, bool, error) {
+	var payload string
+	err := db.conn.QueryRow(`
+		SELECT payload FROM player_aggregate_cache
+		WHERE steam_id = ? AND filters_hash = ? AND pipeline_version = ?`,
+		strconv.FormatUint(steamID, 10), filtersHash, version).Scan(&payload)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("read aggregate cache: %w", err)
+	}
+	return []byte(payload), true, nil
+}
+
+// PutAggregateCache caches payload for a player under filtersHash, replacing
+// the entries for the same filters computed by other pipeline versions.
+func (db *DB) PutAggregateCache(steamID uint64, filtersHash string, version int, payload []byte) error {
+	id := strconv.FormatUint(steamID, 10)
+	return db.withTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM player_aggregate_cache WHERE steam_id = ? AND filters_hash = ?`, id, filtersHash); err != nil {
+			return fmt.Errorf("replace aggregate cache: %w", err)
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO player_aggregate_cache(steam_id, filters_hash, pipeline_version, payload, created_at)
+			VALUES (?, ?, ?, ?, ?)`,
+			id, filtersHash, version, string(payload), time.Now().UTC().Format(time.RFC3339)); err != nil {
+			return fmt.Errorf("write aggregate cache: %w", err)
+		}
+		return nil
+	})
+}
+
+// ClearAggregateCache drops every cached entry and returns how many there
+// were.
+func (db *DB) ClearAggregateCache() (int, error) {
+	var n int64
+	err := db.withTx(func(tx *sql.Tx) error {
+		r, err := tx.Exec(`DELETE FROM player_aggregate_cache`)
+		if err != nil {
+			return fmt.Errorf("clear aggregate cache: %w", err)
+		}
+		n, _ = r.RowsAffected()
+		return nil
+	})
+	return int(n), err
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// insertDemoDiagnostics stores a demo's event counts and pass timings within
+// an open transaction. A zero value (no DemoHash) stores nothing.
+func insertDemoDiagnostics(tx *sql.Tx, d model.DemoDiagnostics) error {
+	if d.DemoHash == "" {
+		return nil
+	}
+	if _, err := tx.Exec(`
+		INSERT OR REPLACE INTO demo_diagnostics(
+			demo_hash, rounds, kills, damages, flashes, weapon_fires,
+			first_sights, position_samples, pre_live_rounds, server_frame_drop_pct
+		) VALUES (?,?,?,?,?,?,?,?,?,?)`,
+		d.DemoHash, d.Rounds, d.Kills, d.Damages, d.Flashes, d.WeaponFires,
+		d.FirstSights, d.PositionSamples, d.PreLiveRounds, d.ServerFrameDropPct,
+	); err != nil {
+		return fmt.Errorf("insert demo_diagnostics: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO demo_pass_timings(demo_hash, seq, pass, ms)
+		VALUES (?,?,?,?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for i, p := range d.Passes {
+		if _, err := stmt.Exec(d.DemoHash, i, p.Pass, p.Ms); err != nil {
+			return fmt.Errorf("insert demo_pass_timings %q: %w", p.Pass, err)
+		}
+	}
+	return nil
+}
+
+// GetDemoDiagnostics returns the event counts and pass timings recorded when
+// a demo was parsed, or nil if none were stored (demos parsed before
+// diagnostics were recorded, or merged from such a database).
+func (db *DB) GetDemoDiagnostics(demoHash string) (*model.DemoDiagnostics, error) {
+	d := model.DemoDiagnostics{DemoHash: demoHash}
+	err := db.conn.QueryRow(`
+		SELECT rounds, kills, damages, flashes, weapon_fires,
+		       first_sights, position_samples, pre_live_rounds, server_frame_drop_pct
+		FROM demo_diagnostics WHERE demo_hash = ?`, demoHash).
+		Scan(&d.Rounds, &d.Kills, &d.Damages, &d.Flashes, &d.WeaponFires,
+			&d.FirstSights, &d.PositionSamples, &d.PreLiveRounds, &d.ServerFrameDropPct)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT pass, ms FROM demo_pass_timings
+		WHERE demo_hash = ? ORDER BY seq`, demoHash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p model.PassTiming
+		if err := rows.Scan(&p.Pass, &p.Ms); err != nil {
+			return nil, err
+		}
+		d.Passes = append(d.Passes, p)
+	}
+	return &d, rows.Err()
+}
+
+// ListDemoDiagnostics returns the event counts of every stored demo with
+// diagnostics (without pass timings), the reference parse output is compared
+// against.
+func (db *DB) ListDemoDiagnostics() ([]model.DemoDiagnostics, error) {
+	rows, err := db.conn.Query(`
+		SELECT demo_hash, rounds, kills, damages, flashes, weapon_fires,
+		       first_sights, position_samples, pre_live_rounds, server_frame_drop_pct
+		FROM demo_diagnostics ORDER BY demo_hash`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.DemoDiagnostics
+	for rows.Next() {
+		var d model.DemoDiagnostics
+		if err := rows.Scan(&d.DemoHash, &d.Rounds, &d.Kills, &d.Damages, &d.Flashes,
+			&d.WeaponFires, &d.FirstSights, &d.PositionSamples, &d.PreLiveRounds,
+			&d.ServerFrameDropPct); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
@@ -2,15 +2,20 @@ package storage
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/pable/go-cs-metrics/internal/model"
 )
 
-// DemoRef holds a demo hash, map name, and match date, used by the simbo3 exporter.
+// DemoRef holds a demo hash, map name, match date and pipeline version, used
+// by the simbo3 exporter.
 type DemoRef struct {
-	Hash      string
-	MapName   string
-	MatchDate string // "YYYY-MM-DD"
+	Hash            string
+	MapName         string
+	MatchDate       string // "YYYY-MM-DD"
+	PipelineVersion int
 }
 
 // WinOutcome captures round outcome data for a single demo.
@@ -49,6 +54,18 @@ type DemoSideStats struct {
 	TTotal  int
 }
 
+// DemoHalfSideStats holds a roster's round wins by regulation half for a
+// single demo. FirstSide is the side ("CT" or "T") the roster started on;
+// the second half is played on the other side. Overtime is not counted.
+type DemoHalfSideStats struct {
+	Hash        string
+	FirstSide   string
+	FirstWins   int
+	FirstTotal  int
+	SecondWins  int
+	SecondTotal int
+}
+
 // PlayerDemoTotals holds per-demo stats for one player (not aggregated).
 type PlayerDemoTotals struct {
 	SteamID      string
@@ -60,6 +77,7 @@ type PlayerDemoTotals struct {
 	KastRounds   int
 	RoundsPlayed int
 	TotalDamage  int
+	Role         string // "AWPer" | "Entry" | "Support" | "Rifler" in this demo
 }
 
 // QualifyingDemos returns demos within the time window where at least quorum
@@ -76,7 +94,7 @@ func (db *DB) QualifyingDemos(steamIDs []string, since time.Time, quorum int) ([
 	args = append(args, since.Format("2006-01-02"))
 
 	query := fmt.Sprintf(`
-		SELECT d.hash, d.map_name, d.match_date
+		SELECT d.hash, d.map_name, d.match_date, d.pipeline_version
 		FROM demos d
 		JOIN player_match_stats p ON p.demo_hash = d.hash
 		WHERE p.steam_id IN (%s)
@@ -95,7 +113,7 @@ func (db *DB) QualifyingDemos(steamIDs []string, since time.Time, quorum int) ([
 	var out []DemoRef
 	for rows.Next() {
 		var r DemoRef
-		if err := rows.Scan(&r.Hash, &r.MapName, &r.MatchDate); err != nil {
+		if err := rows.Scan(&r.Hash, &r.MapName, &r.MatchDate, &r.PipelineVersion); err != nil {
 			return nil, err
 		}
 		out = append(out, r)
@@ -119,7 +137,7 @@ func (db *DB) QualifyingDemosWindow(steamIDs []string, from, before time.Time, q
 	args = append(args, before.Format("2006-01-02"))
 
 	query := fmt.Sprintf(`
-		SELECT d.hash, d.map_name, d.match_date
+		SELECT d.hash, d.map_name, d.match_date, d.pipeline_version
 		FROM demos d
 		JOIN player_match_stats p ON p.demo_hash = d.hash
 		WHERE p.steam_id IN (%s)
@@ -139,7 +157,7 @@ func (db *DB) QualifyingDemosWindow(steamIDs []string, from, before time.Time, q
 	var out []DemoRef
 	for rows.Next() {
 		var r DemoRef
-		if err := rows.Scan(&r.Hash, &r.MapName, &r.MatchDate); err != nil {
+		if err := rows.Scan(&r.Hash, &r.MapName, &r.MatchDate, &r.PipelineVersion); err != nil {
 			return nil, err
 		}
 		out = append(out, r)
@@ -341,12 +359,11 @@ type TradeStats struct {
 	RoundsPlayed int
 }
 
-// BuyTypeWinRate holds win/total counts for eco and force buy types.
-type BuyTypeWinRate struct {
-	EcoWins    int
-	EcoTotal   int
-	ForceWins  int
-	ForceTotal int
+// RoundTypeWinRate holds a team's win/total round counts for one team
+// economy class.
+type RoundTypeWinRate struct {
+	Wins  int
+	Total int
 }
 
 // PostPlantStats holds T-side post-plant win counts for one map.
@@ -355,6 +372,17 @@ type PostPlantStats struct {
 	TTotal int
 }
 
+// BombSiteStats holds a roster's plant counts at one bomb site of one map:
+// rounds it planted there on T and won, and rounds it conceded a plant there
+// on CT and still won.
+type BombSiteStats struct {
+	Site     string // "A" or "B"
+	TPlants  int
+	TWins    int
+	CTPlants int
+	CTWins   int
+}
+
 // MapEntryStats returns per-map opening kill/death counts and rounds_played
 // for the given roster players across the given demo hashes.
 func (db *DB) MapEntryStats(steamIDs []string, demoHashes []string) (map[string]MapEntryStats, error) {
@@ -434,12 +462,14 @@ func (db *DB) TeamTradeStats(steamIDs []string, demoHashes []string) (TradeStats
 	return s, err
 }
 
-// BuyTypeWinRates returns eco and force buy-type win/total counts for the
-// given roster players across the given demo hashes.
-func (db *DB) BuyTypeWinRates(steamIDs []string, demoHashes []string) (BuyTypeWinRate, error) {
-	var r BuyTypeWinRate
+// TeamEconomyWinRates returns the roster team's win/total round counts per
+// team economy class (see model.TeamRoundTypes) across the given demo hashes.
+// The roster's side in a round is the side most of its players in
+// player_round_stats were on; rounds with the roster split evenly are skipped.
+func (db *DB) TeamEconomyWinRates(steamIDs []string, demoHashes []string) (map[string]RoundTypeWinRate, error) {
+	out := make(map[string]RoundTypeWinRate)
 	if len(steamIDs) == 0 || len(demoHashes) == 0 {
-		return r, nil
+		return out, nil
 	}
 	idPH := placeholders(len(steamIDs))
 	hashPH := placeholders(len(demoHashes))
@@ -453,36 +483,42 @@ func (db *DB) BuyTypeWinRates(steamIDs []string, demoHashes []string) (BuyTypeWi
 	}
 
 	query := fmt.Sprintf(`
-		SELECT buy_type,
-		       COALESCE(SUM(won_round), 0),
+		WITH roster AS (
+			SELECT demo_hash, round_number, team, COUNT(*) AS n
+			FROM player_round_stats
+			WHERE steam_id IN (%s)
+			  AND demo_hash IN (%s)
+			  AND team IN ('CT', 'T')
+			GROUP BY demo_hash, round_number, team
+		)
+		SELECT e.round_type,
+		       COALESCE(SUM(e.won), 0),
 		       COUNT(*)
-		FROM player_round_stats
-		WHERE steam_id IN (%s)
-		  AND demo_hash IN (%s)
-		  AND buy_type IN ('eco', 'force')
-		GROUP BY buy_type`,
+		FROM roster r
+		JOIN team_round_economy e
+		  ON e.demo_hash = r.demo_hash AND e.round_number = r.round_number AND e.team = r.team
+		WHERE r.n > COALESCE((
+			SELECT o.n FROM roster o
+			WHERE o.demo_hash = r.demo_hash AND o.round_number = r.round_number AND o.team <> r.team
+		), 0)
+		GROUP BY e.round_type`,
 		idPH, hashPH)
 
 	rows, err := db.conn.Query(query, args...)
 	if err != nil {
-		return r, err
+		return nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var buyType string
-		var wins, total int
-		if err := rows.Scan(&buyType, &wins, &total); err != nil {
-			return r, err
-		}
-		switch buyType {
-		case "eco":
-			r.EcoWins, r.EcoTotal = wins, total
-		case "force":
-			r.ForceWins, r.ForceTotal = wins, total
+		var roundType string
+		var r RoundTypeWinRate
+		if err := rows.Scan(&roundType, &r.Wins, &r.Total); err != nil {
+			return nil, err
 		}
+		out[roundType] = r
 	}
-	return r, rows.Err()
+	return out, rows.Err()
 }
 
 // MapPostPlantTWinRates returns per-map T-side post-plant win/total counts
@@ -531,6 +567,72 @@ func (db *DB) MapPostPlantTWinRates(steamIDs []string, demoHashes []string) (map
 	return out, rows.Err()
 }
 
+// MapBombSiteStats returns per-map bomb site counts (sites in A, B order) for
+// the given roster players across the given demo hashes, from the plant events
+// of the round timeline. The roster's side in a round is the side most of its
+// players were on; rounds with the roster split evenly are skipped, as are
+// demos parsed before plant sites were recorded.
+func (db *DB) MapBombSiteStats(steamIDs []string, demoHashes []string) (map[string][]BombSiteStats, error) {
+	if len(steamIDs) == 0 || len(demoHashes) == 0 {
+		return nil, nil
+	}
+	idPH := placeholders(len(steamIDs))
+	hashPH := placeholders(len(demoHashes))
+
+	args := make([]interface{}, 0, len(steamIDs)+len(demoHashes))
+	for _, id := range steamIDs {
+		args = append(args, id)
+	}
+	for _, h := range demoHashes {
+		args = append(args, h)
+	}
+
+	query := fmt.Sprintf(`
+		WITH roster AS (
+			SELECT demo_hash, round_number, team, COUNT(*) AS n, MAX(won_round) AS won
+			FROM player_round_stats
+			WHERE steam_id IN (%s)
+			  AND demo_hash IN (%s)
+			  AND team IN ('CT', 'T')
+			GROUP BY demo_hash, round_number, team
+		)
+		SELECT d.map_name, e.detail,
+		       COALESCE(SUM(CASE WHEN r.team='T'                THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN r.team='T'  AND r.won=1   THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN r.team='CT'               THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN r.team='CT' AND r.won=1   THEN 1 ELSE 0 END), 0)
+		FROM round_events e
+		JOIN roster r ON r.demo_hash = e.demo_hash AND r.round_number = e.round_number
+		JOIN demos d ON d.hash = e.demo_hash
+		WHERE e.type = ?
+		  AND e.detail IN ('A', 'B')
+		  AND r.n > COALESCE((
+			SELECT o.n FROM roster o
+			WHERE o.demo_hash = r.demo_hash AND o.round_number = r.round_number AND o.team <> r.team
+		  ), 0)
+		GROUP BY d.map_name, e.detail
+		ORDER BY d.map_name, e.detail`,
+		idPH, hashPH)
+	args = append(args, model.TimelinePlant)
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string][]BombSiteStats)
+	for rows.Next() {
+		var mapName string
+		var s BombSiteStats
+		if err := rows.Scan(&mapName, &s.Site, &s.TPlants, &s.TWins, &s.CTPlants, &s.CTWins); err != nil {
+			return nil, err
+		}
+		out[mapName] = append(out[mapName], s)
+	}
+	return out, rows.Err()
+}
+
 // RoundSideStatsByDemo returns per-demo CT/T round win counts for the given
 // roster players and demo hashes, grouped by demo_hash.
 func (db *DB) RoundSideStatsByDemo(steamIDs []string, demoHashes []string) ([]DemoSideStats, error) {
@@ -577,6 +679,77 @@ func (db *DB) RoundSideStatsByDemo(steamIDs []string, demoHashes []string) ([]De
 	return out, rows.Err()
 }
 
+// RoundHalfSideStatsByDemo returns per-demo first- and second-half round win
+// counts for the given roster players and demo hashes. Each round is credited
+// to the side most roster players were on; the halves split where that side
+// first switches, and rounds after the second switch (overtime) are dropped.
+// Demos without a side switch report only a first half.
+func (db *DB) RoundHalfSideStatsByDemo(steamIDs []string, demoHashes []string) ([]DemoHalfSideStats, error) {
+	if len(steamIDs) == 0 || len(demoHashes) == 0 {
+		return nil, nil
+	}
+	idPH := placeholders(len(steamIDs))
+	hashPH := placeholders(len(demoHashes))
+
+	args := make([]interface{}, 0, len(steamIDs)+len(demoHashes))
+	for _, id := range steamIDs {
+		args = append(args, id)
+	}
+	for _, h := range demoHashes {
+		args = append(args, h)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT demo_hash, round_number, team, MAX(won_round)
+		FROM player_round_stats
+		WHERE steam_id IN (%s)
+		  AND demo_hash IN (%s)
+		  AND team IN ('CT', 'T')
+		GROUP BY demo_hash, round_number, team
+		ORDER BY demo_hash, round_number, COUNT(*) DESC, team`,
+		idPH, hashPH)
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []DemoHalfSideStats
+	var cur *DemoHalfSideStats
+	var side string
+	half, lastRound := 0, 0
+	for rows.Next() {
+		var hash, team string
+		var round, won int
+		if err := rows.Scan(&hash, &round, &team, &won); err != nil {
+			return nil, err
+		}
+		if cur == nil || cur.Hash != hash {
+			out = append(out, DemoHalfSideStats{Hash: hash, FirstSide: team})
+			cur = &out[len(out)-1]
+			side, half, lastRound = team, 1, 0
+		}
+		if round == lastRound {
+			continue // minority side of a round already counted
+		}
+		lastRound = round
+		if team != side {
+			side = team
+			half++
+		}
+		switch half {
+		case 1:
+			cur.FirstTotal++
+			cur.FirstWins += won
+		case 2:
+			cur.SecondTotal++
+			cur.SecondWins += won
+		}
+	}
+	return out, rows.Err()
+}
+
 // RosterMatchTotalsByDemo returns per-player per-demo stats (not aggregated)
 // for the given roster players across the given demo hashes.
 func (db *DB) RosterMatchTotalsByDemo(steamIDs []string, demoHashes []string) ([]PlayerDemoTotals, error) {
@@ -596,7 +769,7 @@ func (db *DB) RosterMatchTotalsByDemo(steamIDs []string, demoHashes []string) ([
 
 	query := fmt.Sprintf(`
 		SELECT steam_id, name, demo_hash,
-		       kills, deaths, assists, kast_rounds, rounds_played, total_damage
+		       kills, deaths, assists, kast_rounds, rounds_played, total_damage, role
 		FROM player_match_stats
 		WHERE steam_id IN (%s)
 		  AND demo_hash IN (%s)
@@ -615,7 +788,7 @@ func (db *DB) RosterMatchTotalsByDemo(steamIDs []string, demoHashes []string) ([
 		if err := rows.Scan(
 			&p.SteamID, &p.Name, &p.DemoHash,
 			&p.Kills, &p.Deaths, &p.Assists,
-			&p.KastRounds, &p.RoundsPlayed, &p.TotalDamage,
+			&p.KastRounds, &p.RoundsPlayed, &p.TotalDamage, &p.Role,
 		); err != nil {
 			return nil, err
 		}
@@ -624,6 +797,56 @@ func (db *DB) RosterMatchTotalsByDemo(steamIDs []string, demoHashes []string) ([
 	return out, rows.Err()
 }
 
+// KillStates returns the stored pre-kill round states for the given demos, or
+// for every stored demo when demoHashes is nil. Rows are ordered by demo, round
+// and tick so each round's kill sequence is contiguous.
+func (db *DB) KillStates(demoHashes []string) ([]model.KillState, error) {
+	query := `
+		SELECT demo_hash, round_number, tick, killer_id, victim_id,
+		       killer_team, victim_team, ct_alive, t_alive, bomb_planted, winner_team, weapon,
+		       clock_remaining_sec
+		FROM round_kill_states`
+	var args []interface{}
+	if demoHashes != nil {
+		if len(demoHashes) == 0 {
+			return nil, nil
+		}
+		query += fmt.Sprintf(" WHERE demo_hash IN (%s)", placeholders(len(demoHashes)))
+		for _, h := range demoHashes {
+			args = append(args, h)
+		}
+	}
+	query += " ORDER BY demo_hash, round_number, tick"
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.KillState
+	for rows.Next() {
+		var ks model.KillState
+		var killerStr, victimStr, killerTeam, victimTeam, winnerTeam string
+		var planted int
+		if err := rows.Scan(
+			&ks.DemoHash, &ks.RoundNumber, &ks.Tick, &killerStr, &victimStr,
+			&killerTeam, &victimTeam, &ks.CTAlive, &ks.TAlive, &planted, &winnerTeam, &ks.Weapon,
+			&ks.ClockSec,
+		); err != nil {
+			return nil, err
+		}
+		ks.KillerSteamID, _ = strconv.ParseUint(killerStr, 10, 64)
+		ks.VictimSteamID, _ = strconv.ParseUint(victimStr, 10, 64)
+		ks.KillerTeam = parseTeam(killerTeam)
+		ks.VictimTeam = parseTeam(victimTeam)
+		ks.WinnerTeam = parseTeam(winnerTeam)
+		ks.BombPlanted = planted != 0
+		out = append(out, ks)
+	}
+	return out, rows.Err()
+}
+
 // placeholders returns a comma-separated string of n "?" for SQL IN clauses,
 // e.g. placeholders(3) → "?,?,?".
 func placeholders(n int) string {
@@ -20,6 +20,32 @@ func (db *DB) DemoExists(hash string) (bool, error) {
 	return count > 0, nil
 }
 
+// StoredMatchIDs returns which of the external match IDs (FACEIT or Valve
+// match IDs, see model.DemoSource) belong to a stored demo.
+func (db *DB) StoredMatchIDs(ids []string) (map[string]bool, error) {
+	stored := make(map[string]bool)
+	if len(ids) == 0 {
+		return stored, nil
+	}
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := db.conn.Query(`SELECT external_match_id FROM demos WHERE external_match_id IN (`+placeholders(len(ids))+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		stored[id] = true
+	}
+	return stored, rows.Err()
+}
+
 // DemoExistsByQuickHash looks up a demo by its 64-KB prefix hash. Returns
 // (true, fullHash) if found, (false, "") if not. Use this for a cheap
 // pre-existence check before committing to a full demo parse.
@@ -38,18 +64,52 @@ func (db *DB) DemoExistsByQuickHash(quickHash string) (bool, string, error) {
 // UpdateDemoMeta updates the flag-derived metadata columns on an already-stored demo.
 // quickHash may be empty, in which case any existing quick_hash value is preserved.
 // Columns sourced from the demo file itself (map_name, match_date, tickrate,
-// ct_score, t_score) are not touched.
+// ct_score, t_score) are not touched. Changed fields are recorded in the
+// audit log as a retag.
 func (db *DB) UpdateDemoMeta(hash, quickHash, matchType, tier, eventID string, isBaseline bool) error {
 	var qh interface{}
 	if quickHash != "" {
 		qh = quickHash
 	}
-	_, err := db.conn.Exec(`
-		UPDATE demos SET quick_hash=COALESCE(?,quick_hash), match_type=?, tier=?, event_id=?, is_baseline=?
-		WHERE hash=?`,
-		qh, matchType, tier, eventID, boolInt(isBaseline), hash,
-	)
-	return err
+	return db.withTx(func(tx *sql.Tx) error {
+		var (
+			oldType, oldTier, oldEvent string
+			oldBaseline, version       int
+		)
+		err := tx.QueryRow(`
+			SELECT match_type, tier, event_id, is_baseline, pipeline_version FROM demos WHERE hash=?`, hash).
+			Scan(&oldType, &oldTier, &oldEvent, &oldBaseline, &version)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`
+			UPDATE demos SET quick_hash=COALESCE(?,quick_hash), match_type=?, tier=?, event_id=?, is_baseline=?
+			WHERE hash=?`,
+			qh, matchType, tier, eventID, boolInt(isBaseline), hash,
+		); err != nil {
+			return err
+		}
+		var changes []string
+		if oldType != matchType {
+			changes = append(changes, fmt.Sprintf("type %q → %q", oldType, matchType))
+		}
+		if oldTier != tier {
+			changes = append(changes, fmt.Sprintf("tier %q → %q", oldTier, tier))
+		}
+		if oldEvent != eventID {
+			changes = append(changes, fmt.Sprintf("event %q → %q", oldEvent, eventID))
+		}
+		if oldBaseline != boolInt(isBaseline) {
+			changes = append(changes, fmt.Sprintf("baseline %t → %t", oldBaseline != 0, isBaseline))
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+		return db.audit(tx, AuditRetag, hash, version, strings.Join(changes, ", "))
+	})
 }
 
 // InsertDemo inserts a demo record. Uses INSERT OR REPLACE for idempotency.
@@ -57,21 +117,224 @@ func (db *DB) UpdateDemoMeta(hash, quickHash, matchType, tier, eventID string, i
 // string if unavailable and it will be stored as NULL.
 // MapName is normalized to title-case (e.g. "de_mirage" → "Mirage") before storage
 // so all reads return a consistent name regardless of what the demo header contains.
+// The write is audited like ReplaceDemo.
 func (db *DB) InsertDemo(summary model.MatchSummary, quickHash string) error {
+	return db.withTx(func(tx *sql.Tx) error {
+		action, detail, err := demoWriteAction(tx, summary.DemoHash)
+		if err != nil {
+			return err
+		}
+		if err := insertDemo(tx, summary, quickHash); err != nil {
+			return err
+		}
+		return db.audit(tx, action, summary.DemoHash, summary.PipelineVersion, detail)
+	})
+}
+
+// demoWriteAction returns the audit action for storing hash: an insert, or a
+// replace noting the stored copy's pipeline version.
+func demoWriteAction(tx *sql.Tx, hash string) (action, detail string, err error) {
+	var prev int
+	err = tx.QueryRow("SELECT pipeline_version FROM demos WHERE hash = ?", hash).Scan(&prev)
+	if err == sql.ErrNoRows {
+		return AuditInsert, "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("look up demo: %w", err)
+	}
+	return AuditReplace, fmt.Sprintf("was v%d", prev), nil
+}
+
+// insertDemo is InsertDemo within an open transaction. Source fields left
+// empty in summary keep the values already stored for the hash, so a re-parse
+// from a temp download does not lose the path or URL recorded earlier.
+func insertDemo(tx *sql.Tx, summary model.MatchSummary, quickHash string) error {
 	var qh interface{}
 	if quickHash != "" {
 		qh = quickHash
 	}
-	_, err := db.conn.Exec(`
-		INSERT OR REPLACE INTO demos(hash, map_name, match_date, match_type, tickrate, ct_score, t_score, tier, is_baseline, event_id, quick_hash)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+	old, err := storedDemoSource(tx, summary.DemoHash)
+	if err != nil {
+		return err
+	}
+	src := summary.Source.Or(old)
+	_, err = tx.Exec(`
+		INSERT OR REPLACE INTO demos(hash, map_name, match_date, match_type, tickrate, ct_score, t_score, tier, is_baseline, event_id, quick_hash, pipeline_version,
+			source_origin, source_path, source_url, share_code, external_match_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		summary.DemoHash, normalizeMapName(summary.MapName), summary.MatchDate, summary.MatchType,
 		summary.Tickrate, summary.CTScore, summary.TScore,
-		summary.Tier, boolInt(summary.IsBaseline), summary.EventID, qh,
+		summary.Tier, boolInt(summary.IsBaseline), summary.EventID, qh, summary.PipelineVersion,
+		src.Origin, src.Path, src.URL, src.ShareCode, src.MatchID,
 	)
 	return err
 }
 
+// storedDemoSource returns the provenance stored for hash; zero when the demo
+// is not stored.
+func storedDemoSource(tx *sql.Tx, hash string) (model.DemoSource, error) {
+	var s model.DemoSource
+	err := tx.QueryRow(`
+		SELECT source_origin, source_path, source_url, share_code, external_match_id FROM demos WHERE hash = ?`, hash).
+		Scan(&s.Origin, &s.Path, &s.URL, &s.ShareCode, &s.MatchID)
+	if err == sql.ErrNoRows {
+		return model.DemoSource{}, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("look up demo source: %w", err)
+	}
+	return s, nil
+}
+
+// UpdateDemoSource fills in the provenance of an already-stored demo. Empty
+// fields of src keep the stored values; a demo that is not stored is a no-op.
+// Used when a parse is skipped because the demo is already in the database.
+func (db *DB) UpdateDemoSource(hash string, src model.DemoSource) error {
+	if src.IsZero() {
+		return nil
+	}
+	return db.withTx(func(tx *sql.Tx) error {
+		old, err := storedDemoSource(tx, hash)
+		if err != nil {
+			return err
+		}
+		src = src.Or(old)
+		_, err = tx.Exec(`
+			UPDATE demos SET source_origin=?, source_path=?, source_url=?, share_code=?, external_match_id=?
+			WHERE hash=?`,
+			src.Origin, src.Path, src.URL, src.ShareCode, src.MatchID, hash)
+		return err
+	})
+}
+
+// DemoData is everything stored for one parsed demo: the demos row and the
+// rows of every per-demo stats table.
+type DemoData struct {
+	Summary       model.MatchSummary
+	QuickHash     string // SHA-256 of the first 64 KB; empty stores NULL
+	MatchStats    []model.PlayerMatchStats
+	RoundStats    []model.PlayerRoundStats
+	WeaponStats   []model.PlayerWeaponStats
+	DuelSegments  []model.PlayerDuelSegment
+	DeathSegments []model.PlayerDeathSegment
+	DeathContexts []model.PlayerDeathContext
+	TimeToDamage  []model.PlayerTimeToDamage
+	BurstStats    []model.PlayerBurstStats
+	DuelDistances []model.PlayerDuelDistance
+	Positions     []model.PlayerPositionStats
+	FirstSights   []model.RawFirstSight // tracked players only
+	KillStates    []model.KillState
+	TeamEconomy   []model.TeamRoundEconomy
+	Timeline      []model.TimelineEvent
+	TradeChains   []model.TradeChain
+	Unmapped      []model.UnmappedWeapon
+	Diagnostics   model.DemoDiagnostics // skipped when DemoHash is empty
+}
+
+// ReplaceDemo stores d in a single transaction, first deleting any rows
+// already stored for the same hash (the demos row and every child table).
+// Re-parsing a demo therefore either fully replaces the old results or leaves
+// them untouched; stats rows for players or rounds that no longer appear are
+// removed rather than left behind. The write is audited as an insert or, when
+// the hash was already stored, a replace.
+func (db *DB) ReplaceDemo(d DemoData) error {
+	hash := d.Summary.DemoHash
+	return db.withTx(func(tx *sql.Tx) error {
+		action, detail, err := demoWriteAction(tx, hash)
+		if err != nil {
+			return err
+		}
+		old, err := storedDemoSource(tx, hash)
+		if err != nil {
+			return err
+		}
+		summary := d.Summary
+		summary.Source = summary.Source.Or(old)
+		for _, table := range childTables {
+			if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE demo_hash = ?", table), hash); err != nil {
+				return fmt.Errorf("delete %s: %w", table, err)
+			}
+		}
+		if _, err := tx.Exec("DELETE FROM demos WHERE hash = ?", hash); err != nil {
+			return fmt.Errorf("delete demo: %w", err)
+		}
+		if err := insertDemo(tx, summary, d.QuickHash); err != nil {
+			return fmt.Errorf("insert demo: %w", err)
+		}
+		if err := insertPlayerMatchStats(tx, d.MatchStats); err != nil {
+			return fmt.Errorf("insert player stats: %w", err)
+		}
+		if err := insertPlayerRoundStats(tx, d.RoundStats); err != nil {
+			return fmt.Errorf("insert round stats: %w", err)
+		}
+		if err := insertPlayerWeaponStats(tx, d.WeaponStats); err != nil {
+			return fmt.Errorf("insert weapon stats: %w", err)
+		}
+		if err := insertPlayerDuelSegments(tx, d.DuelSegments); err != nil {
+			return fmt.Errorf("insert duel segments: %w", err)
+		}
+		if err := insertPlayerDeathSegments(tx, d.DeathSegments); err != nil {
+			return fmt.Errorf("insert death segments: %w", err)
+		}
+		if err := insertPlayerDeathContexts(tx, d.DeathContexts); err != nil {
+			return fmt.Errorf("insert death contexts: %w", err)
+		}
+		if err := insertPlayerTimeToDamage(tx, d.TimeToDamage); err != nil {
+			return fmt.Errorf("insert time to damage: %w", err)
+		}
+		if err := insertPlayerBurstStats(tx, d.BurstStats); err != nil {
+			return fmt.Errorf("insert burst stats: %w", err)
+		}
+		if err := insertPlayerDuelDistances(tx, d.DuelDistances); err != nil {
+			return fmt.Errorf("insert duel distances: %w", err)
+		}
+		if err := insertPlayerPositions(tx, d.Positions); err != nil {
+			return fmt.Errorf("insert positions: %w", err)
+		}
+		if err := insertFirstSights(tx, hash, d.FirstSights); err != nil {
+			return fmt.Errorf("insert first sights: %w", err)
+		}
+		if err := insertKillStates(tx, d.KillStates); err != nil {
+			return fmt.Errorf("insert kill states: %w", err)
+		}
+		if err := insertTeamRoundEconomy(tx, d.TeamEconomy); err != nil {
+			return fmt.Errorf("insert team economy: %w", err)
+		}
+		if err := insertRoundEvents(tx, d.Timeline); err != nil {
+			return fmt.Errorf("insert round events: %w", err)
+		}
+		if err := insertTradeChains(tx, d.TradeChains); err != nil {
+			return fmt.Errorf("insert trade chains: %w", err)
+		}
+		if err := insertUnmappedWeapons(tx, d.Unmapped); err != nil {
+			return fmt.Errorf("insert unmapped weapons: %w", err)
+		}
+		if err := insertDemoDiagnostics(tx, d.Diagnostics); err != nil {
+			return fmt.Errorf("insert diagnostics: %w", err)
+		}
+		return db.audit(tx, action, hash, d.Summary.PipelineVersion, detail)
+	})
+}
+
+// DemoPipelineVersion returns the pipeline version a stored demo was produced
+// by: the older of its demos stamp and its player_match_stats stamps, as in
+// ListOutdatedDemos. found is false if the hash is not stored.
+func (db *DB) DemoPipelineVersion(hash string) (version int, found bool, err error) {
+	err = db.conn.QueryRow(`
+		SELECT MIN(d.pipeline_version, COALESCE(MIN(p.pipeline_version), d.pipeline_version))
+		FROM demos d
+		LEFT JOIN player_match_stats p ON p.demo_hash = d.hash
+		WHERE d.hash = ?
+		GROUP BY d.hash`, hash).Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return version, true, nil
+}
+
 // normalizeMapName converts a CS2 map identifier to the title-case name used
 // throughout the pipeline (e.g. "de_mirage" → "Mirage", "de_dust2" → "Dust2").
 // The function is idempotent: already-normalized names are returned unchanged.
@@ -87,12 +350,11 @@ func normalizeMapName(name string) string {
 
 // InsertPlayerMatchStats bulk-inserts player match stats in a transaction.
 func (db *DB) InsertPlayerMatchStats(stats []model.PlayerMatchStats) error {
-	tx, err := db.conn.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
+	return db.withTx(func(tx *sql.Tx) error { return insertPlayerMatchStats(tx, stats) })
+}
 
+// insertPlayerMatchStats is InsertPlayerMatchStats within an open transaction.
+func insertPlayerMatchStats(tx *sql.Tx, stats []model.PlayerMatchStats) error {
 	stmt, err := tx.Prepare(`
 		INSERT OR REPLACE INTO player_match_stats(
 			demo_hash, steam_id, name, team,
@@ -109,8 +371,33 @@ func (db *DB) InsertPlayerMatchStats(stats []model.PlayerMatchStats) error {
 			awp_deaths, awp_deaths_dry, awp_deaths_repeek, awp_deaths_isolated,
 			effective_flashes,
 			role, median_ttk_ms, median_ttd_ms, one_tap_kills, counter_strafe_pct,
-			rounds_won, median_trade_kill_delay_ms, median_trade_death_delay_ms
-		) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)`)
+			rounds_won, median_trade_kill_delay_ms, median_trade_death_delay_ms,
+			pipeline_version, low_hp_handed, low_hp_wasted,
+			median_spotted_before_death_ms,
+			defuses, ninja_defuses, plant_denials,
+			death_speed_samples, moving_deaths,
+			collateral_kills, spray_transfer_kills,
+			team_conflict_rounds,
+			time_to_damage_samples, median_time_to_damage_ms,
+			awp_shots, awp_shot_kills, awp_shot_body_hits,
+			awp_rounds_faced,
+			utility_damage_taken, flashes_received, blind_time_received_sec,
+			burst_taps, burst_short, burst_spray, burst_panic,
+			late_round_deaths, play_for_time_rounds, play_for_time_deaths,
+			longest_win_streak, streak_rounds, streak_kills, streak_damage, bounce_rounds, bounce_kills, bounce_damage, bounce_wins, half_first_kills,
+			played_off_flash_kills, played_off_smoke_kills, played_off_utility_kills,
+			leading_rounds, leading_kills, leading_deaths, leading_damage, tied_rounds, tied_kills, tied_deaths, tied_damage, trailing_rounds, trailing_kills, trailing_deaths, trailing_damage,
+			equipment_value, peek_duels, peek_wins, hold_duels, hold_wins,
+			assisted_duel_wins, assisted_duel_losses,
+			primary_deaths, wrong_weapon_deaths,
+			loss_flashed, loss_isolated, loss_util_support, loss_repeek, loss_explained,
+			bullet_hits, head_hits,
+			util_throws_early, util_throws_mid, util_throws_late, median_util_throw_sec,
+			eco_rounds, eco_damage, buy_rounds, buy_damage,
+			unspotted_deaths, spotted_deaths,
+			ping_samples, avg_ping_ms, max_ping_ms,
+			kast_strict_rounds
+		) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)`)
 	if err != nil {
 		return err
 	}
@@ -133,30 +420,56 @@ func (db *DB) InsertPlayerMatchStats(stats []model.PlayerMatchStats) error {
 			s.EffectiveFlashes,
 			s.Role, s.MedianTTKMs, s.MedianTTDMs, s.OneTapKills, s.CounterStrafePercent,
 			s.RoundsWon, s.MedianTradeKillDelayMs, s.MedianTradeDeathDelayMs,
+			s.PipelineVersion, s.LowHPHanded, s.LowHPWasted,
+			s.MedianSpottedBeforeDeathMs,
+			s.Defuses, s.NinjaDefuses, s.PlantDenials,
+			s.DeathSpeedSamples, s.MovingDeaths,
+			s.CollateralKills, s.SprayTransferKills,
+			s.TeamConflictRounds,
+			s.TimeToDamageSamples, s.MedianTimeToDamageMs,
+			s.AWPShots, s.AWPShotKills, s.AWPShotBodyHits,
+			s.AWPRoundsFaced,
+			s.UtilityDamageTaken, s.FlashesReceived, s.BlindTimeReceivedSec,
+			s.BurstTaps, s.BurstShort, s.BurstSpray, s.BurstPanic,
+			s.LateRoundDeaths, s.PlayForTimeRounds, s.PlayForTimeDeaths,
+			s.LongestWinStreak, s.StreakRounds, s.StreakKills, s.StreakDamage, s.BounceRounds, s.BounceKills, s.BounceDamage, s.BounceWins, s.HalfFirstKills,
+			s.PlayedOffFlashKills, s.PlayedOffSmokeKills, s.PlayedOffUtilityKills,
+			s.Leading.Rounds, s.Leading.Kills, s.Leading.Deaths, s.Leading.Damage, s.Tied.Rounds, s.Tied.Kills, s.Tied.Deaths, s.Tied.Damage, s.Trailing.Rounds, s.Trailing.Kills, s.Trailing.Deaths, s.Trailing.Damage,
+			s.EquipmentValue, s.PeekDuels, s.PeekWins, s.HoldDuels, s.HoldWins,
+			s.AssistedDuelWins, s.AssistedDuelLosses,
+			s.PrimaryDeaths, s.WrongWeaponDeaths,
+			s.LossFlashed, s.LossIsolated, s.LossUtilSupport, s.LossRePeek, s.LossExplained,
+			s.BulletHits, s.HeadHits,
+			s.UtilThrowsEarly, s.UtilThrowsMid, s.UtilThrowsLate, s.MedianUtilThrowSec,
+			s.EcoRounds, s.EcoDamage, s.BuyRounds, s.BuyDamage,
+			s.UnspottedDeaths, s.SpottedDeaths,
+			s.PingSamples, s.AvgPingMs, s.MaxPingMs,
+			s.KASTStrictRounds,
 		)
 		if err != nil {
 			return fmt.Errorf("insert player_match_stats for %d: %w", s.SteamID, err)
 		}
 	}
-	return tx.Commit()
+	return nil
 }
 
 // InsertPlayerRoundStats bulk-inserts per-round stats in a transaction.
 func (db *DB) InsertPlayerRoundStats(stats []model.PlayerRoundStats) error {
-	tx, err := db.conn.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
+	return db.withTx(func(tx *sql.Tx) error { return insertPlayerRoundStats(tx, stats) })
+}
 
+// insertPlayerRoundStats is InsertPlayerRoundStats within an open transaction.
+func insertPlayerRoundStats(tx *sql.Tx, stats []model.PlayerRoundStats) error {
 	stmt, err := tx.Prepare(`
 		INSERT OR REPLACE INTO player_round_stats(
 			demo_hash, steam_id, round_number, team,
 			got_kill, got_assist, survived, was_traded, kast_earned,
 			is_opening_kill, is_opening_death, is_trade_kill, is_trade_death,
 			kills, assists, damage, unused_utility, buy_type,
-			is_post_plant, is_in_clutch, clutch_enemy_count, won_round
-		) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)`)
+			is_post_plant, is_in_clutch, clutch_enemy_count, won_round, end_reason,
+			deaths, death_tick, death_sec,
+			clutch_start_tick, clutch_start_sec, clutch_enemies
+		) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)`)
 	if err != nil {
 		return err
 	}
@@ -171,31 +484,90 @@ func (db *DB) InsertPlayerRoundStats(stats []model.PlayerRoundStats) error {
 			boolInt(s.IsTradeKill), boolInt(s.IsTradeDeath),
 			s.Kills, s.Assists, s.Damage, s.UnusedUtility, s.BuyType,
 			boolInt(s.IsPostPlant), boolInt(s.IsInClutch), s.ClutchEnemyCount,
-			boolInt(s.WonRound),
+			boolInt(s.WonRound), s.EndReason,
+			s.Deaths, s.DeathTick, s.DeathSec,
+			s.ClutchStartTick, s.ClutchStartSec, joinSteamIDs(s.ClutchEnemies),
 		)
 		if err != nil {
 			return fmt.Errorf("insert player_round_stats: %w", err)
 		}
 	}
-	return tx.Commit()
+	return nil
 }
 
-// ListDemos returns all stored match summaries ordered by match_date desc.
-func (db *DB) ListDemos() ([]model.MatchSummary, error) {
-	rows, err := db.conn.Query(`
-		SELECT hash, map_name, match_date, match_type, tickrate, ct_score, t_score, tier, is_baseline, event_id
-		FROM demos ORDER BY match_date DESC`)
-	if err != nil {
-		return nil, err
+// DemoFilter narrows ListDemos, ListOutdatedDemos and GetWeaponMeta. Zero fields match every
+// demo; Limit 0 means no limit.
+type DemoFilter struct {
+	Map      string // map name, matched without the de_ prefix and case-insensitively
+	Since    string // YYYY-MM-DD; demos on or after this date
+	Type     string // match type, case-insensitive (e.g. "scrim")
+	Tier     string // exact demos.tier value
+	Baseline bool   // only baseline (reference corpus) demos
+	SteamID  uint64 // only demos with a player_match_stats row for this player
+	Limit    int
+	Offset   int
+}
+
+// where returns the SQL conditions (each prefixed with " AND ") and their
+// arguments for f, over the demos table aliased as d.
+func (f DemoFilter) where() (string, []any) {
+	conds := ""
+	var args []any
+	if f.Map != "" {
+		conds += " AND LOWER(REPLACE(d.map_name, 'de_', '')) = ?"
+		args = append(args, strings.TrimPrefix(strings.ToLower(f.Map), "de_"))
 	}
-	defer rows.Close()
+	if f.Since != "" {
+		conds += " AND d.match_date >= ?"
+		args = append(args, f.Since)
+	}
+	if f.Type != "" {
+		conds += " AND LOWER(d.match_type) = LOWER(?)"
+		args = append(args, f.Type)
+	}
+	if f.Tier != "" {
+		conds += " AND d.tier = ?"
+		args = append(args, f.Tier)
+	}
+	if f.Baseline {
+		conds += " AND d.is_baseline = 1"
+	}
+	if f.SteamID != 0 {
+		conds += " AND EXISTS (SELECT 1 FROM player_match_stats ps WHERE ps.demo_hash = d.hash AND ps.steam_id = ?)"
+		args = append(args, strconv.FormatUint(f.SteamID, 10))
+	}
+	return conds, args
+}
+
+// page returns the LIMIT/OFFSET clause for f and its arguments. SQLite needs a
+// LIMIT for OFFSET, so an offset without a limit uses LIMIT -1 (no limit).
+func (f DemoFilter) page() (string, []any) {
+	if f.Limit <= 0 && f.Offset <= 0 {
+		return "", nil
+	}
+	limit := f.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	return " LIMIT ? OFFSET ?", []any{limit, f.Offset}
+}
 
+// demoSourceCols are the provenance columns of demos (alias d), in
+// model.DemoSource field order.
+const demoSourceCols = "d.source_origin, d.source_path, d.source_url, d.share_code, d.external_match_id"
+
+// scanDemoSummaries reads rows of hash, map_name, match_date, match_type,
+// tickrate, ct_score, t_score, tier, is_baseline, event_id, pipeline_version
+// followed by the demoSourceCols.
+func scanDemoSummaries(rows *sql.Rows) ([]model.MatchSummary, error) {
+	defer rows.Close()
 	var out []model.MatchSummary
 	for rows.Next() {
 		var s model.MatchSummary
 		var isBaselineInt int
 		if err := rows.Scan(&s.DemoHash, &s.MapName, &s.MatchDate, &s.MatchType,
-			&s.Tickrate, &s.CTScore, &s.TScore, &s.Tier, &isBaselineInt, &s.EventID); err != nil {
+			&s.Tickrate, &s.CTScore, &s.TScore, &s.Tier, &isBaselineInt, &s.EventID, &s.PipelineVersion,
+			&s.Source.Origin, &s.Source.Path, &s.Source.URL, &s.Source.ShareCode, &s.Source.MatchID); err != nil {
 			return nil, err
 		}
 		s.IsBaseline = isBaselineInt != 0
@@ -204,15 +576,60 @@ func (db *DB) ListDemos() ([]model.MatchSummary, error) {
 	return out, rows.Err()
 }
 
+// ListDemos returns the stored match summaries that pass f, ordered by
+// match_date desc and paged by f.Limit/f.Offset.
+func (db *DB) ListDemos(f DemoFilter) ([]model.MatchSummary, error) {
+	conds, args := f.where()
+	page, pageArgs := f.page()
+	rows, err := db.conn.Query(`
+		SELECT d.hash, d.map_name, d.match_date, d.match_type, d.tickrate, d.ct_score, d.t_score,
+		       d.tier, d.is_baseline, d.event_id, d.pipeline_version, `+demoSourceCols+`
+		FROM demos d
+		WHERE 1=1`+conds+`
+		ORDER BY d.match_date DESC, d.hash`+page, append(args, pageArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	return scanDemoSummaries(rows)
+}
+
+// ListOutdatedDemos returns demos passing f whose stats were produced by a
+// pipeline version older than current, ordered by match_date desc. A demo
+// counts as outdated if either its demos row or any of its player_match_stats
+// rows carries an older version; PipelineVersion on the result is the oldest
+// of those stamps.
+func (db *DB) ListOutdatedDemos(current int, f DemoFilter) ([]model.MatchSummary, error) {
+	conds, args := f.where()
+	page, pageArgs := f.page()
+	args = append(args, current)
+	rows, err := db.conn.Query(`
+		SELECT d.hash, d.map_name, d.match_date, d.match_type, d.tickrate, d.ct_score, d.t_score,
+		       d.tier, d.is_baseline, d.event_id,
+		       MIN(d.pipeline_version, COALESCE(MIN(p.pipeline_version), d.pipeline_version)) AS ver,
+		       `+demoSourceCols+`
+		FROM demos d
+		LEFT JOIN player_match_stats p ON p.demo_hash = d.hash
+		WHERE 1=1`+conds+`
+		GROUP BY d.hash
+		HAVING ver < ?
+		ORDER BY d.match_date DESC, d.hash`+page, append(args, pageArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	return scanDemoSummaries(rows)
+}
+
 // GetDemoByPrefix finds the first demo whose hash starts with the given prefix.
 func (db *DB) GetDemoByPrefix(prefix string) (*model.MatchSummary, error) {
 	var s model.MatchSummary
 	var isBaselineInt int
 	err := db.conn.QueryRow(`
-		SELECT hash, map_name, match_date, match_type, tickrate, ct_score, t_score, tier, is_baseline, event_id
-		FROM demos WHERE hash LIKE ? LIMIT 1`, prefix+"%").
+		SELECT d.hash, d.map_name, d.match_date, d.match_type, d.tickrate, d.ct_score, d.t_score, d.tier, d.is_baseline, d.event_id, d.pipeline_version,
+		       `+demoSourceCols+`
+		FROM demos d WHERE d.hash LIKE ? LIMIT 1`, prefix+"%").
 		Scan(&s.DemoHash, &s.MapName, &s.MatchDate, &s.MatchType,
-			&s.Tickrate, &s.CTScore, &s.TScore, &s.Tier, &isBaselineInt, &s.EventID)
+			&s.Tickrate, &s.CTScore, &s.TScore, &s.Tier, &isBaselineInt, &s.EventID, &s.PipelineVersion,
+			&s.Source.Origin, &s.Source.Path, &s.Source.URL, &s.Source.ShareCode, &s.Source.MatchID)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -239,7 +656,32 @@ func (db *DB) GetPlayerMatchStats(demoHash string) ([]model.PlayerMatchStats, er
 		       median_correction_deg, pct_correction_under2_deg,
 		       awp_deaths, awp_deaths_dry, awp_deaths_repeek, awp_deaths_isolated,
 		       effective_flashes,
-		       role, median_ttk_ms, median_ttd_ms, one_tap_kills, counter_strafe_pct
+		       role, median_ttk_ms, median_ttd_ms, one_tap_kills, counter_strafe_pct,
+		       pipeline_version, low_hp_handed, low_hp_wasted,
+		       median_spotted_before_death_ms,
+		       defuses, ninja_defuses, plant_denials,
+		       death_speed_samples, moving_deaths,
+		       collateral_kills, spray_transfer_kills,
+		       team_conflict_rounds,
+		       time_to_damage_samples, median_time_to_damage_ms,
+		       awp_shots, awp_shot_kills, awp_shot_body_hits,
+		       awp_rounds_faced,
+		       utility_damage_taken, flashes_received, blind_time_received_sec,
+		       burst_taps, burst_short, burst_spray, burst_panic,
+		       late_round_deaths, play_for_time_rounds, play_for_time_deaths,
+		       longest_win_streak, streak_rounds, streak_kills, streak_damage, bounce_rounds, bounce_kills, bounce_damage, bounce_wins, half_first_kills,
+		       played_off_flash_kills, played_off_smoke_kills, played_off_utility_kills,
+		       leading_rounds, leading_kills, leading_deaths, leading_damage, tied_rounds, tied_kills, tied_deaths, tied_damage, trailing_rounds, trailing_kills, trailing_deaths, trailing_damage,
+		       equipment_value, peek_duels, peek_wins, hold_duels, hold_wins,
+		       assisted_duel_wins, assisted_duel_losses,
+		       primary_deaths, wrong_weapon_deaths,
+		       loss_flashed, loss_isolated, loss_util_support, loss_repeek, loss_explained,
+		       bullet_hits, head_hits,
+		       util_throws_early, util_throws_mid, util_throws_late, median_util_throw_sec,
+		       eco_rounds, eco_damage, buy_rounds, buy_damage,
+		       unspotted_deaths, spotted_deaths,
+		       ping_samples, avg_ping_ms, max_ping_ms,
+		       kast_strict_rounds
 		FROM player_match_stats WHERE demo_hash = ?
 		ORDER BY kills DESC`, demoHash)
 	if err != nil {
@@ -266,6 +708,31 @@ func (db *DB) GetPlayerMatchStats(demoHash string) ([]model.PlayerMatchStats, er
 			&s.AWPDeaths, &s.AWPDeathsDry, &s.AWPDeathsRePeek, &s.AWPDeathsIsolated,
 			&s.EffectiveFlashes,
 			&s.Role, &s.MedianTTKMs, &s.MedianTTDMs, &s.OneTapKills, &s.CounterStrafePercent,
+			&s.PipelineVersion, &s.LowHPHanded, &s.LowHPWasted,
+			&s.MedianSpottedBeforeDeathMs,
+			&s.Defuses, &s.NinjaDefuses, &s.PlantDenials,
+			&s.DeathSpeedSamples, &s.MovingDeaths,
+			&s.CollateralKills, &s.SprayTransferKills,
+			&s.TeamConflictRounds,
+			&s.TimeToDamageSamples, &s.MedianTimeToDamageMs,
+			&s.AWPShots, &s.AWPShotKills, &s.AWPShotBodyHits,
+			&s.AWPRoundsFaced,
+			&s.UtilityDamageTaken, &s.FlashesReceived, &s.BlindTimeReceivedSec,
+			&s.BurstTaps, &s.BurstShort, &s.BurstSpray, &s.BurstPanic,
+			&s.LateRoundDeaths, &s.PlayForTimeRounds, &s.PlayForTimeDeaths,
+			&s.LongestWinStreak, &s.StreakRounds, &s.StreakKills, &s.StreakDamage, &s.BounceRounds, &s.BounceKills, &s.BounceDamage, &s.BounceWins, &s.HalfFirstKills,
+			&s.PlayedOffFlashKills, &s.PlayedOffSmokeKills, &s.PlayedOffUtilityKills,
+			&s.Leading.Rounds, &s.Leading.Kills, &s.Leading.Deaths, &s.Leading.Damage, &s.Tied.Rounds, &s.Tied.Kills, &s.Tied.Deaths, &s.Tied.Damage, &s.Trailing.Rounds, &s.Trailing.Kills, &s.Trailing.Deaths, &s.Trailing.Damage,
+			&s.EquipmentValue, &s.PeekDuels, &s.PeekWins, &s.HoldDuels, &s.HoldWins,
+			&s.AssistedDuelWins, &s.AssistedDuelLosses,
+			&s.PrimaryDeaths, &s.WrongWeaponDeaths,
+			&s.LossFlashed, &s.LossIsolated, &s.LossUtilSupport, &s.LossRePeek, &s.LossExplained,
+			&s.BulletHits, &s.HeadHits,
+			&s.UtilThrowsEarly, &s.UtilThrowsMid, &s.UtilThrowsLate, &s.MedianUtilThrowSec,
+			&s.EcoRounds, &s.EcoDamage, &s.BuyRounds, &s.BuyDamage,
+			&s.UnspottedDeaths, &s.SpottedDeaths,
+			&s.PingSamples, &s.AvgPingMs, &s.MaxPingMs,
+			&s.KASTStrictRounds,
 		); err != nil {
 			return nil, err
 		}
@@ -319,20 +786,86 @@ func (db *DB) GetPlayerSideStats(demoHash string) ([]model.PlayerSideStats, erro
 	return out, rows.Err()
 }
 
+// roundStatsColumns is the player_round_stats column list read by
+// scanPlayerRoundStats, in scan order.
+const roundStatsColumns = `steam_id, round_number, team,
+		       got_kill, got_assist, survived, was_traded, kast_earned,
+		       is_opening_kill, is_opening_death, is_trade_kill, is_trade_death,
+		       kills, assists, damage, unused_utility, buy_type,
+		       is_post_plant, is_in_clutch, clutch_enemy_count, won_round, end_reason,
+		       deaths, death_tick, death_sec,
+		       clutch_start_tick, clutch_start_sec, clutch_enemies`
+
+// scanPlayerRoundStats scans one row selected with roundStatsColumns.
+func scanPlayerRoundStats(rows *sql.Rows, demoHash string) (model.PlayerRoundStats, error) {
+	var s model.PlayerRoundStats
+	var steamIDStr, teamStr string
+	var gotKill, gotAssist, survived, wasTraded, kastEarned int
+	var isOpeningKill, isOpeningDeath, isTradeKill, isTradeDeath int
+	var isPostPlant, isInClutch, wonRound int
+	var clutchEnemies string
+	if err := rows.Scan(
+		&steamIDStr, &s.RoundNumber, &teamStr,
+		&gotKill, &gotAssist, &survived, &wasTraded, &kastEarned,
+		&isOpeningKill, &isOpeningDeath, &isTradeKill, &isTradeDeath,
+		&s.Kills, &s.Assists, &s.Damage, &s.UnusedUtility, &s.BuyType,
+		&isPostPlant, &isInClutch, &s.ClutchEnemyCount, &wonRound, &s.EndReason,
+		&s.Deaths, &s.DeathTick, &s.DeathSec,
+		&s.ClutchStartTick, &s.ClutchStartSec, &clutchEnemies,
+	); err != nil {
+		return s, err
+	}
+	s.DemoHash = demoHash
+	s.SteamID, _ = strconv.ParseUint(steamIDStr, 10, 64)
+	s.Team = parseTeam(teamStr)
+	s.GotKill = gotKill != 0
+	s.GotAssist = gotAssist != 0
+	s.Survived = survived != 0
+	s.WasTraded = wasTraded != 0
+	s.KASTEarned = kastEarned != 0
+	s.IsOpeningKill = isOpeningKill != 0
+	s.IsOpeningDeath = isOpeningDeath != 0
+	s.IsTradeKill = isTradeKill != 0
+	s.IsTradeDeath = isTradeDeath != 0
+	s.IsPostPlant = isPostPlant != 0
+	s.IsInClutch = isInClutch != 0
+	s.WonRound = wonRound != 0
+	s.ClutchEnemies = splitSteamIDs(clutchEnemies)
+	return s, nil
+}
+
+// joinSteamIDs encodes SteamID64s as a comma-separated TEXT value.
+func joinSteamIDs(ids []uint64) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatUint(id, 10)
+	}
+	return strings.Join(parts, ",")
+}
+
+// splitSteamIDs decodes a joinSteamIDs value; empty yields nil.
+func splitSteamIDs(s string) []uint64 {
+	if s == "" {
+		return nil
+	}
+	var out []uint64
+	for _, part := range strings.Split(s, ",") {
+		if id, err := strconv.ParseUint(part, 10, 64); err == nil {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
 // GetPlayerRoundStats returns per-round stats for a single player in a single demo,
 // ordered by round number ascending.
 func (db *DB) GetPlayerRoundStats(demoHash string, steamID uint64) ([]model.PlayerRoundStats, error) {
-	steamIDStr := strconv.FormatUint(steamID, 10)
 	rows, err := db.conn.Query(`
-		SELECT round_number, team,
-		       got_kill, got_assist, survived, was_traded, kast_earned,
-		       is_opening_kill, is_opening_death, is_trade_kill, is_trade_death,
-		       kills, assists, damage, unused_utility, buy_type,
-		       is_post_plant, is_in_clutch, clutch_enemy_count, won_round
+		SELECT `+roundStatsColumns+`
 		FROM player_round_stats
 		WHERE demo_hash = ? AND steam_id = ?
 		ORDER BY round_number ASC`,
-		demoHash, steamIDStr)
+		demoHash, strconv.FormatUint(steamID, 10))
 	if err != nil {
 		return nil, err
 	}
@@ -340,53 +873,158 @@ func (db *DB) GetPlayerRoundStats(demoHash string, steamID uint64) ([]model.Play
 
 	var out []model.PlayerRoundStats
 	for rows.Next() {
-		var s model.PlayerRoundStats
-		var teamStr string
-		var gotKill, gotAssist, survived, wasTraded, kastEarned int
-		var isOpeningKill, isOpeningDeath, isTradeKill, isTradeDeath int
-		var isPostPlant, isInClutch, wonRound int
-		if err := rows.Scan(
-			&s.RoundNumber, &teamStr,
-			&gotKill, &gotAssist, &survived, &wasTraded, &kastEarned,
-			&isOpeningKill, &isOpeningDeath, &isTradeKill, &isTradeDeath,
-			&s.Kills, &s.Assists, &s.Damage, &s.UnusedUtility, &s.BuyType,
-			&isPostPlant, &isInClutch, &s.ClutchEnemyCount, &wonRound,
-		); err != nil {
+		s, err := scanPlayerRoundStats(rows, demoHash)
+		if err != nil {
 			return nil, err
 		}
-		s.DemoHash = demoHash
-		s.SteamID = steamID
-		s.Team = parseTeam(teamStr)
-		s.GotKill = gotKill != 0
-		s.GotAssist = gotAssist != 0
-		s.Survived = survived != 0
-		s.WasTraded = wasTraded != 0
-		s.KASTEarned = kastEarned != 0
-		s.IsOpeningKill = isOpeningKill != 0
-		s.IsOpeningDeath = isOpeningDeath != 0
-		s.IsTradeKill = isTradeKill != 0
-		s.IsTradeDeath = isTradeDeath != 0
-		s.IsPostPlant = isPostPlant != 0
-		s.IsInClutch = isInClutch != 0
-		s.WonRound = wonRound != 0
 		out = append(out, s)
 	}
 	return out, rows.Err()
 }
 
-// InsertPlayerWeaponStats bulk-inserts per-weapon stats in a transaction.
-func (db *DB) InsertPlayerWeaponStats(stats []model.PlayerWeaponStats) error {
-	tx, err := db.conn.Begin()
+// GetAllRoundStats returns every player's per-round stats for a demo in one
+// query, keyed by round number. Each round's rows are ordered by team (CT
+// first) then SteamID, so team-level round analyses need no per-player
+// queries.
+func (db *DB) GetAllRoundStats(demoHash string) (map[int][]model.PlayerRoundStats, error) {
+	rows, err := db.conn.Query(`
+		SELECT `+roundStatsColumns+`
+		FROM player_round_stats
+		WHERE demo_hash = ?
+		ORDER BY round_number ASC, team ASC, steam_id ASC`,
+		demoHash)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[int][]model.PlayerRoundStats)
+	for rows.Next() {
+		s, err := scanPlayerRoundStats(rows, demoHash)
+		if err != nil {
+			return nil, err
+		}
+		out[s.RoundNumber] = append(out[s.RoundNumber], s)
+	}
+	return out, rows.Err()
+}
+
+// GetPlayerAWPByMap sums a player's AWP death classification per map over the
+// given demos, maps with the most AWP deaths first. No demos returns no rows.
+func (db *DB) GetPlayerAWPByMap(steamID uint64, demoHashes []string) ([]model.PlayerAWPMapStats, error) {
+	if len(demoHashes) == 0 {
+		return nil, nil
+	}
+	args := []any{strconv.FormatUint(steamID, 10)}
+	for _, h := range demoHashes {
+		args = append(args, h)
+	}
+	rows, err := db.conn.Query(`
+		SELECT d.map_name, MAX(p.name), COUNT(*),
+		       SUM(p.awp_deaths), SUM(p.awp_deaths_dry), SUM(p.awp_deaths_repeek),
+		       SUM(p.awp_deaths_isolated), SUM(p.awp_rounds_faced)
+		FROM player_match_stats p
+		JOIN demos d ON d.hash = p.demo_hash
+		WHERE p.steam_id = ? AND p.demo_hash IN (`+placeholders(len(demoHashes))+`)
+		GROUP BY d.map_name
+		ORDER BY SUM(p.awp_deaths) DESC, d.map_name ASC`,
+		args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.PlayerAWPMapStats
+	for rows.Next() {
+		r := model.PlayerAWPMapStats{SteamID: steamID}
+		if err := rows.Scan(&r.MapName, &r.Name, &r.Matches, &r.AWPDeaths, &r.AWPDeathsDry,
+			&r.AWPDeathsRePeek, &r.AWPDeathsIsolated, &r.AWPRoundsFaced); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// GetPlayerHalfStats returns a player's per-half totals for every demo in
+// which they played both regulation halves, ordered by demo hash then half.
+// Halves are split at the player's side switches; rounds after the second
+// switch (overtime) are ignored.
+func (db *DB) GetPlayerHalfStats(steamID uint64) ([]model.PlayerHalfStats, error) {
+	rows, err := db.conn.Query(`
+		SELECT demo_hash, team, kills, assists, survived, damage, kast_earned,
+		       is_opening_kill, is_opening_death
+		FROM player_round_stats
+		WHERE steam_id = ?
+		ORDER BY demo_hash ASC, round_number ASC`,
+		strconv.FormatUint(steamID, 10))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.PlayerHalfStats
+	var (
+		cur     [2]model.PlayerHalfStats
+		curHash string
+		curTeam string
+		curHalf int
+	)
+	flush := func() {
+		if curHash != "" && cur[1].RoundsPlayed > 0 {
+			out = append(out, cur[0], cur[1])
+		}
+	}
+	for rows.Next() {
+		var hash, team string
+		var kills, assists, survived, damage, kast, openK, openD int
+		if err := rows.Scan(&hash, &team, &kills, &assists, &survived, &damage, &kast, &openK, &openD); err != nil {
+			return nil, err
+		}
+		if hash != curHash {
+			flush()
+			curHash, curTeam, curHalf = hash, team, 1
+			for i := range cur {
+				cur[i] = model.PlayerHalfStats{DemoHash: hash, SteamID: steamID, Half: i + 1}
+			}
+		}
+		if team != curTeam {
+			curTeam = team
+			curHalf++
+		}
+		if curHalf > 2 {
+			continue
+		}
+		h := &cur[curHalf-1]
+		h.Kills += kills
+		h.Assists += assists
+		h.Deaths += 1 - survived
+		h.TotalDamage += damage
+		h.RoundsPlayed++
+		h.KASTRounds += kast
+		h.OpeningKills += openK
+		h.OpeningDeaths += openD
 	}
-	defer tx.Rollback()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return out, nil
+}
+
+// InsertPlayerWeaponStats bulk-inserts per-weapon stats in a transaction.
+func (db *DB) InsertPlayerWeaponStats(stats []model.PlayerWeaponStats) error {
+	return db.withTx(func(tx *sql.Tx) error { return insertPlayerWeaponStats(tx, stats) })
+}
 
+// insertPlayerWeaponStats is InsertPlayerWeaponStats within an open transaction.
+func insertPlayerWeaponStats(tx *sql.Tx, stats []model.PlayerWeaponStats) error {
 	stmt, err := tx.Prepare(`
 		INSERT OR REPLACE INTO player_weapon_stats(
 			demo_hash, steam_id, weapon,
-			kills, headshot_kills, assists, deaths, damage, hits
-		) VALUES (?,?,?,?,?,?,?,?,?)`)
+			kills, headshot_kills, assists, deaths, damage, hits, head_hits,
+			distance_kills, kill_distance_sum_m
+		) VALUES (?,?,?,?,?,?,?,?,?,?,?,?)`)
 	if err != nil {
 		return err
 	}
@@ -395,19 +1033,21 @@ func (db *DB) InsertPlayerWeaponStats(stats []model.PlayerWeaponStats) error {
 	for _, s := range stats {
 		_, err = stmt.Exec(
 			s.DemoHash, strconv.FormatUint(s.SteamID, 10), s.Weapon,
-			s.Kills, s.HeadshotKills, s.Assists, s.Deaths, s.Damage, s.Hits,
+			s.Kills, s.HeadshotKills, s.Assists, s.Deaths, s.Damage, s.Hits, s.HeadHits,
+			s.DistanceKills, s.KillDistanceSumM,
 		)
 		if err != nil {
 			return fmt.Errorf("insert player_weapon_stats for %d/%s: %w", s.SteamID, s.Weapon, err)
 		}
 	}
-	return tx.Commit()
+	return nil
 }
 
 // GetPlayerWeaponStats returns all weapon stats for a demo, ordered by kills DESC then damage DESC.
 func (db *DB) GetPlayerWeaponStats(demoHash string) ([]model.PlayerWeaponStats, error) {
 	rows, err := db.conn.Query(`
-		SELECT steam_id, weapon, kills, headshot_kills, assists, deaths, damage, hits
+		SELECT steam_id, weapon, kills, headshot_kills, assists, deaths, damage, hits, head_hits,
+		       distance_kills, kill_distance_sum_m
 		FROM player_weapon_stats WHERE demo_hash = ?
 		ORDER BY kills DESC, damage DESC`, demoHash)
 	if err != nil {
@@ -421,7 +1061,8 @@ func (db *DB) GetPlayerWeaponStats(demoHash string) ([]model.PlayerWeaponStats,
 		var steamIDStr string
 		if err := rows.Scan(
 			&steamIDStr, &s.Weapon,
-			&s.Kills, &s.HeadshotKills, &s.Assists, &s.Deaths, &s.Damage, &s.Hits,
+			&s.Kills, &s.HeadshotKills, &s.Assists, &s.Deaths, &s.Damage, &s.Hits, &s.HeadHits,
+			&s.DistanceKills, &s.KillDistanceSumM,
 		); err != nil {
 			return nil, err
 		}
@@ -432,6 +1073,37 @@ func (db *DB) GetPlayerWeaponStats(demoHash string) ([]model.PlayerWeaponStats,
 	return out, rows.Err()
 }
 
+// GetWeaponMeta returns player_weapon_stats summed per weapon and match date
+// over every player of the demos passing f (paging ignored), ordered by
+// weapon then date. Callers fold the dates into totals or time windows.
+func (db *DB) GetWeaponMeta(f DemoFilter) ([]model.WeaponMetaRow, error) {
+	conds, args := f.where()
+	rows, err := db.conn.Query(`
+		SELECT w.weapon, d.match_date, COUNT(DISTINCT w.demo_hash),
+		       SUM(w.kills), SUM(w.headshot_kills), SUM(w.damage), SUM(w.hits),
+		       SUM(w.distance_kills), SUM(w.kill_distance_sum_m)
+		FROM player_weapon_stats w
+		JOIN demos d ON d.hash = w.demo_hash
+		WHERE 1=1`+conds+`
+		GROUP BY w.weapon, d.match_date
+		ORDER BY w.weapon, d.match_date`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []model.WeaponMetaRow
+	for rows.Next() {
+		var r model.WeaponMetaRow
+		if err := rows.Scan(&r.Weapon, &r.MatchDate, &r.Demos,
+			&r.Kills, &r.HeadshotKills, &r.Damage, &r.Hits,
+			&r.DistanceKills, &r.KillDistanceSumM); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
 // GetAllPlayerMatchStats returns all stored match-stats rows for a given SteamID64 across all demos,
 // joined with the demos table to include map_name.
 func (db *DB) GetAllPlayerMatchStats(steamID uint64) ([]model.PlayerMatchStats, error) {
@@ -451,7 +1123,32 @@ func (db *DB) GetAllPlayerMatchStats(steamID uint64) ([]model.PlayerMatchStats,
 		       p.awp_deaths, p.awp_deaths_dry, p.awp_deaths_repeek, p.awp_deaths_isolated,
 		       p.effective_flashes,
 		       p.role, p.median_ttk_ms, p.median_ttd_ms, p.one_tap_kills, p.counter_strafe_pct,
-		       p.rounds_won, p.median_trade_kill_delay_ms, p.median_trade_death_delay_ms
+		       p.rounds_won, p.median_trade_kill_delay_ms, p.median_trade_death_delay_ms,
+		       p.low_hp_handed, p.low_hp_wasted,
+		       p.median_spotted_before_death_ms,
+		       p.defuses, p.ninja_defuses, p.plant_denials,
+		       p.death_speed_samples, p.moving_deaths,
+		       p.collateral_kills, p.spray_transfer_kills,
+		       p.team_conflict_rounds,
+		       p.time_to_damage_samples, p.median_time_to_damage_ms,
+		       p.awp_shots, p.awp_shot_kills, p.awp_shot_body_hits,
+		       p.awp_rounds_faced,
+		       p.utility_damage_taken, p.flashes_received, p.blind_time_received_sec,
+		       p.burst_taps, p.burst_short, p.burst_spray, p.burst_panic,
+		       p.late_round_deaths, p.play_for_time_rounds, p.play_for_time_deaths,
+		       p.longest_win_streak, p.streak_rounds, p.streak_kills, p.streak_damage, p.bounce_rounds, p.bounce_kills, p.bounce_damage, p.bounce_wins, p.half_first_kills,
+		       p.played_off_flash_kills, p.played_off_smoke_kills, p.played_off_utility_kills,
+		       p.leading_rounds, p.leading_kills, p.leading_deaths, p.leading_damage, p.tied_rounds, p.tied_kills, p.tied_deaths, p.tied_damage, p.trailing_rounds, p.trailing_kills, p.trailing_deaths, p.trailing_damage,
+		       p.equipment_value, p.peek_duels, p.peek_wins, p.hold_duels, p.hold_wins,
+		       p.assisted_duel_wins, p.assisted_duel_losses,
+		       p.primary_deaths, p.wrong_weapon_deaths,
+		       p.loss_flashed, p.loss_isolated, p.loss_util_support, p.loss_repeek, p.loss_explained,
+		       p.bullet_hits, p.head_hits,
+		       p.util_throws_early, p.util_throws_mid, p.util_throws_late, p.median_util_throw_sec,
+		       p.eco_rounds, p.eco_damage, p.buy_rounds, p.buy_damage,
+		       p.unspotted_deaths, p.spotted_deaths,
+		       p.ping_samples, p.avg_ping_ms, p.max_ping_ms,
+		       p.kast_strict_rounds
 		FROM player_match_stats p
 		JOIN demos d ON d.hash = p.demo_hash
 		WHERE p.steam_id = ?
@@ -481,6 +1178,31 @@ func (db *DB) GetAllPlayerMatchStats(steamID uint64) ([]model.PlayerMatchStats,
 			&s.EffectiveFlashes,
 			&s.Role, &s.MedianTTKMs, &s.MedianTTDMs, &s.OneTapKills, &s.CounterStrafePercent,
 			&s.RoundsWon, &s.MedianTradeKillDelayMs, &s.MedianTradeDeathDelayMs,
+			&s.LowHPHanded, &s.LowHPWasted,
+			&s.MedianSpottedBeforeDeathMs,
+			&s.Defuses, &s.NinjaDefuses, &s.PlantDenials,
+			&s.DeathSpeedSamples, &s.MovingDeaths,
+			&s.CollateralKills, &s.SprayTransferKills,
+			&s.TeamConflictRounds,
+			&s.TimeToDamageSamples, &s.MedianTimeToDamageMs,
+			&s.AWPShots, &s.AWPShotKills, &s.AWPShotBodyHits,
+			&s.AWPRoundsFaced,
+			&s.UtilityDamageTaken, &s.FlashesReceived, &s.BlindTimeReceivedSec,
+			&s.BurstTaps, &s.BurstShort, &s.BurstSpray, &s.BurstPanic,
+			&s.LateRoundDeaths, &s.PlayForTimeRounds, &s.PlayForTimeDeaths,
+			&s.LongestWinStreak, &s.StreakRounds, &s.StreakKills, &s.StreakDamage, &s.BounceRounds, &s.BounceKills, &s.BounceDamage, &s.BounceWins, &s.HalfFirstKills,
+			&s.PlayedOffFlashKills, &s.PlayedOffSmokeKills, &s.PlayedOffUtilityKills,
+			&s.Leading.Rounds, &s.Leading.Kills, &s.Leading.Deaths, &s.Leading.Damage, &s.Tied.Rounds, &s.Tied.Kills, &s.Tied.Deaths, &s.Tied.Damage, &s.Trailing.Rounds, &s.Trailing.Kills, &s.Trailing.Deaths, &s.Trailing.Damage,
+			&s.EquipmentValue, &s.PeekDuels, &s.PeekWins, &s.HoldDuels, &s.HoldWins,
+			&s.AssistedDuelWins, &s.AssistedDuelLosses,
+			&s.PrimaryDeaths, &s.WrongWeaponDeaths,
+			&s.LossFlashed, &s.LossIsolated, &s.LossUtilSupport, &s.LossRePeek, &s.LossExplained,
+			&s.BulletHits, &s.HeadHits,
+			&s.UtilThrowsEarly, &s.UtilThrowsMid, &s.UtilThrowsLate, &s.MedianUtilThrowSec,
+			&s.EcoRounds, &s.EcoDamage, &s.BuyRounds, &s.BuyDamage,
+			&s.UnspottedDeaths, &s.SpottedDeaths,
+			&s.PingSamples, &s.AvgPingMs, &s.MaxPingMs,
+			&s.KASTStrictRounds,
 		); err != nil {
 			return nil, err
 		}
@@ -495,9 +1217,10 @@ func (db *DB) GetAllPlayerMatchStats(steamID uint64) ([]model.PlayerMatchStats,
 func (db *DB) GetAllPlayerDuelSegments(steamID uint64) ([]model.PlayerDuelSegment, error) {
 	steamIDStr := strconv.FormatUint(steamID, 10)
 	rows, err := db.conn.Query(`
-		SELECT demo_hash, weapon_bucket, distance_bin,
+		SELECT demo_hash, round_context, weapon_bucket, distance_bin,
 		       duel_count, first_hit_count, first_hit_hs_count,
-		       median_corr_deg, median_sight_deg, median_expo_win_ms
+		       median_corr_deg, median_sight_deg, median_expo_win_ms,
+		       still_first_hits, still_first_hit_hs, moving_first_hits, moving_first_hit_hs
 		FROM player_duel_segments WHERE steam_id = ?`, steamIDStr)
 	if err != nil {
 		return nil, err
@@ -508,9 +1231,10 @@ func (db *DB) GetAllPlayerDuelSegments(steamID uint64) ([]model.PlayerDuelSegmen
 	for rows.Next() {
 		var s model.PlayerDuelSegment
 		if err := rows.Scan(
-			&s.DemoHash, &s.WeaponBucket, &s.DistanceBin,
+			&s.DemoHash, &s.RoundContext, &s.WeaponBucket, &s.DistanceBin,
 			&s.DuelCount, &s.FirstHitCount, &s.FirstHitHSCount,
 			&s.MedianCorrDeg, &s.MedianSightDeg, &s.MedianExpoWinMs,
+			&s.StillFirstHits, &s.StillFirstHitHS, &s.MovingFirstHits, &s.MovingFirstHitHS,
 		); err != nil {
 			return nil, err
 		}
@@ -520,23 +1244,20 @@ func (db *DB) GetAllPlayerDuelSegments(steamID uint64) ([]model.PlayerDuelSegmen
 	return out, rows.Err()
 }
 
-// InsertPlayerDuelSegments bulk-inserts FHHS segments in a transaction.
-func (db *DB) InsertPlayerDuelSegments(segs []model.PlayerDuelSegment) error {
+// InsertPlayerDeathSegments bulk-inserts "died to" segments in a transaction.
+func (db *DB) InsertPlayerDeathSegments(segs []model.PlayerDeathSegment) error {
 	if len(segs) == 0 {
 		return nil
 	}
-	tx, err := db.conn.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
+	return db.withTx(func(tx *sql.Tx) error { return insertPlayerDeathSegments(tx, segs) })
+}
 
+// insertPlayerDeathSegments is InsertPlayerDeathSegments within an open transaction.
+func insertPlayerDeathSegments(tx *sql.Tx, segs []model.PlayerDeathSegment) error {
 	stmt, err := tx.Prepare(`
-		INSERT OR REPLACE INTO player_duel_segments(
-			demo_hash, steam_id, weapon_bucket, distance_bin,
-			duel_count, first_hit_count, first_hit_hs_count,
-			median_corr_deg, median_sight_deg, median_expo_win_ms
-		) VALUES (?,?,?,?,?,?,?,?,?,?)`)
+		INSERT OR REPLACE INTO player_death_segments(
+			demo_hash, steam_id, weapon_bucket, distance_bin, deaths, headshot_deaths
+		) VALUES (?,?,?,?,?,?)`)
 	if err != nil {
 		return err
 	}
@@ -545,37 +1266,508 @@ func (db *DB) InsertPlayerDuelSegments(segs []model.PlayerDuelSegment) error {
 	for _, s := range segs {
 		_, err = stmt.Exec(
 			s.DemoHash, strconv.FormatUint(s.SteamID, 10), s.WeaponBucket, s.DistanceBin,
-			s.DuelCount, s.FirstHitCount, s.FirstHitHSCount,
-			s.MedianCorrDeg, s.MedianSightDeg, s.MedianExpoWinMs,
+			s.Deaths, s.HeadshotDeaths,
 		)
 		if err != nil {
-			return fmt.Errorf("insert player_duel_segments for %d/%s/%s: %w", s.SteamID, s.WeaponBucket, s.DistanceBin, err)
+			return fmt.Errorf("insert player_death_segments for %d/%s/%s: %w", s.SteamID, s.WeaponBucket, s.DistanceBin, err)
 		}
 	}
-	return tx.Commit()
+	return nil
 }
 
-// GetPlayerDuelSegments returns all FHHS segments for a demo hash.
-func (db *DB) GetPlayerDuelSegments(demoHash string) ([]model.PlayerDuelSegment, error) {
+// GetAllPlayerDeathSegments returns every "died to" segment for a player across all demos.
+func (db *DB) GetAllPlayerDeathSegments(steamID uint64) ([]model.PlayerDeathSegment, error) {
 	rows, err := db.conn.Query(`
-		SELECT steam_id, weapon_bucket, distance_bin,
-		       duel_count, first_hit_count, first_hit_hs_count,
-		       median_corr_deg, median_sight_deg, median_expo_win_ms
-		FROM player_duel_segments WHERE demo_hash = ?`, demoHash)
+		SELECT demo_hash, weapon_bucket, distance_bin, deaths, headshot_deaths
+		FROM player_death_segments WHERE steam_id = ?`, strconv.FormatUint(steamID, 10))
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var out []model.PlayerDuelSegment
+	var out []model.PlayerDeathSegment
 	for rows.Next() {
-		var s model.PlayerDuelSegment
-		var steamIDStr string
-		if err := rows.Scan(
-			&steamIDStr, &s.WeaponBucket, &s.DistanceBin,
-			&s.DuelCount, &s.FirstHitCount, &s.FirstHitHSCount,
-			&s.MedianCorrDeg, &s.MedianSightDeg, &s.MedianExpoWinMs,
-		); err != nil {
+		var s model.PlayerDeathSegment
+		if err := rows.Scan(&s.DemoHash, &s.WeaponBucket, &s.DistanceBin, &s.Deaths, &s.HeadshotDeaths); err != nil {
+			return nil, err
+		}
+		s.SteamID = steamID
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// insertPlayerDeathContexts stores pre-death context counts within an open transaction.
+func insertPlayerDeathContexts(tx *sql.Tx, rows []model.PlayerDeathContext) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	stmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO player_death_contexts(
+			demo_hash, steam_id, movement, flashed, isolated, after_kill, deaths
+		) VALUES (?,?,?,?,?,?,?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, r := range rows {
+		if _, err := stmt.Exec(r.DemoHash, strconv.FormatUint(r.SteamID, 10), r.Movement,
+			boolInt(r.Flashed), boolInt(r.Isolated), boolInt(r.AfterKill), r.Deaths); err != nil {
+			return fmt.Errorf("insert player_death_contexts for %d/%s: %w", r.SteamID, r.Movement, err)
+		}
+	}
+	return nil
+}
+
+// GetAllPlayerDeathContexts returns every pre-death context row for a player across all demos.
+func (db *DB) GetAllPlayerDeathContexts(steamID uint64) ([]model.PlayerDeathContext, error) {
+	rows, err := db.conn.Query(`
+		SELECT demo_hash, movement, flashed, isolated, after_kill, deaths
+		FROM player_death_contexts WHERE steam_id = ?`, strconv.FormatUint(steamID, 10))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.PlayerDeathContext
+	for rows.Next() {
+		var r model.PlayerDeathContext
+		var flashed, isolated, afterKill int
+		if err := rows.Scan(&r.DemoHash, &r.Movement, &flashed, &isolated, &afterKill, &r.Deaths); err != nil {
+			return nil, err
+		}
+		r.SteamID = steamID
+		r.Flashed, r.Isolated, r.AfterKill = flashed != 0, isolated != 0, afterKill != 0
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// InsertPlayerTimeToDamage bulk-inserts per-weapon time-to-damage rows in a transaction.
+func (db *DB) InsertPlayerTimeToDamage(rows []model.PlayerTimeToDamage) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	return db.withTx(func(tx *sql.Tx) error { return insertPlayerTimeToDamage(tx, rows) })
+}
+
+// insertPlayerTimeToDamage is InsertPlayerTimeToDamage within an open transaction.
+func insertPlayerTimeToDamage(tx *sql.Tx, rows []model.PlayerTimeToDamage) error {
+	stmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO player_time_to_damage(
+			demo_hash, steam_id, weapon_bucket, samples, median_ms
+		) VALUES (?,?,?,?,?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, r := range rows {
+		_, err = stmt.Exec(r.DemoHash, strconv.FormatUint(r.SteamID, 10), r.WeaponBucket, r.Samples, r.MedianMs)
+		if err != nil {
+			return fmt.Errorf("insert player_time_to_damage for %d/%s: %w", r.SteamID, r.WeaponBucket, err)
+		}
+	}
+	return nil
+}
+
+// GetAllPlayerTimeToDamage returns every per-weapon time-to-damage row for a
+// player across all demos.
+func (db *DB) GetAllPlayerTimeToDamage(steamID uint64) ([]model.PlayerTimeToDamage, error) {
+	rows, err := db.conn.Query(`
+		SELECT demo_hash, weapon_bucket, samples, median_ms
+		FROM player_time_to_damage WHERE steam_id = ?`, strconv.FormatUint(steamID, 10))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.PlayerTimeToDamage
+	for rows.Next() {
+		var r model.PlayerTimeToDamage
+		if err := rows.Scan(&r.DemoHash, &r.WeaponBucket, &r.Samples, &r.MedianMs); err != nil {
+			return nil, err
+		}
+		r.SteamID = steamID
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// InsertPlayerBurstStats bulk-inserts per-weapon burst histogram rows in a transaction.
+func (db *DB) InsertPlayerBurstStats(rows []model.PlayerBurstStats) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	return db.withTx(func(tx *sql.Tx) error { return insertPlayerBurstStats(tx, rows) })
+}
+
+// insertPlayerBurstStats is InsertPlayerBurstStats within an open transaction.
+func insertPlayerBurstStats(tx *sql.Tx, rows []model.PlayerBurstStats) error {
+	stmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO player_burst_stats(
+			demo_hash, steam_id, weapon_bucket, taps, short_bursts, sprays, panic_sprays
+		) VALUES (?,?,?,?,?,?,?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, r := range rows {
+		_, err = stmt.Exec(r.DemoHash, strconv.FormatUint(r.SteamID, 10), r.WeaponBucket,
+			r.Taps, r.ShortBursts, r.Sprays, r.PanicSprays)
+		if err != nil {
+			return fmt.Errorf("insert player_burst_stats for %d/%s: %w", r.SteamID, r.WeaponBucket, err)
+		}
+	}
+	return nil
+}
+
+// GetAllPlayerBurstStats returns every per-weapon burst histogram row for a
+// player across all demos.
+func (db *DB) GetAllPlayerBurstStats(steamID uint64) ([]model.PlayerBurstStats, error) {
+	rows, err := db.conn.Query(`
+		SELECT demo_hash, weapon_bucket, taps, short_bursts, sprays, panic_sprays
+		FROM player_burst_stats WHERE steam_id = ?`, strconv.FormatUint(steamID, 10))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.PlayerBurstStats
+	for rows.Next() {
+		var r model.PlayerBurstStats
+		if err := rows.Scan(&r.DemoHash, &r.WeaponBucket, &r.Taps, &r.ShortBursts, &r.Sprays, &r.PanicSprays); err != nil {
+			return nil, err
+		}
+		r.SteamID = steamID
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// insertPlayerDuelDistances stores per-meter won-duel counts within an open
+// transaction.
+func insertPlayerDuelDistances(tx *sql.Tx, rows []model.PlayerDuelDistance) error {
+	stmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO player_duel_distances(
+			demo_hash, steam_id, weapon_bucket, meters, duel_count, first_hit_count, first_hit_hs_count
+		) VALUES (?,?,?,?,?,?,?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, r := range rows {
+		_, err = stmt.Exec(r.DemoHash, strconv.FormatUint(r.SteamID, 10), r.WeaponBucket, r.Meters,
+			r.DuelCount, r.FirstHitCount, r.FirstHitHSCount)
+		if err != nil {
+			return fmt.Errorf("insert player_duel_distances for %d/%s/%dm: %w", r.SteamID, r.WeaponBucket, r.Meters, err)
+		}
+	}
+	return nil
+}
+
+// GetAllPlayerDuelDistances returns every per-meter won-duel row for a player
+// across all demos.
+func (db *DB) GetAllPlayerDuelDistances(steamID uint64) ([]model.PlayerDuelDistance, error) {
+	rows, err := db.conn.Query(`
+		SELECT demo_hash, weapon_bucket, meters, duel_count, first_hit_count, first_hit_hs_count
+		FROM player_duel_distances WHERE steam_id = ?`, strconv.FormatUint(steamID, 10))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.PlayerDuelDistance
+	for rows.Next() {
+		var r model.PlayerDuelDistance
+		if err := rows.Scan(&r.DemoHash, &r.WeaponBucket, &r.Meters,
+			&r.DuelCount, &r.FirstHitCount, &r.FirstHitHSCount); err != nil {
+			return nil, err
+		}
+		r.SteamID = steamID
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// GetBaselineDuelDistances pools the per-meter won-duel rows of every
+// baseline demo by weapon bucket and distance — the corpus quantile distance
+// bins are cut from. DemoHash and SteamID are left empty.
+func (db *DB) GetBaselineDuelDistances() ([]model.PlayerDuelDistance, error) {
+	rows, err := db.conn.Query(`
+		SELECT t.weapon_bucket, t.meters,
+		       SUM(t.duel_count), SUM(t.first_hit_count), SUM(t.first_hit_hs_count)
+		FROM player_duel_distances t
+		JOIN demos d ON d.hash = t.demo_hash
+		WHERE d.is_baseline = 1
+		GROUP BY t.weapon_bucket, t.meters
+		ORDER BY t.weapon_bucket, t.meters`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.PlayerDuelDistance
+	for rows.Next() {
+		var r model.PlayerDuelDistance
+		if err := rows.Scan(&r.WeaponBucket, &r.Meters,
+			&r.DuelCount, &r.FirstHitCount, &r.FirstHitHSCount); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// insertPlayerPositions stores per-position early-round counts within an open
+// transaction.
+func insertPlayerPositions(tx *sql.Tx, rows []model.PlayerPositionStats) error {
+	stmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO player_positions(
+			demo_hash, steam_id, side, place, rounds, avg_x, avg_y
+		) VALUES (?,?,?,?,?,?,?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, r := range rows {
+		_, err = stmt.Exec(r.DemoHash, strconv.FormatUint(r.SteamID, 10), r.Side.String(), r.Place,
+			r.Rounds, r.AvgX, r.AvgY)
+		if err != nil {
+			return fmt.Errorf("insert player_positions for %d/%s: %w", r.SteamID, r.Place, err)
+		}
+	}
+	return nil
+}
+
+// GetAllPlayerPositions returns every early-round position row for a player
+// across all demos, with the demo's map name.
+func (db *DB) GetAllPlayerPositions(steamID uint64) ([]model.PlayerPositionStats, error) {
+	rows, err := db.conn.Query(`
+		SELECT p.demo_hash, d.map_name, p.side, p.place, p.rounds, p.avg_x, p.avg_y
+		FROM player_positions p
+		JOIN demos d ON d.hash = p.demo_hash
+		WHERE p.steam_id = ?`, strconv.FormatUint(steamID, 10))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.PlayerPositionStats
+	for rows.Next() {
+		var r model.PlayerPositionStats
+		var side string
+		if err := rows.Scan(&r.DemoHash, &r.MapName, &side, &r.Place, &r.Rounds, &r.AvgX, &r.AvgY); err != nil {
+			return nil, err
+		}
+		r.SteamID = steamID
+		r.Side = parseTeam(side)
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// GetTimeToDamageReferences pools the time-to-damage rows of every player
+// except excludeID by weapon bucket: Samples is the total and MedianMs the
+// sample-weighted mean of the per-demo medians. As with GetSegmentReferences,
+// the baseline-demo pool is used when it has at least minSamples samples.
+// DemoHash and SteamID are left empty.
+func (db *DB) GetTimeToDamageReferences(excludeID uint64, minSamples int) ([]model.PlayerTimeToDamage, error) {
+	rows, err := db.conn.Query(`
+		SELECT d.is_baseline, t.weapon_bucket, SUM(t.samples),
+		       COALESCE(SUM(t.median_ms * t.samples) / NULLIF(SUM(t.samples), 0), 0)
+		FROM player_time_to_damage t
+		JOIN demos d ON d.hash = t.demo_hash
+		WHERE t.steam_id != ?
+		GROUP BY d.is_baseline, t.weapon_bucket
+		ORDER BY t.weapon_bucket`,
+		strconv.FormatUint(excludeID, 10))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	all := make(map[string]*model.PlayerTimeToDamage)
+	base := make(map[string]model.PlayerTimeToDamage)
+	var order []string
+	for rows.Next() {
+		var r model.PlayerTimeToDamage
+		var isBaseline int
+		if err := rows.Scan(&isBaseline, &r.WeaponBucket, &r.Samples, &r.MedianMs); err != nil {
+			return nil, err
+		}
+		if isBaseline != 0 {
+			base[r.WeaponBucket] = r
+		}
+		a, ok := all[r.WeaponBucket]
+		if !ok {
+			cp := r
+			all[r.WeaponBucket] = &cp
+			order = append(order, r.WeaponBucket)
+			continue
+		}
+		n := a.Samples + r.Samples
+		if n > 0 {
+			a.MedianMs = (a.MedianMs*float64(a.Samples) + r.MedianMs*float64(r.Samples)) / float64(n)
+		}
+		a.Samples = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	out := make([]model.PlayerTimeToDamage, 0, len(order))
+	for _, b := range order {
+		if r, ok := base[b]; ok && r.Samples >= minSamples {
+			out = append(out, r)
+			continue
+		}
+		out = append(out, *all[b])
+	}
+	return out, nil
+}
+
+// GetSegmentReferences pools duel segments of every player except excludeID by
+// (weapon_bucket, distance_bin). For each segment the baseline-demo pool is used
+// when it has at least minFirstHits first hits; otherwise all demos are pooled.
+func (db *DB) GetSegmentReferences(excludeID uint64, minFirstHits int) ([]model.SegmentReference, error) {
+	rows, err := db.conn.Query(`
+		SELECT d.is_baseline, s.weapon_bucket, s.distance_bin,
+		       SUM(s.first_hit_count), SUM(s.first_hit_hs_count),
+		       COALESCE(AVG(NULLIF(s.median_sight_deg, 0)), 0),
+		       COALESCE(AVG(NULLIF(s.median_corr_deg, 0)), 0)
+		FROM player_duel_segments s
+		JOIN demos d ON d.hash = s.demo_hash
+		WHERE s.steam_id != ?
+		GROUP BY d.is_baseline, s.weapon_bucket, s.distance_bin`,
+		strconv.FormatUint(excludeID, 10))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type key struct{ bucket, bin string }
+	all := make(map[key]*model.SegmentReference)
+	base := make(map[key]model.SegmentReference)
+	var order []key
+	for rows.Next() {
+		var r model.SegmentReference
+		var isBaseline int
+		if err := rows.Scan(&isBaseline, &r.WeaponBucket, &r.DistanceBin,
+			&r.FirstHitCount, &r.FirstHitHSCount, &r.AvgSightDeg, &r.AvgCorrDeg); err != nil {
+			return nil, err
+		}
+		k := key{r.WeaponBucket, r.DistanceBin}
+		if isBaseline != 0 {
+			r.Baseline = true
+			base[k] = r
+		}
+		a, ok := all[k]
+		if !ok {
+			cp := r
+			cp.Baseline = false
+			all[k] = &cp
+			order = append(order, k)
+			continue
+		}
+		// Pool the baseline and non-baseline groups, weighting the angle means
+		// by first hits; a group without angle data does not dilute the other.
+		n1, n2 := float64(a.FirstHitCount), float64(r.FirstHitCount)
+		a.AvgSightDeg = pooledMean(a.AvgSightDeg, n1, r.AvgSightDeg, n2)
+		a.AvgCorrDeg = pooledMean(a.AvgCorrDeg, n1, r.AvgCorrDeg, n2)
+		a.FirstHitCount += r.FirstHitCount
+		a.FirstHitHSCount += r.FirstHitHSCount
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	out := make([]model.SegmentReference, 0, len(order))
+	for _, k := range order {
+		if b, ok := base[k]; ok && b.FirstHitCount >= minFirstHits {
+			out = append(out, b)
+			continue
+		}
+		out = append(out, *all[k])
+	}
+	return out, nil
+}
+
+// pooledMean combines two group means weighted by n, treating a zero mean as
+// "no data" rather than a value.
+func pooledMean(m1, n1, m2, n2 float64) float64 {
+	switch {
+	case m1 == 0:
+		return m2
+	case m2 == 0 || n1+n2 == 0:
+		return m1
+	}
+	return (m1*n1 + m2*n2) / (n1 + n2)
+}
+
+// InsertPlayerDuelSegments bulk-inserts FHHS segments in a transaction.
+func (db *DB) InsertPlayerDuelSegments(segs []model.PlayerDuelSegment) error {
+	if len(segs) == 0 {
+		return nil
+	}
+	return db.withTx(func(tx *sql.Tx) error { return insertPlayerDuelSegments(tx, segs) })
+}
+
+// insertPlayerDuelSegments is InsertPlayerDuelSegments within an open transaction.
+func insertPlayerDuelSegments(tx *sql.Tx, segs []model.PlayerDuelSegment) error {
+	stmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO player_duel_segments(
+			demo_hash, steam_id, round_context, weapon_bucket, distance_bin,
+			duel_count, first_hit_count, first_hit_hs_count,
+			median_corr_deg, median_sight_deg, median_expo_win_ms,
+			still_first_hits, still_first_hit_hs, moving_first_hits, moving_first_hit_hs
+		) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, s := range segs {
+		_, err = stmt.Exec(
+			s.DemoHash, strconv.FormatUint(s.SteamID, 10), s.RoundContext, s.WeaponBucket, s.DistanceBin,
+			s.DuelCount, s.FirstHitCount, s.FirstHitHSCount,
+			s.MedianCorrDeg, s.MedianSightDeg, s.MedianExpoWinMs,
+			s.StillFirstHits, s.StillFirstHitHS, s.MovingFirstHits, s.MovingFirstHitHS,
+		)
+		if err != nil {
+			return fmt.Errorf("insert player_duel_segments for %d/%s/%s/%s: %w", s.SteamID, s.RoundContext, s.WeaponBucket, s.DistanceBin, err)
+		}
+	}
+	return nil
+}
+
+// GetPlayerDuelSegments returns all FHHS segments for a demo hash.
+func (db *DB) GetPlayerDuelSegments(demoHash string) ([]model.PlayerDuelSegment, error) {
+	rows, err := db.conn.Query(`
+		SELECT steam_id, round_context, weapon_bucket, distance_bin,
+		       duel_count, first_hit_count, first_hit_hs_count,
+		       median_corr_deg, median_sight_deg, median_expo_win_ms,
+		       still_first_hits, still_first_hit_hs, moving_first_hits, moving_first_hit_hs
+		FROM player_duel_segments WHERE demo_hash = ?`, demoHash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.PlayerDuelSegment
+	for rows.Next() {
+		var s model.PlayerDuelSegment
+		var steamIDStr string
+		if err := rows.Scan(
+			&steamIDStr, &s.RoundContext, &s.WeaponBucket, &s.DistanceBin,
+			&s.DuelCount, &s.FirstHitCount, &s.FirstHitHSCount,
+			&s.MedianCorrDeg, &s.MedianSightDeg, &s.MedianExpoWinMs,
+			&s.StillFirstHits, &s.StillFirstHitHS, &s.MovingFirstHits, &s.MovingFirstHitHS,
+		); err != nil {
 			return nil, err
 		}
 		s.DemoHash = demoHash
@@ -585,6 +1777,402 @@ func (db *DB) GetPlayerDuelSegments(demoHash string) ([]model.PlayerDuelSegment,
 	return out, rows.Err()
 }
 
+// InsertFirstSights bulk-inserts raw first-sight events for one demo in a
+// transaction. Callers pass only the sights of tracked players.
+func (db *DB) InsertFirstSights(demoHash string, sights []model.RawFirstSight) error {
+	if len(sights) == 0 {
+		return nil
+	}
+	return db.withTx(func(tx *sql.Tx) error { return insertFirstSights(tx, demoHash, sights) })
+}
+
+// insertFirstSights is InsertFirstSights within an open transaction.
+func insertFirstSights(tx *sql.Tx, demoHash string, sights []model.RawFirstSight) error {
+	stmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO player_first_sights(
+			demo_hash, steam_id, enemy_id, round_number, tick,
+			angle_deg, pitch_deg, yaw_deg, observer_pitch_deg, observer_yaw_deg
+		) VALUES (?,?,?,?,?,?,?,?,?,?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, fs := range sights {
+		_, err = stmt.Exec(
+			demoHash, strconv.FormatUint(fs.ObserverID, 10), strconv.FormatUint(fs.EnemyID, 10),
+			fs.RoundNumber, fs.Tick,
+			fs.AngleDeg, fs.PitchDeg, fs.YawDeg, fs.ObserverPitchDeg, fs.ObserverYawDeg,
+		)
+		if err != nil {
+			return fmt.Errorf("insert player_first_sights for %d: %w", fs.ObserverID, err)
+		}
+	}
+	return nil
+}
+
+// GetFirstSights returns the stored first-sight events observed by a player in
+// a demo, ordered by round then tick. Empty if the player was not tracked when
+// the demo was parsed.
+func (db *DB) GetFirstSights(demoHash string, steamID uint64) ([]model.RawFirstSight, error) {
+	rows, err := db.conn.Query(`
+		SELECT enemy_id, round_number, tick,
+		       angle_deg, pitch_deg, yaw_deg, observer_pitch_deg, observer_yaw_deg
+		FROM player_first_sights
+		WHERE demo_hash = ? AND steam_id = ?
+		ORDER BY round_number ASC, tick ASC`,
+		demoHash, strconv.FormatUint(steamID, 10))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.RawFirstSight
+	for rows.Next() {
+		var fs model.RawFirstSight
+		var enemyIDStr string
+		if err := rows.Scan(
+			&enemyIDStr, &fs.RoundNumber, &fs.Tick,
+			&fs.AngleDeg, &fs.PitchDeg, &fs.YawDeg, &fs.ObserverPitchDeg, &fs.ObserverYawDeg,
+		); err != nil {
+			return nil, err
+		}
+		fs.ObserverID = steamID
+		fs.EnemyID, _ = strconv.ParseUint(enemyIDStr, 10, 64)
+		out = append(out, fs)
+	}
+	return out, rows.Err()
+}
+
+// InsertKillStates bulk-inserts the pre-kill round states of one demo in a transaction.
+func (db *DB) InsertKillStates(states []model.KillState) error {
+	if len(states) == 0 {
+		return nil
+	}
+	return db.withTx(func(tx *sql.Tx) error { return insertKillStates(tx, states) })
+}
+
+// insertKillStates is InsertKillStates within an open transaction.
+func insertKillStates(tx *sql.Tx, states []model.KillState) error {
+	stmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO round_kill_states(
+			demo_hash, round_number, tick, killer_id, victim_id,
+			killer_team, victim_team, ct_alive, t_alive, bomb_planted, winner_team, weapon,
+			clock_remaining_sec
+		) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, ks := range states {
+		_, err = stmt.Exec(
+			ks.DemoHash, ks.RoundNumber, ks.Tick,
+			strconv.FormatUint(ks.KillerSteamID, 10), strconv.FormatUint(ks.VictimSteamID, 10),
+			ks.KillerTeam.String(), ks.VictimTeam.String(),
+			ks.CTAlive, ks.TAlive, boolInt(ks.BombPlanted), ks.WinnerTeam.String(), ks.Weapon,
+			ks.ClockSec,
+		)
+		if err != nil {
+			return fmt.Errorf("insert round_kill_states round %d tick %d: %w", ks.RoundNumber, ks.Tick, err)
+		}
+	}
+	return nil
+}
+
+// GetRoundOutcomes returns the winner and end reason of every stored round of a
+// demo, ordered by round number. The winner is derived from any player's side
+// and won_round flag; rounds stored before end reasons were recorded have an
+// empty EndReason. StartedCTSide is the side most players who were on CT in
+// the first stored round played that round (the previous round's side on a
+// tie).
+func (db *DB) GetRoundOutcomes(demoHash string) ([]model.RoundOutcome, error) {
+	rows, err := db.conn.Query(`
+		SELECT round_number, steam_id, team, won_round, end_reason
+		FROM player_round_stats
+		WHERE demo_hash = ? AND team IN ('CT', 'T')
+		ORDER BY round_number ASC`,
+		demoHash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.RoundOutcome
+	startedCT := make(map[string]bool)
+	side := model.TeamCT
+	var cur model.RoundOutcome
+	votes := 0 // started-CT players on CT minus those on T in cur's round
+	flush := func() {
+		if cur.RoundNumber == 0 {
+			return
+		}
+		switch {
+		case votes > 0:
+			side = model.TeamCT
+		case votes < 0:
+			side = model.TeamT
+		}
+		cur.StartedCTSide = side
+		if cur.WinnerTeam != model.TeamUnknown {
+			out = append(out, cur)
+		}
+	}
+	first := 0
+	for rows.Next() {
+		var round, won int
+		var steamID, teamStr, reason string
+		if err := rows.Scan(&round, &steamID, &teamStr, &won, &reason); err != nil {
+			return nil, err
+		}
+		team := parseTeam(teamStr)
+		if first == 0 {
+			first = round
+		}
+		if round == first && team == model.TeamCT {
+			startedCT[steamID] = true
+		}
+		if round != cur.RoundNumber {
+			flush()
+			cur, votes = model.RoundOutcome{RoundNumber: round}, 0
+		}
+		if startedCT[steamID] {
+			if team == model.TeamCT {
+				votes++
+			} else {
+				votes--
+			}
+		}
+		if won == 1 && cur.WinnerTeam == model.TeamUnknown {
+			cur.WinnerTeam = team
+		}
+		if reason > cur.EndReason {
+			cur.EndReason = reason
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return out, nil
+}
+
+// insertTeamRoundEconomy stores each side's per-round economy within an open
+// transaction.
+func insertTeamRoundEconomy(tx *sql.Tx, rows []model.TeamRoundEconomy) error {
+	stmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO team_round_economy(
+			demo_hash, round_number, team, players, equip_value, round_type, won
+		) VALUES (?,?,?,?,?,?,?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, r := range rows {
+		_, err = stmt.Exec(r.DemoHash, r.RoundNumber, r.Team.String(), r.Players, r.EquipValue,
+			r.RoundType, boolInt(r.Won))
+		if err != nil {
+			return fmt.Errorf("insert team_round_economy round %d %s: %w", r.RoundNumber, r.Team, err)
+		}
+	}
+	return nil
+}
+
+// GetTeamRoundEconomy returns both sides' economy for every stored round of a
+// demo, ordered by round number (CT before T). Demos parsed before team
+// economy was recorded return no rows.
+func (db *DB) GetTeamRoundEconomy(demoHash string) ([]model.TeamRoundEconomy, error) {
+	rows, err := db.conn.Query(`
+		SELECT round_number, team, players, equip_value, round_type, won
+		FROM team_round_economy
+		WHERE demo_hash = ?
+		ORDER BY round_number ASC, team ASC`,
+		demoHash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.TeamRoundEconomy
+	for rows.Next() {
+		r := model.TeamRoundEconomy{DemoHash: demoHash}
+		var teamStr string
+		var won int
+		if err := rows.Scan(&r.RoundNumber, &teamStr, &r.Players, &r.EquipValue, &r.RoundType, &won); err != nil {
+			return nil, err
+		}
+		r.Team = parseTeam(teamStr)
+		r.Won = won != 0
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// insertTradeChains stores a demo's trade chains within an open transaction.
+func insertTradeChains(tx *sql.Tx, chains []model.TradeChain) error {
+	stmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO round_trade_chains(
+			demo_hash, round_number, chain_index, start_tick, end_tick,
+			kills, trades, first_team, ct_kills, t_kills
+		) VALUES (?,?,?,?,?,?,?,?,?,?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, c := range chains {
+		_, err = stmt.Exec(c.DemoHash, c.RoundNumber, c.Index, c.StartTick, c.EndTick,
+			c.Kills, c.Trades, c.FirstTeam.String(), c.CTKills, c.TKills)
+		if err != nil {
+			return fmt.Errorf("insert round_trade_chains round %d #%d: %w", c.RoundNumber, c.Index, err)
+		}
+	}
+	return nil
+}
+
+// GetTradeChains returns a demo's stored trade chains ordered by round and
+// chain index. Demos parsed before trade chains were recorded return no rows.
+func (db *DB) GetTradeChains(demoHash string) ([]model.TradeChain, error) {
+	rows, err := db.conn.Query(`
+		SELECT round_number, chain_index, start_tick, end_tick, kills, trades, first_team, ct_kills, t_kills
+		FROM round_trade_chains
+		WHERE demo_hash = ?
+		ORDER BY round_number ASC, chain_index ASC`,
+		demoHash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.TradeChain
+	for rows.Next() {
+		c := model.TradeChain{DemoHash: demoHash}
+		var teamStr string
+		if err := rows.Scan(&c.RoundNumber, &c.Index, &c.StartTick, &c.EndTick,
+			&c.Kills, &c.Trades, &teamStr, &c.CTKills, &c.TKills); err != nil {
+			return nil, err
+		}
+		c.FirstTeam = parseTeam(teamStr)
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// insertRoundEvents stores a demo's round timeline within an open transaction.
+func insertRoundEvents(tx *sql.Tx, events []model.TimelineEvent) error {
+	stmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO round_events(
+			demo_hash, seq, round_number, tick, type, actor_id, target_id, actor_team, target_team,
+			actor_x, actor_y, actor_z, target_x, target_y, target_z,
+			weapon, headshot, duration_sec, detail
+		) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, e := range events {
+		_, err = stmt.Exec(e.DemoHash, e.Seq, e.RoundNumber, e.Tick, e.Type,
+			strconv.FormatUint(e.ActorID, 10), strconv.FormatUint(e.TargetID, 10),
+			eventTeam(e.ActorTeam), eventTeam(e.TargetTeam),
+			e.ActorPos.X, e.ActorPos.Y, e.ActorPos.Z, e.TargetPos.X, e.TargetPos.Y, e.TargetPos.Z,
+			e.Weapon, boolInt(e.Headshot), e.DurationSec, e.Detail)
+		if err != nil {
+			return fmt.Errorf("insert round_events %d (%s): %w", e.Seq, e.Type, err)
+		}
+	}
+	return nil
+}
+
+// eventTeam is the stored team of a round event: "CT", "T", or "" when none.
+func eventTeam(t model.Team) string {
+	if t != model.TeamCT && t != model.TeamT {
+		return ""
+	}
+	return t.String()
+}
+
+// GetRoundEvents returns a demo's round timeline in stream order. Demos
+// parsed before the timeline was recorded return no rows.
+func (db *DB) GetRoundEvents(demoHash string) ([]model.TimelineEvent, error) {
+	rows, err := db.conn.Query(`
+		SELECT seq, round_number, tick, type, actor_id, target_id, actor_team, target_team,
+		       actor_x, actor_y, actor_z, target_x, target_y, target_z,
+		       weapon, headshot, duration_sec, detail
+		FROM round_events
+		WHERE demo_hash = ?
+		ORDER BY seq ASC`,
+		demoHash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.TimelineEvent
+	for rows.Next() {
+		e := model.TimelineEvent{DemoHash: demoHash}
+		var actorStr, targetStr, actorTeam, targetTeam string
+		var headshot int
+		if err := rows.Scan(&e.Seq, &e.RoundNumber, &e.Tick, &e.Type, &actorStr, &targetStr, &actorTeam, &targetTeam,
+			&e.ActorPos.X, &e.ActorPos.Y, &e.ActorPos.Z, &e.TargetPos.X, &e.TargetPos.Y, &e.TargetPos.Z,
+			&e.Weapon, &headshot, &e.DurationSec, &e.Detail); err != nil {
+			return nil, err
+		}
+		e.ActorID, _ = strconv.ParseUint(actorStr, 10, 64)
+		e.TargetID, _ = strconv.ParseUint(targetStr, 10, 64)
+		e.ActorTeam = parseTeam(actorTeam)
+		e.TargetTeam = parseTeam(targetTeam)
+		e.Headshot = headshot != 0
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// insertUnmappedWeapons stores a demo's unmapped weapon diagnostics within an
+// open transaction.
+func insertUnmappedWeapons(tx *sql.Tx, rows []model.UnmappedWeapon) error {
+	stmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO demo_unmapped_weapons(demo_hash, weapon, events)
+		VALUES (?,?,?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, r := range rows {
+		if _, err := stmt.Exec(r.DemoHash, r.Weapon, r.Events); err != nil {
+			return fmt.Errorf("insert demo_unmapped_weapons %q: %w", r.Weapon, err)
+		}
+	}
+	return nil
+}
+
+// GetUnmappedWeapons returns the weapons of a demo that the weapon bucket
+// table didn't know when it was parsed, most events first.
+func (db *DB) GetUnmappedWeapons(demoHash string) ([]model.UnmappedWeapon, error) {
+	rows, err := db.conn.Query(`
+		SELECT weapon, events
+		FROM demo_unmapped_weapons
+		WHERE demo_hash = ?
+		ORDER BY events DESC, weapon ASC`,
+		demoHash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.UnmappedWeapon
+	for rows.Next() {
+		r := model.UnmappedWeapon{DemoHash: demoHash}
+		if err := rows.Scan(&r.Weapon, &r.Events); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
 // GetClutchStatsByDemo returns per-player clutch attempt/win counts for a single
 // demo, keyed by SteamID. No schema changes needed — reads existing player_round_stats.
 func (db *DB) GetClutchStatsByDemo(demoHash string) (map[uint64]*model.PlayerClutchMatchStats, error) {
@@ -710,6 +2298,47 @@ func (db *DB) GetDBOverview() (DBOverview, error) {
 	return ov, err
 }
 
+// SizeBytes returns the database size as page_count × page_size, which
+// includes pages still held in the write-ahead log.
+func (db *DB) SizeBytes() (int64, error) {
+	var pages, size int64
+	if err := db.conn.QueryRow(`PRAGMA page_count`).Scan(&pages); err != nil {
+		return 0, err
+	}
+	if err := db.conn.QueryRow(`PRAGMA page_size`).Scan(&size); err != nil {
+		return 0, err
+	}
+	return pages * size, nil
+}
+
+// TableFootprint returns the row count, on-disk size (table plus index pages,
+// from the dbstat virtual table) and number of demos with rows of a per-demo
+// table. The table must have a demo_hash column.
+func (db *DB) TableFootprint(table string) (model.DatasetFootprint, error) {
+	fp := model.DatasetFootprint{Table: table}
+	var n int
+	if err := db.conn.QueryRow(
+		`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&n); err != nil {
+		return fp, err
+	}
+	if n == 0 {
+		return fp, fmt.Errorf("unknown table %q", table)
+	}
+	// table is a known table name at this point, so it is safe to interpolate.
+	if err := db.conn.QueryRow(
+		`SELECT COUNT(*), COUNT(DISTINCT demo_hash) FROM `+table).Scan(&fp.Rows, &fp.Demos); err != nil {
+		return fp, fmt.Errorf("count %s: %w", table, err)
+	}
+	if err := db.conn.QueryRow(`
+		SELECT COALESCE(SUM(s.pgsize), 0)
+		FROM dbstat s
+		JOIN sqlite_master m ON m.name = s.name
+		WHERE m.tbl_name = ?`, table).Scan(&fp.Bytes); err != nil {
+		return fp, fmt.Errorf("size of %s: %w", table, err)
+	}
+	return fp, nil
+}
+
 // GetMapStats returns match counts and round-win breakdowns per map, ordered by match count desc.
 func (db *DB) GetMapStats() ([]MapStat, error) {
 	rows, err := db.conn.Query(`
@@ -768,23 +2397,6 @@ type PlayerRatingRow struct {
 	Matches int
 }
 
-// ratingProxy computes the community approximation of HLTV Rating 2.0.
-//
-//	Impact = 2.13*KPR + 0.42*APR − 0.41
-//	Rating ≈ 0.0073*KAST% + 0.3591*KPR − 0.5329*DPR + 0.2372*Impact + 0.0032*ADR + 0.1587
-func ratingProxy(kills, assists, deaths, rounds, kastRounds, damage int) float64 {
-	if rounds == 0 {
-		return 0
-	}
-	kpr := float64(kills) / float64(rounds)
-	apr := float64(assists) / float64(rounds)
-	dpr := float64(deaths) / float64(rounds)
-	kast := 100.0 * float64(kastRounds) / float64(rounds)
-	adr := float64(damage) / float64(rounds)
-	impact := 2.13*kpr + 0.42*apr - 0.41
-	return 0.0073*kast + 0.3591*kpr - 0.5329*dpr + 0.2372*impact + 0.0032*adr + 0.1587
-}
-
 // GetTopPlayersByRating returns up to limit players ranked by the Rating 2.0 proxy,
 // computed from aggregated match stats across the filtered demo set. mapFilter must
 // be de_-stripped and lowercased (e.g. "mirage"); since is a YYYY-MM-DD cutoff.
@@ -848,7 +2460,7 @@ func (db *DB) GetTopPlayersByRating(limit, minMatches int, mapFilter, since stri
 	}
 	ranked := make([]rated, len(candidates))
 	for i, c := range candidates {
-		ranked[i] = rated{c, ratingProxy(c.kills, c.assists, c.deaths, c.rounds, c.kast, c.damage)}
+		ranked[i] = rated{c, model.RatingProxy(c.kills, c.assists, c.deaths, c.rounds, c.kast, c.damage)}
 	}
 	sort.Slice(ranked, func(i, j int) bool { return ranked[i].rating > ranked[j].rating })
 
@@ -862,6 +2474,94 @@ func (db *DB) GetTopPlayersByRating(limit, minMatches int, mapFilter, since stri
 	return out, nil
 }
 
+// GetPlayerFingerprints returns the fingerprint of every player with at
+// least minMatches stored matches, plus the player include regardless of
+// matches (0 = none), ordered by SteamID. FHHS is summed over all duel
+// segments per weapon bucket.
+func (db *DB) GetPlayerFingerprints(minMatches int, include uint64) ([]model.PlayerFingerprint, error) {
+	rows, err := db.conn.Query(`
+		SELECT steam_id, name, COUNT(*),
+		       COALESCE(AVG(NULLIF(median_ttk_ms, 0)), 0),
+		       COALESCE(AVG(NULLIF(counter_strafe_pct, 0)), 0),
+		       COALESCE(SUM(crosshair_median_deg * crosshair_encounters) / NULLIF(SUM(crosshair_encounters), 0), 0),
+		       COALESCE(SUM(crosshair_pct_under5 * crosshair_encounters) / NULLIF(SUM(crosshair_encounters), 0), 0),
+		       SUM(crosshair_encounters)
+		FROM player_match_stats
+		GROUP BY steam_id
+		HAVING COUNT(*) >= ? OR steam_id = ?
+		ORDER BY CAST(steam_id AS INTEGER)`,
+		minMatches, strconv.FormatUint(include, 10))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.PlayerFingerprint
+	index := make(map[uint64]int)
+	for rows.Next() {
+		var fp model.PlayerFingerprint
+		var id string
+		if err := rows.Scan(&id, &fp.Name, &fp.Matches, &fp.TTKMs, &fp.CounterStrafePct,
+			&fp.CrosshairMedianDeg, &fp.CrosshairPctUnder5, &fp.CrosshairSamples); err != nil {
+			return nil, err
+		}
+		fp.SteamID, _ = strconv.ParseUint(id, 10, 64)
+		fp.FHHS = make(map[string]model.FHHSCount)
+		index[fp.SteamID] = len(out)
+		out = append(out, fp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	segs, err := db.conn.Query(`
+		SELECT steam_id, weapon_bucket, SUM(first_hit_count), SUM(first_hit_hs_count)
+		FROM player_duel_segments
+		GROUP BY steam_id, weapon_bucket`)
+	if err != nil {
+		return nil, err
+	}
+	defer segs.Close()
+	for segs.Next() {
+		var id, bucket string
+		var c model.FHHSCount
+		if err := segs.Scan(&id, &bucket, &c.FirstHits, &c.Headshots); err != nil {
+			return nil, err
+		}
+		sid, _ := strconv.ParseUint(id, 10, 64)
+		if i, ok := index[sid]; ok && c.FirstHits > 0 {
+			out[i].FHHS[bucket] = c
+		}
+	}
+	return out, segs.Err()
+}
+
+// GetCoPlayers returns the SteamIDs of every other player stored in a demo
+// with steamID, on either team.
+func (db *DB) GetCoPlayers(steamID uint64) (map[uint64]bool, error) {
+	id := strconv.FormatUint(steamID, 10)
+	rows, err := db.conn.Query(`
+		SELECT DISTINCT o.steam_id
+		FROM player_match_stats p
+		JOIN player_match_stats o ON o.demo_hash = p.demo_hash AND o.steam_id != p.steam_id
+		WHERE p.steam_id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[uint64]bool)
+	for rows.Next() {
+		var other string
+		if err := rows.Scan(&other); err != nil {
+			return nil, err
+		}
+		if sid, err := strconv.ParseUint(other, 10, 64); err == nil {
+			out[sid] = true
+		}
+	}
+	return out, rows.Err()
+}
+
 // GetMatchTypeCounts returns the number of demos per match type, ordered by count desc.
 func (db *DB) GetMatchTypeCounts() ([]MatchTypeCount, error) {
 	rows, err := db.conn.Query(`
@@ -884,6 +2584,32 @@ func (db *DB) GetMatchTypeCounts() ([]MatchTypeCount, error) {
 	return out, rows.Err()
 }
 
+// GetPeekerBaselines returns the peeker's advantage pooled over every stored
+// demo, one row per demos.tier ("" for untiered demos) ordered by tier. Each
+// classified duel is counted once, from the peeker's row.
+func (db *DB) GetPeekerBaselines() ([]model.PeekerBaseline, error) {
+	rows, err := db.conn.Query(`
+		SELECT COALESCE(d.tier, ''), SUM(p.peek_duels), SUM(p.peek_wins)
+		FROM player_match_stats p
+		JOIN demos d ON d.hash = p.demo_hash
+		GROUP BY COALESCE(d.tier, '')
+		HAVING SUM(p.peek_duels) > 0
+		ORDER BY 1`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []model.PeekerBaseline
+	for rows.Next() {
+		var b model.PeekerBaseline
+		if err := rows.Scan(&b.Tier, &b.Duels, &b.PeekerWins); err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
 // QueryRaw executes an arbitrary SQL query and returns the column names and
 // all row values as strings. NULL values are rendered as "NULL".
 func (db *DB) QueryRaw(query string) (cols []string, rows [][]string, err error) {
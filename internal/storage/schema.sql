@@ -9,7 +9,14 @@ CREATE TABLE IF NOT EXISTS demos (
     tier        TEXT NOT NULL DEFAULT '',
     is_baseline INTEGER NOT NULL DEFAULT 0,
     event_id    TEXT NOT NULL DEFAULT '',
-    quick_hash  TEXT
+    quick_hash  TEXT,
+    pipeline_version INTEGER NOT NULL DEFAULT 0,
+    -- Provenance: where the demo came from (see model.DemoSource).
+    source_origin     TEXT NOT NULL DEFAULT '',
+    source_path       TEXT NOT NULL DEFAULT '',
+    source_url        TEXT NOT NULL DEFAULT '',
+    share_code        TEXT NOT NULL DEFAULT '',
+    external_match_id TEXT NOT NULL DEFAULT ''
 );
 
 CREATE TABLE IF NOT EXISTS player_match_stats (
@@ -92,12 +99,15 @@ CREATE TABLE IF NOT EXISTS player_weapon_stats (
     deaths         INTEGER NOT NULL DEFAULT 0,
     damage         INTEGER NOT NULL DEFAULT 0,
     hits           INTEGER NOT NULL DEFAULT 0,
+    distance_kills INTEGER NOT NULL DEFAULT 0,
+    kill_distance_sum_m REAL NOT NULL DEFAULT 0,
     UNIQUE(demo_hash, steam_id, weapon)
 );
 
 CREATE TABLE IF NOT EXISTS player_duel_segments (
     demo_hash          TEXT NOT NULL REFERENCES demos(hash),
     steam_id           TEXT NOT NULL,
+    round_context      TEXT NOT NULL DEFAULT '',
     weapon_bucket      TEXT NOT NULL,
     distance_bin       TEXT NOT NULL,
     duel_count         INTEGER NOT NULL DEFAULT 0,
@@ -106,9 +116,288 @@ CREATE TABLE IF NOT EXISTS player_duel_segments (
     median_corr_deg    REAL    NOT NULL DEFAULT 0,
     median_sight_deg   REAL    NOT NULL DEFAULT 0,
     median_expo_win_ms REAL    NOT NULL DEFAULT 0,
+    -- first hits split by shooter speed at the first shot (<= 34 u/s = still)
+    still_first_hits    INTEGER NOT NULL DEFAULT 0,
+    still_first_hit_hs  INTEGER NOT NULL DEFAULT 0,
+    moving_first_hits   INTEGER NOT NULL DEFAULT 0,
+    moving_first_hit_hs INTEGER NOT NULL DEFAULT 0,
+    UNIQUE(demo_hash, steam_id, round_context, weapon_bucket, distance_bin)
+);
+
+-- Deaths to enemies per (killer weapon bucket, distance bin) per player per
+-- demo (steam_id = the player who died): the "died to" profile.
+CREATE TABLE IF NOT EXISTS player_death_segments (
+    demo_hash       TEXT NOT NULL REFERENCES demos(hash),
+    steam_id        TEXT NOT NULL,
+    weapon_bucket   TEXT NOT NULL,
+    distance_bin    TEXT NOT NULL,
+    deaths          INTEGER NOT NULL DEFAULT 0,
+    headshot_deaths INTEGER NOT NULL DEFAULT 0,
     UNIQUE(demo_hash, steam_id, weapon_bucket, distance_bin)
 );
 
+-- Deaths to enemies per pre-death context per player per demo: movement at
+-- the killer's first hit in the 3s before the death (moving / holding /
+-- unknown), flashed, isolated and after_kill (had just killed an enemy).
+CREATE TABLE IF NOT EXISTS player_death_contexts (
+    demo_hash  TEXT NOT NULL REFERENCES demos(hash),
+    steam_id   TEXT NOT NULL,
+    movement   TEXT NOT NULL,
+    flashed    INTEGER NOT NULL DEFAULT 0,
+    isolated   INTEGER NOT NULL DEFAULT 0,
+    after_kill INTEGER NOT NULL DEFAULT 0,
+    deaths     INTEGER NOT NULL DEFAULT 0,
+    UNIQUE(demo_hash, steam_id, movement, flashed, isolated, after_kill)
+);
+
+-- Median delay from first sighting an enemy to first damaging them, per
+-- weapon bucket per player per demo (hesitation).
+CREATE TABLE IF NOT EXISTS player_time_to_damage (
+    demo_hash     TEXT NOT NULL REFERENCES demos(hash),
+    steam_id      TEXT NOT NULL,
+    weapon_bucket TEXT NOT NULL,
+    samples       INTEGER NOT NULL DEFAULT 0,
+    median_ms     REAL NOT NULL DEFAULT 0,
+    UNIQUE(demo_hash, steam_id, weapon_bucket)
+);
+
+-- Burst-length histogram (runs of shots ≤ 200ms apart) per weapon bucket
+-- per player per demo.
+CREATE TABLE IF NOT EXISTS player_burst_stats (
+    demo_hash     TEXT NOT NULL REFERENCES demos(hash),
+    steam_id      TEXT NOT NULL,
+    weapon_bucket TEXT NOT NULL,
+    taps          INTEGER NOT NULL DEFAULT 0,
+    short_bursts  INTEGER NOT NULL DEFAULT 0,
+    sprays        INTEGER NOT NULL DEFAULT 0,
+    panic_sprays  INTEGER NOT NULL DEFAULT 0,
+    UNIQUE(demo_hash, steam_id, weapon_bucket)
+);
+
+-- Won duels per whole-meter distance (capped at 60) per weapon bucket per
+-- player per demo; quantile distance bins are cut from these rows.
+CREATE TABLE IF NOT EXISTS player_duel_distances (
+    demo_hash          TEXT NOT NULL REFERENCES demos(hash),
+    steam_id           TEXT NOT NULL,
+    weapon_bucket      TEXT NOT NULL,
+    meters             INTEGER NOT NULL,
+    duel_count         INTEGER NOT NULL DEFAULT 0,
+    first_hit_count    INTEGER NOT NULL DEFAULT 0,
+    first_hit_hs_count INTEGER NOT NULL DEFAULT 0,
+    UNIQUE(demo_hash, steam_id, weapon_bucket, meters)
+);
+
+-- Early-round positions: rounds a player set up at each callout (or grid
+-- cell) per side per demo.
+CREATE TABLE IF NOT EXISTS player_positions (
+    demo_hash TEXT NOT NULL REFERENCES demos(hash),
+    steam_id  TEXT NOT NULL,
+    side      TEXT NOT NULL,
+    place     TEXT NOT NULL,
+    rounds    INTEGER NOT NULL DEFAULT 0,
+    avg_x     REAL    NOT NULL DEFAULT 0,
+    avg_y     REAL    NOT NULL DEFAULT 0,
+    UNIQUE(demo_hash, steam_id, side, place)
+);
+
+-- Raw first-sight events, stored only for players listed in
+-- CSMETRICS_SIGHT_PLAYERS at parse time (steam_id = observer).
+CREATE TABLE IF NOT EXISTS player_first_sights (
+    demo_hash          TEXT NOT NULL REFERENCES demos(hash),
+    steam_id           TEXT NOT NULL,
+    enemy_id           TEXT NOT NULL,
+    round_number       INTEGER NOT NULL,
+    tick               INTEGER NOT NULL,
+    angle_deg          REAL    NOT NULL DEFAULT 0,
+    pitch_deg          REAL    NOT NULL DEFAULT 0,
+    yaw_deg            REAL    NOT NULL DEFAULT 0,
+    observer_pitch_deg REAL    NOT NULL DEFAULT 0,
+    observer_yaw_deg   REAL    NOT NULL DEFAULT 0,
+    UNIQUE(demo_hash, steam_id, enemy_id, round_number)
+);
+
+-- Round state before every kill in a decided round; fits the round
+-- win-probability model and credits win-probability-added per player.
+CREATE TABLE IF NOT EXISTS round_kill_states (
+    demo_hash      TEXT NOT NULL REFERENCES demos(hash),
+    round_number   INTEGER NOT NULL,
+    tick           INTEGER NOT NULL,
+    killer_id      TEXT NOT NULL,
+    victim_id      TEXT NOT NULL,
+    killer_team    TEXT NOT NULL,
+    victim_team    TEXT NOT NULL,
+    ct_alive       INTEGER NOT NULL,
+    t_alive        INTEGER NOT NULL,
+    bomb_planted   INTEGER NOT NULL DEFAULT 0,
+    winner_team    TEXT NOT NULL,
+    UNIQUE(demo_hash, round_number, tick, victim_id)
+);
+
+-- Each side's summed freeze-end equipment value and economy class
+-- (pistol/full-eco/semi-eco/force/full-buy) per decided round.
+CREATE TABLE IF NOT EXISTS team_round_economy (
+    demo_hash    TEXT NOT NULL REFERENCES demos(hash),
+    round_number INTEGER NOT NULL,
+    team         TEXT NOT NULL,
+    players      INTEGER NOT NULL DEFAULT 0,
+    equip_value  INTEGER NOT NULL DEFAULT 0,
+    round_type   TEXT NOT NULL,
+    won          INTEGER NOT NULL DEFAULT 0,
+    UNIQUE(demo_hash, round_number, team)
+);
+
+-- Round-by-round event stream (round boundaries, kills with positions,
+-- flashes, plants, defuses) for `timeline`; seq orders events within a demo.
+CREATE TABLE IF NOT EXISTS round_events (
+    demo_hash    TEXT NOT NULL REFERENCES demos(hash),
+    seq          INTEGER NOT NULL,
+    round_number INTEGER NOT NULL,
+    tick         INTEGER NOT NULL,
+    type         TEXT NOT NULL,
+    actor_id     TEXT NOT NULL DEFAULT '0',
+    target_id    TEXT NOT NULL DEFAULT '0',
+    actor_team   TEXT NOT NULL DEFAULT '',
+    target_team  TEXT NOT NULL DEFAULT '',
+    actor_x      REAL NOT NULL DEFAULT 0,
+    actor_y      REAL NOT NULL DEFAULT 0,
+    actor_z      REAL NOT NULL DEFAULT 0,
+    target_x     REAL NOT NULL DEFAULT 0,
+    target_y     REAL NOT NULL DEFAULT 0,
+    target_z     REAL NOT NULL DEFAULT 0,
+    weapon       TEXT NOT NULL DEFAULT '',
+    headshot     INTEGER NOT NULL DEFAULT 0,
+    duration_sec REAL NOT NULL DEFAULT 0,
+    detail       TEXT NOT NULL DEFAULT '',
+    UNIQUE(demo_hash, seq)
+);
+
+-- Trade chains: runs of enemy kills in a round linked by trades, re-trades and
+-- multi-kills within the trade window, with each side's kills in the chain.
+CREATE TABLE IF NOT EXISTS round_trade_chains (
+    demo_hash    TEXT NOT NULL REFERENCES demos(hash),
+    round_number INTEGER NOT NULL,
+    chain_index  INTEGER NOT NULL,
+    start_tick   INTEGER NOT NULL,
+    end_tick     INTEGER NOT NULL,
+    kills        INTEGER NOT NULL DEFAULT 0,
+    trades       INTEGER NOT NULL DEFAULT 0,
+    first_team   TEXT NOT NULL DEFAULT '',
+    ct_kills     INTEGER NOT NULL DEFAULT 0,
+    t_kills      INTEGER NOT NULL DEFAULT 0,
+    UNIQUE(demo_hash, round_number, chain_index)
+);
+
+-- Diagnostics: weapon names seen in a demo that the weapon bucket table
+-- doesn't know (grouped under "Other"), with how many events used them.
+CREATE TABLE IF NOT EXISTS demo_unmapped_weapons (
+    demo_hash TEXT NOT NULL REFERENCES demos(hash),
+    weapon    TEXT NOT NULL,
+    events    INTEGER NOT NULL DEFAULT 0,
+    UNIQUE(demo_hash, weapon)
+);
+
+-- Diagnostics: raw event counts of each parsed demo. Few weapon fires or
+-- first sights per round compared with other demos signal a parser problem.
+CREATE TABLE IF NOT EXISTS demo_diagnostics (
+    demo_hash        TEXT NOT NULL UNIQUE REFERENCES demos(hash),
+    rounds           INTEGER NOT NULL DEFAULT 0,
+    kills            INTEGER NOT NULL DEFAULT 0,
+    damages          INTEGER NOT NULL DEFAULT 0,
+    flashes          INTEGER NOT NULL DEFAULT 0,
+    weapon_fires     INTEGER NOT NULL DEFAULT 0,
+    first_sights     INTEGER NOT NULL DEFAULT 0,
+    position_samples INTEGER NOT NULL DEFAULT 0,
+    pre_live_rounds  INTEGER NOT NULL DEFAULT 0
+);
+
+-- Diagnostics: wall time of the parse and each aggregation pass of a demo,
+-- in run order (seq). Machine-dependent; ignored when comparing merges.
+CREATE TABLE IF NOT EXISTS demo_pass_timings (
+    demo_hash TEXT NOT NULL REFERENCES demos(hash),
+    seq       INTEGER NOT NULL,
+    pass      TEXT NOT NULL,
+    ms        REAL NOT NULL DEFAULT 0,
+    UNIQUE(demo_hash, seq)
+);
+
+-- Baseline corpus quotas for "baseline build": target demo count per tier.
+-- Progress is the number of is_baseline demos stored with that tier.
+CREATE TABLE IF NOT EXISTS baseline_quotas (
+    tier       TEXT PRIMARY KEY,
+    target     INTEGER NOT NULL,
+    updated_at TEXT NOT NULL
+);
+
+-- Demo sources tried by "baseline build" ("faceit:<match id>" or
+-- "file:<quick hash>"), so a match shared by several anchor players or a
+-- file seen on a previous run is not fetched or parsed twice. Failed sources
+-- are retried; every other status is final.
+CREATE TABLE IF NOT EXISTS baseline_sources (
+    source_id  TEXT PRIMARY KEY,
+    tier       TEXT NOT NULL,
+    anchor     TEXT NOT NULL DEFAULT '',
+    status     TEXT NOT NULL,                 -- stored | duplicate | skipped | failed
+    demo_hash  TEXT NOT NULL DEFAULT '',
+    detail     TEXT NOT NULL DEFAULT '',
+    updated_at TEXT NOT NULL
+);
+
+-- Soft-deleted demos ("db delete"). The demos row and every child stats row
+-- are kept as JSON ({"<table>": [{"<column>": value, ...}, ...]}) until
+-- "db restore" puts them back or "db purge" drops them for good. Read paths
+-- never see trashed demos because their rows are gone from the live tables.
+CREATE TABLE IF NOT EXISTS demo_trash (
+    hash             TEXT PRIMARY KEY,
+    map_name         TEXT NOT NULL DEFAULT '',
+    match_date       TEXT NOT NULL DEFAULT '',
+    pipeline_version INTEGER NOT NULL DEFAULT 0,
+    row_count        INTEGER NOT NULL DEFAULT 0,  -- child stats rows in the snapshot
+    deleted_at       TEXT NOT NULL,
+    deleted_by       TEXT NOT NULL DEFAULT '',
+    snapshot         TEXT NOT NULL
+);
+
+-- Append-only log of demo-level writes. pipeline_version is the version of
+-- the demo row the action produced (or removed, for delete and purge).
+CREATE TABLE IF NOT EXISTS demo_audit (
+    id               INTEGER PRIMARY KEY AUTOINCREMENT,
+    at               TEXT NOT NULL,
+    action           TEXT NOT NULL,               -- insert | replace | retag | merge | delete | restore | purge
+    demo_hash        TEXT NOT NULL,
+    pipeline_version INTEGER NOT NULL DEFAULT 0,
+    actor            TEXT NOT NULL DEFAULT '',
+    detail           TEXT NOT NULL DEFAULT ''
+);
+
+-- Cached per-player report data (player, analyze player) keyed by a hash of
+-- the command and its filters and by the pipeline version that computed it.
+-- The triggers below drop a player's entries whenever one of their
+-- player_match_stats rows is inserted, deleted or updated (parse, merge,
+-- delete, restore, SQL backfills), so a hit is never older than the data.
+CREATE TABLE IF NOT EXISTS player_aggregate_cache (
+    steam_id         TEXT NOT NULL,
+    filters_hash     TEXT NOT NULL,
+    pipeline_version INTEGER NOT NULL,
+    payload          TEXT NOT NULL,               -- JSON, shape owned by the command
+    created_at       TEXT NOT NULL,
+    PRIMARY KEY (steam_id, filters_hash, pipeline_version)
+);
+
+CREATE TRIGGER IF NOT EXISTS trg_pms_insert_cache AFTER INSERT ON player_match_stats
+BEGIN
+    DELETE FROM player_aggregate_cache WHERE steam_id = NEW.steam_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_pms_delete_cache AFTER DELETE ON player_match_stats
+BEGIN
+    DELETE FROM player_aggregate_cache WHERE steam_id = OLD.steam_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_pms_update_cache AFTER UPDATE ON player_match_stats
+BEGIN
+    DELETE FROM player_aggregate_cache WHERE steam_id IN (OLD.steam_id, NEW.steam_id);
+END;
+
 -- Indexes for common query patterns (safe to apply to existing databases).
 CREATE INDEX IF NOT EXISTS idx_demos_match_date       ON demos(match_date);
 CREATE INDEX IF NOT EXISTS idx_pms_steam_id           ON player_match_stats(steam_id);
@@ -117,3 +406,24 @@ CREATE INDEX IF NOT EXISTS idx_prs_steam_id           ON player_round_stats(stea
 CREATE INDEX IF NOT EXISTS idx_prs_demo_hash          ON player_round_stats(demo_hash);
 CREATE INDEX IF NOT EXISTS idx_pds_steam_id           ON player_duel_segments(steam_id);
 CREATE INDEX IF NOT EXISTS idx_pds_demo_hash          ON player_duel_segments(demo_hash);
+CREATE INDEX IF NOT EXISTS idx_pdths_steam_id         ON player_death_segments(steam_id);
+CREATE INDEX IF NOT EXISTS idx_pdths_demo_hash        ON player_death_segments(demo_hash);
+CREATE INDEX IF NOT EXISTS idx_pdc_steam_id           ON player_death_contexts(steam_id);
+CREATE INDEX IF NOT EXISTS idx_pdc_demo_hash          ON player_death_contexts(demo_hash);
+CREATE INDEX IF NOT EXISTS idx_pttd_steam_id          ON player_time_to_damage(steam_id);
+CREATE INDEX IF NOT EXISTS idx_pttd_demo_hash         ON player_time_to_damage(demo_hash);
+CREATE INDEX IF NOT EXISTS idx_pbs_steam_id           ON player_burst_stats(steam_id);
+CREATE INDEX IF NOT EXISTS idx_pbs_demo_hash          ON player_burst_stats(demo_hash);
+CREATE INDEX IF NOT EXISTS idx_pdd_steam_id           ON player_duel_distances(steam_id);
+CREATE INDEX IF NOT EXISTS idx_pdd_demo_hash          ON player_duel_distances(demo_hash);
+CREATE INDEX IF NOT EXISTS idx_ppos_steam_id          ON player_positions(steam_id);
+CREATE INDEX IF NOT EXISTS idx_ppos_demo_hash         ON player_positions(demo_hash);
+CREATE INDEX IF NOT EXISTS idx_pfs_demo_hash          ON player_first_sights(demo_hash);
+CREATE INDEX IF NOT EXISTS idx_rks_demo_hash          ON round_kill_states(demo_hash);
+CREATE INDEX IF NOT EXISTS idx_rev_demo_hash          ON round_events(demo_hash);
+CREATE INDEX IF NOT EXISTS idx_rtc_demo_hash          ON round_trade_chains(demo_hash);
+CREATE INDEX IF NOT EXISTS idx_tre_demo_hash          ON team_round_economy(demo_hash);
+CREATE INDEX IF NOT EXISTS idx_duw_demo_hash          ON demo_unmapped_weapons(demo_hash);
+CREATE INDEX IF NOT EXISTS idx_dpt_demo_hash          ON demo_pass_timings(demo_hash);
+CREATE INDEX IF NOT EXISTS idx_bsrc_tier              ON baseline_sources(tier);
+CREATE INDEX IF NOT EXISTS idx_audit_demo_hash        ON demo_audit(demo_hash);
@@ -6,6 +6,7 @@ import (
 	_ "embed"
 	"fmt"
 	"strings"
+	"sync"
 
 	_ "modernc.org/sqlite"
 )
@@ -13,18 +14,38 @@ import (
 //go:embed schema.sql
 var schemaSQL string
 
-// DB wraps a sql.DB for the metrics store.
+// busyTimeoutMs is how long a write waits for another process (a second CLI
+// run, a daemon) holding the database write lock before failing with
+// SQLITE_BUSY.
+const busyTimeoutMs = 10000
+
+// DB wraps a sql.DB for the metrics store. It is safe for concurrent use:
+// reads run in parallel on the connection pool, while write transactions are
+// queued on writeMu so goroutines never contend for SQLite's single write
+// lock. Other processes sharing the file are waited for via busy_timeout.
 type DB struct {
-	conn *sql.DB
+	conn    *sql.DB
+	writeMu sync.Mutex
+	actor   string // OS user recorded in the audit log
 }
 
 // Open opens (or creates) the SQLite database at the given path and applies the schema.
+//
+// Every pooled connection uses WAL journaling (readers never block the writer
+// and vice versa), a busy timeout for cross-process writers, and BEGIN
+// IMMEDIATE transactions so a writer takes the lock up front instead of
+// failing on a read-to-write upgrade. Foreign keys are declared but not
+// enforced. An in-memory database is private to its connection, so ":memory:"
+// is limited to a single pooled connection.
 func Open(path string) (*DB, error) {
-	dsn := fmt.Sprintf("file:%s?_foreign_keys=on&_journal_mode=WAL", path)
+	dsn := fmt.Sprintf("file:%s?_pragma=journal_mode(WAL)&_pragma=busy_timeout(%d)&_txlock=immediate", path, busyTimeoutMs)
 	conn, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("open db: %w", err)
 	}
+	if path == ":memory:" {
+		conn.SetMaxOpenConns(1)
+	}
 	if _, err := conn.Exec(schemaSQL); err != nil {
 		conn.Close()
 		return nil, fmt.Errorf("apply schema: %w", err)
@@ -47,12 +68,117 @@ func Open(path string) (*DB, error) {
 		`ALTER TABLE player_round_stats ADD COLUMN clutch_enemy_count INTEGER NOT NULL DEFAULT 0`,
 		`ALTER TABLE player_match_stats ADD COLUMN counter_strafe_pct REAL NOT NULL DEFAULT 0`,
 		`ALTER TABLE player_round_stats ADD COLUMN won_round INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_round_stats ADD COLUMN end_reason TEXT NOT NULL DEFAULT ''`,
 		`ALTER TABLE player_match_stats ADD COLUMN rounds_won INTEGER NOT NULL DEFAULT 0`,
 		`ALTER TABLE player_match_stats ADD COLUMN median_trade_kill_delay_ms REAL NOT NULL DEFAULT 0`,
 		`ALTER TABLE player_match_stats ADD COLUMN median_trade_death_delay_ms REAL NOT NULL DEFAULT 0`,
 		`ALTER TABLE demos ADD COLUMN event_id TEXT NOT NULL DEFAULT ''`,
 		`ALTER TABLE demos ADD COLUMN quick_hash TEXT`,
 		`CREATE INDEX IF NOT EXISTS idx_demos_quick_hash ON demos(quick_hash) WHERE quick_hash IS NOT NULL`,
+		`ALTER TABLE demos ADD COLUMN pipeline_version INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN pipeline_version INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN low_hp_handed INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN low_hp_wasted INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN median_spotted_before_death_ms REAL NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN defuses INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN ninja_defuses INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN plant_denials INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN death_speed_samples INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN moving_deaths INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN collateral_kills INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN spray_transfer_kills INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN team_conflict_rounds INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN time_to_damage_samples INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN median_time_to_damage_ms REAL NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN awp_shots INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN awp_shot_kills INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN awp_shot_body_hits INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN awp_rounds_faced INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN utility_damage_taken INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN flashes_received INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN blind_time_received_sec REAL NOT NULL DEFAULT 0`,
+		`ALTER TABLE round_kill_states ADD COLUMN weapon TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE player_match_stats ADD COLUMN burst_taps INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN burst_short INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN burst_spray INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN burst_panic INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE round_kill_states ADD COLUMN clock_remaining_sec REAL NOT NULL DEFAULT -1`,
+		`ALTER TABLE player_match_stats ADD COLUMN late_round_deaths INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN play_for_time_rounds INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN play_for_time_deaths INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN longest_win_streak INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN streak_rounds INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN streak_kills INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN streak_damage INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN bounce_rounds INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN bounce_kills INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN bounce_damage INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN bounce_wins INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN half_first_kills INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN played_off_flash_kills INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN played_off_smoke_kills INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN played_off_utility_kills INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_round_stats ADD COLUMN deaths INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_round_stats ADD COLUMN death_tick INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_round_stats ADD COLUMN death_sec REAL NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_round_stats ADD COLUMN clutch_start_tick INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_round_stats ADD COLUMN clutch_start_sec REAL NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_round_stats ADD COLUMN clutch_enemies TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE player_match_stats ADD COLUMN leading_rounds INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN leading_kills INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN leading_deaths INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN leading_damage INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN tied_rounds INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN tied_kills INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN tied_deaths INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN tied_damage INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN trailing_rounds INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN trailing_kills INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN trailing_deaths INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN trailing_damage INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN equipment_value INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN peek_duels INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN peek_wins INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN hold_duels INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN hold_wins INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN assisted_duel_wins INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN assisted_duel_losses INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN primary_deaths INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN wrong_weapon_deaths INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN loss_flashed INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN loss_isolated INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN loss_util_support INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN loss_repeek INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN loss_explained INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN bullet_hits INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN head_hits INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN util_throws_early INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN util_throws_mid INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN util_throws_late INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN median_util_throw_sec REAL NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN eco_rounds INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN eco_damage INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN buy_rounds INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN buy_damage INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN unspotted_deaths INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN spotted_deaths INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN ping_samples INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN avg_ping_ms REAL NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN max_ping_ms INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_match_stats ADD COLUMN kast_strict_rounds INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE demo_diagnostics ADD COLUMN server_frame_drop_pct REAL NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_weapon_stats ADD COLUMN distance_kills INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_weapon_stats ADD COLUMN kill_distance_sum_m REAL NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_weapon_stats ADD COLUMN head_hits INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_duel_segments ADD COLUMN still_first_hits INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_duel_segments ADD COLUMN still_first_hit_hs INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_duel_segments ADD COLUMN moving_first_hits INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE player_duel_segments ADD COLUMN moving_first_hit_hs INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE demos ADD COLUMN source_origin TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE demos ADD COLUMN source_path TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE demos ADD COLUMN source_url TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE demos ADD COLUMN share_code TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE demos ADD COLUMN external_match_id TEXT NOT NULL DEFAULT ''`,
 	}
 	for _, stmt := range altMigrations {
 		if _, err := conn.Exec(stmt); err != nil && !strings.Contains(err.Error(), "duplicate column") {
@@ -60,10 +186,85 @@ func Open(path string) (*DB, error) {
 			return nil, fmt.Errorf("migration: %w", err)
 		}
 	}
-	return &DB{conn: conn}, nil
+	if err := migrateDuelSegmentContext(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("migration: %w", err)
+	}
+	return &DB{conn: conn, actor: currentActor()}, nil
+}
+
+// migrateDuelSegmentContext rebuilds player_duel_segments from before round
+// contexts: round_context is part of the UNIQUE key, which ALTER TABLE cannot
+// change. Existing rows keep an empty round_context until their demo is
+// re-parsed. The column check runs inside the transaction so two processes
+// opening an old database at once rebuild it only once.
+func migrateDuelSegmentContext(conn *sql.DB) error {
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var n int
+	if err := tx.QueryRow(`SELECT COUNT(1) FROM pragma_table_info('player_duel_segments') WHERE name = 'round_context'`).Scan(&n); err != nil {
+		return fmt.Errorf("inspect player_duel_segments: %w", err)
+	}
+	if n > 0 {
+		return nil
+	}
+	const cols = `demo_hash, steam_id, weapon_bucket, distance_bin,
+		duel_count, first_hit_count, first_hit_hs_count,
+		median_corr_deg, median_sight_deg, median_expo_win_ms`
+	stmts := []string{
+		`CREATE TABLE player_duel_segments_new (
+			demo_hash          TEXT NOT NULL REFERENCES demos(hash),
+			steam_id           TEXT NOT NULL,
+			round_context      TEXT NOT NULL DEFAULT '',
+			weapon_bucket      TEXT NOT NULL,
+			distance_bin       TEXT NOT NULL,
+			duel_count         INTEGER NOT NULL DEFAULT 0,
+			first_hit_count    INTEGER NOT NULL DEFAULT 0,
+			first_hit_hs_count INTEGER NOT NULL DEFAULT 0,
+			median_corr_deg    REAL    NOT NULL DEFAULT 0,
+			median_sight_deg   REAL    NOT NULL DEFAULT 0,
+			median_expo_win_ms REAL    NOT NULL DEFAULT 0,
+			still_first_hits    INTEGER NOT NULL DEFAULT 0,
+			still_first_hit_hs  INTEGER NOT NULL DEFAULT 0,
+			moving_first_hits   INTEGER NOT NULL DEFAULT 0,
+			moving_first_hit_hs INTEGER NOT NULL DEFAULT 0,
+			UNIQUE(demo_hash, steam_id, round_context, weapon_bucket, distance_bin)
+		)`,
+		`INSERT INTO player_duel_segments_new(` + cols + `) SELECT ` + cols + ` FROM player_duel_segments`,
+		`DROP TABLE player_duel_segments`,
+		`ALTER TABLE player_duel_segments_new RENAME TO player_duel_segments`,
+		`CREATE INDEX IF NOT EXISTS idx_pds_steam_id  ON player_duel_segments(steam_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_pds_demo_hash ON player_duel_segments(demo_hash)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("rebuild player_duel_segments: %w", err)
+		}
+	}
+	return tx.Commit()
 }
 
 // Close closes the underlying connection.
 func (db *DB) Close() error {
 	return db.conn.Close()
 }
+
+// withTx runs fn in a write transaction, committing if it returns nil and
+// rolling back otherwise. Write transactions of this process run one at a time.
+func (db *DB) withTx(fn func(*sql.Tx) error) error {
+	db.writeMu.Lock()
+	defer db.writeMu.Unlock()
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
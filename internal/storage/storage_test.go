@@ -1,8 +1,16 @@
 package storage
 
 import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/pable/go-cs-metrics/internal/aggregator"
 	"github.com/pable/go-cs-metrics/internal/model"
 )
 
@@ -29,7 +37,7 @@ func TestDemoInsertAndExists(t *testing.T) {
 		TScore:    10,
 	}
 
-	if err := db.InsertDemo(summary); err != nil {
+	if err := db.InsertDemo(summary, ""); err != nil {
 		t.Fatalf("InsertDemo: %v", err)
 	}
 
@@ -55,12 +63,12 @@ func TestListDemos(t *testing.T) {
 		{DemoHash: "h2", MapName: "de_mirage", MatchDate: "2025-02-01", MatchType: "Premier", Tickrate: 128},
 	}
 	for _, s := range summaries {
-		if err := db.InsertDemo(s); err != nil {
+		if err := db.InsertDemo(s, ""); err != nil {
 			t.Fatalf("InsertDemo: %v", err)
 		}
 	}
 
-	list, err := db.ListDemos()
+	list, err := db.ListDemos(DemoFilter{})
 	if err != nil {
 		t.Fatalf("ListDemos: %v", err)
 	}
@@ -76,7 +84,7 @@ func TestListDemos(t *testing.T) {
 func TestGetDemoByPrefix(t *testing.T) {
 	db := openMemDB(t)
 
-	db.InsertDemo(model.MatchSummary{DemoHash: "deadbeef1234", MapName: "de_inferno", MatchDate: "2025-01-01", MatchType: "Wingman", Tickrate: 64})
+	db.InsertDemo(model.MatchSummary{DemoHash: "deadbeef1234", MapName: "de_inferno", MatchDate: "2025-01-01", MatchType: "Wingman", Tickrate: 64}, "")
 
 	s, err := db.GetDemoByPrefix("deadb")
 	if err != nil {
@@ -101,7 +109,7 @@ func TestGetDemoByPrefix(t *testing.T) {
 func TestPlayerMatchStatsRoundTrip(t *testing.T) {
 	db := openMemDB(t)
 
-	db.InsertDemo(model.MatchSummary{DemoHash: "h1", MapName: "de_dust2", MatchDate: "2025-01-01", MatchType: "Competitive", Tickrate: 64})
+	db.InsertDemo(model.MatchSummary{DemoHash: "h1", MapName: "de_dust2", MatchDate: "2025-01-01", MatchType: "Competitive", Tickrate: 64}, "")
 
 	stats := []model.PlayerMatchStats{
 		{
@@ -111,6 +119,20 @@ func TestPlayerMatchStatsRoundTrip(t *testing.T) {
 			OpeningKills: 4, OpeningDeaths: 2, TradeKills: 3, TradeDeaths: 1,
 			KASTRounds: 18, UnusedUtility: 5,
 			CrosshairEncounters: 12, CrosshairMedianDeg: 4.3, CrosshairPctUnder5: 58.3,
+			Leading:        model.ScorelineSplit{Rounds: 10, Kills: 9, Deaths: 5, Damage: 1100},
+			Tied:           model.ScorelineSplit{Rounds: 5, Kills: 4, Deaths: 3, Damage: 520},
+			Trailing:       model.ScorelineSplit{Rounds: 10, Kills: 7, Deaths: 7, Damage: 880},
+			EquipmentValue: 98750,
+			PeekDuels:      6, PeekWins: 4, HoldDuels: 5, HoldWins: 2,
+			DuelWins: 9, DuelLosses: 7, AssistedDuelWins: 3, AssistedDuelLosses: 2,
+			PrimaryDeaths: 14, WrongWeaponDeaths: 3,
+			LossFlashed: 2, LossIsolated: 4, LossUtilSupport: 1, LossRePeek: 3, LossExplained: 6,
+			BulletHits: 48, HeadHits: 13,
+			UtilThrowsEarly: 11, UtilThrowsMid: 7, UtilThrowsLate: 4, MedianUtilThrowSec: 21.5,
+			EcoRounds: 6, EcoDamage: 820, BuyRounds: 15, BuyDamage: 1130,
+			UnspottedDeaths: 4, SpottedDeaths: 12,
+			PingSamples: 290, AvgPingMs: 42.5, MaxPingMs: 87,
+			KASTStrictRounds: 15,
 		},
 		{
 			DemoHash: "h1", SteamID: 76561198000000002, Name: "Bob", Team: model.TeamT,
@@ -159,6 +181,83 @@ func TestPlayerMatchStatsRoundTrip(t *testing.T) {
 	if alice.CrosshairPctUnder5 != 58.3 {
 		t.Errorf("Alice CrosshairPctUnder5: want 58.3, got %f", alice.CrosshairPctUnder5)
 	}
+	if alice.Leading != stats[0].Leading || alice.Tied != stats[0].Tied || alice.Trailing != stats[0].Trailing {
+		t.Errorf("Alice scoreline splits = %+v / %+v / %+v, want %+v / %+v / %+v",
+			alice.Leading, alice.Tied, alice.Trailing, stats[0].Leading, stats[0].Tied, stats[0].Trailing)
+	}
+	if alice.EquipmentValue != 98750 {
+		t.Errorf("Alice EquipmentValue: want 98750, got %d", alice.EquipmentValue)
+	}
+	if alice.PeekDuels != 6 || alice.PeekWins != 4 || alice.HoldDuels != 5 || alice.HoldWins != 2 {
+		t.Errorf("Alice peek/hold = %d/%d %d/%d, want 6/4 5/2", alice.PeekDuels, alice.PeekWins, alice.HoldDuels, alice.HoldWins)
+	}
+	if alice.AssistedDuelWins != 3 || alice.AssistedDuelLosses != 2 {
+		t.Errorf("Alice assisted duels = %d/%d, want 3/2", alice.AssistedDuelWins, alice.AssistedDuelLosses)
+	}
+	if alice.PrimaryDeaths != 14 || alice.WrongWeaponDeaths != 3 {
+		t.Errorf("Alice primary/wrong deaths = %d/%d, want 14/3", alice.PrimaryDeaths, alice.WrongWeaponDeaths)
+	}
+	if alice.LossFlashed != 2 || alice.LossIsolated != 4 || alice.LossUtilSupport != 1 || alice.LossRePeek != 3 || alice.LossExplained != 6 {
+		t.Errorf("Alice loss reasons = %d/%d/%d/%d/%d, want 2/4/1/3/6",
+			alice.LossFlashed, alice.LossIsolated, alice.LossUtilSupport, alice.LossRePeek, alice.LossExplained)
+	}
+	if alice.BulletHits != 48 || alice.HeadHits != 13 {
+		t.Errorf("Alice bullet/head hits = %d/%d, want 48/13", alice.BulletHits, alice.HeadHits)
+	}
+	if alice.UtilThrowsEarly != 11 || alice.UtilThrowsMid != 7 || alice.UtilThrowsLate != 4 || alice.MedianUtilThrowSec != 21.5 {
+		t.Errorf("Alice utility timing = %d/%d/%d, median %v; want 11/7/4, median 21.5",
+			alice.UtilThrowsEarly, alice.UtilThrowsMid, alice.UtilThrowsLate, alice.MedianUtilThrowSec)
+	}
+	if alice.EcoRounds != 6 || alice.EcoDamage != 820 || alice.BuyRounds != 15 || alice.BuyDamage != 1130 {
+		t.Errorf("Alice eco/buy split = %d rounds %d dmg / %d rounds %d dmg; want 6/820 / 15/1130",
+			alice.EcoRounds, alice.EcoDamage, alice.BuyRounds, alice.BuyDamage)
+	}
+	if alice.UnspottedDeaths != 4 || alice.SpottedDeaths != 12 {
+		t.Errorf("Alice unspotted/spotted deaths = %d/%d; want 4/12", alice.UnspottedDeaths, alice.SpottedDeaths)
+	}
+	if alice.PingSamples != 290 || alice.AvgPingMs != 42.5 || alice.MaxPingMs != 87 {
+		t.Errorf("Alice ping = %d samples avg %.1f max %d; want 290/42.5/87", alice.PingSamples, alice.AvgPingMs, alice.MaxPingMs)
+	}
+	if alice.KASTStrictRounds != 15 {
+		t.Errorf("Alice KASTStrictRounds = %d; want 15", alice.KASTStrictRounds)
+	}
+	all, err := db.GetAllPlayerMatchStats(76561198000000001)
+	if err != nil || len(all) != 1 || all[0].Trailing != stats[0].Trailing || all[0].EquipmentValue != 98750 ||
+		all[0].AssistedDuelWins != 3 || all[0].AssistedDuelLosses != 2 || all[0].WrongWeaponDeaths != 3 || all[0].LossExplained != 6 ||
+		all[0].BulletHits != 48 || all[0].HeadHits != 13 || all[0].UtilThrowsLate != 4 || all[0].MedianUtilThrowSec != 21.5 ||
+		all[0].EcoDamage != 820 || all[0].BuyRounds != 15 || all[0].UnspottedDeaths != 4 || all[0].SpottedDeaths != 12 ||
+		all[0].PingSamples != 290 || all[0].AvgPingMs != 42.5 || all[0].MaxPingMs != 87 || all[0].KASTStrictRounds != 15 {
+		t.Errorf("GetAllPlayerMatchStats = %+v, %v; want one row with trailing %+v and equipment 98750", all, err, stats[0].Trailing)
+	}
+}
+
+func TestPeekerBaselines(t *testing.T) {
+	db := openMemDB(t)
+	db.InsertDemo(model.MatchSummary{DemoHash: "p1", MapName: "de_mirage", MatchDate: "2025-01-01", MatchType: "Scrim", Tickrate: 64, Tier: "pro"}, "")
+	db.InsertDemo(model.MatchSummary{DemoHash: "p2", MapName: "de_mirage", MatchDate: "2025-01-02", MatchType: "Scrim", Tickrate: 64, Tier: "pro"}, "")
+	db.InsertDemo(model.MatchSummary{DemoHash: "f1", MapName: "de_mirage", MatchDate: "2025-01-03", MatchType: "FACEIT", Tickrate: 64}, "")
+	db.InsertDemo(model.MatchSummary{DemoHash: "x1", MapName: "de_mirage", MatchDate: "2025-01-04", MatchType: "Scrim", Tickrate: 64, Tier: "semi"}, "")
+	if err := db.InsertPlayerMatchStats([]model.PlayerMatchStats{
+		{DemoHash: "p1", SteamID: 1, PeekDuels: 10, PeekWins: 6, HoldDuels: 4, HoldWins: 2},
+		{DemoHash: "p1", SteamID: 2, PeekDuels: 4, PeekWins: 2, HoldDuels: 10, HoldWins: 4},
+		{DemoHash: "p2", SteamID: 1, PeekDuels: 6, PeekWins: 2},
+		{DemoHash: "f1", SteamID: 3, PeekDuels: 5, PeekWins: 3},
+		{DemoHash: "x1", SteamID: 4, HoldDuels: 3, HoldWins: 1}, // no peek duels: tier dropped
+	}); err != nil {
+		t.Fatalf("InsertPlayerMatchStats: %v", err)
+	}
+
+	got, err := db.GetPeekerBaselines()
+	if err != nil {
+		t.Fatalf("GetPeekerBaselines: %v", err)
+	}
+	want := []model.PeekerBaseline{
+		{Tier: "", Duels: 5, PeekerWins: 3},
+		{Tier: "pro", Duels: 20, PeekerWins: 10},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetPeekerBaselines = %+v, want %+v", got, want)
+	}
 }
 
 func TestMapNameNormalization(t *testing.T) {
@@ -189,7 +288,7 @@ func TestMapNameNormalization(t *testing.T) {
 				MatchDate: "2025-01-01",
 				MatchType: "pro",
 				Tickrate:  128,
-			}); err != nil {
+			}, ""); err != nil {
 				t.Fatalf("InsertDemo: %v", err)
 			}
 
@@ -226,9 +325,1690 @@ func TestInsertIdempotency(t *testing.T) {
 	db := openMemDB(t)
 
 	s := model.MatchSummary{DemoHash: "idem1", MapName: "de_nuke", MatchDate: "2025-01-01", MatchType: "Competitive", Tickrate: 64}
-	db.InsertDemo(s)
+	db.InsertDemo(s, "")
 	// Second insert should not error (INSERT OR REPLACE).
-	if err := db.InsertDemo(s); err != nil {
+	if err := db.InsertDemo(s, ""); err != nil {
 		t.Errorf("second InsertDemo should succeed (idempotent): %v", err)
 	}
 }
+
+func TestReplaceDemo(t *testing.T) {
+	db := openMemDB(t)
+
+	s := model.MatchSummary{DemoHash: "repl1", MapName: "de_inferno", MatchDate: "2025-01-01", MatchType: "Competitive", Tickrate: 64, PipelineVersion: 1}
+	old := DemoData{
+		Summary: s,
+		MatchStats: []model.PlayerMatchStats{
+			{DemoHash: "repl1", SteamID: 1, Name: "a", Kills: 10, PipelineVersion: 1},
+			{DemoHash: "repl1", SteamID: 2, Name: "b", Kills: 5, PipelineVersion: 1},
+		},
+		RoundStats: []model.PlayerRoundStats{{DemoHash: "repl1", SteamID: 2, RoundNumber: 1}},
+	}
+	if err := db.ReplaceDemo(old); err != nil {
+		t.Fatalf("ReplaceDemo (new): %v", err)
+	}
+	if v, found, err := db.DemoPipelineVersion("repl1"); err != nil || !found || v != 1 {
+		t.Fatalf("DemoPipelineVersion = %d, %v, %v; want 1, true, nil", v, found, err)
+	}
+
+	s.PipelineVersion = 2
+	s.CTScore = 13
+	fresh := DemoData{
+		Summary:    s,
+		MatchStats: []model.PlayerMatchStats{{DemoHash: "repl1", SteamID: 1, Name: "a", Kills: 12, PipelineVersion: 2}},
+	}
+	if err := db.ReplaceDemo(fresh); err != nil {
+		t.Fatalf("ReplaceDemo (replace): %v", err)
+	}
+
+	stats, err := db.GetPlayerMatchStats("repl1")
+	if err != nil {
+		t.Fatalf("GetPlayerMatchStats: %v", err)
+	}
+	if len(stats) != 1 || stats[0].Kills != 12 {
+		t.Errorf("got %d rows %+v, want only the replaced row with 12 kills", len(stats), stats)
+	}
+	rounds, err := db.GetPlayerRoundStats("repl1", 2)
+	if err != nil {
+		t.Fatalf("GetPlayerRoundStats: %v", err)
+	}
+	if len(rounds) != 0 {
+		t.Errorf("stale round rows survived replace: %d", len(rounds))
+	}
+	demo, err := db.GetDemoByPrefix("repl1")
+	if err != nil || demo == nil || demo.CTScore != 13 {
+		t.Errorf("demo row not replaced: %+v, %v", demo, err)
+	}
+	if v, _, _ := db.DemoPipelineVersion("repl1"); v != 2 {
+		t.Errorf("DemoPipelineVersion after replace = %d, want 2", v)
+	}
+	if _, found, err := db.DemoPipelineVersion("missing"); err != nil || found {
+		t.Errorf("DemoPipelineVersion(missing) found=%v err=%v, want false, nil", found, err)
+	}
+}
+
+func TestDemoSource(t *testing.T) {
+	db := openMemDB(t)
+
+	s := model.MatchSummary{DemoHash: "src1", MapName: "de_nuke", MatchDate: "2025-02-01", MatchType: "FACEIT", Tickrate: 64,
+		Source: model.DemoSource{Origin: model.SourceFACEIT, URL: "https://www.faceit.com/en/cs2/room/1-abc", MatchID: "1-abc"}}
+	if err := db.ReplaceDemo(DemoData{Summary: s}); err != nil {
+		t.Fatalf("ReplaceDemo: %v", err)
+	}
+
+	// A re-parse from a local file adds the path and keeps the URL and ID.
+	s.Source = model.DemoSource{Origin: model.SourceFile, Path: "/demos/nuke.dem"}
+	if err := db.ReplaceDemo(DemoData{Summary: s}); err != nil {
+		t.Fatalf("ReplaceDemo (re-parse): %v", err)
+	}
+	want := model.DemoSource{Origin: model.SourceFile, Path: "/demos/nuke.dem", URL: "https://www.faceit.com/en/cs2/room/1-abc", MatchID: "1-abc"}
+	demo, err := db.GetDemoByPrefix("src1")
+	if err != nil || demo == nil {
+		t.Fatalf("GetDemoByPrefix: %+v, %v", demo, err)
+	}
+	if demo.Source != want {
+		t.Errorf("source after re-parse = %+v, want %+v", demo.Source, want)
+	}
+
+	// Filling a share code on a stored demo leaves the other fields alone.
+	if err := db.UpdateDemoSource("src1", model.DemoSource{ShareCode: "CSGO-aaaaa-bbbbb-ccccc-ddddd-eeeee"}); err != nil {
+		t.Fatalf("UpdateDemoSource: %v", err)
+	}
+	if err := db.UpdateDemoSource("missing", model.DemoSource{Path: "/x.dem"}); err != nil {
+		t.Fatalf("UpdateDemoSource(missing): %v", err)
+	}
+	want.ShareCode = "CSGO-aaaaa-bbbbb-ccccc-ddddd-eeeee"
+	list, err := db.ListDemos(DemoFilter{})
+	if err != nil {
+		t.Fatalf("ListDemos: %v", err)
+	}
+	if len(list) != 1 || list[0].Source != want {
+		t.Errorf("ListDemos = %+v, want one demo with source %+v", list, want)
+	}
+	if got := want.Label(); got != "file 1-abc" {
+		t.Errorf("Label = %q, want %q", got, "file 1-abc")
+	}
+
+	stored, err := db.StoredMatchIDs([]string{"1-abc", "2-def"})
+	if err != nil {
+		t.Fatalf("StoredMatchIDs: %v", err)
+	}
+	if !stored["1-abc"] || stored["2-def"] || len(stored) != 1 {
+		t.Errorf("StoredMatchIDs = %v, want only 1-abc", stored)
+	}
+}
+
+func TestMergeFromDedupByHash(t *testing.T) {
+	srcPath := filepath.Join(t.TempDir(), "other.db")
+	src, err := Open(srcPath)
+	if err != nil {
+		t.Fatalf("open source db: %v", err)
+	}
+	for _, h := range []string{"shared", "only_src"} {
+		if err := src.InsertDemo(model.MatchSummary{DemoHash: h, MapName: "de_nuke", MatchDate: "2025-03-01", MatchType: "Scrim", Tickrate: 64}, ""); err != nil {
+			t.Fatalf("InsertDemo: %v", err)
+		}
+		if err := src.InsertPlayerMatchStats([]model.PlayerMatchStats{
+			{DemoHash: h, SteamID: 76561198000000009, Name: "Src", Team: model.TeamT, Kills: 99, RoundsPlayed: 24},
+		}); err != nil {
+			t.Fatalf("InsertPlayerMatchStats: %v", err)
+		}
+	}
+	src.Close()
+
+	db := openMemDB(t)
+	db.InsertDemo(model.MatchSummary{DemoHash: "shared", MapName: "de_nuke", MatchDate: "2025-03-01", MatchType: "Scrim", Tickrate: 64}, "")
+	db.InsertPlayerMatchStats([]model.PlayerMatchStats{
+		{DemoHash: "shared", SteamID: 76561198000000009, Name: "Local", Team: model.TeamT, Kills: 7, RoundsPlayed: 24},
+	})
+
+	res, err := db.MergeFrom(srcPath)
+	if err != nil {
+		t.Fatalf("MergeFrom: %v", err)
+	}
+	if res.DemosInserted != 1 || res.DemosSkipped != 1 || res.RowsInserted != 1 {
+		t.Errorf("MergeResult = %+v, want 1 inserted, 1 skipped, 1 row", res)
+	}
+	// Same hash, different stats, no pipeline versions on either side: reported
+	// as a conflict and the local copy wins the tie.
+	if len(res.Conflicts) != 1 || res.Conflicts[0].Hash != "shared" || res.Conflicts[0].TookSource {
+		t.Errorf("Conflicts = %+v, want one kept-local conflict on shared", res.Conflicts)
+	}
+
+	// The already-stored demo keeps its local stats.
+	got, err := db.GetPlayerMatchStats("shared")
+	if err != nil {
+		t.Fatalf("GetPlayerMatchStats: %v", err)
+	}
+	if len(got) != 1 || got[0].Kills != 7 {
+		t.Errorf("shared demo stats overwritten: %+v", got)
+	}
+
+	got, err = db.GetPlayerMatchStats("only_src")
+	if err != nil {
+		t.Fatalf("GetPlayerMatchStats: %v", err)
+	}
+	if len(got) != 1 || got[0].Kills != 99 {
+		t.Errorf("merged demo stats missing: %+v", got)
+	}
+	if log, _ := db.GetAuditLog("only_src", 0); len(log) != 1 || log[0].Action != AuditMerge || log[0].DemoHash != "only_src" || log[0].Detail != "from other.db" {
+		t.Errorf("audit after merge = %+v, want one merge of only_src from other.db", log)
+	}
+
+	// Merging again is a no-op.
+	res, err = db.MergeFrom(srcPath)
+	if err != nil {
+		t.Fatalf("second MergeFrom: %v", err)
+	}
+	if res.DemosInserted != 0 || res.DemosSkipped != 2 {
+		t.Errorf("second MergeResult = %+v, want 0 inserted, 2 skipped", res)
+	}
+}
+
+func TestMergeFromConflictNewerVersionWins(t *testing.T) {
+	srcPath := filepath.Join(t.TempDir(), "other.db")
+	src, err := Open(srcPath)
+	if err != nil {
+		t.Fatalf("open source db: %v", err)
+	}
+	for _, h := range []string{"newer_src", "older_src", "identical"} {
+		v := 2
+		if h == "older_src" {
+			v = 1
+		}
+		src.InsertDemo(model.MatchSummary{DemoHash: h, MapName: "de_inferno", MatchDate: "2025-04-01", MatchType: "Scrim", Tickrate: 64, PipelineVersion: v}, "")
+		kills := 30
+		if h == "identical" {
+			kills = 10
+		}
+		src.InsertPlayerMatchStats([]model.PlayerMatchStats{
+			{DemoHash: h, SteamID: 76561198000000001, Name: "A", Team: model.TeamCT, Kills: kills, RoundsPlayed: 20},
+		})
+	}
+	src.Close()
+
+	db := openMemDB(t)
+	for _, h := range []string{"newer_src", "older_src", "identical"} {
+		db.InsertDemo(model.MatchSummary{DemoHash: h, MapName: "de_inferno", MatchDate: "2025-04-01", MatchType: "Scrim", Tickrate: 64, PipelineVersion: 1}, "")
+		db.InsertPlayerMatchStats([]model.PlayerMatchStats{
+			{DemoHash: h, SteamID: 76561198000000001, Name: "A", Team: model.TeamCT, Kills: 10, RoundsPlayed: 20},
+		})
+	}
+
+	res, err := db.MergeFrom(srcPath)
+	if err != nil {
+		t.Fatalf("MergeFrom: %v", err)
+	}
+	if len(res.Conflicts) != 2 {
+		t.Fatalf("expected 2 conflicts (identical stats are not a conflict), got %+v", res.Conflicts)
+	}
+	if res.DemosReplaced != 1 || res.DemosSkipped != 2 || res.DemosInserted != 0 {
+		t.Errorf("MergeResult = %+v, want 1 replaced, 2 skipped, 0 inserted", res)
+	}
+
+	got, _ := db.GetPlayerMatchStats("newer_src")
+	if len(got) != 1 || got[0].Kills != 30 {
+		t.Errorf("newer_src: expected source stats (30 kills), got %+v", got)
+	}
+	got, _ = db.GetPlayerMatchStats("older_src")
+	if len(got) != 1 || got[0].Kills != 10 {
+		t.Errorf("older_src: expected local stats kept (10 kills), got %+v", got)
+	}
+}
+
+func TestListDemosFilter(t *testing.T) {
+	db := openMemDB(t)
+
+	db.InsertDemo(model.MatchSummary{DemoHash: "m1", MapName: "de_mirage", MatchDate: "2025-03-01", MatchType: "Scrim", Tickrate: 64}, "")
+	db.InsertDemo(model.MatchSummary{DemoHash: "m2", MapName: "de_mirage", MatchDate: "2025-02-01", MatchType: "Scrim", Tickrate: 64, Tier: "pro"}, "")
+	db.InsertDemo(model.MatchSummary{DemoHash: "m3", MapName: "de_mirage", MatchDate: "2025-01-01", MatchType: "Premier", Tickrate: 64}, "")
+	db.InsertDemo(model.MatchSummary{DemoHash: "n1", MapName: "de_nuke", MatchDate: "2025-03-02", MatchType: "Scrim", Tickrate: 64}, "")
+	db.InsertPlayerMatchStats([]model.PlayerMatchStats{
+		{DemoHash: "m1", SteamID: 7, Name: "A", Team: model.TeamCT},
+		{DemoHash: "m3", SteamID: 7, Name: "A", Team: model.TeamCT},
+		{DemoHash: "n1", SteamID: 8, Name: "B", Team: model.TeamT},
+	})
+
+	hashes := func(f DemoFilter) string {
+		t.Helper()
+		list, err := db.ListDemos(f)
+		if err != nil {
+			t.Fatalf("ListDemos(%+v): %v", f, err)
+		}
+		var out []string
+		for _, d := range list {
+			out = append(out, d.DemoHash)
+		}
+		return strings.Join(out, ",")
+	}
+	cases := []struct {
+		f    DemoFilter
+		want string
+	}{
+		{DemoFilter{Map: "Mirage"}, "m1,m2,m3"},
+		{DemoFilter{Map: "de_mirage", Since: "2025-02-01"}, "m1,m2"},
+		{DemoFilter{Type: "scrim"}, "n1,m1,m2"},
+		{DemoFilter{Tier: "pro"}, "m2"},
+		{DemoFilter{SteamID: 7}, "m1,m3"},
+		{DemoFilter{Limit: 2}, "n1,m1"},
+		{DemoFilter{Limit: 2, Offset: 2}, "m2,m3"},
+		{DemoFilter{Offset: 3}, "m3"},
+	}
+	for _, c := range cases {
+		if got := hashes(c.f); got != c.want {
+			t.Errorf("ListDemos(%+v) = %s, want %s", c.f, got, c.want)
+		}
+	}
+
+	outdated, err := db.ListOutdatedDemos(1, DemoFilter{Map: "nuke"})
+	if err != nil {
+		t.Fatalf("ListOutdatedDemos: %v", err)
+	}
+	if len(outdated) != 1 || outdated[0].DemoHash != "n1" {
+		t.Errorf("ListOutdatedDemos(map nuke) = %+v, want only n1", outdated)
+	}
+}
+
+func TestListOutdatedDemos(t *testing.T) {
+	db := openMemDB(t)
+
+	db.InsertDemo(model.MatchSummary{DemoHash: "current", MapName: "de_nuke", MatchDate: "2025-01-03", MatchType: "Scrim", Tickrate: 64, PipelineVersion: 2}, "")
+	db.InsertDemo(model.MatchSummary{DemoHash: "legacy", MapName: "de_nuke", MatchDate: "2025-01-02", MatchType: "Scrim", Tickrate: 64}, "")
+	db.InsertDemo(model.MatchSummary{DemoHash: "stale_rows", MapName: "de_nuke", MatchDate: "2025-01-01", MatchType: "Scrim", Tickrate: 64, PipelineVersion: 2}, "")
+	db.InsertPlayerMatchStats([]model.PlayerMatchStats{
+		{DemoHash: "current", SteamID: 1, Name: "A", Team: model.TeamCT, PipelineVersion: 2},
+		{DemoHash: "stale_rows", SteamID: 1, Name: "A", Team: model.TeamCT, PipelineVersion: 2},
+		{DemoHash: "stale_rows", SteamID: 2, Name: "B", Team: model.TeamT, PipelineVersion: 1},
+	})
+
+	got, err := db.ListOutdatedDemos(2, DemoFilter{})
+	if err != nil {
+		t.Fatalf("ListOutdatedDemos: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 outdated demos, got %d: %+v", len(got), got)
+	}
+	if got[0].DemoHash != "legacy" || got[0].PipelineVersion != 0 {
+		t.Errorf("first outdated = %s v%d, want legacy v0", got[0].DemoHash, got[0].PipelineVersion)
+	}
+	if got[1].DemoHash != "stale_rows" || got[1].PipelineVersion != 1 {
+		t.Errorf("second outdated = %s v%d, want stale_rows v1 (oldest stats row)", got[1].DemoHash, got[1].PipelineVersion)
+	}
+}
+
+func TestGetPlayerHalfStats(t *testing.T) {
+	db := openMemDB(t)
+
+	db.InsertDemo(model.MatchSummary{DemoHash: "full", MapName: "de_inferno", MatchDate: "2025-01-01", MatchType: "Scrim", Tickrate: 64}, "")
+	db.InsertDemo(model.MatchSummary{DemoHash: "onehalf", MapName: "de_inferno", MatchDate: "2025-01-02", MatchType: "Scrim", Tickrate: 64}, "")
+
+	// "full": 2 CT rounds, 2 T rounds, then 1 overtime CT round (ignored).
+	// "onehalf": only CT rounds (e.g. surrender before half-time) → omitted.
+	round := func(hash string, n int, team model.Team, kills, damage int, survived, openK bool) model.PlayerRoundStats {
+		return model.PlayerRoundStats{DemoHash: hash, SteamID: 7, RoundNumber: n, Team: team,
+			Kills: kills, Damage: damage, GotKill: kills > 0, Survived: survived, KASTEarned: kills > 0 || survived,
+			IsOpeningKill: openK}
+	}
+	if err := db.InsertPlayerRoundStats([]model.PlayerRoundStats{
+		round("full", 1, model.TeamCT, 2, 180, true, true),
+		round("full", 2, model.TeamCT, 0, 20, false, false),
+		round("full", 3, model.TeamT, 1, 100, false, false),
+		round("full", 4, model.TeamT, 0, 0, false, false),
+		round("full", 5, model.TeamCT, 3, 300, true, true),
+		round("onehalf", 1, model.TeamCT, 1, 100, true, false),
+	}); err != nil {
+		t.Fatalf("InsertPlayerRoundStats: %v", err)
+	}
+
+	got, err := db.GetPlayerHalfStats(7)
+	if err != nil {
+		t.Fatalf("GetPlayerHalfStats: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 halves (one demo), got %d: %+v", len(got), got)
+	}
+	h1, h2 := got[0], got[1]
+	if h1.Half != 1 || h1.RoundsPlayed != 2 || h1.Kills != 2 || h1.Deaths != 1 || h1.TotalDamage != 200 || h1.OpeningKills != 1 {
+		t.Errorf("half 1 = %+v", h1)
+	}
+	if h2.Half != 2 || h2.RoundsPlayed != 2 || h2.Kills != 1 || h2.Deaths != 2 || h2.TotalDamage != 100 || h2.OpeningKills != 0 {
+		t.Errorf("half 2 = %+v (overtime round must be excluded)", h2)
+	}
+}
+
+// TestGetPlayerAWPByMap: AWP deaths are summed per map over the given demos
+// only, busiest map first.
+func TestGetPlayerAWPByMap(t *testing.T) {
+	db := openMemDB(t)
+	demo := func(hash, mapName string) {
+		db.InsertDemo(model.MatchSummary{DemoHash: hash, MapName: mapName, MatchDate: "2025-01-01", MatchType: "Scrim", Tickrate: 64}, "")
+	}
+	demo("m1", "de_mirage")
+	demo("m2", "de_mirage")
+	demo("n1", "de_nuke")
+	demo("skip", "de_nuke")
+	stats := func(hash string, deaths, dry, faced int) model.PlayerMatchStats {
+		return model.PlayerMatchStats{DemoHash: hash, SteamID: 7, Name: "p", Team: model.TeamCT,
+			AWPDeaths: deaths, AWPDeathsDry: dry, AWPRoundsFaced: faced}
+	}
+	if err := db.InsertPlayerMatchStats([]model.PlayerMatchStats{
+		stats("m1", 3, 2, 10), stats("m2", 2, 1, 8), stats("n1", 1, 0, 5), stats("skip", 9, 9, 9),
+	}); err != nil {
+		t.Fatalf("InsertPlayerMatchStats: %v", err)
+	}
+
+	got, err := db.GetPlayerAWPByMap(7, []string{"m1", "m2", "n1"})
+	if err != nil {
+		t.Fatalf("GetPlayerAWPByMap: %v", err)
+	}
+	want := []model.PlayerAWPMapStats{
+		{SteamID: 7, Name: "p", MapName: "Mirage", Matches: 2, AWPDeaths: 5, AWPDeathsDry: 3, AWPRoundsFaced: 18},
+		{SteamID: 7, Name: "p", MapName: "Nuke", Matches: 1, AWPDeaths: 1, AWPRoundsFaced: 5},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetPlayerAWPByMap = %+v, want %+v", got, want)
+	}
+}
+
+func TestFirstSightsRoundTrip(t *testing.T) {
+	db := openMemDB(t)
+	db.InsertDemo(model.MatchSummary{DemoHash: "fs", MapName: "de_mirage", MatchDate: "2025-01-01", MatchType: "Scrim", Tickrate: 64}, "")
+
+	sights := []model.RawFirstSight{
+		{Tick: 900, RoundNumber: 2, ObserverID: 7, EnemyID: 76561198000000001, AngleDeg: 12.5, PitchDeg: 3, YawDeg: 12, ObserverPitchDeg: 1.5, ObserverYawDeg: 90},
+		{Tick: 300, RoundNumber: 1, ObserverID: 7, EnemyID: 76561198000000002, AngleDeg: 1.2, PitchDeg: 0.5, YawDeg: 1},
+		{Tick: 310, RoundNumber: 1, ObserverID: 8, EnemyID: 76561198000000003, AngleDeg: 40},
+	}
+	if err := db.InsertFirstSights("fs", sights); err != nil {
+		t.Fatalf("InsertFirstSights: %v", err)
+	}
+	// Re-inserting the same demo replaces rather than duplicates.
+	if err := db.InsertFirstSights("fs", sights); err != nil {
+		t.Fatalf("InsertFirstSights (again): %v", err)
+	}
+
+	got, err := db.GetFirstSights("fs", 7)
+	if err != nil {
+		t.Fatalf("GetFirstSights: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 sights for player 7, got %d", len(got))
+	}
+	if got[0].RoundNumber != 1 || got[0].EnemyID != 76561198000000002 || got[0].AngleDeg != 1.2 {
+		t.Errorf("first sight = %+v, want round 1 enemy …002", got[0])
+	}
+	if got[1].ObserverID != 7 || got[1].ObserverYawDeg != 90 || got[1].YawDeg != 12 {
+		t.Errorf("second sight = %+v", got[1])
+	}
+
+	none, err := db.GetFirstSights("fs", 9)
+	if err != nil || len(none) != 0 {
+		t.Errorf("untracked player: got %v, %v", none, err)
+	}
+}
+
+func TestKillStatesRoundTrip(t *testing.T) {
+	db := openMemDB(t)
+	db.InsertDemo(model.MatchSummary{DemoHash: "ks1", MapName: "de_nuke", MatchDate: "2025-01-01", MatchType: "Scrim", Tickrate: 64}, "")
+	db.InsertDemo(model.MatchSummary{DemoHash: "ks2", MapName: "de_nuke", MatchDate: "2025-01-02", MatchType: "Scrim", Tickrate: 64}, "")
+
+	if err := db.InsertKillStates([]model.KillState{
+		{DemoHash: "ks1", RoundNumber: 1, Tick: 800, KillerSteamID: 7, VictimSteamID: 8, KillerTeam: model.TeamT, VictimTeam: model.TeamCT,
+			CTAlive: 5, TAlive: 5, BombPlanted: true, WinnerTeam: model.TeamT, Weapon: "AK-47", ClockSec: 31.5},
+		{DemoHash: "ks2", RoundNumber: 3, Tick: 500, VictimSteamID: 9, VictimTeam: model.TeamT,
+			CTAlive: 4, TAlive: 2, WinnerTeam: model.TeamCT},
+	}); err != nil {
+		t.Fatalf("InsertKillStates: %v", err)
+	}
+
+	all, err := db.KillStates(nil)
+	if err != nil {
+		t.Fatalf("KillStates(nil): %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("expected 2 states across all demos, got %d", len(all))
+	}
+	got := all[0]
+	if got.KillerSteamID != 7 || got.VictimTeam != model.TeamCT || !got.BombPlanted || got.WinnerTeam != model.TeamT || got.CTAlive != 5 || got.Weapon != "AK-47" || got.ClockSec != 31.5 {
+		t.Errorf("state = %+v", got)
+	}
+	if all[1].KillerSteamID != 0 || all[1].BombPlanted {
+		t.Errorf("world kill state = %+v", all[1])
+	}
+
+	some, err := db.KillStates([]string{"ks2"})
+	if err != nil || len(some) != 1 || some[0].DemoHash != "ks2" {
+		t.Errorf("KillStates(ks2) = %+v, %v", some, err)
+	}
+	none, err := db.KillStates([]string{})
+	if err != nil || len(none) != 0 {
+		t.Errorf("KillStates(empty) = %+v, %v", none, err)
+	}
+}
+
+func TestGetSegmentReferences(t *testing.T) {
+	db := openMemDB(t)
+	db.InsertDemo(model.MatchSummary{DemoHash: "pro", MapName: "de_inferno", MatchDate: "2025-01-01", MatchType: "Pro", Tickrate: 64, IsBaseline: true}, "")
+	db.InsertDemo(model.MatchSummary{DemoHash: "pug", MapName: "de_inferno", MatchDate: "2025-01-02", MatchType: "Pug", Tickrate: 64}, "")
+
+	seg := func(hash string, id uint64, bucket, bin string, hits, hs int, sight float64) model.PlayerDuelSegment {
+		return model.PlayerDuelSegment{DemoHash: hash, SteamID: id, WeaponBucket: bucket, DistanceBin: bin,
+			DuelCount: hits, FirstHitCount: hits, FirstHitHSCount: hs, MedianSightDeg: sight}
+	}
+	if err := db.InsertPlayerDuelSegments([]model.PlayerDuelSegment{
+		seg("pro", 1, "AK", "10-15m", 20, 10, 4), // baseline pool large enough → used alone
+		seg("pug", 2, "AK", "10-15m", 20, 2, 8),
+		seg("pro", 1, "AWP", "30m+", 4, 1, 0), // baseline too small → pooled with pugs
+		seg("pug", 2, "AWP", "30m+", 16, 3, 6),
+		seg("pug", 9, "AK", "10-15m", 50, 50, 1), // the excluded player
+	}); err != nil {
+		t.Fatalf("InsertPlayerDuelSegments: %v", err)
+	}
+
+	refs, err := db.GetSegmentReferences(9, 10)
+	if err != nil {
+		t.Fatalf("GetSegmentReferences: %v", err)
+	}
+	got := make(map[string]model.SegmentReference)
+	for _, r := range refs {
+		got[r.WeaponBucket+"/"+r.DistanceBin] = r
+	}
+	ak := got["AK/10-15m"]
+	if !ak.Baseline || ak.FirstHitCount != 20 || ak.FHHSPct() != 50 || ak.AvgSightDeg != 4 {
+		t.Errorf("AK reference = %+v, want baseline 10/20 at 4°", ak)
+	}
+	awp := got["AWP/30m+"]
+	if awp.Baseline || awp.FirstHitCount != 20 || awp.FirstHitHSCount != 4 || awp.AvgSightDeg != 6 {
+		t.Errorf("AWP reference = %+v, want pooled 4/20 with sight 6° (no-data group ignored)", awp)
+	}
+}
+
+// The still/moving first-hit split round-trips through both segment readers.
+func TestDuelSegmentMovementRoundTrip(t *testing.T) {
+	db := openMemDB(t)
+	db.InsertDemo(model.MatchSummary{DemoHash: "d1", MapName: "de_mirage", MatchDate: "2025-01-01", MatchType: "Pug", Tickrate: 64}, "")
+	want := model.PlayerDuelSegment{DemoHash: "d1", SteamID: 7, RoundContext: model.RoundContextGun,
+		WeaponBucket: "AK", DistanceBin: "10-15m", DuelCount: 9, FirstHitCount: 8, FirstHitHSCount: 4,
+		StillFirstHits: 5, StillFirstHitHS: 3, MovingFirstHits: 2, MovingFirstHitHS: 1}
+	if err := db.InsertPlayerDuelSegments([]model.PlayerDuelSegment{want}); err != nil {
+		t.Fatalf("InsertPlayerDuelSegments: %v", err)
+	}
+	byDemo, err := db.GetPlayerDuelSegments("d1")
+	if err != nil {
+		t.Fatalf("GetPlayerDuelSegments: %v", err)
+	}
+	byPlayer, err := db.GetAllPlayerDuelSegments(7)
+	if err != nil {
+		t.Fatalf("GetAllPlayerDuelSegments: %v", err)
+	}
+	for name, got := range map[string][]model.PlayerDuelSegment{"by demo": byDemo, "by player": byPlayer} {
+		if len(got) != 1 || got[0] != want {
+			t.Errorf("%s = %+v, want %+v", name, got, want)
+		}
+	}
+}
+
+// Pre-death context counts are stored with the demo and read back per player.
+func TestDeathContextsRoundTrip(t *testing.T) {
+	db := openMemDB(t)
+	rows := []model.PlayerDeathContext{
+		{DemoHash: "d1", SteamID: 7, Movement: model.DeathMoving, Isolated: true, AfterKill: true, Deaths: 3},
+		{DemoHash: "d1", SteamID: 7, Movement: model.DeathHolding, Flashed: true, Deaths: 1},
+		{DemoHash: "d1", SteamID: 8, Movement: model.DeathMovementUnknown, Deaths: 2},
+	}
+	if err := db.ReplaceDemo(DemoData{
+		Summary:       model.MatchSummary{DemoHash: "d1", MapName: "de_mirage", MatchDate: "2025-01-01", MatchType: "Pug", Tickrate: 64},
+		DeathContexts: rows,
+	}); err != nil {
+		t.Fatalf("ReplaceDemo: %v", err)
+	}
+	got, err := db.GetAllPlayerDeathContexts(7)
+	if err != nil {
+		t.Fatalf("GetAllPlayerDeathContexts: %v", err)
+	}
+	want := map[model.PlayerDeathContext]bool{rows[0]: true, rows[1]: true}
+	for _, r := range got {
+		if !want[r] {
+			t.Errorf("unexpected death context %+v", r)
+		}
+		delete(want, r)
+	}
+	if len(want) > 0 {
+		t.Errorf("death contexts %+v missing from %+v", want, got)
+	}
+}
+
+func TestGetTimeToDamageReferences(t *testing.T) {
+	db := openMemDB(t)
+	db.InsertDemo(model.MatchSummary{DemoHash: "pro", MapName: "de_inferno", MatchDate: "2025-01-01", MatchType: "Pro", Tickrate: 64, IsBaseline: true}, "")
+	db.InsertDemo(model.MatchSummary{DemoHash: "pug", MapName: "de_inferno", MatchDate: "2025-01-02", MatchType: "Pug", Tickrate: 64}, "")
+
+	ttd := func(hash string, id uint64, bucket string, n int, ms float64) model.PlayerTimeToDamage {
+		return model.PlayerTimeToDamage{DemoHash: hash, SteamID: id, WeaponBucket: bucket, Samples: n, MedianMs: ms}
+	}
+	if err := db.InsertPlayerTimeToDamage([]model.PlayerTimeToDamage{
+		ttd("pro", 1, "AK", 20, 200), // baseline pool large enough → used alone
+		ttd("pug", 2, "AK", 20, 400),
+		ttd("pro", 1, "AWP", 5, 300), // baseline too small → pooled with pugs
+		ttd("pug", 2, "AWP", 15, 500),
+		ttd("pug", 9, "AK", 50, 100), // the excluded player
+	}); err != nil {
+		t.Fatalf("InsertPlayerTimeToDamage: %v", err)
+	}
+
+	refs, err := db.GetTimeToDamageReferences(9, 10)
+	if err != nil {
+		t.Fatalf("GetTimeToDamageReferences: %v", err)
+	}
+	got := make(map[string]model.PlayerTimeToDamage)
+	for _, r := range refs {
+		got[r.WeaponBucket] = r
+	}
+	if ak := got["AK"]; ak.Samples != 20 || ak.MedianMs != 200 {
+		t.Errorf("AK reference = %+v, want baseline 20 samples at 200ms", ak)
+	}
+	if awp := got["AWP"]; awp.Samples != 20 || awp.MedianMs != 450 {
+		t.Errorf("AWP reference = %+v, want pooled 20 samples at 450ms", awp)
+	}
+
+	rows, err := db.GetAllPlayerTimeToDamage(9)
+	if err != nil {
+		t.Fatalf("GetAllPlayerTimeToDamage: %v", err)
+	}
+	if len(rows) != 1 || rows[0] != ttd("pug", 9, "AK", 50, 100) {
+		t.Errorf("GetAllPlayerTimeToDamage(9) = %+v", rows)
+	}
+}
+
+func TestPlayerBurstStatsRoundTrip(t *testing.T) {
+	db := openMemDB(t)
+	s := model.MatchSummary{DemoHash: "burst", MapName: "de_mirage", MatchDate: "2025-01-01", MatchType: "Pug", Tickrate: 64}
+	want := model.PlayerBurstStats{DemoHash: "burst", SteamID: 7, WeaponBucket: "AK", Taps: 12, ShortBursts: 6, Sprays: 3, PanicSprays: 1}
+	if err := db.ReplaceDemo(DemoData{
+		Summary:    s,
+		BurstStats: []model.PlayerBurstStats{want, {DemoHash: "burst", SteamID: 8, WeaponBucket: "M4", Taps: 1}},
+	}); err != nil {
+		t.Fatalf("ReplaceDemo: %v", err)
+	}
+
+	rows, err := db.GetAllPlayerBurstStats(7)
+	if err != nil {
+		t.Fatalf("GetAllPlayerBurstStats: %v", err)
+	}
+	if len(rows) != 1 || rows[0] != want {
+		t.Errorf("GetAllPlayerBurstStats(7) = %+v, want [%+v]", rows, want)
+	}
+}
+
+func TestPlayerPositionsRoundTrip(t *testing.T) {
+	db := openMemDB(t)
+	s := model.MatchSummary{DemoHash: "pos", MapName: "de_mirage", MatchDate: "2025-01-01", MatchType: "Pug", Tickrate: 64}
+	want := model.PlayerPositionStats{DemoHash: "pos", SteamID: 7, Side: model.TeamCT, Place: "Connector", Rounds: 5, AvgX: -512.5, AvgY: 64}
+	if err := db.ReplaceDemo(DemoData{
+		Summary: s,
+		Positions: []model.PlayerPositionStats{want,
+			{DemoHash: "pos", SteamID: 8, Side: model.TeamT, Place: "Palace", Rounds: 3}},
+	}); err != nil {
+		t.Fatalf("ReplaceDemo: %v", err)
+	}
+
+	rows, err := db.GetAllPlayerPositions(7)
+	if err != nil {
+		t.Fatalf("GetAllPlayerPositions: %v", err)
+	}
+	want.MapName = "Mirage" // normalized by insertDemo
+	if len(rows) != 1 || rows[0] != want {
+		t.Errorf("GetAllPlayerPositions(7) = %+v, want [%+v]", rows, want)
+	}
+}
+
+func TestTableFootprint(t *testing.T) {
+	db := openMemDB(t)
+	with := model.MatchSummary{DemoHash: "fp1", MapName: "de_mirage", MatchDate: "2025-01-01", MatchType: "Pug", Tickrate: 64}
+	without := model.MatchSummary{DemoHash: "fp2", MapName: "de_mirage", MatchDate: "2025-01-02", MatchType: "Pug", Tickrate: 64}
+	if err := db.ReplaceDemo(DemoData{
+		Summary: with,
+		Positions: []model.PlayerPositionStats{
+			{DemoHash: "fp1", SteamID: 7, Side: model.TeamCT, Place: "Connector", Rounds: 5},
+			{DemoHash: "fp1", SteamID: 8, Side: model.TeamT, Place: "Palace", Rounds: 3}},
+	}); err != nil {
+		t.Fatalf("ReplaceDemo: %v", err)
+	}
+	if err := db.ReplaceDemo(DemoData{Summary: without}); err != nil {
+		t.Fatalf("ReplaceDemo: %v", err)
+	}
+
+	fp, err := db.TableFootprint("player_positions")
+	if err != nil {
+		t.Fatalf("TableFootprint: %v", err)
+	}
+	if fp.Rows != 2 || fp.Demos != 1 || fp.Bytes <= 0 {
+		t.Errorf("player_positions footprint = %+v, want 2 rows in 1 demo and a size", fp)
+	}
+	if fp.BytesPerDemo() != fp.Bytes {
+		t.Errorf("BytesPerDemo = %d, want %d", fp.BytesPerDemo(), fp.Bytes)
+	}
+	empty, err := db.TableFootprint("round_events")
+	if err != nil {
+		t.Fatalf("TableFootprint: %v", err)
+	}
+	if empty.Rows != 0 || empty.Demos != 0 || empty.BytesPerDemo() != 0 {
+		t.Errorf("round_events footprint = %+v, want empty", empty)
+	}
+	if _, err := db.TableFootprint("no_such_table"); err == nil {
+		t.Error("TableFootprint(no_such_table) succeeded, want error")
+	}
+
+	size, err := db.SizeBytes()
+	if err != nil {
+		t.Fatalf("SizeBytes: %v", err)
+	}
+	if size < fp.Bytes+empty.Bytes {
+		t.Errorf("SizeBytes = %d, want at least the two tables' %d", size, fp.Bytes+empty.Bytes)
+	}
+}
+
+func TestDuelDistancesRoundTrip(t *testing.T) {
+	db := openMemDB(t)
+	row := func(hash string, id uint64, m, duels, hs int) model.PlayerDuelDistance {
+		return model.PlayerDuelDistance{DemoHash: hash, SteamID: id, WeaponBucket: "AWP", Meters: m,
+			DuelCount: duels, FirstHitCount: duels, FirstHitHSCount: hs}
+	}
+	for _, d := range []struct {
+		summary model.MatchSummary
+		rows    []model.PlayerDuelDistance
+	}{
+		{model.MatchSummary{DemoHash: "b1", MapName: "de_mirage", MatchDate: "2025-01-01", MatchType: "Pro", Tickrate: 64, IsBaseline: true},
+			[]model.PlayerDuelDistance{row("b1", 7, 25, 2, 1), row("b1", 8, 25, 3, 0), row("b1", 8, 40, 1, 1)}},
+		{model.MatchSummary{DemoHash: "own", MapName: "de_mirage", MatchDate: "2025-01-02", MatchType: "Pug", Tickrate: 64},
+			[]model.PlayerDuelDistance{row("own", 7, 25, 4, 4)}},
+	} {
+		if err := db.ReplaceDemo(DemoData{Summary: d.summary, DuelDistances: d.rows}); err != nil {
+			t.Fatalf("ReplaceDemo %s: %v", d.summary.DemoHash, err)
+		}
+	}
+
+	mine, err := db.GetAllPlayerDuelDistances(7)
+	if err != nil {
+		t.Fatalf("GetAllPlayerDuelDistances: %v", err)
+	}
+	if len(mine) != 2 {
+		t.Errorf("GetAllPlayerDuelDistances(7) = %+v, want 2 rows", mine)
+	}
+
+	// The baseline pool sums demo b1 only, per distance.
+	base, err := db.GetBaselineDuelDistances()
+	if err != nil {
+		t.Fatalf("GetBaselineDuelDistances: %v", err)
+	}
+	want := []model.PlayerDuelDistance{
+		{WeaponBucket: "AWP", Meters: 25, DuelCount: 5, FirstHitCount: 5, FirstHitHSCount: 1},
+		{WeaponBucket: "AWP", Meters: 40, DuelCount: 1, FirstHitCount: 1, FirstHitHSCount: 1},
+	}
+	if len(base) != len(want) || base[0] != want[0] || base[1] != want[1] {
+		t.Errorf("GetBaselineDuelDistances = %+v, want %+v", base, want)
+	}
+}
+
+// TestTeamRoundEconomy: per-demo rows round-trip, and the roster's win
+// rates follow the side most of its players were on each round.
+func TestTeamRoundEconomy(t *testing.T) {
+	db := openMemDB(t)
+	econ := func(round int, team model.Team, rt string, won bool) model.TeamRoundEconomy {
+		return model.TeamRoundEconomy{DemoHash: "te", RoundNumber: round, Team: team, Players: 5,
+			EquipValue: 10000, RoundType: rt, Won: won}
+	}
+	rows := []model.TeamRoundEconomy{
+		econ(1, model.TeamCT, model.TeamRoundPistol, false),
+		econ(1, model.TeamT, model.TeamRoundPistol, true),
+		econ(2, model.TeamCT, model.TeamRoundFullEco, false),
+		econ(2, model.TeamT, model.TeamRoundForce, true),
+	}
+	rs := func(id uint64, round int, team model.Team) model.PlayerRoundStats {
+		return model.PlayerRoundStats{DemoHash: "te", SteamID: id, RoundNumber: round, Team: team}
+	}
+	if err := db.ReplaceDemo(DemoData{
+		Summary:     model.MatchSummary{DemoHash: "te", MapName: "de_nuke", MatchDate: "2025-01-01", MatchType: "Scrim", Tickrate: 64},
+		RoundStats:  []model.PlayerRoundStats{rs(1, 1, model.TeamT), rs(2, 1, model.TeamT), rs(3, 1, model.TeamCT), rs(1, 2, model.TeamCT), rs(2, 2, model.TeamCT), rs(3, 2, model.TeamT)},
+		TeamEconomy: rows,
+	}); err != nil {
+		t.Fatalf("ReplaceDemo: %v", err)
+	}
+
+	got, err := db.GetTeamRoundEconomy("te")
+	if err != nil {
+		t.Fatalf("GetTeamRoundEconomy: %v", err)
+	}
+	if !reflect.DeepEqual(got, rows) {
+		t.Errorf("GetTeamRoundEconomy = %+v, want %+v", got, rows)
+	}
+
+	// Players 1 and 2 (T in round 1, CT in round 2) outnumber player 3.
+	rates, err := db.TeamEconomyWinRates([]string{"1", "2", "3"}, []string{"te"})
+	if err != nil {
+		t.Fatalf("TeamEconomyWinRates: %v", err)
+	}
+	want := map[string]RoundTypeWinRate{
+		model.TeamRoundPistol:  {Wins: 1, Total: 1},
+		model.TeamRoundFullEco: {Wins: 0, Total: 1},
+	}
+	if !reflect.DeepEqual(rates, want) {
+		t.Errorf("TeamEconomyWinRates = %+v, want %+v", rates, want)
+	}
+}
+
+// TestRoundHalfSideStatsByDemo: rounds follow the side most roster players
+// were on, halves split at the first switch and overtime is dropped.
+func TestRoundHalfSideStatsByDemo(t *testing.T) {
+	db := openMemDB(t)
+	rs := func(hash string, id uint64, round int, team model.Team, won bool) model.PlayerRoundStats {
+		return model.PlayerRoundStats{DemoHash: hash, SteamID: id, RoundNumber: round, Team: team, WonRound: won}
+	}
+	if err := db.InsertPlayerRoundStats([]model.PlayerRoundStats{
+		rs("h1", 1, 1, model.TeamT, true), rs("h1", 2, 1, model.TeamT, true),
+		// Player 3 is on the other side this round; the majority wins.
+		rs("h1", 1, 2, model.TeamT, false), rs("h1", 2, 2, model.TeamT, false), rs("h1", 3, 2, model.TeamCT, true),
+		rs("h1", 1, 3, model.TeamT, true), rs("h1", 2, 3, model.TeamT, true),
+		rs("h1", 1, 4, model.TeamCT, true), rs("h1", 2, 4, model.TeamCT, true),
+		rs("h1", 1, 5, model.TeamCT, false), rs("h1", 2, 5, model.TeamCT, false),
+		// Overtime: a second switch, not counted.
+		rs("h1", 1, 6, model.TeamT, true), rs("h1", 2, 6, model.TeamT, true),
+		rs("h2", 1, 1, model.TeamCT, false), rs("h2", 1, 2, model.TeamCT, true),
+	}); err != nil {
+		t.Fatalf("InsertPlayerRoundStats: %v", err)
+	}
+
+	got, err := db.RoundHalfSideStatsByDemo([]string{"1", "2", "3"}, []string{"h1", "h2"})
+	if err != nil {
+		t.Fatalf("RoundHalfSideStatsByDemo: %v", err)
+	}
+	want := []DemoHalfSideStats{
+		{Hash: "h1", FirstSide: "T", FirstWins: 2, FirstTotal: 3, SecondWins: 1, SecondTotal: 2},
+		{Hash: "h2", FirstSide: "CT", FirstWins: 1, FirstTotal: 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RoundHalfSideStatsByDemo = %+v, want %+v", got, want)
+	}
+}
+
+// TestRoundEventsRoundTrip: a demo's timeline is read back in stream order
+// with positions, teams and details; a round end with no winner keeps no team.
+func TestRoundEventsRoundTrip(t *testing.T) {
+	db := openMemDB(t)
+	events := []model.TimelineEvent{
+		{DemoHash: "rt", Seq: 0, RoundNumber: 1, Tick: 100, Type: model.TimelineRoundStart},
+		{DemoHash: "rt", Seq: 1, RoundNumber: 1, Tick: 1380, Type: model.TimelineFreezeEnd},
+		{DemoHash: "rt", Seq: 2, RoundNumber: 1, Tick: 2000, Type: model.TimelineFlash, ActorID: 1, TargetID: 2,
+			ActorTeam: model.TeamCT, TargetTeam: model.TeamT, DurationSec: 2.5},
+		{DemoHash: "rt", Seq: 3, RoundNumber: 1, Tick: 2100, Type: model.TimelineKill, ActorID: 1, TargetID: 2,
+			ActorTeam: model.TeamCT, TargetTeam: model.TeamT, ActorPos: model.Vec3{X: -1200.5, Y: 300, Z: -160},
+			TargetPos: model.Vec3{X: -900, Y: 420.25, Z: -160}, Weapon: "AK-47", Headshot: true},
+		{DemoHash: "rt", Seq: 4, RoundNumber: 1, Tick: 3000, Type: model.TimelinePlant, ActorID: 2,
+			ActorTeam: model.TeamT, ActorPos: model.Vec3{X: 10, Y: 20, Z: 30}, Detail: "A"},
+		{DemoHash: "rt", Seq: 5, RoundNumber: 1, Tick: 4000, Type: model.TimelineRoundEnd, ActorTeam: model.TeamCT, Detail: model.EndReasonDefuse},
+		{DemoHash: "rt", Seq: 6, RoundNumber: 2, Tick: 5000, Type: model.TimelineRoundEnd, Detail: model.EndReasonOther},
+	}
+	if err := db.ReplaceDemo(DemoData{
+		Summary:  model.MatchSummary{DemoHash: "rt", MapName: "de_mirage", MatchDate: "2025-01-01", MatchType: "Scrim", Tickrate: 64},
+		Timeline: events,
+	}); err != nil {
+		t.Fatalf("ReplaceDemo: %v", err)
+	}
+	got, err := db.GetRoundEvents("rt")
+	if err != nil {
+		t.Fatalf("GetRoundEvents: %v", err)
+	}
+	if !reflect.DeepEqual(got, events) {
+		t.Errorf("GetRoundEvents = %+v, want %+v", got, events)
+	}
+	if none, err := db.GetRoundEvents("missing"); err != nil || len(none) != 0 {
+		t.Errorf("GetRoundEvents(missing) = %v, %v; want no rows", none, err)
+	}
+}
+
+// TestMapBombSiteStats: plants count per site on the roster's majority side;
+// rounds without a plant and sites other than A/B are ignored.
+func TestMapBombSiteStats(t *testing.T) {
+	db := openMemDB(t)
+	rs := func(id uint64, round int, team model.Team, won bool) model.PlayerRoundStats {
+		return model.PlayerRoundStats{DemoHash: "bs", SteamID: id, RoundNumber: round, Team: team, WonRound: won}
+	}
+	plant := func(seq, round int, site string) model.TimelineEvent {
+		return model.TimelineEvent{DemoHash: "bs", Seq: seq, RoundNumber: round, Tick: 1000 * round,
+			Type: model.TimelinePlant, ActorTeam: model.TeamT, Detail: site}
+	}
+	if err := db.ReplaceDemo(DemoData{
+		Summary: model.MatchSummary{DemoHash: "bs", MapName: "de_inferno", MatchDate: "2025-01-01", MatchType: "Scrim", Tickrate: 64},
+		RoundStats: []model.PlayerRoundStats{
+			rs(1, 1, model.TeamT, true), rs(2, 1, model.TeamT, true), rs(3, 1, model.TeamCT, false),
+			rs(1, 2, model.TeamT, false), rs(2, 2, model.TeamT, false),
+			rs(1, 3, model.TeamT, true), rs(2, 3, model.TeamT, true),
+			rs(1, 4, model.TeamCT, true), rs(2, 4, model.TeamCT, true),
+			rs(1, 5, model.TeamCT, false), rs(2, 5, model.TeamCT, false),
+			rs(1, 6, model.TeamCT, true), rs(2, 6, model.TeamCT, true),
+		},
+		Timeline: []model.TimelineEvent{
+			plant(0, 1, "A"), plant(1, 2, "B"), plant(2, 3, "A"),
+			plant(3, 4, "B"), plant(4, 5, "B"), plant(5, 6, ""),
+		},
+	}); err != nil {
+		t.Fatalf("ReplaceDemo: %v", err)
+	}
+
+	got, err := db.MapBombSiteStats([]string{"1", "2", "3"}, []string{"bs"})
+	if err != nil {
+		t.Fatalf("MapBombSiteStats: %v", err)
+	}
+	want := map[string][]BombSiteStats{
+		"Inferno": {
+			{Site: "A", TPlants: 2, TWins: 2},
+			{Site: "B", TPlants: 1, CTPlants: 2, CTWins: 1},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MapBombSiteStats = %+v, want %+v", got, want)
+	}
+}
+
+// TestPlayerFingerprints: fingerprints need minMatches matches unless
+// requested by SteamID; crosshair stats are weighted by encounters and FHHS
+// is summed per weapon bucket. Co-players are everyone sharing a demo.
+func TestPlayerFingerprints(t *testing.T) {
+	db := openMemDB(t)
+	for _, h := range []string{"f1", "f2"} {
+		if err := db.InsertDemo(model.MatchSummary{DemoHash: h, MapName: "de_mirage", MatchDate: "2025-01-01", MatchType: "Scrim", Tickrate: 64}, ""); err != nil {
+			t.Fatalf("InsertDemo: %v", err)
+		}
+	}
+	if err := db.InsertPlayerMatchStats([]model.PlayerMatchStats{
+		{DemoHash: "f1", SteamID: 1, Name: "main", MedianTTKMs: 300, CounterStrafePercent: 60,
+			CrosshairEncounters: 10, CrosshairMedianDeg: 4, CrosshairPctUnder5: 50},
+		{DemoHash: "f2", SteamID: 1, Name: "main", MedianTTKMs: 0, CounterStrafePercent: 80,
+			CrosshairEncounters: 30, CrosshairMedianDeg: 8, CrosshairPctUnder5: 30},
+		{DemoHash: "f1", SteamID: 2, Name: "mate"},
+		{DemoHash: "f2", SteamID: 3, Name: "new"},
+	}); err != nil {
+		t.Fatalf("InsertPlayerMatchStats: %v", err)
+	}
+	if err := db.InsertPlayerDuelSegments([]model.PlayerDuelSegment{
+		{DemoHash: "f1", SteamID: 1, WeaponBucket: "AK", DistanceBin: "0-5m", FirstHitCount: 10, FirstHitHSCount: 4},
+		{DemoHash: "f2", SteamID: 1, WeaponBucket: "AK", DistanceBin: "5-10m", FirstHitCount: 20, FirstHitHSCount: 5},
+	}); err != nil {
+		t.Fatalf("InsertPlayerDuelSegments: %v", err)
+	}
+
+	got, err := db.GetPlayerFingerprints(2, 3)
+	if err != nil {
+		t.Fatalf("GetPlayerFingerprints: %v", err)
+	}
+	want := []model.PlayerFingerprint{
+		{SteamID: 1, Name: "main", Matches: 2, TTKMs: 300, CounterStrafePct: 70, CrosshairMedianDeg: 7,
+			CrosshairPctUnder5: 35, CrosshairSamples: 40, FHHS: map[string]model.FHHSCount{"AK": {FirstHits: 30, Headshots: 9}}},
+		{SteamID: 3, Name: "new", Matches: 1, FHHS: map[string]model.FHHSCount{}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetPlayerFingerprints = %+v, want %+v", got, want)
+	}
+
+	co, err := db.GetCoPlayers(1)
+	if err != nil {
+		t.Fatalf("GetCoPlayers: %v", err)
+	}
+	if want := map[uint64]bool{2: true, 3: true}; !reflect.DeepEqual(co, want) {
+		t.Errorf("GetCoPlayers = %v, want %v", co, want)
+	}
+}
+
+// TestTradeChainsRoundTrip: trade chains are stored by ReplaceDemo and read
+// back in round and chain order.
+func TestTradeChainsRoundTrip(t *testing.T) {
+	db := openMemDB(t)
+	chains := []model.TradeChain{
+		{DemoHash: "tc", RoundNumber: 2, Index: 0, StartTick: 500, EndTick: 700, Kills: 2, Trades: 1, FirstTeam: model.TeamCT, CTKills: 1, TKills: 1},
+		{DemoHash: "tc", RoundNumber: 1, Index: 1, StartTick: 900, EndTick: 1200, Kills: 3, Trades: 2, FirstTeam: model.TeamT, CTKills: 1, TKills: 2},
+		{DemoHash: "tc", RoundNumber: 1, Index: 0, StartTick: 100, EndTick: 300, Kills: 2, Trades: 1, FirstTeam: model.TeamT, CTKills: 1, TKills: 1},
+	}
+	if err := db.ReplaceDemo(DemoData{
+		Summary:     model.MatchSummary{DemoHash: "tc", MapName: "de_nuke", MatchDate: "2025-01-01", MatchType: "Scrim", Tickrate: 64},
+		TradeChains: chains,
+	}); err != nil {
+		t.Fatalf("ReplaceDemo: %v", err)
+	}
+	got, err := db.GetTradeChains("tc")
+	if err != nil {
+		t.Fatalf("GetTradeChains: %v", err)
+	}
+	want := []model.TradeChain{chains[2], chains[1], chains[0]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetTradeChains = %+v, want %+v", got, want)
+	}
+}
+
+// TestWeaponMeta: kill distance round-trips through player_weapon_stats and
+// GetWeaponMeta sums every player per weapon and date within the filter.
+func TestWeaponMeta(t *testing.T) {
+	db := openMemDB(t)
+	demos := []struct {
+		hash, date string
+		baseline   bool
+		weapons    []model.PlayerWeaponStats
+	}{
+		{"m1", "2025-01-01", true, []model.PlayerWeaponStats{
+			{SteamID: 1, Weapon: "AK-47", Kills: 10, HeadshotKills: 5, Damage: 900, Hits: 30, HeadHits: 11, DistanceKills: 8, KillDistanceSumM: 120},
+			{SteamID: 2, Weapon: "AK-47", Kills: 4, HeadshotKills: 1, Damage: 300, Hits: 12, DistanceKills: 4, KillDistanceSumM: 40},
+			{SteamID: 2, Weapon: "AWP", Kills: 3, HeadshotKills: 0, Damage: 300, Hits: 3},
+		}},
+		{"m2", "2025-01-01", false, []model.PlayerWeaponStats{
+			{SteamID: 1, Weapon: "AK-47", Kills: 6, HeadshotKills: 3, Damage: 500, Hits: 20},
+		}},
+		{"m3", "2025-02-01", true, []model.PlayerWeaponStats{
+			{SteamID: 3, Weapon: "M4A1", Kills: 7, HeadshotKills: 2, Damage: 700, Hits: 25, DistanceKills: 7, KillDistanceSumM: 105},
+		}},
+	}
+	for _, d := range demos {
+		for i := range d.weapons {
+			d.weapons[i].DemoHash = d.hash
+		}
+		if err := db.ReplaceDemo(DemoData{
+			Summary:     model.MatchSummary{DemoHash: d.hash, MapName: "de_mirage", MatchDate: d.date, MatchType: "Pro", Tickrate: 64, IsBaseline: d.baseline},
+			WeaponStats: d.weapons,
+		}); err != nil {
+			t.Fatalf("ReplaceDemo %s: %v", d.hash, err)
+		}
+	}
+
+	stored, err := db.GetPlayerWeaponStats("m1")
+	if err != nil {
+		t.Fatalf("GetPlayerWeaponStats: %v", err)
+	}
+	if got := stored[0]; got.SteamID != 1 || got.DistanceKills != 8 || got.KillDistanceSumM != 120 || got.HeadHits != 11 {
+		t.Errorf("stored AK row = %+v, want distance kills 8, sum 120, head hits 11", got)
+	}
+
+	all, err := db.GetWeaponMeta(DemoFilter{})
+	if err != nil {
+		t.Fatalf("GetWeaponMeta: %v", err)
+	}
+	want := []model.WeaponMetaRow{
+		{Weapon: "AK-47", MatchDate: "2025-01-01", Demos: 2, Kills: 20, HeadshotKills: 9, Damage: 1700, Hits: 62, DistanceKills: 12, KillDistanceSumM: 160},
+		{Weapon: "AWP", MatchDate: "2025-01-01", Demos: 1, Kills: 3, Damage: 300, Hits: 3},
+		{Weapon: "M4A1", MatchDate: "2025-02-01", Demos: 1, Kills: 7, HeadshotKills: 2, Damage: 700, Hits: 25, DistanceKills: 7, KillDistanceSumM: 105},
+	}
+	if !reflect.DeepEqual(all, want) {
+		t.Errorf("GetWeaponMeta = %+v, want %+v", all, want)
+	}
+
+	baseline, err := db.GetWeaponMeta(DemoFilter{Baseline: true, Since: "2025-01-15"})
+	if err != nil {
+		t.Fatalf("GetWeaponMeta baseline: %v", err)
+	}
+	if len(baseline) != 1 || baseline[0].Weapon != "M4A1" {
+		t.Errorf("baseline since 2025-01-15 = %+v, want only M4A1", baseline)
+	}
+}
+
+// TestUnmappedWeapons: unmapped weapon diagnostics round-trip and are
+// replaced on re-parse.
+func TestUnmappedWeapons(t *testing.T) {
+	db := openMemDB(t)
+	summary := model.MatchSummary{DemoHash: "uw", MapName: "de_inferno", MatchDate: "2025-01-01", MatchType: "Scrim", Tickrate: 64}
+	rows := []model.UnmappedWeapon{
+		{DemoHash: "uw", Weapon: "CWeaponNewRifle", Events: 12},
+		{DemoHash: "uw", Weapon: "UNKNOWN", Events: 2},
+	}
+	if err := db.ReplaceDemo(DemoData{Summary: summary, Unmapped: rows}); err != nil {
+		t.Fatalf("ReplaceDemo: %v", err)
+	}
+	got, err := db.GetUnmappedWeapons("uw")
+	if err != nil {
+		t.Fatalf("GetUnmappedWeapons: %v", err)
+	}
+	if !reflect.DeepEqual(got, rows) {
+		t.Errorf("GetUnmappedWeapons = %+v, want %+v", got, rows)
+	}
+
+	// A re-parse with every weapon mapped clears the diagnostics.
+	if err := db.ReplaceDemo(DemoData{Summary: summary}); err != nil {
+		t.Fatalf("ReplaceDemo (re-parse): %v", err)
+	}
+	got, err = db.GetUnmappedWeapons("uw")
+	if err != nil {
+		t.Fatalf("GetUnmappedWeapons (re-parse): %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("GetUnmappedWeapons after re-parse = %+v, want none", got)
+	}
+}
+
+// TestDemoDiagnostics: event counts and pass timings round-trip, demos stored
+// without diagnostics have none, and timings alone never make a merge conflict.
+func TestDemoDiagnostics(t *testing.T) {
+	db := openMemDB(t)
+	summary := model.MatchSummary{DemoHash: "dg", MapName: "de_nuke", MatchDate: "2025-01-01", MatchType: "Scrim", Tickrate: 64}
+	diag := model.DemoDiagnostics{
+		DemoHash: "dg", Rounds: 24, Kills: 170, Damages: 900, Flashes: 80,
+		WeaponFires: 6000, FirstSights: 400, PositionSamples: 1200, PreLiveRounds: 1,
+		ServerFrameDropPct: 0.35,
+		Passes:             []model.PassTiming{{Pass: "parse", Ms: 2400.5}, {Pass: "spectators", Ms: 0.1}, {Pass: "trades", Ms: 3.25}},
+	}
+	if err := db.ReplaceDemo(DemoData{Summary: summary, Diagnostics: diag}); err != nil {
+		t.Fatalf("ReplaceDemo: %v", err)
+	}
+	got, err := db.GetDemoDiagnostics("dg")
+	if err != nil {
+		t.Fatalf("GetDemoDiagnostics: %v", err)
+	}
+	if got == nil || !reflect.DeepEqual(*got, diag) {
+		t.Errorf("GetDemoDiagnostics = %+v, want %+v", got, diag)
+	}
+
+	if err := db.ReplaceDemo(DemoData{Summary: model.MatchSummary{DemoHash: "old", MapName: "de_nuke", MatchDate: "2025-01-02", MatchType: "Scrim", Tickrate: 64}}); err != nil {
+		t.Fatalf("ReplaceDemo (no diagnostics): %v", err)
+	}
+	if got, err := db.GetDemoDiagnostics("old"); err != nil || got != nil {
+		t.Errorf("GetDemoDiagnostics(old) = %+v, %v; want nil", got, err)
+	}
+	list, err := db.ListDemoDiagnostics()
+	if err != nil {
+		t.Fatalf("ListDemoDiagnostics: %v", err)
+	}
+	if len(list) != 1 || list[0].DemoHash != "dg" || list[0].FirstSights != 400 || list[0].Passes != nil {
+		t.Errorf("ListDemoDiagnostics = %+v, want dg counts without passes", list)
+	}
+
+	// The same demo parsed on another machine: equal counts, other timings.
+	srcPath := filepath.Join(t.TempDir(), "other.db")
+	src, err := Open(srcPath)
+	if err != nil {
+		t.Fatalf("open source db: %v", err)
+	}
+	other := diag
+	other.Passes = []model.PassTiming{{Pass: "parse", Ms: 9000}}
+	if err := src.ReplaceDemo(DemoData{Summary: summary, Diagnostics: other}); err != nil {
+		t.Fatalf("ReplaceDemo (source): %v", err)
+	}
+	src.Close()
+	res, err := db.MergeFrom(srcPath)
+	if err != nil {
+		t.Fatalf("MergeFrom: %v", err)
+	}
+	if len(res.Conflicts) != 0 {
+		t.Errorf("Conflicts = %+v, want none for differing pass timings", res.Conflicts)
+	}
+}
+
+func TestGetRoundOutcomes(t *testing.T) {
+	db := openMemDB(t)
+	db.InsertDemo(model.MatchSummary{DemoHash: "ro", MapName: "de_anubis", MatchDate: "2025-01-01", MatchType: "Scrim", Tickrate: 64}, "")
+
+	rs := func(id uint64, round int, team model.Team, won bool, reason string) model.PlayerRoundStats {
+		return model.PlayerRoundStats{DemoHash: "ro", SteamID: id, RoundNumber: round, Team: team, WonRound: won, EndReason: reason}
+	}
+	if err := db.InsertPlayerRoundStats([]model.PlayerRoundStats{
+		rs(1, 1, model.TeamCT, false, model.EndReasonBomb),
+		rs(2, 1, model.TeamT, true, model.EndReasonBomb),
+		rs(3, 1, model.TeamT, true, model.EndReasonBomb),
+		rs(1, 2, model.TeamCT, true, model.EndReasonTime),
+		rs(2, 2, model.TeamT, false, model.EndReasonTime),
+		// Sides switch: player 1 (started CT) is now T; players 4 and 5
+		// were not on CT in round 1, so they do not count.
+		rs(1, 3, model.TeamT, true, model.EndReasonElimination),
+		rs(2, 3, model.TeamCT, false, model.EndReasonElimination),
+		rs(4, 3, model.TeamCT, false, model.EndReasonElimination),
+		rs(5, 3, model.TeamCT, false, model.EndReasonElimination),
+		// No started-CT player on the server: keeps the previous side.
+		rs(2, 4, model.TeamCT, true, model.EndReasonDefuse),
+	}); err != nil {
+		t.Fatalf("InsertPlayerRoundStats: %v", err)
+	}
+
+	got, err := db.GetRoundOutcomes("ro")
+	if err != nil {
+		t.Fatalf("GetRoundOutcomes: %v", err)
+	}
+	want := []model.RoundOutcome{
+		{RoundNumber: 1, WinnerTeam: model.TeamT, EndReason: model.EndReasonBomb, StartedCTSide: model.TeamCT},
+		{RoundNumber: 2, WinnerTeam: model.TeamCT, EndReason: model.EndReasonTime, StartedCTSide: model.TeamCT},
+		{RoundNumber: 3, WinnerTeam: model.TeamT, EndReason: model.EndReasonElimination, StartedCTSide: model.TeamT},
+		{RoundNumber: 4, WinnerTeam: model.TeamCT, EndReason: model.EndReasonDefuse, StartedCTSide: model.TeamT},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d outcomes, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("outcome %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	rounds, err := db.GetPlayerRoundStats("ro", 1)
+	if err != nil || len(rounds) != 3 || rounds[0].EndReason != model.EndReasonBomb {
+		t.Errorf("GetPlayerRoundStats end_reason round trip: %+v, %v", rounds, err)
+	}
+}
+
+func TestGetAllRoundStats(t *testing.T) {
+	db := openMemDB(t)
+	db.InsertDemo(model.MatchSummary{DemoHash: "ar", MapName: "de_nuke", MatchDate: "2025-01-01", MatchType: "Scrim", Tickrate: 64}, "")
+
+	if err := db.InsertPlayerRoundStats([]model.PlayerRoundStats{
+		{DemoHash: "ar", SteamID: 3, RoundNumber: 1, Team: model.TeamT, Kills: 2, GotKill: true, BuyType: "eco",
+			IsInClutch: true, ClutchEnemyCount: 2, ClutchStartTick: 1140, ClutchStartSec: 10, ClutchEnemies: []uint64{1, 76561198000000001}},
+		{DemoHash: "ar", SteamID: 1, RoundNumber: 1, Team: model.TeamCT, Survived: true, WonRound: true, BuyType: "full"},
+		{DemoHash: "ar", SteamID: 2, RoundNumber: 1, Team: model.TeamT, IsOpeningDeath: true, BuyType: "eco"},
+		{DemoHash: "ar", SteamID: 1, RoundNumber: 2, Team: model.TeamCT, EndReason: model.EndReasonDefuse, BuyType: "full"},
+		{DemoHash: "other", SteamID: 1, RoundNumber: 1, Team: model.TeamCT, BuyType: "full"},
+	}); err != nil {
+		t.Fatalf("InsertPlayerRoundStats: %v", err)
+	}
+
+	got, err := db.GetAllRoundStats("ar")
+	if err != nil {
+		t.Fatalf("GetAllRoundStats: %v", err)
+	}
+	if len(got) != 2 || len(got[1]) != 3 || len(got[2]) != 1 {
+		t.Fatalf("rounds = %v, want round 1 with 3 players and round 2 with 1", got)
+	}
+	// Round 1: CT first, then T by SteamID.
+	var order []uint64
+	for _, r := range got[1] {
+		order = append(order, r.SteamID)
+		if r.DemoHash != "ar" || r.RoundNumber != 1 {
+			t.Errorf("row %+v: wrong demo or round", r)
+		}
+	}
+	if order[0] != 1 || order[1] != 2 || order[2] != 3 {
+		t.Errorf("round 1 order = %v, want [1 2 3]", order)
+	}
+	if r := got[1][0]; !r.Survived || !r.WonRound || r.Team != model.TeamCT || r.BuyType != "full" {
+		t.Errorf("player 1 round 1 = %+v", r)
+	}
+	if r := got[1][1]; !r.IsOpeningDeath || r.Team != model.TeamT {
+		t.Errorf("player 2 round 1 = %+v", r)
+	}
+	if r := got[1][2]; r.Kills != 2 || !r.GotKill {
+		t.Errorf("player 3 round 1 = %+v", r)
+	}
+	if r := got[1][2]; r.ClutchStartTick != 1140 || r.ClutchStartSec != 10 ||
+		len(r.ClutchEnemies) != 2 || r.ClutchEnemies[0] != 1 || r.ClutchEnemies[1] != 76561198000000001 {
+		t.Errorf("player 3 clutch = tick %d, %vs, enemies %v", r.ClutchStartTick, r.ClutchStartSec, r.ClutchEnemies)
+	}
+	if r := got[1][0]; r.ClutchEnemies != nil {
+		t.Errorf("player 1 clutch enemies = %v, want nil", r.ClutchEnemies)
+	}
+	if got[2][0].EndReason != model.EndReasonDefuse {
+		t.Errorf("round 2 end reason = %q, want defuse", got[2][0].EndReason)
+	}
+
+	none, err := db.GetAllRoundStats("missing")
+	if err != nil || len(none) != 0 {
+		t.Errorf("GetAllRoundStats(missing) = %v, %v; want empty", none, err)
+	}
+}
+
+// TestMetricColumnsExist checks that every stored column the metric registry
+// cites ("table.column", or a bare table) exists in the schema.
+func TestMetricColumnsExist(t *testing.T) {
+	db := openMemDB(t)
+	for _, m := range aggregator.Metrics() {
+		for _, ref := range m.Columns {
+			table, column, hasColumn := strings.Cut(ref, ".")
+			var n int
+			var err error
+			if hasColumn {
+				err = db.conn.QueryRow(`SELECT COUNT(1) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&n)
+			} else {
+				err = db.conn.QueryRow(`SELECT COUNT(1) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&n)
+			}
+			if err != nil {
+				t.Fatalf("%s: look up %s: %v", m.Name, ref, err)
+			}
+			if n == 0 {
+				t.Errorf("%s: column %s not in the schema", m.Name, ref)
+			}
+		}
+	}
+}
+
+// concurrentDemo is a small demo with one player row, for the concurrency tests.
+func concurrentDemo(writer, i int) DemoData {
+	hash := fmt.Sprintf("w%d-d%d", writer, i)
+	return DemoData{
+		Summary: model.MatchSummary{DemoHash: hash, MapName: "de_inferno", MatchDate: "2025-03-01", MatchType: "Premier", Tickrate: 64},
+		MatchStats: []model.PlayerMatchStats{
+			{DemoHash: hash, SteamID: uint64(writer*1000 + i), Name: "p", Team: model.TeamCT, Kills: i},
+		},
+	}
+}
+
+// TestConcurrentReadWrite shares one file database between concurrent
+// ReplaceDemo writers and ListDemos/GetPlayerMatchStats readers, as the HTTP
+// server and background fetches would. No call may fail with SQLITE_BUSY.
+func TestConcurrentReadWrite(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "metrics.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+
+	const writers, perWriter, readers = 4, 10, 4
+	errs := make(chan error, writers*perWriter+readers*perWriter)
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				if err := db.ReplaceDemo(concurrentDemo(w, i)); err != nil {
+					errs <- fmt.Errorf("ReplaceDemo: %w", err)
+				}
+			}
+		}(w)
+	}
+	for r := 0; r < readers; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				if _, err := db.ListDemos(DemoFilter{}); err != nil {
+					errs <- fmt.Errorf("ListDemos: %w", err)
+				}
+				if _, err := db.GetPlayerMatchStats("w0-d0"); err != nil {
+					errs <- fmt.Errorf("GetPlayerMatchStats: %w", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	list, err := db.ListDemos(DemoFilter{})
+	if err != nil {
+		t.Fatalf("ListDemos: %v", err)
+	}
+	if len(list) != writers*perWriter {
+		t.Errorf("demos = %d, want %d", len(list), writers*perWriter)
+	}
+}
+
+// TestConcurrentHandlesSameFile writes through two separately opened handles
+// on the same file, standing in for two csmetrics processes: the busy timeout
+// must serialise them instead of failing.
+func TestConcurrentHandlesSameFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.db")
+	var dbs [2]*DB
+	for i := range dbs {
+		db, err := Open(path)
+		if err != nil {
+			t.Fatalf("Open #%d: %v", i, err)
+		}
+		defer db.Close()
+		dbs[i] = db
+	}
+
+	const perWriter = 10
+	errs := make(chan error, len(dbs)*perWriter)
+	var wg sync.WaitGroup
+	for w, db := range dbs {
+		wg.Add(1)
+		go func(w int, db *DB) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				if err := db.ReplaceDemo(concurrentDemo(w, i)); err != nil {
+					errs <- fmt.Errorf("handle %d ReplaceDemo: %w", w, err)
+				}
+				if err := db.UpdateDemoMeta(concurrentDemo(w, i).Summary.DemoHash, "", "FACEIT", "", "", false); err != nil {
+					errs <- fmt.Errorf("handle %d UpdateDemoMeta: %w", w, err)
+				}
+			}
+		}(w, db)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	list, err := dbs[1].ListDemos(DemoFilter{})
+	if err != nil {
+		t.Fatalf("ListDemos: %v", err)
+	}
+	if len(list) != len(dbs)*perWriter {
+		t.Errorf("demos = %d, want %d", len(list), len(dbs)*perWriter)
+	}
+	for _, d := range list {
+		if d.MatchType != "FACEIT" {
+			t.Errorf("%s match type = %q, want FACEIT", d.DemoHash, d.MatchType)
+		}
+	}
+}
+
+// TestDuelSegmentRoundContextMigration opens a database whose
+// player_duel_segments predates round contexts: old rows survive with an
+// empty context, and one bucket/bin can then hold a row per context.
+func TestDuelSegmentRoundContextMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.db")
+	old, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open raw db: %v", err)
+	}
+	if _, err := old.Exec(`
+		CREATE TABLE player_duel_segments (
+			demo_hash          TEXT NOT NULL,
+			steam_id           TEXT NOT NULL,
+			weapon_bucket      TEXT NOT NULL,
+			distance_bin       TEXT NOT NULL,
+			duel_count         INTEGER NOT NULL DEFAULT 0,
+			first_hit_count    INTEGER NOT NULL DEFAULT 0,
+			first_hit_hs_count INTEGER NOT NULL DEFAULT 0,
+			median_corr_deg    REAL    NOT NULL DEFAULT 0,
+			median_sight_deg   REAL    NOT NULL DEFAULT 0,
+			median_expo_win_ms REAL    NOT NULL DEFAULT 0,
+			UNIQUE(demo_hash, steam_id, weapon_bucket, distance_bin)
+		);
+		INSERT INTO player_duel_segments VALUES ('old', '7', 'AK', '10-15m', 5, 4, 2, 1.5, 3, 400);`); err != nil {
+		t.Fatalf("create old table: %v", err)
+	}
+	old.Close()
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+
+	segs, err := db.GetPlayerDuelSegments("old")
+	if err != nil {
+		t.Fatalf("GetPlayerDuelSegments: %v", err)
+	}
+	if len(segs) != 1 || segs[0].RoundContext != "" || segs[0].FirstHitHSCount != 2 || segs[0].MedianExpoWinMs != 400 {
+		t.Fatalf("migrated segments = %+v, want the old row with an empty context", segs)
+	}
+
+	var fresh []model.PlayerDuelSegment
+	for _, ctx := range []string{model.RoundContextPistol, model.RoundContextGun} {
+		fresh = append(fresh, model.PlayerDuelSegment{DemoHash: "new", SteamID: 7, RoundContext: ctx,
+			WeaponBucket: "Pistol", DistanceBin: "5-10m", DuelCount: 1, FirstHitCount: 1})
+	}
+	if err := db.InsertPlayerDuelSegments(fresh); err != nil {
+		t.Fatalf("InsertPlayerDuelSegments: %v", err)
+	}
+	all, err := db.GetAllPlayerDuelSegments(7)
+	if err != nil {
+		t.Fatalf("GetAllPlayerDuelSegments: %v", err)
+	}
+	got := make(map[string]bool)
+	for _, s := range all {
+		got[s.DemoHash+"/"+s.RoundContext] = true
+	}
+	if len(all) != 3 || !got["new/pistol"] || !got["new/gun"] || !got["old/"] {
+		t.Errorf("segments after migration = %v, want old/, new/pistol and new/gun", got)
+	}
+
+	// Reopening an already migrated database is a no-op.
+	db2, err := Open(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	db2.Close()
+}
+
+// TestBaselineQuotas: progress counts baseline demos of the tier, sources
+// are counted per tier, and only failed sources may be tried again.
+func TestBaselineQuotas(t *testing.T) {
+	db := openMemDB(t)
+	for _, s := range []model.MatchSummary{
+		{DemoHash: "b1", MapName: "de_mirage", MatchDate: "2025-01-01", MatchType: "FACEIT", Tier: "faceit-10", IsBaseline: true},
+		{DemoHash: "b2", MapName: "de_mirage", MatchDate: "2025-01-02", MatchType: "FACEIT", Tier: "faceit-10", IsBaseline: true},
+		{DemoHash: "b3", MapName: "de_mirage", MatchDate: "2025-01-03", MatchType: "FACEIT", Tier: "faceit-8", IsBaseline: true},
+		{DemoHash: "own", MapName: "de_mirage", MatchDate: "2025-01-04", MatchType: "FACEIT", Tier: "faceit-10"},
+	} {
+		if err := db.InsertDemo(s, ""); err != nil {
+			t.Fatalf("InsertDemo %s: %v", s.DemoHash, err)
+		}
+	}
+	if err := db.SetBaselineQuota("faceit-10", 5); err != nil {
+		t.Fatalf("SetBaselineQuota: %v", err)
+	}
+	if err := db.SetBaselineQuota("faceit-10", 3); err != nil {
+		t.Fatalf("SetBaselineQuota (update): %v", err)
+	}
+	if err := db.SetBaselineQuota("faceit-8", 1); err != nil {
+		t.Fatalf("SetBaselineQuota: %v", err)
+	}
+	for _, s := range []BaselineSource{
+		{SourceID: "faceit:a", Tier: "faceit-10", Status: BaselineStored, DemoHash: "b1"},
+		{SourceID: "faceit:b", Tier: "faceit-10", Status: BaselineSkipped, Detail: "level 9"},
+		{SourceID: "faceit:c", Tier: "faceit-10", Status: BaselineFailed, Detail: "download: 404"},
+	} {
+		if err := db.RecordBaselineSource(s); err != nil {
+			t.Fatalf("RecordBaselineSource %s: %v", s.SourceID, err)
+		}
+	}
+
+	got, err := db.GetBaselineQuotas("faceit-10")
+	if err != nil {
+		t.Fatalf("GetBaselineQuotas: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("GetBaselineQuotas(faceit-10) returned %d rows, want 1", len(got))
+	}
+	q := got[0]
+	if q.Target != 3 || q.Stored != 2 || q.Tried != 3 || q.Failed != 1 || q.Remaining() != 1 {
+		t.Errorf("faceit-10 quota = %+v (remaining %d), want target 3, stored 2, tried 3, failed 1, remaining 1", q, q.Remaining())
+	}
+	all, err := db.GetBaselineQuotas("")
+	if err != nil {
+		t.Fatalf("GetBaselineQuotas(all): %v", err)
+	}
+	if len(all) != 2 || all[1].Tier != "faceit-8" || all[1].Remaining() != 0 {
+		t.Errorf("GetBaselineQuotas(all) = %+v, want faceit-10 then a complete faceit-8", all)
+	}
+
+	for id, want := range map[string]bool{"faceit:a": true, "faceit:b": true, "faceit:c": false, "faceit:new": false} {
+		done, err := db.BaselineSourceDone(id)
+		if err != nil {
+			t.Fatalf("BaselineSourceDone %s: %v", id, err)
+		}
+		if done != want {
+			t.Errorf("BaselineSourceDone(%s) = %v, want %v", id, done, want)
+		}
+	}
+
+	// A retried failure that succeeds replaces the earlier attempt.
+	if err := db.RecordBaselineSource(BaselineSource{SourceID: "faceit:c", Tier: "faceit-10", Status: BaselineStored}); err != nil {
+		t.Fatalf("RecordBaselineSource (retry): %v", err)
+	}
+	if done, _ := db.BaselineSourceDone("faceit:c"); !done {
+		t.Error("BaselineSourceDone(faceit:c) after a stored retry = false, want true")
+	}
+}
+
+func TestDeleteRestoreDemo(t *testing.T) {
+	db := openMemDB(t)
+	d := DemoData{
+		Summary: model.MatchSummary{DemoHash: "del1", MapName: "de_nuke", MatchDate: "2025-02-01", MatchType: "Pug", Tickrate: 64, CTScore: 13, Tier: "faceit", PipelineVersion: 3},
+		MatchStats: []model.PlayerMatchStats{
+			{DemoHash: "del1", SteamID: 1, Name: "a", Kills: 21, CrosshairMedianDeg: 4.25, PipelineVersion: 3},
+		},
+		RoundStats: []model.PlayerRoundStats{{DemoHash: "del1", SteamID: 1, RoundNumber: 4, Team: model.TeamCT}},
+	}
+	if err := db.ReplaceDemo(d); err != nil {
+		t.Fatalf("ReplaceDemo: %v", err)
+	}
+
+	trashed, err := db.DeleteDemo("del1")
+	if err != nil {
+		t.Fatalf("DeleteDemo: %v", err)
+	}
+	if trashed.Rows != 2 || trashed.MapName != "Nuke" || trashed.PipelineVersion != 3 {
+		t.Errorf("DeleteDemo = %+v, want 2 rows of Nuke at v3", trashed)
+	}
+	if demo, _ := db.GetDemoByPrefix("del1"); demo != nil {
+		t.Fatal("deleted demo still listed")
+	}
+	if stats, _ := db.GetPlayerMatchStats("del1"); len(stats) != 0 {
+		t.Errorf("deleted demo still has %d stats rows", len(stats))
+	}
+	if list, err := db.ListTrash(); err != nil || len(list) != 1 || list[0].DemoHash != "del1" {
+		t.Errorf("ListTrash = %+v, %v; want del1", list, err)
+	}
+	if _, err := db.DeleteDemo("del1"); err == nil {
+		t.Error("DeleteDemo of a trashed demo succeeded, want error")
+	}
+
+	if _, err := db.RestoreDemo("del1"); err != nil {
+		t.Fatalf("RestoreDemo: %v", err)
+	}
+	demo, err := db.GetDemoByPrefix("del1")
+	if err != nil || demo == nil || demo.CTScore != 13 || demo.Tier != "faceit" || demo.PipelineVersion != 3 {
+		t.Errorf("restored demo = %+v, %v", demo, err)
+	}
+	stats, err := db.GetPlayerMatchStats("del1")
+	if err != nil || len(stats) != 1 || stats[0].Kills != 21 || stats[0].CrosshairMedianDeg != 4.25 {
+		t.Errorf("restored stats = %+v, %v; want 21 kills at 4.25°", stats, err)
+	}
+	rounds, err := db.GetPlayerRoundStats("del1", 1)
+	if err != nil || len(rounds) != 1 || rounds[0].RoundNumber != 4 || rounds[0].Team != model.TeamCT {
+		t.Errorf("restored rounds = %+v, %v", rounds, err)
+	}
+	if list, _ := db.ListTrash(); len(list) != 0 {
+		t.Errorf("trash after restore = %+v, want empty", list)
+	}
+
+	// A copy stored again after the delete blocks the restore.
+	if _, err := db.DeleteDemo("del1"); err != nil {
+		t.Fatalf("DeleteDemo (again): %v", err)
+	}
+	if err := db.ReplaceDemo(d); err != nil {
+		t.Fatalf("ReplaceDemo (re-parse): %v", err)
+	}
+	if _, err := db.RestoreDemo("del1"); err == nil {
+		t.Error("RestoreDemo over a stored copy succeeded, want error")
+	}
+
+	purged, err := db.PurgeTrash(time.Now().Add(-time.Hour))
+	if err != nil || len(purged) != 0 {
+		t.Errorf("PurgeTrash(an hour ago) = %+v, %v; want nothing purged", purged, err)
+	}
+	purged, err = db.PurgeTrash(time.Time{})
+	if err != nil || len(purged) != 1 {
+		t.Errorf("PurgeTrash(all) = %+v, %v; want 1 purged", purged, err)
+	}
+	if tr, _ := db.GetTrashedByPrefix("del"); tr != nil {
+		t.Errorf("trash after purge still holds %+v", tr)
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	db := openMemDB(t)
+	s := model.MatchSummary{DemoHash: "aud1", MapName: "de_anubis", MatchDate: "2025-03-01", MatchType: "Pug", Tickrate: 64, PipelineVersion: 1}
+	if err := db.ReplaceDemo(DemoData{Summary: s}); err != nil {
+		t.Fatalf("ReplaceDemo: %v", err)
+	}
+	s.PipelineVersion = 2
+	if err := db.ReplaceDemo(DemoData{Summary: s}); err != nil {
+		t.Fatalf("ReplaceDemo (replace): %v", err)
+	}
+	if err := db.UpdateDemoMeta("aud1", "", "Pug", "faceit", "", true); err != nil {
+		t.Fatalf("UpdateDemoMeta: %v", err)
+	}
+	if err := db.UpdateDemoMeta("aud1", "", "Pug", "faceit", "", true); err != nil {
+		t.Fatalf("UpdateDemoMeta (no change): %v", err)
+	}
+	if _, err := db.DeleteDemo("aud1"); err != nil {
+		t.Fatalf("DeleteDemo: %v", err)
+	}
+	if _, err := db.RestoreDemo("aud1"); err != nil {
+		t.Fatalf("RestoreDemo: %v", err)
+	}
+	if err := db.ReplaceDemo(DemoData{Summary: model.MatchSummary{DemoHash: "other", MapName: "de_anubis", MatchDate: "2025-03-02"}}); err != nil {
+		t.Fatalf("ReplaceDemo (other): %v", err)
+	}
+
+	log, err := db.GetAuditLog("aud", 0)
+	if err != nil {
+		t.Fatalf("GetAuditLog: %v", err)
+	}
+	want := []string{AuditRestore, AuditDelete, AuditRetag, AuditReplace, AuditInsert}
+	if len(log) != len(want) {
+		t.Fatalf("got %d audit entries %+v, want %v", len(log), log, want)
+	}
+	for i, e := range log {
+		if e.Action != want[i] || e.DemoHash != "aud1" {
+			t.Errorf("entry %d = %s %s, want %s aud1", i, e.Action, e.DemoHash, want[i])
+		}
+	}
+	if log[3].PipelineVersion != 2 || log[3].Detail != "was v1" {
+		t.Errorf("replace entry = %+v, want v2 \"was v1\"", log[3])
+	}
+	if log[2].Detail != `tier "" → "faceit", baseline false → true` {
+		t.Errorf("retag detail = %q", log[2].Detail)
+	}
+	if last, _ := db.GetAuditLog("", 1); len(last) != 1 || last[0].DemoHash != "other" {
+		t.Errorf("GetAuditLog(limit 1) = %+v, want the latest insert of other", last)
+	}
+}
+
+func TestAggregateCache(t *testing.T) {
+	db := openMemDB(t)
+	d := DemoData{
+		Summary:    model.MatchSummary{DemoHash: "c1", MapName: "de_nuke", MatchDate: "2025-02-01", PipelineVersion: 5},
+		MatchStats: []model.PlayerMatchStats{{DemoHash: "c1", SteamID: 1, Name: "a", PipelineVersion: 5}},
+	}
+	if err := db.ReplaceDemo(d); err != nil {
+		t.Fatalf("ReplaceDemo: %v", err)
+	}
+	for _, id := range []uint64{1, 2} {
+		if err := db.PutAggregateCache(id, "f", 5, []byte(`{"kills":21}`)); err != nil {
+			t.Fatalf("PutAggregateCache(%d): %v", id, err)
+		}
+	}
+	if got, ok, err := db.GetAggregateCache(1, "f", 5); err != nil || !ok || string(got) != `{"kills":21}` {
+		t.Errorf("GetAggregateCache = %q, %v, %v; want the stored payload", got, ok, err)
+	}
+	if _, ok, _ := db.GetAggregateCache(1, "f", 6); ok {
+		t.Error("hit for another pipeline version")
+	}
+	if _, ok, _ := db.GetAggregateCache(1, "g", 5); ok {
+		t.Error("hit for other filters")
+	}
+
+	// A newer version replaces the entry for the same filters.
+	if err := db.PutAggregateCache(2, "f", 6, []byte(`{}`)); err != nil {
+		t.Fatalf("PutAggregateCache(v6): %v", err)
+	}
+	if _, ok, _ := db.GetAggregateCache(2, "f", 5); ok {
+		t.Error("v5 entry kept after a v6 entry was cached")
+	}
+
+	// Storing a demo with player 1 drops their entries only.
+	d.Summary.DemoHash, d.MatchStats[0].DemoHash = "c2", "c2"
+	if err := db.ReplaceDemo(d); err != nil {
+		t.Fatalf("ReplaceDemo(c2): %v", err)
+	}
+	if _, ok, _ := db.GetAggregateCache(1, "f", 5); ok {
+		t.Error("player 1 entry survived an insert of their stats")
+	}
+	if _, ok, _ := db.GetAggregateCache(2, "f", 6); !ok {
+		t.Error("player 2 entry dropped by an insert of player 1's stats")
+	}
+
+	// So does deleting one of their demos.
+	if err := db.PutAggregateCache(1, "f", 5, []byte(`{}`)); err != nil {
+		t.Fatalf("PutAggregateCache: %v", err)
+	}
+	if _, err := db.DeleteDemo("c1"); err != nil {
+		t.Fatalf("DeleteDemo: %v", err)
+	}
+	if _, ok, _ := db.GetAggregateCache(1, "f", 5); ok {
+		t.Error("player 1 entry survived a delete of their demo")
+	}
+
+	if n, err := db.ClearAggregateCache(); err != nil || n != 1 {
+		t.Errorf("ClearAggregateCache = %d, %v; want 1", n, err)
+	}
+}
@@ -0,0 +1,326 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+
+	"github.com/pable/go-cs-metrics/internal/model"
+)
+
+// Audit actions recorded in demo_audit.
+const (
+	AuditInsert  = "insert"  // demo stored for the first time
+	AuditReplace = "replace" // demo re-parsed over an existing copy
+	AuditRetag   = "retag"   // match type, tier, event or baseline flag changed
+	AuditMerge   = "merge"   // demo copied in by "db merge"
+	AuditDelete  = "delete"  // demo moved to the trash
+	AuditRestore = "restore" // demo restored from the trash
+	AuditPurge   = "purge"   // trashed demo dropped for good
+)
+
+// currentActor names the OS user running this process, recorded with every
+// audit entry so writes to a shared database can be attributed.
+func currentActor() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// audit appends one entry to demo_audit within tx.
+func (db *DB) audit(tx *sql.Tx, action, hash string, version int, detail string) error {
+	_, err := tx.Exec(`
+		INSERT INTO demo_audit(at, action, demo_hash, pipeline_version, actor, detail)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		time.Now().UTC().Format(time.RFC3339), action, hash, version, db.actor, detail)
+	if err != nil {
+		return fmt.Errorf("audit %s: %w", action, err)
+	}
+	return nil
+}
+
+// GetAuditLog returns the most recent audit entries, newest first. A non-empty
+// hashPrefix limits the log to matching demos; limit <= 0 returns every entry.
+func (db *DB) GetAuditLog(hashPrefix string, limit int) ([]model.AuditEntry, error) {
+	if limit <= 0 {
+		limit = -1 // SQLite: no limit
+	}
+	rows, err := db.conn.Query(`
+		SELECT id, at, action, demo_hash, pipeline_version, actor, detail
+		FROM demo_audit
+		WHERE demo_hash LIKE ?
+		ORDER BY id DESC
+		LIMIT ?`, hashPrefix+"%", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []model.AuditEntry
+	for rows.Next() {
+		var e model.AuditEntry
+		if err := rows.Scan(&e.ID, &e.At, &e.Action, &e.DemoHash, &e.PipelineVersion, &e.Actor, &e.Detail); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// DeleteDemo soft-deletes a stored demo: its demos row and every child stats
+// row are saved to demo_trash as one JSON snapshot and then removed from the
+// live tables, so every report stops seeing the demo at once. A demo already
+// in the trash under the same hash is overwritten by the newer snapshot.
+func (db *DB) DeleteDemo(hash string) (model.TrashedDemo, error) {
+	t := model.TrashedDemo{DemoHash: hash, DeletedBy: db.actor}
+	err := db.withTx(func(tx *sql.Tx) error {
+		demo, err := snapshotRows(tx, "demos", "hash", hash)
+		if err != nil {
+			return err
+		}
+		if len(demo) == 0 {
+			return fmt.Errorf("demo %s is not stored", hash)
+		}
+		snap := map[string][]map[string]interface{}{"demos": demo}
+		for _, table := range childTables {
+			rows, err := snapshotRows(tx, table, "demo_hash", hash)
+			if err != nil {
+				return err
+			}
+			if len(rows) > 0 {
+				snap[table] = rows
+				t.Rows += len(rows)
+			}
+		}
+		t.MapName, _ = demo[0]["map_name"].(string)
+		t.MatchDate, _ = demo[0]["match_date"].(string)
+		if v, ok := demo[0]["pipeline_version"].(int64); ok {
+			t.PipelineVersion = int(v)
+		}
+		blob, err := json.Marshal(snap)
+		if err != nil {
+			return fmt.Errorf("encode snapshot: %w", err)
+		}
+		t.DeletedAt = time.Now().UTC().Format(time.RFC3339)
+		if _, err := tx.Exec(`
+			INSERT OR REPLACE INTO demo_trash(hash, map_name, match_date, pipeline_version, row_count, deleted_at, deleted_by, snapshot)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			hash, t.MapName, t.MatchDate, t.PipelineVersion, t.Rows, t.DeletedAt, t.DeletedBy, string(blob)); err != nil {
+			return fmt.Errorf("trash demo: %w", err)
+		}
+		for _, table := range childTables {
+			if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE demo_hash = ?", table), hash); err != nil {
+				return fmt.Errorf("delete %s: %w", table, err)
+			}
+		}
+		if _, err := tx.Exec("DELETE FROM demos WHERE hash = ?", hash); err != nil {
+			return fmt.Errorf("delete demo: %w", err)
+		}
+		return db.audit(tx, AuditDelete, hash, t.PipelineVersion, fmt.Sprintf("%d stats rows to trash", t.Rows))
+	})
+	return t, err
+}
+
+// RestoreDemo moves a trashed demo back into the live tables. Only columns
+// that still exist are restored; columns added since the deletion take their
+// schema defaults. Restoring fails if the hash has been stored again (re-parsed
+// or merged) in the meantime, so a newer copy is never overwritten.
+func (db *DB) RestoreDemo(hash string) (model.TrashedDemo, error) {
+	var t model.TrashedDemo
+	err := db.withTx(func(tx *sql.Tx) error {
+		var blob string
+		err := tx.QueryRow(`
+			SELECT hash, map_name, match_date, pipeline_version, row_count, deleted_at, deleted_by, snapshot
+			FROM demo_trash WHERE hash = ?`, hash).
+			Scan(&t.DemoHash, &t.MapName, &t.MatchDate, &t.PipelineVersion, &t.Rows, &t.DeletedAt, &t.DeletedBy, &blob)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("demo %s is not in the trash", hash)
+		}
+		if err != nil {
+			return err
+		}
+		var stored int
+		if err := tx.QueryRow("SELECT COUNT(1) FROM demos WHERE hash = ?", hash).Scan(&stored); err != nil {
+			return err
+		}
+		if stored > 0 {
+			return fmt.Errorf("demo %s is stored again; delete that copy before restoring", hash)
+		}
+
+		dec := json.NewDecoder(strings.NewReader(blob))
+		dec.UseNumber()
+		var snap map[string][]map[string]interface{}
+		if err := dec.Decode(&snap); err != nil {
+			return fmt.Errorf("decode snapshot: %w", err)
+		}
+		for _, table := range append([]string{"demos"}, childTables...) {
+			if err := restoreRows(tx, table, snap[table]); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Exec("DELETE FROM demo_trash WHERE hash = ?", hash); err != nil {
+			return fmt.Errorf("clear trash: %w", err)
+		}
+		return db.audit(tx, AuditRestore, hash, t.PipelineVersion,
+			fmt.Sprintf("%d stats rows, deleted %s by %s", t.Rows, t.DeletedAt, t.DeletedBy))
+	})
+	return t, err
+}
+
+// ListTrash returns every trashed demo, most recently deleted first.
+func (db *DB) ListTrash() ([]model.TrashedDemo, error) {
+	rows, err := db.conn.Query(`
+		SELECT hash, map_name, match_date, pipeline_version, row_count, deleted_at, deleted_by
+		FROM demo_trash ORDER BY deleted_at DESC, hash`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []model.TrashedDemo
+	for rows.Next() {
+		var t model.TrashedDemo
+		if err := rows.Scan(&t.DemoHash, &t.MapName, &t.MatchDate, &t.PipelineVersion, &t.Rows, &t.DeletedAt, &t.DeletedBy); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// GetTrashedByPrefix returns the trashed demo whose hash starts with prefix,
+// or nil if none matches.
+func (db *DB) GetTrashedByPrefix(prefix string) (*model.TrashedDemo, error) {
+	var t model.TrashedDemo
+	err := db.conn.QueryRow(`
+		SELECT hash, map_name, match_date, pipeline_version, row_count, deleted_at, deleted_by
+		FROM demo_trash WHERE hash LIKE ? LIMIT 1`, prefix+"%").
+		Scan(&t.DemoHash, &t.MapName, &t.MatchDate, &t.PipelineVersion, &t.Rows, &t.DeletedAt, &t.DeletedBy)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// PurgeTrash permanently drops trashed demos deleted before cutoff; a zero
+// cutoff empties the trash. Returns the purged demos.
+func (db *DB) PurgeTrash(cutoff time.Time) ([]model.TrashedDemo, error) {
+	all, err := db.ListTrash()
+	if err != nil {
+		return nil, err
+	}
+	var purged []model.TrashedDemo
+	err = db.withTx(func(tx *sql.Tx) error {
+		for _, t := range all {
+			if !cutoff.IsZero() {
+				at, err := time.Parse(time.RFC3339, t.DeletedAt)
+				if err == nil && !at.Before(cutoff) {
+					continue
+				}
+			}
+			if _, err := tx.Exec("DELETE FROM demo_trash WHERE hash = ?", t.DemoHash); err != nil {
+				return fmt.Errorf("purge %s: %w", t.DemoHash, err)
+			}
+			if err := db.audit(tx, AuditPurge, t.DemoHash, t.PipelineVersion, "deleted "+t.DeletedAt); err != nil {
+				return err
+			}
+			purged = append(purged, t)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return purged, nil
+}
+
+// snapshotRows returns the rows of table whose keyCol equals hash as
+// column-to-value maps. TEXT and BLOB values are returned as strings.
+func snapshotRows(tx *sql.Tx, table, keyCol, hash string) ([]map[string]interface{}, error) {
+	rows, err := tx.Query(fmt.Sprintf("SELECT * FROM %s WHERE %s = ?", table, keyCol), hash)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot %s: %w", table, err)
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var out []map[string]interface{}
+	for rows.Next() {
+		vals := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]interface{}, len(cols))
+		for i, c := range cols {
+			if b, ok := vals[i].([]byte); ok {
+				vals[i] = string(b)
+			}
+			row[c] = vals[i]
+		}
+		out = append(out, row)
+	}
+	return out, rows.Err()
+}
+
+// restoreRows inserts snapshot rows into table, skipping columns the table no
+// longer has. JSON numbers are restored as integers where they fit, otherwise
+// as floats; SQLite's column affinity takes care of the rest.
+func restoreRows(tx *sql.Tx, table string, rows []map[string]interface{}) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	live := make(map[string]bool)
+	info, err := tx.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return fmt.Errorf("table info %s: %w", table, err)
+	}
+	for info.Next() {
+		var name string
+		if err := info.Scan(&name); err != nil {
+			info.Close()
+			return err
+		}
+		live[name] = true
+	}
+	info.Close()
+	if err := info.Err(); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		var cols []string
+		var args []interface{}
+		for c, v := range row {
+			if !live[c] {
+				continue
+			}
+			if n, ok := v.(json.Number); ok {
+				if i, err := n.Int64(); err == nil {
+					v = i
+				} else if f, err := n.Float64(); err == nil {
+					v = f
+				}
+			}
+			cols = append(cols, c)
+			args = append(args, v)
+		}
+		if _, err := tx.Exec(fmt.Sprintf("INSERT INTO %s(%s) VALUES (%s)",
+			table, strings.Join(cols, ", "), placeholders(len(cols))), args...); err != nil {
+			return fmt.Errorf("restore %s: %w", table, err)
+		}
+	}
+	return nil
+}